@@ -0,0 +1,226 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// maxModuleSize is the maximum size of the modules and patches sent to the
+// admin API.
+const maxModuleSize = 1 << 20
+
+// requireToken serves the requests having the token as bearer token with h,
+// and rejects the others.
+func requireToken(token string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(auth, []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// adminModulesHandler serves the admin API managing modules at runtime:
+// GET lists the runtime modules, and PUT, PATCH and DELETE of
+// <prefix>/<name> set, patch and remove a module. Requests must have the
+// token as bearer token.
+func adminModulesHandler(sc *config.SafeConfig, prefix, token string, logger *slog.Logger) http.Handler {
+	return requireToken(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if name == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				http.Error(w, "This endpoint requires a GET request.", http.StatusMethodNotAllowed)
+				return
+			}
+			modules, err := sc.RuntimeModules()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(modules)
+			return
+		}
+		if strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		var (
+			err     error
+			created bool
+		)
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch:
+			var body []byte
+			body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxModuleSize))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if r.Method == http.MethodPut {
+				var replaced bool
+				replaced, err = sc.SetModule(name, body)
+				created = !replaced
+			} else {
+				err = sc.PatchModule(name, body)
+			}
+		case http.MethodDelete:
+			err = sc.DeleteModule(name)
+		default:
+			w.Header().Set("Allow", "PUT, PATCH, DELETE")
+			http.Error(w, "This endpoint requires a PUT, PATCH or DELETE request.", http.StatusMethodNotAllowed)
+			return
+		}
+		switch {
+		case errors.Is(err, config.ErrModuleNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, config.ErrModuleInConfigFile):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			logger.Info("Changed runtime module", "module", name, "method", r.Method)
+			if created {
+				w.WriteHeader(http.StatusCreated)
+			} else {
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}
+	}))
+}
+
+// runtimeSettings are the settings of the Go runtime changed by the runtime
+// tuning endpoint. Settings that are not set are left unchanged.
+type runtimeSettings struct {
+	GOMAXPROCS           *int   `json:"gomaxprocs,omitempty"`
+	GCPercent            *int   `json:"gc_percent,omitempty"`
+	MemoryLimit          *int64 `json:"memory_limit,omitempty"`
+	MutexProfileFraction *int   `json:"mutex_profile_fraction,omitempty"`
+	BlockProfileRate     *int   `json:"block_profile_rate,omitempty"`
+}
+
+var (
+	runtimeSettingsMu sync.Mutex
+	// The runtime has no getter for the block profile rate.
+	blockProfileRate int
+)
+
+func currentRuntimeSettings() runtimeSettings {
+	sample := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(sample)
+	gcPercent := int(sample[0].Value.Uint64())
+	// A negative limit leaves it unchanged.
+	memoryLimit := debug.SetMemoryLimit(-1)
+	gomaxprocs := runtime.GOMAXPROCS(0)
+	mutexProfileFraction := runtime.SetMutexProfileFraction(-1)
+	return runtimeSettings{
+		GOMAXPROCS:           &gomaxprocs,
+		GCPercent:            &gcPercent,
+		MemoryLimit:          &memoryLimit,
+		MutexProfileFraction: &mutexProfileFraction,
+		BlockProfileRate:     &blockProfileRate,
+	}
+}
+
+func (s runtimeSettings) validate() error {
+	if s.GOMAXPROCS != nil && *s.GOMAXPROCS < 1 {
+		return fmt.Errorf("gomaxprocs %d must be at least 1", *s.GOMAXPROCS)
+	}
+	if s.MemoryLimit != nil && *s.MemoryLimit < 0 {
+		return fmt.Errorf("memory_limit %d must not be negative", *s.MemoryLimit)
+	}
+	if s.MutexProfileFraction != nil && *s.MutexProfileFraction < 0 {
+		return fmt.Errorf("mutex_profile_fraction %d must not be negative", *s.MutexProfileFraction)
+	}
+	if s.BlockProfileRate != nil && *s.BlockProfileRate < 0 {
+		return fmt.Errorf("block_profile_rate %d must not be negative", *s.BlockProfileRate)
+	}
+	return nil
+}
+
+func (s runtimeSettings) apply() {
+	if s.GOMAXPROCS != nil {
+		runtime.GOMAXPROCS(*s.GOMAXPROCS)
+	}
+	if s.GCPercent != nil {
+		debug.SetGCPercent(*s.GCPercent)
+	}
+	if s.MemoryLimit != nil {
+		debug.SetMemoryLimit(*s.MemoryLimit)
+	}
+	if s.MutexProfileFraction != nil {
+		runtime.SetMutexProfileFraction(*s.MutexProfileFraction)
+	}
+	if s.BlockProfileRate != nil {
+		runtime.SetBlockProfileRate(*s.BlockProfileRate)
+		blockProfileRate = *s.BlockProfileRate
+	}
+}
+
+// adminRuntimeHandler serves the settings of the Go runtime, such as
+// GOMAXPROCS and the GC target, as JSON on GET, and changes those sent on PUT,
+// so that they can be tuned and the mutex and block profiles enabled without
+// a restart.
+func adminRuntimeHandler(logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runtimeSettingsMu.Lock()
+		defer runtimeSettingsMu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var settings runtimeSettings
+			dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&settings); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := settings.validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			settings.apply()
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "This endpoint requires a GET or PUT request.", http.StatusMethodNotAllowed)
+			return
+		}
+		settings := currentRuntimeSettings()
+		if r.Method == http.MethodPut {
+			logger.Info("Changed runtime settings", "gomaxprocs", *settings.GOMAXPROCS, "gc_percent", *settings.GCPercent,
+				"memory_limit", *settings.MemoryLimit, "mutex_profile_fraction", *settings.MutexProfileFraction, "block_profile_rate", *settings.BlockProfileRate)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	})
+}
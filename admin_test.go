@@ -0,0 +1,100 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestAdminModulesHandler(t *testing.T) {
+	sc := config.NewSafeConfig(prometheus.NewRegistry())
+	if err := sc.ReloadConfig("blackbox.yml", nil); err != nil {
+		t.Fatal(err)
+	}
+	handler := adminModulesHandler(sc, "/api/v1/modules", "secret", promslog.NewNopLogger())
+
+	tests := []struct {
+		method, path, token, body string
+		status                    int
+	}{
+		{http.MethodPut, "/api/v1/modules/tcp_office", "wrong", "prober: tcp", http.StatusUnauthorized},
+		{http.MethodPut, "/api/v1/modules/tcp_office", "secret", "prober: tcp\ntimeout: 5s", http.StatusCreated},
+		{http.MethodPut, "/api/v1/modules/tcp_office", "secret", "prober: tcp\ntimeout: 3s", http.StatusNoContent},
+		{http.MethodPatch, "/api/v1/modules/tcp_office", "secret", "tcp:\n  preferred_ip_protocol: ip4", http.StatusNoContent},
+		{http.MethodPatch, "/api/v1/modules/tcp_office", "secret", "tcp:\n  unknown: true", http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/modules/http_2xx", "secret", "prober: http", http.StatusConflict},
+		{http.MethodGet, "/api/v1/modules/tcp_office", "secret", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/modules", "secret", "", http.StatusOK},
+		{http.MethodDelete, "/api/v1/modules/tcp_office", "secret", "", http.StatusNoContent},
+		{http.MethodDelete, "/api/v1/modules/tcp_office", "secret", "", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != test.status {
+			t.Errorf("Expected status %d for %s %s %q, got %d: %s", test.status, test.method, test.path, test.body, rr.Code, rr.Body.String())
+		}
+		if test.method == http.MethodPatch && rr.Code == http.StatusNoContent {
+			if module := sc.C.Modules["tcp_office"]; module.TCP.IPProtocol != "ip4" || module.Timeout.Seconds() != 3 {
+				t.Errorf("Unexpected patched module %+v", module)
+			}
+		}
+		if test.method == http.MethodGet && rr.Code == http.StatusOK && !strings.Contains(rr.Body.String(), "tcp_office") {
+			t.Errorf("Expected the runtime modules to be listed, got %q", rr.Body.String())
+		}
+	}
+	if _, ok := sc.C.Modules["tcp_office"]; ok {
+		t.Error("Expected the module to be removed")
+	}
+}
+
+func TestAdminRuntimeHandler(t *testing.T) {
+	handler := adminRuntimeHandler(promslog.NewNopLogger())
+	previous := currentRuntimeSettings()
+	defer previous.apply()
+
+	tests := []struct {
+		method, body string
+		status       int
+	}{
+		{http.MethodPut, `{"gomaxprocs": 1, "gc_percent": 50, "block_profile_rate": 1000}`, http.StatusOK},
+		{http.MethodPut, `{"gomaxprocs": 0}`, http.StatusBadRequest},
+		{http.MethodPut, `{"gc": 50}`, http.StatusBadRequest},
+		{http.MethodPost, `{}`, http.StatusMethodNotAllowed},
+		{http.MethodGet, "", http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/api/v1/admin/runtime", strings.NewReader(test.body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != test.status {
+			t.Errorf("Expected status %d for %s %q, got %d: %s", test.status, test.method, test.body, rr.Code, rr.Body.String())
+		}
+	}
+
+	settings := currentRuntimeSettings()
+	if *settings.GOMAXPROCS != 1 || *settings.GCPercent != 50 || *settings.BlockProfileRate != 1000 {
+		t.Errorf("Unexpected runtime settings %d, %d and %d", *settings.GOMAXPROCS, *settings.GCPercent, *settings.BlockProfileRate)
+	}
+}
@@ -14,14 +14,26 @@
 package config
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"math"
+	"net"
+	"net/http"
 	"net/textproto"
+	"net/url"
 	"os"
+	"reflect"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -35,6 +47,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/http/httpguts"
 )
 
 var (
@@ -55,17 +69,132 @@ var (
 	// DefaultGRPCProbe set default value for HTTPProbe
 	DefaultGRPCProbe = GRPCProbe{
 		Service:            "",
+		Protocol:           "grpc",
 		IPProtocolFallback: true,
 	}
 
+	// DefaultProxyProbe set default value for ProxyProbe
+	DefaultProxyProbe = ProxyProbe{
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+
+	// DefaultPortScanProbe set default value for PortScanProbe
+	DefaultPortScanProbe = PortScanProbe{
+		IPProtocolFallback: true,
+		ConnectTimeout:     time.Second,
+		Concurrency:        16,
+	}
+
+	// DefaultRoughtimeProbe set default value for RoughtimeProbe
+	DefaultRoughtimeProbe = RoughtimeProbe{
+		IPProtocolFallback: true,
+	}
+
+	// DefaultSNMPProbe set default value for SNMPProbe
+	DefaultSNMPProbe = SNMPProbe{
+		IPProtocolFallback: true,
+		Version:            2,
+		Community:          "public",
+		OID:                "1.3.6.1.2.1.1.3.0",
+		SecurityLevel:      "noAuthNoPriv",
+		AuthProtocol:       "SHA",
+		PrivProtocol:       "AES",
+	}
+
+	// DefaultBGPProbe set default value for BGPProbe
+	DefaultBGPProbe = BGPProbe{
+		IPProtocolFallback: true,
+		HoldTime:           90 * time.Second,
+	}
+
+	// DefaultNFSProbe set default value for NFSProbe
+	DefaultNFSProbe = NFSProbe{
+		IPProtocolFallback: true,
+		Version:            3,
+	}
+
+	// DefaultSMBProbe set default value for SMBProbe
+	DefaultSMBProbe = SMBProbe{
+		IPProtocolFallback: true,
+		Dialects:           []string{"2.0.2", "2.1", "3.0", "3.0.2", "3.1.1"},
+	}
+
+	// DefaultEtcdProbe set default value for EtcdProbe
+	DefaultEtcdProbe = EtcdProbe{
+		IPProtocolFallback: true,
+		Key:                "health",
+	}
+
+	// DefaultZooKeeperProbe set default value for ZooKeeperProbe
+	DefaultZooKeeperProbe = ZooKeeperProbe{
+		IPProtocolFallback: true,
+	}
+
+	// DefaultConsulProbe set default value for ConsulProbe
+	DefaultConsulProbe = ConsulProbe{
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+
+	// DefaultGameServerProbe set default value for GameServerProbe
+	DefaultGameServerProbe = GameServerProbe{
+		IPProtocolFallback: true,
+		Protocol:           "a2s",
+	}
+
+	// DefaultIPPProbe set default value for IPPProbe
+	DefaultIPPProbe = IPPProbe{
+		ValidPrinterStates: []string{"idle", "processing"},
+		HTTPClientConfig:   config.DefaultHTTPClientConfig,
+	}
+
+	// DefaultSMTPProbe set default value for SMTPProbe
+	DefaultSMTPProbe = SMTPProbe{
+		IPProtocolFallback: true,
+		EHLODomain:         "localhost",
+	}
+
+	// DefaultNDPProbe set default value for NDPProbe
+	DefaultNDPProbe = NDPProbe{
+		Solicitation: "router",
+	}
+
+	// DefaultSSEProbe set default value for SSEProbe
+	DefaultSSEProbe = SSEProbe{
+		Events:           1,
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+
+	// DefaultTracerouteProbe set default value for TracerouteProbe
+	DefaultTracerouteProbe = TracerouteProbe{
+		IPProtocolFallback: true,
+		Protocol:           "icmp",
+		MaxHops:            30,
+		Port:               33434,
+	}
+
+	// DefaultOIDCProbe set default value for OIDCProbe
+	DefaultOIDCProbe = OIDCProbe{
+		// The metadata required by OpenID Connect Discovery 1.0.
+		RequiredFields: []string{
+			"issuer",
+			"authorization_endpoint",
+			"jwks_uri",
+			"response_types_supported",
+			"subject_types_supported",
+			"id_token_signing_alg_values_supported",
+		},
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+
 	// DefaultTCPProbe set default value for TCPProbe
 	DefaultTCPProbe = TCPProbe{
 		IPProtocolFallback: true,
 	}
 
 	// DefaultICMPProbe set default value for ICMPProbe
-	DefaultICMPTTL   = 64
-	DefaultICMPProbe = ICMPProbe{
+	DefaultICMPTTL            = 64
+	DefaultICMPPacketInterval = time.Second
+	DefaultICMPProbe          = ICMPProbe{
 		IPProtocolFallback: true,
 		TTL:                DefaultICMPTTL,
 	}
@@ -74,18 +203,151 @@ var (
 	DefaultDNSProbe = DNSProbe{
 		IPProtocolFallback: true,
 		Recursion:          true,
+		ServerStrategy:     "failover",
 	}
 )
 
 type Config struct {
-	Modules map[string]Module `yaml:"modules"`
+	Modules            map[string]Module   `yaml:"modules"`
+	GrafanaAnnotations *GrafanaAnnotations `yaml:"grafana_annotations,omitempty"`
+	HTTPDefaults       *HTTPDefaults       `yaml:"http_defaults,omitempty"`
+	// PreviousModules are the versions of the modules before they were last
+	// changed by a reload.
+	PreviousModules map[string]Module `yaml:"-"`
+}
+
+// HTTPDefaults are applied to the modules of the http prober that do not set
+// them, to identify the probes of a fleet the same way.
+type HTTPDefaults struct {
+	// UserAgent is sent unless the module sets a User-Agent header.
+	UserAgent string `yaml:"user_agent,omitempty"`
+	// Headers are sent unless the module sets a header of the same name.
+	Headers       map[string]string `yaml:"headers,omitempty"`
+	TLSMinVersion config.TLSVersion `yaml:"tls_min_version,omitempty"`
+}
+
+// GrafanaAnnotations posts an annotation to the Grafana HTTP API when the
+// probes of a target start failing or succeed again.
+type GrafanaAnnotations struct {
+	// URL is the base URL of Grafana, the annotations are posted to
+	// /api/annotations below it.
+	URL string `yaml:"url"`
+	// DashboardUID restricts the annotations to a dashboard, they are shown
+	// on all the dashboards querying their tags otherwise.
+	DashboardUID     string                  `yaml:"dashboard_uid,omitempty"`
+	Tags             []string                `yaml:"tags,omitempty"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *GrafanaAnnotations) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = GrafanaAnnotations{HTTPClientConfig: config.DefaultHTTPClientConfig}
+	type plain GrafanaAnnotations
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.URL == "" {
+		return errors.New("url must be set for grafana_annotations")
+	}
+	if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid url %q for grafana_annotations", s.URL)
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// The versions of a module that can be requested.
+const (
+	ModuleVersionCurrent  = "current"
+	ModuleVersionPrevious = "previous"
+)
+
+// SelectModule returns the requested version of a module and its name. If no
+// version is requested, the previous version is used for the targets that
+// are not part of the canary_percent of the current version. Modules that
+// did not change have no previous version, their current version is used.
+func (c *Config) SelectModule(name, version, target string) (Module, string, error) {
+	module, ok := c.Modules[name]
+	if !ok {
+		return Module{}, "", fmt.Errorf("unknown module %q", name)
+	}
+	previous, hasPrevious := c.PreviousModules[name]
+	switch version {
+	case "":
+		if hasPrevious && module.CanaryPercent > 0 && !inCanary(target, module.CanaryPercent) {
+			return previous, ModuleVersionPrevious, nil
+		}
+	case ModuleVersionCurrent:
+	case ModuleVersionPrevious:
+		if hasPrevious {
+			return previous, ModuleVersionPrevious, nil
+		}
+	default:
+		return Module{}, "", fmt.Errorf("module version %q is not valid", version)
+	}
+	return module, ModuleVersionCurrent, nil
+}
+
+// inCanary returns whether a target is part of the percent of targets
+// probed with the current version of a module. The same targets remain in
+// the canary, so that their results can be compared over time.
+func inCanary(target string, percent float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte(target))
+	return float64(h.Sum32()%10000) < percent*100
+}
+
+// previousModules returns the previous versions of the modules of c, which
+// replaces the configuration current: the modules of current that changed,
+// and the previous versions of the ones that did not.
+func previousModules(current, c *Config) map[string]Module {
+	previous := map[string]Module{}
+	for name, module := range c.Modules {
+		if old, ok := current.Modules[name]; ok && moduleChanged(old, module) {
+			previous[name] = old
+		} else if old, ok := current.PreviousModules[name]; ok {
+			previous[name] = old
+		}
+	}
+	return previous
+}
+
+// moduleChanged reports whether module differs from old. Modules are
+// compared in their YAML form, as the compiled templates and regexps they
+// hold are not comparable, and their secrets, which the YAML form hides.
+func moduleChanged(old, module Module) bool {
+	oldYAML, err := yaml.Marshal(old)
+	if err != nil {
+		return true
+	}
+	moduleYAML, err := yaml.Marshal(module)
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(oldYAML, moduleYAML) || !slices.Equal(secretValues(reflect.ValueOf(old), nil), secretValues(reflect.ValueOf(module), nil))
 }
 
 type SafeConfig struct {
 	sync.RWMutex
-	C                   *Config
+	C *Config
+	// AllowUnsafeHTTPMethods must be set for modules to use unsafe HTTP
+	// methods, see IsUnsafeHTTPMethod.
+	AllowUnsafeHTTPMethods bool
+	// ProberRegistered reports whether a prober of the given name exists.
+	// If set, modules using other probers are rejected.
+	ProberRegistered func(name string) bool
+	// DecryptCommand decrypts the encrypted secrets of the modules, see
+	// decryptSecret.
+	DecryptCommand []string
+	// RuntimeModulesFile is the file the modules managed at runtime are
+	// persisted in, if set.
+	RuntimeModulesFile  string
 	configReloadSuccess prometheus.Gauge
 	configReloadSeconds prometheus.Gauge
+
+	// runtimeMu serializes the changes of the runtime modules, whose YAML
+	// documents are kept to patch and persist them.
+	runtimeMu      sync.Mutex
+	runtimeModules map[string]map[string]interface{}
 }
 
 func NewSafeConfig(reg prometheus.Registerer) *SafeConfig {
@@ -127,27 +389,61 @@ func (sc *SafeConfig) ReloadConfig(confFile string, logger *slog.Logger) (err er
 	}
 
 	for name, module := range c.Modules {
-		if module.HTTP.NoFollowRedirects != nil {
-			// Hide the old flag from the /config page.
-			module.HTTP.NoFollowRedirects = nil
-			c.Modules[name] = module
-			if logger != nil {
-				logger.Warn("no_follow_redirects is deprecated and will be removed in the next release. It is replaced by follow_redirects.", "module", name)
-			}
+		if err := sc.checkModule(name, &module, logger); err != nil {
+			return err
+		}
+		c.Modules[name] = module
+	}
+	if c.GrafanaAnnotations != nil {
+		if err := decryptSecrets(reflect.ValueOf(c.GrafanaAnnotations), sc.decryptSecret); err != nil {
+			return fmt.Errorf("grafana_annotations: %s", err)
 		}
 	}
+	if err := sc.addRuntimeModules(c); err != nil {
+		return err
+	}
 
 	sc.Lock()
+	c.PreviousModules = previousModules(sc.C, c)
 	sc.C = c
 	sc.Unlock()
 
 	return nil
 }
 
+// checkModule checks the parts of a module that depend on the exporter
+// rather than on the module alone.
+func (sc *SafeConfig) checkModule(name string, module *Module, logger *slog.Logger) error {
+	if sc.ProberRegistered != nil && !sc.ProberRegistered(module.Prober) {
+		return fmt.Errorf("module %s uses the unknown prober %q", name, module.Prober)
+	}
+	if module.HTTP.NoFollowRedirects != nil {
+		// Hide the old flag from the /config page.
+		module.HTTP.NoFollowRedirects = nil
+		if logger != nil {
+			logger.Warn("no_follow_redirects is deprecated and will be removed in the next release. It is replaced by follow_redirects.", "module", name)
+		}
+	}
+	if IsUnsafeHTTPMethod(module.HTTP.Method) {
+		if !sc.AllowUnsafeHTTPMethods {
+			return fmt.Errorf("module %s uses the unsafe HTTP method %s, which requires --http.allow-unsafe-methods", name, module.HTTP.Method)
+		}
+		if !module.HTTP.AllowUnsafeMethod {
+			return fmt.Errorf("module %s uses the unsafe HTTP method %s, which requires allow_unsafe_method to be set", name, module.HTTP.Method)
+		}
+	}
+	if err := decryptSecrets(reflect.ValueOf(module), sc.decryptSecret); err != nil {
+		return fmt.Errorf("module %s: %s", name, err)
+	}
+	return nil
+}
+
 // Regexp encapsulates a regexp.Regexp and makes it YAML marshalable.
 type Regexp struct {
 	*regexp.Regexp
 	original string
+	// name identifies the validator using the regexp in metrics.
+	name string
 }
 
 // NewRegexp creates a new anchored Regexp and returns an error if the
@@ -160,11 +456,28 @@ func NewRegexp(s string) (Regexp, error) {
 	}, err
 }
 
+// namedRegexp is the form of a regexp given a name, in the lists of
+// regexps of validators.
+type namedRegexp struct {
+	Regexp string `yaml:"regexp"`
+	Name   string `yaml:"name,omitempty"`
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var s string
 	if err := unmarshal(&s); err != nil {
-		return err
+		var named namedRegexp
+		if unmarshal(&named) != nil {
+			return err
+		}
+		r, err := NewRegexp(named.Regexp)
+		if err != nil {
+			return fmt.Errorf("\"Could not compile regular expression\" regexp=\"%s\"", named.Regexp)
+		}
+		r.name = named.Name
+		*re = r
+		return nil
 	}
 	r, err := NewRegexp(s)
 	if err != nil {
@@ -176,12 +489,26 @@ func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // MarshalYAML implements the yaml.Marshaler interface.
 func (re Regexp) MarshalYAML() (interface{}, error) {
+	if re.name != "" {
+		return namedRegexp{Regexp: re.original, Name: re.name}, nil
+	}
 	if re.original != "" {
 		return re.original, nil
 	}
 	return nil, nil
 }
 
+// Name returns the name of the validator using the regexp, if any.
+func (re Regexp) Name() string {
+	return re.name
+}
+
+// WithName returns a copy of the regexp identified by the given name.
+func (re Regexp) WithName(name string) Regexp {
+	re.name = name
+	return re
+}
+
 // MustNewRegexp works like NewRegexp, but panics if the regular expression does not compile.
 func MustNewRegexp(s string) Regexp {
 	re, err := NewRegexp(s)
@@ -194,246 +521,2352 @@ func MustNewRegexp(s string) Regexp {
 type Module struct {
 	Prober  string        `yaml:"prober,omitempty"`
 	Timeout time.Duration `yaml:"timeout,omitempty"`
-	HTTP    HTTPProbe     `yaml:"http,omitempty"`
-	TCP     TCPProbe      `yaml:"tcp,omitempty"`
-	ICMP    ICMPProbe     `yaml:"icmp,omitempty"`
-	DNS     DNSProbe      `yaml:"dns,omitempty"`
-	GRPC    GRPCProbe     `yaml:"grpc,omitempty"`
+	// DeduplicationWindow is how long the result of a probe is shared with
+	// identical probe requests.
+	DeduplicationWindow time.Duration `yaml:"deduplication_window,omitempty"`
+	// MinInterval is the minimum time between two probes of a target with the
+	// module, faster probe requests are rejected.
+	MinInterval time.Duration `yaml:"min_interval,omitempty"`
+	// CanaryPercent is the percentage of the targets probed with the module
+	// after it changed, the others are probed with its previous version.
+	// All targets are probed with it if it is 0.
+	CanaryPercent float64 `yaml:"canary_percent,omitempty"`
+	// ActiveHours are the windows of time in which targets are probed.
+	// Outside of them, probes are skipped and succeed.
+	ActiveHours []TimeWindow `yaml:"active_hours,omitempty"`
+	// URLOverrides are the fields of the module that probe requests can
+	// override with URL parameters.
+	URLOverrides []string `yaml:"url_overrides,omitempty"`
+	// SelfTestTarget is probed by the self-test at startup instead of the
+	// built-in loopback server.
+	SelfTestTarget string `yaml:"self_test_target,omitempty"`
+	// MaxConcurrent is the maximum number of probes of the module running at
+	// the same time, unlimited if 0.
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+	// ConcurrencyPolicy is what happens to probes over MaxConcurrent: they
+	// wait for a running probe to finish with queue, the default, or are
+	// rejected with reject.
+	ConcurrencyPolicy string `yaml:"concurrency_policy,omitempty"`
+	// Retries is the number of times a failed probe is run again within the
+	// timeout of the probe.
+	Retries int `yaml:"retries,omitempty"`
+	// RetryInterval is the time waited before each retry.
+	RetryInterval time.Duration `yaml:"retry_interval,omitempty"`
+	// FallbackTarget is probed when the probe of the target fails, e.g. a
+	// disaster recovery endpoint.
+	FallbackTarget string          `yaml:"fallback_target,omitempty"`
+	HTTP           HTTPProbe       `yaml:"http,omitempty"`
+	TCP            TCPProbe        `yaml:"tcp,omitempty"`
+	ICMP           ICMPProbe       `yaml:"icmp,omitempty"`
+	DNS            DNSProbe        `yaml:"dns,omitempty"`
+	GRPC           GRPCProbe       `yaml:"grpc,omitempty"`
+	Proxy          ProxyProbe      `yaml:"proxy,omitempty"`
+	PortScan       PortScanProbe   `yaml:"portscan,omitempty"`
+	Roughtime      RoughtimeProbe  `yaml:"roughtime,omitempty"`
+	SNMP           SNMPProbe       `yaml:"snmp,omitempty"`
+	BGP            BGPProbe        `yaml:"bgp,omitempty"`
+	OIDC           OIDCProbe       `yaml:"oidc,omitempty"`
+	NFS            NFSProbe        `yaml:"nfs,omitempty"`
+	SMB            SMBProbe        `yaml:"smb,omitempty"`
+	Etcd           EtcdProbe       `yaml:"etcd,omitempty"`
+	ZooKeeper      ZooKeeperProbe  `yaml:"zookeeper,omitempty"`
+	Consul         ConsulProbe     `yaml:"consul,omitempty"`
+	GameServer     GameServerProbe `yaml:"gameserver,omitempty"`
+	IPP            IPPProbe        `yaml:"ipp,omitempty"`
+	SMTP           SMTPProbe       `yaml:"smtp,omitempty"`
+	NDP            NDPProbe        `yaml:"ndp,omitempty"`
+	SSE            SSEProbe        `yaml:"sse,omitempty"`
+	Traceroute     TracerouteProbe `yaml:"traceroute,omitempty"`
 }
 
+// maxCapturedHeaders is the maximum number of capture_headers of a module.
+const maxCapturedHeaders = 10
+
 type HTTPProbe struct {
 	// Defaults to 2xx.
 	ValidStatusCodes             []int                   `yaml:"valid_status_codes,omitempty"`
 	ValidHTTPVersions            []string                `yaml:"valid_http_versions,omitempty"`
+	HTTPVersion                  string                  `yaml:"http_version,omitempty"`
 	IPProtocol                   string                  `yaml:"preferred_ip_protocol,omitempty"`
 	IPProtocolFallback           bool                    `yaml:"ip_protocol_fallback,omitempty"`
 	SkipResolvePhaseWithProxy    bool                    `yaml:"skip_resolve_phase_with_proxy,omitempty"`
 	NoFollowRedirects            *bool                   `yaml:"no_follow_redirects,omitempty"`
 	FailIfSSL                    bool                    `yaml:"fail_if_ssl,omitempty"`
 	FailIfNotSSL                 bool                    `yaml:"fail_if_not_ssl,omitempty"`
+	FailIfTLSVersionBelow        config.TLSVersion       `yaml:"fail_if_tls_version_below,omitempty"`
+	FailIfSelfSigned             bool                    `yaml:"fail_if_self_signed,omitempty"`
+	InsecureCapture              bool                    `yaml:"insecure_capture,omitempty"`
 	Method                       string                  `yaml:"method,omitempty"`
+	AllowUnsafeMethod            bool                    `yaml:"allow_unsafe_method,omitempty"`
 	Headers                      map[string]string       `yaml:"headers,omitempty"`
 	FailIfBodyMatchesRegexp      []Regexp                `yaml:"fail_if_body_matches_regexp,omitempty"`
 	FailIfBodyNotMatchesRegexp   []Regexp                `yaml:"fail_if_body_not_matches_regexp,omitempty"`
 	FailIfHeaderMatchesRegexp    []HeaderMatch           `yaml:"fail_if_header_matches,omitempty"`
 	FailIfHeaderNotMatchesRegexp []HeaderMatch           `yaml:"fail_if_header_not_matches,omitempty"`
+	FailIfBodyJSONNotMatches     []JSONMatch             `yaml:"fail_if_body_json_not_matches,omitempty"`
+	BodyHash                     string                  `yaml:"body_hash,omitempty"`
+	FailIfBodySHA256NotEquals    string                  `yaml:"fail_if_body_sha256_not_equals,omitempty"`
 	Body                         string                  `yaml:"body,omitempty"`
 	BodyFile                     string                  `yaml:"body_file,omitempty"`
 	HTTPClientConfig             config.HTTPClientConfig `yaml:"http_client_config,inline"`
 	Compression                  string                  `yaml:"compression,omitempty"`
 	BodySizeLimit                units.Base2Bytes        `yaml:"body_size_limit,omitempty"`
+	LongPoll                     LongPoll                `yaml:"long_poll,omitempty"`
+	ValidateHSTS                 HSTSValidator           `yaml:"validate_hsts,omitempty"`
+	SecurityHeaders              SecurityHeadersPolicy   `yaml:"security_headers,omitempty"`
+	ValidateProxyChain           ProxyChainValidator     `yaml:"validate_proxy_chain,omitempty"`
+	ValidateAltSvc               AltSvcValidator         `yaml:"validate_alt_svc,omitempty"`
+	ValidateCrawlConfig          CrawlConfigValidator    `yaml:"validate_crawl_config,omitempty"`
+	ValidateSAMLMetadata         SAMLMetadataValidator   `yaml:"validate_saml_metadata,omitempty"`
+	CrawlAssets                  AssetCrawlConfig        `yaml:"crawl_assets,omitempty"`
+	GraphQL                      GraphQLQuery            `yaml:"graphql,omitempty"`
+	ValidateHealthJSON           HealthJSONValidator     `yaml:"validate_health_json,omitempty"`
+	ValidateJWT                  JWTValidator            `yaml:"validate_jwt,omitempty"`
+	SuccessCriteria              *SuccessCriterion       `yaml:"success_criteria,omitempty"`
+	CaptivePortal                CaptivePortalCheck      `yaml:"captive_portal,omitempty"`
+	WAFCanary                    WAFCanary               `yaml:"waf_canary,omitempty"`
+	Maintenance                  MaintenanceSignal       `yaml:"maintenance,omitempty"`
+	IdentifyNode                 NodeIdentification      `yaml:"identify_node,omitempty"`
+	CaptureHeaders               []string                `yaml:"capture_headers,omitempty"`
+	CompareBody                  BodyComparison          `yaml:"compare_body,omitempty"`
+	CanonicalizeBody             BodyCanonicalization    `yaml:"canonicalize_body,omitempty"`
+	Samples                      int                     `yaml:"samples,omitempty"`
+	WarmupRequests               int                     `yaml:"warmup_requests,omitempty"`
+	TLSHandshakeTimeout          time.Duration           `yaml:"tls_handshake_timeout,omitempty"`
+	ResponseHeaderTimeout        time.Duration           `yaml:"response_header_timeout,omitempty"`
+	ExpectContinueTimeout        time.Duration           `yaml:"expect_continue_timeout,omitempty"`
+	MaxResponseHeaderBytes       units.Base2Bytes        `yaml:"max_response_header_bytes,omitempty"`
+	EnableKeepAlives             bool                    `yaml:"enable_keep_alives,omitempty"`
+	ForceHTTP10                  bool                    `yaml:"force_http10,omitempty"`
+	LenientParsing               bool                    `yaml:"lenient_parsing,omitempty"`
+	RawRequest                   string                  `yaml:"raw_request,omitempty"`
+	RawRequestFile               string                  `yaml:"raw_request_file,omitempty"`
+	TLSSigner                    ExternalSigner          `yaml:"tls_signer,omitempty"`
+	ProbeSignature               ProbeSignature          `yaml:"probe_signature,omitempty"`
 }
 
-type GRPCProbe struct {
-	Service             string           `yaml:"service,omitempty"`
-	TLS                 bool             `yaml:"tls,omitempty"`
-	TLSConfig           config.TLSConfig `yaml:"tls_config,omitempty"`
-	IPProtocolFallback  bool             `yaml:"ip_protocol_fallback,omitempty"`
-	PreferredIPProtocol string           `yaml:"preferred_ip_protocol,omitempty"`
+// BodyCanonicalization transforms the body before it is matched by the
+// regexps of the body and compared, so that volatile parts of pages such as
+// timestamps and CSRF tokens do not fail the probe. The transformations are
+// applied in the order of the fields.
+type BodyCanonicalization struct {
+	// CanonicalJSON re-encodes JSON bodies compactly, with sorted keys.
+	CanonicalJSON bool `yaml:"canonical_json,omitempty"`
+	// StripHTMLTags removes the tags, comments, scripts and styles of HTML
+	// bodies and decodes their entities.
+	StripHTMLTags bool `yaml:"strip_html_tags,omitempty"`
+	// RemoveRegexp removes the matches of the regexps.
+	RemoveRegexp []Regexp `yaml:"remove_regexp,omitempty"`
+	// StripWhitespace trims the lines and removes the empty ones.
+	StripWhitespace bool `yaml:"strip_whitespace,omitempty"`
+	Lowercase       bool `yaml:"lowercase,omitempty"`
 }
 
-type HeaderMatch struct {
-	Header       string `yaml:"header,omitempty"`
-	Regexp       Regexp `yaml:"regexp,omitempty"`
-	AllowMissing bool   `yaml:"allow_missing,omitempty"`
+// Enabled returns whether the body is transformed.
+func (s BodyCanonicalization) Enabled() bool {
+	return s.CanonicalJSON || s.StripHTMLTags || len(s.RemoveRegexp) > 0 || s.StripWhitespace || s.Lowercase
 }
 
-type Label struct {
-	Name  string `yaml:"name,omitempty"`
-	Value string `yaml:"value,omitempty"`
+// BodyComparison compares the body of responses to a golden copy, or to the
+// body of the previous response of the target, to detect unexpected changes
+// of static content such as defacements.
+type BodyComparison struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// GoldenFile is the expected body. The previous body is used if it is
+	// not set.
+	GoldenFile    string  `yaml:"golden_file,omitempty"`
+	FailIfChanged bool    `yaml:"fail_if_changed,omitempty"`
+	MinSimilarity float64 `yaml:"min_similarity,omitempty"`
 }
 
-type QueryResponse struct {
-	Expect   Regexp  `yaml:"expect,omitempty"`
-	Labels   []Label `yaml:"labels,omitempty"`
-	Send     string  `yaml:"send,omitempty"`
-	StartTLS bool    `yaml:"starttls,omitempty"`
+// MaintenanceSignal identifies the responses of targets in planned
+// maintenance, such as a 503 with an "X-Maintenance: true" header. All the
+// conditions that are set have to hold.
+type MaintenanceSignal struct {
+	StatusCodes []int  `yaml:"status_codes,omitempty"`
+	Header      string `yaml:"header,omitempty"`
+	// HeaderRegexp is matched against the values of the header, which only
+	// has to be present if it is not set.
+	HeaderRegexp Regexp   `yaml:"header_regexp,omitempty"`
+	JSONPath     JSONPath `yaml:"json_path,omitempty"`
+	// JSONRegexp is matched against the value at JSONPath, which has to be
+	// true if it is not set.
+	JSONRegexp Regexp `yaml:"json_regexp,omitempty"`
 }
 
-type TCPProbe struct {
-	IPProtocol         string           `yaml:"preferred_ip_protocol,omitempty"`
-	IPProtocolFallback bool             `yaml:"ip_protocol_fallback,omitempty"`
-	SourceIPAddress    string           `yaml:"source_ip_address,omitempty"`
-	QueryResponse      []QueryResponse  `yaml:"query_response,omitempty"`
-	TLS                bool             `yaml:"tls,omitempty"`
-	TLSConfig          config.TLSConfig `yaml:"tls_config,omitempty"`
+// Enabled returns whether maintenance responses are detected.
+func (s MaintenanceSignal) Enabled() bool {
+	return len(s.StatusCodes) > 0 || s.Header != "" || !s.JSONPath.IsZero()
 }
 
-type ICMPProbe struct {
-	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"` // Defaults to "ip6".
-	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
-	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
-	PayloadSize        int    `yaml:"payload_size,omitempty"`
-	DontFragment       bool   `yaml:"dont_fragment,omitempty"`
-	TTL                int    `yaml:"ttl,omitempty"`
+// NodeIdentification extracts the identifier of the anycast or CDN node that
+// served a response from a header, such as X-Served-By, or from the body.
+type NodeIdentification struct {
+	Header string `yaml:"header,omitempty"`
+	// HeaderRegexp is matched against the values of the header, which is
+	// used as is if it is not set.
+	HeaderRegexp Regexp `yaml:"header_regexp,omitempty"`
+	BodyRegexp   Regexp `yaml:"body_regexp,omitempty"`
 }
 
-type DNSProbe struct {
-	IPProtocol         string           `yaml:"preferred_ip_protocol,omitempty"`
-	IPProtocolFallback bool             `yaml:"ip_protocol_fallback,omitempty"`
-	DNSOverTLS         bool             `yaml:"dns_over_tls,omitempty"`
-	TLSConfig          config.TLSConfig `yaml:"tls_config,omitempty"`
-	SourceIPAddress    string           `yaml:"source_ip_address,omitempty"`
-	TransportProtocol  string           `yaml:"transport_protocol,omitempty"`
-	QueryClass         string           `yaml:"query_class,omitempty"` // Defaults to IN.
-	QueryName          string           `yaml:"query_name,omitempty"`
-	QueryType          string           `yaml:"query_type,omitempty"`        // Defaults to ANY.
-	Recursion          bool             `yaml:"recursion_desired,omitempty"` // Defaults to true.
-	ValidRcodes        []string         `yaml:"valid_rcodes,omitempty"`      // Defaults to NOERROR.
-	ValidateAnswer     DNSRRValidator   `yaml:"validate_answer_rrs,omitempty"`
-	ValidateAuthority  DNSRRValidator   `yaml:"validate_authority_rrs,omitempty"`
-	ValidateAdditional DNSRRValidator   `yaml:"validate_additional_rrs,omitempty"`
+// Enabled returns whether the node that served the response is identified.
+func (n NodeIdentification) Enabled() bool {
+	return n.Header != "" || n.BodyRegexp.Regexp != nil
 }
 
-type DNSRRValidator struct {
-	FailIfMatchesRegexp     []string `yaml:"fail_if_matches_regexp,omitempty"`
-	FailIfAllMatchRegexp    []string `yaml:"fail_if_all_match_regexp,omitempty"`
-	FailIfNotMatchesRegexp  []string `yaml:"fail_if_not_matches_regexp,omitempty"`
-	FailIfNoneMatchesRegexp []string `yaml:"fail_if_none_matches_regexp,omitempty"`
+// WAFCanary sends a request designed to be blocked by a web application
+// firewall or rate limiter, to verify that the protection is active.
+type WAFCanary struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// QueryParams are added to the query of the target, e.g. an SQL
+	// injection attempt.
+	QueryParams         map[string]string `yaml:"query_params,omitempty"`
+	ExpectedStatusCodes []int             `yaml:"expected_status_codes,omitempty"`
+	Layers              []WAFLayer        `yaml:"layers,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	type plain Config
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	return nil
+// WAFLayer identifies the protection layer that blocked a request by a
+// header of its response.
+type WAFLayer struct {
+	Name   string `yaml:"name,omitempty"`
+	Header string `yaml:"header,omitempty"`
+	Regexp Regexp `yaml:"regexp,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *Module) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultModule
-	type plain Module
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	return nil
+// CaptivePortalCheck mirrors the connectivity checks of operating systems,
+// which request a known URL and conclude that the network is behind a captive
+// portal when the response is not the expected one.
+type CaptivePortalCheck struct {
+	Enabled            bool   `yaml:"enabled,omitempty"`
+	ExpectedStatusCode int    `yaml:"expected_status_code,omitempty"`
+	ExpectedBody       string `yaml:"expected_body,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *HTTPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultHTTPProbe
-	type plain HTTPProbe
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
+// HealthJSONValidator parses application/health+json responses, see
+// https://datatracker.ietf.org/doc/html/draft-inadarei-api-health-check.
+type HealthJSONValidator struct {
+	Enabled    bool `yaml:"enabled,omitempty"`
+	FailIfWarn bool `yaml:"fail_if_warn,omitempty"`
+}
 
-	// BodySizeLimit == 0 means no limit. By leaving it at 0 we
-	// avoid setting up the limiter.
-	if s.BodySizeLimit < 0 || s.BodySizeLimit == math.MaxInt64 {
-		// The implementation behind http.MaxBytesReader tries
-		// to add 1 to the specified limit causing it to wrap
-		// around and become negative, and then it tries to use
-		// that result to index an slice.
-		s.BodySizeLimit = math.MaxInt64 - 1
-	}
+// JWTValidator verifies the signature of a JWT returned in a header or a
+// field of a JSON body against the keys of a JWKS URL.
+type JWTValidator struct {
+	Header   string   `yaml:"header,omitempty"`
+	JSONPath JSONPath `yaml:"json_path,omitempty"`
+	JWKSURL  string   `yaml:"jwks_url,omitempty"`
+	// Issuer is the expected issuer of the token, if set.
+	Issuer string `yaml:"issuer,omitempty"`
+}
 
-	if err := s.HTTPClientConfig.Validate(); err != nil {
-		return err
-	}
+// Enabled returns true if the response has a JWT to validate.
+func (v JWTValidator) Enabled() bool {
+	return v.Header != "" || !v.JSONPath.IsZero()
+}
 
-	if s.NoFollowRedirects != nil {
-		s.HTTPClientConfig.FollowRedirects = !*s.NoFollowRedirects
-	}
+// GraphQLQuery is a GraphQL query POSTed by the HTTP probe in place of the
+// body.
+type GraphQLQuery struct {
+	Query         string                 `yaml:"query,omitempty"`
+	OperationName string                 `yaml:"operation_name,omitempty"`
+	Variables     map[string]interface{} `yaml:"variables,omitempty"`
+	Assertions    []GraphQLAssertion     `yaml:"assertions,omitempty"`
+}
 
-	if s.Body != "" && s.BodyFile != "" {
-		return errors.New("setting body and body_file both are not allowed")
-	}
+// GraphQLAssertion checks the values selected in the response. Without a
+// regexp, the selected values only have to be present and not null.
+type GraphQLAssertion struct {
+	Path   JSONPath `yaml:"path,omitempty"`
+	Regexp Regexp   `yaml:"regexp,omitempty"`
+}
 
-	for key, value := range s.Headers {
-		switch textproto.CanonicalMIMEHeaderKey(key) {
-		case "Accept-Encoding":
-			if !isCompressionAcceptEncodingValid(s.Compression, value) {
-				return fmt.Errorf(`invalid configuration "%s: %s", "compression: %s"`, key, value, s.Compression)
-			}
-		}
-	}
+type AssetCrawlConfig struct {
+	MaxResources int  `yaml:"max_resources,omitempty"`
+	FailIfBroken bool `yaml:"fail_if_broken,omitempty"`
+}
 
-	return nil
+type HSTSValidator struct {
+	FailIfMaxAgeBelow          model.Duration `yaml:"fail_if_max_age_below,omitempty"`
+	FailIfNotIncludeSubdomains bool           `yaml:"fail_if_not_include_subdomains,omitempty"`
+	FailIfNotPreload           bool           `yaml:"fail_if_not_preload,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *GRPCProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultGRPCProbe
-	type plain GRPCProbe
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	return nil
+type CrawlConfigValidator struct {
+	// One of robots_txt or sitemap.
+	Format              string         `yaml:"format,omitempty"`
+	FailIfOlderThan     model.Duration `yaml:"fail_if_older_than,omitempty"`
+	FailIfURLCountBelow int            `yaml:"fail_if_url_count_below,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *DNSProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultDNSProbe
-	type plain DNSProbe
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	if s.QueryName == "" {
-		return errors.New("query name must be set for DNS module")
-	}
-	if s.QueryClass != "" {
-		if _, ok := dns.StringToClass[s.QueryClass]; !ok {
-			return fmt.Errorf("query class '%s' is not valid", s.QueryClass)
-		}
-	}
-	if s.QueryType != "" {
-		if _, ok := dns.StringToType[s.QueryType]; !ok {
-			return fmt.Errorf("query type '%s' is not valid", s.QueryType)
-		}
-	}
+// SAMLMetadataValidator parses the response body as the SAML metadata of an
+// identity provider and checks its signing certificates.
+type SAMLMetadataValidator struct {
+	Enabled                 bool           `yaml:"enabled,omitempty"`
+	FailIfCertExpiresWithin model.Duration `yaml:"fail_if_cert_expires_within,omitempty"`
+}
 
-	return nil
+type SecurityHeadersPolicy struct {
+	Rules            []SecurityHeaderRule `yaml:"rules,omitempty"`
+	FailIfScoreBelow float64              `yaml:"fail_if_score_below,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *TCPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultTCPProbe
-	type plain TCPProbe
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	return nil
+type SecurityHeaderRule struct {
+	Header             string   `yaml:"header,omitempty"`
+	AllowedValues      []string `yaml:"allowed_values,omitempty"`
+	RequiredDirectives []string `yaml:"required_directives,omitempty"`
+	Regexp             Regexp   `yaml:"regexp,omitempty"`
+	Weight             float64  `yaml:"weight,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *DNSRRValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	type plain DNSRRValidator
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
-	return nil
+// ProxyChainValidator checks the proxies and CDN layers a request traversed,
+// as reported by the Via, Server and X-Served-By headers of the response.
+type ProxyChainValidator struct {
+	Enabled         bool            `yaml:"enabled,omitempty"`
+	MustTraverse    []ProxyHopMatch `yaml:"must_traverse,omitempty"`
+	MustNotTraverse []ProxyHopMatch `yaml:"must_not_traverse,omitempty"`
 }
 
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *ICMPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = DefaultICMPProbe
-	type plain ICMPProbe
-	if err := unmarshal((*plain)(s)); err != nil {
-		return err
-	}
+// LongPoll stops the probe of a long-poll or slow endpoint once the response
+// headers, or the first bytes of the body, arrived, rather than waiting for
+// the end of the body.
+type LongPoll struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// FirstBytes of the body have to be read before the probe stops. With
+	// 0 the headers are enough.
+	FirstBytes units.Base2Bytes `yaml:"first_bytes,omitempty"`
+}
 
-	if runtime.GOOS == "windows" && s.DontFragment {
+// AltSvcValidator checks the alternative services advertised in the Alt-Svc
+// header of the response, and optionally sends the request to them.
+type AltSvcValidator struct {
+	Enabled           bool `yaml:"enabled,omitempty"`
+	FailIfMissing     bool `yaml:"fail_if_missing,omitempty"`
+	ProbeAlternatives bool `yaml:"probe_alternatives,omitempty"`
+	// FailIfBroken fails the probe if one of the probed alternatives does
+	// not answer like the target.
+	FailIfBroken bool `yaml:"fail_if_broken,omitempty"`
+}
+
+// ProxyHopMatch matches the hops of the proxy chain reported in a header, or
+// in any of them if the header is not set.
+type ProxyHopMatch struct {
+	Header string `yaml:"header,omitempty"`
+	Regexp Regexp `yaml:"regexp,omitempty"`
+}
+
+type GRPCProbe struct {
+	Service             string           `yaml:"service,omitempty"`
+	Protocol            string           `yaml:"protocol,omitempty"`
+	TLS                 bool             `yaml:"tls,omitempty"`
+	TLSConfig           config.TLSConfig `yaml:"tls_config,omitempty"`
+	IPProtocolFallback  bool             `yaml:"ip_protocol_fallback,omitempty"`
+	PreferredIPProtocol string           `yaml:"preferred_ip_protocol,omitempty"`
+}
+
+// ProxyProbe forwards the probe to the blackbox exporter of a region.
+type ProxyProbe struct {
+	// Regions maps region names to the URL of their blackbox exporter.
+	Regions map[string]string `yaml:"regions,omitempty"`
+	// Region is probed if the probe request does not set the region
+	// parameter. It may be a comma-separated list of regions, or "all".
+	Region string `yaml:"region,omitempty"`
+	// MinSuccessfulRegions is the number of regions the probe must succeed
+	// from when several regions are probed. Defaults to all of them.
+	MinSuccessfulRegions int                     `yaml:"min_successful_regions,omitempty"`
+	Module               string                  `yaml:"module,omitempty"`
+	HTTPClientConfig     config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// OIDCProbe checks the discovery document and the keys of an OpenID
+// Connect provider, whose issuer URL is the target.
+type OIDCProbe struct {
+	// RequiredFields must be present in the discovery document.
+	RequiredFields []string `yaml:"required_fields,omitempty"`
+	// A client credentials token request is made if ClientID is set.
+	ClientID         string                  `yaml:"client_id,omitempty"`
+	ClientSecret     config.Secret           `yaml:"client_secret,omitempty"`
+	Scopes           []string                `yaml:"scopes,omitempty"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// PortScanProbe attempts TCP connections to a set of ports of the target.
+type PortScanProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	Ports              Ports  `yaml:"ports,omitempty"`
+	// AllowedPorts are the ports that may be open. If set, the probe fails
+	// if any other scanned port is open.
+	AllowedPorts   Ports         `yaml:"allowed_ports,omitempty"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"`
+	Concurrency    int           `yaml:"concurrency,omitempty"`
+}
+
+// RoughtimeProbe queries the time of a Roughtime server, authenticated with
+// the long-term public key of the server.
+type RoughtimeProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	// PublicKey is the base64 encoded Ed25519 long-term public key of the
+	// server.
+	PublicKey string `yaml:"public_key,omitempty"`
+	// MaxOffset fails the probe if the local clock is further than this from
+	// the time of the server, beyond the uncertainty of the server.
+	MaxOffset time.Duration `yaml:"max_offset,omitempty"`
+}
+
+// SNMPProbe gets the value of an OID from an SNMP agent.
+type SNMPProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	// Version is the SNMP version: 2 for v2c, or 3.
+	Version int    `yaml:"version,omitempty"`
+	OID     string `yaml:"oid,omitempty"`
+	// The value must match the regexp, and be within the range if it is
+	// numeric.
+	ValueRegexp Regexp   `yaml:"value_regexp,omitempty"`
+	ValueMin    *float64 `yaml:"value_min,omitempty"`
+	ValueMax    *float64 `yaml:"value_max,omitempty"`
+
+	// Community is used by SNMP v2c.
+	Community config.Secret `yaml:"community,omitempty"`
+
+	// The User-based Security Model settings of SNMP v3.
+	Username      string        `yaml:"username,omitempty"`
+	SecurityLevel string        `yaml:"security_level,omitempty"`
+	AuthProtocol  string        `yaml:"auth_protocol,omitempty"`
+	AuthPassword  config.Secret `yaml:"auth_password,omitempty"`
+	PrivProtocol  string        `yaml:"priv_protocol,omitempty"`
+	PrivPassword  config.Secret `yaml:"priv_password,omitempty"`
+	ContextName   string        `yaml:"context_name,omitempty"`
+}
+
+// BGPProbe establishes a BGP session with the target as a passive peer.
+type BGPProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	LocalASN           uint32 `yaml:"local_asn,omitempty"`
+	// PeerASN fails the probe if the peer has another AS number.
+	PeerASN uint32 `yaml:"peer_asn,omitempty"`
+	// RouterID defaults to the local IPv4 address of the session.
+	RouterID string        `yaml:"router_id,omitempty"`
+	HoldTime time.Duration `yaml:"hold_time,omitempty"`
+}
+
+// NFSProbe calls the NULL procedure of the NFS service of the target over
+// TCP.
+type NFSProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	Version            uint32 `yaml:"version,omitempty"`
+	// UsePortmapper looks up the port of the NFS service with the portmapper
+	// of the target instead of using port 2049.
+	UsePortmapper bool `yaml:"use_portmapper,omitempty"`
+}
+
+// smbDialects are the SMB 2 and 3 dialects the smb prober can offer.
+var smbDialects = map[string]bool{
+	"2.0.2": true,
+	"2.1":   true,
+	"3.0":   true,
+	"3.0.2": true,
+	"3.1.1": true,
+}
+
+// SMBProbe negotiates an SMB 2 or 3 dialect with the target.
+type SMBProbe struct {
+	IPProtocol         string   `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool     `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string   `yaml:"source_ip_address,omitempty"`
+	Dialects           []string `yaml:"dialects,omitempty"`
+}
+
+// EtcdProbe checks a member of an etcd cluster with its gRPC API.
+type EtcdProbe struct {
+	IPProtocol         string           `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool             `yaml:"ip_protocol_fallback,omitempty"`
+	TLS                bool             `yaml:"tls,omitempty"`
+	TLSConfig          config.TLSConfig `yaml:"tls_config,omitempty"`
+	// Username and Password authenticate the probe if auth is enabled.
+	Username string        `yaml:"username,omitempty"`
+	Password config.Secret `yaml:"password,omitempty"`
+	// Key is read with a linearizable read, which requires a quorum.
+	Key string `yaml:"key,omitempty"`
+}
+
+// ZooKeeperProbe checks a ZooKeeper server with the ruok and srvr four
+// letter words.
+type ZooKeeperProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+}
+
+// ConsulProbe checks the Raft leader and peers known to a Consul agent.
+type ConsulProbe struct {
+	// MinPeers fails the probe if the cluster has fewer Raft peers.
+	MinPeers         int                     `yaml:"min_peers,omitempty"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// GameServerProbe queries a game server over UDP with the query protocol of
+// its engine.
+type GameServerProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	// Protocol is a2s, the Steam server query protocol, or quake3.
+	Protocol string `yaml:"protocol,omitempty"`
+}
+
+// IPPProbe gets the state of a printer with the Internet Printing Protocol.
+type IPPProbe struct {
+	// ValidPrinterStates are the printer states (idle, processing, stopped)
+	// the probe succeeds with.
+	ValidPrinterStates             []string                `yaml:"valid_printer_states,omitempty"`
+	FailIfStateReasonMatchesRegexp []Regexp                `yaml:"fail_if_state_reason_matches_regexp,omitempty"`
+	HTTPClientConfig               config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// SMTPProbe checks the banner of a mail server, and optionally that it
+// answers EHLO and supports STARTTLS.
+type SMTPProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	// BannerRegexp has to match the text of the 220 greeting.
+	BannerRegexp Regexp           `yaml:"banner_regexp,omitempty"`
+	EHLO         bool             `yaml:"ehlo,omitempty"`
+	EHLODomain   string           `yaml:"ehlo_domain,omitempty"`
+	StartTLS     bool             `yaml:"starttls,omitempty"`
+	TLSConfig    config.TLSConfig `yaml:"tls_config,omitempty"`
+}
+
+// SSEProbe connects to a Server-Sent Events stream and waits for its events.
+type SSEProbe struct {
+	// Events is the number of events to wait for.
+	Events int `yaml:"events,omitempty"`
+	// EventType only counts the events of this type, all events are counted
+	// if it is empty.
+	EventType                  string                  `yaml:"event_type,omitempty"`
+	FailIfDataMatchesRegexp    []Regexp                `yaml:"fail_if_data_matches_regexp,omitempty"`
+	FailIfDataNotMatchesRegexp []Regexp                `yaml:"fail_if_data_not_matches_regexp,omitempty"`
+	HTTPClientConfig           config.HTTPClientConfig `yaml:"http_client_config,inline"`
+}
+
+// TracerouteProbe discovers the hops of the path to the target by sending
+// probes with increasing TTLs.
+type TracerouteProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	// Protocol is icmp for echo requests, or udp for datagrams to closed
+	// ports.
+	Protocol string `yaml:"protocol,omitempty"`
+	MaxHops  int    `yaml:"max_hops,omitempty"`
+	// Port is the destination port of the UDP probe of the first hop, the
+	// probe of each following hop uses the next port.
+	Port int `yaml:"port,omitempty"`
+}
+
+// NDPProbe solicits routers or a neighbor on a link with the Neighbor
+// Discovery Protocol of IPv6, and validates their advertisements.
+type NDPProbe struct {
+	// Interface is the link to probe. Defaults to the zone of the target.
+	Interface string `yaml:"interface,omitempty"`
+	// Solicitation is router or neighbor.
+	Solicitation string `yaml:"solicitation,omitempty"`
+	// ExpectedPrefixes have to be announced by the router advertisement.
+	ExpectedPrefixes  []string      `yaml:"expected_prefixes,omitempty"`
+	ExpectManaged     *bool         `yaml:"expect_managed,omitempty"`
+	ExpectOtherConfig *bool         `yaml:"expect_other_config,omitempty"`
+	MinRouterLifetime time.Duration `yaml:"min_router_lifetime,omitempty"`
+}
+
+// Ports is a set of TCP ports, written as a comma-separated list of ports
+// and port ranges such as "22,80,8000-8100".
+type Ports struct {
+	ports    []int
+	original string
+}
+
+// NewPorts parses a list of ports and port ranges.
+func NewPorts(s string) (Ports, error) {
+	var ports []int
+	seen := map[int]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(field, "-")
+		start, err := parsePort(first)
+		if err != nil {
+			return Ports{}, err
+		}
+		end := start
+		if isRange {
+			if end, err = parsePort(last); err != nil {
+				return Ports{}, err
+			}
+			if end < start {
+				return Ports{}, fmt.Errorf("port range '%s' is not valid", field)
+			}
+		}
+		for port := start; port <= end; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return Ports{ports: ports, original: s}, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port '%s' is not valid", s)
+	}
+	return port, nil
+}
+
+// MustNewPorts works like NewPorts, but panics if the ports are not valid.
+func MustNewPorts(s string) Ports {
+	ports, err := NewPorts(s)
+	if err != nil {
+		panic(err)
+	}
+	return ports
+}
+
+// List returns the ports in ascending order.
+func (p Ports) List() []int {
+	return p.ports
+}
+
+// Contains returns whether the port is in the set.
+func (p Ports) Contains(port int) bool {
+	i := sort.SearchInts(p.ports, port)
+	return i < len(p.ports) && p.ports[i] == port
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *Ports) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	ports, err := NewPorts(s)
+	if err != nil {
+		return err
+	}
+	*p = ports
+	return nil
+}
+
+// IsZero reports whether no ports are set, so that empty sets are omitted
+// when marshalling.
+func (p Ports) IsZero() bool {
+	return len(p.ports) == 0
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p Ports) MarshalYAML() (interface{}, error) {
+	if p.original != "" {
+		return p.original, nil
+	}
+	return nil, nil
+}
+
+// weekdays are the names of the days of TimeWindow.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// TimeWindow is a window of time repeated on some days of the week, such as
+// "monday to friday from 07:00 to 20:00". Windows ending before they start
+// end on the next day, and are part of the day they start on.
+type TimeWindow struct {
+	// Days are the days of the week of the window, all of them if empty.
+	Days  []string `yaml:"days,omitempty"`
+	Start string   `yaml:"start"`
+	End   string   `yaml:"end"`
+	// Location is the name of the time zone of the window, e.g.
+	// "Europe/Berlin", by default the one of the exporter.
+	Location string `yaml:"location,omitempty"`
+
+	days     [7]bool
+	start    int
+	end      int
+	location *time.Location
+}
+
+// parseTimeOfDay returns the minutes since midnight of a time such as
+// "07:30". "24:00" is the end of the day.
+func parseTimeOfDay(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time of day '%s' is not valid", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// NewTimeWindow parses a window of time between two times of day such as
+// "07:30" on the days, given by their English names.
+func NewTimeWindow(days []string, start, end, location string) (TimeWindow, error) {
+	w := TimeWindow{Days: days, Start: start, End: end, Location: location}
+	var err error
+	if w.start, err = parseTimeOfDay(start); err != nil {
+		return TimeWindow{}, err
+	}
+	if w.end, err = parseTimeOfDay(end); err != nil {
+		return TimeWindow{}, err
+	}
+	if w.start == w.end {
+		return TimeWindow{}, fmt.Errorf("time window from %s to %s is empty", start, end)
+	}
+	if w.location, err = time.LoadLocation(location); err != nil {
+		return TimeWindow{}, fmt.Errorf("location '%s' is not valid: %w", location, err)
+	}
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return TimeWindow{}, fmt.Errorf("day '%s' is not valid", day)
+		}
+		w.days[weekday] = true
+	}
+	if len(days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return w, nil
+}
+
+// MustNewTimeWindow works like NewTimeWindow, but panics if the window is
+// not valid.
+func MustNewTimeWindow(days []string, start, end, location string) TimeWindow {
+	w, err := NewTimeWindow(days, start, end, location)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *TimeWindow) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimeWindow
+	var w plain
+	if err := unmarshal(&w); err != nil {
+		return err
+	}
+	window, err := NewTimeWindow(w.Days, w.Start, w.End, w.Location)
+	if err != nil {
+		return err
+	}
+	*s = window
+	return nil
+}
+
+// Contains returns whether the time is within the window.
+func (s TimeWindow) Contains(t time.Time) bool {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if s.start < s.end {
+		return s.days[day] && minute >= s.start && minute < s.end
+	}
+	// The window ends on the next day.
+	return (s.days[day] && minute >= s.start) || (s.days[(day+6)%7] && minute < s.end)
+}
+
+// SuccessCriterion combines conditions on the result of a probe with boolean
+// logic. A criterion is either a group of criteria, using one of any_of,
+// all_of and none_of, or a set of conditions that all have to hold.
+type SuccessCriterion struct {
+	AnyOf  []SuccessCriterion `yaml:"any_of,omitempty"`
+	AllOf  []SuccessCriterion `yaml:"all_of,omitempty"`
+	NoneOf []SuccessCriterion `yaml:"none_of,omitempty"`
+
+	// Conditions of HTTP probes.
+	StatusCodes       []int        `yaml:"status_codes,omitempty"`
+	HeaderMatches     *HeaderMatch `yaml:"header_matches,omitempty"`
+	BodyMatchesRegexp Regexp       `yaml:"body_matches_regexp,omitempty"`
+
+	// Conditions of DNS probes, the regexps match if any resource record of
+	// the section matches.
+	Rcodes                  []string `yaml:"rcodes,omitempty"`
+	AnswerMatchesRegexp     Regexp   `yaml:"answer_matches_regexp,omitempty"`
+	AuthorityMatchesRegexp  Regexp   `yaml:"authority_matches_regexp,omitempty"`
+	AdditionalMatchesRegexp Regexp   `yaml:"additional_matches_regexp,omitempty"`
+
+	// Conditions of TCP probes, the regexp matches if any line received
+	// from the target matches.
+	ResponseMatchesRegexp Regexp `yaml:"response_matches_regexp,omitempty"`
+}
+
+// Validate checks that each criterion is either a group or a set of
+// conditions, and that the conditions apply to the prober.
+func (c *SuccessCriterion) Validate(prober string) error {
+	groups := 0
+	for _, group := range [][]SuccessCriterion{c.AnyOf, c.AllOf, c.NoneOf} {
+		if len(group) > 0 {
+			groups++
+		}
+		for i := range group {
+			if err := group[i].Validate(prober); err != nil {
+				return err
+			}
+		}
+	}
+
+	conditions := []struct {
+		name   string
+		prober string
+		set    bool
+	}{
+		{"status_codes", "http", len(c.StatusCodes) > 0},
+		{"header_matches", "http", c.HeaderMatches != nil},
+		{"body_matches_regexp", "http", c.BodyMatchesRegexp.Regexp != nil},
+		{"rcodes", "dns", len(c.Rcodes) > 0},
+		{"answer_matches_regexp", "dns", c.AnswerMatchesRegexp.Regexp != nil},
+		{"authority_matches_regexp", "dns", c.AuthorityMatchesRegexp.Regexp != nil},
+		{"additional_matches_regexp", "dns", c.AdditionalMatchesRegexp.Regexp != nil},
+		{"response_matches_regexp", "tcp", c.ResponseMatchesRegexp.Regexp != nil},
+	}
+	hasConditions := false
+	for _, condition := range conditions {
+		if !condition.set {
+			continue
+		}
+		if condition.prober != prober {
+			return fmt.Errorf("success criteria condition '%s' is not supported by the %s prober", condition.name, prober)
+		}
+		hasConditions = true
+	}
+	for _, rcode := range c.Rcodes {
+		if _, ok := dns.StringToRcode[rcode]; !ok {
+			return fmt.Errorf("rcode '%s' is not valid", rcode)
+		}
+	}
+
+	switch {
+	case groups > 1 || (groups == 1 && hasConditions):
+		return errors.New("a success criterion must be either one of any_of, all_of and none_of, or a set of conditions")
+	case groups == 0 && !hasConditions:
+		return errors.New("success criteria cannot be empty")
+	}
+	return nil
+}
+
+type HeaderMatch struct {
+	Header       string `yaml:"header,omitempty"`
+	Regexp       Regexp `yaml:"regexp,omitempty"`
+	AllowMissing bool   `yaml:"allow_missing,omitempty"`
+	// Name identifies the validator in metrics.
+	Name string `yaml:"name,omitempty"`
+}
+
+// JSONMatch checks the values selected in a JSON response body. One of them
+// has to match the regexp, be equal to the value, or have at least
+// min_length elements. Without any of these, a value that is not null only
+// has to be present.
+type JSONMatch struct {
+	JSONPath  JSONPath `yaml:"json_path,omitempty"`
+	Regexp    Regexp   `yaml:"regexp,omitempty"`
+	Value     string   `yaml:"value,omitempty"`
+	MinLength int      `yaml:"min_length,omitempty"`
+	// Name identifies the validator in metrics.
+	Name string `yaml:"name,omitempty"`
+}
+
+type Label struct {
+	Name  string `yaml:"name,omitempty"`
+	Value string `yaml:"value,omitempty"`
+}
+
+type QueryResponse struct {
+	Expect Regexp  `yaml:"expect,omitempty"`
+	Labels []Label `yaml:"labels,omitempty"`
+	Send   string  `yaml:"send,omitempty"`
+	// SendTemplate computes the data to send, e.g. lengths or checksums,
+	// from the match of the expect regexp and the lines received so far.
+	SendTemplate Template `yaml:"send_template,omitempty"`
+	// The binary variants of expect and send, given in hex or base64, are
+	// matched and sent as is, without line endings.
+	ExpectHex    string `yaml:"expect_hex,omitempty"`
+	ExpectBase64 string `yaml:"expect_base64,omitempty"`
+	SendHex      string `yaml:"send_hex,omitempty"`
+	SendBase64   string `yaml:"send_base64,omitempty"`
+	// ExpectPrompt matches the data received before a line ending, such as
+	// the login or command prompts of network devices.
+	ExpectPrompt Regexp `yaml:"expect_prompt,omitempty"`
+	// Delay is waited for before the step.
+	Delay    time.Duration `yaml:"delay,omitempty"`
+	StartTLS bool          `yaml:"starttls,omitempty"`
+	// ReadLimit bounds the bytes read while waiting for the expected data
+	// of the step, 0 meaning no limit.
+	ReadLimit units.Base2Bytes `yaml:"read_limit,omitempty"`
+}
+
+type TCPProbe struct {
+	IPProtocol         string           `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool             `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string           `yaml:"source_ip_address,omitempty"`
+	QueryResponse      []QueryResponse  `yaml:"query_response,omitempty"`
+	TLS                bool             `yaml:"tls,omitempty"`
+	TLSConfig          config.TLSConfig `yaml:"tls_config,omitempty"`
+	TLSSigner          ExternalSigner   `yaml:"tls_signer,omitempty"`
+	// ExpectFailure inverts the probe, it succeeds if the connection fails.
+	ExpectFailure   bool              `yaml:"expect_failure,omitempty"`
+	ExpectRefused   bool              `yaml:"expect_refused,omitempty"`
+	SuccessCriteria *SuccessCriterion `yaml:"success_criteria,omitempty"`
+	// LineEnding is appended to the lines sent: lf, crlf or none.
+	LineEnding string `yaml:"line_ending,omitempty"`
+	// Telnet handles the option negotiation of Telnet servers.
+	Telnet bool `yaml:"telnet,omitempty"`
+	// ValidateTLS is checked on the connection of tls or starttls.
+	ValidateTLS TLSExpectations `yaml:"validate_tls,omitempty"`
+}
+
+// TLSExpectations enforce a crypto policy on the TLS connection of a probe.
+type TLSExpectations struct {
+	// ALPN are offered to the server, which has to select one of them.
+	ALPN []string `yaml:"alpn,omitempty"`
+	// Curves are the only key exchange curves offered to the server.
+	Curves []string `yaml:"curves,omitempty"`
+	// KeyTypes are the accepted types of the key of the certificate of the
+	// server: RSA, ECDSA or Ed25519.
+	KeyTypes        []string `yaml:"key_types,omitempty"`
+	MinRSAKeyBits   int      `yaml:"min_rsa_key_bits,omitempty"`
+	MinECDSAKeyBits int      `yaml:"min_ecdsa_key_bits,omitempty"`
+}
+
+// TLSCurves are the names of the curves of TLSExpectations.
+var TLSCurves = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P-256":  tls.CurveP256,
+	"P-384":  tls.CurveP384,
+	"P-521":  tls.CurveP521,
+}
+
+// IsZero returns whether no expectation is set.
+func (s TLSExpectations) IsZero() bool {
+	return len(s.ALPN) == 0 && len(s.Curves) == 0 && len(s.KeyTypes) == 0 && s.MinRSAKeyBits == 0 && s.MinECDSAKeyBits == 0
+}
+
+func (s TLSExpectations) validate() error {
+	for _, curve := range s.Curves {
+		if _, ok := TLSCurves[curve]; !ok {
+			return fmt.Errorf("curve '%s' is not valid", curve)
+		}
+	}
+	for _, keyType := range s.KeyTypes {
+		if keyType != "RSA" && keyType != "ECDSA" && keyType != "Ed25519" {
+			return fmt.Errorf("key type '%s' is not valid", keyType)
+		}
+	}
+	if s.MinRSAKeyBits < 0 || s.MinECDSAKeyBits < 0 {
+		return errors.New("min_rsa_key_bits and min_ecdsa_key_bits cannot be negative")
+	}
+	return nil
+}
+
+// ExternalSigner signs the TLS handshakes of the client certificate with a
+// command, so that its private key can stay in an HSM or a PKCS#11 token.
+// The command reads the digest to sign on its standard input and writes the
+// signature to its standard output.
+type ExternalSigner struct {
+	Command []string `yaml:"command,omitempty"`
+	// CertificateFile contains the client certificate, followed by its
+	// intermediates.
+	CertificateFile string `yaml:"certificate_file,omitempty"`
+}
+
+// Enabled returns whether the handshakes are signed by a command.
+func (s ExternalSigner) Enabled() bool {
+	return len(s.Command) > 0
+}
+
+// validate checks that the client certificate of tlsConfig is not also set.
+func (s ExternalSigner) validate(tlsConfig config.TLSConfig) error {
+	if !s.Enabled() {
+		return nil
+	}
+	if tlsConfig.Cert != "" || tlsConfig.CertFile != "" || tlsConfig.CertRef != "" ||
+		tlsConfig.Key != "" || tlsConfig.KeyFile != "" || tlsConfig.KeyRef != "" {
+		return errors.New("tls_signer cannot be combined with the client certificate and key of tls_config")
+	}
+	return nil
+}
+
+// ProbeSignature adds an HMAC-SHA256 signature of the requests to a header,
+// so that targets can allowlist genuine probes and reject spoofed ones.
+type ProbeSignature struct {
+	Header string `yaml:"header,omitempty"`
+	// KeyID is sent with the signature, so that targets can accept the old
+	// and the new key while the key is rotated.
+	KeyID string        `yaml:"key_id,omitempty"`
+	Key   config.Secret `yaml:"key,omitempty"`
+	// KeyFile is read by each probe, the key can be rotated without a reload.
+	KeyFile string `yaml:"key_file,omitempty"`
+}
+
+// Enabled returns whether the requests are signed.
+func (s ProbeSignature) Enabled() bool {
+	return s.Key != "" || s.KeyFile != ""
+}
+
+type ICMPProbe struct {
+	IPProtocol         string `yaml:"preferred_ip_protocol,omitempty"` // Defaults to "ip6".
+	IPProtocolFallback bool   `yaml:"ip_protocol_fallback,omitempty"`
+	SourceIPAddress    string `yaml:"source_ip_address,omitempty"`
+	PayloadSize        int    `yaml:"payload_size,omitempty"`
+	DontFragment       bool   `yaml:"dont_fragment,omitempty"`
+	TTL                int    `yaml:"ttl,omitempty"`
+	// PacketCount is the number of echo requests sent by a probe, sent every
+	// PacketInterval, DefaultICMPPacketInterval if 0.
+	PacketCount    int           `yaml:"packet_count,omitempty"`
+	PacketInterval time.Duration `yaml:"packet_interval,omitempty"`
+	// ExpectFailure inverts the probe, it succeeds if no reply is received.
+	ExpectFailure            bool  `yaml:"expect_failure,omitempty"`
+	ExpectedUnreachableCodes []int `yaml:"expected_unreachable_codes,omitempty"`
+}
+
+type DNSProbe struct {
+	IPProtocol         string            `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback bool              `yaml:"ip_protocol_fallback,omitempty"`
+	DNSOverTLS         bool              `yaml:"dns_over_tls,omitempty"`
+	TLSConfig          config.TLSConfig  `yaml:"tls_config,omitempty"`
+	SourceIPAddress    string            `yaml:"source_ip_address,omitempty"`
+	TransportProtocol  string            `yaml:"transport_protocol,omitempty"`
+	Servers            []string          `yaml:"servers,omitempty"`
+	ServerStrategy     string            `yaml:"server_strategy,omitempty"`
+	QueryClass         string            `yaml:"query_class,omitempty"` // Defaults to IN.
+	QueryName          string            `yaml:"query_name,omitempty"`
+	QueryType          string            `yaml:"query_type,omitempty"`        // Defaults to ANY.
+	Recursion          bool              `yaml:"recursion_desired,omitempty"` // Defaults to true.
+	ValidRcodes        []string          `yaml:"valid_rcodes,omitempty"`      // Defaults to NOERROR.
+	ExpectRcode        string            `yaml:"expect_rcode,omitempty"`
+	ValidateAnswer     DNSRRValidator    `yaml:"validate_answer_rrs,omitempty"`
+	ValidateAuthority  DNSRRValidator    `yaml:"validate_authority_rrs,omitempty"`
+	ValidateAdditional DNSRRValidator    `yaml:"validate_additional_rrs,omitempty"`
+	SuccessCriteria    *SuccessCriterion `yaml:"success_criteria,omitempty"`
+	RequestNSID        bool              `yaml:"request_nsid,omitempty"`
+	Trace              DNSTrace          `yaml:"trace,omitempty"`
+}
+
+// DNSTrace resolves the target iteratively from the root servers, following
+// the delegations like a resolver would, to find the level of the hierarchy
+// that is broken for a name. The target is the name to resolve rather than a
+// server.
+type DNSTrace struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// RootHints are the addresses of the servers the resolution starts from,
+	// the root servers if empty.
+	RootHints []string `yaml:"root_hints,omitempty"`
+}
+
+type DNSRRValidator struct {
+	FailIfMatchesRegexp     []string `yaml:"fail_if_matches_regexp,omitempty"`
+	FailIfAllMatchRegexp    []string `yaml:"fail_if_all_match_regexp,omitempty"`
+	FailIfNotMatchesRegexp  []string `yaml:"fail_if_not_matches_regexp,omitempty"`
+	FailIfNoneMatchesRegexp []string `yaml:"fail_if_none_matches_regexp,omitempty"`
+	// Name identifies the validator in metrics.
+	Name string `yaml:"name,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Config
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	for name, module := range s.Modules {
+		s.HTTPDefaults.apply(&module)
+		s.Modules[name] = module
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *HTTPDefaults) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain HTTPDefaults
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	for name := range s.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q in http_defaults", name)
+		}
+		if s.UserAgent != "" && textproto.CanonicalMIMEHeaderKey(name) == "User-Agent" {
+			return errors.New("user_agent cannot be combined with a User-Agent header in http_defaults")
+		}
+	}
+	return nil
+}
+
+// apply sets the defaults on a module of the http prober, keeping what the
+// module sets itself.
+func (d *HTTPDefaults) apply(m *Module) {
+	if d == nil || m.Prober != "http" {
+		return
+	}
+	if m.HTTP.HTTPClientConfig.TLSConfig.MinVersion == 0 {
+		m.HTTP.HTTPClientConfig.TLSConfig.MinVersion = d.TLSMinVersion
+	}
+	// Raw requests are sent as is.
+	if m.HTTP.RawRequest != "" || m.HTTP.RawRequestFile != "" {
+		return
+	}
+	// The map of the module is not modified, it may be shared with the
+	// previous configuration.
+	headers := make(map[string]string, len(m.HTTP.Headers)+len(d.Headers)+1)
+	set := make(map[string]bool, len(m.HTTP.Headers))
+	for name, value := range m.HTTP.Headers {
+		headers[name] = value
+		set[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+	for name, value := range d.Headers {
+		if !set[textproto.CanonicalMIMEHeaderKey(name)] {
+			headers[name] = value
+		}
+	}
+	if d.UserAgent != "" && !set["User-Agent"] {
+		headers["User-Agent"] = d.UserAgent
+	}
+	if len(headers) > 0 {
+		m.HTTP.Headers = headers
+	}
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *Module) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultModule
+	type plain Module
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.DeduplicationWindow < 0 {
+		return errors.New("deduplication_window cannot be negative")
+	}
+	if s.MinInterval < 0 {
+		return errors.New("min_interval cannot be negative")
+	}
+	if s.MaxConcurrent < 0 {
+		return errors.New("max_concurrent cannot be negative")
+	}
+	switch s.ConcurrencyPolicy {
+	case "", "queue", "reject":
+	default:
+		return fmt.Errorf("concurrency_policy %q is not valid, expected queue or reject", s.ConcurrencyPolicy)
+	}
+	if s.Retries < 0 {
+		return errors.New("retries cannot be negative")
+	}
+	if s.RetryInterval < 0 {
+		return errors.New("retry_interval cannot be negative")
+	}
+	if s.Retries > 0 && s.Timeout > 0 {
+		if d := time.Duration(s.Retries) * s.RetryInterval; d >= s.Timeout {
+			return fmt.Errorf("retrying %d times every %s takes longer than the timeout of %s", s.Retries, s.RetryInterval, s.Timeout)
+		}
+	}
+	if s.Prober == "icmp" && s.ICMP.PacketCount > 1 && s.Timeout > 0 {
+		interval := s.ICMP.PacketInterval
+		if interval == 0 {
+			interval = DefaultICMPPacketInterval
+		}
+		if d := time.Duration(s.ICMP.PacketCount-1) * interval; d >= s.Timeout {
+			return fmt.Errorf("sending %d echo requests every %s takes longer than the timeout of %s", s.ICMP.PacketCount, interval, s.Timeout)
+		}
+	}
+	if s.CanaryPercent < 0 || s.CanaryPercent > 100 {
+		return fmt.Errorf("canary_percent %g must be between 0 and 100", s.CanaryPercent)
+	}
+	if s.Prober == "portscan" && len(s.PortScan.Ports.List()) == 0 {
+		return errors.New("ports must be set for portscan module")
+	}
+	if s.Prober == "roughtime" && s.Roughtime.PublicKey == "" {
+		return errors.New("public_key must be set for roughtime module")
+	}
+	if s.Prober == "bgp" && s.BGP.LocalASN == 0 {
+		return errors.New("local_asn must be set for bgp module")
+	}
+	if s.Prober == "snmp" && s.SNMP.Version == 3 && s.SNMP.Username == "" {
+		return errors.New("username must be set for SNMP version 3")
+	}
+	for _, override := range s.URLOverrides {
+		prober, ok := URLOverrideProbers[override]
+		if !ok {
+			return fmt.Errorf("url override '%s' is not valid", override)
+		}
+		if prober != "" && prober != s.Prober {
+			return fmt.Errorf("url override '%s' only applies to the %s prober", override, prober)
+		}
+	}
+	return nil
+}
+
+// URLOverrideProbers maps the fields that can be overridden with URL
+// parameters to the prober they apply to, empty if they apply to all.
+var URLOverrideProbers = map[string]string{
+	"timeout":        "",
+	"headers":        "http",
+	"query_name":     "dns",
+	"query_response": "tcp",
+}
+
+// safeHTTPMethods are the methods that can be used by probes without
+// confirmation. POST is not safe, but has long been used to probe endpoints
+// and is kept for compatibility.
+var safeHTTPMethods = map[string]bool{
+	"GET":      true,
+	"HEAD":     true,
+	"OPTIONS":  true,
+	"TRACE":    true,
+	"POST":     true,
+	"PROPFIND": true,
+	"REPORT":   true,
+	"SEARCH":   true,
+}
+
+// IsUnsafeHTTPMethod returns whether the HTTP method may change the state of
+// the server, such as PUT, PATCH, DELETE or custom methods. Such methods
+// have to be allowed globally and by the module using them.
+func IsUnsafeHTTPMethod(method string) bool {
+	return method != "" && !safeHTTPMethods[method]
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *HTTPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultHTTPProbe
+	type plain HTTPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.ValidateAltSvc.FailIfBroken && !s.ValidateAltSvc.ProbeAlternatives {
+		return errors.New("fail_if_broken of validate_alt_svc requires probe_alternatives")
+	}
+
+	switch s.BodyHash {
+	case "", "md5", "sha1", "sha256", "sha512":
+	default:
+		return fmt.Errorf("body_hash %q is not valid, expected md5, sha1, sha256 or sha512", s.BodyHash)
+	}
+	if s.FailIfBodySHA256NotEquals != "" {
+		if b, err := hex.DecodeString(s.FailIfBodySHA256NotEquals); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("fail_if_body_sha256_not_equals %q is not a hex encoded SHA-256 digest", s.FailIfBodySHA256NotEquals)
+		}
+		s.FailIfBodySHA256NotEquals = strings.ToLower(s.FailIfBodySHA256NotEquals)
+	}
+
+	if s.LongPoll.FirstBytes < 0 {
+		return errors.New("first_bytes of long_poll cannot be negative")
+	}
+	if s.LongPoll.Enabled && (s.BodyHash != "" || s.FailIfBodySHA256NotEquals != "" || s.CrawlAssets.MaxResources > 0) {
+		return errors.New("long_poll cannot be combined with body_hash, fail_if_body_sha256_not_equals or crawl_assets")
+	}
+
+	// BodySizeLimit == 0 means no limit. By leaving it at 0 we
+	// avoid setting up the limiter.
+	if s.BodySizeLimit < 0 || s.BodySizeLimit == math.MaxInt64 {
+		// The implementation behind http.MaxBytesReader tries
+		// to add 1 to the specified limit causing it to wrap
+		// around and become negative, and then it tries to use
+		// that result to index an slice.
+		s.BodySizeLimit = math.MaxInt64 - 1
+	}
+
+	if err := s.HTTPClientConfig.Validate(); err != nil {
+		return err
+	}
+
+	if s.NoFollowRedirects != nil {
+		s.HTTPClientConfig.FollowRedirects = !*s.NoFollowRedirects
+	}
+
+	if s.Body != "" && s.BodyFile != "" {
+		return errors.New("setting body and body_file both are not allowed")
+	}
+
+	if s.SuccessCriteria != nil {
+		if len(s.ValidStatusCodes) > 0 {
+			return errors.New("valid_status_codes cannot be combined with success_criteria")
+		}
+		if err := s.SuccessCriteria.Validate("http"); err != nil {
+			return err
+		}
+	}
+
+	if s.CaptivePortal.Enabled {
+		if len(s.ValidStatusCodes) > 0 || s.SuccessCriteria != nil {
+			return errors.New("captive_portal cannot be combined with valid_status_codes or success_criteria")
+		}
+	}
+
+	if s.WAFCanary.Enabled {
+		if len(s.ValidStatusCodes) > 0 || s.SuccessCriteria != nil || s.CaptivePortal.Enabled {
+			return errors.New("waf_canary cannot be combined with valid_status_codes, success_criteria or captive_portal")
+		}
+	}
+
+	if s.GraphQL.Query != "" {
+		if s.Body != "" || s.BodyFile != "" {
+			return errors.New("graphql cannot be combined with body or body_file")
+		}
+		if s.Method != "" && s.Method != "POST" {
+			return errors.New("graphql queries must use the POST method")
+		}
+	}
+
+	if s.Method != "" && !httpguts.ValidHeaderFieldName(s.Method) {
+		return fmt.Errorf("HTTP method '%s' is not valid", s.Method)
+	}
+
+	if s.Samples < 0 {
+		return fmt.Errorf("samples %d must not be negative", s.Samples)
+	}
+
+	// The body of warm-up requests must be sent again for each of them.
+	if s.WarmupRequests < 0 {
+		return fmt.Errorf("warmup_requests %d must not be negative", s.WarmupRequests)
+	}
+	if s.WarmupRequests > 0 && (s.BodyFile != "" || s.RawRequest != "" || s.RawRequestFile != "") {
+		return errors.New("warmup_requests cannot be combined with body_file or raw_request")
+	}
+
+	if s.TLSHandshakeTimeout < 0 || s.ResponseHeaderTimeout < 0 || s.ExpectContinueTimeout < 0 || s.MaxResponseHeaderBytes < 0 {
+		return errors.New("tls_handshake_timeout, response_header_timeout, expect_continue_timeout and max_response_header_bytes cannot be negative")
+	}
+
+	// Each captured header adds a series to every probe of the module.
+	if len(s.CaptureHeaders) > maxCapturedHeaders {
+		return fmt.Errorf("capture_headers cannot have more than %d headers", maxCapturedHeaders)
+	}
+
+	// insecure_capture verifies the certificates itself, after the handshake.
+	if s.InsecureCapture && s.HTTPClientConfig.TLSConfig.InsecureSkipVerify {
+		return errors.New("insecure_capture cannot be combined with insecure_skip_verify")
+	}
+
+	if err := s.TLSSigner.validate(s.HTTPClientConfig.TLSConfig); err != nil {
+		return err
+	}
+	// The client of the signer is not built from the http_client_config, which
+	// handles authentication.
+	if s.TLSSigner.Enabled() && (s.HTTPClientConfig.BasicAuth != nil || s.HTTPClientConfig.Authorization != nil || s.HTTPClientConfig.OAuth2 != nil) {
+		return errors.New("tls_signer cannot be combined with basic_auth, authorization or oauth2")
+	}
+
+	switch s.HTTPVersion {
+	case "", "1.1", "2", "3":
+	default:
+		return fmt.Errorf("http_version %q is not valid, expected 1.1, 2 or 3", s.HTTPVersion)
+	}
+	if s.HTTPVersion != "" && (s.ForceHTTP10 || s.LenientParsing || s.RawRequest != "" || s.RawRequestFile != "") {
+		return errors.New("http_version cannot be combined with force_http10, lenient_parsing or raw_request")
+	}
+	// HTTP/3 requests are made without the transport of the HTTP client
+	// configuration, only the authentication headers are added.
+	if s.HTTPVersion == "3" && (s.HTTPClientConfig.ProxyURL.URL != nil || s.HTTPClientConfig.ProxyFromEnvironment || s.HTTPClientConfig.OAuth2 != nil || s.TLSSigner.Enabled()) {
+		return errors.New("http_version 3 cannot be combined with a proxy, oauth2 or tls_signer")
+	}
+
+	// Requests are then made without the transport of the HTTP client
+	// configuration, only the authentication headers are added.
+	if (s.ForceHTTP10 || s.LenientParsing || s.RawRequest != "" || s.RawRequestFile != "") && (s.HTTPClientConfig.ProxyURL.URL != nil || s.HTTPClientConfig.ProxyFromEnvironment || s.HTTPClientConfig.OAuth2 != nil || s.TLSSigner.Enabled()) {
+		return errors.New("force_http10, lenient_parsing and raw_request cannot be combined with a proxy, oauth2 or tls_signer")
+	}
+
+	if s.RawRequest != "" || s.RawRequestFile != "" {
+		if s.ProbeSignature.Enabled() {
+			return errors.New("probe_signature cannot be combined with raw_request")
+		}
+		if s.RawRequest != "" && s.RawRequestFile != "" {
+			return errors.New("setting raw_request and raw_request_file both are not allowed")
+		}
+		// The request is sent as is, nothing of the configuration is added.
+		if s.Body != "" || s.BodyFile != "" || s.GraphQL.Query != "" || len(s.Headers) > 0 || s.HTTPClientConfig.BasicAuth != nil || s.HTTPClientConfig.Authorization != nil {
+			return errors.New("raw_request cannot be combined with body, body_file, graphql, headers, basic_auth or authorization")
+		}
+	}
+
+	var names []string
+	for _, regexps := range [][]Regexp{s.FailIfBodyMatchesRegexp, s.FailIfBodyNotMatchesRegexp} {
+		for _, re := range regexps {
+			names = append(names, re.Name())
+		}
+	}
+	for _, matchers := range [][]HeaderMatch{s.FailIfHeaderMatchesRegexp, s.FailIfHeaderNotMatchesRegexp} {
+		for _, m := range matchers {
+			names = append(names, m.Name)
+		}
+	}
+	for _, m := range s.FailIfBodyJSONNotMatches {
+		names = append(names, m.Name)
+	}
+	if err := checkValidatorNames(names); err != nil {
+		return err
+	}
+
+	for key, value := range s.Headers {
+		switch textproto.CanonicalMIMEHeaderKey(key) {
+		case "Accept-Encoding":
+			if !isCompressionAcceptEncodingValid(s.Compression, value) {
+				return fmt.Errorf(`invalid configuration "%s: %s", "compression: %s"`, key, value, s.Compression)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *GRPCProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultGRPCProbe
+	type plain GRPCProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	switch s.Protocol {
+	case "grpc", "grpc-web", "connect":
+	default:
+		return fmt.Errorf("gRPC protocol '%s' is not valid", s.Protocol)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ProxyProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultProxyProbe
+	type plain ProxyProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if len(s.Regions) == 0 {
+		return errors.New("at least one region must be set for proxy module")
+	}
+	for region, exporterURL := range s.Regions {
+		u, err := url.Parse(exporterURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("URL '%s' of region '%s' is not valid", exporterURL, region)
+		}
+	}
+	if s.Region != "all" {
+		for _, region := range strings.Split(s.Region, ",") {
+			region = strings.TrimSpace(region)
+			if _, ok := s.Regions[region]; region != "" && !ok {
+				return fmt.Errorf("default region '%s' is not configured", region)
+			}
+		}
+	}
+	if s.MinSuccessfulRegions < 0 || s.MinSuccessfulRegions > len(s.Regions) {
+		return fmt.Errorf("min_successful_regions must be between 0 and the number of regions (%d)", len(s.Regions))
+	}
+	if s.Module == "" {
+		return errors.New("module must be set for proxy module")
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *OIDCProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultOIDCProbe
+	type plain OIDCProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.ClientID == "" && (s.ClientSecret != "" || len(s.Scopes) > 0) {
+		return errors.New("client_secret and scopes require client_id to be set")
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *PortScanProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultPortScanProbe
+	type plain PortScanProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.ConnectTimeout <= 0 {
+		return errors.New("connect_timeout must be positive")
+	}
+	if s.Concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *RoughtimeProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultRoughtimeProbe
+	type plain RoughtimeProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.PublicKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(s.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return errors.New("public_key is not a base64 encoded Ed25519 public key")
+		}
+	}
+	if s.MaxOffset < 0 {
+		return errors.New("max_offset cannot be negative")
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *BGPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultBGPProbe
+	type plain BGPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.RouterID != "" {
+		if ip := net.ParseIP(s.RouterID); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("router_id '%s' is not an IPv4 address", s.RouterID)
+		}
+	}
+	if s.HoldTime != 0 && (s.HoldTime < 3*time.Second || s.HoldTime > 65535*time.Second) {
+		return errors.New("hold_time must be 0s, or between 3s and 65535s")
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *NFSProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultNFSProbe
+	type plain NFSProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Version < 2 || s.Version > 4 {
+		return fmt.Errorf("NFS version %d is not supported", s.Version)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SMBProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultSMBProbe
+	type plain SMBProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if len(s.Dialects) == 0 {
+		return errors.New("dialects cannot be empty for smb probes")
+	}
+	for _, dialect := range s.Dialects {
+		if !smbDialects[dialect] {
+			return fmt.Errorf("SMB dialect '%s' is not supported", dialect)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *EtcdProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultEtcdProbe
+	type plain EtcdProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Key == "" {
+		return errors.New("key cannot be empty for etcd probes")
+	}
+	if s.Password != "" && s.Username == "" {
+		return errors.New("password requires username to be set")
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ZooKeeperProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultZooKeeperProbe
+	type plain ZooKeeperProbe
+	return unmarshal((*plain)(s))
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ConsulProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultConsulProbe
+	type plain ConsulProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.MinPeers < 0 {
+		return errors.New("min_peers cannot be negative")
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *GameServerProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultGameServerProbe
+	type plain GameServerProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Protocol != "a2s" && s.Protocol != "quake3" {
+		return fmt.Errorf("game server query protocol '%s' is not supported", s.Protocol)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *IPPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultIPPProbe
+	type plain IPPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	for _, state := range s.ValidPrinterStates {
+		if state != "idle" && state != "processing" && state != "stopped" {
+			return fmt.Errorf("printer state '%s' is not valid", state)
+		}
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SMTPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultSMTPProbe
+	type plain SMTPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if strings.ContainsAny(s.EHLODomain, " \r\n") || s.EHLODomain == "" {
+		return fmt.Errorf("ehlo_domain %q is not valid", s.EHLODomain)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SSEProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultSSEProbe
+	type plain SSEProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Events < 1 {
+		return fmt.Errorf("events %d must be at least 1", s.Events)
+	}
+	return s.HTTPClientConfig.Validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *TracerouteProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultTracerouteProbe
+	type plain TracerouteProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Protocol != "icmp" && s.Protocol != "udp" {
+		return fmt.Errorf("protocol '%s' is not valid, expected icmp or udp", s.Protocol)
+	}
+	if s.MaxHops < 1 || s.MaxHops > 255 {
+		return fmt.Errorf("max_hops %d must be between 1 and 255", s.MaxHops)
+	}
+	if s.Port < 1 || s.Port+s.MaxHops-1 > 65535 {
+		return fmt.Errorf("port %d leaves no room for the ports of %d hops", s.Port, s.MaxHops)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *NDPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultNDPProbe
+	type plain NDPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Solicitation != "router" && s.Solicitation != "neighbor" {
+		return fmt.Errorf("solicitation '%s' is not valid, expected router or neighbor", s.Solicitation)
+	}
+	for _, prefix := range s.ExpectedPrefixes {
+		ip, _, err := net.ParseCIDR(prefix)
+		if err != nil || ip.To4() != nil {
+			return fmt.Errorf("expected prefix %q is not an IPv6 prefix", prefix)
+		}
+	}
+	if s.Solicitation == "neighbor" && (len(s.ExpectedPrefixes) > 0 || s.ExpectManaged != nil || s.ExpectOtherConfig != nil || s.MinRouterLifetime != 0) {
+		return errors.New("expected_prefixes, expect_managed, expect_other_config and min_router_lifetime only apply to router solicitations")
+	}
+	if s.MinRouterLifetime < 0 {
+		return errors.New("min_router_lifetime cannot be negative")
+	}
+	return nil
+}
+
+var snmpOIDRE = regexp.MustCompile(`^[0-2](\.[0-9]+)+$`)
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SNMPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultSNMPProbe
+	type plain SNMPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Version != 2 && s.Version != 3 {
+		return fmt.Errorf("SNMP version %d is not supported", s.Version)
+	}
+	if !snmpOIDRE.MatchString(s.OID) {
+		return fmt.Errorf("oid '%s' is not valid", s.OID)
+	}
+	if s.ValueMin != nil && s.ValueMax != nil && *s.ValueMin > *s.ValueMax {
+		return errors.New("value_min cannot be greater than value_max")
+	}
+	switch s.SecurityLevel {
+	case "noAuthNoPriv":
+	case "authNoPriv", "authPriv":
+		if s.AuthPassword == "" {
+			return fmt.Errorf("auth_password must be set for security level %s", s.SecurityLevel)
+		}
+	default:
+		return fmt.Errorf("security level '%s' is not valid", s.SecurityLevel)
+	}
+	if s.SecurityLevel == "authPriv" && s.PrivPassword == "" {
+		return errors.New("priv_password must be set for security level authPriv")
+	}
+	switch s.AuthProtocol {
+	case "MD5", "SHA", "SHA256":
+	default:
+		return fmt.Errorf("auth protocol '%s' is not supported", s.AuthProtocol)
+	}
+	if s.PrivProtocol != "AES" {
+		return fmt.Errorf("priv protocol '%s' is not supported", s.PrivProtocol)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *DNSProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultDNSProbe
+	type plain DNSProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Trace.Enabled {
+		if s.QueryName != "" {
+			return errors.New("query name cannot be set with trace, the target is the name to resolve")
+		}
+		if len(s.Servers) > 0 || s.DNSOverTLS || s.TransportProtocol == "unix" || s.TransportProtocol == "tls" || s.TransportProtocol == "doh" {
+			return errors.New("trace cannot be combined with servers, dns_over_tls or transport protocol unix, tls or doh")
+		}
+		for _, hint := range s.Trace.RootHints {
+			host := hint
+			if h, _, err := net.SplitHostPort(hint); err == nil {
+				host = h
+			}
+			if net.ParseIP(host) == nil {
+				return fmt.Errorf("root hint %q is not an IP address", hint)
+			}
+		}
+	} else if s.QueryName == "" {
+		return errors.New("query name must be set for DNS module")
+	}
+	if s.QueryClass != "" {
+		if _, ok := dns.StringToClass[s.QueryClass]; !ok {
+			return fmt.Errorf("query class '%s' is not valid", s.QueryClass)
+		}
+	}
+	if s.QueryType != "" {
+		if _, ok := dns.StringToType[s.QueryType]; !ok {
+			return fmt.Errorf("query type '%s' is not valid", s.QueryType)
+		}
+	}
+	if s.TransportProtocol == "unix" && (s.DNSOverTLS || s.SourceIPAddress != "") {
+		return errors.New("transport protocol unix cannot be combined with dns_over_tls or source_ip_address")
+	}
+	if s.TransportProtocol == "doh" && s.DNSOverTLS {
+		return errors.New("transport protocol doh cannot be combined with dns_over_tls")
+	}
+	if s.ServerStrategy != "failover" && s.ServerStrategy != "parallel" {
+		return fmt.Errorf("server strategy '%s' is not valid", s.ServerStrategy)
+	}
+	for _, server := range s.Servers {
+		if server == "" {
+			return errors.New("DNS servers cannot be empty")
+		}
+	}
+	if s.ExpectRcode != "" {
+		if _, ok := dns.StringToRcode[s.ExpectRcode]; !ok {
+			return fmt.Errorf("rcode '%s' is not valid", s.ExpectRcode)
+		}
+		if len(s.ValidRcodes) > 0 || s.SuccessCriteria != nil {
+			return errors.New("expect_rcode cannot be combined with valid_rcodes or success_criteria")
+		}
+	}
+	if s.SuccessCriteria != nil {
+		if len(s.ValidRcodes) > 0 {
+			return errors.New("valid_rcodes cannot be combined with success_criteria")
+		}
+		if err := s.SuccessCriteria.Validate("dns"); err != nil {
+			return err
+		}
+	}
+	if err := checkValidatorNames([]string{s.ValidateAnswer.Name, s.ValidateAuthority.Name, s.ValidateAdditional.Name}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *TCPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultTCPProbe
+	type plain TCPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.ExpectRefused && !s.ExpectFailure {
+		return errors.New("expect_refused requires expect_failure to be set")
+	}
+	if err := s.TLSSigner.validate(s.TLSConfig); err != nil {
+		return err
+	}
+	if s.ExpectFailure && (s.TLS || len(s.QueryResponse) > 0) {
+		return errors.New("expect_failure cannot be combined with tls or query_response")
+	}
+	switch s.LineEnding {
+	case "", "lf", "crlf", "none":
+	default:
+		return fmt.Errorf("line ending '%s' is not valid", s.LineEnding)
+	}
+	if s.Telnet {
+		for _, qr := range s.QueryResponse {
+			if qr.StartTLS {
+				return errors.New("telnet cannot be combined with starttls")
+			}
+		}
+	}
+	if err := s.ValidateTLS.validate(); err != nil {
+		return err
+	}
+	if !s.ValidateTLS.IsZero() && !s.TLS && !slices.ContainsFunc(s.QueryResponse, func(qr QueryResponse) bool { return qr.StartTLS }) {
+		return errors.New("validate_tls requires tls or a starttls step in query_response")
+	}
+	if s.SuccessCriteria != nil {
+		if s.ExpectFailure {
+			return errors.New("expect_failure cannot be combined with success_criteria")
+		}
+		if err := s.SuccessCriteria.Validate("tcp"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *DNSRRValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain DNSRRValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Enabled returns true if any of the HSTS requirements is set.
+func (s *HSTSValidator) Enabled() bool {
+	return s.FailIfMaxAgeBelow > 0 || s.FailIfNotIncludeSubdomains || s.FailIfNotPreload
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ICMPProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = DefaultICMPProbe
+	type plain ICMPProbe
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" && s.DontFragment {
 		return errors.New("\"dont_fragment\" is not supported on windows platforms")
 	}
 
-	if s.TTL < 0 {
-		return errors.New("\"ttl\" cannot be negative")
+	if s.TTL < 0 {
+		return errors.New("\"ttl\" cannot be negative")
+	}
+	if s.TTL > 255 {
+		return errors.New("\"ttl\" cannot exceed 255")
+	}
+
+	if s.PacketCount < 0 {
+		return errors.New("\"packet_count\" cannot be negative")
+	}
+	if s.PacketInterval < 0 {
+		return errors.New("\"packet_interval\" cannot be negative")
+	}
+	if s.PacketCount > 1 && s.ExpectFailure {
+		return errors.New("\"packet_count\" cannot be combined with \"expect_failure\"")
+	}
+
+	if len(s.ExpectedUnreachableCodes) > 0 && !s.ExpectFailure {
+		return errors.New("\"expected_unreachable_codes\" requires \"expect_failure\" to be set")
+	}
+	for _, code := range s.ExpectedUnreachableCodes {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("\"expected_unreachable_codes\" contains invalid code %d", code)
+		}
+	}
+	return nil
+}
+
+// countSet returns the number of options that are set.
+func countSet(options ...bool) int {
+	n := 0
+	for _, set := range options {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *QueryResponse) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain QueryResponse
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
 	}
-	if s.TTL > 255 {
-		return errors.New("\"ttl\" cannot exceed 255")
+	if s.Send != "" && !s.SendTemplate.IsZero() {
+		return errors.New("send and send_template cannot both be set")
+	}
+	if countSet(s.Expect.Regexp != nil, s.ExpectPrompt.Regexp != nil, s.ExpectHex != "", s.ExpectBase64 != "") > 1 {
+		return errors.New("only one of expect, expect_prompt, expect_hex and expect_base64 can be set")
+	}
+	if s.Delay < 0 {
+		return errors.New("delay cannot be negative")
+	}
+	if s.ReadLimit < 0 {
+		return errors.New("read_limit cannot be negative")
+	}
+	if countSet(s.Send != "" || !s.SendTemplate.IsZero(), s.SendHex != "", s.SendBase64 != "") > 1 {
+		return errors.New("only one of send, send_hex and send_base64 can be set")
+	}
+	if _, err := hex.DecodeString(strings.Join(strings.Fields(s.ExpectHex), "")); err != nil {
+		return fmt.Errorf("expect_hex is not valid: %s", err)
 	}
+	if _, err := hex.DecodeString(strings.Join(strings.Fields(s.SendHex), "")); err != nil {
+		return fmt.Errorf("send_hex is not valid: %s", err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(s.ExpectBase64); err != nil {
+		return fmt.Errorf("expect_base64 is not valid: %s", err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(s.SendBase64); err != nil {
+		return fmt.Errorf("send_base64 is not valid: %s", err)
+	}
+
 	return nil
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (s *QueryResponse) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	type plain QueryResponse
+func (s *CrawlConfigValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CrawlConfigValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	switch s.Format {
+	case "robots_txt", "sitemap":
+	case "":
+		if s.FailIfOlderThan != 0 || s.FailIfURLCountBelow != 0 {
+			return errors.New("format must be set to validate a crawl configuration")
+		}
+	default:
+		return fmt.Errorf("crawl configuration format '%s' is not valid", s.Format)
+	}
+	if s.FailIfURLCountBelow < 0 {
+		return errors.New("fail_if_url_count_below cannot be negative")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SAMLMetadataValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SAMLMetadataValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.FailIfCertExpiresWithin != 0 && !s.Enabled {
+		return errors.New("fail_if_cert_expires_within requires validate_saml_metadata to be enabled")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *GraphQLQuery) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GraphQLQuery
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Query == "" {
+		return errors.New("query must be set for graphql")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *GraphQLAssertion) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GraphQLAssertion
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Path.IsZero() {
+		return errors.New("path must be set for graphql assertions")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *JSONMatch) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain JSONMatch
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.JSONPath.IsZero() {
+		return errors.New("json_path must be set for fail_if_body_json_not_matches")
+	}
+	if s.MinLength < 0 {
+		return fmt.Errorf("min_length %d must not be negative", s.MinLength)
+	}
+	set := 0
+	for _, ok := range []bool{s.Regexp.Regexp != nil, s.Value != "", s.MinLength > 0} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("only one of regexp, value and min_length can be set for fail_if_body_json_not_matches")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *JWTValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain JWTValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Header != "" && !s.JSONPath.IsZero() {
+		return errors.New("header and json_path cannot both be set for validate_jwt")
+	}
+	if !s.Enabled() {
+		return errors.New("header or json_path must be set for validate_jwt")
+	}
+	u, err := url.Parse(s.JWKSURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("jwks_url '%s' is not valid", s.JWKSURL)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *HealthJSONValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain HealthJSONValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.FailIfWarn && !s.Enabled {
+		return errors.New("fail_if_warn requires validate_health_json to be enabled")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *CaptivePortalCheck) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CaptivePortalCheck
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if !s.Enabled {
+		if s.ExpectedStatusCode != 0 || s.ExpectedBody != "" {
+			return errors.New("captive_portal settings require it to be enabled")
+		}
+		return nil
+	}
+	// The check of Android, which expects an empty response.
+	if s.ExpectedStatusCode == 0 {
+		s.ExpectedStatusCode = http.StatusNoContent
+	}
+	if s.ExpectedStatusCode < 100 || s.ExpectedStatusCode > 599 {
+		return fmt.Errorf("expected_status_code %d is not a valid HTTP status code", s.ExpectedStatusCode)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *WAFCanary) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain WAFCanary
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if !s.Enabled {
+		if len(s.QueryParams) > 0 || len(s.ExpectedStatusCodes) > 0 || len(s.Layers) > 0 {
+			return errors.New("waf_canary settings require it to be enabled")
+		}
+		return nil
+	}
+	// Blocked, and rate limited.
+	if len(s.ExpectedStatusCodes) == 0 {
+		s.ExpectedStatusCodes = []int{http.StatusForbidden, http.StatusTooManyRequests}
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *MaintenanceSignal) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain MaintenanceSignal
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.HeaderRegexp.Regexp != nil && s.Header == "" {
+		return errors.New("header_regexp requires header to be set for maintenance")
+	}
+	if s.JSONRegexp.Regexp != nil && s.JSONPath.IsZero() {
+		return errors.New("json_regexp requires json_path to be set for maintenance")
+	}
+	// A status code alone cannot tell maintenance from outages.
+	if s.Header == "" && s.JSONPath.IsZero() && len(s.StatusCodes) > 0 {
+		return errors.New("maintenance requires header or json_path to be set")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ExternalSigner) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ExternalSigner
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Enabled() != (s.CertificateFile != "") {
+		return errors.New("command and certificate_file must both be set for tls_signer")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ProbeSignature) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ProbeSignature
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Key != "" && s.KeyFile != "" {
+		return errors.New("key and key_file are mutually exclusive for probe_signature")
+	}
+	if !s.Enabled() {
+		return errors.New("key or key_file must be set for probe_signature")
+	}
+	if s.Header == "" {
+		s.Header = "X-Blackbox-Signature"
+	}
+	if !httpguts.ValidHeaderFieldName(s.Header) {
+		return fmt.Errorf("invalid header name %q for probe_signature", s.Header)
+	}
+	if strings.ContainsAny(s.KeyID, ",=") {
+		return fmt.Errorf("key_id %q of probe_signature cannot contain ',' or '='", s.KeyID)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (n *NodeIdentification) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain NodeIdentification
+	if err := unmarshal((*plain)(n)); err != nil {
+		return err
+	}
+
+	if n.HeaderRegexp.Regexp != nil && n.Header == "" {
+		return errors.New("header_regexp requires header to be set for identify_node")
+	}
+	if n.Header != "" && n.BodyRegexp.Regexp != nil {
+		return errors.New("identify_node cannot have both header and body_regexp set")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *BodyCanonicalization) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BodyCanonicalization
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.CanonicalJSON && s.StripHTMLTags {
+		return errors.New("canonical_json cannot be combined with strip_html_tags")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *BodyComparison) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BodyComparison
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if !s.Enabled {
+		if s.GoldenFile != "" || s.FailIfChanged || s.MinSimilarity != 0 {
+			return errors.New("compare_body settings require it to be enabled")
+		}
+		return nil
+	}
+	if s.MinSimilarity < 0 || s.MinSimilarity > 1 {
+		return fmt.Errorf("min_similarity %g must be between 0 and 1", s.MinSimilarity)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *WAFLayer) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain WAFLayer
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Name == "" || s.Header == "" || s.Regexp.Regexp == nil {
+		return errors.New("name, header and regexp must be set for waf_canary layers")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *AssetCrawlConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain AssetCrawlConfig
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.MaxResources < 0 {
+		return errors.New("max_resources cannot be negative")
+	}
+	if s.FailIfBroken && s.MaxResources == 0 {
+		return errors.New("fail_if_broken requires max_resources to be set")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SecurityHeadersPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SecurityHeadersPolicy
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.FailIfScoreBelow < 0 || s.FailIfScoreBelow > 1 {
+		return errors.New("fail_if_score_below must be between 0 and 1")
+	}
+	if s.FailIfScoreBelow > 0 && len(s.Rules) == 0 {
+		return errors.New("fail_if_score_below requires at least one security header rule")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *SecurityHeaderRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	s.Weight = 1
+	type plain SecurityHeaderRule
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Header == "" {
+		return errors.New("header name must be set for security header rules")
+	}
+	if s.Weight < 0 {
+		return errors.New("weight of security header rules cannot be negative")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ProxyChainValidator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ProxyChainValidator
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	if len(s.MustTraverse) > 0 || len(s.MustNotTraverse) > 0 {
+		s.Enabled = true
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ProxyHopMatch) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ProxyHopMatch
 	if err := unmarshal((*plain)(s)); err != nil {
 		return err
 	}
 
+	if s.Regexp.Regexp == nil {
+		return errors.New("regexp must be set for proxy hop matchers")
+	}
+
+	return nil
+}
+
+// checkValidatorNames checks that the names given to the validators of a
+// probe, which label probe_validator_success, are unique.
+func checkValidatorNames(names []string) error {
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return fmt.Errorf("validator name '%s' is used more than once", name)
+		}
+		seen[name] = true
+	}
 	return nil
 }
 
@@ -14,10 +14,18 @@
 package config
 
 import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -100,6 +108,342 @@ func TestLoadBadConfigs(t *testing.T) {
 			input: "testdata/invalid-http-body-config.yml",
 			want:  `error parsing config file: setting body and body_file both are not allowed`,
 		},
+		{
+			input: "testdata/invalid-http-security-headers-score.yml",
+			want:  `error parsing config file: fail_if_score_below must be between 0 and 1`,
+		},
+		{
+			input: "testdata/invalid-http-security-headers-rule.yml",
+			want:  `error parsing config file: header name must be set for security header rules`,
+		},
+		{
+			input: "testdata/invalid-http-crawl-config-format.yml",
+			want:  `error parsing config file: crawl configuration format 'humans_txt' is not valid`,
+		},
+		{
+			input: "testdata/invalid-http-crawl-assets.yml",
+			want:  `error parsing config file: fail_if_broken requires max_resources to be set`,
+		},
+		{
+			input: "testdata/invalid-grpc-protocol.yml",
+			want:  `error parsing config file: gRPC protocol 'grpcs' is not valid`,
+		},
+		{
+			input: "testdata/invalid-tcp-expect-refused.yml",
+			want:  `error parsing config file: expect_refused requires expect_failure to be set`,
+		},
+		{
+			input: "testdata/invalid-icmp-unreachable-codes.yml",
+			want:  `error parsing config file: "expected_unreachable_codes" contains invalid code 256`,
+		},
+		{
+			input: "testdata/invalid-http-graphql-path.yml",
+			want:  `error parsing config file: JSONPath 'data.viewer.id' must start with '$'`,
+		},
+		{
+			input: "testdata/invalid-http-health-json.yml",
+			want:  `error parsing config file: fail_if_warn requires validate_health_json to be enabled`,
+		},
+		{
+			input: "testdata/invalid-http-proxy-hop-regexp.yml",
+			want:  `error parsing config file: regexp must be set for proxy hop matchers`,
+		},
+		{
+			input: "testdata/invalid-http-waf-canary-layer.yml",
+			want:  `error parsing config file: name, header and regexp must be set for waf_canary layers`,
+		},
+		{
+			input: "testdata/invalid-http-maintenance.yml",
+			want:  `error parsing config file: maintenance requires header or json_path to be set`,
+		},
+		{
+			input: "testdata/invalid-http-compare-body.yml",
+			want:  `error parsing config file: min_similarity 90 must be between 0 and 1`,
+		},
+		{
+			input: "testdata/invalid-http-canonicalize-body.yml",
+			want:  `error parsing config file: canonical_json cannot be combined with strip_html_tags`,
+		},
+		{
+			input: "testdata/invalid-http-samples.yml",
+			want:  `error parsing config file: samples -1 must not be negative`,
+		},
+		{
+			input: "testdata/invalid-dns-unix-dot.yml",
+			want:  `error parsing config file: transport protocol unix cannot be combined with dns_over_tls or source_ip_address`,
+		},
+		{
+			input: "testdata/invalid-http-transport-timeout.yml",
+			want:  `error parsing config file: tls_handshake_timeout, response_header_timeout, expect_continue_timeout and max_response_header_bytes cannot be negative`,
+		},
+		{
+			input: "testdata/invalid-tcp-query-response-read-limit.yml",
+			want:  `error parsing config file: read_limit cannot be negative`,
+		},
+		{
+			input: "testdata/invalid-http-identify-node.yml",
+			want:  `error parsing config file: identify_node cannot have both header and body_regexp set`,
+		},
+		{
+			input: "testdata/invalid-tcp-tls-signer.yml",
+			want:  `error parsing config file: command and certificate_file must both be set for tls_signer`,
+		},
+		{
+			input: "testdata/invalid-http-tls-signer-auth.yml",
+			want:  `error parsing config file: tls_signer cannot be combined with basic_auth, authorization or oauth2`,
+		},
+		{
+			input: "testdata/invalid-http-capture-headers.yml",
+			want:  `error parsing config file: capture_headers cannot have more than 10 headers`,
+		},
+		{
+			input: "testdata/invalid-http-insecure-capture.yml",
+			want:  `error parsing config file: insecure_capture cannot be combined with insecure_skip_verify`,
+		},
+		{
+			input: "testdata/invalid-grafana-annotations.yml",
+			want:  `error parsing config file: url must be set for grafana_annotations`,
+		},
+		{
+			input: "testdata/invalid-dns-trace.yml",
+			want:  `error parsing config file: root hint "a.root-servers.net" is not an IP address`,
+		},
+		{
+			input: "testdata/invalid-dns-trace-tls.yml",
+			want:  `error parsing config file: trace cannot be combined with servers, dns_over_tls or transport protocol unix, tls or doh`,
+		},
+		{
+			input: "testdata/invalid-dns-doh-dot.yml",
+			want:  `error parsing config file: transport protocol doh cannot be combined with dns_over_tls`,
+		},
+		{
+			input: "testdata/invalid-http-version.yml",
+			want:  `error parsing config file: http_version "1.0" is not valid, expected 1.1, 2 or 3`,
+		},
+		{
+			input: "testdata/invalid-http3-proxy.yml",
+			want:  `error parsing config file: http_version 3 cannot be combined with a proxy, oauth2 or tls_signer`,
+		},
+		{
+			input: "testdata/invalid-url-overrides.yml",
+			want:  `error parsing config file: url override 'query_name' only applies to the dns prober`,
+		},
+		{
+			input: "testdata/invalid-concurrency-policy.yml",
+			want:  `error parsing config file: concurrency_policy "drop" is not valid, expected queue or reject`,
+		},
+		{
+			input: "testdata/invalid-icmp-packet-count.yml",
+			want:  `error parsing config file: sending 10 echo requests every 1s takes longer than the timeout of 5s`,
+		},
+		{
+			input: "testdata/invalid-http-warmup-body-file.yml",
+			want:  `error parsing config file: warmup_requests cannot be combined with body_file or raw_request`,
+		},
+		{
+			input: "testdata/invalid-retries.yml",
+			want:  `error parsing config file: retrying 3 times every 2s takes longer than the timeout of 5s`,
+		},
+		{
+			input: "testdata/invalid-http-alt-svc.yml",
+			want:  `error parsing config file: fail_if_broken of validate_alt_svc requires probe_alternatives`,
+		},
+		{
+			input: "testdata/invalid-http-body-json.yml",
+			want:  `error parsing config file: only one of regexp, value and min_length can be set for fail_if_body_json_not_matches`,
+		},
+		{
+			input: "testdata/invalid-http-body-sha256.yml",
+			want:  `error parsing config file: fail_if_body_sha256_not_equals "b23467a1fd16b02ba38671df2c15e1d7" is not a hex encoded SHA-256 digest`,
+		},
+		{
+			input: "testdata/invalid-sse-events.yml",
+			want:  `error parsing config file: events 0 must be at least 1`,
+		},
+		{
+			input: "testdata/invalid-traceroute-protocol.yml",
+			want:  `error parsing config file: protocol 'tcp' is not valid, expected icmp or udp`,
+		},
+		{
+			input: "testdata/invalid-http-long-poll-hash.yml",
+			want:  `error parsing config file: long_poll cannot be combined with body_hash, fail_if_body_sha256_not_equals or crawl_assets`,
+		},
+		{
+			input: "testdata/invalid-min-interval.yml",
+			want:  `error parsing config file: min_interval cannot be negative`,
+		},
+		{
+			input: "testdata/invalid-dns-expect-rcode.yml",
+			want:  `error parsing config file: expect_rcode cannot be combined with valid_rcodes or success_criteria`,
+		},
+		{
+			input: "testdata/invalid-http-lenient-parsing.yml",
+			want:  `error parsing config file: force_http10, lenient_parsing and raw_request cannot be combined with a proxy, oauth2 or tls_signer`,
+		},
+		{
+			input: "testdata/invalid-http-raw-request.yml",
+			want:  `error parsing config file: raw_request cannot be combined with body, body_file, graphql, headers, basic_auth or authorization`,
+		},
+		{
+			input: "testdata/invalid-http-defaults.yml",
+			want:  `error parsing config file: user_agent cannot be combined with a User-Agent header in http_defaults`,
+		},
+		{
+			input: "testdata/invalid-http-probe-signature.yml",
+			want:  `error parsing config file: key and key_file are mutually exclusive for probe_signature`,
+		},
+		{
+			input: "testdata/invalid-http-jwt-source.yml",
+			want:  `error parsing config file: header or json_path must be set for validate_jwt`,
+		},
+		{
+			input: "testdata/invalid-http-saml-metadata.yml",
+			want:  `error parsing config file: fail_if_cert_expires_within requires validate_saml_metadata to be enabled`,
+		},
+		{
+			input: "testdata/invalid-http-captive-portal.yml",
+			want:  `error parsing config file: captive_portal cannot be combined with valid_status_codes or success_criteria`,
+		},
+		{
+			input: "testdata/invalid-http-validator-name.yml",
+			want:  `error parsing config file: validator name 'login_form' is used more than once`,
+		},
+		{
+			input: "testdata/invalid-tcp-send-template.yml",
+			want:  `error parsing config file: send and send_template cannot both be set`,
+		},
+		{
+			input: "testdata/invalid-tcp-send-hex.yml",
+			want:  `error parsing config file: send_hex is not valid: encoding/hex: odd length hex string`,
+		},
+		{
+			input: "testdata/invalid-tcp-expect-binary.yml",
+			want:  `error parsing config file: only one of expect, expect_prompt, expect_hex and expect_base64 can be set`,
+		},
+		{
+			input: "testdata/invalid-tcp-line-ending.yml",
+			want:  `error parsing config file: line ending 'cr' is not valid`,
+		},
+		{
+			input: "testdata/invalid-tcp-telnet-starttls.yml",
+			want:  `error parsing config file: telnet cannot be combined with starttls`,
+		},
+		{
+			input: "testdata/invalid-http-success-criteria.yml",
+			want:  `error parsing config file: success criteria condition 'rcodes' is not supported by the http prober`,
+		},
+		{
+			input: "testdata/invalid-tcp-validate-tls.yml",
+			want:  `error parsing config file: validate_tls requires tls or a starttls step in query_response`,
+		},
+		{
+			input: "testdata/invalid-tcp-validate-tls-curve.yml",
+			want:  `error parsing config file: curve 'P-224' is not valid`,
+		},
+		{
+			input: "testdata/invalid-tcp-success-criteria.yml",
+			want:  `error parsing config file: a success criterion must be either one of any_of, all_of and none_of, or a set of conditions`,
+		},
+		{
+			input: "testdata/invalid-http-method.yml",
+			want:  `error parsing config file: HTTP method 'GET /' is not valid`,
+		},
+		{
+			input: "testdata/invalid-http-unsafe-method.yml",
+			want:  `module http_patch uses the unsafe HTTP method PATCH, which requires --http.allow-unsafe-methods`,
+		},
+		{
+			input: "testdata/invalid-portscan-ports.yml",
+			want:  `error parsing config file: port range '443-80' is not valid`,
+		},
+		{
+			input: "testdata/invalid-portscan-no-ports.yml",
+			want:  `error parsing config file: ports must be set for portscan module`,
+		},
+		{
+			input: "testdata/invalid-roughtime-public-key.yml",
+			want:  `error parsing config file: public_key is not a base64 encoded Ed25519 public key`,
+		},
+		{
+			input: "testdata/invalid-roughtime-no-public-key.yml",
+			want:  `error parsing config file: public_key must be set for roughtime module`,
+		},
+		{
+			input: "testdata/invalid-snmp-version.yml",
+			want:  `error parsing config file: SNMP version 1 is not supported`,
+		},
+		{
+			input: "testdata/invalid-snmp-v3-username.yml",
+			want:  `error parsing config file: username must be set for SNMP version 3`,
+		},
+		{
+			input: "testdata/invalid-oidc-client-secret.yml",
+			want:  `error parsing config file: client_secret and scopes require client_id to be set`,
+		},
+		{
+			input: "testdata/invalid-bgp-local-asn.yml",
+			want:  `error parsing config file: local_asn must be set for bgp module`,
+		},
+		{
+			input: "testdata/invalid-bgp-router-id.yml",
+			want:  `error parsing config file: router_id '2001:db8::1' is not an IPv4 address`,
+		},
+		{
+			input: "testdata/invalid-nfs-version.yml",
+			want:  `error parsing config file: NFS version 5 is not supported`,
+		},
+		{
+			input: "testdata/invalid-smb-dialect.yml",
+			want:  `error parsing config file: SMB dialect '1.0' is not supported`,
+		},
+		{
+			input: "testdata/invalid-etcd-password.yml",
+			want:  `error parsing config file: password requires username to be set`,
+		},
+		{
+			input: "testdata/invalid-consul-min-peers.yml",
+			want:  `error parsing config file: min_peers cannot be negative`,
+		},
+		{
+			input: "testdata/invalid-gameserver-protocol.yml",
+			want:  `error parsing config file: game server query protocol 'gamespy' is not supported`,
+		},
+		{
+			input: "testdata/invalid-ipp-printer-state.yml",
+			want:  `error parsing config file: printer state 'offline' is not valid`,
+		},
+		{
+			input: "testdata/invalid-smtp-ehlo-domain.yml",
+			want:  `error parsing config file: ehlo_domain "prober example" is not valid`,
+		},
+		{
+			input: "testdata/invalid-ndp-neighbor-prefixes.yml",
+			want:  `error parsing config file: expected_prefixes, expect_managed, expect_other_config and min_router_lifetime only apply to router solicitations`,
+		},
+		{
+			input: "testdata/invalid-active-hours.yml",
+			want:  `error parsing config file: day 'weekend' is not valid`,
+		},
+		{
+			input: "testdata/invalid-canary-percent.yml",
+			want:  `error parsing config file: canary_percent 150 must be between 0 and 100`,
+		},
+		{
+			input: "testdata/invalid-dns-server-strategy.yml",
+			want:  `error parsing config file: server strategy 'round_robin' is not valid`,
+		},
+		{
+			input: "testdata/invalid-deduplication-window.yml",
+			want:  `error parsing config file: deduplication_window cannot be negative`,
+		},
+		{
+			input: "testdata/invalid-proxy-default-region.yml",
+			want:  `error parsing config file: default region 'ap' is not configured`,
+		},
+		{
+			input: "testdata/invalid-proxy-min-successful-regions.yml",
+			want:  `error parsing config file: min_successful_regions must be between 0 and the number of regions (2)`,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.input, func(t *testing.T) {
@@ -111,6 +455,112 @@ func TestLoadBadConfigs(t *testing.T) {
 	}
 }
 
+func TestUnsafeHTTPMethods(t *testing.T) {
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	sc.AllowUnsafeHTTPMethods = true
+	if err := sc.ReloadConfig("testdata/http-unsafe-method.yml", nil); err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+
+	// The module must confirm the use of the unsafe method, even when the
+	// flag is set.
+	want := "module http_delete uses the unsafe HTTP method DELETE, which requires allow_unsafe_method to be set"
+	if err := sc.ReloadConfig("testdata/invalid-http-unsafe-method-unconfirmed.yml", nil); err == nil || err.Error() != want {
+		t.Fatalf("Expected error %q, got %v", want, err)
+	}
+}
+
+func TestHTTPDefaults(t *testing.T) {
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	if err := sc.ReloadConfig("testdata/http-defaults.yml", nil); err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+
+	tests := []struct {
+		module     string
+		headers    map[string]string
+		minVersion config.TLSVersion
+	}{
+		{
+			module:     "http_2xx",
+			headers:    map[string]string{"User-Agent": "blackbox-probe/1.0 (+https://noc.example.com)", "X-Probe-Team": "sre"},
+			minVersion: tls.VersionTLS12,
+		},
+		{
+			module:     "http_custom",
+			headers:    map[string]string{"user-agent": "custom", "X-Probe-Team": "web"},
+			minVersion: tls.VersionTLS13,
+		},
+		{
+			module: "tcp_connect",
+		},
+	}
+	for _, test := range tests {
+		module := sc.C.Modules[test.module]
+		if !reflect.DeepEqual(module.HTTP.Headers, test.headers) {
+			t.Errorf("%s: expected headers %v, got %v", test.module, test.headers, module.HTTP.Headers)
+		}
+		if minVersion := module.HTTP.HTTPClientConfig.TLSConfig.MinVersion; minVersion != test.minVersion {
+			t.Errorf("%s: expected TLS min version %v, got %v", test.module, test.minVersion, minVersion)
+		}
+	}
+
+	// The defaults also apply to the modules added at runtime.
+	if _, err := sc.SetModule("runtime", []byte("prober: http")); err != nil {
+		t.Fatal(err)
+	}
+	if ua := sc.C.Modules["runtime"].HTTP.Headers["User-Agent"]; ua != "blackbox-probe/1.0 (+https://noc.example.com)" {
+		t.Errorf("Expected the default User-Agent for the runtime module, got %q", ua)
+	}
+}
+
+func TestEncryptedSecrets(t *testing.T) {
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	// Either module may be checked first.
+	want := ": error decrypting secret: encrypted secrets require --config.decrypt-command"
+	if err := sc.ReloadConfig("testdata/encrypted-secrets.yml", nil); err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("Expected error ending with %q, got %v", want, err)
+	}
+
+	// The ciphertexts of the test are their plaintext.
+	sc.DecryptCommand = []string{"cat"}
+	if err := sc.ReloadConfig("testdata/encrypted-secrets.yml", nil); err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if password := sc.C.Modules["http_basic_auth"].HTTP.HTTPClientConfig.BasicAuth.Password; password != "s3cret" {
+		t.Errorf("Expected password s3cret, got %q", password)
+	}
+	if community := sc.C.Modules["snmp_v2c"].SNMP.Community; community != "public" {
+		t.Errorf("Expected community public, got %q", community)
+	}
+
+	sc.DecryptCommand = []string{"false"}
+	if err := sc.ReloadConfig("testdata/encrypted-secrets.yml", nil); err == nil {
+		t.Fatal("Expected error of the decrypt command")
+	}
+}
+
+func TestUnknownProber(t *testing.T) {
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	sc.ProberRegistered = func(name string) bool {
+		return slices.Contains([]string{"http", "tcp", "icmp", "dns", "grpc", "proxy", "portscan", "roughtime", "snmp", "bgp", "oidc", "nfs", "smb", "etcd", "zookeeper", "consul", "gameserver", "ipp", "smtp", "ndp", "sse", "traceroute"}, name)
+	}
+	if err := sc.ReloadConfig("testdata/blackbox-good.yml", nil); err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+
+	want := `module ldap_bind uses the unknown prober "ldap"`
+	if err := sc.ReloadConfig("testdata/invalid-unknown-prober.yml", nil); err == nil || err.Error() != want {
+		t.Fatalf("Expected error %q, got %v", want, err)
+	}
+
+	// Without registered probers, the prober is not checked.
+	sc.ProberRegistered = nil
+	if err := sc.ReloadConfig("testdata/invalid-unknown-prober.yml", nil); err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+}
+
 func TestHideConfigSecrets(t *testing.T) {
 	sc := NewSafeConfig(prometheus.NewRegistry())
 
@@ -213,3 +663,250 @@ func TestIsEncodingAcceptable(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPorts(t *testing.T) {
+	testcases := map[string]struct {
+		input    string
+		expected []int
+		err      string
+	}{
+		"single port": {
+			input:    "22",
+			expected: []int{22},
+		},
+		"list and ranges": {
+			input:    "8000-8002, 22,80,8001",
+			expected: []int{22, 80, 8000, 8001, 8002},
+		},
+		"invalid port": {
+			input: "22,65536",
+			err:   "port '65536' is not valid",
+		},
+		"reversed range": {
+			input: "90-80",
+			err:   "port range '90-80' is not valid",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ports, err := NewPorts(tc.input)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ports.List(), tc.expected) {
+				t.Errorf("Expected ports %v, got %v", tc.expected, ports.List())
+			}
+			for _, port := range tc.expected {
+				if !ports.Contains(port) {
+					t.Errorf("Expected port %d to be contained", port)
+				}
+			}
+			if ports.Contains(443) {
+				t.Error("Expected port 443 not to be contained")
+			}
+		})
+	}
+}
+
+func TestNamedRegexp(t *testing.T) {
+	var regexps []Regexp
+	if err := yaml.Unmarshal([]byte("- 'plain'\n- regexp: 'named'\n  name: my_validator\n"), &regexps); err != nil {
+		t.Fatal(err)
+	}
+	if len(regexps) != 2 {
+		t.Fatalf("Expected 2 regexps, got %d", len(regexps))
+	}
+	if regexps[0].Name() != "" || !regexps[0].MatchString("plain") {
+		t.Errorf("Unexpected unnamed regexp %q with name %q", regexps[0].String(), regexps[0].Name())
+	}
+	if regexps[1].Name() != "my_validator" || !regexps[1].MatchString("named") {
+		t.Errorf("Unexpected named regexp %q with name %q", regexps[1].String(), regexps[1].Name())
+	}
+
+	out, err := yaml.Marshal(regexps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "- plain\n- regexp: named\n  name: my_validator\n"; string(out) != expected {
+		t.Errorf("Expected marshaled regexps %q, got %q", expected, string(out))
+	}
+
+	if err := yaml.Unmarshal([]byte("- regexp: '(['\n  name: broken\n"), &regexps); err == nil {
+		t.Error("Expected an error for an invalid named regexp")
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	testcases := map[string]struct {
+		input   string
+		inside  []string
+		outside []string
+		err     string
+	}{
+		"working days": {
+			input:   "{days: [monday, tuesday, wednesday, thursday, friday], start: '07:00', end: '20:00', location: UTC}",
+			inside:  []string{"2026-10-16T07:00:00Z", "2026-10-12T19:59:00Z"},
+			outside: []string{"2026-10-16T20:00:00Z", "2026-10-16T06:59:00Z", "2026-10-17T12:00:00Z"},
+		},
+		"overnight": {
+			input:   "{days: [Friday], start: '22:00', end: '02:00', location: Europe/Berlin}",
+			inside:  []string{"2026-10-16T20:00:00Z", "2026-10-16T23:59:00Z"},
+			outside: []string{"2026-10-16T00:30:00Z", "2026-10-17T00:00:00Z", "2026-10-17T20:00:00Z"},
+		},
+		"whole day": {
+			input:  "{start: '00:00', end: '24:00'}",
+			inside: []string{"2026-10-16T00:00:00Z", "2026-10-18T23:59:59Z"},
+		},
+		"invalid time": {
+			input: "{start: '7am', end: '20:00'}",
+			err:   "time of day '7am' is not valid",
+		},
+		"invalid day": {
+			input: "{days: [weekend], start: '07:00', end: '20:00'}",
+			err:   "day 'weekend' is not valid",
+		},
+		"empty": {
+			input: "{start: '07:00', end: '07:00'}",
+			err:   "time window from 07:00 to 07:00 is empty",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var window TimeWindow
+			err := yaml.Unmarshal([]byte(tc.input), &window)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tc.inside {
+				if ts, _ := time.Parse(time.RFC3339, s); !window.Contains(ts) {
+					t.Errorf("Expected %s to be within the window", s)
+				}
+			}
+			for _, s := range tc.outside {
+				if ts, _ := time.Parse(time.RFC3339, s); window.Contains(ts) {
+					t.Errorf("Expected %s to be outside of the window", s)
+				}
+			}
+		})
+	}
+}
+
+func TestModuleVersionsIdenticalReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blackbox.yml")
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	reload := func(config string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.ReloadConfig(file, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := `modules:
+  tcp_template:
+    prober: tcp
+    tcp:
+      query_response:
+        - send_template: "PING {{ .Target }}"
+  http_token:
+    prober: http
+    http:
+      fail_if_body_not_matches_regexp: [ok]
+      authorization:
+        credentials: secret
+`
+	reload(config)
+	// Modules holding compiled templates and regexps are unchanged when
+	// their configuration is.
+	reload(config)
+	if len(sc.C.PreviousModules) != 0 {
+		t.Fatalf("Expected no previous modules, got %v", sc.C.PreviousModules)
+	}
+	// Changes of secrets, which the YAML form of modules hides, are changes.
+	reload(strings.Replace(config, "credentials: secret", "credentials: rotated", 1))
+	if _, ok := sc.C.PreviousModules["http_token"]; !ok || len(sc.C.PreviousModules) != 1 {
+		t.Fatalf("Expected the previous version of http_token only, got %v", sc.C.PreviousModules)
+	}
+}
+
+func TestModuleVersions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blackbox.yml")
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	reload := func(config string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.ReloadConfig(file, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reload("modules:\n  http_2xx:\n    prober: http\n    timeout: 5s\n  tcp_connect:\n    prober: tcp\n")
+	if len(sc.C.PreviousModules) != 0 {
+		t.Errorf("Expected no previous modules, got %v", sc.C.PreviousModules)
+	}
+	reload("modules:\n  http_2xx:\n    prober: http\n    timeout: 10s\n    canary_percent: 25\n  tcp_connect:\n    prober: tcp\n")
+	// Reloads not changing a module keep its previous version.
+	reload("modules:\n  http_2xx:\n    prober: http\n    timeout: 10s\n    canary_percent: 25\n  tcp_connect:\n    prober: tcp\n    timeout: 1s\n")
+	if sc.C.PreviousModules["http_2xx"].Timeout != 5*time.Second {
+		t.Fatalf("Expected the previous version of http_2xx to be kept, got %v", sc.C.PreviousModules)
+	}
+
+	for _, test := range []struct {
+		module, version, expected string
+		timeout                   time.Duration
+		err                       bool
+	}{
+		{module: "http_2xx", version: "current", expected: "current", timeout: 10 * time.Second},
+		{module: "http_2xx", version: "previous", expected: "previous", timeout: 5 * time.Second},
+		{module: "tcp_connect", version: "previous", expected: "previous", timeout: 0},
+		{module: "http_2xx", version: "next", err: true},
+	} {
+		module, version, err := sc.C.SelectModule(test.module, test.version, "example.com")
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected an error for version %q", test.version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != test.expected || module.Timeout != test.timeout {
+			t.Errorf("Expected version %s of %s with timeout %s, got %s with %s", test.expected, test.module, test.timeout, version, module.Timeout)
+		}
+	}
+
+	// The canary gets about a quarter of the targets, always the same ones.
+	canary := 0
+	for i := 0; i < 1000; i++ {
+		target := fmt.Sprintf("target-%d.example.com", i)
+		_, version, _ := sc.C.SelectModule("http_2xx", "", target)
+		if _, again, _ := sc.C.SelectModule("http_2xx", "", target); again != version {
+			t.Fatalf("Expected target %s to stay on version %s", target, version)
+		}
+		if version == ModuleVersionCurrent {
+			canary++
+		}
+	}
+	if canary < 200 || canary > 300 {
+		t.Errorf("Expected about 250 targets in the canary, got %d", canary)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment selects members of objects by name, or elements of arrays
+// by index. A wildcard segment selects all members or elements.
+type jsonPathSegment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// JSONPath is a JSONPath expression selecting values in a JSON document
+// decoded with encoding/json. It supports the subset of the syntax needed to
+// address values: "$", ".name", "['name']", "[0]", ".*" and "[*]".
+type JSONPath struct {
+	segments []jsonPathSegment
+	original string
+}
+
+// NewJSONPath parses a JSONPath expression.
+func NewJSONPath(s string) (JSONPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return JSONPath{}, fmt.Errorf("JSONPath '%s' must start with '$'", s)
+	}
+	var segments []jsonPathSegment
+	rest := s[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return JSONPath{}, fmt.Errorf("JSONPath '%s' has an empty member name", s)
+			case "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			default:
+				segments = append(segments, jsonPathSegment{name: name})
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return JSONPath{}, fmt.Errorf("JSONPath '%s' has an unterminated bracket", s)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if selector == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+				continue
+			}
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, jsonPathSegment{name: selector[1 : len(selector)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil || index < 0 {
+				return JSONPath{}, fmt.Errorf("JSONPath '%s' has an invalid selector '%s'", s, selector)
+			}
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+		default:
+			return JSONPath{}, fmt.Errorf("JSONPath '%s' is not valid", s)
+		}
+	}
+	return JSONPath{segments: segments, original: s}, nil
+}
+
+// MustNewJSONPath works like NewJSONPath, but panics if the expression is not
+// valid.
+func MustNewJSONPath(s string) JSONPath {
+	p, err := NewJSONPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Select returns the values selected by the expression in the document.
+func (p JSONPath) Select(document interface{}) []interface{} {
+	values := []interface{}{document}
+	for _, segment := range p.segments {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if segment.wildcard {
+					for _, member := range v {
+						next = append(next, member)
+					}
+				} else if member, ok := v[segment.name]; ok && !segment.isIndex {
+					next = append(next, member)
+				}
+			case []interface{}:
+				if segment.wildcard {
+					next = append(next, v...)
+				} else if segment.isIndex && segment.index < len(v) {
+					next = append(next, v[segment.index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// String returns the original expression.
+func (p JSONPath) String() string {
+	return p.original
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *JSONPath) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	path, err := NewJSONPath(s)
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p JSONPath) MarshalYAML() (interface{}, error) {
+	if p.original != "" {
+		return p.original, nil
+	}
+	return nil, nil
+}
+
+// IsZero reports whether the expression is unset.
+func (p JSONPath) IsZero() bool {
+	return p.original == ""
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	var document interface{}
+	if err := json.Unmarshal([]byte(`{"data":{"users":[{"name":"a","tags":{"x":1}},{"name":"b","tags":{"y":2}}],"odd key":true}}`), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := map[string]struct {
+		path     string
+		expected []interface{}
+		err      string
+	}{
+		"root": {
+			path:     "$.data['odd key']",
+			expected: []interface{}{true},
+		},
+		"index": {
+			path:     "$.data.users[1].name",
+			expected: []interface{}{"b"},
+		},
+		"wildcard array": {
+			path:     "$.data.users[*].name",
+			expected: []interface{}{"a", "b"},
+		},
+		"wildcard object": {
+			path:     "$.data.users[*].tags.*",
+			expected: []interface{}{float64(1), float64(2)},
+		},
+		"missing": {
+			path: "$.data.users[2].name",
+		},
+		"name on array": {
+			path: "$.data.users.name",
+		},
+		"no root": {
+			path: "data.users",
+			err:  "JSONPath 'data.users' must start with '$'",
+		},
+		"unterminated bracket": {
+			path: "$.data[0",
+			err:  "JSONPath '$.data[0' has an unterminated bracket",
+		},
+		"invalid selector": {
+			path: "$.data[-1]",
+			err:  "JSONPath '$.data[-1]' has an invalid selector '-1'",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewJSONPath(tc.path)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := p.Select(document)
+			// Members of objects are selected in no particular order.
+			sort.Slice(values, func(i, j int) bool {
+				a, _ := json.Marshal(values[i])
+				b, _ := json.Marshal(values[j])
+				return string(a) < string(b)
+			})
+			if len(values) != len(tc.expected) || (len(values) > 0 && !reflect.DeepEqual(values, tc.expected)) {
+				t.Errorf("Expected %v, got %v", tc.expected, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrModuleNotFound is returned for changes of runtime modules that do
+	// not exist.
+	ErrModuleNotFound = errors.New("module not found")
+	// ErrModuleInConfigFile is returned for changes of modules defined in
+	// the configuration file, which cannot be changed at runtime.
+	ErrModuleInConfigFile = errors.New("module is defined in the configuration file")
+)
+
+// runtimeModulesFile is the format of the file runtime modules are persisted
+// in, which is the one of the configuration file.
+type runtimeModulesFile struct {
+	Modules map[string]map[string]interface{} `yaml:"modules"`
+}
+
+// decodeModule decodes a module from its YAML document, as it would be from
+// the configuration file.
+func decodeModule(doc map[string]interface{}) (Module, error) {
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return Module{}, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	var module Module
+	if err := decoder.Decode(&module); err != nil {
+		return Module{}, err
+	}
+	return module, nil
+}
+
+// mergePatch applies a merge patch to a document, see RFC 7386: the values
+// of the patch replace the ones of the document, except for maps which are
+// merged, and null values remove keys.
+func mergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		patchMap, ok := value.(map[string]interface{})
+		if !ok {
+			merged[key] = value
+			continue
+		}
+		docMap, _ := merged[key].(map[string]interface{})
+		merged[key] = mergePatch(docMap, patchMap)
+	}
+	return merged
+}
+
+// LoadRuntimeModules loads the runtime modules persisted in the
+// RuntimeModulesFile. They are added to the configuration when it is
+// reloaded.
+func (sc *SafeConfig) LoadRuntimeModules() error {
+	b, err := os.ReadFile(sc.RuntimeModulesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading runtime modules file: %s", err)
+	}
+	var f runtimeModulesFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("error parsing runtime modules file: %s", err)
+	}
+	sc.runtimeMu.Lock()
+	defer sc.runtimeMu.Unlock()
+	sc.runtimeModules = f.Modules
+	return nil
+}
+
+// saveRuntimeModules persists the runtime modules, replacing the file so
+// that it is never partially written.
+func (sc *SafeConfig) saveRuntimeModules(modules map[string]map[string]interface{}) error {
+	if sc.RuntimeModulesFile == "" {
+		return nil
+	}
+	b, err := yaml.Marshal(runtimeModulesFile{Modules: modules})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(sc.RuntimeModulesFile), filepath.Base(sc.RuntimeModulesFile)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), sc.RuntimeModulesFile)
+}
+
+// addRuntimeModules adds the runtime modules to a configuration loaded from
+// the configuration file.
+func (sc *SafeConfig) addRuntimeModules(c *Config) error {
+	sc.runtimeMu.Lock()
+	defer sc.runtimeMu.Unlock()
+	for name, doc := range sc.runtimeModules {
+		if _, ok := c.Modules[name]; ok {
+			return fmt.Errorf("module %s is defined both in the config file and at runtime", name)
+		}
+		module, err := decodeModule(doc)
+		if err != nil {
+			return fmt.Errorf("error parsing runtime module %s: %s", name, err)
+		}
+		c.HTTPDefaults.apply(&module)
+		if err := sc.checkModule(name, &module, nil); err != nil {
+			return err
+		}
+		if c.Modules == nil {
+			c.Modules = map[string]Module{}
+		}
+		c.Modules[name] = module
+	}
+	return nil
+}
+
+// updateRuntimeModule replaces the YAML document of a runtime module with
+// the result of update, or removes the module if it returns nil. The
+// document passed to update is nil if the module does not exist. It returns
+// whether the module existed.
+func (sc *SafeConfig) updateRuntimeModule(name string, update func(doc map[string]interface{}) (map[string]interface{}, error)) (bool, error) {
+	sc.runtimeMu.Lock()
+	defer sc.runtimeMu.Unlock()
+
+	sc.RLock()
+	current := sc.C
+	sc.RUnlock()
+	doc, existed := sc.runtimeModules[name]
+	if _, ok := current.Modules[name]; ok && !existed {
+		return false, ErrModuleInConfigFile
+	}
+
+	doc, err := update(doc)
+	if err != nil {
+		return existed, err
+	}
+	// Configurations are shared with running probes, the new one is a copy.
+	c := &Config{Modules: make(map[string]Module, len(current.Modules)+1), GrafanaAnnotations: current.GrafanaAnnotations, HTTPDefaults: current.HTTPDefaults}
+	for n, m := range current.Modules {
+		c.Modules[n] = m
+	}
+	runtimeModules := make(map[string]map[string]interface{}, len(sc.runtimeModules)+1)
+	for n, d := range sc.runtimeModules {
+		runtimeModules[n] = d
+	}
+	if doc == nil {
+		delete(c.Modules, name)
+		delete(runtimeModules, name)
+	} else {
+		module, err := decodeModule(doc)
+		if err != nil {
+			return existed, fmt.Errorf("error parsing module: %s", err)
+		}
+		c.HTTPDefaults.apply(&module)
+		if err := sc.checkModule(name, &module, nil); err != nil {
+			return existed, err
+		}
+		c.Modules[name] = module
+		runtimeModules[name] = doc
+	}
+	c.PreviousModules = previousModules(current, c)
+	if err := sc.saveRuntimeModules(runtimeModules); err != nil {
+		return existed, fmt.Errorf("error saving runtime modules: %s", err)
+	}
+	sc.runtimeModules = runtimeModules
+	sc.Lock()
+	sc.C = c
+	sc.Unlock()
+	return existed, nil
+}
+
+// SetModule adds or replaces a runtime module, given as a YAML document in
+// the format of the modules of the configuration file. It returns whether
+// the module replaced an existing one.
+func (sc *SafeConfig) SetModule(name string, module []byte) (bool, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(module, &doc); err != nil {
+		return false, fmt.Errorf("error parsing module: %s", err)
+	}
+	if doc == nil {
+		return false, errors.New("module is empty")
+	}
+	return sc.updateRuntimeModule(name, func(map[string]interface{}) (map[string]interface{}, error) {
+		return doc, nil
+	})
+}
+
+// PatchModule applies a merge patch, given as a YAML document, to a runtime
+// module.
+func (sc *SafeConfig) PatchModule(name string, patch []byte) error {
+	var patchDoc map[string]interface{}
+	if err := yaml.Unmarshal(patch, &patchDoc); err != nil {
+		return fmt.Errorf("error parsing patch: %s", err)
+	}
+	_, err := sc.updateRuntimeModule(name, func(doc map[string]interface{}) (map[string]interface{}, error) {
+		if doc == nil {
+			return nil, ErrModuleNotFound
+		}
+		return mergePatch(doc, patchDoc), nil
+	})
+	return err
+}
+
+// DeleteModule removes a runtime module.
+func (sc *SafeConfig) DeleteModule(name string) error {
+	_, err := sc.updateRuntimeModule(name, func(doc map[string]interface{}) (map[string]interface{}, error) {
+		if doc == nil {
+			return nil, ErrModuleNotFound
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// RuntimeModules returns the YAML documents of the runtime modules, as they
+// were set and patched.
+func (sc *SafeConfig) RuntimeModules() ([]byte, error) {
+	sc.runtimeMu.Lock()
+	defer sc.runtimeMu.Unlock()
+	return yaml.Marshal(runtimeModulesFile{Modules: sc.runtimeModules})
+}
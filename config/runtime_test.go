@@ -0,0 +1,90 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRuntimeModules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "modules.yml")
+	sc := NewSafeConfig(prometheus.NewRegistry())
+	sc.RuntimeModulesFile = file
+	if err := sc.ReloadConfig("testdata/blackbox-good.yml", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sc.SetModule("http_2xx", []byte("prober: http")); !errors.Is(err, ErrModuleInConfigFile) {
+		t.Errorf("Expected modules of the config file to be rejected, got %v", err)
+	}
+	if _, err := sc.SetModule("runtime", []byte("prober: http\nhttp:\n  unknown_field: true\n")); err == nil {
+		t.Error("Expected an error for an invalid module")
+	}
+	replaced, err := sc.SetModule("runtime", []byte(`{"prober": "http", "timeout": "5s", "http": {"method": "HEAD", "basic_auth": {"username": "user", "password": "secret"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced {
+		t.Error("Expected a new module")
+	}
+	if err := sc.PatchModule("runtime", []byte("timeout: 10s\nhttp:\n  method: null\n  valid_status_codes: [200]\n")); err != nil {
+		t.Fatal(err)
+	}
+	module := sc.C.Modules["runtime"]
+	if module.Timeout != 10*time.Second || module.HTTP.Method != "" || len(module.HTTP.ValidStatusCodes) != 1 {
+		t.Errorf("Unexpected patched module %+v", module)
+	}
+	if module.HTTP.HTTPClientConfig.BasicAuth == nil || module.HTTP.HTTPClientConfig.BasicAuth.Password != "secret" {
+		t.Error("Expected the password to be kept by the patch")
+	}
+	if err := sc.PatchModule("missing", []byte("timeout: 10s")); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("Expected the module not to be found, got %v", err)
+	}
+
+	// The runtime modules are persisted and survive reloads.
+	if err := sc.ReloadConfig("testdata/blackbox-good.yml", nil); err != nil {
+		t.Fatal(err)
+	}
+	restarted := NewSafeConfig(prometheus.NewRegistry())
+	restarted.RuntimeModulesFile = file
+	if err := restarted.LoadRuntimeModules(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.ReloadConfig("testdata/blackbox-good.yml", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*SafeConfig{sc, restarted} {
+		if c.C.Modules["runtime"].Timeout != 10*time.Second {
+			t.Errorf("Expected the runtime module to be kept, got %+v", c.C.Modules["runtime"])
+		}
+	}
+
+	if err := restarted.DeleteModule("runtime"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restarted.C.Modules["runtime"]; ok {
+		t.Error("Expected the module to be removed")
+	}
+	if _, ok := restarted.C.Modules["http_2xx"]; !ok {
+		t.Error("Expected the modules of the config file to be kept")
+	}
+	if err := restarted.DeleteModule("runtime"); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("Expected the module not to be found, got %v", err)
+	}
+}
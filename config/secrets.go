@@ -0,0 +1,140 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/config"
+)
+
+// Encrypted secrets are written as ENC[<base64 ciphertext>] in place of the
+// value of secret fields, e.g. the output of age or of a KMS encryption.
+const (
+	encryptedSecretPrefix = "ENC["
+	encryptedSecretSuffix = "]"
+)
+
+var secretType = reflect.TypeOf(config.Secret(""))
+
+// decryptSecret runs the decryption command with the ciphertext on its
+// standard input, and returns its output.
+func (sc *SafeConfig) decryptSecret(ciphertext []byte) (string, error) {
+	if len(sc.DecryptCommand) == 0 {
+		return "", errors.New("encrypted secrets require --config.decrypt-command")
+	}
+	cmd := exec.Command(sc.DecryptCommand[0], sc.DecryptCommand[1:]...)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	plaintext, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// Like the secrets read from files, surrounding whitespace is ignored.
+	return strings.TrimSpace(string(plaintext)), nil
+}
+
+// decryptSecrets replaces the encrypted secrets found in v, which has to be
+// settable, with their plaintext.
+func decryptSecrets(v reflect.Value, decrypt func([]byte) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return decryptSecrets(v.Elem(), decrypt)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := decryptSecrets(v.Field(i), decrypt); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := decryptSecrets(v.Index(i), decrypt); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// The values of maps are not settable, they are decrypted in a copy.
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			if err := decryptSecrets(value, decrypt); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.String:
+		s := v.String()
+		if v.Type() != secretType || !strings.HasPrefix(s, encryptedSecretPrefix) || !strings.HasSuffix(s, encryptedSecretSuffix) {
+			return nil
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(s[len(encryptedSecretPrefix) : len(s)-len(encryptedSecretSuffix)])
+		if err != nil {
+			return fmt.Errorf("error decoding encrypted secret: %s", err)
+		}
+		plaintext, err := decrypt(ciphertext)
+		if err != nil {
+			return fmt.Errorf("error decrypting secret: %s", err)
+		}
+		v.SetString(plaintext)
+	}
+	return nil
+}
+
+// secretValues appends the values of the secrets found in v to values, in
+// the order of the fields. The keys of maps are sorted, so that equal values
+// have the same secrets in the same order.
+func secretValues(v reflect.Value, values []string) []string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			values = secretValues(v.Elem(), values)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				values = secretValues(v.Field(i), values)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			values = secretValues(v.Index(i), values)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			values = secretValues(v.MapIndex(key), values)
+		}
+	case reflect.String:
+		if v.Type() == secretType {
+			values = append(values, v.String())
+		}
+	}
+	return values
+}
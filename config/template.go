@@ -0,0 +1,159 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"text/template"
+)
+
+// templateFuncs are the functions available to send templates, for the
+// encodings, lengths and checksums of binary protocols.
+var templateFuncs = template.FuncMap{
+	"base64":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64Decode": decodeBase64,
+	"hex":          func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"hexDecode":    decodeHex,
+	"crc32":        func(s string) uint32 { return crc32.ChecksumIEEE([]byte(s)) },
+	"sum8":         sum8,
+	"xor8":         xor8,
+	"atoi":         strconv.Atoi,
+	"add":          func(a, b int) int { return a + b },
+	"sub":          func(a, b int) int { return a - b },
+	"mul":          func(a, b int) int { return a * b },
+	"div":          divide,
+	"mod":          modulo,
+	"bitand":       func(a, b int) int { return a & b },
+	"bitor":        func(a, b int) int { return a | b },
+	"bitxor":       func(a, b int) int { return a ^ b },
+	"shl":          func(a int, b uint) int { return a << b },
+	"shr":          func(a int, b uint) int { return a >> b },
+	"byte":         func(v int) string { return string([]byte{byte(v)}) },
+	"uint16be":     func(v int) string { return string(binary.BigEndian.AppendUint16(nil, uint16(v))) },
+	"uint32be":     func(v int) string { return string(binary.BigEndian.AppendUint32(nil, uint32(v))) },
+	"uint16le":     func(v int) string { return string(binary.LittleEndian.AppendUint16(nil, uint16(v))) },
+	"uint32le":     func(v int) string { return string(binary.LittleEndian.AppendUint32(nil, uint32(v))) },
+}
+
+func decodeBase64(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
+
+func decodeHex(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	return string(b), err
+}
+
+// sum8 returns the sum of the bytes of s modulo 256.
+func sum8(s string) int {
+	var sum byte
+	for i := 0; i < len(s); i++ {
+		sum += s[i]
+	}
+	return int(sum)
+}
+
+// xor8 returns the XOR of the bytes of s, the checksum of protocols such as
+// NMEA.
+func xor8(s string) int {
+	var sum byte
+	for i := 0; i < len(s); i++ {
+		sum ^= s[i]
+	}
+	return int(sum)
+}
+
+func divide(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+
+func modulo(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("modulo by zero")
+	}
+	return a % b, nil
+}
+
+// Template is a text/template computing the data sent by a probe, e.g. from
+// the previous responses of the target. It is YAML marshalable.
+type Template struct {
+	*template.Template
+	original string
+}
+
+// NewTemplate parses a template using the functions available to probes.
+func NewTemplate(s string) (Template, error) {
+	t, err := template.New("send_template").Funcs(templateFuncs).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return Template{}, err
+	}
+	return Template{Template: t, original: s}, nil
+}
+
+// MustNewTemplate works like NewTemplate, but panics if the template is not
+// valid.
+func MustNewTemplate(s string) Template {
+	t, err := NewTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ExecuteString executes the template and returns its output.
+func (t Template) ExecuteString(data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (t *Template) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	tmpl, err := NewTemplate(s)
+	if err != nil {
+		return fmt.Errorf("send_template is not valid: %s", err)
+	}
+	*t = tmpl
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t Template) MarshalYAML() (interface{}, error) {
+	if t.original != "" {
+		return t.original, nil
+	}
+	return nil, nil
+}
+
+// IsZero reports whether the template is unset.
+func (t Template) IsZero() bool {
+	return t.original == ""
+}
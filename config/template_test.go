@@ -0,0 +1,70 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	testcases := map[string]struct {
+		template string
+		expected string
+		err      bool
+	}{
+		"base64":            {template: `{{ base64 "ping" }}`, expected: "cGluZw=="},
+		"base64 decode":     {template: `{{ base64Decode "cGluZw==" }}`, expected: "ping"},
+		"hex":               {template: `{{ hex "\x01\xff" }}`, expected: "01ff"},
+		"hex decode":        {template: `{{ hexDecode "7069" }}`, expected: "pi"},
+		"invalid hex":       {template: `{{ hexDecode "zz" }}`, err: true},
+		"crc32":             {template: `{{ crc32 "123456789" }}`, expected: "3421780262"},
+		"sum8":              {template: `{{ sum8 "\xff\x02" }}`, expected: "1"},
+		"arithmetic":        {template: `{{ mod (add (mul 3 4) (sub 10 7)) 7 }}`, expected: "1"},
+		"modulo by zero":    {template: `{{ mod 1 0 }}`, err: true},
+		"xor8":              {template: `{{ xor8 "GPGLL" }}`, expected: "80"},
+		"division":          {template: `{{ div 7 2 }}`, expected: "3"},
+		"division by zero":  {template: `{{ div 1 0 }}`, err: true},
+		"bitwise":           {template: `{{ bitor (bitand 0xf0 0x3c) (bitxor 5 3) }}`, expected: "54"},
+		"shifts":            {template: `{{ shl 1 4 }} {{ shr 0x1234 8 }}`, expected: "16 18"},
+		"builtin and":       {template: `{{ and 1 0 }}`, expected: "0"},
+		"length prefix":     {template: `{{ uint16be (len "ping") }}ping`, expected: "\x00\x04ping"},
+		"little endian":     {template: `{{ uint32le 258 }}`, expected: "\x02\x01\x00\x00"},
+		"byte":              {template: `{{ byte (atoi "65") }}`, expected: "A"},
+		"big endian uint32": {template: `{{ uint32be 1 }}`, expected: "\x00\x00\x00\x01"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			out, err := MustNewTemplate(tc.template).ExecuteString(nil)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, out)
+			}
+		})
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	if _, err := NewTemplate(`{{ unknownFunc "x" }}`); err == nil {
+		t.Fatal("Expected an error for an unknown function")
+	}
+}
@@ -14,12 +14,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html"
+	"log/slog"
 	"net"
 	"net/http"
-	_ "net/http/pprof"
+	"net/http/pprof"
 	"net/url"
 	"os"
 	"os/signal"
@@ -27,6 +30,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus"
@@ -47,23 +51,62 @@ import (
 var (
 	sc = config.NewSafeConfig(prometheus.DefaultRegisterer)
 
-	configFile     = kingpin.Flag("config.file", "Blackbox exporter configuration file.").Default("blackbox.yml").String()
-	timeoutOffset  = kingpin.Flag("timeout-offset", "Offset to subtract from timeout in seconds.").Default("0.5").Float64()
-	configCheck    = kingpin.Flag("config.check", "If true validate the config file and then exit.").Default().Bool()
-	logLevelProber = kingpin.Flag("log.prober", "Log level from probe requests. One of: [debug, info, warn, error]").Default("info").String()
-	historyLimit   = kingpin.Flag("history.limit", "The maximum amount of items to keep in the history.").Default("100").Uint()
-	externalURL    = kingpin.Flag("web.external-url", "The URL under which Blackbox exporter is externally reachable (for example, if Blackbox exporter is served via a reverse proxy). Used for generating relative and absolute links back to Blackbox exporter itself. If the URL has a path portion, it will be used to prefix all HTTP endpoints served by Blackbox exporter. If omitted, relevant URL components will be derived automatically.").PlaceHolder("<url>").String()
-	routePrefix    = kingpin.Flag("web.route-prefix", "Prefix for the internal routes of web endpoints. Defaults to path of --web.external-url.").PlaceHolder("<path>").String()
-	toolkitFlags   = webflag.AddFlags(kingpin.CommandLine, ":9115")
+	configFile             = kingpin.Flag("config.file", "Blackbox exporter configuration file.").Default("blackbox.yml").String()
+	timeoutOffset          = kingpin.Flag("timeout-offset", "Offset to subtract from timeout in seconds.").Default("0.5").Float64()
+	configCheck            = kingpin.Flag("config.check", "If true validate the config file and then exit.").Default().Bool()
+	decryptCommand         = kingpin.Flag("config.decrypt-command", "Command decrypting the secrets of modules written as ENC[<base64 ciphertext>], such as \"age --decrypt --identity /etc/blackbox_exporter/key.txt\". It gets the ciphertext on stdin and writes the plaintext to stdout.").PlaceHolder("<command>").String()
+	logLevelProber         = kingpin.Flag("log.prober", "Log level from probe requests. One of: [debug, info, warn, error]").Default("info").String()
+	historyLimit           = kingpin.Flag("history.limit", "The maximum amount of items to keep in the history.").Default("100").Uint()
+	allowUnsafeHTTPMethods = kingpin.Flag("http.allow-unsafe-methods", "Allow modules to probe with HTTP methods that may change the state of the target, such as PUT, PATCH, DELETE or custom methods. Each module must also set allow_unsafe_method.").Default("false").Bool()
+	tlsKeyLog              = kingpin.Flag("debug.tls-key-log", "Allow debug probe requests with tls_key_log=true to capture the TLS session keys of the http, tcp, grpc and dns probers, in the key log format read by Wireshark. The keys are written to the debug output unless --debug.tls-key-log-file is set.").Default("false").Bool()
+	tlsKeyLogFile          = kingpin.Flag("debug.tls-key-log-file", "File the TLS session keys captured by debug probe requests are appended to, instead of the debug output.").PlaceHolder("<path>").String()
+	stateFile              = kingpin.Flag("state.file", "File the state of the probers is saved to and restored from across restarts, such as the sequence of ICMP requests and the previous bodies compared by the http prober. The state is not persisted if empty.").PlaceHolder("<path>").String()
+	stateSaveInterval      = kingpin.Flag("state.save-interval", "How often the state of the probers is saved to --state.file.").Default("1m").Duration()
+	nat64Prefix            = kingpin.Flag("nat64.prefix", "NAT64 prefix of an IPv6-only host, such as 64:ff9b::/96. The IPv4 addresses of targets, including targets without IPv6 addresses, are then reached at the IPv6 addresses synthesized from the prefix.").PlaceHolder("<prefix>").String()
+	selfTest               = kingpin.Flag("self-test", "Probe each module once on startup, against its self_test_target or a loopback test server, to report the modules working on this host in blackbox_exporter_module_selftest_success.").Default("false").Bool()
+	artifactsDir           = kingpin.Flag("artifacts.dir", "Directory the response of failed http probes is saved to, with its status and headers, for post-incident analysis. The ID of the artifact is logged with the probe, and it can be retrieved under /api/v1/artifacts/<id> with the token of --admin.token-file. Responses are not saved if empty.").PlaceHolder("<path>").String()
+	artifactsMaxSize       = kingpin.Flag("artifacts.max-size", "Maximum total size of the artifacts, the oldest are removed beyond it.").Default("100MB").Bytes()
+	artifactsRetention     = kingpin.Flag("artifacts.retention", "How long artifacts are kept.").Default("24h").Duration()
+	adminTokenFile         = kingpin.Flag("admin.token-file", "File containing the bearer token of the admin API, which adds, patches and removes modules at runtime under /api/v1/modules/. The admin API is disabled if empty.").PlaceHolder("<path>").String()
+	adminModulesFile       = kingpin.Flag("admin.modules-file", "Writable file the modules managed by the admin API are persisted in, and loaded from on startup. Runtime modules are lost on restart if empty.").PlaceHolder("<path>").String()
+	enableAdminAPI         = kingpin.Flag("web.enable-admin-api", "Enable the endpoints for profiling and tuning the exporter, /debug/pprof/ and /api/v1/admin/runtime.").Default("false").Bool()
+	externalURL            = kingpin.Flag("web.external-url", "The URL under which Blackbox exporter is externally reachable (for example, if Blackbox exporter is served via a reverse proxy). Used for generating relative and absolute links back to Blackbox exporter itself. If the URL has a path portion, it will be used to prefix all HTTP endpoints served by Blackbox exporter. If omitted, relevant URL components will be derived automatically.").PlaceHolder("<url>").String()
+	routePrefix            = kingpin.Flag("web.route-prefix", "Prefix for the internal routes of web endpoints. Defaults to path of --web.external-url.").PlaceHolder("<path>").String()
+	toolkitFlags           = webflag.AddFlags(kingpin.CommandLine, ":9115")
+
+	_ = kingpin.Command("serve", "Run the exporter. This is the default command.").Default()
+
+	probeCmd    = kingpin.Command("probe", "Run a single probe with the modules of the configuration file, write its metrics to stdout and exit with 0 if it succeeded, 1 if it failed and 2 if it could not run.")
+	probeModule = probeCmd.Flag("module", "Module to probe the target with.").Default("http_2xx").String()
+	probeTarget = probeCmd.Flag("target", "Target to probe.").Required().String()
+	probeHost   = probeCmd.Flag("hostname", "Hostname of the target, as the hostname parameter of /probe.").String()
+	probeDebug  = probeCmd.Flag("debug", "Write the debug output of the probe instead of its metrics.").Bool()
+	probeFormat = probeCmd.Flag("format", "Format of the result, json for the JSON document of /probe?format=json.").Default("metrics").Enum("metrics", "json")
 
 	moduleUnknownCounter = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "blackbox_module_unknown_total",
 		Help: "Count of unknown modules requested by probes",
 	})
+	moduleSelfTestSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blackbox_exporter_module_selftest_success",
+		Help: "Whether the self-test of the module on startup succeeded.",
+	}, []string{"module"})
 )
 
 func init() {
 	prometheus.MustRegister(versioncollector.NewCollector("blackbox_exporter"))
+	prometheus.MustRegister(prober.NewModuleUsageCollector(func() []string {
+		sc.RLock()
+		defer sc.RUnlock()
+		if sc.C == nil {
+			return nil
+		}
+		modules := make([]string, 0, len(sc.C.Modules))
+		for name := range sc.C.Modules {
+			modules = append(modules, name)
+		}
+		return modules
+	}))
 }
 
 func main() {
@@ -76,7 +119,7 @@ func run() int {
 	flag.AddFlags(kingpin.CommandLine, promslogConfig)
 	kingpin.Version(version.Print("blackbox_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
 	logger := promslog.New(promslogConfig)
 	rh := &prober.ResultHistory{MaxResults: *historyLimit}
 
@@ -86,6 +129,16 @@ func run() int {
 	logger.Info("Starting blackbox_exporter", "version", version.Info())
 	logger.Info(version.BuildContext())
 
+	sc.AllowUnsafeHTTPMethods = *allowUnsafeHTTPMethods
+	sc.DecryptCommand = strings.Fields(*decryptCommand)
+	sc.ProberRegistered = prober.IsRegistered
+	sc.RuntimeModulesFile = *adminModulesFile
+	if *adminModulesFile != "" {
+		if err := sc.LoadRuntimeModules(); err != nil {
+			logger.Error("Error loading runtime modules", "err", err)
+			return 1
+		}
+	}
 	if err := sc.ReloadConfig(*configFile, logger); err != nil {
 		logger.Error("Error loading config", "err", err)
 		return 1
@@ -96,8 +149,68 @@ func run() int {
 		return 0
 	}
 
+	if *nat64Prefix != "" {
+		if err := prober.SetNAT64Prefix(*nat64Prefix); err != nil {
+			logger.Error("Error setting the NAT64 prefix", "err", err)
+			return 1
+		}
+	}
+
+	if command == probeCmd.FullCommand() {
+		params := url.Values{"module": {*probeModule}, "target": {*probeTarget}}
+		if *probeHost != "" {
+			params.Set("hostname", *probeHost)
+		}
+		if *probeDebug {
+			params.Set("debug", "true")
+		}
+		if *probeFormat == "json" {
+			params.Set("format", "json")
+		}
+		return probeOnce(context.Background(), sc.C, params, os.Stdout, logger, allowedLevel)
+	}
+
+	if *tlsKeyLog {
+		logger.Warn("Debug probe requests can capture TLS session keys", "file", *tlsKeyLogFile)
+		prober.EnableTLSKeyLog(*tlsKeyLogFile)
+	}
+
 	logger.Info("Loaded config file")
 
+	if *stateFile != "" {
+		if err := prober.LoadState(*stateFile); err != nil {
+			logger.Warn("Error loading the state of the probers, starting with a fresh state", "file", *stateFile, "err", err)
+		}
+		go func() {
+			for range time.Tick(*stateSaveInterval) {
+				if err := prober.SaveState(*stateFile); err != nil {
+					logger.Error("Error saving the state of the probers", "file", *stateFile, "err", err)
+				}
+			}
+		}()
+	}
+
+	var artifactStore *prober.ArtifactStore
+	if *artifactsDir != "" {
+		store, err := prober.NewArtifactStore(*artifactsDir, int64(*artifactsMaxSize), *artifactsRetention)
+		if err != nil {
+			logger.Error("Error creating the artifacts directory", "dir", *artifactsDir, "err", err)
+			return 1
+		}
+		prober.EnableArtifacts(store)
+		artifactStore = store
+	}
+
+	if *selfTest {
+		for name, success := range prober.SelfTest(context.Background(), sc.C.Modules, logger) {
+			if success {
+				moduleSelfTestSuccess.WithLabelValues(name).Set(1)
+			} else {
+				moduleSelfTestSuccess.WithLabelValues(name).Set(0)
+			}
+		}
+	}
+
 	// Infer or set Blackbox exporter externalURL
 	listenAddrs := toolkitFlags.WebListenAddresses
 	if *externalURL == "" && *toolkitFlags.WebSystemdSocket {
@@ -151,10 +264,61 @@ func run() int {
 		}
 	}()
 
+	var adminToken string
+	if *adminTokenFile != "" {
+		token, err := os.ReadFile(*adminTokenFile)
+		if err != nil {
+			logger.Error("Error reading admin token file", "err", err)
+			return 1
+		}
+		adminToken = string(bytes.TrimSpace(token))
+		if adminToken == "" {
+			logger.Error("Admin token file is empty", "file", *adminTokenFile)
+			return 1
+		}
+	} else if artifactStore != nil {
+		logger.Warn("Artifacts can only be retrieved from their directory without --admin.token-file", "dir", *artifactsDir)
+	}
+
+	srv := &http.Server{Handler: newRouter(beURL, reloadCh, rh, artifactStore, adminToken, logger, allowedLevel)}
+	srvc := make(chan struct{})
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		if err := web.ListenAndServe(srv, toolkitFlags, logger); err != nil {
+			logger.Error("Error starting HTTP server", "err", err)
+			close(srvc)
+		}
+	}()
+
+	for {
+		select {
+		case <-term:
+			logger.Info("Received SIGTERM, exiting gracefully...")
+			if *stateFile != "" {
+				if err := prober.SaveState(*stateFile); err != nil {
+					logger.Error("Error saving the state of the probers", "file", *stateFile, "err", err)
+				}
+			}
+			return 0
+		case <-srvc:
+			return 1
+		}
+	}
+
+}
+
+// newRouter returns the handler of the web endpoints of the exporter. It is
+// not http.DefaultServeMux, to which imported packages such as
+// net/http/pprof add their own endpoints.
+func newRouter(beURL *url.URL, reloadCh chan<- chan error, rh *prober.ResultHistory, artifactStore *prober.ArtifactStore, adminToken string, logger *slog.Logger, allowedLevel *promslog.AllowedLevel) *http.ServeMux {
+	mux := http.NewServeMux()
+
 	// Match Prometheus behavior and redirect over externalURL for root path only
 	// if routePrefix is different than "/"
 	if *routePrefix != "/" {
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			if r.URL.Path != "/" {
 				http.NotFound(w, r)
 				return
@@ -163,7 +327,7 @@ func run() int {
 		})
 	}
 
-	http.HandleFunc(path.Join(*routePrefix, "/-/reload"),
+	mux.HandleFunc(path.Join(*routePrefix, "/-/reload"),
 		func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != "POST" {
 				w.WriteHeader(http.StatusMethodNotAllowed)
@@ -177,18 +341,43 @@ func run() int {
 				http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
 			}
 		})
-	http.Handle(path.Join(*routePrefix, "/metrics"), promhttp.Handler())
-	http.HandleFunc(path.Join(*routePrefix, "/-/healthy"), func(w http.ResponseWriter, r *http.Request) {
+	if adminToken != "" {
+		modulesPath := path.Join(*routePrefix, "/api/v1/modules")
+		adminHandler := adminModulesHandler(sc, modulesPath, adminToken, logger)
+		mux.Handle(modulesPath, adminHandler)
+		mux.Handle(modulesPath+"/", adminHandler)
+		if artifactStore != nil {
+			artifactsPath := path.Join(*routePrefix, "/api/v1/artifacts")
+			mux.Handle(artifactsPath+"/", requireToken(adminToken, artifactStore.Handler(artifactsPath)))
+		}
+	}
+	if h := prober.FailpointsHandler(path.Join(*routePrefix, "/debug/failpoints")); h != nil {
+		logger.Warn("Failpoints are enabled, do not use this build in production")
+		mux.Handle(path.Join(*routePrefix, "/debug/failpoints"), h)
+		mux.Handle(path.Join(*routePrefix, "/debug/failpoints")+"/", h)
+	}
+	if *enableAdminAPI {
+		// The pprof handlers expect to be served under /debug/pprof/.
+		pprofPrefix := strings.TrimSuffix(*routePrefix, "/")
+		mux.Handle(path.Join(*routePrefix, "/debug/pprof")+"/", http.StripPrefix(pprofPrefix, http.HandlerFunc(pprof.Index)))
+		mux.Handle(path.Join(*routePrefix, "/debug/pprof/cmdline"), http.StripPrefix(pprofPrefix, http.HandlerFunc(pprof.Cmdline)))
+		mux.Handle(path.Join(*routePrefix, "/debug/pprof/profile"), http.StripPrefix(pprofPrefix, http.HandlerFunc(pprof.Profile)))
+		mux.Handle(path.Join(*routePrefix, "/debug/pprof/symbol"), http.StripPrefix(pprofPrefix, http.HandlerFunc(pprof.Symbol)))
+		mux.Handle(path.Join(*routePrefix, "/debug/pprof/trace"), http.StripPrefix(pprofPrefix, http.HandlerFunc(pprof.Trace)))
+		mux.Handle(path.Join(*routePrefix, "/api/v1/admin/runtime"), adminRuntimeHandler(logger))
+	}
+	mux.Handle(path.Join(*routePrefix, "/metrics"), promhttp.Handler())
+	mux.HandleFunc(path.Join(*routePrefix, "/-/healthy"), func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Healthy"))
 	})
-	http.HandleFunc(path.Join(*routePrefix, "/probe"), func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(path.Join(*routePrefix, "/probe"), func(w http.ResponseWriter, r *http.Request) {
 		sc.Lock()
 		conf := sc.C
 		sc.Unlock()
 		prober.Handler(w, r, conf, logger, rh, *timeoutOffset, nil, moduleUnknownCounter, allowedLevel)
 	})
-	http.HandleFunc(*routePrefix, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(*routePrefix, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`<html>
     <head><title>Blackbox Exporter</title></head>
@@ -217,7 +406,7 @@ func run() int {
     </html>`))
 	})
 
-	http.HandleFunc(path.Join(*routePrefix, "/logs"), func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(path.Join(*routePrefix, "/logs"), func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
 		if err != nil {
 			id = -1
@@ -250,7 +439,7 @@ func run() int {
 		w.Write([]byte(result.DebugOutput))
 	})
 
-	http.HandleFunc(path.Join(*routePrefix, "/config"), func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(path.Join(*routePrefix, "/config"), func(w http.ResponseWriter, r *http.Request) {
 		sc.RLock()
 		c, err := yaml.Marshal(sc.C)
 		sc.RUnlock()
@@ -263,28 +452,7 @@ func run() int {
 		w.Write(c)
 	})
 
-	srv := &http.Server{}
-	srvc := make(chan struct{})
-	term := make(chan os.Signal, 1)
-	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		if err := web.ListenAndServe(srv, toolkitFlags, logger); err != nil {
-			logger.Error("Error starting HTTP server", "err", err)
-			close(srvc)
-		}
-	}()
-
-	for {
-		select {
-		case <-term:
-			logger.Info("Received SIGTERM, exiting gracefully...")
-			return 0
-		case <-srvc:
-			return 1
-		}
-	}
-
+	return mux
 }
 
 func startsOrEndsWithQuote(s string) bool {
@@ -13,7 +13,17 @@
 
 package main
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/prober"
+)
 
 func TestComputeExternalURL(t *testing.T) {
 	tests := []struct {
@@ -67,3 +77,33 @@ func TestComputeExternalURL(t *testing.T) {
 		}
 	}
 }
+
+func TestNewRouterAdminAPI(t *testing.T) {
+	defer func(prefix string, enabled bool) {
+		*routePrefix, *enableAdminAPI = prefix, enabled
+	}(*routePrefix, *enableAdminAPI)
+
+	for _, prefix := range []string{"/", "/blackbox/"} {
+		for _, enabled := range []bool{false, true} {
+			*routePrefix, *enableAdminAPI = prefix, enabled
+			beURL, err := url.Parse("http://localhost:9115" + prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Building the routes must not conflict with the ones
+			// net/http/pprof adds to http.DefaultServeMux.
+			router := newRouter(beURL, make(chan chan error), &prober.ResultHistory{}, nil, "", promslog.NewNopLogger(), &promslog.AllowedLevel{})
+
+			// Unknown paths are served the landing page.
+			for _, path := range []string{prefix + "debug/pprof/", prefix + "debug/pprof/cmdline", "/debug/pprof/cmdline", prefix + "api/v1/admin/runtime"} {
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+				served := rr.Code == http.StatusOK && !strings.Contains(rr.Body.String(), "<h1>Blackbox Exporter</h1>")
+				// pprof is never served outside of the route prefix.
+				if expected := enabled && strings.HasPrefix(path, prefix); served != expected {
+					t.Errorf("Expected %s to be served %t with prefix %s and admin API %t, got status %d", path, expected, prefix, enabled, rr.Code)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+	"github.com/prometheus/blackbox_exporter/prober"
+)
+
+// Exit codes of one-shot probes.
+const (
+	probeExitSucceeded = 0
+	probeExitFailed    = 1
+	probeExitError     = 2
+)
+
+// probeResponse records the response of the probe handler.
+type probeResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *probeResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *probeResponse) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *probeResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// probeOnce runs a single probe as a request to /probe with the params, and
+// writes the response, metrics by default, to out. It returns the exit code
+// of the probe command.
+func probeOnce(ctx context.Context, c *config.Config, params url.Values, out io.Writer, logger *slog.Logger, logLevel *promslog.AllowedLevel) int {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/probe?"+params.Encode(), nil)
+	if err != nil {
+		logger.Error("Error creating probe request", "err", err)
+		return probeExitError
+	}
+	// The history tells whether the probe succeeded, whatever the format of
+	// the response.
+	rh := &prober.ResultHistory{MaxResults: 1}
+	resp := &probeResponse{header: http.Header{}}
+	prober.Handler(resp, req, c, logger, rh, 0, nil, nil, logLevel)
+	if resp.status != 0 && resp.status != http.StatusOK {
+		logger.Error("Error running probe", "err", strings.TrimSpace(resp.body.String()))
+		return probeExitError
+	}
+	if _, err := out.Write(resp.body.Bytes()); err != nil {
+		logger.Error("Error writing probe output", "err", err)
+		return probeExitError
+	}
+	results := rh.List()
+	if len(results) == 0 {
+		return probeExitError
+	}
+	if !results[0].Success {
+		return probeExitFailed
+	}
+	return probeExitSucceeded
+}
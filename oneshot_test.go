@@ -0,0 +1,76 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProbeOnce(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{Modules: map[string]config.Module{
+		"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+	}}
+	for _, test := range []struct {
+		name     string
+		params   url.Values
+		exitCode int
+		output   string
+	}{
+		{
+			name:     "success",
+			params:   url.Values{"module": {"http_2xx"}, "target": {ts.URL}},
+			exitCode: probeExitSucceeded,
+			output:   "probe_success 1",
+		},
+		{
+			name:     "failure",
+			params:   url.Values{"module": {"http_2xx"}, "target": {ts.URL + "/missing"}, "format": {"json"}},
+			exitCode: probeExitFailed,
+			output:   `"success":false`,
+		},
+		{
+			name:     "unknown module",
+			params:   url.Values{"module": {"http_3xx"}, "target": {ts.URL}},
+			exitCode: probeExitError,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if exitCode := probeOnce(context.Background(), c, test.params, &out, promslog.NewNopLogger(), &promslog.AllowedLevel{}); exitCode != test.exitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", test.exitCode, exitCode, out.String())
+			}
+			if !strings.Contains(out.String(), test.output) {
+				t.Errorf("Expected %q in output, got %s", test.output, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// inActiveHours returns whether the time is within one of the active hours
+// of the module.
+func inActiveHours(module config.Module, now time.Time) bool {
+	for _, window := range module.ActiveHours {
+		if window.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// runProbeInActiveHours runs the probe if the time is within the active
+// hours of the module. Otherwise the target is not contacted and the probe
+// succeeds, so that planned shutdowns of targets do not fire alerts, and
+// probe_skipped tells that it was skipped.
+func runProbeInActiveHours(ctx context.Context, prober ProbeFn, target string, module config.Module, logger *slog.Logger, now time.Time) *ProbeResult {
+	if len(module.ActiveHours) == 0 {
+		return runProbeWithRetries(ctx, prober, target, module, logger)
+	}
+
+	var result *ProbeResult
+	skipped := 0.0
+	if inActiveHours(module, now) {
+		result = runProbeWithRetries(ctx, prober, target, module, logger)
+	} else {
+		logger.Info("Skipping probe outside of the active hours of the module")
+		result = &ProbeResult{
+			Prober:  module.Prober,
+			Target:  target,
+			Success: true,
+		}
+		skipped = 1
+	}
+	result.Observations = append(result.Observations, Observation{
+		Name:  "probe_skipped",
+		Help:  "Indicates if the probe was skipped as it is outside of the active hours of the module",
+		Type:  ObservationGauge,
+		Value: skipped,
+	})
+	return result
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestRunProbeInActiveHours(t *testing.T) {
+	var probed bool
+	prober := func(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+		probed = true
+		return false
+	}
+	// Friday 16 October 2026.
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		activeHours []config.TimeWindow
+		probed      bool
+		skipped     float64
+	}{
+		{name: "no active hours", probed: true, skipped: -1},
+		{name: "active", activeHours: []config.TimeWindow{config.MustNewTimeWindow(nil, "20:00", "06:00", "UTC")}, probed: true},
+		{
+			name: "inactive",
+			activeHours: []config.TimeWindow{
+				config.MustNewTimeWindow(nil, "06:00", "20:00", "UTC"),
+				config.MustNewTimeWindow([]string{"saturday"}, "20:00", "06:00", "UTC"),
+			},
+			skipped: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probed = false
+			module := config.Module{Prober: "tcp", ActiveHours: test.activeHours}
+			result := runProbeInActiveHours(context.Background(), prober, "target", module, promslog.NewNopLogger(), now)
+			if probed != test.probed {
+				t.Fatalf("Expected probed %v, got %v", test.probed, probed)
+			}
+			// Skipped probes succeed, the others fail.
+			if result.Success == test.probed {
+				t.Errorf("Unexpected success %v", result.Success)
+			}
+			skipped := -1.0
+			for _, o := range result.Observations {
+				if o.Name == "probe_skipped" {
+					skipped = o.Value
+				}
+			}
+			if skipped != test.skipped {
+				t.Errorf("Expected probe_skipped %v, got %v", test.skipped, skipped)
+			}
+		})
+	}
+}
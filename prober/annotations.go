@@ -0,0 +1,117 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// annotationTimeout bounds the time spent posting an annotation, which is
+// done after the probe request has been answered.
+const annotationTimeout = 10 * time.Second
+
+var annotationsFailedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "blackbox_grafana_annotations_failed_total",
+	Help: "Count of annotations of probe state changes that could not be posted to Grafana",
+})
+
+// grafanaAnnotation is the body of a request to the annotations API of
+// Grafana.
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// newStateChangeAnnotation describes the new state of the probes of a
+// target. The module and the target are also tags, so that dashboards can
+// select the annotations of the targets they show.
+func newStateChangeAnnotation(c *config.GrafanaAnnotations, moduleName, target string, result *ProbeResult, now time.Time) grafanaAnnotation {
+	state, text := "up", fmt.Sprintf("Probe of %s with module %s succeeded", target, moduleName)
+	if !result.Success {
+		reason := "no error was logged"
+		if len(result.Errors) > 0 {
+			reason = result.Errors[0]
+		}
+		state, text = "down", fmt.Sprintf("Probe of %s with module %s failed: %s", target, moduleName, reason)
+	}
+	tags := append([]string{}, c.Tags...)
+	tags = append(tags, "module:"+moduleName, "target:"+target, "state:"+state)
+	return grafanaAnnotation{
+		DashboardUID: c.DashboardUID,
+		Time:         now.UnixMilli(),
+		Tags:         tags,
+		Text:         text,
+	}
+}
+
+func postGrafanaAnnotation(ctx context.Context, c *config.GrafanaAnnotations, annotation grafanaAnnotation) error {
+	client, err := pconfig.NewClientFromConfig(c.HTTPClientConfig, "grafana_annotations")
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+"/api/annotations", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgentDefaultHeader)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// annotateStateChange posts an annotation in the background if the success
+// of the probe differs from the previous probe of the target with the
+// module. The first probe of a target is not annotated.
+func annotateStateChange(c *config.GrafanaAnnotations, moduleName, target string, result *ProbeResult, now time.Time, logger *slog.Logger) {
+	key := valueChangeKey{module: moduleName, target: target, kind: "success"}
+	if changed, ok := valueChanges.observe(key, strconv.FormatBool(result.Success), now); !ok || !changed {
+		return
+	}
+	annotation := newStateChangeAnnotation(c, moduleName, target, result, now)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), annotationTimeout)
+		defer cancel()
+		if err := postGrafanaAnnotation(ctx, c, annotation); err != nil {
+			annotationsFailedCounter.Inc()
+			logger.Error("Error posting annotation to Grafana", "module", moduleName, "target", target, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestAnnotateStateChange(t *testing.T) {
+	annotations := make(chan grafanaAnnotation, 3)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/annotations" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var a grafanaAnnotation
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("Error decoding annotation: %s", err)
+		}
+		annotations <- a
+	}))
+	defer ts.Close()
+
+	c := &config.GrafanaAnnotations{
+		URL:          ts.URL + "/",
+		DashboardUID: "probes",
+		Tags:         []string{"blackbox"},
+		HTTPClientConfig: pconfig.HTTPClientConfig{
+			Authorization: &pconfig.Authorization{Type: "Bearer", Credentials: "token"},
+		},
+	}
+	target := "annotations.example.com"
+	now := time.Now()
+	for i, result := range []*ProbeResult{
+		{Success: true},
+		{Success: true},
+		{Success: false, Errors: []string{"Error resolving address", "Probe failed"}},
+	} {
+		annotateStateChange(c, "http_2xx", target, result, now.Add(time.Duration(i)*time.Second), promslog.NewNopLogger())
+	}
+
+	select {
+	case a := <-annotations:
+		if a.Text != "Probe of annotations.example.com with module http_2xx failed: Error resolving address" {
+			t.Errorf("Unexpected text %q", a.Text)
+		}
+		if a.DashboardUID != "probes" || a.Time != now.Add(2*time.Second).UnixMilli() {
+			t.Errorf("Unexpected annotation %+v", a)
+		}
+		if expected := []string{"blackbox", "module:http_2xx", "target:annotations.example.com", "state:down"}; !slices.Equal(a.Tags, expected) {
+			t.Errorf("Expected tags %v, got %v", expected, a.Tags)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No annotation posted")
+	}
+	select {
+	case a := <-annotations:
+		t.Errorf("Unexpected annotation %+v", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
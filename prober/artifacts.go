@@ -0,0 +1,225 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxArtifactBodySize is the maximum size of the body saved in an artifact,
+// longer bodies are truncated.
+const maxArtifactBodySize = 1 << 20
+
+var artifactIDRegexp = regexp.MustCompile(`^[0-9]+-[0-9a-f]{8}$`)
+
+// artifactStore is where the responses of failed probes are saved, nil if
+// they are not.
+var artifactStore *ArtifactStore
+
+// EnableArtifacts makes the failed probes of the http prober save their
+// response to store.
+func EnableArtifacts(store *ArtifactStore) {
+	artifactStore = store
+}
+
+// ArtifactStore keeps the responses of failed probes in a directory, for as
+// long as the retention and within a maximum total size.
+type ArtifactStore struct {
+	dir       string
+	maxSize   int64
+	retention time.Duration
+
+	mu sync.Mutex
+}
+
+// NewArtifactStore returns a store of artifacts in dir, which is created if
+// it does not exist.
+func NewArtifactStore(dir string, maxSize int64, retention time.Duration) (*ArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &ArtifactStore{dir: dir, maxSize: maxSize, retention: retention}, nil
+}
+
+// save writes the artifact of a failed probe and returns its ID. The oldest
+// artifacts are then removed, past the retention or the maximum size.
+func (s *ArtifactStore) save(module, target string, a *probeArtifact, now time.Time) (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("%d-%s", now.Unix(), hex.EncodeToString(random))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Module: %s\nTarget: %s\nTime: %s\n\n", module, target, now.UTC().Format(time.RFC3339))
+	a.writeTo(&buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.WriteFile(filepath.Join(s.dir, id), buf.Bytes(), 0o600); err != nil {
+		return "", err
+	}
+	s.prune(now)
+	return id, nil
+}
+
+// prune removes the artifacts older than the retention, and then the oldest
+// ones until their total size is within the maximum. Artifacts that cannot
+// be removed are left for the next time.
+func (s *ArtifactStore) prune(now time.Time) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	type artifactFile struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		files []artifactFile
+		total int64
+	)
+	for _, entry := range entries {
+		if !artifactIDRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if s.retention > 0 && now.Sub(info.ModTime()) > s.retention {
+			os.Remove(filepath.Join(s.dir, entry.Name()))
+			continue
+		}
+		files = append(files, artifactFile{entry.Name(), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if s.maxSize <= 0 || total <= s.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err == nil {
+			total -= f.size
+		}
+	}
+}
+
+// Handler serves the artifacts at <prefix>/<id>.
+func (s *ArtifactStore) Handler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "This endpoint requires a GET request.", http.StatusMethodNotAllowed)
+			return
+		}
+		id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if !artifactIDRegexp.MatchString(id) {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		b, err := os.ReadFile(filepath.Join(s.dir, id))
+		s.mu.Unlock()
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(b)
+	})
+}
+
+// probeArtifact is the last response received by a probe.
+type probeArtifact struct {
+	mu     sync.Mutex
+	status string
+	proto  string
+	header http.Header
+	body   bytes.Buffer
+}
+
+type probeArtifactKey struct{}
+
+// withProbeArtifact returns a context making the http prober record its
+// response in a.
+func withProbeArtifact(ctx context.Context, a *probeArtifact) context.Context {
+	return context.WithValue(ctx, probeArtifactKey{}, a)
+}
+
+// recordResponse records the status and headers of resp in the artifact of
+// the probe, if any, and returns the body of resp, which records what is
+// read of it.
+func recordResponse(ctx context.Context, resp *http.Response) io.ReadCloser {
+	a, _ := ctx.Value(probeArtifactKey{}).(*probeArtifact)
+	if a == nil {
+		return resp.Body
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = resp.Status
+	a.proto = resp.Proto
+	a.header = resp.Header.Clone()
+	a.body.Reset()
+	return &artifactBody{ReadCloser: resp.Body, a: a}
+}
+
+func (a *probeArtifact) recorded() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status != ""
+}
+
+func (a *probeArtifact) writeTo(w io.Writer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	fmt.Fprintf(w, "%s %s\r\n", a.proto, a.status)
+	a.header.Write(w)
+	io.WriteString(w, "\r\n")
+	w.Write(a.body.Bytes())
+}
+
+// artifactBody records the start of the body read in its artifact.
+type artifactBody struct {
+	io.ReadCloser
+	a *probeArtifact
+}
+
+func (b *artifactBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.a.mu.Lock()
+	if room := maxArtifactBodySize - b.a.body.Len(); room > 0 {
+		b.a.body.Write(p[:min(n, room)])
+	}
+	b.a.mu.Unlock()
+	return n, err
+}
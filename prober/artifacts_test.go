@@ -0,0 +1,124 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestHandlerArtifacts(t *testing.T) {
+	store, err := NewArtifactStore(t.TempDir(), 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	EnableArtifacts(store)
+	t.Cleanup(func() { EnableArtifacts(nil) })
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.Header().Set("X-Request-Id", "abc123")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("database is down"))
+		}
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{
+		Modules: map[string]config.Module{
+			"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+		},
+	}
+	for _, path := range []string{"/ok", "/fail"} {
+		req, err := http.NewRequest("GET", "?debug=true&module=http_2xx&target="+ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		if saved := strings.Contains(rr.Body.String(), "Saved the response of the failed probe"); saved != (path == "/fail") {
+			t.Errorf("Expected the response of %s to be saved: %t, got debug output %s", path, path == "/fail", rr.Body.String())
+		}
+	}
+
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected one artifact, got %d", len(entries))
+	}
+	rr := httptest.NewRecorder()
+	store.Handler("/api/v1/artifacts").ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/artifacts/"+entries[0].Name(), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the artifact, got %d", rr.Code)
+	}
+	for _, want := range []string{"Module: http_2xx", "Target: " + ts.URL + "/fail", "500 Internal Server Error", "X-Request-Id: abc123", "database is down"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("Expected the artifact to contain %q, got %s", want, rr.Body.String())
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	store.Handler("/api/v1/artifacts").ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/artifacts/../blackbox.yml", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an invalid ID, got %d", rr.Code)
+	}
+}
+
+func TestArtifactStorePrune(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewArtifactStore(dir, 250, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	write := func(id string, age time.Duration) {
+		file := filepath.Join(dir, id)
+		if err := os.WriteFile(file, make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("1-00000001", 2*time.Hour)
+	write("2-00000002", 30*time.Minute)
+	write("3-00000003", 20*time.Minute)
+	write("4-00000004", 10*time.Minute)
+	write("unrelated", 2*time.Hour)
+	store.prune(now)
+
+	var names []string
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// The first is past the retention, the second over the maximum size.
+	if got := strings.Join(names, ","); got != "3-00000003,4-00000004,unrelated" {
+		t.Errorf("Unexpected artifacts after pruning: %s", got)
+	}
+}
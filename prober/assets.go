@@ -0,0 +1,216 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/html"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// assetLinkRels are the rel values of <link> elements whose href is fetched
+// as part of the page.
+var assetLinkRels = map[string]bool{
+	"icon":             true,
+	"shortcut":         true,
+	"apple-touch-icon": true,
+	"stylesheet":       true,
+	"preload":          true,
+	"modulepreload":    true,
+	"manifest":         true,
+}
+
+// extractAssetURLs returns the URLs of the resources referenced by an HTML
+// document, resolved against base, in document order and without duplicates.
+// If the document does not reference an icon, /favicon.ico is added, as
+// browsers request it anyway.
+func extractAssetURLs(body []byte, base *url.URL) []*url.URL {
+	var (
+		refs    []string
+		hasIcon bool
+	)
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		attrs := map[string]string{}
+		for _, attr := range token.Attr {
+			attrs[strings.ToLower(attr.Key)] = attr.Val
+		}
+		switch token.Data {
+		case "base":
+			if href, ok := attrs["href"]; ok {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+		case "img", "script", "source", "audio", "video", "iframe":
+			if src := attrs["src"]; src != "" {
+				refs = append(refs, src)
+			}
+		case "link":
+			for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+				if assetLinkRels[rel] && attrs["href"] != "" {
+					refs = append(refs, attrs["href"])
+					if strings.Contains(rel, "icon") || rel == "shortcut" {
+						hasIcon = true
+					}
+					break
+				}
+			}
+		}
+	}
+	if !hasIcon {
+		refs = append(refs, "/favicon.ico")
+	}
+
+	var urls []*url.URL
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		if seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// crawlAssets fetches the same-origin resources referenced by the landing
+// page, up to the configured maximum, and exports the status codes of the
+// responses and the total weight of the page.
+func crawlAssets(ctx context.Context, rt http.RoundTripper, resp *http.Response, body []byte, bodyBytes int64, httpConfig config.HTTPProbe, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		discoveredGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_assets_discovered",
+			Help: "Number of same-origin resources referenced by the page",
+		})
+		responsesGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_http_asset_responses",
+			Help: "Number of fetched resources by response status code",
+		}, []string{"status_code"})
+		errorsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_asset_errors",
+			Help: "Number of resources that could not be fetched",
+		})
+		pageWeightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_page_weight_bytes",
+			Help: "Total size of the page and the fetched resources in bytes",
+		})
+	)
+	registry.MustRegister(discoveredGauge, responsesGaugeVec, errorsGauge, pageWeightGauge)
+
+	// The request URL carries the resolved IP address, while the original
+	// hostname is kept in the Host field.
+	base := resp.Request.URL
+	host := resp.Request.Host
+	if host == "" {
+		host = base.Host
+	}
+	var assets []*url.URL
+	for _, u := range extractAssetURLs(body, base) {
+		if u.Scheme != base.Scheme {
+			continue
+		}
+		switch u.Host {
+		case base.Host:
+		case host:
+			u.Host = base.Host
+		default:
+			continue
+		}
+		assets = append(assets, u)
+	}
+	discoveredGauge.Set(float64(len(assets)))
+	if len(assets) > httpConfig.CrawlAssets.MaxResources {
+		logger.Info("Limiting number of fetched resources", "discovered", len(assets), "max_resources", httpConfig.CrawlAssets.MaxResources)
+		assets = assets[:httpConfig.CrawlAssets.MaxResources]
+	}
+
+	client := &http.Client{
+		Transport: rt,
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			if r.URL.Host != base.Host || len(via) > 10 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	broken := 0
+	weight := bodyBytes
+	for _, u := range assets {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			logger.Error("Error creating resource request", "url", u.String(), "err", err)
+			errorsGauge.Add(1)
+			broken++
+			continue
+		}
+		req.Host = host
+		req.Header.Set("User-Agent", resp.Request.Header.Get("User-Agent"))
+
+		assetResp, err := client.Do(req)
+		if err != nil {
+			logger.Info("Error fetching resource", "url", u.String(), "err", err)
+			errorsGauge.Add(1)
+			broken++
+			continue
+		}
+		n, err := io.Copy(io.Discard, assetResp.Body)
+		assetResp.Body.Close()
+		weight += n
+		if err != nil {
+			logger.Info("Error reading resource", "url", u.String(), "err", err)
+			errorsGauge.Add(1)
+			broken++
+			continue
+		}
+		logger.Debug("Fetched resource", "url", u.String(), "status_code", assetResp.StatusCode, "bytes", n)
+		responsesGaugeVec.WithLabelValues(strconv.Itoa(assetResp.StatusCode)).Add(1)
+		if assetResp.StatusCode >= 400 {
+			logger.Info("Resource returned an error status code", "url", u.String(), "status_code", assetResp.StatusCode)
+			broken++
+		}
+	}
+	pageWeightGauge.Set(float64(weight))
+	logger.Info("Fetched page resources", "resources", len(assets), "broken", broken, "page_weight_bytes", weight)
+
+	if httpConfig.CrawlAssets.FailIfBroken && broken > 0 {
+		logger.Error("Some of the page resources are broken", "broken", broken)
+		return false
+	}
+	return true
+}
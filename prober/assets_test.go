@@ -0,0 +1,128 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestExtractAssetURLs(t *testing.T) {
+	base, _ := url.Parse("https://example.com/dir/page.html")
+	body := []byte(`<html><head>
+<link rel="stylesheet" href="style.css">
+<link rel="canonical" href="https://example.com/">
+<link rel="shortcut icon" href="/img/favicon.png">
+<script src="https://cdn.example.net/lib.js"></script>
+</head><body>
+<img src="/logo.png"><img src="/logo.png#dup"><img src="data:image/png;base64,AAAA">
+<a href="/other.html">link</a>
+</body></html>`)
+
+	expected := []string{
+		"https://example.com/dir/style.css",
+		"https://example.com/img/favicon.png",
+		"https://cdn.example.net/lib.js",
+		"https://example.com/logo.png",
+	}
+	urls := extractAssetURLs(body, base)
+	if len(urls) != len(expected) {
+		t.Fatalf("Expected %d URLs, got %v", len(expected), urls)
+	}
+	for i, u := range urls {
+		if u.String() != expected[i] {
+			t.Fatalf("Expected URL %d to be %s, got %s", i, expected[i], u)
+		}
+	}
+
+	// Without an icon link, the default favicon location is added.
+	urls = extractAssetURLs([]byte(`<img src="a.png">`), base)
+	if len(urls) != 2 || urls[1].String() != "https://example.com/favicon.ico" {
+		t.Fatalf("Expected favicon.ico to be added, got %v", urls)
+	}
+}
+
+func TestCrawlAssets(t *testing.T) {
+	page := `<html><head><link rel="stylesheet" href="/style.css"><script src="/missing.js"></script>
+<script src="https://cdn.example.net/lib.js"></script></head><body><img src="/logo.png"></body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(page))
+		case "/style.css":
+			w.Write([]byte("body{}"))
+		case "/logo.png", "/favicon.ico":
+			w.Write([]byte("0123456789"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	testcases := map[string]struct {
+		crawl              config.AssetCrawlConfig
+		expectedResult     bool
+		expectedDiscovered float64
+		expectedWeight     float64
+	}{
+		"all resources, broken allowed": {
+			crawl:              config.AssetCrawlConfig{MaxResources: 10},
+			expectedResult:     true,
+			expectedDiscovered: 4,
+			expectedWeight:     float64(len(page) + len("body{}") + len("404 page not found\n") + 20),
+		},
+		"all resources, fail if broken": {
+			crawl:              config.AssetCrawlConfig{MaxResources: 10, FailIfBroken: true},
+			expectedResult:     false,
+			expectedDiscovered: 4,
+			expectedWeight:     float64(len(page) + len("body{}") + len("404 page not found\n") + 20),
+		},
+		"limited to first resource": {
+			crawl:              config.AssetCrawlConfig{MaxResources: 1, FailIfBroken: true},
+			expectedResult:     true,
+			expectedDiscovered: 4,
+			expectedWeight:     float64(len(page) + len("body{}")),
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, CrawlAssets: tc.crawl}}
+			if result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{
+				"probe_http_assets_discovered": tc.expectedDiscovered,
+				"probe_http_page_weight_bytes": tc.expectedWeight,
+			}, mfs, t)
+		})
+	}
+}
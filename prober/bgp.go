@@ -0,0 +1,319 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// BGP messages, see RFC 4271.
+const (
+	bgpDefaultPort   = "179"
+	bgpHeaderSize    = 19
+	bgpMaxMessageLen = 4096
+
+	bgpOpen         = 1
+	bgpUpdate       = 2
+	bgpNotification = 3
+	bgpKeepalive    = 4
+
+	bgpCapabilityParam      = 2
+	bgpCapabilityMP         = 1
+	bgpCapabilityFourOctets = 65
+
+	// bgpASTrans stands for 4-octet AS numbers in the 2-octet field of the
+	// OPEN message, see RFC 6793.
+	bgpASTrans = 23456
+)
+
+var bgpMarker = bytes.Repeat([]byte{0xff}, 16)
+
+// bgpNotificationErrors are the names of the error codes of NOTIFICATION
+// messages.
+var bgpNotificationErrors = map[byte]string{
+	1: "message header error",
+	2: "OPEN message error",
+	3: "UPDATE message error",
+	4: "hold timer expired",
+	5: "finite state machine error",
+	6: "cease",
+}
+
+func encodeBGPMessage(msgType byte, body []byte) []byte {
+	b := append([]byte(nil), bgpMarker...)
+	b = binary.BigEndian.AppendUint16(b, uint16(bgpHeaderSize+len(body)))
+	b = append(b, msgType)
+	return append(b, body...)
+}
+
+// readBGPMessage reads a message and returns its type and body.
+func readBGPMessage(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, bgpHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(header[:16], bgpMarker) {
+		return 0, nil, errors.New("invalid BGP message marker")
+	}
+	length := int(binary.BigEndian.Uint16(header[16:]))
+	if length < bgpHeaderSize || length > bgpMaxMessageLen {
+		return 0, nil, fmt.Errorf("invalid BGP message length %d", length)
+	}
+	body := make([]byte, length-bgpHeaderSize)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[18], body, nil
+}
+
+// bgpOpenMessage is the content of an OPEN message.
+type bgpOpenMessage struct {
+	version  byte
+	asn      uint32
+	holdTime uint16
+	routerID net.IP
+}
+
+func encodeBGPOpen(asn uint32, holdTime uint16, routerID net.IP) []byte {
+	shortASN := uint16(bgpASTrans)
+	if asn <= 0xffff {
+		shortASN = uint16(asn)
+	}
+	capabilities := []byte{
+		// Multiprotocol extensions for IPv4 unicast.
+		bgpCapabilityMP, 4, 0, 1, 0, 1,
+		bgpCapabilityFourOctets, 4,
+	}
+	capabilities = binary.BigEndian.AppendUint32(capabilities, asn)
+
+	body := []byte{4}
+	body = binary.BigEndian.AppendUint16(body, shortASN)
+	body = binary.BigEndian.AppendUint16(body, holdTime)
+	body = append(body, routerID.To4()...)
+	body = append(body, byte(2+len(capabilities)), bgpCapabilityParam, byte(len(capabilities)))
+	body = append(body, capabilities...)
+	return encodeBGPMessage(bgpOpen, body)
+}
+
+func decodeBGPOpen(body []byte) (*bgpOpenMessage, error) {
+	if len(body) < 10 || len(body) != 10+int(body[9]) {
+		return nil, errors.New("invalid OPEN message length")
+	}
+	open := &bgpOpenMessage{
+		version:  body[0],
+		asn:      uint32(binary.BigEndian.Uint16(body[1:])),
+		holdTime: binary.BigEndian.Uint16(body[3:]),
+		routerID: net.IP(body[5:9]),
+	}
+	for params := body[10:]; len(params) > 0; {
+		if len(params) < 2 || len(params) < 2+int(params[1]) {
+			return nil, errors.New("invalid OPEN message parameter")
+		}
+		paramType, value := params[0], params[2:2+int(params[1])]
+		params = params[2+int(params[1]):]
+		if paramType != bgpCapabilityParam {
+			continue
+		}
+		for len(value) > 0 {
+			if len(value) < 2 || len(value) < 2+int(value[1]) {
+				return nil, errors.New("invalid OPEN message capability")
+			}
+			code, capability := value[0], value[2:2+int(value[1])]
+			value = value[2+int(value[1]):]
+			if code == bgpCapabilityFourOctets && len(capability) == 4 {
+				open.asn = binary.BigEndian.Uint32(capability)
+			}
+		}
+	}
+	return open, nil
+}
+
+func bgpNotificationError(body []byte) error {
+	if len(body) < 2 {
+		return errors.New("peer sent a NOTIFICATION")
+	}
+	name, ok := bgpNotificationErrors[body[0]]
+	if !ok {
+		name = fmt.Sprintf("error code %d", body[0])
+	}
+	return fmt.Errorf("peer sent a NOTIFICATION: %s, subcode %d", name, body[1])
+}
+
+// ProbeBGP establishes a BGP session with the target, as a passive test peer
+// that announces no routes, and closes it once the peer accepted the OPEN
+// message.
+func ProbeBGP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_bgp_duration_seconds",
+			Help: "Duration of the BGP session establishment by phase",
+		}, []string{"phase"})
+		holdTimeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_bgp_hold_time_seconds",
+			Help: "The hold time negotiated with the peer",
+		})
+		peerASNGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_bgp_peer_asn",
+			Help: "The AS number of the peer",
+		})
+		peerInfoGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_bgp_peer_info",
+			Help: "Contains the BGP identifier of the peer",
+		}, []string{"router_id"})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.BGP
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, bgpDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	dialProtocol := "tcp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "tcp6"
+	}
+	dialer := &net.Dialer{}
+	if len(c.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(c.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", c.SourceIPAddress)
+			return false
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.TCPAddr{IP: srcIP}
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), port))
+	durationGaugeVec.WithLabelValues("connect").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error dialing BGP peer", "err", err)
+		return false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			logger.Error("Error setting deadline", "err", err)
+			return false
+		}
+	}
+
+	routerID := net.ParseIP(c.RouterID).To4()
+	if routerID == nil {
+		// The local IPv4 address is the usual BGP identifier.
+		routerID = conn.LocalAddr().(*net.TCPAddr).IP.To4()
+		if routerID == nil {
+			logger.Error("router_id must be set for BGP sessions over IPv6")
+			return false
+		}
+	}
+	holdTime := uint16(c.HoldTime.Seconds())
+
+	logger.Info("Sending OPEN", "asn", c.LocalASN, "hold_time", holdTime, "router_id", routerID)
+	start = time.Now()
+	if _, err := conn.Write(encodeBGPOpen(c.LocalASN, holdTime, routerID)); err != nil {
+		logger.Error("Error sending OPEN", "err", err)
+		return false
+	}
+	msgType, body, err := readBGPMessage(conn)
+	durationGaugeVec.WithLabelValues("open").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error reading OPEN", "err", err)
+		return false
+	}
+	if msgType == bgpNotification {
+		logger.Error("Error establishing BGP session", "err", bgpNotificationError(body))
+		return false
+	}
+	if msgType != bgpOpen {
+		logger.Error("Expected OPEN message", "type", msgType)
+		return false
+	}
+	open, err := decodeBGPOpen(body)
+	if err != nil {
+		logger.Error("Error decoding OPEN", "err", err)
+		return false
+	}
+	logger.Info("Received OPEN", "version", open.version, "asn", open.asn, "hold_time", open.holdTime, "router_id", open.routerID)
+
+	negotiated := min(holdTime, open.holdTime)
+	registry.MustRegister(holdTimeGauge, peerASNGauge, peerInfoGaugeVec)
+	holdTimeGauge.Set(float64(negotiated))
+	peerASNGauge.Set(float64(open.asn))
+	peerInfoGaugeVec.WithLabelValues(open.routerID.String()).Set(1)
+
+	// OPEN message errors: unsupported version number, bad peer AS.
+	if open.version != 4 {
+		logger.Error("Unsupported BGP version", "version", open.version)
+		conn.Write(encodeBGPMessage(bgpNotification, []byte{2, 1}))
+		return false
+	}
+	if c.PeerASN != 0 && open.asn != c.PeerASN {
+		logger.Error("Unexpected peer AS number", "asn", open.asn, "expected", c.PeerASN)
+		conn.Write(encodeBGPMessage(bgpNotification, []byte{2, 2}))
+		return false
+	}
+
+	// The session is established once both peers acknowledged the OPEN of
+	// the other with a KEEPALIVE.
+	start = time.Now()
+	if _, err := conn.Write(encodeBGPMessage(bgpKeepalive, nil)); err != nil {
+		logger.Error("Error sending KEEPALIVE", "err", err)
+		return false
+	}
+	for {
+		msgType, body, err = readBGPMessage(conn)
+		if err != nil {
+			logger.Error("Error reading KEEPALIVE", "err", err)
+			return false
+		}
+		if msgType == bgpNotification {
+			logger.Error("Error establishing BGP session", "err", bgpNotificationError(body))
+			return false
+		}
+		if msgType == bgpKeepalive {
+			break
+		}
+		// Some peers send their UPDATE messages right away.
+		if msgType != bgpUpdate {
+			logger.Error("Unexpected BGP message", "type", msgType)
+			return false
+		}
+	}
+	durationGaugeVec.WithLabelValues("keepalive").Set(time.Since(start).Seconds())
+	logger.Info("BGP session established")
+
+	// Cease, administrative shutdown.
+	if _, err := conn.Write(encodeBGPMessage(bgpNotification, []byte{6, 2})); err != nil {
+		logger.Debug("Error sending NOTIFICATION", "err", err)
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startBGPTestPeer accepts a session from AS 65001 only, as AS 4200000001.
+func startBGPTestPeer(t *testing.T) (string, chan byte) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	// The type of the last message received from the probe.
+	last := make(chan byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(time.Second))
+		_, body, err := readBGPMessage(conn)
+		if err != nil {
+			return
+		}
+		open, err := decodeBGPOpen(body)
+		if err != nil {
+			return
+		}
+		if open.asn != 65001 {
+			// Bad peer AS.
+			conn.Write(encodeBGPMessage(bgpNotification, []byte{2, 2}))
+			last <- bgpOpen
+			return
+		}
+		conn.Write(encodeBGPOpen(4200000001, 30, net.IPv4(192, 0, 2, 1)))
+		conn.Write(encodeBGPMessage(bgpKeepalive, nil))
+		var msgType byte
+		for {
+			t, _, err := readBGPMessage(conn)
+			if err != nil {
+				break
+			}
+			msgType = t
+		}
+		last <- msgType
+	}()
+	return ln.Addr().String(), last
+}
+
+func TestBGP(t *testing.T) {
+	tests := []struct {
+		name    string
+		probe   config.BGPProbe
+		success bool
+		last    byte
+	}{
+		{name: "established", probe: config.BGPProbe{LocalASN: 65001, HoldTime: 90 * time.Second}, success: true, last: bgpNotification},
+		{name: "expected peer ASN", probe: config.BGPProbe{LocalASN: 65001, PeerASN: 4200000001, HoldTime: 90 * time.Second}, success: true, last: bgpNotification},
+		{name: "unexpected peer ASN", probe: config.BGPProbe{LocalASN: 65001, PeerASN: 65002, HoldTime: 90 * time.Second}, success: false, last: bgpNotification},
+		{name: "rejected", probe: config.BGPProbe{LocalASN: 65003, HoldTime: 90 * time.Second}, success: false, last: bgpOpen},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr, last := startBGPTestPeer(t)
+			test.probe.IPProtocol = "ip4"
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if success := ProbeBGP(testCTX, addr, config.Module{BGP: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if test.last != 0 {
+				if msgType := <-last; msgType != test.last {
+					t.Errorf("Expected the last message received by the peer to be of type %d, got %d", test.last, msgType)
+				}
+			}
+			if !test.success {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{
+				"probe_bgp_hold_time_seconds": 30,
+				"probe_bgp_peer_asn":          4200000001,
+			}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{
+				"probe_bgp_peer_info": {"router_id": "192.0.2.1"},
+			}, mfs, t)
+		})
+	}
+}
+
+func TestBGPOpenFourOctetASN(t *testing.T) {
+	_, body, err := readBGPMessage(bytes.NewReader(encodeBGPOpen(4200000001, 90, net.IPv4(192, 0, 2, 2))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asn := binary.BigEndian.Uint16(body[1:]); asn != bgpASTrans {
+		t.Errorf("Expected AS_TRANS in the 2-octet AS number field, got %d", asn)
+	}
+	open, err := decodeBGPOpen(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if open.asn != 4200000001 || open.holdTime != 90 || !open.routerID.Equal(net.IPv4(192, 0, 2, 2)) {
+		t.Fatalf("Unexpected OPEN message %+v", open)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// maxPreviousBodies bounds the number of targets whose previous body is kept.
+const maxPreviousBodies = 1000
+
+// bodyStore keeps the body of the previous response of targets compared
+// without a golden file.
+type bodyStore struct {
+	mu     sync.Mutex
+	bodies map[string][]byte
+}
+
+var previousBodies = &bodyStore{bodies: map[string][]byte{}}
+
+// swap stores the body of the target and returns the previous one.
+func (s *bodyStore) swap(target string, body []byte) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.bodies[target]
+	if !ok && len(s.bodies) >= maxPreviousBodies {
+		// Forget an arbitrary target, whose next body is then not compared.
+		for t := range s.bodies {
+			delete(s.bodies, t)
+			break
+		}
+	}
+	s.bodies[target] = bytes.Clone(body)
+	return previous, ok
+}
+
+// bodySimilarity returns the share of the lines of two bodies they have in
+// common, 1 for identical bodies and 0 for bodies sharing no line.
+func bodySimilarity(a, b []byte) float64 {
+	linesA := bytes.Split(a, []byte("\n"))
+	linesB := bytes.Split(b, []byte("\n"))
+	counts := make(map[string]int, len(linesA))
+	for _, line := range linesA {
+		counts[string(line)]++
+	}
+	common := 0
+	for _, line := range linesB {
+		if counts[string(line)] > 0 {
+			counts[string(line)]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(linesA)+len(linesB))
+}
+
+// compareBody compares the body to the golden file, or to the previous body
+// of the target, and exports whether it changed and how similar it is. The
+// first body of a target is not considered to have changed.
+func compareBody(target string, body []byte, c config.BodyComparison, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		changedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_body_changed",
+			Help: "Indicates if the body differs from the golden file or the previous response",
+		})
+		similarityGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_body_similarity_ratio",
+			Help: "Share of the lines of the body in common with the golden file or the previous response",
+		})
+	)
+
+	var expected []byte
+	if c.GoldenFile != "" {
+		var err error
+		expected, err = os.ReadFile(c.GoldenFile)
+		if err != nil {
+			logger.Error("Error reading golden file", "err", err)
+			return false
+		}
+	} else {
+		previous, ok := previousBodies.swap(target, body)
+		if !ok {
+			logger.Info("No previous body to compare the body to")
+			previous = body
+		}
+		expected = previous
+	}
+
+	registry.MustRegister(changedGauge, similarityGauge)
+	changed := !bytes.Equal(body, expected)
+	similarity := 1.0
+	if changed {
+		changedGauge.Set(1)
+		similarity = bodySimilarity(expected, body)
+	}
+	similarityGauge.Set(similarity)
+
+	success := true
+	if c.FailIfChanged && changed {
+		logger.Error("Body changed", "similarity", similarity)
+		success = false
+	}
+	if similarity < c.MinSimilarity {
+		logger.Error("Body is not similar enough", "similarity", similarity, "min_similarity", c.MinSimilarity)
+		success = false
+	}
+	return success
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestBodySimilarity(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected float64
+	}{
+		{"a\nb\nc\nd", "a\nb\nc\nd", 1},
+		{"a\nb\nc\nd", "a\nb\nc\nX", 0.75},
+		{"a\nb", "c\nd", 0},
+		{"a\nb\nc", "a\nb\nc\nd\ne", 0.75},
+	} {
+		if similarity := bodySimilarity([]byte(test.a), []byte(test.b)); similarity != test.expected {
+			t.Errorf("Expected similarity %v of %q and %q, got %v", test.expected, test.a, test.b, similarity)
+		}
+	}
+}
+
+func TestCompareBody(t *testing.T) {
+	body := "<html>\n<h1>Welcome</h1>\n<p>Static page</p>\n</html>\n"
+	golden := filepath.Join(t.TempDir(), "golden.html")
+	if err := os.WriteFile(golden, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	probe := func(t *testing.T, target string, c config.BodyComparison) (bool, map[string]float64) {
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, CompareBody: c}}
+		success := ProbeHTTP(testCTX, target, module, registry, promslog.NewNopLogger())
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]float64{}
+		for _, mf := range mfs {
+			values[mf.GetName()] = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+		return success, values
+	}
+
+	t.Run("golden file", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>\n<h1>Hacked</h1>\n<p>Static page</p>\n</html>\n"))
+		}))
+		defer ts.Close()
+
+		success, values := probe(t, ts.URL, config.BodyComparison{Enabled: true, GoldenFile: golden, MinSimilarity: 0.5})
+		if !success {
+			t.Error("Expected the probe to succeed above the minimum similarity")
+		}
+		if values["probe_http_body_changed"] != 1 || values["probe_http_body_similarity_ratio"] != 0.8 {
+			t.Errorf("Unexpected comparison %v", values)
+		}
+		if success, _ := probe(t, ts.URL, config.BodyComparison{Enabled: true, GoldenFile: golden, FailIfChanged: true}); success {
+			t.Error("Expected the probe of the changed body to fail")
+		}
+	})
+
+	t.Run("previous body", func(t *testing.T) {
+		current := body
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(current))
+		}))
+		defer ts.Close()
+
+		c := config.BodyComparison{Enabled: true, FailIfChanged: true}
+		for i, expected := range []bool{true, true, false, true} {
+			if i == 2 {
+				current = "<html>\n<h1>Hacked</h1>\n</html>\n"
+			}
+			success, values := probe(t, ts.URL, c)
+			if success != expected {
+				t.Fatalf("Expected success %v of probe %d, got %v (%v)", expected, i, success, values)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"golang.org/x/net/html"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// canonicalJSON re-encodes a JSON document. Objects are decoded as maps,
+// which are encoded with sorted keys.
+func canonicalJSON(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Keep the numbers as they are written.
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// stripHTMLTags returns the text of an HTML document, without the content of
+// scripts and styles.
+func stripHTMLTags(body []byte) ([]byte, error) {
+	var text bytes.Buffer
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	skip := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return text.Bytes(), nil
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			skip = string(name) == "script" || string(name) == "style"
+		case html.EndTagToken:
+			skip = false
+		case html.TextToken:
+			if !skip {
+				// The text is unescaped by the tokenizer.
+				text.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// canonicalizeBody applies the transformations of the configuration to the
+// body.
+func canonicalizeBody(body []byte, c config.BodyCanonicalization) ([]byte, error) {
+	var err error
+	if c.CanonicalJSON {
+		if body, err = canonicalJSON(body); err != nil {
+			return nil, err
+		}
+	}
+	if c.StripHTMLTags {
+		if body, err = stripHTMLTags(body); err != nil {
+			return nil, err
+		}
+	}
+	for _, re := range c.RemoveRegexp {
+		body = re.ReplaceAll(body, nil)
+	}
+	if c.StripWhitespace {
+		var lines [][]byte
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				lines = append(lines, line)
+			}
+		}
+		body = bytes.Join(lines, []byte("\n"))
+	}
+	if c.Lowercase {
+		body = bytes.ToLower(body)
+	}
+	return body, nil
+}
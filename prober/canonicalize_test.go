@@ -0,0 +1,104 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestCanonicalizeBody(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		c        config.BodyCanonicalization
+		expected string
+		err      bool
+	}{
+		"canonical json": {
+			body:     "{\n  \"b\": [1, 2.50],\n  \"a\": {\"d\": null, \"c\": \"x\"}\n}\n",
+			c:        config.BodyCanonicalization{CanonicalJSON: true},
+			expected: `{"a":{"c":"x","d":null},"b":[1,2.50]}`,
+		},
+		"invalid json": {
+			body: "<html></html>",
+			c:    config.BodyCanonicalization{CanonicalJSON: true},
+			err:  true,
+		},
+		"html": {
+			body:     "<html><head><style>h1 {}</style><script>var t = 1;</script></head>\n<body><h1>Tom &amp; Jerry</h1><!-- comment --></body></html>",
+			c:        config.BodyCanonicalization{StripHTMLTags: true},
+			expected: "\nTom & Jerry",
+		},
+		"whitespace and case": {
+			body:     "  Hello \r\n\n\tWorld\n",
+			c:        config.BodyCanonicalization{StripWhitespace: true, Lowercase: true},
+			expected: "hello\nworld",
+		},
+		"remove regexp": {
+			body:     `<input name="csrf" value="a1b2c3"> generated 2026-10-16T17:00:00Z`,
+			c:        config.BodyCanonicalization{RemoveRegexp: []config.Regexp{config.MustNewRegexp(`value="\w+"`), config.MustNewRegexp(`\d{4}-\d{2}-\d{2}T[0-9:]+Z`)}},
+			expected: `<input name="csrf" > generated `,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := canonicalizeBody([]byte(test.body), test.c)
+			if test.err {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, string(body))
+			}
+		})
+	}
+}
+
+func TestCanonicalizeBodyBeforeMatching(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, "<html><body><h1>WELCOME</h1><input name=\"csrf\" value=\"token%d\"></body></html>", requests)
+	}))
+	defer ts.Close()
+
+	httpConfig := config.HTTPProbe{
+		IPProtocolFallback:         true,
+		FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("^welcome$")},
+		CompareBody:                config.BodyComparison{Enabled: true, FailIfChanged: true},
+		CanonicalizeBody:           config.BodyCanonicalization{StripHTMLTags: true, Lowercase: true},
+	}
+	for i := 0; i < 2; i++ {
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if !ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: httpConfig}, prometheus.NewRegistry(), promslog.NewNopLogger()) {
+			t.Fatalf("Expected probe %d to succeed", i)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// detectCaptivePortal exports whether the response was intercepted, as
+// operating systems do: a captive portal redirects the request to its login
+// page, or answers it itself with a status code or body other than the ones
+// of the known URL. The probe fails if a captive portal is detected.
+func detectCaptivePortal(resp *http.Response, body []byte, redirects int, c config.CaptivePortalCheck, registry *prometheus.Registry, logger *slog.Logger) bool {
+	detectedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_captive_portal_detected",
+		Help: "Indicates if the response was intercepted by a captive portal",
+	})
+	registry.MustRegister(detectedGauge)
+
+	detected := true
+	switch {
+	case redirects > 0:
+		logger.Error("Captive portal detected, the request was redirected", "redirects", redirects, "url", resp.Request.URL)
+	case resp.StatusCode != c.ExpectedStatusCode:
+		logger.Error("Captive portal detected, unexpected status code", "status_code", resp.StatusCode,
+			"expected_status_code", c.ExpectedStatusCode, "location", resp.Header.Get("Location"))
+	case c.ExpectedBody != "" && !bytes.Equal(bytes.TrimSpace(body), []byte(c.ExpectedBody)):
+		logger.Error("Captive portal detected, unexpected body")
+	default:
+		detected = false
+	}
+	if detected {
+		detectedGauge.Set(1)
+	}
+	return !detected
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestCaptivePortal(t *testing.T) {
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		check          config.CaptivePortalCheck
+		noFollow       bool
+		expectedResult bool
+	}{
+		"no content": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			check:          config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusNoContent},
+			expectedResult: true,
+		},
+		"success body": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>\n"))
+			},
+			check: config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusOK,
+				ExpectedBody: "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>"},
+			expectedResult: true,
+		},
+		"login page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html><body>Please log in</body></html>"))
+			},
+			check: config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusOK,
+				ExpectedBody: "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>"},
+		},
+		"unexpected status": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html><body>Please log in</body></html>"))
+			},
+			check: config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusNoContent},
+		},
+		"redirect followed": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/login" {
+					http.Redirect(w, r, "/login", http.StatusFound)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			},
+			check: config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusNoContent},
+		},
+		"redirect not followed": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/login", http.StatusFound)
+			},
+			check:    config.CaptivePortalCheck{Enabled: true, ExpectedStatusCode: http.StatusNoContent},
+			noFollow: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(test.handler)
+			defer ts.Close()
+
+			httpConfig := config.HTTPProbe{IPProtocolFallback: true, CaptivePortal: test.check}
+			httpConfig.HTTPClientConfig.FollowRedirects = !test.noFollow
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: httpConfig}, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			detected := 1.0
+			if test.expectedResult {
+				detected = 0
+			}
+			checkRegistryResults(map[string]float64{"probe_captive_portal_detected": detected}, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// valueChangeExpiry is how long an observed value is remembered. Values
+// observed less often than this are never reported as changed.
+const valueChangeExpiry = time.Hour
+
+type moduleNameKey struct{}
+
+// withModuleName returns a context telling the probers the name of the
+// module of the probe.
+func withModuleName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, moduleNameKey{}, name)
+}
+
+// moduleNameFromContext returns the name of the module of the probe, which
+// is empty outside of probe requests.
+func moduleNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(moduleNameKey{}).(string)
+	return name
+}
+
+type valueChangeKey struct {
+	module string
+	target string
+	kind   string
+}
+
+type observedValue struct {
+	value string
+	seen  time.Time
+}
+
+// valueChangeTracker remembers the last value of a kind, such as the serial
+// of the certificate, observed for each target and module combination.
+type valueChangeTracker struct {
+	mu        sync.Mutex
+	last      map[valueChangeKey]observedValue
+	lastPrune time.Time
+}
+
+var valueChanges = &valueChangeTracker{last: map[valueChangeKey]observedValue{}}
+
+// observe records a value observed at now and returns whether it differs
+// from the previous one, if there was one.
+func (t *valueChangeTracker) observe(key valueChangeKey, value string, now time.Time) (changed, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastPrune) > valueChangeExpiry {
+		for k, last := range t.last {
+			if now.Sub(last.seen) > valueChangeExpiry {
+				delete(t.last, k)
+			}
+		}
+		t.lastPrune = now
+	}
+
+	last, ok := t.last[key]
+	t.last[key] = observedValue{value: value, seen: now}
+	if !ok || now.Sub(last.seen) > valueChangeExpiry {
+		return false, false
+	}
+	return last.value != value, true
+}
+
+// exportValueChanged exports whether the value differs from the one observed
+// by the previous probe of the same module and target, so that changes show
+// without recording rules. The first value observed is not a change.
+func exportValueChanged(ctx context.Context, name, help, kind, target, value string, registry *prometheus.Registry) {
+	changedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: name,
+		Help: help,
+	})
+	registry.MustRegister(changedGauge)
+	key := valueChangeKey{module: moduleNameFromContext(ctx), target: target, kind: kind}
+	if changed, _ := valueChanges.observe(key, value, time.Now()); changed {
+		changedGauge.Set(1)
+	}
+}
+
+// exportCertChanged exports probe_ssl_cert_changed for the serial of the
+// certificate of the target.
+func exportCertChanged(ctx context.Context, target, serial string, registry *prometheus.Registry) {
+	exportValueChanged(ctx, "probe_ssl_cert_changed",
+		"Indicates if the serial of the certificate changed since the previous probe", "cert", target, serial, registry)
+}
+
+// dnsAnswerSet returns the records of an answer, in a form that does not
+// depend on their order and TTL.
+func dnsAnswerSet(answer []dns.RR) string {
+	records := make([]string, 0, len(answer))
+	for _, rr := range answer {
+		rr = dns.Copy(rr)
+		rr.Header().Ttl = 0
+		records = append(records, rr.String())
+	}
+	sort.Strings(records)
+	return strings.Join(records, "\n")
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestValueChangeTracker(t *testing.T) {
+	tracker := &valueChangeTracker{last: map[valueChangeKey]observedValue{}}
+	key := valueChangeKey{module: "tls", target: "example.com:443", kind: "cert"}
+	now := time.Now()
+	for _, test := range []struct {
+		value       string
+		at          time.Duration
+		changed, ok bool
+	}{
+		{value: "1", at: 0},
+		{value: "1", at: time.Minute, ok: true},
+		{value: "2", at: 2 * time.Minute, changed: true, ok: true},
+		{value: "2", at: 3 * time.Minute, ok: true},
+		// Values observed too long ago are forgotten.
+		{value: "3", at: 2 * time.Hour},
+	} {
+		changed, ok := tracker.observe(key, test.value, now.Add(test.at))
+		if changed != test.changed || ok != test.ok {
+			t.Errorf("Expected changed %v and ok %v for %s at %s, got %v and %v", test.changed, test.ok, test.value, test.at, changed, ok)
+		}
+	}
+
+	// Other modules probing the same target are tracked separately.
+	if _, ok := tracker.observe(valueChangeKey{module: "tls_client", target: key.target, kind: key.kind}, "4", now.Add(2*time.Hour)); ok {
+		t.Error("Expected no previous value for another module")
+	}
+}
+
+func TestExportValueChanged(t *testing.T) {
+	ctx := withModuleName(context.Background(), "test_export_value_changed")
+	for i, test := range []struct {
+		serial   string
+		expected float64
+	}{
+		{"01", 0},
+		{"01", 0},
+		{"02", 1},
+	} {
+		registry := prometheus.NewRegistry()
+		exportCertChanged(ctx, "example.com:443", test.serial, registry)
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryResults(map[string]float64{"probe_ssl_cert_changed": test.expected}, mfs, t)
+		if t.Failed() {
+			t.Fatalf("Unexpected result for probe %d", i)
+		}
+	}
+}
+
+func TestDNSAnswerSet(t *testing.T) {
+	a, err := dns.NewRR("example.com. 300 IN A 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dns.NewRR("example.com. 60 IN A 192.0.2.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := dns.NewRR("example.com. 12 IN A 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dnsAnswerSet([]dns.RR{a, b}) != dnsAnswerSet([]dns.RR{b, cached}) {
+		t.Error("Expected the order and TTL of the records to be ignored")
+	}
+	if a.Header().Ttl != 300 {
+		t.Error("Expected the records of the answer to be left unchanged")
+	}
+	if dnsAnswerSet([]dns.RR{a}) == dnsAnswerSet([]dns.RR{a, b}) {
+		t.Error("Expected a different answer set with another record")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	probesInFlightGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blackbox_probes_in_flight",
+		Help: "Number of probes of the module running, for modules with max_concurrent",
+	}, []string{"module"})
+	probesQueuedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blackbox_probes_queued",
+		Help: "Number of probes of the module waiting for one of the max_concurrent probes to finish",
+	}, []string{"module"})
+	probesConcurrencyRejectedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blackbox_probes_concurrency_rejected_total",
+		Help: "Count of probe requests rejected because max_concurrent probes of the module were running",
+	}, []string{"module"})
+)
+
+// moduleConcurrency enforces the max_concurrent of modules, so that modules
+// running heavy probes, such as crawls, cannot take all the resources of
+// the exporter.
+type moduleConcurrency struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+var probeConcurrency = &moduleConcurrency{slots: map[string]chan struct{}{}}
+
+// acquire takes one of the limit slots of module, waiting for one to be freed
+// until ctx is done if queue is true. It returns false if no slot was taken,
+// and otherwise a function releasing the slot.
+func (m *moduleConcurrency) acquire(ctx context.Context, module string, limit int, queue bool) (func(), bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+	m.mu.Lock()
+	slots, ok := m.slots[module]
+	// After a reload changing max_concurrent, the probes already running
+	// release the slots of the previous limit, which is briefly exceeded.
+	if !ok || cap(slots) != limit {
+		slots = make(chan struct{}, limit)
+		m.slots[module] = slots
+	}
+	m.mu.Unlock()
+
+	release := func() {
+		<-slots
+		probesInFlightGauge.WithLabelValues(module).Dec()
+	}
+	select {
+	case slots <- struct{}{}:
+		probesInFlightGauge.WithLabelValues(module).Inc()
+		return release, true
+	default:
+	}
+	if !queue {
+		probesConcurrencyRejectedCounter.WithLabelValues(module).Inc()
+		return nil, false
+	}
+
+	probesQueuedGauge.WithLabelValues(module).Inc()
+	defer probesQueuedGauge.WithLabelValues(module).Dec()
+	select {
+	case slots <- struct{}{}:
+		probesInFlightGauge.WithLabelValues(module).Inc()
+		return release, true
+	case <-ctx.Done():
+		probesConcurrencyRejectedCounter.WithLabelValues(module).Inc()
+		return nil, false
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestModuleConcurrency(t *testing.T) {
+	m := &moduleConcurrency{slots: map[string]chan struct{}{}}
+	release, ok := m.acquire(context.Background(), "a", 1, false)
+	if !ok {
+		t.Fatal("Expected the first probe to get a slot")
+	}
+	if _, ok := m.acquire(context.Background(), "a", 1, false); ok {
+		t.Fatal("Expected the probe over max_concurrent to be rejected")
+	}
+	if _, ok := m.acquire(context.Background(), "b", 1, false); !ok {
+		t.Fatal("Expected the probe of another module to get a slot")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, ok := m.acquire(ctx, "a", 1, true); ok {
+		t.Fatal("Expected the queued probe to give up at the timeout")
+	}
+
+	queued := make(chan bool)
+	go func() {
+		_, ok := m.acquire(context.Background(), "a", 1, true)
+		queued <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release()
+	if !<-queued {
+		t.Fatal("Expected the queued probe to get the released slot")
+	}
+}
+
+func TestHandlerMaxConcurrent(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-unblock
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{
+		Modules: map[string]config.Module{
+			"http_crawler": {
+				Prober:            "http",
+				Timeout:           5 * time.Second,
+				MaxConcurrent:     1,
+				ConcurrencyPolicy: "reject",
+				HTTP:              config.HTTPProbe{IPProtocolFallback: true},
+			},
+		},
+	}
+	probe := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "?module=http_crawler&target="+ts.URL, nil)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		return rr
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- probe() }()
+	<-started
+	if rr := probe(); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the probe over max_concurrent to be rejected with status %d, got %d: %s", http.StatusServiceUnavailable, rr.Code, rr.Body.String())
+	}
+	close(unblock)
+	if rr := <-first; rr.Code != http.StatusOK {
+		t.Errorf("Expected the first probe to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const consulDefaultPort = "8500"
+
+// ProbeConsul checks that the Consul agent of the target knows the leader of
+// the Raft cluster of the servers, which is lost with the quorum, and counts
+// its peers.
+func ProbeConsul(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_consul_duration_seconds",
+			Help: "Duration of the Consul checks by phase",
+		}, []string{"phase"})
+		hasLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_consul_has_leader",
+			Help: "Indicates if the agent knows the leader of the cluster",
+		})
+		leaderGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_consul_leader_info",
+			Help: "Contains the address of the leader of the cluster",
+		}, []string{"leader"})
+		peersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_consul_peers",
+			Help: "The number of Raft peers of the cluster",
+		})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.Consul
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "http://" + target
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		logger.Error("Could not parse target URL", "err", err)
+		return false
+	}
+	if targetURL.Port() == "" {
+		targetURL.Host = net.JoinHostPort(targetURL.Hostname(), consulDefaultPort)
+	}
+	base := strings.TrimSuffix(targetURL.String(), "/")
+
+	client, err := pconfig.NewClientFromConfig(c.HTTPClientConfig, "consul_probe", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating HTTP client", "err", err)
+		return false
+	}
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/status/leader", nil)
+	if err != nil {
+		logger.Error("Error creating request", "err", err)
+		return false
+	}
+	var leader string
+	err = getJSONDocument(client, req, &leader)
+	durationGaugeVec.WithLabelValues("leader").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error getting leader", "err", err)
+		return false
+	}
+	registry.MustRegister(hasLeaderGauge)
+	if leader == "" {
+		logger.Error("Cluster has no leader")
+		return false
+	}
+	registry.MustRegister(leaderGaugeVec)
+	hasLeaderGauge.Set(1)
+	leaderGaugeVec.WithLabelValues(leader).Set(1)
+
+	start = time.Now()
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/status/peers", nil)
+	if err != nil {
+		logger.Error("Error creating request", "err", err)
+		return false
+	}
+	var peers []string
+	err = getJSONDocument(client, req, &peers)
+	durationGaugeVec.WithLabelValues("peers").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error getting peers", "err", err)
+		return false
+	}
+	registry.MustRegister(peersGauge)
+	peersGauge.Set(float64(len(peers)))
+	logger.Info("Got Raft status", "leader", leader, "peers", len(peers))
+	if len(peers) < c.MinPeers {
+		logger.Error("Cluster has fewer peers than expected", "peers", len(peers), "min_peers", c.MinPeers)
+		return false
+	}
+	return true
+}
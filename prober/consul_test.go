@@ -0,0 +1,98 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestConsul(t *testing.T) {
+	tests := []struct {
+		name     string
+		leader   string
+		peers    []string
+		minPeers int
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "leader",
+			leader:   "10.0.0.1:8300",
+			peers:    []string{"10.0.0.1:8300", "10.0.0.2:8300", "10.0.0.3:8300"},
+			minPeers: 3,
+			success:  true,
+			expected: map[string]float64{"probe_consul_has_leader": 1, "probe_consul_peers": 3},
+		},
+		{
+			name:     "no leader",
+			peers:    []string{"10.0.0.1:8300", "10.0.0.2:8300", "10.0.0.3:8300"},
+			expected: map[string]float64{"probe_consul_has_leader": 0},
+		},
+		{
+			name:     "missing peers",
+			leader:   "10.0.0.1:8300",
+			peers:    []string{"10.0.0.1:8300"},
+			minPeers: 3,
+			expected: map[string]float64{"probe_consul_has_leader": 1, "probe_consul_peers": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				switch r.URL.Path {
+				case "/v1/status/leader":
+					json.NewEncoder(w).Encode(test.leader)
+				case "/v1/status/peers":
+					json.NewEncoder(w).Encode(test.peers)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			module := config.Module{Consul: config.ConsulProbe{
+				MinPeers: test.minPeers,
+				HTTPClientConfig: pconfig.HTTPClientConfig{
+					Authorization: &pconfig.Authorization{Type: "Bearer", Credentials: "token"},
+				},
+			}}
+			if success := ProbeConsul(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// maxSitemapEntries is the maximum number of entries allowed in a single
+// sitemap or sitemap index, see https://www.sitemaps.org/protocol.html.
+const maxSitemapEntries = 50000
+
+// robotsTxt holds the statistics of a parsed robots.txt file, see RFC 9309.
+type robotsTxt struct {
+	userAgents int
+	rules      int
+	sitemaps   int
+}
+
+func parseRobotsTxt(body []byte) (robotsTxt, error) {
+	var robots robotsTxt
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			return robots, fmt.Errorf("line %d: missing ':' separator", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "user-agent":
+			if value == "" {
+				return robots, fmt.Errorf("line %d: empty user-agent", lineNumber)
+			}
+			robots.userAgents++
+			inGroup = true
+		case "allow", "disallow":
+			if !inGroup {
+				return robots, fmt.Errorf("line %d: rule outside of a user-agent group", lineNumber)
+			}
+			robots.rules++
+		case "sitemap":
+			u, err := url.Parse(value)
+			if err != nil || !u.IsAbs() {
+				return robots, fmt.Errorf("line %d: sitemap %q is not an absolute URL", lineNumber, value)
+			}
+			robots.sitemaps++
+		}
+		// Other fields (e.g. crawl-delay) are not standardized and ignored.
+	}
+	return robots, scanner.Err()
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// sitemapTimeFormats are the W3C datetime formats allowed for lastmod.
+var sitemapTimeFormats = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseSitemapTime(s string) (time.Time, error) {
+	for _, format := range sitemapTimeFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid lastmod %q", s)
+}
+
+// parseSitemap validates a sitemap or sitemap index and returns the number of
+// entries and the most recent lastmod found.
+func parseSitemap(body []byte) (int, time.Time, error) {
+	var (
+		doc    sitemapDocument
+		newest time.Time
+	)
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return 0, newest, err
+	}
+
+	var entries []sitemapEntry
+	switch doc.XMLName.Local {
+	case "urlset":
+		entries = doc.URLs
+	case "sitemapindex":
+		entries = doc.Sitemaps
+	default:
+		return 0, newest, fmt.Errorf("unexpected root element %q", doc.XMLName.Local)
+	}
+	if len(entries) > maxSitemapEntries {
+		return 0, newest, fmt.Errorf("too many entries: %d > %d", len(entries), maxSitemapEntries)
+	}
+
+	for _, entry := range entries {
+		loc := strings.TrimSpace(entry.Loc)
+		if loc == "" {
+			return 0, newest, errors.New("entry without loc")
+		}
+		if u, err := url.Parse(loc); err != nil || !u.IsAbs() {
+			return 0, newest, fmt.Errorf("loc %q is not an absolute URL", loc)
+		}
+		if entry.LastMod == "" {
+			continue
+		}
+		lastMod, err := parseSitemapTime(strings.TrimSpace(entry.LastMod))
+		if err != nil {
+			return 0, newest, err
+		}
+		if lastMod.After(newest) {
+			newest = lastMod
+		}
+	}
+	return len(entries), newest, nil
+}
+
+// validateCrawlConfig validates a robots.txt or sitemap response body and
+// exports its freshness and the number of URLs it contains.
+func validateCrawlConfig(body []byte, header http.Header, v config.CrawlConfigValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		prefix       = "probe_http_" + v.Format
+		lastModified time.Time
+		urls         int
+		err          error
+	)
+
+	validGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "_valid",
+		Help: "Indicates if the response is syntactically valid",
+	})
+	ageGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "_age_seconds",
+		Help: "Seconds since the content was last modified, based on the Last-Modified header and sitemap lastmod entries",
+	})
+	registry.MustRegister(validGauge)
+
+	switch v.Format {
+	case "robots_txt":
+		var robots robotsTxt
+		robots, err = parseRobotsTxt(body)
+		if err == nil {
+			userAgentsGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_http_robots_txt_user_agents",
+				Help: "Number of user-agent lines in robots.txt",
+			})
+			rulesGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_http_robots_txt_rules",
+				Help: "Number of allow and disallow rules in robots.txt",
+			})
+			sitemapsGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_http_robots_txt_sitemaps",
+				Help: "Number of sitemap URLs in robots.txt",
+			})
+			registry.MustRegister(userAgentsGauge, rulesGauge, sitemapsGauge)
+			userAgentsGauge.Set(float64(robots.userAgents))
+			rulesGauge.Set(float64(robots.rules))
+			sitemapsGauge.Set(float64(robots.sitemaps))
+			urls = robots.sitemaps
+		}
+	case "sitemap":
+		urls, lastModified, err = parseSitemap(body)
+		if err == nil {
+			urlsGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_http_sitemap_urls",
+				Help: "Number of entries in the sitemap or sitemap index",
+			})
+			registry.MustRegister(urlsGauge)
+			urlsGauge.Set(float64(urls))
+		}
+	}
+	if err != nil {
+		logger.Error("Invalid crawl configuration", "format", v.Format, "err", err)
+		return false
+	}
+	validGauge.Set(1)
+
+	if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil && t.After(lastModified) {
+		lastModified = t
+	}
+	if !lastModified.IsZero() {
+		age := time.Since(lastModified)
+		registry.MustRegister(ageGauge)
+		ageGauge.Set(age.Seconds())
+		if v.FailIfOlderThan > 0 && age > time.Duration(v.FailIfOlderThan) {
+			logger.Error("Crawl configuration is older than allowed", "age", age, "max_age", v.FailIfOlderThan)
+			return false
+		}
+	} else if v.FailIfOlderThan > 0 {
+		logger.Error("Unable to determine age of crawl configuration")
+		return false
+	}
+
+	if urls < v.FailIfURLCountBelow {
+		logger.Error("Crawl configuration has fewer URLs than required", "urls", urls, "min_urls", v.FailIfURLCountBelow)
+		return false
+	}
+	return true
+}
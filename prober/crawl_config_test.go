@@ -0,0 +1,217 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestParseRobotsTxt(t *testing.T) {
+	testcases := map[string]struct {
+		body     string
+		expected robotsTxt
+		wantErr  bool
+	}{
+		"valid": {
+			body:     "\xef\xbb\xbf# comment\nUser-agent: *\nDisallow: /private # trailing comment\nAllow: /\n\nUser-Agent: bot\nCrawl-delay: 10\nDisallow:\nSitemap: https://example.com/sitemap.xml\n",
+			expected: robotsTxt{userAgents: 2, rules: 3, sitemaps: 1},
+		},
+		"empty": {
+			body: "",
+		},
+		"rule before user-agent": {
+			body:    "Disallow: /\nUser-agent: *\n",
+			wantErr: true,
+		},
+		"missing separator": {
+			body:    "User-agent *\n",
+			wantErr: true,
+		},
+		"relative sitemap": {
+			body:    "Sitemap: /sitemap.xml\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			robots, err := parseRobotsTxt([]byte(tc.body))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if robots != tc.expected {
+				t.Fatalf("Expected %+v, got %+v", tc.expected, robots)
+			}
+		})
+	}
+}
+
+func TestParseSitemap(t *testing.T) {
+	testcases := map[string]struct {
+		body           string
+		expectedURLs   int
+		expectedNewest time.Time
+		wantErr        bool
+	}{
+		"urlset": {
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2024-01-02</lastmod></url>
+  <url><loc>https://example.com/a</loc><lastmod>2024-03-04T05:06:07+00:00</lastmod></url>
+  <url><loc>https://example.com/b</loc></url>
+</urlset>`,
+			expectedURLs:   3,
+			expectedNewest: time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC),
+		},
+		"sitemapindex": {
+			body: `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap1.xml.gz</loc><lastmod>2024-01-02T10:00Z</lastmod></sitemap>
+</sitemapindex>`,
+			expectedURLs:   1,
+			expectedNewest: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		},
+		"invalid xml": {
+			body:    `<urlset><url><loc>https://example.com/</loc></urlset>`,
+			wantErr: true,
+		},
+		"wrong root": {
+			body:    `<html></html>`,
+			wantErr: true,
+		},
+		"relative loc": {
+			body:    `<urlset><url><loc>/a</loc></url></urlset>`,
+			wantErr: true,
+		},
+		"invalid lastmod": {
+			body:    `<urlset><url><loc>https://example.com/</loc><lastmod>yesterday</lastmod></url></urlset>`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			urls, newest, err := parseSitemap([]byte(tc.body))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if urls != tc.expectedURLs {
+				t.Fatalf("Expected %d URLs, got %d", tc.expectedURLs, urls)
+			}
+			if !newest.Equal(tc.expectedNewest) {
+				t.Fatalf("Expected newest lastmod %s, got %s", tc.expectedNewest, newest)
+			}
+		})
+	}
+}
+
+func TestCrawlConfigProbe(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC().Format(http.TimeFormat)
+
+	testcases := map[string]struct {
+		body           string
+		lastModified   string
+		validator      config.CrawlConfigValidator
+		expectedResult bool
+		expectedValid  float64
+	}{
+		"fresh robots.txt": {
+			body:           "User-agent: *\nDisallow: /admin\nSitemap: https://example.com/sitemap.xml\n",
+			lastModified:   recent,
+			validator:      config.CrawlConfigValidator{Format: "robots_txt", FailIfOlderThan: model.Duration(24 * time.Hour), FailIfURLCountBelow: 1},
+			expectedResult: true,
+			expectedValid:  1,
+		},
+		"stale robots.txt": {
+			body:           "User-agent: *\nDisallow: /admin\n",
+			lastModified:   old,
+			validator:      config.CrawlConfigValidator{Format: "robots_txt", FailIfOlderThan: model.Duration(24 * time.Hour)},
+			expectedResult: false,
+			expectedValid:  1,
+		},
+		"robots.txt without sitemap": {
+			body:           "User-agent: *\nDisallow: /admin\n",
+			validator:      config.CrawlConfigValidator{Format: "robots_txt", FailIfURLCountBelow: 1},
+			expectedResult: false,
+			expectedValid:  1,
+		},
+		"unknown age": {
+			body:           "User-agent: *\n",
+			validator:      config.CrawlConfigValidator{Format: "robots_txt", FailIfOlderThan: model.Duration(24 * time.Hour)},
+			expectedResult: false,
+			expectedValid:  1,
+		},
+		"invalid sitemap": {
+			body:           "<html><body>Not found</body></html>",
+			validator:      config.CrawlConfigValidator{Format: "sitemap"},
+			expectedResult: false,
+			expectedValid:  0,
+		},
+		"sitemap with recent lastmod": {
+			body:           `<urlset><url><loc>https://example.com/</loc><lastmod>` + time.Now().UTC().Format(time.RFC3339) + `</lastmod></url></urlset>`,
+			lastModified:   old,
+			validator:      config.CrawlConfigValidator{Format: "sitemap", FailIfOlderThan: model.Duration(24 * time.Hour), FailIfURLCountBelow: 1},
+			expectedResult: true,
+			expectedValid:  1,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.lastModified != "" {
+					w.Header().Set("Last-Modified", tc.lastModified)
+				}
+				w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, ValidateCrawlConfig: tc.validator}}
+			if result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_http_" + tc.validator.Format + "_valid": tc.expectedValid}, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"net/textproto"
+	"slices"
+
+	"github.com/miekg/dns"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// evaluateCriterion evaluates the success criteria of a probe, calling
+// conditionsHold for the criteria that are sets of conditions.
+func evaluateCriterion(c *config.SuccessCriterion, conditionsHold func(*config.SuccessCriterion) bool) bool {
+	switch {
+	case len(c.AnyOf) > 0:
+		for i := range c.AnyOf {
+			if evaluateCriterion(&c.AnyOf[i], conditionsHold) {
+				return true
+			}
+		}
+		return false
+	case len(c.AllOf) > 0:
+		for i := range c.AllOf {
+			if !evaluateCriterion(&c.AllOf[i], conditionsHold) {
+				return false
+			}
+		}
+		return true
+	case len(c.NoneOf) > 0:
+		for i := range c.NoneOf {
+			if evaluateCriterion(&c.NoneOf[i], conditionsHold) {
+				return false
+			}
+		}
+		return true
+	default:
+		return conditionsHold(c)
+	}
+}
+
+// httpConditionsHold evaluates the conditions of a criterion on an HTTP
+// response.
+func httpConditionsHold(c *config.SuccessCriterion, resp *http.Response, body []byte) bool {
+	if len(c.StatusCodes) > 0 && !slices.Contains(c.StatusCodes, resp.StatusCode) {
+		return false
+	}
+	if c.HeaderMatches != nil {
+		values := resp.Header[textproto.CanonicalMIMEHeaderKey(c.HeaderMatches.Header)]
+		if len(values) == 0 && !c.HeaderMatches.AllowMissing {
+			return false
+		}
+		if len(values) > 0 && !slices.ContainsFunc(values, c.HeaderMatches.Regexp.MatchString) {
+			return false
+		}
+	}
+	if c.BodyMatchesRegexp.Regexp != nil && !c.BodyMatchesRegexp.Match(body) {
+		return false
+	}
+	return true
+}
+
+// anyRRMatches returns true if the text of one of the resource records
+// matches the regexp.
+func anyRRMatches(rrs []dns.RR, re config.Regexp) bool {
+	for _, rr := range rrs {
+		if re.MatchString(rr.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsConditionsHold evaluates the conditions of a criterion on a DNS
+// response.
+func dnsConditionsHold(c *config.SuccessCriterion, response *dns.Msg) bool {
+	if len(c.Rcodes) > 0 && !slices.Contains(c.Rcodes, dns.RcodeToString[response.Rcode]) {
+		return false
+	}
+	if c.AnswerMatchesRegexp.Regexp != nil && !anyRRMatches(response.Answer, c.AnswerMatchesRegexp) {
+		return false
+	}
+	if c.AuthorityMatchesRegexp.Regexp != nil && !anyRRMatches(response.Ns, c.AuthorityMatchesRegexp) {
+		return false
+	}
+	if c.AdditionalMatchesRegexp.Regexp != nil && !anyRRMatches(response.Extra, c.AdditionalMatchesRegexp) {
+		return false
+	}
+	return true
+}
+
+// tcpConditionsHold evaluates the conditions of a criterion on the lines
+// received from the target.
+func tcpConditionsHold(c *config.SuccessCriterion, lines []string) bool {
+	if c.ResponseMatchesRegexp.Regexp != nil && !slices.ContainsFunc(lines, c.ResponseMatchesRegexp.MatchString) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestHTTPSuccessCriteria(t *testing.T) {
+	// Status 200 with the expected body, or 503 with a Retry-After header.
+	criteria := &config.SuccessCriterion{
+		AnyOf: []config.SuccessCriterion{
+			{StatusCodes: []int{200}, BodyMatchesRegexp: config.MustNewRegexp("ready")},
+			{AllOf: []config.SuccessCriterion{
+				{StatusCodes: []int{503}},
+				{HeaderMatches: &config.HeaderMatch{Header: "Retry-After", Regexp: config.MustNewRegexp("^[0-9]+$")}},
+			}},
+		},
+	}
+
+	// The body is checked whether the optional header is there or not.
+	optionalHeader := &config.SuccessCriterion{
+		HeaderMatches:     &config.HeaderMatch{Header: "Retry-After", Regexp: config.MustNewRegexp("^[0-9]+$"), AllowMissing: true},
+		BodyMatchesRegexp: config.MustNewRegexp("ready"),
+	}
+
+	tests := map[string]struct {
+		statusCode     int
+		retryAfter     string
+		body           string
+		criteria       *config.SuccessCriterion
+		expectedResult bool
+	}{
+		"ok":                       {statusCode: 200, body: "ready", criteria: criteria, expectedResult: true},
+		"ok with unexpected body":  {statusCode: 200, body: "starting", criteria: criteria, expectedResult: false},
+		"unavailable":              {statusCode: 503, retryAfter: "120", criteria: criteria, expectedResult: true},
+		"unavailable without hint": {statusCode: 503, criteria: criteria, expectedResult: false},
+		"missing allowed header":   {statusCode: 200, body: "ready", criteria: optionalHeader, expectedResult: true},
+		"missing allowed header with unexpected body": {
+			statusCode:     200,
+			body:           "starting",
+			criteria:       optionalHeader,
+			expectedResult: false,
+		},
+		"none of": {
+			statusCode:     200,
+			body:           "ready (degraded)",
+			criteria:       &config.SuccessCriterion{NoneOf: []config.SuccessCriterion{{BodyMatchesRegexp: config.MustNewRegexp("degraded")}}},
+			expectedResult: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.statusCode)
+				w.Write([]byte(test.body))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				SuccessCriteria:    test.criteria,
+			}}, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestDNSSuccessCriteria(t *testing.T) {
+	server, addr := startDNSServer("udp", recursiveDNSHandler)
+	defer server.Shutdown()
+
+	// Either a NOERROR answer with the expected address, or REFUSED.
+	criteria := &config.SuccessCriterion{
+		AnyOf: []config.SuccessCriterion{
+			{Rcodes: []string{"NOERROR"}, AnswerMatchesRegexp: config.MustNewRegexp("127.0.0.1$")},
+			{Rcodes: []string{"REFUSED"}},
+		},
+	}
+
+	tests := []struct {
+		recursion      bool
+		criteria       *config.SuccessCriterion
+		expectedResult bool
+	}{
+		{recursion: true, criteria: criteria, expectedResult: true},
+		{recursion: false, criteria: criteria, expectedResult: true},
+		{
+			recursion:      true,
+			criteria:       &config.SuccessCriterion{NoneOf: []config.SuccessCriterion{{AnswerMatchesRegexp: config.MustNewRegexp("127.0.0.2$")}}},
+			expectedResult: false,
+		},
+	}
+
+	for i, test := range tests {
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		result := ProbeDNS(testCTX, addr.String(), config.Module{Timeout: time.Second, DNS: config.DNSProbe{
+			IPProtocol:         "ip4",
+			IPProtocolFallback: true,
+			TransportProtocol:  "udp",
+			QueryName:          "example.com",
+			Recursion:          test.recursion,
+			ServerStrategy:     "failover",
+			SuccessCriteria:    test.criteria,
+		}}, registry, promslog.NewNopLogger())
+		if result != test.expectedResult {
+			t.Fatalf("Test %d: expected result %t, got %t", i, test.expectedResult, result)
+		}
+	}
+}
+
+func TestTCPSuccessCriteria(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+	banners := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(conn, "%s\n", <-banners)
+			conn.Close()
+		}
+	}()
+
+	criteria := &config.SuccessCriterion{
+		AnyOf: []config.SuccessCriterion{
+			{ResponseMatchesRegexp: config.MustNewRegexp("^\\+OK")},
+			{ResponseMatchesRegexp: config.MustNewRegexp("^220 ")},
+		},
+	}
+	tests := []struct {
+		banner         string
+		expectedResult bool
+	}{
+		{banner: "+OK POP3 ready", expectedResult: true},
+		{banner: "220 smtp.example.com ESMTP", expectedResult: true},
+		{banner: "554 no service", expectedResult: false},
+	}
+	for i, test := range tests {
+		banners <- test.banner
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		result := ProbeTCP(testCTX, ln.Addr().String(), config.Module{TCP: config.TCPProbe{
+			IPProtocol:         "ip4",
+			IPProtocolFallback: true,
+			SuccessCriteria:    criteria,
+		}}, registry, promslog.NewNopLogger())
+		if result != test.expectedResult {
+			t.Fatalf("Test %d: expected result %t, got %t", i, test.expectedResult, result)
+		}
+	}
+}
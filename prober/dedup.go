@@ -0,0 +1,93 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var probesDeduplicatedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "blackbox_probes_deduplicated_total",
+	Help: "Count of probe requests served from the result of an identical probe",
+}, []string{"module"})
+
+// probeOutcome is the result of a probe, or why it was rejected.
+type probeOutcome struct {
+	result *ProbeResult
+	// The module was running max_concurrent probes.
+	concurrencyLimited bool
+	// The target was probed less than min_interval ago, and may be probed
+	// again after retryAfter.
+	rateLimited bool
+	retryAfter  time.Duration
+}
+
+// probeCall is a probe execution whose outcome may be shared by identical
+// probe requests.
+type probeCall struct {
+	done    chan struct{}
+	outcome probeOutcome
+}
+
+// probeDeduplicator shares the execution of identical probes requested
+// concurrently or within a short window, e.g. by a pair of HA Prometheus
+// servers scraping the same targets.
+type probeDeduplicator struct {
+	mu    sync.Mutex
+	calls map[string]*probeCall
+}
+
+var probeDedup = &probeDeduplicator{calls: map[string]*probeCall{}}
+
+// do runs fn unless an identical probe is in flight or finished less than
+// window ago, in which case its outcome is returned instead. shared reports
+// whether the outcome comes from another execution. Rejected probes are only
+// shared with the requests waiting for them. If ctx is done before the shared
+// execution finishes, ok is false.
+func (d *probeDeduplicator) do(ctx context.Context, key string, window time.Duration, fn func() probeOutcome) (outcome probeOutcome, shared, ok bool) {
+	d.mu.Lock()
+	if c, found := d.calls[key]; found {
+		d.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.outcome, true, true
+		case <-ctx.Done():
+			return probeOutcome{}, true, false
+		}
+	}
+	c := &probeCall{done: make(chan struct{})}
+	d.calls[key] = c
+	d.mu.Unlock()
+
+	c.outcome = fn()
+	close(c.done)
+	forget := func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.calls[key] == c {
+			delete(d.calls, key)
+		}
+	}
+	if c.outcome.result == nil {
+		forget()
+	} else {
+		time.AfterFunc(window, forget)
+	}
+	return c.outcome, false, true
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProbeDeduplicator(t *testing.T) {
+	d := &probeDeduplicator{calls: map[string]*probeCall{}}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() probeOutcome {
+		calls.Add(1)
+		<-release
+		return probeOutcome{result: &ProbeResult{Success: true}}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		shared atomic.Int32
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcome, s, ok := d.do(context.Background(), "key", 100*time.Millisecond, fn)
+			if !ok || outcome.result == nil || !outcome.result.Success {
+				t.Errorf("Expected successful result, got outcome=%v ok=%t", outcome, ok)
+			}
+			if s {
+				shared.Add(1)
+			}
+		}()
+	}
+	// Give the goroutines time to join the first execution.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 || shared.Load() != 2 {
+		t.Fatalf("Expected 1 execution shared by 2 requests, got %d executions and %d shared", calls.Load(), shared.Load())
+	}
+
+	// Within the window, the result is still shared.
+	if _, s, _ := d.do(context.Background(), "key", 100*time.Millisecond, fn); !s {
+		t.Fatal("Expected result to be shared within the window")
+	}
+	// A different key is probed separately.
+	if _, s, _ := d.do(context.Background(), "other", 100*time.Millisecond, fn); s {
+		t.Fatal("Expected a different key not to be shared")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, s, _ := d.do(context.Background(), "key", 100*time.Millisecond, fn); s {
+		t.Fatal("Expected result not to be shared after the window")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("Expected 3 executions, got %d", calls.Load())
+	}
+
+	// Rejected probes are not shared once done.
+	rejected := func() probeOutcome { return probeOutcome{concurrencyLimited: true} }
+	if outcome, _, _ := d.do(context.Background(), "rejected", time.Minute, rejected); !outcome.concurrencyLimited {
+		t.Fatalf("Expected the probe to be rejected, got %v", outcome)
+	}
+	if _, s, _ := d.do(context.Background(), "rejected", time.Minute, fn); s {
+		t.Fatal("Expected a rejected probe not to be shared")
+	}
+}
+
+func TestProbeDeduplicatorTimeout(t *testing.T) {
+	d := &probeDeduplicator{calls: map[string]*probeCall{}}
+	release := make(chan struct{})
+	defer close(release)
+	go d.do(context.Background(), "key", time.Second, func() probeOutcome {
+		<-release
+		return probeOutcome{result: &ProbeResult{Success: true}}
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, ok := d.do(ctx, "key", time.Second, nil); ok {
+		t.Fatal("Expected waiting for the shared result to time out")
+	}
+}
+
+func TestHandlerDeduplication(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{
+		Modules: map[string]config.Module{
+			"http_dedup": {
+				Prober:              "http",
+				Timeout:             5 * time.Second,
+				DeduplicationWindow: time.Minute,
+				HTTP:                config.HTTPProbe{IPProtocolFallback: true},
+			},
+		},
+	}
+	deduplicated := func() float64 {
+		var m dto.Metric
+		if err := probesDeduplicatedCounter.WithLabelValues("http_dedup").Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := deduplicated()
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "?module=http_dedup&target="+ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		if !strings.Contains(rr.Body.String(), "probe_success 1") {
+			t.Fatalf("Expected successful probe, got: %s", rr.Body.String())
+		}
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("Expected target to be probed once, got %d requests", requests.Load())
+	}
+	if got := deduplicated() - before; got != 1 {
+		t.Fatalf("Expected 1 deduplicated probe, got %v", got)
+	}
+}
+
+func TestHandlerDeduplicationMaxConcurrent(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{
+		Modules: map[string]config.Module{
+			"http_dedup_concurrency": {
+				Prober:              "http",
+				Timeout:             5 * time.Second,
+				DeduplicationWindow: time.Minute,
+				MaxConcurrent:       1,
+				ConcurrencyPolicy:   "reject",
+				HTTP:                config.HTTPProbe{IPProtocolFallback: true},
+			},
+		},
+	}
+	rateLimited := func() float64 {
+		var m dto.Metric
+		if err := probesRateLimitedCounter.WithLabelValues("http_dedup_concurrency").Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := rateLimited()
+	probe := func(target string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "?module=http_dedup_concurrency&target="+target, nil)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		return rr
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- probe(ts.URL + "/slow") }()
+	<-started
+	// Both the rejected probe and the identical one following it are
+	// rejected for concurrency, the rejection is not reused as a result.
+	for i := 0; i < 2; i++ {
+		if rr := probe(ts.URL); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected the probe over max_concurrent to be rejected with status %d, got %d: %s", http.StatusServiceUnavailable, rr.Code, rr.Body.String())
+		}
+	}
+	close(unblock)
+	if rr := <-first; rr.Code != http.StatusOK {
+		t.Errorf("Expected the first probe to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// Once the slot is free, the identical probe runs.
+	if rr := probe(ts.URL); !strings.Contains(rr.Body.String(), "probe_success 1") {
+		t.Errorf("Expected successful probe once the slot is released, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rateLimited() - before; got != 0 {
+		t.Errorf("Expected no rate limited probe, got %v", got)
+	}
+}
@@ -14,11 +14,24 @@
 package prober
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/miekg/dns"
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,8 +40,15 @@ import (
 	"github.com/prometheus/blackbox_exporter/config"
 )
 
-// validRRs checks a slice of RRs received from the server against a DNSRRValidator.
-func validRRs(rrs *[]dns.RR, v *config.DNSRRValidator, logger *slog.Logger) bool {
+// validRRs checks a slice of RRs received from the server against a
+// DNSRRValidator, recording the result if the validator is named.
+func validRRs(rrs *[]dns.RR, v *config.DNSRRValidator, results *validatorResults, logger *slog.Logger) bool {
+	valid := checkRRs(rrs, v, logger)
+	results.record(v.Name, valid)
+	return valid
+}
+
+func checkRRs(rrs *[]dns.RR, v *config.DNSRRValidator, logger *slog.Logger) bool {
 	var anyMatch bool = false
 	var allMatch bool = true
 	// Fail the probe if there are no RRs of a given type, but a regexp match is required
@@ -123,70 +143,78 @@ func validRcode(rcode int, valid []string, logger *slog.Logger) bool {
 	return false
 }
 
-func ProbeDNS(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
-	var dialProtocol string
-	probeDNSDurationGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "probe_dns_duration_seconds",
-		Help: "Duration of DNS request by phase",
-	}, []string{"phase"})
-	probeDNSAnswerRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_dns_answer_rrs",
-		Help: "Returns number of entries in the answer resource record list",
-	})
-	probeDNSAuthorityRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_dns_authority_rrs",
-		Help: "Returns number of entries in the authority resource record list",
-	})
-	probeDNSAdditionalRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_dns_additional_rrs",
-		Help: "Returns number of entries in the additional resource record list",
-	})
-	probeDNSQuerySucceeded := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_dns_query_succeeded",
-		Help: "Displays whether or not the query was executed successfully",
-	})
-
-	for _, lv := range []string{"resolve", "connect", "request"} {
-		probeDNSDurationGaugeVec.WithLabelValues(lv)
-	}
+// dohMediaType is the media type of DNS messages in DNS over HTTPS.
+const dohMediaType = "application/dns-message"
 
-	registry.MustRegister(probeDNSDurationGaugeVec)
-	registry.MustRegister(probeDNSAnswerRRSGauge)
-	registry.MustRegister(probeDNSAuthorityRRSGauge)
-	registry.MustRegister(probeDNSAdditionalRRSGauge)
-	registry.MustRegister(probeDNSQuerySucceeded)
+// dnsServerResult is the outcome of querying a single DNS server.
+type dnsServerResult struct {
+	server   string
+	response *dns.Msg
+	resolve  float64
+	connect  float64
+	request  float64
+	// tls is the state of the connection of DNS over TLS or HTTPS.
+	tls *tls.ConnectionState
+	err error
+}
 
-	qc := uint16(dns.ClassINET)
-	if module.DNS.QueryClass != "" {
-		var ok bool
-		qc, ok = dns.StringToClass[module.DNS.QueryClass]
-		if !ok {
-			logger.Error("Invalid query class", "Class seen", module.DNS.QueryClass, "Existing classes", dns.ClassToString)
-			return false
+// dnsServers returns the servers to query. The servers configured in the
+// module take precedence over the target, which may itself be a
+// comma-separated list of servers.
+func dnsServers(target string, module config.Module) []string {
+	if len(module.DNS.Servers) > 0 {
+		return module.DNS.Servers
+	}
+	var servers []string
+	for _, server := range strings.Split(target, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
 		}
 	}
+	return servers
+}
 
-	qt := dns.TypeANY
-	if module.DNS.QueryType != "" {
-		var ok bool
-		qt, ok = dns.StringToType[module.DNS.QueryType]
-		if !ok {
-			logger.Error("Invalid query type", "Type seen", module.DNS.QueryType, "Existing types", dns.TypeToString)
-			return false
+// dnsNSID returns the name server identifier of the server in a response, see
+// RFC 5001. It is returned as text if it is printable, as most servers set it
+// to their host name, and in hex otherwise.
+func dnsNSID(response *dns.Msg) (string, bool) {
+	opt := response.IsEdns0()
+	if opt == nil {
+		return "", false
+	}
+	for _, o := range opt.Option {
+		nsid, ok := o.(*dns.EDNS0_NSID)
+		if !ok || nsid.Nsid == "" {
+			continue
+		}
+		b, err := hex.DecodeString(nsid.Nsid)
+		if err != nil || !utf8.Valid(b) || strings.IndexFunc(string(b), func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+			return nsid.Nsid, true
 		}
+		return string(b), true
 	}
-	var probeDNSSOAGauge prometheus.Gauge
+	return "", false
+}
 
-	var ip *net.IPAddr
-	if module.DNS.TransportProtocol == "" {
-		module.DNS.TransportProtocol = "udp"
+// queryDNSServer sends msg to a single server. The resolution metrics of the
+// server address are registered in registry.
+func queryDNSServer(ctx context.Context, server string, module config.Module, msg *dns.Msg, registry *prometheus.Registry, logger *slog.Logger) (result dnsServerResult) {
+	var dialProtocol string
+	result.server = server
+
+	if module.DNS.TransportProtocol == "unix" {
+		// The server is the path of a socket carrying messages like TCP,
+		// without an address to resolve.
+		exchangeDNS(ctx, &dns.Client{Net: "unix"}, msg, server, &result, logger)
+		return
 	}
-	if !(module.DNS.TransportProtocol == "udp" || module.DNS.TransportProtocol == "tcp") {
-		logger.Error("Configuration error: Expected transport protocol udp or tcp", "protocol", module.DNS.TransportProtocol)
-		return false
+	if module.DNS.TransportProtocol == "doh" {
+		// The server is the URL of a DNS over HTTPS server.
+		exchangeDNSOverHTTPS(ctx, server, module, msg, &result, registry, logger)
+		return
 	}
 
-	targetAddr, port, err := net.SplitHostPort(target)
+	targetAddr, port, err := net.SplitHostPort(server)
 	if err != nil {
 		// Target only contains host so fallback to default port and set targetAddr as target.
 		if module.DNS.DNSOverTLS {
@@ -194,14 +222,15 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 		} else {
 			port = "53"
 		}
-		targetAddr = target
+		targetAddr = server
 	}
 	ip, lookupTime, err := chooseProtocol(ctx, module.DNS.IPProtocol, module.DNS.IPProtocolFallback, targetAddr, registry, logger)
 	if err != nil {
 		logger.Error("Error resolving address", "err", err)
-		return false
+		result.err = err
+		return
 	}
-	probeDNSDurationGaugeVec.WithLabelValues("resolve").Add(lookupTime)
+	result.resolve = lookupTime
 	targetIP := net.JoinHostPort(ip.String(), port)
 
 	if ip.IP.To4() == nil {
@@ -215,7 +244,8 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 			dialProtocol += "-tls"
 		} else {
 			logger.Error("Configuration error: Expected transport protocol tcp for DoT", "protocol", module.DNS.TransportProtocol)
-			return false
+			result.err = errors.New("DNS over TLS requires transport protocol tcp")
+			return
 		}
 	}
 
@@ -226,8 +256,10 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 		tlsConfig, err := pconfig.NewTLSConfig(&module.DNS.TLSConfig)
 		if err != nil {
 			logger.Error("Failed to create TLS configuration", "err", err)
-			return false
+			result.err = err
+			return
 		}
+		tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
 		if tlsConfig.ServerName == "" {
 			// Use target-hostname as default for TLS-servername.
 			tlsConfig.ServerName = targetAddr
@@ -241,7 +273,8 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 		srcIP := net.ParseIP(module.DNS.SourceIPAddress)
 		if srcIP == nil {
 			logger.Error("Error parsing source ip address", "srcIP", module.DNS.SourceIPAddress)
-			return false
+			result.err = fmt.Errorf("invalid source ip address %q", module.DNS.SourceIPAddress)
+			return
 		}
 		logger.Info("Using local address", "srcIP", srcIP)
 		client.Dialer = &net.Dialer{}
@@ -252,33 +285,537 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 		}
 	}
 
-	msg := new(dns.Msg)
-	msg.Id = dns.Id()
-	msg.RecursionDesired = module.DNS.Recursion
-	msg.Question = make([]dns.Question, 1)
-	msg.Question[0] = dns.Question{dns.Fqdn(module.DNS.QueryName), qt, qc}
+	exchangeDNS(ctx, client, msg, targetIP, &result, logger)
+	return
+}
 
-	logger.Info("Making DNS query", "target", targetIP, "dial_protocol", dialProtocol, "query", module.DNS.QueryName, "type", qt, "class", qc)
+// exchangeDNS sends msg to the address with the client, and records the
+// response and the timings in result.
+func exchangeDNS(ctx context.Context, client *dns.Client, msg *dns.Msg, address string, result *dnsServerResult, logger *slog.Logger) {
+	logger.Info("Making DNS query", "target", address, "dial_protocol", client.Net, "query", msg.Question[0].Name, "type", msg.Question[0].Qtype, "class", msg.Question[0].Qclass)
 	timeoutDeadline, _ := ctx.Deadline()
 	client.Timeout = time.Until(timeoutDeadline)
 	requestStart := time.Now()
-	response, rtt, err := client.Exchange(msg, targetIP)
+	var (
+		response *dns.Msg
+		rtt      time.Duration
+		err      error
+	)
+	if strings.HasSuffix(client.Net, "-tls") {
+		response, rtt, err = exchangeDNSOverTLS(ctx, client, msg, address, result)
+	} else {
+		response, rtt, err = client.Exchange(msg, address)
+	}
 	// The rtt value returned from client.Exchange includes only the time to
 	// exchange messages with the server _after_ the connection is created.
 	// We compute the connection time as the total time for the operation
 	// minus the time for the actual request rtt.
-	probeDNSDurationGaugeVec.WithLabelValues("connect").Set((time.Since(requestStart) - rtt).Seconds())
-	probeDNSDurationGaugeVec.WithLabelValues("request").Set(rtt.Seconds())
+	result.connect = (time.Since(requestStart) - rtt).Seconds()
+	result.request = rtt.Seconds()
 	if err != nil {
 		logger.Error("Error while sending a DNS query", "err", err)
-		return false
+		result.err = err
+		return
 	}
 	logger.Info("Got response", "response", response)
+	if nsid, ok := dnsNSID(response); ok {
+		logger.Info("Got name server identifier", "nsid", nsid)
+	}
+	result.response = response
+}
+
+// exchangeDNSOverTLS dials the connection itself, so that the state of the
+// TLS connection is recorded in result.
+func exchangeDNSOverTLS(ctx context.Context, client *dns.Client, msg *dns.Msg, address string, result *dnsServerResult) (*dns.Msg, time.Duration, error) {
+	conn, err := client.DialContext(ctx, address)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	if tlsConn, ok := conn.Conn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		result.tls = &state
+	}
+	return client.ExchangeWithConnContext(ctx, msg, conn)
+}
+
+// exchangeDNSOverHTTPS posts msg to the URL of a DNS over HTTPS server as
+// described in RFC 8484, and records the response, the timings and the state
+// of the TLS connection in result.
+func exchangeDNSOverHTTPS(ctx context.Context, server string, module config.Module, msg *dns.Msg, result *dnsServerResult, registry *prometheus.Registry, logger *slog.Logger) {
+	u, err := url.Parse(server)
+	if err == nil && u.Scheme != "https" && u.Scheme != "http" {
+		err = fmt.Errorf("unsupported scheme %q, expected https", u.Scheme)
+	}
+	if err != nil {
+		logger.Error("Error parsing the URL of the DoH server", "server", server, "err", err)
+		result.err = err
+		return
+	}
+	ip, lookupTime, err := chooseProtocol(ctx, module.DNS.IPProtocol, module.DNS.IPProtocolFallback, u.Hostname(), registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		result.err = err
+		return
+	}
+	result.resolve = lookupTime
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	address := net.JoinHostPort(ip.String(), port)
+
+	tlsConfig, err := pconfig.NewTLSConfig(&module.DNS.TLSConfig)
+	if err != nil {
+		logger.Error("Failed to create TLS configuration", "err", err)
+		result.err = err
+		return
+	}
+	tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	dialer := &net.Dialer{}
+	if len(module.DNS.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(module.DNS.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", module.DNS.SourceIPAddress)
+			result.err = fmt.Errorf("invalid source ip address %q", module.DNS.SourceIPAddress)
+			return
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.TCPAddr{IP: srcIP}
+	}
+	// The address of the server was resolved by chooseProtocol, every
+	// connection goes to it whatever the host of the URL.
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// RFC 8484 recommends an ID of 0, so that responses can be cached.
+	query := msg.Copy()
+	query.Id = 0
+	body, err := query.Pack()
+	if err != nil {
+		logger.Error("Error packing the DNS query", "err", err)
+		result.err = err
+		return
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		logger.Error("Error creating request", "err", err)
+		result.err = err
+		return
+	}
+	request.Header.Set("Content-Type", dohMediaType)
+	request.Header.Set("Accept", dohMediaType)
+
+	logger.Info("Making DNS query", "target", u.String(), "address", address, "query", msg.Question[0].Name, "type", msg.Question[0].Qtype, "class", msg.Question[0].Qclass)
+	requestStart := time.Now()
+	connected := requestStart
+	request = request.WithContext(httptrace.WithClientTrace(request.Context(), &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected = time.Now() },
+	}))
+	resp, err := client.Do(request)
+	if err == nil {
+		defer resp.Body.Close()
+		result.tls = resp.TLS
+		switch {
+		case resp.StatusCode != http.StatusOK:
+			err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		case resp.Header.Get("Content-Type") != dohMediaType:
+			err = fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+		default:
+			body, err = io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+		}
+	}
+	result.connect = connected.Sub(requestStart).Seconds()
+	result.request = time.Since(connected).Seconds()
+	var response *dns.Msg
+	if err == nil {
+		response = new(dns.Msg)
+		err = response.Unpack(body)
+	}
+	if err != nil {
+		logger.Error("Error while sending a DNS query", "err", err)
+		result.err = err
+		return
+	}
+	logger.Info("Got response", "response", response)
+	if nsid, ok := dnsNSID(response); ok {
+		logger.Info("Got name server identifier", "nsid", nsid)
+	}
+	result.response = response
+}
+
+// validDNSResponse checks the rcode and the RRs of a response.
+// The results of named validators are recorded in results, which may be nil.
+func validDNSResponse(response *dns.Msg, module config.Module, results *validatorResults, logger *slog.Logger) bool {
+	validRcodes := module.DNS.ValidRcodes
+	if module.DNS.ExpectRcode != "" {
+		validRcodes = []string{module.DNS.ExpectRcode}
+	}
+	// With success criteria, the rcode is checked by the criteria.
+	if module.DNS.SuccessCriteria == nil && !validRcode(response.Rcode, validRcodes, logger) {
+		return false
+	}
+	if module.DNS.ExpectRcode != "" && dnsNegativeAnswer(response) {
+		if _, ok := dnsNegativeTTL(response); !ok {
+			logger.Error("Negative answer has no SOA record of the zone of the name in the authority section")
+			return false
+		}
+	}
+	success := true
+	logger.Info("Validating Answer RRs")
+	if !validRRs(&response.Answer, &module.DNS.ValidateAnswer, results, logger) {
+		logger.Error("Answer RRs validation failed")
+		success = false
+	}
+	logger.Info("Validating Authority RRs")
+	if !validRRs(&response.Ns, &module.DNS.ValidateAuthority, results, logger) {
+		logger.Error("Authority RRs validation failed")
+		success = false
+	}
+	logger.Info("Validating Additional RRs")
+	if !validRRs(&response.Extra, &module.DNS.ValidateAdditional, results, logger) {
+		logger.Error("Additional RRs validation failed")
+		success = false
+	}
+	if !success {
+		return false
+	}
+	if module.DNS.SuccessCriteria != nil {
+		if !evaluateCriterion(module.DNS.SuccessCriteria, func(c *config.SuccessCriterion) bool {
+			return dnsConditionsHold(c, response)
+		}) {
+			logger.Error("Success criteria did not hold", "rcode", dns.RcodeToString[response.Rcode])
+			return false
+		}
+	}
+	return true
+}
+
+// dnsNegativeAnswer returns whether a response says that the name or the
+// records of the type do not exist.
+func dnsNegativeAnswer(response *dns.Msg) bool {
+	return response.Rcode == dns.RcodeNameError || (response.Rcode == dns.RcodeSuccess && len(response.Answer) == 0)
+}
 
+// dnsNegativeTTL returns the time a negative answer is cached for, the lower
+// of the TTL and of the minimum of the SOA record of its authority section
+// (RFC 2308). The SOA record has to be the one of a zone of the name.
+func dnsNegativeTTL(response *dns.Msg) (uint32, bool) {
+	if len(response.Question) == 0 {
+		return 0, false
+	}
+	for _, rr := range response.Ns {
+		if soa, ok := rr.(*dns.SOA); ok && dns.IsSubDomain(soa.Hdr.Name, response.Question[0].Name) {
+			return min(soa.Hdr.Ttl, soa.Minttl), true
+		}
+	}
+	return 0, false
+}
+
+// exportDNSNegativeTTL exports the time a negative answer is cached for.
+func exportDNSNegativeTTL(response *dns.Msg, registry *prometheus.Registry) {
+	if !dnsNegativeAnswer(response) {
+		return
+	}
+	ttl, ok := dnsNegativeTTL(response)
+	if !ok {
+		return
+	}
+	probeDNSNegativeTTLGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_dns_negative_ttl_seconds",
+		Help: "Returns the time the negative answer is cached for, from the SOA record of the authority section",
+	})
+	registry.MustRegister(probeDNSNegativeTTLGauge)
+	probeDNSNegativeTTLGauge.Set(float64(ttl))
+}
+
+// dnsAnswerTTLRange returns the lowest and highest TTL of the records of an
+// answer, which is not ok if it has no records.
+func dnsAnswerTTLRange(answer []dns.RR) (minTTL, maxTTL uint32, ok bool) {
+	for i, rr := range answer {
+		ttl := rr.Header().Ttl
+		if i == 0 || ttl < minTTL {
+			minTTL = ttl
+		}
+		if ttl > maxTTL {
+			maxTTL = ttl
+		}
+	}
+	return minTTL, maxTTL, len(answer) > 0
+}
+
+func ProbeDNS(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	probeDNSDurationGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_dns_duration_seconds",
+		Help: "Duration of DNS request by phase",
+	}, []string{"phase"})
+	probeDNSAnswerRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_dns_answer_rrs",
+		Help: "Returns number of entries in the answer resource record list",
+	})
+	probeDNSAuthorityRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_dns_authority_rrs",
+		Help: "Returns number of entries in the authority resource record list",
+	})
+	probeDNSAdditionalRRSGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_dns_additional_rrs",
+		Help: "Returns number of entries in the additional resource record list",
+	})
+	probeDNSQuerySucceeded := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_dns_query_succeeded",
+		Help: "Displays whether or not the query was executed successfully",
+	})
+
+	for _, lv := range []string{"resolve", "connect", "request"} {
+		probeDNSDurationGaugeVec.WithLabelValues(lv)
+	}
+
+	registry.MustRegister(probeDNSDurationGaugeVec)
+	registry.MustRegister(probeDNSAnswerRRSGauge)
+	registry.MustRegister(probeDNSAuthorityRRSGauge)
+	registry.MustRegister(probeDNSAdditionalRRSGauge)
+	registry.MustRegister(probeDNSQuerySucceeded)
+
+	qc := uint16(dns.ClassINET)
+	if module.DNS.QueryClass != "" {
+		var ok bool
+		qc, ok = dns.StringToClass[module.DNS.QueryClass]
+		if !ok {
+			logger.Error("Invalid query class", "Class seen", module.DNS.QueryClass, "Existing classes", dns.ClassToString)
+			return false
+		}
+	}
+
+	qt := dns.TypeANY
+	if module.DNS.QueryType != "" {
+		var ok bool
+		qt, ok = dns.StringToType[module.DNS.QueryType]
+		if !ok {
+			logger.Error("Invalid query type", "Type seen", module.DNS.QueryType, "Existing types", dns.TypeToString)
+			return false
+		}
+	}
+	var probeDNSSOAGauge prometheus.Gauge
+
+	if module.DNS.TransportProtocol == "" {
+		module.DNS.TransportProtocol = "udp"
+	}
+	// The tls transport protocol is a shorthand for dns_over_tls over tcp.
+	if module.DNS.TransportProtocol == "tls" {
+		module.DNS.TransportProtocol = "tcp"
+		module.DNS.DNSOverTLS = true
+	}
+	if !(module.DNS.TransportProtocol == "udp" || module.DNS.TransportProtocol == "tcp" || module.DNS.TransportProtocol == "unix" || module.DNS.TransportProtocol == "doh") {
+		logger.Error("Configuration error: Expected transport protocol udp, tcp, unix or doh", "protocol", module.DNS.TransportProtocol)
+		return false
+	}
+
+	if module.DNS.Trace.Enabled {
+		response := traceDNS(ctx, dns.CanonicalName(target), qt, qc, module, registry, logger)
+		if response == nil {
+			return false
+		}
+		probeDNSAnswerRRSGauge.Set(float64(len(response.Answer)))
+		probeDNSAuthorityRRSGauge.Set(float64(len(response.Ns)))
+		probeDNSAdditionalRRSGauge.Set(float64(len(response.Extra)))
+		probeDNSQuerySucceeded.Set(1)
+		exportDNSNegativeTTL(response, registry)
+		return validDNSResponse(response, module, newValidatorResults(registry), logger)
+	}
+
+	servers := dnsServers(target, module)
+	if len(servers) == 0 {
+		logger.Error("No DNS server to query")
+		return false
+	}
+
+	msg := new(dns.Msg)
+	msg.Id = dns.Id()
+	msg.RecursionDesired = module.DNS.Recursion
+	msg.Question = make([]dns.Question, 1)
+	msg.Question[0] = dns.Question{Name: dns.Fqdn(module.DNS.QueryName), Qtype: qt, Qclass: qc}
+	if module.DNS.RequestNSID {
+		msg.SetEdns0(dns.DefaultMsgSize, false)
+		opt := msg.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID})
+	}
+
+	// The address metrics of chooseProtocol are only exported for the first
+	// server, the other ones are covered by the per-server metrics.
+	serverRegistry := func(i int) *prometheus.Registry {
+		if i == 0 {
+			return registry
+		}
+		return prometheus.NewRegistry()
+	}
+	serverLogger := func(server string) *slog.Logger {
+		if len(servers) == 1 {
+			return logger
+		}
+		return logger.With("server", server)
+	}
+
+	results := make([]dnsServerResult, len(servers))
+	if module.DNS.ServerStrategy == "parallel" {
+		var wg sync.WaitGroup
+		for i, server := range servers {
+			wg.Add(1)
+			go func(i int, server string) {
+				defer wg.Done()
+				results[i] = queryDNSServer(ctx, server, module, msg.Copy(), serverRegistry(i), serverLogger(server))
+			}(i, server)
+		}
+		wg.Wait()
+	} else {
+		// Query the servers in order until one of them answers.
+		for i, server := range servers {
+			results[i] = queryDNSServer(ctx, server, module, msg, serverRegistry(i), serverLogger(server))
+			if results[i].err == nil {
+				break
+			}
+			if i < len(servers)-1 {
+				logger.Info("Failing over to next DNS server", "failed_server", server, "next_server", servers[i+1])
+			}
+		}
+	}
+
+	var (
+		success = module.DNS.ServerStrategy == "parallel"
+		primary *dnsServerResult
+		valid   = make([]bool, len(results))
+	)
+	for i := range results {
+		result := &results[i]
+		if result.err == nil && result.response != nil {
+			// Only the results of the validators on the primary
+			// response are exported.
+			var results *validatorResults
+			if primary == nil {
+				primary = result
+				results = newValidatorResults(registry)
+			}
+			valid[i] = validDNSResponse(result.response, module, results, serverLogger(result.server))
+		}
+		if module.DNS.ServerStrategy == "parallel" {
+			success = success && valid[i]
+		} else if result.server != "" && result.err == nil {
+			success = valid[i]
+		}
+	}
+
+	if len(servers) > 1 {
+		probeDNSServerSuccessGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_dns_server_success",
+			Help: "Displays whether or not the query to the server succeeded and its response was valid",
+		}, []string{"server"})
+		probeDNSServerDurationGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_dns_server_duration_seconds",
+			Help: "Duration of the query to the server, including the resolution of its address",
+		}, []string{"server"})
+		probeDNSServerNSIDInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_dns_server_nsid_info",
+			Help: "Contains the name server identifier (NSID) of the instance of the server that answered",
+		}, []string{"server", "nsid"})
+		registry.MustRegister(probeDNSServerSuccessGaugeVec, probeDNSServerDurationGaugeVec)
+		if module.DNS.RequestNSID {
+			registry.MustRegister(probeDNSServerNSIDInfo)
+		}
+		for i, result := range results {
+			if result.server == "" {
+				// Not queried, an earlier server answered.
+				continue
+			}
+			probeDNSServerDurationGaugeVec.WithLabelValues(result.server).Set(result.resolve + result.connect + result.request)
+			if result.response != nil && module.DNS.RequestNSID {
+				if nsid, ok := dnsNSID(result.response); ok {
+					probeDNSServerNSIDInfo.WithLabelValues(result.server, nsid).Set(1)
+				}
+			}
+			if valid[i] {
+				probeDNSServerSuccessGaugeVec.WithLabelValues(result.server).Set(1)
+			} else {
+				probeDNSServerSuccessGaugeVec.WithLabelValues(result.server).Set(0)
+			}
+		}
+	}
+
+	if primary == nil {
+		// None of the servers answered, export the timings of the last one.
+		last := results[0]
+		for _, result := range results {
+			if result.server != "" {
+				last = result
+			}
+		}
+		probeDNSDurationGaugeVec.WithLabelValues("resolve").Add(last.resolve)
+		probeDNSDurationGaugeVec.WithLabelValues("connect").Set(last.connect)
+		probeDNSDurationGaugeVec.WithLabelValues("request").Set(last.request)
+		return false
+	}
+
+	probeDNSDurationGaugeVec.WithLabelValues("resolve").Add(primary.resolve)
+	probeDNSDurationGaugeVec.WithLabelValues("connect").Set(primary.connect)
+	probeDNSDurationGaugeVec.WithLabelValues("request").Set(primary.request)
+
+	if primary.tls != nil {
+		exportTLSState(ctx, target, primary.tls, registry)
+	}
+
+	response := primary.response
 	probeDNSAnswerRRSGauge.Set(float64(len(response.Answer)))
 	probeDNSAuthorityRRSGauge.Set(float64(len(response.Ns)))
 	probeDNSAdditionalRRSGauge.Set(float64(len(response.Extra)))
 	probeDNSQuerySucceeded.Set(1)
+	exportDNSNegativeTTL(response, registry)
+	exportValueChanged(ctx, "probe_dns_answer_changed",
+		"Indicates if the records of the answer changed since the previous probe", "dns_answer", target, dnsAnswerSet(response.Answer), registry)
+	if minTTL, maxTTL, ok := dnsAnswerTTLRange(response.Answer); ok {
+		probeDNSAnswerMinTTLGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_dns_answer_min_ttl_seconds",
+			Help: "Returns the lowest TTL of the resource records of the answer",
+		})
+		probeDNSAnswerMaxTTLGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_dns_answer_max_ttl_seconds",
+			Help: "Returns the highest TTL of the resource records of the answer",
+		})
+		registry.MustRegister(probeDNSAnswerMinTTLGauge, probeDNSAnswerMaxTTLGauge)
+		probeDNSAnswerMinTTLGauge.Set(float64(minTTL))
+		probeDNSAnswerMaxTTLGauge.Set(float64(maxTTL))
+	}
+
+	if module.DNS.RequestNSID {
+		if nsid, ok := dnsNSID(response); ok {
+			probeDNSNSIDInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "probe_dns_nsid_info",
+				Help: "Contains the name server identifier (NSID) of the server that answered",
+			}, []string{"nsid"})
+			probeDNSNSIDHash := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_dns_nsid_hash",
+				Help: "Specifies the hash of the name server identifier (NSID) of the server that answered",
+			})
+			registry.MustRegister(probeDNSNSIDInfo, probeDNSNSIDHash)
+			probeDNSNSIDInfo.WithLabelValues(nsid).Set(1)
+			probeDNSNSIDHash.Set(stringHash(nsid))
+		}
+	}
 
 	if qt == dns.TypeSOA {
 		probeDNSSOAGauge = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -294,23 +831,5 @@ func ProbeDNS(ctx context.Context, target string, module config.Module, registry
 		}
 	}
 
-	if !validRcode(response.Rcode, module.DNS.ValidRcodes, logger) {
-		return false
-	}
-	logger.Info("Validating Answer RRs")
-	if !validRRs(&response.Answer, &module.DNS.ValidateAnswer, logger) {
-		logger.Error("Answer RRs validation failed")
-		return false
-	}
-	logger.Info("Validating Authority RRs")
-	if !validRRs(&response.Ns, &module.DNS.ValidateAuthority, logger) {
-		logger.Error("Authority RRs validation failed")
-		return false
-	}
-	logger.Info("Validating Additional RRs")
-	if !validRRs(&response.Extra, &module.DNS.ValidateAdditional, logger) {
-		logger.Error("Additional RRs validation failed")
-		return false
-	}
-	return true
+	return success
 }
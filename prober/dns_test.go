@@ -15,14 +15,23 @@ package prober
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/miekg/dns"
 	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/promslog"
 
 	"github.com/prometheus/blackbox_exporter/config"
@@ -647,11 +656,444 @@ func TestDNSMetrics(t *testing.T) {
 				"request": {},
 			},
 		},
-		"probe_dns_answer_rrs":      nil,
-		"probe_dns_authority_rrs":   nil,
-		"probe_dns_additional_rrs":  nil,
-		"probe_dns_query_succeeded": nil,
+		"probe_dns_answer_rrs":             nil,
+		"probe_dns_authority_rrs":          nil,
+		"probe_dns_additional_rrs":         nil,
+		"probe_dns_query_succeeded":        nil,
+		"probe_dns_answer_min_ttl_seconds": nil,
+		"probe_dns_answer_max_ttl_seconds": nil,
 	}
 
 	checkMetrics(expectedMetrics, mfs, t)
 }
+
+func TestDNSAnswerTTLRange(t *testing.T) {
+	var answer []dns.RR
+	for _, rr := range []string{
+		"example.com. 3600 IN A 127.0.0.1",
+		"example.com. 30 IN A 127.0.0.2",
+		"example.com. 86400 IN A 127.0.0.3",
+	} {
+		a, err := dns.NewRR(rr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer = append(answer, a)
+	}
+	if minTTL, maxTTL, ok := dnsAnswerTTLRange(answer); !ok || minTTL != 30 || maxTTL != 86400 {
+		t.Errorf("Expected TTLs from 30 to 86400, got %d to %d (%t)", minTTL, maxTTL, ok)
+	}
+	if _, _, ok := dnsAnswerTTLRange(nil); ok {
+		t.Error("Expected no TTLs for an empty answer")
+	}
+}
+
+func TestDNSMultipleServers(t *testing.T) {
+	for _, protocol := range PROTOCOLS {
+		goodServer, goodAddr := startDNSServer(protocol, recursiveDNSHandler)
+		defer goodServer.Shutdown()
+		failServer, failAddr := startDNSServer(protocol, dns.HandleFailed)
+		defer failServer.Shutdown()
+
+		// Reserve a port and close it again to get a server that does not answer.
+		ln, err := net.Listen("tcp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		down := ln.Addr().String()
+		ln.Close()
+
+		local := func(addr net.Addr) string {
+			_, port, _ := net.SplitHostPort(addr.String())
+			return net.JoinHostPort("127.0.0.1", port)
+		}
+		good, fail := local(goodAddr), local(failAddr)
+
+		tests := []struct {
+			target         string
+			servers        []string
+			strategy       string
+			shouldSucceed  bool
+			expectedServer map[string]float64
+		}{
+			{
+				target:         down + "," + good,
+				shouldSucceed:  true,
+				expectedServer: map[string]float64{down: 0, good: 1},
+			},
+			{
+				target:         "ignored",
+				servers:        []string{good, down},
+				shouldSucceed:  true,
+				expectedServer: map[string]float64{good: 1},
+			},
+			{
+				target:         fail + "," + good,
+				shouldSucceed:  false,
+				expectedServer: map[string]float64{fail: 0},
+			},
+			{
+				target:         good + "," + fail,
+				strategy:       "parallel",
+				shouldSucceed:  false,
+				expectedServer: map[string]float64{good: 1, fail: 0},
+			},
+			{
+				target:         good + "," + good,
+				strategy:       "parallel",
+				shouldSucceed:  true,
+				expectedServer: map[string]float64{good: 1},
+			},
+			{
+				target:        down,
+				shouldSucceed: false,
+			},
+		}
+
+		for i, test := range tests {
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, DNS: config.DNSProbe{
+				IPProtocol:         "ip4",
+				IPProtocolFallback: true,
+				TransportProtocol:  protocol,
+				QueryName:          "example.com",
+				Recursion:          true,
+				Servers:            test.servers,
+				ServerStrategy:     test.strategy,
+			}}
+			result := ProbeDNS(testCTX, test.target, module, registry, promslog.NewNopLogger())
+			if result != test.shouldSucceed {
+				t.Fatalf("Test %d (%s) had unexpected result: %v", i, protocol, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedServer == nil {
+				checkAbsentMetrics([]string{"probe_dns_server_success"}, mfs, t)
+				continue
+			}
+			for _, mf := range mfs {
+				if mf.GetName() != "probe_dns_server_success" {
+					continue
+				}
+				if len(mf.Metric) != len(test.expectedServer) {
+					t.Fatalf("Test %d (%s): expected %d servers, got %v", i, protocol, len(test.expectedServer), mf.Metric)
+				}
+				for _, m := range mf.Metric {
+					server := m.Label[0].GetValue()
+					if expected, ok := test.expectedServer[server]; !ok || expected != m.GetGauge().GetValue() {
+						t.Fatalf("Test %d (%s): unexpected result %v for server %s", i, protocol, m.GetGauge().GetValue(), server)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDNSUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "dns.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := dns.NewServeMux()
+	h.HandleFunc(".", recursiveDNSHandler)
+	server := &dns.Server{Listener: ln, Handler: h}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	module := config.Module{
+		Timeout: time.Second,
+		DNS: config.DNSProbe{
+			TransportProtocol: "unix",
+			QueryName:         "example.com",
+			Recursion:         true,
+		},
+	}
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeDNS(testCTX, socket, module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("DNS query over the unix socket failed, expected success.")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{"probe_dns_answer_rrs": 2}, mfs, t)
+}
+
+func TestDNSOverTLS(t *testing.T) {
+	certExpiry := time.Now().AddDate(0, 0, 1)
+	_, certPEM, key := generateSelfSignedCertificate(generateCertificateTemplate(certExpiry, true))
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := dns.NewServeMux()
+	h.HandleFunc(".", recursiveDNSHandler)
+	server := &dns.Server{Listener: ln, Net: "tcp-tls", Handler: h}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	module := config.Module{
+		Timeout: time.Second,
+		DNS: config.DNSProbe{
+			IPProtocol:        "ip4",
+			TransportProtocol: "tls",
+			QueryName:         "example.com",
+			Recursion:         true,
+			TLSConfig:         pconfig.TLSConfig{InsecureSkipVerify: true},
+		},
+	}
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeDNS(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("DNS query over TLS failed, expected success.")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{
+		"probe_dns_answer_rrs":           2,
+		"probe_ssl_earliest_cert_expiry": float64(certExpiry.Unix()),
+	}, mfs, t)
+}
+
+func TestDNSOverHTTPS(t *testing.T) {
+	contentType := "application/dns-message"
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		query := new(dns.Msg)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" || query.Unpack(body) != nil {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		m := new(dns.Msg)
+		m.SetReply(query)
+		a, err := dns.NewRR("example.com. 3600 IN A 127.0.0.1")
+		if err != nil {
+			panic(err)
+		}
+		m.Answer = append(m.Answer, a)
+		b, err := m.Pack()
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	module := config.Module{
+		Timeout: time.Second,
+		DNS: config.DNSProbe{
+			IPProtocol:        "ip4",
+			TransportProtocol: "doh",
+			QueryName:         "example.com",
+			QueryType:         "A",
+			Recursion:         true,
+			TLSConfig:         pconfig.TLSConfig{InsecureSkipVerify: true},
+			ValidateAnswer: config.DNSRRValidator{
+				FailIfNotMatchesRegexp: []string{".*\\tIN\\tA\\t127.0.0.1"},
+			},
+		},
+	}
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeDNS(testCTX, ts.URL+"/dns-query", module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("DNS query over HTTPS failed, expected success.")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{
+		"probe_dns_answer_rrs":      1,
+		"probe_dns_query_succeeded": 1,
+	}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_tls_version_info": {"version": "TLS 1.3"},
+	}, mfs, t)
+
+	// Answers of another media type are not DNS messages.
+	contentType = "application/json"
+	registry = prometheus.NewRegistry()
+	if ProbeDNS(testCTX, ts.URL+"/dns-query", module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("DNS query over HTTPS succeeded with a JSON answer, expected failure.")
+	}
+}
+
+func TestDNSNSID(t *testing.T) {
+	for _, test := range []struct {
+		nsid     string
+		expected string
+	}{
+		{hex.EncodeToString([]byte("resolver-ams-3")), "resolver-ams-3"},
+		{"00ff10", "00ff10"},
+	} {
+		server, addr := startDNSServer("udp", func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			if opt := r.IsEdns0(); opt != nil {
+				m.SetEdns0(opt.UDPSize(), false)
+				for _, o := range opt.Option {
+					if o.Option() == dns.EDNS0NSID {
+						m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: test.nsid})
+					}
+				}
+			}
+			w.WriteMsg(m)
+		})
+		defer server.Shutdown()
+
+		module := config.Module{
+			Timeout: time.Second,
+			DNS: config.DNSProbe{
+				IPProtocol:         "ip4",
+				IPProtocolFallback: true,
+				QueryName:          "example.com",
+				RequestNSID:        true,
+			},
+		}
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if !ProbeDNS(testCTX, addr.String(), module, registry, promslog.NewNopLogger()) {
+			t.Fatalf("DNS test connection failed, expected success.")
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryLabels(map[string]map[string]string{"probe_dns_nsid_info": {"nsid": test.expected}}, mfs, t)
+		checkRegistryResults(map[string]float64{"probe_dns_nsid_hash": stringHash(test.expected)}, mfs, t)
+	}
+}
+
+// nsidDNSHandler answers with the NSID if it is requested.
+func nsidDNSHandler(nsid string) func(dns.ResponseWriter, *dns.Msg) {
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if opt := r.IsEdns0(); opt != nil {
+			m.SetEdns0(opt.UDPSize(), false)
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: hex.EncodeToString([]byte(nsid))})
+		}
+		w.WriteMsg(m)
+	}
+}
+
+func TestDNSServerNSID(t *testing.T) {
+	var servers []string
+	for _, nsid := range []string{"instance-a", "instance-b"} {
+		server, addr := startDNSServer("udp", nsidDNSHandler(nsid))
+		defer server.Shutdown()
+		servers = append(servers, addr.String())
+	}
+
+	module := config.Module{
+		Timeout: time.Second,
+		DNS: config.DNSProbe{
+			IPProtocol:         "ip4",
+			IPProtocolFallback: true,
+			QueryName:          "example.com",
+			ServerStrategy:     "parallel",
+			Servers:            servers,
+			RequestNSID:        true,
+		},
+	}
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeDNS(testCTX, "", module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("DNS test connection failed, expected success.")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_dns_server_nsid_info" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			got[labels["server"]] = labels["nsid"]
+		}
+	}
+	if got[servers[0]] != "instance-a" || got[servers[1]] != "instance-b" {
+		t.Errorf("Unexpected NSIDs of the servers: %v", got)
+	}
+}
+
+func negativeDNSHandler(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	switch r.Question[0].Name {
+	case "gone.example.com.":
+		m.Rcode = dns.RcodeNameError
+		m.Ns = mustRRs("example.com. 3600 IN SOA ns.example.com. noc.example.com. 1000 7200 3600 1209600 300")
+	case "nosoa.example.com.":
+		m.Rcode = dns.RcodeNameError
+	default:
+		m.Answer = mustRRs(r.Question[0].Name + " 3600 IN A 127.0.0.1")
+	}
+	if err := w.WriteMsg(m); err != nil {
+		panic(err)
+	}
+}
+
+func TestDNSExpectRcode(t *testing.T) {
+	server, addr := startDNSServer("udp", negativeDNSHandler)
+	defer server.Shutdown()
+
+	tests := []struct {
+		name     string
+		success  bool
+		expected map[string]float64
+	}{
+		{name: "gone.example.com", success: true, expected: map[string]float64{"probe_dns_negative_ttl_seconds": 300}},
+		{name: "nosoa.example.com"},
+		{name: "www.example.com"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			module := config.Module{DNS: config.DNSProbe{
+				IPProtocol:  "ip4",
+				QueryName:   test.name,
+				QueryType:   "A",
+				ExpectRcode: "NXDOMAIN",
+			}}
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if success := ProbeDNS(testCTX, addr.String(), module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const (
+	// dnsTraceMaxSteps bounds the depth of the delegations followed.
+	dnsTraceMaxSteps = 16
+	// dnsTraceQueryTimeout is the time a server of a zone has to answer
+	// before the next one is tried.
+	dnsTraceQueryTimeout = 2 * time.Second
+)
+
+// dnsTracePort is the port of the servers the delegations refer to, which
+// only tests change.
+var dnsTracePort = "53"
+
+// dnsRootHints are the addresses of the root servers, a to m.
+var dnsRootHints = []string{
+	"198.41.0.4", "170.247.170.2", "192.33.4.12", "199.7.91.13", "192.203.230.10", "192.5.5.241", "192.112.36.4",
+	"198.97.190.53", "192.36.148.17", "192.58.128.30", "193.0.14.129", "199.7.83.42", "202.12.27.33",
+	"2001:503:ba3e::2:30", "2801:1b8:10::b", "2001:500:2::c", "2001:500:2d::d", "2001:500:a8::e", "2001:500:2f::f", "2001:500:12::d0d",
+	"2001:500:1::53", "2001:7fe::53", "2001:503:c27::2:30", "2001:7fd::1", "2001:500:9f::42", "2001:dc3::35",
+}
+
+// dnsTraceServers returns the addresses of the servers to query, in the
+// preferred IP protocol first. The other protocol is only used with
+// ip_protocol_fallback.
+func dnsTraceServers(addresses []string, module config.Module) []string {
+	preferIP4 := module.DNS.IPProtocol == "ip4"
+	var preferred, fallback []string
+	for _, address := range addresses {
+		host := address
+		if h, _, err := net.SplitHostPort(address); err == nil {
+			host = h
+		} else {
+			address = net.JoinHostPort(address, dnsTracePort)
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			continue
+		}
+		if (ip.To4() != nil) == preferIP4 {
+			preferred = append(preferred, address)
+		} else {
+			fallback = append(fallback, address)
+		}
+	}
+	if module.DNS.IPProtocolFallback {
+		preferred = append(preferred, fallback...)
+	}
+	return preferred
+}
+
+// dnsReferral returns the zone a response delegates name to and the names of
+// its servers. The zone has to be below the zone of the servers that sent the
+// response, so that the resolution always goes down the hierarchy.
+func dnsReferral(response *dns.Msg, name, zone string) (string, []string, bool) {
+	var child string
+	var servers []string
+	for _, rr := range response.Ns {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		owner := dns.CanonicalName(ns.Hdr.Name)
+		if child == "" {
+			if !dns.IsSubDomain(owner, name) || !dns.IsSubDomain(zone, owner) || dns.CountLabel(owner) <= dns.CountLabel(zone) {
+				continue
+			}
+			child = owner
+		}
+		if owner == child {
+			servers = append(servers, dns.CanonicalName(ns.Ns))
+		}
+	}
+	return child, servers, child != ""
+}
+
+// dnsDelegationSigned returns whether a referral has a DS record for the
+// zone, i.e. whether the delegation is signed. The signatures are not
+// verified.
+func dnsDelegationSigned(response *dns.Msg, zone string) bool {
+	for _, rr := range response.Ns {
+		if ds, ok := rr.(*dns.DS); ok && dns.CanonicalName(ds.Hdr.Name) == zone {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsGlue returns the addresses of the servers found in the additional
+// section of a referral.
+func dnsGlue(response *dns.Msg, servers []string) []string {
+	var addresses []string
+	for _, rr := range response.Extra {
+		if !slices.Contains(servers, dns.CanonicalName(rr.Header().Name)) {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.A:
+			addresses = append(addresses, rr.A.String())
+		case *dns.AAAA:
+			addresses = append(addresses, rr.AAAA.String())
+		}
+	}
+	return addresses
+}
+
+// queryDNSZone sends msg to the servers of a zone in turn until one of them
+// answers, over TCP if the response over UDP is truncated.
+func queryDNSZone(ctx context.Context, servers []string, msg *dns.Msg, logger *slog.Logger) (*dns.Msg, time.Duration) {
+	for _, server := range servers {
+		for _, protocol := range []string{"udp", "tcp"} {
+			queryCtx, cancel := context.WithTimeout(ctx, dnsTraceQueryTimeout)
+			var result dnsServerResult
+			exchangeDNS(queryCtx, &dns.Client{Net: protocol}, msg, server, &result, logger)
+			cancel()
+			if result.err != nil {
+				break
+			}
+			if !result.response.Truncated {
+				return result.response, time.Duration((result.connect + result.request) * float64(time.Second))
+			}
+			logger.Info("Response is truncated, retrying over TCP", "server", server)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, 0
+}
+
+// traceDNS resolves name iteratively from the root hints and returns the
+// authoritative response, or nil if a zone could not be resolved. The
+// duration of the queries to the servers of each zone and whether they
+// answered with an authoritative response or a referral are exported.
+func traceDNS(ctx context.Context, name string, qt, qc uint16, module config.Module, registry *prometheus.Registry, logger *slog.Logger) *dns.Msg {
+	durationGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_dns_trace_duration_seconds",
+		Help: "Duration of the query to the servers of each zone of the trace",
+	}, []string{"zone"})
+	validGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_dns_trace_delegation_valid",
+		Help: "Indicates if the servers of each zone of the trace answered authoritatively or with a referral down the hierarchy",
+	}, []string{"zone"})
+	signedGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_dns_trace_delegation_signed",
+		Help: "Indicates if the delegation to each zone of the trace has a DS record",
+	}, []string{"zone"})
+	registry.MustRegister(durationGaugeVec, validGaugeVec, signedGaugeVec)
+
+	hints := module.DNS.Trace.RootHints
+	if len(hints) == 0 {
+		hints = dnsRootHints
+	}
+	servers := dnsTraceServers(hints, module)
+	zone := "."
+	for step := 0; step < dnsTraceMaxSteps; step++ {
+		logger.Info("Querying the servers of the zone", "zone", zone, "servers", servers)
+		msg := new(dns.Msg)
+		msg.Id = dns.Id()
+		msg.Question = []dns.Question{{Name: name, Qtype: qt, Qclass: qc}}
+		// The DO bit asks for the DS records of the delegations.
+		msg.SetEdns0(dns.DefaultMsgSize, true)
+		response, duration := queryDNSZone(ctx, servers, msg, logger)
+		if response == nil {
+			logger.Error("No server of the zone answered", "zone", zone)
+			validGaugeVec.WithLabelValues(zone).Set(0)
+			return nil
+		}
+		durationGaugeVec.WithLabelValues(zone).Set(duration.Seconds())
+
+		if response.Authoritative {
+			logger.Info("Got authoritative response", "zone", zone, "rcode", dns.RcodeToString[response.Rcode])
+			validGaugeVec.WithLabelValues(zone).Set(1)
+			return response
+		}
+		child, names, ok := dnsReferral(response, name, zone)
+		if response.Rcode != dns.RcodeSuccess || len(response.Answer) > 0 || !ok {
+			// Lame delegations typically get refused or answered from the
+			// cache of a recursive resolver.
+			logger.Error("Servers of the zone sent neither an authoritative response nor a referral", "zone", zone, "rcode", dns.RcodeToString[response.Rcode])
+			validGaugeVec.WithLabelValues(zone).Set(0)
+			return nil
+		}
+		validGaugeVec.WithLabelValues(zone).Set(1)
+		signed := dnsDelegationSigned(response, child)
+		signedGaugeVec.WithLabelValues(child)
+		if signed {
+			signedGaugeVec.WithLabelValues(child).Set(1)
+		}
+		logger.Info("Got referral", "zone", child, "servers", names, "signed", signed)
+
+		addresses := dnsGlue(response, names)
+		if len(addresses) == 0 {
+			// Servers outside of the delegated zone have no glue, they are
+			// resolved with the resolver of the system.
+			for _, n := range names {
+				ips, err := net.DefaultResolver.LookupIPAddr(ctx, n)
+				if err != nil {
+					logger.Warn("Error resolving the server of the zone", "zone", child, "server", n, "err", err)
+					continue
+				}
+				for _, ip := range ips {
+					addresses = append(addresses, ip.IP.String())
+				}
+			}
+		}
+		servers = dnsTraceServers(addresses, module)
+		if len(servers) == 0 {
+			logger.Error("No address found for the servers of the zone", "zone", child)
+			validGaugeVec.WithLabelValues(child).Set(0)
+			return nil
+		}
+		zone = child
+	}
+	logger.Error("Too many delegations", "max_steps", dnsTraceMaxSteps)
+	return nil
+}
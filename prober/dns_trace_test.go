@@ -0,0 +1,154 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startTraceDNSServer answers the queries received on address with respond,
+// which fills the reply.
+func startTraceDNSServer(t *testing.T, address string, respond func(r *dns.Msg, m *dns.Msg)) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		t.Skipf("Cannot listen on %s: %s", address, err)
+	}
+	h := dns.NewServeMux()
+	h.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		respond(r, m)
+		w.WriteMsg(m)
+	})
+	server := &dns.Server{PacketConn: conn, Handler: h}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+}
+
+func mustRRs(rrs ...string) []dns.RR {
+	var result []dns.RR
+	for _, s := range rrs {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, rr)
+	}
+	return result
+}
+
+func TestDNSTrace(t *testing.T) {
+	// The servers of the zones listen on the same port of different loopback
+	// addresses, as delegations only have addresses.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+	conn.Close()
+	defer func(p string) { dnsTracePort = p }(dnsTracePort)
+	dnsTracePort = port
+
+	startTraceDNSServer(t, "127.0.0.1:"+port, func(r, m *dns.Msg) {
+		m.Ns = mustRRs("test. 172800 IN NS a.nic.test.",
+			"test. 86400 IN DS 19718 13 2 8ACBB0CD28F41250A80A491389424D341522D946B0DA0C0291F2D3D771D7805A")
+		m.Extra = append(m.Extra, mustRRs("a.nic.test. 172800 IN A 127.0.0.2")...)
+	})
+	startTraceDNSServer(t, "127.0.0.2:"+port, func(r, m *dns.Msg) {
+		// Both zones are delegated to the same server, which is only
+		// authoritative for example.test.
+		zone := "example.test."
+		if strings.HasSuffix(r.Question[0].Name, "lame.test.") {
+			zone = "lame.test."
+		}
+		m.Ns = mustRRs(zone + " 172800 IN NS ns." + zone)
+		m.Extra = append(m.Extra, mustRRs("ns."+zone+" 172800 IN A 127.0.0.3")...)
+	})
+	startTraceDNSServer(t, "127.0.0.3:"+port, func(r, m *dns.Msg) {
+		if !strings.HasSuffix(r.Question[0].Name, "example.test.") {
+			m.Rcode = dns.RcodeRefused
+			return
+		}
+		m.Authoritative = true
+		m.Answer = mustRRs(r.Question[0].Name + " 300 IN A 192.0.2.1")
+	})
+
+	tests := []struct {
+		target   string
+		success  bool
+		expected map[string]map[string]float64
+	}{
+		{
+			target:  "www.example.test",
+			success: true,
+			expected: map[string]map[string]float64{
+				"probe_dns_trace_delegation_valid":  {".": 1, "test.": 1, "example.test.": 1},
+				"probe_dns_trace_delegation_signed": {"test.": 1, "example.test.": 0},
+			},
+		},
+		{
+			target: "www.lame.test",
+			expected: map[string]map[string]float64{
+				"probe_dns_trace_delegation_valid": {".": 1, "test.": 1, "lame.test.": 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			module := config.Module{DNS: config.DNSProbe{
+				IPProtocol: "ip4",
+				QueryType:  "A",
+				Trace:      config.DNSTrace{Enabled: true, RootHints: []string{"127.0.0.1"}},
+			}}
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if success := ProbeDNS(testCTX, test.target, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mf := range mfs {
+				expected, ok := test.expected[mf.GetName()]
+				if !ok {
+					continue
+				}
+				if len(mf.Metric) != len(expected) {
+					t.Errorf("Expected %d zones for %s, got %d", len(expected), mf.GetName(), len(mf.Metric))
+				}
+				for _, m := range mf.Metric {
+					zone := m.Label[0].GetValue()
+					if value, ok := expected[zone]; !ok || value != m.GetGauge().GetValue() {
+						t.Errorf("Unexpected value %v of %s for zone %s", m.GetGauge().GetValue(), mf.GetName(), zone)
+					}
+				}
+			}
+			if test.success {
+				checkRegistryResults(map[string]float64{"probe_dns_answer_rrs": 1}, mfs, t)
+			}
+		})
+	}
+}
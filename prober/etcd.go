@@ -0,0 +1,298 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// The methods of the etcd v3 API used by the prober. Their messages are
+// encoded with protowire rather than generated code.
+const (
+	etcdDefaultPort = "2379"
+
+	etcdAuthenticate = "/etcdserverpb.Auth/Authenticate"
+	etcdStatus       = "/etcdserverpb.Maintenance/Status"
+	etcdRange        = "/etcdserverpb.KV/Range"
+
+	// The token of authenticated requests is passed as metadata.
+	etcdTokenMetadata = "token"
+
+	// The numbers of the fields of the messages.
+	etcdAuthName        = 1
+	etcdAuthPassword    = 2
+	etcdAuthToken       = 2
+	etcdStatusHeader    = 1
+	etcdStatusVersion   = 2
+	etcdStatusLeader    = 4
+	etcdStatusRaftTerm  = 6
+	etcdStatusErrors    = 8
+	etcdStatusIsLearner = 10
+	etcdHeaderMemberID  = 2
+	etcdRangeKey        = 1
+	etcdRangeCountOnly  = 9
+)
+
+// etcdCodec passes the encoded messages of the etcd API through as is.
+type etcdCodec struct{}
+
+func (etcdCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (etcdCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (etcdCodec) Name() string {
+	return "proto"
+}
+
+func invokeEtcd(ctx context.Context, conn *grpc.ClientConn, method string, req []byte) ([]byte, error) {
+	var resp []byte
+	if err := conn.Invoke(ctx, method, &req, &resp, grpc.ForceCodec(etcdCodec{})); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// walkProtoFields calls fn with the fields of a message, with the value of
+// varint fields and the content of length-delimited ones. Other fields are
+// skipped.
+func walkProtoFields(b []byte, fn func(num protowire.Number, v uint64, data []byte)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var (
+			v    uint64
+			data []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			fn(num, v, data)
+		}
+	}
+	return nil
+}
+
+// etcdStatusResponse is the content of a Status response.
+type etcdStatusResponse struct {
+	memberID  uint64
+	leader    uint64
+	raftTerm  uint64
+	version   string
+	isLearner bool
+	errors    []string
+}
+
+func decodeEtcdStatus(b []byte) (*etcdStatusResponse, error) {
+	status := &etcdStatusResponse{}
+	var headerErr error
+	err := walkProtoFields(b, func(num protowire.Number, v uint64, data []byte) {
+		switch num {
+		case etcdStatusHeader:
+			headerErr = walkProtoFields(data, func(num protowire.Number, v uint64, _ []byte) {
+				if num == etcdHeaderMemberID {
+					status.memberID = v
+				}
+			})
+		case etcdStatusVersion:
+			status.version = string(data)
+		case etcdStatusLeader:
+			status.leader = v
+		case etcdStatusRaftTerm:
+			status.raftTerm = v
+		case etcdStatusErrors:
+			status.errors = append(status.errors, string(data))
+		case etcdStatusIsLearner:
+			status.isLearner = v != 0
+		}
+	})
+	if err == nil {
+		err = headerErr
+	}
+	return status, err
+}
+
+// ProbeEtcd checks the gRPC health of an etcd member, that its cluster has
+// a leader and that a key can be read with a linearizable read, which
+// requires a quorum of the members.
+func ProbeEtcd(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_etcd_duration_seconds",
+			Help: "Duration of the etcd checks by phase",
+		}, []string{"phase"})
+		hasLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_etcd_has_leader",
+			Help: "Indicates if the member knows the leader of the cluster",
+		})
+		isLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_etcd_is_leader",
+			Help: "Indicates if the member is the leader of the cluster",
+		})
+		raftTermGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_etcd_raft_term",
+			Help: "The Raft term of the member, which increases with each election",
+		})
+		infoGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_etcd_server_info",
+			Help: "Contains the version of the member",
+		}, []string{"version"})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.Etcd
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, etcdDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+
+	creds := insecure.NewCredentials()
+	if c.TLS {
+		tlsConfig, err := pconfig.NewTLSConfig(&c.TLSConfig)
+		if err != nil {
+			logger.Error("Error creating TLS configuration", "err", err)
+			return false
+		}
+		tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
+		if len(tlsConfig.ServerName) == 0 {
+			tlsConfig.ServerName = targetAddress
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(net.JoinHostPort(ip.String(), port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		logger.Error("Error creating gRPC client", "err", err)
+		return false
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	health, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	durationGaugeVec.WithLabelValues("health").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error checking health", "err", err)
+		return false
+	}
+	if health.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		logger.Error("Member is not serving", "status", health.GetStatus())
+		return false
+	}
+
+	if c.Username != "" {
+		start = time.Now()
+		req := protowire.AppendTag(nil, etcdAuthName, protowire.BytesType)
+		req = protowire.AppendString(req, c.Username)
+		req = protowire.AppendTag(req, etcdAuthPassword, protowire.BytesType)
+		req = protowire.AppendString(req, string(c.Password))
+		resp, err := invokeEtcd(ctx, conn, etcdAuthenticate, req)
+		durationGaugeVec.WithLabelValues("auth").Set(time.Since(start).Seconds())
+		var token string
+		if err == nil {
+			err = walkProtoFields(resp, func(num protowire.Number, _ uint64, data []byte) {
+				if num == etcdAuthToken {
+					token = string(data)
+				}
+			})
+		}
+		if err == nil && token == "" {
+			err = errors.New("no token")
+		}
+		if err != nil {
+			logger.Error("Error authenticating", "err", err)
+			return false
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, etcdTokenMetadata, token)
+	}
+
+	start = time.Now()
+	resp, err := invokeEtcd(ctx, conn, etcdStatus, nil)
+	durationGaugeVec.WithLabelValues("status").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error getting member status", "err", err)
+		return false
+	}
+	status, err := decodeEtcdStatus(resp)
+	if err != nil {
+		logger.Error("Error decoding member status", "err", err)
+		return false
+	}
+	logger.Info("Got member status", "version", status.version, "member_id", fmt.Sprintf("%x", status.memberID), "leader", fmt.Sprintf("%x", status.leader), "raft_term", status.raftTerm, "is_learner", status.isLearner)
+	registry.MustRegister(hasLeaderGauge, isLeaderGauge, raftTermGauge, infoGaugeVec)
+	raftTermGauge.Set(float64(status.raftTerm))
+	infoGaugeVec.WithLabelValues(status.version).Set(1)
+	if status.leader == 0 {
+		logger.Error("Member has no leader")
+		return false
+	}
+	hasLeaderGauge.Set(1)
+	if status.leader == status.memberID {
+		isLeaderGauge.Set(1)
+	}
+	if len(status.errors) > 0 {
+		logger.Error("Member reported errors", "errors", strings.Join(status.errors, "; "))
+		return false
+	}
+
+	start = time.Now()
+	req := protowire.AppendTag(nil, etcdRangeKey, protowire.BytesType)
+	req = protowire.AppendString(req, c.Key)
+	req = protowire.AppendTag(req, etcdRangeCountOnly, protowire.VarintType)
+	req = protowire.AppendVarint(req, 1)
+	_, err = invokeEtcd(ctx, conn, etcdRange, req)
+	durationGaugeVec.WithLabelValues("read").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error reading key", "key", c.Key, "err", err)
+		return false
+	}
+	return true
+}
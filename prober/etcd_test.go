@@ -0,0 +1,177 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// etcdTestMember serves the methods of the etcd API used by the prober.
+type etcdTestMember struct {
+	memberID uint64
+	leader   uint64
+	errors   []string
+	// noQuorum fails linearizable reads.
+	noQuorum bool
+	// password enables authentication for the user root.
+	password string
+}
+
+func (m *etcdTestMember) handle(_ interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	var req []byte
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	if m.password != "" && method != etcdAuthenticate && method != "/grpc.health.v1.Health/Check" {
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		if !slices.Contains(md.Get(etcdTokenMetadata), "root-token") {
+			return status.Error(codes.InvalidArgument, "etcdserver: user name is empty")
+		}
+	}
+
+	var resp []byte
+	switch method {
+	case "/grpc.health.v1.Health/Check":
+		resp = protowire.AppendTag(resp, 1, protowire.VarintType)
+		resp = protowire.AppendVarint(resp, uint64(grpc_health_v1.HealthCheckResponse_SERVING))
+	case etcdAuthenticate:
+		var name, password string
+		walkProtoFields(req, func(num protowire.Number, _ uint64, data []byte) {
+			switch num {
+			case etcdAuthName:
+				name = string(data)
+			case etcdAuthPassword:
+				password = string(data)
+			}
+		})
+		if name != "root" || password != m.password {
+			return status.Error(codes.InvalidArgument, "etcdserver: authentication failed, invalid user ID or password")
+		}
+		resp = protowire.AppendTag(resp, etcdAuthToken, protowire.BytesType)
+		resp = protowire.AppendString(resp, "root-token")
+	case etcdStatus:
+		header := protowire.AppendTag(nil, etcdHeaderMemberID, protowire.VarintType)
+		header = protowire.AppendVarint(header, m.memberID)
+		resp = protowire.AppendTag(resp, etcdStatusHeader, protowire.BytesType)
+		resp = protowire.AppendBytes(resp, header)
+		resp = protowire.AppendTag(resp, etcdStatusVersion, protowire.BytesType)
+		resp = protowire.AppendString(resp, "3.5.17")
+		resp = protowire.AppendTag(resp, etcdStatusLeader, protowire.VarintType)
+		resp = protowire.AppendVarint(resp, m.leader)
+		resp = protowire.AppendTag(resp, etcdStatusRaftTerm, protowire.VarintType)
+		resp = protowire.AppendVarint(resp, 7)
+		for _, err := range m.errors {
+			resp = protowire.AppendTag(resp, etcdStatusErrors, protowire.BytesType)
+			resp = protowire.AppendString(resp, err)
+		}
+	case etcdRange:
+		if m.noQuorum {
+			return status.Error(codes.Unavailable, "etcdserver: request timed out")
+		}
+	default:
+		return status.Error(codes.Unimplemented, method)
+	}
+	return stream.SendMsg(&resp)
+}
+
+func startEtcdTestMember(t *testing.T, m *etcdTestMember) string {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	s := grpc.NewServer(grpc.ForceServerCodec(etcdCodec{}), grpc.UnknownServiceHandler(m.handle))
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+	return ln.Addr().String()
+}
+
+func TestEtcd(t *testing.T) {
+	tests := []struct {
+		name     string
+		member   *etcdTestMember
+		probe    config.EtcdProbe
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "leader",
+			member:   &etcdTestMember{memberID: 1, leader: 1},
+			probe:    config.EtcdProbe{Key: "health"},
+			success:  true,
+			expected: map[string]float64{"probe_etcd_has_leader": 1, "probe_etcd_is_leader": 1, "probe_etcd_raft_term": 7},
+		},
+		{
+			name:     "follower with authentication",
+			member:   &etcdTestMember{memberID: 2, leader: 1, password: "secret"},
+			probe:    config.EtcdProbe{Key: "health", Username: "root", Password: "secret"},
+			success:  true,
+			expected: map[string]float64{"probe_etcd_has_leader": 1, "probe_etcd_is_leader": 0},
+		},
+		{
+			name:   "authentication failure",
+			member: &etcdTestMember{memberID: 2, leader: 1, password: "secret"},
+			probe:  config.EtcdProbe{Key: "health", Username: "root", Password: "wrong"},
+		},
+		{
+			name:     "no leader",
+			member:   &etcdTestMember{memberID: 2},
+			probe:    config.EtcdProbe{Key: "health"},
+			expected: map[string]float64{"probe_etcd_has_leader": 0},
+		},
+		{
+			name:   "alarm",
+			member: &etcdTestMember{memberID: 2, leader: 1, errors: []string{"memberID:2 alarm:NOSPACE"}},
+			probe:  config.EtcdProbe{Key: "health"},
+		},
+		{
+			name:     "no quorum",
+			member:   &etcdTestMember{memberID: 2, leader: 1, noQuorum: true},
+			probe:    config.EtcdProbe{Key: "health"},
+			expected: map[string]float64{"probe_etcd_has_leader": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startEtcdTestMember(t, test.member)
+			test.probe.IPProtocol = "ip4"
+			testCTX, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if success := ProbeEtcd(testCTX, addr, config.Module{Etcd: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build failpoints
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// failpointAllModules is the name of the failpoint applying to the modules
+// without one of their own.
+const failpointAllModules = "*"
+
+// failpoint delays the probes of a module, or fails them without contacting
+// the target, to test the alerts on the exporter and its probes.
+type failpoint struct {
+	Delay model.Duration `json:"delay,omitempty"`
+	Fail  bool           `json:"fail,omitempty"`
+}
+
+var failpoints = struct {
+	sync.Mutex
+	m map[string]failpoint
+}{m: map[string]failpoint{}}
+
+func lookupFailpoint(module string) (failpoint, bool) {
+	failpoints.Lock()
+	defer failpoints.Unlock()
+	if fp, ok := failpoints.m[module]; ok {
+		return fp, true
+	}
+	fp, ok := failpoints.m[failpointAllModules]
+	return fp, ok
+}
+
+// withFailpoint returns a prober applying the failpoint of the module, if it
+// has one, before probing.
+func withFailpoint(prober ProbeFn, module string) ProbeFn {
+	return func(ctx context.Context, target string, m config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+		fp, ok := lookupFailpoint(module)
+		if !ok {
+			return prober(ctx, target, m, registry, logger)
+		}
+		if fp.Delay > 0 {
+			logger.Warn("Delaying probe by failpoint", "delay", fp.Delay)
+			select {
+			case <-time.After(time.Duration(fp.Delay)):
+			case <-ctx.Done():
+			}
+		}
+		if fp.Fail {
+			logger.Warn("Failing probe by failpoint")
+			return false
+		}
+		return prober(ctx, target, m, registry, logger)
+	}
+}
+
+// FailpointsHandler serves the failpoints of the modules: GET lists them, and
+// PUT and DELETE of <prefix>/<module> set and remove the failpoint of a
+// module, or of all modules with "*". It is nil unless the exporter is built
+// with the failpoints build tag.
+func FailpointsHandler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if name == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				http.Error(w, "This endpoint requires a GET request.", http.StatusMethodNotAllowed)
+				return
+			}
+			failpoints.Lock()
+			defer failpoints.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(failpoints.m)
+			return
+		}
+		if strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			var fp failpoint
+			dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&fp); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			failpoints.Lock()
+			failpoints.m[name] = fp
+			failpoints.Unlock()
+		case http.MethodDelete:
+			failpoints.Lock()
+			_, ok := failpoints.m[name]
+			delete(failpoints.m, name)
+			failpoints.Unlock()
+			if !ok {
+				http.Error(w, "failpoint not found", http.StatusNotFound)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "PUT, DELETE")
+			http.Error(w, "This endpoint requires a PUT or DELETE request.", http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
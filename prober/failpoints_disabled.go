@@ -0,0 +1,30 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !failpoints
+
+package prober
+
+import "net/http"
+
+// withFailpoint returns the prober unchanged, failpoints are only available
+// with the failpoints build tag.
+func withFailpoint(prober ProbeFn, module string) ProbeFn {
+	return prober
+}
+
+// FailpointsHandler returns nil, failpoints are only available with the
+// failpoints build tag.
+func FailpointsHandler(prefix string) http.Handler {
+	return nil
+}
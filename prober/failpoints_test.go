@@ -0,0 +1,96 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build failpoints
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestFailpoints(t *testing.T) {
+	ts := httptest.NewServer(FailpointsHandler("/debug/failpoints"))
+	defer ts.Close()
+	t.Cleanup(func() { clear(failpoints.m) })
+
+	request := func(method, path, body string) int {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	probed := false
+	prober := func(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+		probed = true
+		return true
+	}
+	probe := func(module string) bool {
+		probed = false
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return withFailpoint(prober, module)(ctx, "example.com", config.Module{}, prometheus.NewRegistry(), promslog.NewNopLogger())
+	}
+
+	if code := request(http.MethodPut, "/debug/failpoints/http_2xx", `{"fail": true}`); code != http.StatusNoContent {
+		t.Fatalf("Expected status code 204, got %d", code)
+	}
+	if probe("http_2xx") || probed {
+		t.Error("Expected the probe to fail without probing")
+	}
+	if !probe("tcp_connect") || !probed {
+		t.Error("Expected the probes of other modules to succeed")
+	}
+
+	if code := request(http.MethodPut, "/debug/failpoints/*", `{"delay": "100ms"}`); code != http.StatusNoContent {
+		t.Fatalf("Expected status code 204, got %d", code)
+	}
+	start := time.Now()
+	if !probe("tcp_connect") || !probed {
+		t.Error("Expected the delayed probe to succeed")
+	}
+	if time.Since(start) < 100*time.Millisecond {
+		t.Errorf("Expected the probe to be delayed by 100ms, took %s", time.Since(start))
+	}
+
+	if code := request(http.MethodDelete, "/debug/failpoints/http_2xx", ""); code != http.StatusNoContent {
+		t.Fatalf("Expected status code 204, got %d", code)
+	}
+	if code := request(http.MethodDelete, "/debug/failpoints/http_2xx", ""); code != http.StatusNotFound {
+		t.Fatalf("Expected status code 404, got %d", code)
+	}
+	if code := request(http.MethodPut, "/debug/failpoints/http_2xx", `{"fail": "yes"}`); code != http.StatusBadRequest {
+		t.Fatalf("Expected status code 400, got %d", code)
+	}
+	if code := request(http.MethodPost, "/debug/failpoints", ""); code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status code 405, got %d", code)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// runProbeWithFallback runs the probe of the target and, if it fails, the
+// probe of the fallback target of the module. The result is the one of the
+// target that answered, with the errors of both probes, and
+// probe_fallback_target_used tells which one it was.
+func runProbeWithFallback(ctx context.Context, prober ProbeFn, target string, module config.Module, logger *slog.Logger) *ProbeResult {
+	if module.FallbackTarget == "" {
+		return RunProbe(ctx, prober, target, module, logger)
+	}
+
+	start := time.Now()
+	// The target gets half of the timeout, so that there is time left to
+	// probe the fallback target.
+	primaryCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		primaryCtx, cancel = context.WithDeadline(ctx, start.Add(time.Until(deadline)/2))
+		defer cancel()
+	}
+	result := RunProbe(primaryCtx, prober, target, module, logger)
+	used := 0.0
+	if !result.Success {
+		logger.Warn("Probe failed, probing the fallback target", "fallback_target", module.FallbackTarget)
+		primaryErrors := result.Errors
+		result = RunProbe(ctx, prober, module.FallbackTarget, module, logger.With("fallback_target", module.FallbackTarget))
+		result.Errors = append(primaryErrors, result.Errors...)
+		result.Duration = time.Since(start)
+		used = 1
+	}
+	result.Observations = append(result.Observations, Observation{
+		Name:  "probe_fallback_target_used",
+		Help:  "Indicates if the target failed and the fallback target was probed instead",
+		Type:  ObservationGauge,
+		Value: used,
+	})
+	return result
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestRunProbeWithFallback(t *testing.T) {
+	// The probe of "up" succeeds, any other target fails after waiting for
+	// the end of its context.
+	var probed []string
+	prober := func(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+		probed = append(probed, target)
+		if target == "up" {
+			return true
+		}
+		<-ctx.Done()
+		logger.Error("Error probing target", "err", ctx.Err())
+		return false
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		fallbackTarget string
+		success        bool
+		probed         []string
+		used           float64
+		errors         int
+	}{
+		{name: "no fallback", target: "down", probed: []string{"down"}, errors: 1},
+		{name: "primary answered", target: "up", fallbackTarget: "down", success: true, probed: []string{"up"}},
+		{name: "fallback answered", target: "down", fallbackTarget: "up", success: true, probed: []string{"down", "up"}, used: 1, errors: 1},
+		{name: "both failed", target: "down", fallbackTarget: "down", probed: []string{"down", "down"}, used: 1, errors: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probed = nil
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			result := runProbeWithFallback(ctx, prober, test.target, config.Module{FallbackTarget: test.fallbackTarget}, promslog.NewNopLogger())
+			if result.Success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, result.Success)
+			}
+			if !slices.Equal(probed, test.probed) {
+				t.Fatalf("Expected probes of %v, got %v", test.probed, probed)
+			}
+			if len(result.Errors) != test.errors {
+				t.Errorf("Expected %d errors, got %v", test.errors, result.Errors)
+			}
+			var found bool
+			for _, o := range result.Observations {
+				if o.Name == "probe_fallback_target_used" {
+					found = true
+					if o.Value != test.used {
+						t.Errorf("Expected probe_fallback_target_used %v, got %v", test.used, o.Value)
+					}
+				}
+			}
+			if found != (test.fallbackTarget != "") {
+				t.Errorf("Unexpected presence of probe_fallback_target_used: %v", found)
+			}
+			// The failed probe of the target must leave time for the
+			// fallback target.
+			if test.used == 1 && test.success && ctx.Err() != nil {
+				t.Errorf("The probe of the target used the whole timeout")
+			}
+		})
+	}
+}
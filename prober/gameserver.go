@@ -0,0 +1,249 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// The Steam server queries, see
+// https://developer.valvesoftware.com/wiki/Server_queries, and the status
+// query of Quake 3 engine servers.
+const (
+	a2sDefaultPort    = "27015"
+	quake3DefaultPort = "27960"
+	// gameServerMaxPacketSize is the size of the packets of the Steam
+	// server queries, larger responses are split.
+	gameServerMaxPacketSize = 1400
+
+	a2sInfoResponse = 'I'
+	a2sChallenge    = 'A'
+)
+
+var (
+	gameServerHeader   = []byte{0xff, 0xff, 0xff, 0xff}
+	a2sInfoRequest     = []byte("\xff\xff\xff\xffTSource Engine Query\x00")
+	quake3StatusQuery  = []byte("\xff\xff\xff\xffgetstatus\n")
+	quake3StatusHeader = []byte("\xff\xff\xff\xffstatusResponse\n")
+)
+
+// gameServerInfo is the state of a game server returned by the queries.
+type gameServerInfo struct {
+	name       string
+	mapName    string
+	players    int
+	maxPlayers int
+	// bots is -1 if the protocol does not report them.
+	bots int
+}
+
+// gameServerExchange sends a query and returns the response and the round
+// trip time.
+func gameServerExchange(conn net.Conn, query []byte) ([]byte, time.Duration, error) {
+	start := time.Now()
+	if _, err := conn.Write(query); err != nil {
+		return nil, 0, err
+	}
+	buf := make([]byte, gameServerMaxPacketSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	return buf[:n], time.Since(start), nil
+}
+
+// queryA2SInfo sends an A2S_INFO query. Servers may answer with a challenge
+// that must be appended to the query to get the information.
+func queryA2SInfo(conn net.Conn) (*gameServerInfo, time.Duration, error) {
+	query := a2sInfoRequest
+	for i := 0; i < 2; i++ {
+		resp, rtt, err := gameServerExchange(conn, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(resp) < 5 || !bytes.Equal(resp[:4], gameServerHeader) {
+			return nil, 0, errors.New("invalid A2S response")
+		}
+		switch resp[4] {
+		case a2sInfoResponse:
+			info, err := decodeA2SInfo(resp[5:])
+			return info, rtt, err
+		case a2sChallenge:
+			if len(resp) < 9 {
+				return nil, 0, errors.New("invalid A2S challenge")
+			}
+			query = append(append([]byte(nil), a2sInfoRequest...), resp[5:9]...)
+		default:
+			return nil, 0, fmt.Errorf("unexpected A2S response type %q", resp[4])
+		}
+	}
+	return nil, 0, errors.New("server sent another A2S challenge")
+}
+
+func decodeA2SInfo(b []byte) (*gameServerInfo, error) {
+	// The protocol version precedes the server name, map, folder and game.
+	if len(b) < 1 {
+		return nil, errors.New("A2S_INFO response too short")
+	}
+	fields := bytes.SplitN(b[1:], []byte{0}, 5)
+	if len(fields) < 5 || len(fields[4]) < 5 {
+		return nil, errors.New("A2S_INFO response too short")
+	}
+	// The Steam application ID precedes the player counts.
+	rest := fields[4][2:]
+	return &gameServerInfo{
+		name:       string(fields[0]),
+		mapName:    string(fields[1]),
+		players:    int(rest[0]),
+		maxPlayers: int(rest[1]),
+		bots:       int(rest[2]),
+	}, nil
+}
+
+// queryQuake3Status sends a getstatus query, which returns the server
+// variables followed by a line per player.
+func queryQuake3Status(conn net.Conn) (*gameServerInfo, time.Duration, error) {
+	resp, rtt, err := gameServerExchange(conn, quake3StatusQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !bytes.HasPrefix(resp, quake3StatusHeader) {
+		return nil, 0, errors.New("invalid getstatus response")
+	}
+	lines := strings.Split(strings.TrimRight(string(resp[len(quake3StatusHeader):]), "\n"), "\n")
+	vars := map[string]string{}
+	fields := strings.Split(strings.TrimPrefix(lines[0], "\\"), "\\")
+	for i := 0; i+1 < len(fields); i += 2 {
+		vars[fields[i]] = fields[i+1]
+	}
+	info := &gameServerInfo{
+		name:    vars["sv_hostname"],
+		mapName: vars["mapname"],
+		players: len(lines) - 1,
+		bots:    -1,
+	}
+	if info.mapName == "" {
+		return nil, 0, errors.New("getstatus response has no mapname")
+	}
+	info.maxPlayers, _ = strconv.Atoi(vars["sv_maxclients"])
+	return info, rtt, nil
+}
+
+// ProbeGameServer queries a game server over UDP and exports its player
+// count and map.
+func ProbeGameServer(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		latencyGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_gameserver_latency_seconds",
+			Help: "Round trip time of the query",
+		})
+		playersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_gameserver_players",
+			Help: "The number of players on the server",
+		})
+		maxPlayersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_gameserver_max_players",
+			Help: "The maximum number of players on the server",
+		})
+		botsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_gameserver_bots",
+			Help: "The number of bots among the players",
+		})
+		mapHashGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_gameserver_map_hash",
+			Help: "Specifies the hash of the name of the current map",
+		})
+	)
+
+	c := module.GameServer
+	defaultPort := a2sDefaultPort
+	if c.Protocol == "quake3" {
+		defaultPort = quake3DefaultPort
+	}
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, defaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	dialProtocol := "udp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "udp6"
+	}
+	dialer := &net.Dialer{}
+	if len(c.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(c.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", c.SourceIPAddress)
+			return false
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.UDPAddr{IP: srcIP}
+	}
+	conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), port))
+	if err != nil {
+		logger.Error("Error dialing UDP", "err", err)
+		return false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			logger.Error("Error setting deadline", "err", err)
+			return false
+		}
+	}
+
+	logger.Info("Querying game server", "protocol", c.Protocol)
+	var (
+		info *gameServerInfo
+		rtt  time.Duration
+	)
+	switch c.Protocol {
+	case "quake3":
+		info, rtt, err = queryQuake3Status(conn)
+	default:
+		info, rtt, err = queryA2SInfo(conn)
+	}
+	if err != nil {
+		logger.Error("Error querying game server", "err", err)
+		return false
+	}
+	logger.Info("Got game server state", "name", info.name, "map", info.mapName, "players", info.players, "max_players", info.maxPlayers)
+
+	registry.MustRegister(latencyGauge, playersGauge, maxPlayersGauge, mapHashGauge)
+	latencyGauge.Set(rtt.Seconds())
+	playersGauge.Set(float64(info.players))
+	maxPlayersGauge.Set(float64(info.maxPlayers))
+	mapHashGauge.Set(stringHash(info.mapName))
+	if info.bots >= 0 {
+		registry.MustRegister(botsGauge)
+		botsGauge.Set(float64(info.bots))
+	}
+	return true
+}
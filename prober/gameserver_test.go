@@ -0,0 +1,131 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startGameServer answers the queries it receives with respond.
+func startGameServer(t *testing.T, respond func(query []byte) []byte) string {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, gameServerMaxPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := respond(buf[:n]); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// a2sTestServer requires the challenge of the A2S_INFO query, as servers
+// updated since 2020 do.
+func a2sTestServer(query []byte) []byte {
+	challenge := []byte{1, 2, 3, 4}
+	if !bytes.Equal(query, append(append([]byte(nil), a2sInfoRequest...), challenge...)) {
+		return append([]byte("\xff\xff\xff\xffA"), challenge...)
+	}
+	resp := []byte("\xff\xff\xff\xffI\x11Test Server\x00de_dust2\x00csgo\x00Counter-Strike\x00")
+	// The application ID, players, max players, bots, server type,
+	// environment, visibility and VAC.
+	return append(resp, 0xda, 0x02, 12, 24, 2, 'd', 'l', 0, 1)
+}
+
+func quake3TestServer(query []byte) []byte {
+	if !bytes.Equal(query, quake3StatusQuery) {
+		return nil
+	}
+	return []byte("\xff\xff\xff\xffstatusResponse\n" +
+		"\\sv_hostname\\Test Arena\\mapname\\q3dm17\\sv_maxclients\\16\\g_gametype\\0\n" +
+		"12 48 \"Sarge\"\n" +
+		"7 0 \"Visor\"\n")
+}
+
+func TestGameServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		respond  func([]byte) []byte
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "a2s",
+			protocol: "a2s",
+			respond:  a2sTestServer,
+			success:  true,
+			expected: map[string]float64{
+				"probe_gameserver_players":     12,
+				"probe_gameserver_max_players": 24,
+				"probe_gameserver_bots":        2,
+				"probe_gameserver_map_hash":    stringHash("de_dust2"),
+			},
+		},
+		{
+			name:     "quake3",
+			protocol: "quake3",
+			respond:  quake3TestServer,
+			success:  true,
+			expected: map[string]float64{
+				"probe_gameserver_players":     2,
+				"probe_gameserver_max_players": 16,
+				"probe_gameserver_map_hash":    stringHash("q3dm17"),
+			},
+		},
+		{
+			name:     "no answer",
+			protocol: "quake3",
+			respond:  a2sTestServer,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startGameServer(t, test.respond)
+			testCTX, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			module := config.Module{GameServer: config.GameServerProbe{IPProtocol: "ip4", Protocol: test.protocol}}
+			if success := ProbeGameServer(testCTX, addr, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if !test.success {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
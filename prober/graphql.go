@@ -0,0 +1,123 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// graphQLRequest is the body of a GraphQL request over HTTP, see
+// https://graphql.github.io/graphql-over-http/draft/.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the body of a GraphQL response.
+type graphQLResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Extensions struct {
+		// Apollo tracing, also implemented by other servers, reports the
+		// execution time in nanoseconds.
+		Tracing *struct {
+			Duration float64 `json:"duration"`
+		} `json:"tracing"`
+	} `json:"extensions"`
+}
+
+func graphQLRequestBody(q config.GraphQLQuery) ([]byte, error) {
+	return json.Marshal(graphQLRequest{
+		Query:         q.Query,
+		OperationName: q.OperationName,
+		Variables:     q.Variables,
+	})
+}
+
+// formatJSONValue returns the text the regexp of an assertion is matched
+// against: strings as is, and other values in their JSON encoding.
+func formatJSONValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// validateGraphQLResponse checks that the response has no errors and that the
+// assertions hold on the selected values.
+func validateGraphQLResponse(body []byte, q config.GraphQLQuery, registry *prometheus.Registry, logger *slog.Logger) bool {
+	errorsGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_graphql_errors",
+		Help: "Number of errors in the GraphQL response",
+	})
+	registry.MustRegister(errorsGauge)
+
+	var resp graphQLResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		logger.Error("Error decoding GraphQL response", "err", err)
+		return false
+	}
+
+	if resp.Extensions.Tracing != nil {
+		executionGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_graphql_execution_duration_seconds",
+			Help: "Query execution time reported by the server in the tracing extension",
+		})
+		registry.MustRegister(executionGauge)
+		executionGauge.Set(resp.Extensions.Tracing.Duration / 1e9)
+	}
+
+	errorsGauge.Set(float64(len(resp.Errors)))
+	if len(resp.Errors) > 0 {
+		logger.Error("GraphQL response contains errors", "errors", len(resp.Errors), "first_error", resp.Errors[0].Message)
+		return false
+	}
+
+	// Assertions are evaluated against the whole response, e.g. "$.data.user.id".
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		logger.Error("Error decoding GraphQL response", "err", err)
+		return false
+	}
+	for _, assertion := range q.Assertions {
+		values := assertion.Path.Select(document)
+		if len(values) == 0 {
+			logger.Error("GraphQL response has no value at path", "path", assertion.Path.String())
+			return false
+		}
+		for _, value := range values {
+			if value == nil {
+				logger.Error("GraphQL response has a null value at path", "path", assertion.Path.String())
+				return false
+			}
+			if assertion.Regexp.Regexp != nil && !assertion.Regexp.MatchString(formatJSONValue(value)) {
+				logger.Error("GraphQL response value did not match regular expression", "path", assertion.Path.String(), "regexp", assertion.Regexp.String(), "value", formatJSONValue(value))
+				return false
+			}
+		}
+	}
+	return true
+}
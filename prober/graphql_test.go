@@ -0,0 +1,118 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestGraphQL(t *testing.T) {
+	tests := map[string]struct {
+		response        string
+		assertions      []config.GraphQLAssertion
+		expectedResult  bool
+		expectedMetrics map[string]float64
+	}{
+		"data": {
+			response: `{"data":{"user":{"id":"42","roles":["admin","dev"]}}}`,
+			assertions: []config.GraphQLAssertion{
+				{Path: config.MustNewJSONPath("$.data.user.id"), Regexp: config.MustNewRegexp("^42$")},
+				{Path: config.MustNewJSONPath("$.data.user.roles[*]")},
+			},
+			expectedResult:  true,
+			expectedMetrics: map[string]float64{"probe_graphql_errors": 0},
+		},
+		"errors": {
+			response:        `{"data":{"user":null},"errors":[{"message":"not authorized"}]}`,
+			expectedResult:  false,
+			expectedMetrics: map[string]float64{"probe_graphql_errors": 1},
+		},
+		"null value": {
+			response:       `{"data":{"user":null}}`,
+			assertions:     []config.GraphQLAssertion{{Path: config.MustNewJSONPath("$.data.user")}},
+			expectedResult: false,
+		},
+		"missing value": {
+			response:       `{"data":{"user":{"id":"42"}}}`,
+			assertions:     []config.GraphQLAssertion{{Path: config.MustNewJSONPath("$.data.user.name")}},
+			expectedResult: false,
+		},
+		"regexp mismatch": {
+			response:       `{"data":{"user":{"id":42}}}`,
+			assertions:     []config.GraphQLAssertion{{Path: config.MustNewJSONPath("$.data.user.id"), Regexp: config.MustNewRegexp("^43$")}},
+			expectedResult: false,
+		},
+		"tracing": {
+			response:       `{"data":{"user":{"id":"42"}},"extensions":{"tracing":{"version":1,"duration":1500000}}}`,
+			expectedResult: true,
+			expectedMetrics: map[string]float64{
+				"probe_graphql_errors":                     0,
+				"probe_graphql_execution_duration_seconds": 0.0015,
+			},
+		},
+		"not json": {
+			response:       `<html></html>`,
+			expectedResult: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req graphQLRequest
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query != "query User($id: ID!) { user(id: $id) { id } }" || req.Variables["id"] != "42" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(test.response))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				GraphQL: config.GraphQLQuery{
+					Query:      "query User($id: ID!) { user(id: $id) { id } }",
+					Variables:  map[string]interface{}{"id": "42"},
+					Assertions: test.assertions,
+				},
+			}}, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expectedMetrics, mfs, t)
+		})
+	}
+}
@@ -96,21 +96,6 @@ func ProbeGRPC(ctx context.Context, target string, module config.Module, registr
 			Name: "probe_grpc_healthcheck_response",
 			Help: "Response HealthCheck response",
 		}, []string{"serving_status"})
-
-		probeSSLEarliestCertExpiryGauge = prometheus.NewGauge(sslEarliestCertExpiryGaugeOpts)
-
-		probeTLSVersion = prometheus.NewGaugeVec(
-			probeTLSInfoGaugeOpts,
-			[]string{"version"},
-		)
-
-		probeSSLLastInformation = prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "probe_ssl_last_chain_info",
-				Help: "Contains SSL leaf certificate information",
-			},
-			[]string{"fingerprint_sha256", "subject", "issuer", "subjectalternative", "serialnumber"},
-		)
 	)
 
 	for _, lv := range []string{"resolve"} {
@@ -138,11 +123,13 @@ func ProbeGRPC(ctx context.Context, target string, module config.Module, registr
 		targetHost = targetURL.Host
 	}
 
+	exportClientCertExpiry(module.GRPC.TLSConfig, config.ExternalSigner{}, registry, logger)
 	tlsConfig, err := pconfig.NewTLSConfig(&module.GRPC.TLSConfig)
 	if err != nil {
 		logger.Error("Error creating TLS configuration", "err", err)
 		return false
 	}
+	tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
 
 	ip, lookupTime, err := chooseProtocol(ctx, module.GRPC.PreferredIPProtocol, module.GRPC.IPProtocolFallback, targetHost, registry, logger)
 	if err != nil {
@@ -163,31 +150,46 @@ func ProbeGRPC(ctx context.Context, target string, module config.Module, registr
 		targetURL.Host = net.JoinHostPort(ip.String(), targetPort)
 	}
 
-	var opts []grpc.DialOption
-	target = targetHost + ":" + targetPort
-	if !module.GRPC.TLS {
-		logger.Debug("Dialing GRPC without TLS")
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if len(targetPort) == 0 {
-			target = targetHost + ":80"
+	var client GRPCHealthCheck
+	switch module.GRPC.Protocol {
+	case "grpc-web", "connect":
+		scheme, port := "http", targetPort
+		if module.GRPC.TLS {
+			scheme = "https"
 		}
-	} else {
-		creds := credentials.NewTLS(tlsConfig)
-		opts = append(opts, grpc.WithTransportCredentials(creds))
-		if len(targetPort) == 0 {
-			target = targetHost + ":443"
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[scheme]
+		}
+		checkURL := scheme + "://" + net.JoinHostPort(ip.String(), port) + strings.TrimSuffix(targetURL.Path, "/") + grpcHealthCheckPath
+		logger.Debug("Checking health over HTTP", "protocol", module.GRPC.Protocol, "url", checkURL)
+		client = newHTTPHealthCheckClient(module.GRPC.Protocol, checkURL, targetHost, tlsConfig)
+	default:
+		var opts []grpc.DialOption
+		target = targetHost + ":" + targetPort
+		if !module.GRPC.TLS {
+			logger.Debug("Dialing GRPC without TLS")
+			opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if len(targetPort) == 0 {
+				target = targetHost + ":80"
+			}
+		} else {
+			creds := credentials.NewTLS(tlsConfig)
+			opts = append(opts, grpc.WithTransportCredentials(creds))
+			if len(targetPort) == 0 {
+				target = targetHost + ":443"
+			}
 		}
-	}
 
-	conn, err := grpc.NewClient(target, opts...)
+		conn, err := grpc.NewClient(target, opts...)
 
-	if err != nil {
-		logger.Error("did not connect", "err", err)
-	}
+		if err != nil {
+			logger.Error("did not connect", "err", err)
+		}
 
-	client := NewGrpcHealthCheckClient(conn)
-	defer conn.Close()
-	ok, statusCode, serverPeer, servingStatus, err := client.Check(context.Background(), module.GRPC.Service)
+		client = NewGrpcHealthCheckClient(conn)
+		defer conn.Close()
+	}
+	ok, statusCode, serverPeer, servingStatus, err := client.Check(ctx, module.GRPC.Service)
 	durationGaugeVec.WithLabelValues("check").Add(time.Since(checkStart).Seconds())
 
 	for servingStatusName, _ := range grpc_health_v1.HealthCheckResponse_ServingStatus_value {
@@ -200,11 +202,8 @@ func ProbeGRPC(ctx context.Context, target string, module config.Module, registr
 	if serverPeer != nil {
 		tlsInfo, tlsOk := serverPeer.AuthInfo.(credentials.TLSInfo)
 		if tlsOk {
-			registry.MustRegister(probeSSLEarliestCertExpiryGauge, probeTLSVersion, probeSSLLastInformation)
 			isSSLGauge.Set(float64(1))
-			probeSSLEarliestCertExpiryGauge.Set(float64(getEarliestCertExpiry(&tlsInfo.State).Unix()))
-			probeTLSVersion.WithLabelValues(getTLSVersion(&tlsInfo.State)).Set(1)
-			probeSSLLastInformation.WithLabelValues(getFingerprint(&tlsInfo.State), getSubject(&tlsInfo.State), getIssuer(&tlsInfo.State), getDNSNames(&tlsInfo.State), getSerialNumber(&tlsInfo.State)).Set(1)
+			exportTLSState(ctx, target, &tlsInfo.State, registry)
 		} else {
 			isSSLGauge.Set(float64(0))
 		}
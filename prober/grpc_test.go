@@ -17,9 +17,13 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/binary"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -32,6 +36,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestGRPCConnection(t *testing.T) {
@@ -463,3 +468,131 @@ func TestGRPCAbsentFailedTLS(t *testing.T) {
 
 	checkAbsentMetrics(absentMetrics, mfs, t)
 }
+
+// newGRPCHTTPHealthServer returns a handler answering health checks with the
+// gRPC-Web or Connect protocol, reporting the given status for "service".
+func newGRPCHTTPHealthServer(t *testing.T, protocol string, servingStatus grpc_health_v1.HealthCheckResponse_ServingStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/grpc.health.v1.Health/Check" {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Error reading request body: %s", err)
+			return
+		}
+		if protocol == "grpc-web" {
+			if r.Header.Get("Content-Type") != "application/grpc-web+proto" || len(body) < 5 {
+				t.Errorf("Unexpected gRPC-Web request: %q %q", r.Header.Get("Content-Type"), body)
+				return
+			}
+			body = body[5:]
+		}
+		var req grpc_health_v1.HealthCheckRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+			return
+		}
+
+		if req.Service != "service" {
+			if protocol == "grpc-web" {
+				w.Header().Set("Content-Type", "application/grpc-web+proto")
+				w.Header().Set("Grpc-Status", "5")
+				w.Header().Set("Grpc-Message", "unknown service")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not_found","message":"unknown service"}`))
+			return
+		}
+
+		msg, _ := proto.Marshal(&grpc_health_v1.HealthCheckResponse{Status: servingStatus})
+		if protocol == "grpc-web" {
+			w.Header().Set("Content-Type", "application/grpc-web+proto")
+			trailer := []byte("grpc-status: 0\r\ngrpc-message: \r\n")
+			frames := []byte{0, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(frames[1:], uint32(len(msg)))
+			frames = append(frames, msg...)
+			frames = append(frames, grpcWebTrailerFlag, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(frames[len(frames)-4:], uint32(len(trailer)))
+			frames = append(frames, trailer...)
+			w.Write(frames)
+			return
+		}
+		w.Header().Set("Content-Type", "application/proto")
+		w.Write(msg)
+	})
+}
+
+func TestGRPCWebAndConnect(t *testing.T) {
+	for _, protocol := range []string{"grpc-web", "connect"} {
+		for _, useTLS := range []bool{false, true} {
+			testcases := map[string]struct {
+				service            string
+				servingStatus      grpc_health_v1.HealthCheckResponse_ServingStatus
+				expectedResult     bool
+				expectedStatusCode float64
+			}{
+				"serving": {
+					service:        "service",
+					servingStatus:  grpc_health_v1.HealthCheckResponse_SERVING,
+					expectedResult: true,
+				},
+				"not serving": {
+					service:        "service",
+					servingStatus:  grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+					expectedResult: false,
+				},
+				"unknown service": {
+					service:            "NonExistingService",
+					expectedResult:     false,
+					expectedStatusCode: 5, // NOT_FOUND
+				},
+			}
+			for name, tc := range testcases {
+				t.Run(fmt.Sprintf("%s/tls=%t/%s", protocol, useTLS, name), func(t *testing.T) {
+					ts := httptest.NewUnstartedServer(newGRPCHTTPHealthServer(t, protocol, tc.servingStatus))
+					if useTLS {
+						ts.EnableHTTP2 = true
+						ts.StartTLS()
+					} else {
+						ts.Start()
+					}
+					defer ts.Close()
+
+					testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					defer cancel()
+					registry := prometheus.NewRegistry()
+
+					result := ProbeGRPC(testCTX, ts.Listener.Addr().String()+"/api",
+						config.Module{Timeout: time.Second, GRPC: config.GRPCProbe{
+							Service:             tc.service,
+							Protocol:            protocol,
+							TLS:                 useTLS,
+							TLSConfig:           pconfig.TLSConfig{InsecureSkipVerify: true},
+							PreferredIPProtocol: "ip4",
+							IPProtocolFallback:  true,
+						},
+						}, registry, promslog.NewNopLogger())
+					if result != tc.expectedResult {
+						t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+					}
+
+					mfs, err := registry.Gather()
+					if err != nil {
+						t.Fatal(err)
+					}
+					expectedResults := map[string]float64{
+						"probe_grpc_status_code": tc.expectedStatusCode,
+					}
+					if useTLS {
+						expectedResults["probe_grpc_ssl"] = 1
+					}
+					checkRegistryResults(expectedResults, mfs, t)
+				})
+			}
+		}
+	}
+}
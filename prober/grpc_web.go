@@ -0,0 +1,266 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// grpcWebTrailerFlag marks a gRPC-Web frame carrying the trailers.
+	grpcWebTrailerFlag = 0x80
+
+	// maxHealthCheckResponseSize limits how much of a gRPC-Web or Connect
+	// response is read, a health check response is only a few bytes.
+	maxHealthCheckResponseSize = 64 * 1024
+)
+
+// connectCodes maps the Connect error codes to their gRPC equivalents, see
+// https://connectrpc.com/docs/protocol/#error-codes.
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+// connectCodeFromHTTPStatus returns the gRPC code implied by the HTTP status
+// of a Connect error response without a code in its body.
+func connectCodeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// httpHealthCheckClient performs the gRPC health check using the gRPC-Web or
+// the Connect protocol, which are served over plain HTTP/1.1 or HTTP/2.
+type httpHealthCheckClient struct {
+	client   *http.Client
+	protocol string
+	url      string
+	host     string
+}
+
+func newHTTPHealthCheckClient(protocol, url, host string, tlsConfig *tls.Config) *httpHealthCheckClient {
+	return &httpHealthCheckClient{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   tlsConfig,
+				ForceAttemptHTTP2: true,
+				DisableKeepAlives: true,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		protocol: protocol,
+		url:      url,
+		host:     host,
+	}
+}
+
+func (c *httpHealthCheckClient) Check(ctx context.Context, service string) (bool, codes.Code, *peer.Peer, string, error) {
+	msg, err := proto.Marshal(&grpc_health_v1.HealthCheckRequest{Service: service})
+	if err != nil {
+		return false, codes.Internal, nil, "", err
+	}
+
+	var body []byte
+	if c.protocol == "grpc-web" {
+		body = make([]byte, 5, 5+len(msg))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+		body = append(body, msg...)
+	} else {
+		body = msg
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, codes.Internal, nil, "", err
+	}
+	req.Host = c.host
+	if c.protocol == "grpc-web" {
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("X-Grpc-Web", "1")
+	} else {
+		req.Header.Set("Content-Type", "application/proto")
+		req.Header.Set("Connect-Protocol-Version", "1")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, codes.Unavailable, nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var serverPeer *peer.Peer
+	if resp.TLS != nil {
+		serverPeer = &peer.Peer{AuthInfo: credentials.TLSInfo{State: *resp.TLS}}
+	}
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckResponseSize))
+	if err != nil {
+		return false, codes.Unavailable, serverPeer, "", err
+	}
+
+	var payload []byte
+	if c.protocol == "grpc-web" {
+		payload, err = parseGRPCWebResponse(resp, respBody)
+	} else {
+		payload, err = parseConnectResponse(resp, respBody)
+	}
+	if err != nil {
+		code := codes.Unknown
+		var statusErr *grpcStatusError
+		if errors.As(err, &statusErr) {
+			code = statusErr.code
+		}
+		return false, code, serverPeer, "", err
+	}
+
+	var res grpc_health_v1.HealthCheckResponse
+	if err := proto.Unmarshal(payload, &res); err != nil {
+		return false, codes.Internal, serverPeer, "", fmt.Errorf("error decoding health check response: %w", err)
+	}
+	if res.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING {
+		return true, codes.OK, serverPeer, res.Status.String(), nil
+	}
+	return false, codes.OK, serverPeer, res.Status.String(), nil
+}
+
+// grpcStatusError is a non-OK status returned by the server.
+type grpcStatusError struct {
+	code    codes.Code
+	message string
+}
+
+func (e *grpcStatusError) Error() string {
+	return fmt.Sprintf("rpc error: code = %s desc = %s", e.code, e.message)
+}
+
+// parseGRPCWebResponse returns the message of a gRPC-Web response, or the
+// status carried by its trailers if it is not OK.
+func parseGRPCWebResponse(resp *http.Response, body []byte) ([]byte, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, &grpcStatusError{code: connectCodeFromHTTPStatus(resp.StatusCode), message: "unexpected HTTP status " + resp.Status}
+	}
+
+	var (
+		message  []byte
+		trailers = http.Header{}
+	)
+	// Trailers-only responses carry the status in the headers.
+	for _, name := range []string{"Grpc-Status", "Grpc-Message"} {
+		if value := resp.Header.Get(name); value != "" {
+			trailers.Set(name, value)
+		}
+	}
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return nil, errors.New("truncated gRPC-Web frame header")
+		}
+		flag, length := body[0], binary.BigEndian.Uint32(body[1:5])
+		if uint32(len(body)-5) < length {
+			return nil, errors.New("truncated gRPC-Web frame")
+		}
+		frame := body[5 : 5+length]
+		body = body[5+length:]
+		if flag&grpcWebTrailerFlag == 0 {
+			message = frame
+			continue
+		}
+		for _, line := range strings.Split(string(frame), "\r\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				trailers.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+			}
+		}
+	}
+
+	grpcStatus := trailers.Get("Grpc-Status")
+	if grpcStatus == "" {
+		return nil, errors.New("gRPC-Web response without grpc-status")
+	}
+	code, err := strconv.Atoi(grpcStatus)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grpc-status %q", grpcStatus)
+	}
+	if codes.Code(code) != codes.OK {
+		return nil, &grpcStatusError{code: codes.Code(code), message: trailers.Get("Grpc-Message")}
+	}
+	if message == nil {
+		return nil, errors.New("gRPC-Web response without message")
+	}
+	return message, nil
+}
+
+// parseConnectResponse returns the message of a unary Connect response, or
+// the error described by its JSON body.
+func parseConnectResponse(resp *http.Response, body []byte) ([]byte, error) {
+	if resp.StatusCode == http.StatusOK {
+		return body, nil
+	}
+
+	var connectErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	code := connectCodeFromHTTPStatus(resp.StatusCode)
+	if err := json.Unmarshal(body, &connectErr); err == nil {
+		if c, ok := connectCodes[connectErr.Code]; ok {
+			code = c
+		}
+	}
+	return nil, &grpcStatusError{code: code, message: connectErr.Message}
+}
@@ -16,12 +16,15 @@ package prober
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"runtime/pprof"
 	"strconv"
 	"time"
 
@@ -33,16 +36,6 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-var (
-	Probers = map[string]ProbeFn{
-		"http": ProbeHTTP,
-		"tcp":  ProbeTCP,
-		"icmp": ProbeICMP,
-		"dns":  ProbeDNS,
-		"grpc": ProbeGRPC,
-	}
-)
-
 func Handler(w http.ResponseWriter, r *http.Request, c *config.Config, logger *slog.Logger, rh *ResultHistory, timeoutOffset float64, params url.Values,
 	moduleUnknownCounter prometheus.Counter,
 	logLevelProber *promslog.AllowedLevel) {
@@ -54,8 +47,7 @@ func Handler(w http.ResponseWriter, r *http.Request, c *config.Config, logger *s
 	if moduleName == "" {
 		moduleName = "http_2xx"
 	}
-	module, ok := c.Modules[moduleName]
-	if !ok {
+	if _, ok := c.Modules[moduleName]; !ok {
 		http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
 		logger.Debug("Unknown module", "module", moduleName)
 		if moduleUnknownCounter != nil {
@@ -63,6 +55,17 @@ func Handler(w http.ResponseWriter, r *http.Request, c *config.Config, logger *s
 		}
 		return
 	}
+	modulesUsage.record(moduleName, time.Now())
+	module, moduleVersion, err := c.SelectModule(moduleName, params.Get("module_version"), params.Get("target"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	overrides, err := applyURLOverrides(&module, params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	timeoutSeconds, err := getTimeout(r, module, timeoutOffset)
 	if err != nil {
@@ -70,43 +73,31 @@ func Handler(w http.ResponseWriter, r *http.Request, c *config.Config, logger *s
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeoutSeconds*float64(time.Second)))
+	ctx, cancel := context.WithTimeout(withModuleName(r.Context(), moduleName), time.Duration(timeoutSeconds*float64(time.Second)))
 	defer cancel()
+	var artifact *probeArtifact
+	if artifactStore != nil {
+		artifact = &probeArtifact{}
+		ctx = withProbeArtifact(ctx, artifact)
+	}
 	r = r.WithContext(ctx)
 
-	probeSuccessGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_success",
-		Help: "Displays whether or not the probe was a success",
-	})
-	probeDurationGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "probe_duration_seconds",
-		Help: "Returns how long the probe took to complete in seconds",
-	})
-
 	target := params.Get("target")
 	if target == "" {
 		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
 		return
 	}
 
-	prober, ok := Probers[module.Prober]
-	if !ok {
-		http.Error(w, fmt.Sprintf("Unknown prober %q", module.Prober), http.StatusBadRequest)
-		return
-	}
-
 	hostname := params.Get("hostname")
-	if module.Prober == "http" && hostname != "" {
-		err = setHTTPHost(hostname, &module)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+	prober, err := prepareProbe(&module, moduleName, hostname)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if module.Prober == "tcp" && hostname != "" {
-		if module.TCP.TLSConfig.ServerName == "" {
-			module.TCP.TLSConfig.ServerName = hostname
+	if module.Prober == "proxy" {
+		if region := params.Get("region"); region != "" {
+			module.Proxy.Region = region
 		}
 	}
 
@@ -122,29 +113,156 @@ func Handler(w http.ResponseWriter, r *http.Request, c *config.Config, logger *s
 	slLogger.Info("Beginning probe", "probe", module.Prober, "timeout_seconds", timeoutSeconds)
 
 	start := time.Now()
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(probeSuccessGauge)
-	registry.MustRegister(probeDurationGauge)
-	success := prober(ctx, target, module, registry, slLogger)
-	duration := time.Since(start).Seconds()
-	probeDurationGauge.Set(duration)
-	if success {
-		probeSuccessGauge.Set(1)
-		slLogger.Info("Probe succeeded", "duration_seconds", duration)
+	// Metrics of this request only, they are not shared with deduplicated
+	// probe requests.
+	requestRegistry := prometheus.NewRegistry()
+	if params.Get("measure_scrape_interval") == "true" {
+		measureScrapeInterval(moduleName, target, start, requestRegistry)
+	}
+	if _, ok := c.PreviousModules[moduleName]; ok || params.Get("module_version") != "" {
+		versionGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_module_version_info",
+			Help: "The version of the module the target was probed with, current or previous",
+		}, []string{"version"})
+		requestRegistry.MustRegister(versionGauge)
+		versionGauge.WithLabelValues(moduleVersion).Set(1)
+	}
+
+	// Probes over the max_concurrent of the module wait for a running probe
+	// to finish until the timeout, or are rejected right away with the reject
+	// concurrency_policy.
+	runProbe := func(ctx context.Context) probeOutcome {
+		release, ok := probeConcurrency.acquire(ctx, moduleName, module.MaxConcurrent, module.ConcurrencyPolicy != "reject")
+		if !ok {
+			return probeOutcome{concurrencyLimited: true}
+		}
+		defer release()
+		var result *ProbeResult
+		// The labels attribute the CPU used by the probe to its module in
+		// profiles, including those collected by continuous profilers.
+		pprof.Do(ctx, pprof.Labels("module", moduleName, "prober", module.Prober), func(ctx context.Context) {
+			result = runProbeInActiveHours(ctx, prober, target, module, slLogger, time.Now())
+		})
+		if result.Success {
+			slLogger.Info("Probe succeeded", "duration_seconds", result.Duration.Seconds())
+		} else {
+			slLogger.Error("Probe failed", "duration_seconds", result.Duration.Seconds())
+		}
+		return probeOutcome{result: result}
+	}
+
+	// Probes are rejected when the target was probed less than min_interval
+	// ago. Requests sharing the result of an identical probe are not.
+	rateLimited := func() (probeOutcome, bool) {
+		if module.MinInterval <= 0 {
+			return probeOutcome{}, false
+		}
+		wait, ok := probeMinInterval.allow(moduleName+"\x00"+target, module.MinInterval, time.Now())
+		return probeOutcome{rateLimited: true, retryAfter: wait}, !ok
+	}
+
+	var (
+		outcome probeOutcome
+		shared  bool
+		tlsKeys string
+	)
+	if module.DeduplicationWindow > 0 && r.URL.Query().Get("debug") != "true" {
+		key := moduleName + "\x00" + moduleVersion + "\x00" + target + "\x00" + hostname + "\x00" + params.Get("region") + "\x00" + overrides
+		var ok bool
+		outcome, shared, ok = probeDedup.do(ctx, key, module.DeduplicationWindow, func() probeOutcome {
+			if limited, ok := rateLimited(); ok {
+				return limited
+			}
+			// Other requests may wait for this probe, so it must not be
+			// canceled when this request goes away.
+			probeCtx, probeCancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(timeoutSeconds*float64(time.Second)))
+			defer probeCancel()
+			return runProbe(probeCtx)
+		})
+		if !ok {
+			slLogger.Error("Timed out waiting for the result of an identical probe")
+			http.Error(w, "Timed out waiting for the result of an identical probe", http.StatusGatewayTimeout)
+			return
+		}
+	} else if limited, ok := rateLimited(); ok {
+		outcome = limited
 	} else {
-		slLogger.Error("Probe failed", "duration_seconds", duration)
+		probeCtx := ctx
+		var keyLog *tlsKeyLog
+		if tlsKeyLogEnabled && r.URL.Query().Get("debug") == "true" && params.Get("tls_key_log") == "true" {
+			keyLog, err = newTLSKeyLog()
+			if err != nil {
+				slLogger.Error("Error opening the TLS key log file", "err", err)
+				http.Error(w, fmt.Sprintf("Error opening the TLS key log file: %s", err), http.StatusInternalServerError)
+				return
+			}
+			defer keyLog.Close()
+			probeCtx = withTLSKeyLog(ctx, keyLog)
+			slLogger.Warn("Capturing the TLS session keys of the probe")
+		}
+		outcome = runProbe(probeCtx)
+		if keyLog != nil {
+			tlsKeys = keyLog.String()
+		}
+	}
+
+	if outcome.concurrencyLimited {
+		slLogger.Warn("Rejected probe, max_concurrent probes of the module are running", "max_concurrent", module.MaxConcurrent)
+		http.Error(w, fmt.Sprintf("Module is running max_concurrent (%d) probes", module.MaxConcurrent), http.StatusServiceUnavailable)
+		return
+	}
+	if outcome.rateLimited {
+		probesRateLimitedCounter.WithLabelValues(moduleName).Inc()
+		slLogger.Warn("Rejected probe, the target was probed less than min_interval ago", "min_interval", module.MinInterval)
+		if outcome.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(outcome.retryAfter.Seconds()))))
+		}
+		http.Error(w, fmt.Sprintf("Target was probed less than min_interval (%s) ago", module.MinInterval), http.StatusTooManyRequests)
+		return
 	}
+	result := outcome.result
 
-	debugOutput := DebugOutput(&module, &sl.buffer, registry)
-	rh.Add(moduleName, target, debugOutput, success)
+	// The metrics of the probe are generated from its result.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newResultCollector(result))
+	gatherers := prometheus.Gatherers{registry, requestRegistry}
+	if shared {
+		probesDeduplicatedCounter.WithLabelValues(moduleName).Inc()
+		slLogger.Info("Reused result of an identical probe", "success", result.Success)
+	} else {
+		if !result.Success && artifact != nil && artifact.recorded() {
+			if id, err := artifactStore.save(moduleName, target, artifact, time.Now()); err != nil {
+				slLogger.Error("Error saving the response of the failed probe", "err", err)
+			} else {
+				slLogger.Info("Saved the response of the failed probe", "artifact", id)
+			}
+		}
+		debugOutput := DebugOutput(&module, &sl.buffer, gatherers)
+		rh.Add(moduleName, target, debugOutput, result.Success)
+		if c.GrafanaAnnotations != nil {
+			annotateStateChange(c.GrafanaAnnotations, moduleName, target, result, time.Now(), logger)
+		}
 
-	if r.URL.Query().Get("debug") == "true" {
-		w.Header().Set("Content-Type", "text/plain")
-		w.Write([]byte(debugOutput))
+		if r.URL.Query().Get("debug") == "true" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(debugOutput))
+			// The keys are not kept in the history.
+			if tlsKeys != "" {
+				fmt.Fprintf(w, "\n\n\nTLS key log:\n%s", tlsKeys)
+			}
+			return
+		}
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(newResultDocument(moduleName, result)); err != nil {
+			slLogger.Error("Error encoding the result of the probe", "err", err)
+		}
 		return
 	}
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 }
 
@@ -247,7 +365,7 @@ func getSlogLevel(level string) slog.Level {
 }
 
 // DebugOutput returns plaintext debug output for a probe.
-func DebugOutput(module *config.Module, logBuffer *bytes.Buffer, registry *prometheus.Registry) string {
+func DebugOutput(module *config.Module, logBuffer *bytes.Buffer, registry prometheus.Gatherer) string {
 	buf := &bytes.Buffer{}
 	fmt.Fprintf(buf, "Logs for the probe:\n")
 	logBuffer.WriteTo(buf)
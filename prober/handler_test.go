@@ -257,3 +257,51 @@ func TestTCPHostnameParam(t *testing.T) {
 	}
 
 }
+
+func TestModuleVersionParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	// The new version of the module expects a 200, the previous one any 2xx.
+	c := &config.Config{
+		Modules: map[string]config.Module{
+			"http_2xx": {Prober: "http", Timeout: 10 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, ValidStatusCodes: []int{200}}},
+		},
+		PreviousModules: map[string]config.Module{
+			"http_2xx": {Prober: "http", Timeout: 10 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+		},
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Handler(w, r, c, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+	})
+
+	for version, expected := range map[string]string{
+		"current":  "probe_success 0",
+		"previous": "probe_success 1",
+	} {
+		req, err := http.NewRequest("GET", "?module=http_2xx&module_version="+version+"&target="+ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("probe request handler returned wrong status code: %v, want %v", status, http.StatusOK)
+		}
+		if !strings.Contains(rr.Body.String(), expected) || !strings.Contains(rr.Body.String(), `probe_module_version_info{version="`+version+`"} 1`) {
+			t.Errorf("Expected %q with version %s, got %s", expected, version, rr.Body.String())
+		}
+	}
+
+	req, err := http.NewRequest("GET", "?module=http_2xx&module_version=next&target="+ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("probe request handler returned wrong status code: %v, want %v", status, http.StatusBadRequest)
+	}
+}
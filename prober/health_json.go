@@ -0,0 +1,136 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// healthStatuses are the normalized statuses of a health check response.
+var healthStatuses = []string{"pass", "warn", "fail"}
+
+// normalizeHealthStatus maps the status of a health check response to pass,
+// warn or fail. The draft allows "ok" and "up" for pass and "error" and
+// "down" for fail, for compatibility with existing health endpoints.
+func normalizeHealthStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "pass", "ok", "up":
+		return "pass"
+	case "warn":
+		return "warn"
+	case "fail", "error", "down":
+		return "fail"
+	default:
+		return ""
+	}
+}
+
+// healthJSON is an application/health+json response.
+type healthJSON struct {
+	Status string `json:"status"`
+	// Checks maps "component:measurement" names to the results of the
+	// check for each instance of the component.
+	Checks map[string][]struct {
+		ComponentID   string      `json:"componentId"`
+		Status        string      `json:"status"`
+		ObservedValue interface{} `json:"observedValue"`
+	} `json:"checks"`
+}
+
+func parseHealthJSON(body []byte) (healthJSON, error) {
+	var health healthJSON
+	if err := json.Unmarshal(body, &health); err != nil {
+		return health, err
+	}
+	if normalizeHealthStatus(health.Status) == "" {
+		return health, errors.New("missing or invalid status")
+	}
+	return health, nil
+}
+
+// validateHealthJSON exports the overall status and the status of each check
+// of a health check response. The probe fails if the status is fail, or warn
+// if so configured.
+func validateHealthJSON(body []byte, v config.HealthJSONValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		statusGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_health_status",
+			Help: "Overall status of the health check response",
+		}, []string{"status"})
+		checkStatusGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_health_check_status",
+			Help: "Status of the checks of the health check response",
+		}, []string{"check", "component_id", "status"})
+		checkValueGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_health_check_observed_value",
+			Help: "Numeric value observed by the checks of the health check response",
+		}, []string{"check", "component_id"})
+	)
+
+	health, err := parseHealthJSON(body)
+	if err != nil {
+		logger.Error("Error parsing health check response", "err", err)
+		return false
+	}
+	registry.MustRegister(statusGaugeVec, checkStatusGaugeVec, checkValueGaugeVec)
+
+	status := normalizeHealthStatus(health.Status)
+	for _, s := range healthStatuses {
+		if s == status {
+			statusGaugeVec.WithLabelValues(s).Set(1)
+		} else {
+			statusGaugeVec.WithLabelValues(s).Set(0)
+		}
+	}
+
+	for name, results := range health.Checks {
+		for _, result := range results {
+			checkStatus := normalizeHealthStatus(result.Status)
+			if checkStatus == "" {
+				logger.Info("Health check has an invalid status", "check", name, "component_id", result.ComponentID, "status", result.Status)
+				continue
+			}
+			for _, s := range healthStatuses {
+				if s == checkStatus {
+					checkStatusGaugeVec.WithLabelValues(name, result.ComponentID, s).Set(1)
+				} else {
+					checkStatusGaugeVec.WithLabelValues(name, result.ComponentID, s).Set(0)
+				}
+			}
+			if value, ok := result.ObservedValue.(float64); ok {
+				checkValueGaugeVec.WithLabelValues(name, result.ComponentID).Set(value)
+			}
+			if checkStatus != "pass" {
+				logger.Info("Health check did not pass", "check", name, "component_id", result.ComponentID, "status", checkStatus)
+			}
+		}
+	}
+
+	switch {
+	case status == "fail":
+		logger.Error("Health check response status is fail", "status", health.Status)
+		return false
+	case status == "warn" && v.FailIfWarn:
+		logger.Error("Health check response status is warn")
+		return false
+	}
+	return true
+}
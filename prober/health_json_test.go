@@ -0,0 +1,123 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestHealthJSON(t *testing.T) {
+	tests := map[string]struct {
+		statusCode     int
+		response       string
+		failIfWarn     bool
+		expectedResult bool
+		expectedStatus string
+	}{
+		"pass": {
+			statusCode:     http.StatusOK,
+			response:       `{"status":"pass","checks":{"postgres:responseTime":[{"componentId":"db-1","status":"pass","observedValue":12.5}]}}`,
+			expectedResult: true,
+			expectedStatus: "pass",
+		},
+		"warn": {
+			statusCode:     http.StatusOK,
+			response:       `{"status":"warn","checks":{"postgres:responseTime":[{"componentId":"db-1","status":"warn","observedValue":12.5}]}}`,
+			expectedResult: true,
+			expectedStatus: "warn",
+		},
+		"warn fails": {
+			statusCode:     http.StatusOK,
+			response:       `{"status":"warn","checks":{"postgres:responseTime":[{"componentId":"db-1","status":"warn","observedValue":12.5}]}}`,
+			failIfWarn:     true,
+			expectedResult: false,
+			expectedStatus: "warn",
+		},
+		"fail": {
+			statusCode:     http.StatusServiceUnavailable,
+			response:       `{"status":"fail","checks":{"postgres:responseTime":[{"componentId":"db-1","status":"fail","observedValue":12.5}]}}`,
+			expectedResult: false,
+			expectedStatus: "fail",
+		},
+		"invalid": {
+			statusCode:     http.StatusOK,
+			response:       `{"status":"unknown"}`,
+			expectedResult: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/health+json")
+				w.WriteHeader(test.statusCode)
+				w.Write([]byte(test.response))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				ValidateHealthJSON: config.HealthJSONValidator{Enabled: true, FailIfWarn: test.failIfWarn},
+			}}, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedStatus == "" {
+				checkAbsentMetrics([]string{"probe_health_status"}, mfs, t)
+				return
+			}
+			found := 0
+			for _, mf := range mfs {
+				switch mf.GetName() {
+				case "probe_health_status", "probe_health_check_status":
+					found++
+					for _, m := range mf.Metric {
+						var status string
+						for _, lp := range m.Label {
+							if lp.GetName() == "status" {
+								status = lp.GetValue()
+							}
+						}
+						if expected := status == test.expectedStatus; (m.GetGauge().GetValue() == 1) != expected {
+							t.Errorf("Unexpected value %v for %s with status %s", m.GetGauge().GetValue(), mf.GetName(), status)
+						}
+					}
+				case "probe_health_check_observed_value":
+					if len(mf.Metric) != 1 || mf.Metric[0].GetGauge().GetValue() != 12.5 {
+						t.Errorf("Unexpected observed values %v", mf.Metric)
+					}
+				}
+			}
+			if found != 2 {
+				t.Errorf("Expected health status metrics, got %d", found)
+			}
+		})
+	}
+}
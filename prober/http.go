@@ -14,10 +14,12 @@
 package prober
 
 import (
+	"bytes"
 	"compress/flate"
 	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -43,74 +45,182 @@ import (
 	"github.com/prometheus/blackbox_exporter/config"
 )
 
-func matchRegularExpressions(reader io.Reader, httpConfig config.HTTPProbe, logger *slog.Logger) bool {
-	body, err := io.ReadAll(reader)
-	if err != nil {
-		logger.Error("Error reading HTTP body", "err", err)
-		return false
-	}
+// needsResponseBody returns true if the response body has to be kept in
+// memory so it can be validated.
+func needsResponseBody(httpConfig config.HTTPProbe) bool {
+	return len(httpConfig.FailIfBodyMatchesRegexp) > 0 ||
+		len(httpConfig.FailIfBodyNotMatchesRegexp) > 0 ||
+		len(httpConfig.FailIfBodyJSONNotMatches) > 0 ||
+		httpConfig.ValidateCrawlConfig.Format != "" ||
+		httpConfig.ValidateSAMLMetadata.Enabled ||
+		httpConfig.CrawlAssets.MaxResources > 0 ||
+		httpConfig.GraphQL.Query != "" ||
+		httpConfig.ValidateHealthJSON.Enabled ||
+		httpConfig.SuccessCriteria != nil ||
+		httpConfig.CaptivePortal.ExpectedBody != "" ||
+		!httpConfig.Maintenance.JSONPath.IsZero() ||
+		httpConfig.IdentifyNode.BodyRegexp.Regexp != nil ||
+		httpConfig.CompareBody.Enabled ||
+		!httpConfig.ValidateJWT.JSONPath.IsZero()
+}
+
+func matchRegularExpressions(body []byte, httpConfig config.HTTPProbe, results *validatorResults, logger *slog.Logger) bool {
+	success := true
 	for _, expression := range httpConfig.FailIfBodyMatchesRegexp {
-		if expression.Regexp.Match(body) {
+		matched := expression.Regexp.Match(body)
+		if matched {
 			logger.Error("Body matched regular expression", "regexp", expression)
-			return false
+			success = false
 		}
+		results.record(expression.Name(), !matched)
 	}
 	for _, expression := range httpConfig.FailIfBodyNotMatchesRegexp {
-		if !expression.Regexp.Match(body) {
+		matched := expression.Regexp.Match(body)
+		if !matched {
 			logger.Error("Body did not match regular expression", "regexp", expression)
-			return false
+			success = false
 		}
+		results.record(expression.Name(), matched)
 	}
-	return true
+	return success
 }
 
-func matchRegularExpressionsOnHeaders(header http.Header, httpConfig config.HTTPProbe, logger *slog.Logger) bool {
+func matchRegularExpressionsOnHeaders(header http.Header, httpConfig config.HTTPProbe, results *validatorResults, logger *slog.Logger) bool {
+	success := true
 	for _, headerMatchSpec := range httpConfig.FailIfHeaderMatchesRegexp {
-		values := header[textproto.CanonicalMIMEHeaderKey(headerMatchSpec.Header)]
-		if len(values) == 0 {
-			if !headerMatchSpec.AllowMissing {
-				logger.Error("Missing required header", "header", headerMatchSpec.Header)
-				return false
-			} else {
-				continue // No need to match any regex on missing headers.
-			}
-		}
+		valid := matchHeader(header, headerMatchSpec, false, logger)
+		results.record(headerMatchSpec.Name, valid)
+		success = success && valid
+	}
+	for _, headerMatchSpec := range httpConfig.FailIfHeaderNotMatchesRegexp {
+		valid := matchHeader(header, headerMatchSpec, true, logger)
+		results.record(headerMatchSpec.Name, valid)
+		success = success && valid
+	}
+	return success
+}
 
-		for _, val := range values {
-			if headerMatchSpec.Regexp.MatchString(val) {
-				logger.Error("Header matched regular expression", "header", headerMatchSpec.Header,
-					"regexp", headerMatchSpec.Regexp, "value_count", len(values))
-				return false
-			}
+// matchHeader checks the values of a header against a matcher, which
+// requires a value to match if mustMatch is set, and none otherwise.
+func matchHeader(header http.Header, headerMatchSpec config.HeaderMatch, mustMatch bool, logger *slog.Logger) bool {
+	values := header[textproto.CanonicalMIMEHeaderKey(headerMatchSpec.Header)]
+	if len(values) == 0 {
+		if !headerMatchSpec.AllowMissing {
+			logger.Error("Missing required header", "header", headerMatchSpec.Header)
+			return false
 		}
+		return true // No need to match any regex on missing headers.
 	}
-	for _, headerMatchSpec := range httpConfig.FailIfHeaderNotMatchesRegexp {
-		values := header[textproto.CanonicalMIMEHeaderKey(headerMatchSpec.Header)]
-		if len(values) == 0 {
-			if !headerMatchSpec.AllowMissing {
-				logger.Error("Missing required header", "header", headerMatchSpec.Header)
-				return false
-			} else {
-				continue // No need to match any regex on missing headers.
-			}
+
+	anyHeaderValueMatched := false
+
+	for _, val := range values {
+		if headerMatchSpec.Regexp.MatchString(val) {
+			anyHeaderValueMatched = true
+			break
 		}
+	}
+
+	if mustMatch && !anyHeaderValueMatched {
+		logger.Error("Header did not match regular expression", "header", headerMatchSpec.Header,
+			"regexp", headerMatchSpec.Regexp, "value_count", len(values))
+		return false
+	}
+	if !mustMatch && anyHeaderValueMatched {
+		logger.Error("Header matched regular expression", "header", headerMatchSpec.Header,
+			"regexp", headerMatchSpec.Regexp, "value_count", len(values))
+		return false
+	}
+	return true
+}
 
-		anyHeaderValueMatched := false
+// hstsPolicy is a parsed Strict-Transport-Security header, see RFC 6797.
+type hstsPolicy struct {
+	maxAge            int64
+	includeSubDomains bool
+	preload           bool
+}
 
-		for _, val := range values {
-			if headerMatchSpec.Regexp.MatchString(val) {
-				anyHeaderValueMatched = true
-				break
+func parseHSTS(value string) (hstsPolicy, error) {
+	var policy hstsPolicy
+	seenMaxAge := false
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(arg), `"`), 10, 64)
+			if err != nil || maxAge < 0 {
+				return policy, fmt.Errorf("invalid max-age directive %q", directive)
 			}
+			policy.maxAge = maxAge
+			seenMaxAge = true
+		case "includesubdomains":
+			policy.includeSubDomains = true
+		case "preload":
+			policy.preload = true
 		}
+	}
+	if !seenMaxAge {
+		return policy, errors.New("missing max-age directive")
+	}
+	return policy, nil
+}
 
-		if !anyHeaderValueMatched {
-			logger.Error("Header did not match regular expression", "header", headerMatchSpec.Header,
-				"regexp", headerMatchSpec.Regexp, "value_count", len(values))
+// httpMaxClockSkew bounds the clock skew derived from the Date header, larger
+// values more likely come from caches that do not set the Age header than from
+// the clock of the server.
+const httpMaxClockSkew = 24 * time.Hour
+
+// serverClockSkew returns how far the clock of the server is ahead of ours,
+// from the Date header of a response whose first byte was received at
+// received. The Date has a resolution of a second, so half a second is added
+// to it, as well as the Age of responses served from a cache.
+func serverClockSkew(header http.Header, received time.Time) (time.Duration, error) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0, err
+	}
+	date = date.Add(500 * time.Millisecond)
+	if age, err := strconv.ParseUint(header.Get("Age"), 10, 32); err == nil {
+		date = date.Add(time.Duration(age) * time.Second)
+	}
+	skew := date.Sub(received)
+	if skew > httpMaxClockSkew || skew < -httpMaxClockSkew {
+		return 0, fmt.Errorf("clock skew %s exceeds %s", skew, httpMaxClockSkew)
+	}
+	return skew, nil
+}
+
+func validateHSTS(header http.Header, v config.HSTSValidator, maxAgeGauge prometheus.Gauge, registry *prometheus.Registry, logger *slog.Logger) bool {
+	value := header.Get("Strict-Transport-Security")
+	if value == "" {
+		if v.Enabled() {
+			logger.Error("Missing Strict-Transport-Security header")
 			return false
 		}
+		return true
 	}
 
+	policy, err := parseHSTS(value)
+	if err != nil {
+		logger.Error("Error parsing Strict-Transport-Security header", "value", value, "err", err)
+		return !v.Enabled()
+	}
+	registry.MustRegister(maxAgeGauge)
+	maxAgeGauge.Set(float64(policy.maxAge))
+
+	if minAge := time.Duration(v.FailIfMaxAgeBelow); minAge > 0 && time.Duration(policy.maxAge)*time.Second < minAge {
+		logger.Error("HSTS max-age is below the required minimum", "max_age_seconds", policy.maxAge, "min_max_age", v.FailIfMaxAgeBelow)
+		return false
+	}
+	if v.FailIfNotIncludeSubdomains && !policy.includeSubDomains {
+		logger.Error("HSTS policy is missing the includeSubDomains directive")
+		return false
+	}
+	if v.FailIfNotPreload && !policy.preload {
+		logger.Error("HSTS policy is missing the preload directive")
+		return false
+	}
 	return true
 }
 
@@ -125,6 +235,21 @@ type roundTripTrace struct {
 	end           time.Time
 	tlsStart      time.Time
 	tlsDone       time.Time
+
+	// The codes of the informational responses received before the
+	// response, and when the first 103 Early Hints was.
+	informational  []int
+	firstEarlyHint time.Time
+
+	// The state of the timeouts and limits of the round trip.
+	cancel         context.CancelCauseFunc
+	limitErr       error
+	tlsTimer       *time.Timer
+	headerTimer    *time.Timer
+	readingHeaders bool
+	headersDone    bool
+	headerBytes    int64
+	continueGate   *continueGate
 }
 
 // transport is a custom transport keeping traces for each HTTP roundtrip.
@@ -133,17 +258,19 @@ type transport struct {
 	NoServerNameTransport http.RoundTripper
 	firstHost             string
 	logger                *slog.Logger
+	limits                roundTripLimits
 
 	mu      sync.Mutex
 	traces  []*roundTripTrace
 	current *roundTripTrace
 }
 
-func newTransport(rt, noServerName http.RoundTripper, logger *slog.Logger) *transport {
+func newTransport(rt, noServerName http.RoundTripper, limits roundTripLimits, logger *slog.Logger) *transport {
 	return &transport{
 		Transport:             rt,
 		NoServerNameTransport: noServerName,
 		logger:                logger,
+		limits:                limits,
 		traces:                []*roundTripTrace{},
 	}
 }
@@ -156,21 +283,34 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if req.URL.Scheme == "https" {
 		trace.tls = true
 	}
+	t.mu.Lock()
 	t.current = trace
 	t.traces = append(t.traces, trace)
+	t.mu.Unlock()
+	if t.limits.timeouts() {
+		ctx, cancel := context.WithCancelCause(req.Context())
+		trace.cancel = cancel
+		req = req.WithContext(ctx)
+	}
+	req = t.expectContinue(req, trace)
 
 	if t.firstHost == "" {
 		t.firstHost = req.URL.Host
 	}
 
+	rt := t.Transport
 	if t.firstHost != req.URL.Host {
 		// This is a redirect to something other than the initial host,
 		// so TLS ServerName should not be set.
 		t.logger.Info("Address does not match first address, not sending TLS ServerName", "first", t.firstHost, "address", req.URL.Host)
-		return t.NoServerNameTransport.RoundTrip(req)
+		rt = t.NoServerNameTransport
 	}
 
-	return t.Transport.RoundTrip(req)
+	resp, err := rt.RoundTrip(req)
+	if limitErr := t.gotHeaders(trace); err != nil && limitErr != nil {
+		return nil, limitErr
+	}
+	return resp, err
 }
 
 func (t *transport) DNSStart(_ httptrace.DNSStartInfo) {
@@ -208,15 +348,36 @@ func (t *transport) GotFirstResponseByte() {
 	defer t.mu.Unlock()
 	t.current.responseStart = time.Now()
 }
+func (t *transport) Got1xxResponse(code int, _ textproto.MIMEHeader) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current.informational = append(t.current.informational, code)
+	if code == http.StatusContinue && t.current.continueGate != nil {
+		t.current.continueGate.open(nil)
+	}
+	if code == http.StatusEarlyHints && t.current.firstEarlyHint.IsZero() {
+		t.current.firstEarlyHint = time.Now()
+	}
+	return nil
+}
 func (t *transport) TLSHandshakeStart() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.current.tlsStart = time.Now()
+	t.startTimer(&t.current.tlsTimer, t.limits.tlsHandshakeTimeout, "TLS handshake")
 }
 func (t *transport) TLSHandshakeDone(_ tls.ConnectionState, _ error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.current.tlsDone = time.Now()
+	stopTimer(t.current.tlsTimer)
+}
+func (t *transport) WroteRequest(_ httptrace.WroteRequestInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current.readingHeaders = true
+	t.current.headerBytes = 0
+	t.startTimer(&t.current.headerTimer, t.limits.responseHeaderTimeout, "Response headers")
 }
 
 // byteCounter implements an io.ReadCloser that keeps track of the total
@@ -234,8 +395,28 @@ func (bc *byteCounter) Read(p []byte) (int, error) {
 
 var userAgentDefaultHeader = fmt.Sprintf("Blackbox Exporter/%s", version.Version)
 
+// httpVersionProtos maps the values of http_version to the protocol of the
+// responses made with it.
+var httpVersionProtos = map[string]string{
+	"1.1": "HTTP/1.1",
+	"2":   "HTTP/2.0",
+	"3":   "HTTP/3.0",
+}
+
+// redirectHop is a response redirecting the probe, made over TLS.
+type redirectHop struct {
+	hop   int
+	host  string
+	state *tls.ConnectionState
+}
+
 func ProbeHTTP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) (success bool) {
+	if module.HTTP.Samples > 1 {
+		return probeHTTPSamples(ctx, target, module, registry, logger)
+	}
+
 	var redirects int
+	var redirectHops []redirectHop
 	var (
 		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "probe_http_duration_seconds",
@@ -264,26 +445,25 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			Help: "Response HTTP status code",
 		})
 
-		probeSSLEarliestCertExpiryGauge = prometheus.NewGauge(sslEarliestCertExpiryGaugeOpts)
-
-		probeSSLLastChainExpiryTimestampSeconds = prometheus.NewGauge(sslChainExpiryInTimeStampGaugeOpts)
+		probeTLSCipher = prometheus.NewGaugeVec(
+			probeTLSCipherGaugeOpts,
+			[]string{"cipher"},
+		)
 
-		probeSSLLastInformation = prometheus.NewGaugeVec(
+		probeRedirectSSLEarliestCertExpiry = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "probe_ssl_last_chain_info",
-				Help: "Contains SSL leaf certificate information",
+				Name: "probe_http_redirect_ssl_earliest_cert_expiry",
+				Help: "Returns earliest SSL cert expiry in unixtime of the redirects followed, by hop",
 			},
-			[]string{"fingerprint_sha256", "subject", "issuer", "subjectalternative", "serialnumber"},
-		)
-
-		probeTLSVersion = prometheus.NewGaugeVec(
-			probeTLSInfoGaugeOpts,
-			[]string{"version"},
+			[]string{"hop", "host"},
 		)
 
-		probeTLSCipher = prometheus.NewGaugeVec(
-			probeTLSCipherGaugeOpts,
-			[]string{"cipher"},
+		probeRedirectSSLInformation = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "probe_http_redirect_ssl_chain_info",
+				Help: "Contains SSL leaf certificate information of the redirects followed, by hop",
+			},
+			[]string{"hop", "host", "subject", "issuer"},
 		)
 
 		probeHTTPVersionGauge = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -300,6 +480,16 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			Name: "probe_http_last_modified_timestamp_seconds",
 			Help: "Returns the Last-Modified HTTP response header in unixtime",
 		})
+
+		probeHTTPServerClockSkew = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_server_clock_skew_seconds",
+			Help: "Returns how far the clock of the server, according to the Date HTTP response header, is ahead of the clock of the exporter",
+		})
+
+		probeHTTPHSTSMaxAge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_hsts_max_age_seconds",
+			Help: "Returns the max-age of the Strict-Transport-Security HTTP response header",
+		})
 	)
 
 	registry.MustRegister(durationGaugeVec)
@@ -312,6 +502,7 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 	registry.MustRegister(probeFailedDueToRegex)
 
 	httpConfig := module.HTTP
+	validators := newValidatorResults(registry)
 
 	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
 		target = "http://" + target
@@ -337,6 +528,8 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		}
 	}
 
+	exportClientCertExpiry(module.HTTP.HTTPClientConfig.TLSConfig, httpConfig.TLSSigner, registry, logger)
+
 	httpClientConfig := module.HTTP.HTTPClientConfig
 	if len(httpClientConfig.TLSConfig.ServerName) == 0 {
 		// If there is no `server_name` in tls_config, use
@@ -352,14 +545,100 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			}
 		}
 	}
-	client, err := pconfig.NewClientFromConfig(httpClientConfig, "http_probe", pconfig.WithKeepAlivesDisabled())
+	serverName := httpClientConfig.TLSConfig.ServerName
+	// HTTP/2 is negotiated with ALPN, the response tells whether the server
+	// agreed to it. HTTP/3 has a transport of its own.
+	switch httpConfig.HTTPVersion {
+	case "1.1":
+		httpClientConfig.EnableHTTP2 = false
+	case "2":
+		httpClientConfig.EnableHTTP2 = true
+	}
+	// With insecure_capture the certificates are verified after the
+	// handshake, against the CA the handshake would have used.
+	var verifyRoots *x509.CertPool
+	if httpConfig.InsecureCapture {
+		tlsConfig, err := pconfig.NewTLSConfig(&httpClientConfig.TLSConfig)
+		if err != nil {
+			logger.Error("Error creating TLS configuration", "err", err)
+			return false
+		}
+		verifyRoots = tlsConfig.RootCAs
+		httpClientConfig.TLSConfig.InsecureSkipVerify = true
+	}
+	tt := newTransport(nil, nil, roundTripLimits{
+		tlsHandshakeTimeout:    httpConfig.TLSHandshakeTimeout,
+		responseHeaderTimeout:  httpConfig.ResponseHeaderTimeout,
+		expectContinueTimeout:  httpConfig.ExpectContinueTimeout,
+		maxResponseHeaderBytes: int64(httpConfig.MaxResponseHeaderBytes),
+	}, logger)
+	// Warm-up requests leave their connections open for the probe request.
+	keepAlives := httpConfig.EnableKeepAlives || httpConfig.WarmupRequests > 0
+	var clientOptions []pconfig.HTTPClientOption
+	if !keepAlives {
+		clientOptions = append(clientOptions, pconfig.WithKeepAlivesDisabled())
+	}
+	var dialContext pconfig.DialContextFunc
+	if tt.limits.maxResponseHeaderBytes > 0 {
+		dialContext = tt.dialContext
+		clientOptions = append(clientOptions, pconfig.WithDialContextFunc(dialContext))
+	}
+	client, err := pconfig.NewClientFromConfig(httpClientConfig, "http_probe", clientOptions...)
 	if err != nil {
 		logger.Error("Error generating HTTP client", "err", err)
 		return false
 	}
+	keyLog := tlsKeyLogWriter(ctx)
+	if keyLog != nil && httpClientConfig.OAuth2 != nil {
+		logger.Warn("TLS session keys are not captured with oauth2")
+		keyLog = nil
+	}
+	// The transports of http_client_config have no way to set the key log.
+	if keyLog != nil {
+		client.Transport, err = newKeyLogRoundTripper(httpClientConfig, keepAlives, dialContext, keyLog)
+		if err != nil {
+			logger.Error("Error generating HTTP client with TLS key log", "err", err)
+			return false
+		}
+	}
+	if httpConfig.TLSSigner.Enabled() {
+		client.Transport, err = newSignerRoundTripper(httpClientConfig, httpConfig.TLSSigner, keepAlives, dialContext, keyLog)
+		if err != nil {
+			logger.Error("Error generating HTTP client with TLS signer", "err", err)
+			return false
+		}
+	}
+	raw := httpConfig.RawRequest != "" || httpConfig.RawRequestFile != ""
+	legacy := httpConfig.ForceHTTP10 || httpConfig.LenientParsing || raw
+	if legacy {
+		client.Transport, err = newLegacyRoundTripper(httpClientConfig, httpConfig, dialContext, keyLog)
+		if err != nil {
+			logger.Error("Error generating HTTP client for legacy servers", "err", err)
+			return false
+		}
+	}
+	http3 := httpConfig.HTTPVersion == "3"
+	if http3 {
+		client.Transport, err = newHTTP3RoundTripper(httpClientConfig, int64(httpConfig.MaxResponseHeaderBytes), keyLog)
+		if err != nil {
+			logger.Error("Error generating HTTP/3 client", "err", err)
+			return false
+		}
+	}
 
 	httpClientConfig.TLSConfig.ServerName = ""
-	noServerName, err := pconfig.NewRoundTripperFromConfig(httpClientConfig, "http_probe", pconfig.WithKeepAlivesDisabled())
+	var noServerName http.RoundTripper
+	if httpConfig.TLSSigner.Enabled() {
+		noServerName, err = newSignerRoundTripper(httpClientConfig, httpConfig.TLSSigner, keepAlives, dialContext, keyLog)
+	} else if legacy {
+		noServerName, err = newLegacyRoundTripper(httpClientConfig, httpConfig, dialContext, keyLog)
+	} else if http3 {
+		noServerName, err = newHTTP3RoundTripper(httpClientConfig, int64(httpConfig.MaxResponseHeaderBytes), keyLog)
+	} else if keyLog != nil {
+		noServerName, err = newKeyLogRoundTripper(httpClientConfig, keepAlives, dialContext, keyLog)
+	} else {
+		noServerName, err = pconfig.NewRoundTripperFromConfig(httpClientConfig, "http_probe", clientOptions...)
+	}
 	if err != nil {
 		logger.Error("Error generating HTTP client without ServerName", "err", err)
 		return false
@@ -374,21 +653,42 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 
 	// Inject transport that tracks traces for each redirect,
 	// and does not set TLS ServerNames on redirect if needed.
-	tt := newTransport(client.Transport, noServerName, logger)
+	tt.Transport = client.Transport
+	tt.NoServerNameTransport = noServerName
 	client.Transport = tt
+	if httpConfig.ProbeSignature.Enabled() {
+		client.Transport, err = newSigningRoundTripper(tt, httpConfig.ProbeSignature)
+		if err != nil {
+			logger.Error("Error generating HTTP client with probe signature", "err", err)
+			return false
+		}
+	}
 
 	client.CheckRedirect = func(r *http.Request, via []*http.Request) error {
 		logger.Info("Received redirect", "location", r.Response.Header.Get("Location"))
 		redirects = len(via)
-		if redirects > 10 || !httpConfig.HTTPClientConfig.FollowRedirects {
+		// A raw request would be sent as is to the location of the redirect.
+		if redirects > 10 || !httpConfig.HTTPClientConfig.FollowRedirects || raw {
 			logger.Info("Not following redirect")
 			return errors.New("don't follow redirects")
 		}
+		// The response of the last hop is checked with the final request, the
+		// certificates of the redirects followed are otherwise never seen.
+		if r.Response.TLS != nil {
+			redirectHops = append(redirectHops, redirectHop{
+				hop:   len(via) - 1,
+				host:  via[len(via)-1].URL.Host,
+				state: r.Response.TLS,
+			})
+		}
 		return nil
 	}
 
 	if httpConfig.Method == "" {
 		httpConfig.Method = "GET"
+		if httpConfig.GraphQL.Query != "" {
+			httpConfig.Method = "POST"
+		}
 	}
 
 	origHost := targetURL.Host
@@ -405,6 +705,15 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		}
 	}
 
+	// The payload of WAF canaries is sent in the query.
+	if len(httpConfig.WAFCanary.QueryParams) > 0 {
+		query := targetURL.Query()
+		for name, value := range httpConfig.WAFCanary.QueryParams {
+			query.Set(name, value)
+		}
+		targetURL.RawQuery = query.Encode()
+	}
+
 	var body io.Reader
 	var respBodyBytes int64
 
@@ -424,6 +733,15 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		body = body_file
 	}
 
+	if httpConfig.GraphQL.Query != "" {
+		graphQLBody, err := graphQLRequestBody(httpConfig.GraphQL)
+		if err != nil {
+			logger.Error("Error encoding GraphQL query", "err", err)
+			return
+		}
+		body = bytes.NewReader(graphQLBody)
+	}
+
 	request, err := http.NewRequest(httpConfig.Method, targetURL.String(), body)
 	if err != nil {
 		logger.Error("Error creating request", "err", err)
@@ -432,6 +750,11 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 	request.Host = origHost
 	request = request.WithContext(ctx)
 
+	if httpConfig.GraphQL.Query != "" {
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/graphql-response+json, application/json")
+	}
+
 	for key, value := range httpConfig.Headers {
 		if textproto.CanonicalMIMEHeaderKey(key) == "Host" {
 			request.Host = value
@@ -445,6 +768,32 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 	if !hasUserAgent {
 		request.Header.Set("User-Agent", userAgentDefaultHeader)
 	}
+	// Raw requests are sent as is, without the header.
+	if !raw {
+		userAgentInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_http_user_agent_info",
+			Help: "Contains the User-Agent sent by the probe",
+		}, []string{"user_agent"})
+		registry.MustRegister(userAgentInfo)
+		userAgentInfo.WithLabelValues(request.Header.Get("User-Agent")).Set(1)
+	}
+
+	if httpConfig.WarmupRequests > 0 {
+		// Warm-up requests bypass the transport tracing the probe request,
+		// and are not redirected.
+		warmupClient := &http.Client{
+			Transport: tt.Transport,
+			Jar:       jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		for i := 0; i < httpConfig.WarmupRequests; i++ {
+			if err := sendWarmupRequest(warmupClient, request); err != nil {
+				logger.Warn("Error for warm-up request", "request", i, "err", err)
+			}
+		}
+	}
 
 	trace := &httptrace.ClientTrace{
 		DNSStart:             tt.DNSStart,
@@ -453,8 +802,10 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		ConnectDone:          tt.ConnectDone,
 		GotConn:              tt.GotConn,
 		GotFirstResponseByte: tt.GotFirstResponseByte,
+		Got1xxResponse:       tt.Got1xxResponse,
 		TLSHandshakeStart:    tt.TLSHandshakeStart,
 		TLSHandshakeDone:     tt.TLSHandshakeDone,
+		WroteRequest:         tt.WroteRequest,
 	}
 	request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
 
@@ -477,7 +828,14 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		requestErrored := (err != nil)
 
 		logger.Info("Received HTTP response", "status_code", resp.StatusCode)
-		if len(httpConfig.ValidStatusCodes) != 0 {
+		if httpConfig.SuccessCriteria != nil {
+			// The status code is checked by the success criteria.
+			success = true
+		} else if httpConfig.CaptivePortal.Enabled || httpConfig.WAFCanary.Enabled {
+			// The status code is checked by the captive portal detection
+			// or the WAF canary.
+			success = true
+		} else if len(httpConfig.ValidStatusCodes) != 0 {
 			for _, code := range httpConfig.ValidStatusCodes {
 				if resp.StatusCode == code {
 					success = true
@@ -495,7 +853,7 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		}
 
 		if success && (len(httpConfig.FailIfHeaderMatchesRegexp) > 0 || len(httpConfig.FailIfHeaderNotMatchesRegexp) > 0) {
-			success = matchRegularExpressionsOnHeaders(resp.Header, httpConfig, logger)
+			success = matchRegularExpressionsOnHeaders(resp.Header, httpConfig, validators, logger)
 			if success {
 				probeFailedDueToRegex.Set(0)
 			} else {
@@ -503,6 +861,32 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			}
 		}
 
+		if !validateHSTS(resp.Header, httpConfig.ValidateHSTS, probeHTTPHSTSMaxAge, registry, logger) {
+			success = false
+		}
+
+		if !scoreSecurityHeaders(resp.Header, httpConfig.SecurityHeaders, registry, logger) {
+			success = false
+		}
+
+		if !validateProxyChain(resp.Header, httpConfig.ValidateProxyChain, registry, logger) {
+			success = false
+		}
+
+		if httpConfig.ValidateAltSvc.Enabled {
+			// The alternatives serve the origin of the last request, whose
+			// server name is the one of the target unless it was redirected
+			// to another host.
+			altSvcClientConfig := module.HTTP.HTTPClientConfig
+			altSvcClientConfig.TLSConfig.ServerName = ""
+			if resp.Request != nil && resp.Request.URL.Host == tt.firstHost {
+				altSvcClientConfig.TLSConfig.ServerName = serverName
+			}
+			if !validateAltSvc(ctx, resp, altSvcClientConfig, httpConfig.ValidateAltSvc, registry, logger) {
+				success = false
+			}
+		}
+
 		// Since the configuration specifies a compression algorithm, blindly treat the response body as a
 		// compressed payload; if we cannot decompress it it's a failure because the configuration says we
 		// should expect the response to be compressed in that way.
@@ -536,10 +920,46 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			resp.Body = http.MaxBytesReader(nil, resp.Body, int64(httpConfig.BodySizeLimit))
 		}
 
+		resp.Body = recordResponse(ctx, resp)
+		var bodyHashes *bodyHashReader
+		if httpConfig.BodyHash != "" || httpConfig.FailIfBodySHA256NotEquals != "" {
+			bodyHashes = newBodyHashReader(resp.Body, httpConfig)
+			resp.Body = bodyHashes
+		}
 		byteCounter := &byteCounter{ReadCloser: resp.Body}
 
+		var respBody []byte
+		if httpConfig.LongPoll.Enabled {
+			respBody, err = readLongPollBody(byteCounter, httpConfig.LongPoll)
+			if err != nil {
+				logger.Error("Error reading the first bytes of the HTTP body", "err", err)
+				success = false
+			}
+		}
+		// Health check and maintenance responses are also parsed when the
+		// status code is an error, as that is how they report their status,
+		// and so is the node that served them.
+		if (success || httpConfig.ValidateHealthJSON.Enabled || httpConfig.Maintenance.Enabled() || httpConfig.IdentifyNode.Enabled()) && needsResponseBody(httpConfig) && !httpConfig.LongPoll.Enabled {
+			respBody, err = io.ReadAll(byteCounter)
+			if err != nil {
+				logger.Error("Error reading HTTP body", "err", err)
+				probeFailedDueToRegex.Set(1)
+				success = false
+			}
+		}
+
+		// The regexps of the body and the comparison see its canonical form.
+		matchedBody := respBody
+		if success && httpConfig.CanonicalizeBody.Enabled() {
+			matchedBody, err = canonicalizeBody(respBody, httpConfig.CanonicalizeBody)
+			if err != nil {
+				logger.Error("Error canonicalizing HTTP body", "err", err)
+				success = false
+			}
+		}
+
 		if success && (len(httpConfig.FailIfBodyMatchesRegexp) > 0 || len(httpConfig.FailIfBodyNotMatchesRegexp) > 0) {
-			success = matchRegularExpressions(byteCounter, httpConfig, logger)
+			success = matchRegularExpressions(matchedBody, httpConfig, validators, logger)
 			if success {
 				probeFailedDueToRegex.Set(0)
 			} else {
@@ -547,12 +967,75 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			}
 		}
 
-		if !requestErrored {
-			_, err = io.Copy(io.Discard, byteCounter)
-			if err != nil {
-				logger.Info("Failed to read HTTP response body", "err", err)
+		if success && len(httpConfig.FailIfBodyJSONNotMatches) > 0 {
+			success = matchJSON(respBody, httpConfig.FailIfBodyJSONNotMatches, validators, logger)
+		}
+
+		if success && httpConfig.ValidateCrawlConfig.Format != "" {
+			success = validateCrawlConfig(respBody, resp.Header, httpConfig.ValidateCrawlConfig, registry, logger)
+		}
+
+		if success && httpConfig.ValidateSAMLMetadata.Enabled {
+			success = validateSAMLMetadata(respBody, httpConfig.ValidateSAMLMetadata, registry, logger)
+		}
+
+		if success && httpConfig.GraphQL.Query != "" {
+			success = validateGraphQLResponse(respBody, httpConfig.GraphQL, registry, logger)
+		}
+
+		if success && httpConfig.ValidateJWT.Enabled() {
+			success = validateJWT(ctx, httpConfig.HTTPClientConfig, resp.Header, respBody, httpConfig.ValidateJWT, registry, logger)
+		}
+
+		if success && httpConfig.CompareBody.Enabled {
+			success = compareBody(target, matchedBody, httpConfig.CompareBody, registry, logger)
+		}
+
+		if success && httpConfig.SuccessCriteria != nil {
+			success = evaluateCriterion(httpConfig.SuccessCriteria, func(c *config.SuccessCriterion) bool {
+				return httpConditionsHold(c, resp, respBody)
+			})
+			if !success {
+				logger.Error("Success criteria did not hold", "status_code", resp.StatusCode)
+			}
+		}
+
+		if success && httpConfig.CaptivePortal.Enabled {
+			success = detectCaptivePortal(resp, respBody, redirects, httpConfig.CaptivePortal, registry, logger)
+		}
+
+		if success && httpConfig.WAFCanary.Enabled {
+			success = checkWAFCanary(resp, httpConfig.WAFCanary, registry, logger)
+		}
+
+		if httpConfig.ValidateHealthJSON.Enabled && respBody != nil {
+			if !validateHealthJSON(respBody, httpConfig.ValidateHealthJSON, registry, logger) {
 				success = false
 			}
+		}
+
+		if httpConfig.Maintenance.Enabled() {
+			if !detectMaintenance(resp, respBody, httpConfig.Maintenance, registry, logger) {
+				success = false
+			}
+		}
+
+		if httpConfig.IdentifyNode.Enabled() {
+			identifyNode(resp, respBody, httpConfig.IdentifyNode, registry, logger)
+		}
+
+		if len(httpConfig.CaptureHeaders) > 0 {
+			captureHeaders(resp, httpConfig.CaptureHeaders, registry, logger)
+		}
+
+		if !requestErrored {
+			if !httpConfig.LongPoll.Enabled {
+				_, err = io.Copy(io.Discard, byteCounter)
+				if err != nil {
+					logger.Info("Failed to read HTTP response body", "err", err)
+					success = false
+				}
+			}
 
 			respBodyBytes = byteCounter.n
 
@@ -564,8 +1047,22 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			}
 		}
 
-		// At this point body is fully read and we can write end time.
+		// At this point body is fully read, or its first bytes in long-poll
+		// mode, and we can write end time.
 		tt.current.end = time.Now()
+		if httpConfig.LongPoll.Enabled {
+			exportLongPollTimes(tt.current, respBodyBytes, registry)
+		}
+
+		if bodyHashes != nil && !bodyHashes.check(httpConfig, registry, logger) {
+			success = false
+		}
+
+		if success && httpConfig.CrawlAssets.MaxResources > 0 {
+			// Use the underlying transport so the timings of the resources
+			// are not added to the ones of the page.
+			success = crawlAssets(ctx, tt.Transport, resp, respBody, respBodyBytes, httpConfig, registry, logger)
+		}
 
 		// Check if there is a Last-Modified HTTP response header.
 		if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
@@ -573,6 +1070,19 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 			probeHTTPLastModified.Set(float64(t.Unix()))
 		}
 
+		if resp.Header.Get("Date") != "" {
+			received := tt.current.responseStart
+			if received.IsZero() {
+				received = tt.current.end
+			}
+			if skew, err := serverClockSkew(resp.Header, received); err == nil {
+				registry.MustRegister(probeHTTPServerClockSkew)
+				probeHTTPServerClockSkew.Set(skew.Seconds())
+			} else {
+				logger.Info("Ignoring Date HTTP response header", "value", resp.Header.Get("Date"), "err", err)
+			}
+		}
+
 		var httpVersionNumber float64
 		httpVersionNumber, err = strconv.ParseFloat(strings.TrimPrefix(resp.Proto, "HTTP/"), 64)
 		if err != nil {
@@ -580,6 +1090,11 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		}
 		probeHTTPVersionGauge.Set(httpVersionNumber)
 
+		if want := httpVersionProtos[httpConfig.HTTPVersion]; want != "" && resp.Proto != want {
+			logger.Error("HTTP version negotiation fell back", "http_version", want, "version", resp.Proto)
+			success = false
+		}
+
 		if len(httpConfig.ValidHTTPVersions) != 0 {
 			found := false
 			for _, version := range httpConfig.ValidHTTPVersions {
@@ -639,15 +1154,18 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		}
 		durationGaugeVec.WithLabelValues("transfer").Add(trace.end.Sub(trace.responseStart).Seconds())
 	}
+	if len(tt.traces) > 0 {
+		exportInformationalResponses(tt.traces[len(tt.traces)-1], registry)
+	}
 
 	if resp.TLS != nil {
+		if !checkTLSState(resp, httpConfig, verifyRoots, registry, logger) {
+			success = false
+		}
 		isSSLGauge.Set(float64(1))
-		registry.MustRegister(probeSSLEarliestCertExpiryGauge, probeTLSVersion, probeTLSCipher, probeSSLLastChainExpiryTimestampSeconds, probeSSLLastInformation)
-		probeSSLEarliestCertExpiryGauge.Set(float64(getEarliestCertExpiry(resp.TLS).Unix()))
-		probeTLSVersion.WithLabelValues(getTLSVersion(resp.TLS)).Set(1)
+		exportTLSState(ctx, target, resp.TLS, registry)
+		registry.MustRegister(probeTLSCipher)
 		probeTLSCipher.WithLabelValues(getTLSCipher(resp.TLS)).Set(1)
-		probeSSLLastChainExpiryTimestampSeconds.Set(float64(getLastChainExpiry(resp.TLS).Unix()))
-		probeSSLLastInformation.WithLabelValues(getFingerprint(resp.TLS), getSubject(resp.TLS), getIssuer(resp.TLS), getDNSNames(resp.TLS), getSerialNumber(resp.TLS)).Set(1)
 		if httpConfig.FailIfSSL {
 			logger.Error("Final request was over SSL")
 			success = false
@@ -657,6 +1175,15 @@ func ProbeHTTP(ctx context.Context, target string, module config.Module, registr
 		success = false
 	}
 
+	if len(redirectHops) > 0 {
+		registry.MustRegister(probeRedirectSSLEarliestCertExpiry, probeRedirectSSLInformation)
+		for _, h := range redirectHops {
+			hop := strconv.Itoa(h.hop)
+			probeRedirectSSLEarliestCertExpiry.WithLabelValues(hop, h.host).Set(float64(getEarliestCertExpiry(h.state).Unix()))
+			probeRedirectSSLInformation.WithLabelValues(hop, h.host, getSubject(h.state), getIssuer(h.state)).Set(1)
+		}
+	}
+
 	statusCodeGauge.Set(float64(resp.StatusCode))
 	contentLengthGauge.Set(float64(resp.ContentLength))
 	bodyUncompressedLengthGauge.Set(float64(respBodyBytes))
@@ -682,3 +1209,23 @@ func getDecompressionReader(algorithm string, origBody io.ReadCloser) (io.ReadCl
 		return nil, errors.New("unsupported compression algorithm")
 	}
 }
+
+// sendWarmupRequest sends a copy of request and reads its response, so that
+// the connection can be reused by the next request.
+func sendWarmupRequest(client *http.Client, request *http.Request) error {
+	req := request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
@@ -0,0 +1,647 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pconfig "github.com/prometheus/common/config"
+	"golang.org/x/net/http2/hpack"
+	"golang.org/x/net/quic"
+)
+
+// The frame and stream types and error code of HTTP/3 used by the probe, see
+// RFC 9114.
+const (
+	http3FrameData     = 0x00
+	http3FrameHeaders  = 0x01
+	http3FrameSettings = 0x04
+	http3StreamControl = 0x00
+	http3NoError       = 0x100
+)
+
+var errQPACKDynamicTable = errors.New("QPACK dynamic table references are not supported")
+
+// http3RoundTripper makes HTTP/3 requests over a new QUIC connection each, as
+// the HTTP client library does not speak HTTP/3. The headers are encoded with
+// the static table of QPACK only, the settings of the connection not allowing
+// the server to use a dynamic table.
+type http3RoundTripper struct {
+	tlsConfig      *tls.Config
+	auth           func(*http.Request) error
+	maxHeaderBytes int64
+}
+
+func newHTTP3RoundTripper(httpClientConfig pconfig.HTTPClientConfig, maxHeaderBytes int64, keyLog io.Writer) (*http3RoundTripper, error) {
+	tlsConfig, err := pconfig.NewTLSConfig(&httpClientConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.NextProtos = []string{"h3"}
+	tlsConfig.KeyLogWriter = keyLog
+	return &http3RoundTripper{
+		tlsConfig:      tlsConfig,
+		auth:           legacyAuth(httpClientConfig),
+		maxHeaderBytes: maxHeaderBytes,
+	}, nil
+}
+
+func (rt *http3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = &httptrace.ClientTrace{}
+	}
+	closeBody := func() {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+	}
+	req = req.Clone(ctx)
+	if err := rt.auth(req); err != nil {
+		closeBody()
+		return nil, err
+	}
+	if req.URL.Scheme != "https" {
+		closeBody()
+		return nil, fmt.Errorf("HTTP/3 is only spoken over https, not %s", req.URL.Scheme)
+	}
+
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr = net.JoinHostPort(req.URL.Hostname(), "443")
+	}
+	network := "udp4"
+	if ip := net.ParseIP(req.URL.Hostname()); ip != nil && ip.To4() == nil {
+		network = "udp6"
+	}
+	// QUIC has no connection of its own before its handshake, which is the
+	// one of TLS.
+	if trace.ConnectStart != nil {
+		trace.ConnectStart(network, addr)
+	}
+	endpoint, err := quic.Listen(network, "", nil)
+	if trace.ConnectDone != nil {
+		trace.ConnectDone(network, addr, err)
+	}
+	if err != nil {
+		closeBody()
+		return nil, err
+	}
+
+	var state tls.ConnectionState
+	tlsConfig := rt.tlsConfig.Clone()
+	verifyConnection := tlsConfig.VerifyConnection
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		state = cs
+		if verifyConnection != nil {
+			return verifyConnection(cs)
+		}
+		return nil
+	}
+	if trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := endpoint.Dial(ctx, network, addr, &quic.Config{TLSConfig: tlsConfig})
+	if trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(state, err)
+	}
+	if err != nil {
+		closeBody()
+		closeHTTP3Endpoint(endpoint)
+		return nil, err
+	}
+	body := &http3Body{conn: conn, endpoint: endpoint}
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{})
+	}
+
+	stream, err := rt.writeRequest(ctx, conn, req)
+	if trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	body.stream = stream
+
+	resp, err := rt.readResponse(stream, req, trace)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	state.HandshakeComplete = true
+	resp.TLS = &state
+	resp.Body = body
+	return resp, nil
+}
+
+// writeRequest sends the control stream of the connection, and the request
+// on a new stream.
+func (rt *http3RoundTripper) writeRequest(ctx context.Context, conn *quic.Conn, req *http.Request) (*quic.Stream, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	control, err := conn.NewSendOnlyStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The default settings are empty, with no dynamic table for QPACK.
+	control.Write([]byte{http3StreamControl, http3FrameSettings, 0})
+	control.Flush()
+
+	stream, err := conn.NewStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetReadContext(ctx)
+	stream.SetWriteContext(ctx)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fields := appendQPACKField([]byte{0, 0}, ":method", req.Method)
+	fields = appendQPACKField(fields, ":scheme", "https")
+	fields = appendQPACKField(fields, ":authority", host)
+	fields = appendQPACKField(fields, ":path", req.URL.RequestURI())
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		switch name {
+		// The fields of HTTP/1 connections are not allowed.
+		case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade", "host", "te":
+			continue
+		}
+		for _, value := range values {
+			fields = appendQPACKField(fields, name, value)
+		}
+	}
+	if req.ContentLength > 0 {
+		fields = appendQPACKField(fields, "content-length", strconv.FormatInt(req.ContentLength, 10))
+	}
+	if _, err := stream.Write(appendHTTP3Frame(nil, http3FrameHeaders, fields)); err != nil {
+		return nil, err
+	}
+
+	if req.Body != nil {
+		buf := make([]byte, 32<<10)
+		for {
+			n, err := req.Body.Read(buf)
+			if n > 0 {
+				if _, err := stream.Write(appendHTTP3Frame(nil, http3FrameData, buf[:n])); err != nil {
+					return nil, err
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	stream.CloseWrite()
+	return stream, nil
+}
+
+// readResponse reads the headers of the response, after those of the
+// informational responses.
+func (rt *http3RoundTripper) readResponse(stream *quic.Stream, req *http.Request, trace *httptrace.ClientTrace) (*http.Response, error) {
+	for first := true; ; first = false {
+		frameType, length, err := readHTTP3FrameHeader(stream)
+		if err == io.EOF {
+			return nil, errors.New("stream ended before the response headers")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && trace.GotFirstResponseByte != nil {
+			trace.GotFirstResponseByte()
+		}
+		switch frameType {
+		case http3FrameHeaders:
+		case http3FrameData:
+			return nil, errors.New("response data received before its headers")
+		default:
+			// Unknown frames are ignored.
+			if _, err := io.CopyN(io.Discard, stream, int64(length)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if rt.maxHeaderBytes > 0 && length > uint64(rt.maxHeaderBytes) {
+			return nil, fmt.Errorf("response headers exceed %d bytes", rt.maxHeaderBytes)
+		}
+		fields := make([]byte, length)
+		if _, err := io.ReadFull(stream, fields); err != nil {
+			return nil, err
+		}
+		status := ""
+		header := http.Header{}
+		err = decodeQPACKFields(fields, func(name, value string) {
+			if name == ":status" {
+				status = value
+			} else if !strings.HasPrefix(name, ":") {
+				header.Add(name, value)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 100 || code > 999 {
+			return nil, fmt.Errorf("invalid response status %q", status)
+		}
+		if code < 200 {
+			if trace.Got1xxResponse != nil {
+				if err := trace.Got1xxResponse(code, textproto.MIMEHeader(header)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		resp := &http.Response{
+			Status:        strconv.Itoa(code) + " " + http.StatusText(code),
+			StatusCode:    code,
+			Proto:         "HTTP/3.0",
+			ProtoMajor:    3,
+			Header:        header,
+			ContentLength: -1,
+			Request:       req,
+		}
+		if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+			resp.ContentLength = n
+		}
+		return resp, nil
+	}
+}
+
+// http3Body reads the DATA frames of a response, and closes its connection.
+type http3Body struct {
+	conn      *quic.Conn
+	endpoint  *quic.Endpoint
+	stream    *quic.Stream
+	remaining uint64
+	closeOnce sync.Once
+}
+
+func (b *http3Body) Read(p []byte) (int, error) {
+	for b.remaining == 0 {
+		frameType, length, err := readHTTP3FrameHeader(b.stream)
+		if err != nil {
+			return 0, err
+		}
+		if frameType == http3FrameData {
+			b.remaining = length
+			continue
+		}
+		// Trailers and unknown frames are ignored.
+		if _, err := io.CopyN(io.Discard, b.stream, int64(length)); err != nil {
+			return 0, err
+		}
+	}
+	if uint64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.stream.Read(p)
+	b.remaining -= uint64(n)
+	if err == io.EOF {
+		if b.remaining > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+	return n, err
+}
+
+func (b *http3Body) Close() error {
+	b.closeOnce.Do(func() {
+		if b.stream != nil {
+			b.stream.CloseRead()
+		}
+		b.conn.Abort(&quic.ApplicationError{Code: http3NoError})
+		closeHTTP3Endpoint(b.endpoint)
+	})
+	return nil
+}
+
+// closeHTTP3Endpoint closes the endpoint, waiting a little for the server to
+// acknowledge the closing of the connection.
+func closeHTTP3Endpoint(endpoint *quic.Endpoint) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	endpoint.Close(ctx)
+}
+
+// appendHTTP3Frame appends a frame with its type and length.
+func appendHTTP3Frame(b []byte, frameType uint64, payload []byte) []byte {
+	b = appendQUICVarint(b, frameType)
+	b = appendQUICVarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+// readHTTP3FrameHeader reads the type and length of the next frame, io.EOF
+// meaning that the stream ended between frames.
+func readHTTP3FrameHeader(r io.ByteReader) (frameType, length uint64, err error) {
+	frameType, err = readQUICVarint(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err = readQUICVarint(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return frameType, length, err
+}
+
+// appendQUICVarint appends the variable-length integer encoding of v, see
+// RFC 9000 section 16.
+func appendQUICVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return binary.BigEndian.AppendUint16(b, 1<<14|uint16(v))
+	case v < 1<<30:
+		return binary.BigEndian.AppendUint32(b, 2<<30|uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(b, 3<<62|v)
+	}
+}
+
+func readQUICVarint(r io.ByteReader) (uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(c & 0x3f)
+	for i := 1; i < 1<<(c>>6); i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// appendQPACKField appends a field line with a literal name and value, the
+// simplest encoding of RFC 9204, never referencing a table.
+func appendQPACKField(b []byte, name, value string) []byte {
+	b = appendQPACKInt(b, 0x20, 3, uint64(len(name)))
+	b = append(b, name...)
+	b = appendQPACKInt(b, 0, 7, uint64(len(value)))
+	return append(b, value...)
+}
+
+// decodeQPACKFields decodes a field section which does not reference the
+// dynamic table.
+func decodeQPACKFields(b []byte, f func(name, value string)) error {
+	requiredInsertCount, b, err := decodeQPACKInt(b, 8)
+	if err != nil {
+		return err
+	}
+	if requiredInsertCount != 0 {
+		return errQPACKDynamicTable
+	}
+	// The base only matters for the dynamic table.
+	if _, b, err = decodeQPACKInt(b, 7); err != nil {
+		return err
+	}
+	for len(b) > 0 {
+		var name, value string
+		switch c := b[0]; {
+		case c&0x80 != 0:
+			// Indexed field line.
+			if c&0x40 == 0 {
+				return errQPACKDynamicTable
+			}
+			var index uint64
+			if index, b, err = decodeQPACKInt(b, 6); err != nil {
+				return err
+			}
+			if index >= uint64(len(qpackStaticTable)) {
+				return fmt.Errorf("invalid QPACK static table index %d", index)
+			}
+			name, value = qpackStaticTable[index][0], qpackStaticTable[index][1]
+		case c&0x40 != 0:
+			// Literal field line with name reference.
+			if c&0x10 == 0 {
+				return errQPACKDynamicTable
+			}
+			var index uint64
+			if index, b, err = decodeQPACKInt(b, 4); err != nil {
+				return err
+			}
+			if index >= uint64(len(qpackStaticTable)) {
+				return fmt.Errorf("invalid QPACK static table index %d", index)
+			}
+			name = qpackStaticTable[index][0]
+			if value, b, err = decodeQPACKString(b, 7); err != nil {
+				return err
+			}
+		case c&0x20 != 0:
+			// Literal field line with literal name.
+			if name, b, err = decodeQPACKString(b, 3); err != nil {
+				return err
+			}
+			if value, b, err = decodeQPACKString(b, 7); err != nil {
+				return err
+			}
+		default:
+			return errQPACKDynamicTable
+		}
+		f(name, value)
+	}
+	return nil
+}
+
+// appendQPACKInt appends v as an integer with a prefix of n bits, the other
+// bits of its first byte being those of flags.
+func appendQPACKInt(b []byte, flags byte, n uint, v uint64) []byte {
+	limit := uint64(1)<<n - 1
+	if v < limit {
+		return append(b, flags|byte(v))
+	}
+	b = append(b, flags|byte(limit))
+	for v -= limit; v >= 0x80; v >>= 7 {
+		b = append(b, byte(v)|0x80)
+	}
+	return append(b, byte(v))
+}
+
+func decodeQPACKInt(b []byte, n uint) (uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, errors.New("truncated QPACK integer")
+	}
+	limit := uint64(1)<<n - 1
+	v := uint64(b[0]) & limit
+	b = b[1:]
+	if v < limit {
+		return v, b, nil
+	}
+	for shift := uint(0); len(b) > 0 && shift < 63; shift += 7 {
+		c := b[0]
+		b = b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, b, nil
+		}
+	}
+	return 0, nil, errors.New("truncated QPACK integer")
+}
+
+// decodeQPACKString decodes a string whose length has a prefix of n bits,
+// after the bit telling whether it is Huffman encoded.
+func decodeQPACKString(b []byte, n uint) (string, []byte, error) {
+	if len(b) == 0 {
+		return "", nil, errors.New("truncated QPACK string")
+	}
+	huffman := b[0]&(1<<n) != 0
+	length, b, err := decodeQPACKInt(b, n)
+	if err != nil {
+		return "", nil, err
+	}
+	if length > uint64(len(b)) {
+		return "", nil, errors.New("truncated QPACK string")
+	}
+	s, b := b[:length], b[length:]
+	if !huffman {
+		return string(s), b, nil
+	}
+	decoded, err := hpack.HuffmanDecodeToString(s)
+	return decoded, b, err
+}
+
+// qpackStaticTable is the static table of QPACK, see RFC 9204 appendix A.
+var qpackStaticTable = [...][2]string{
+	{":authority", ""},
+	{":path", "/"},
+	{"age", "0"},
+	{"content-disposition", ""},
+	{"content-length", "0"},
+	{"cookie", ""},
+	{"date", ""},
+	{"etag", ""},
+	{"if-modified-since", ""},
+	{"if-none-match", ""},
+	{"last-modified", ""},
+	{"link", ""},
+	{"location", ""},
+	{"referer", ""},
+	{"set-cookie", ""},
+	{":method", "CONNECT"},
+	{":method", "DELETE"},
+	{":method", "GET"},
+	{":method", "HEAD"},
+	{":method", "OPTIONS"},
+	{":method", "POST"},
+	{":method", "PUT"},
+	{":scheme", "http"},
+	{":scheme", "https"},
+	{":status", "103"},
+	{":status", "200"},
+	{":status", "304"},
+	{":status", "404"},
+	{":status", "503"},
+	{"accept", "*/*"},
+	{"accept", "application/dns-message"},
+	{"accept-encoding", "gzip, deflate, br"},
+	{"accept-ranges", "bytes"},
+	{"access-control-allow-headers", "cache-control"},
+	{"access-control-allow-headers", "content-type"},
+	{"access-control-allow-origin", "*"},
+	{"cache-control", "max-age=0"},
+	{"cache-control", "max-age=2592000"},
+	{"cache-control", "max-age=604800"},
+	{"cache-control", "no-cache"},
+	{"cache-control", "no-store"},
+	{"cache-control", "public, max-age=31536000"},
+	{"content-encoding", "br"},
+	{"content-encoding", "gzip"},
+	{"content-type", "application/dns-message"},
+	{"content-type", "application/javascript"},
+	{"content-type", "application/json"},
+	{"content-type", "application/x-www-form-urlencoded"},
+	{"content-type", "image/gif"},
+	{"content-type", "image/jpeg"},
+	{"content-type", "image/png"},
+	{"content-type", "text/css"},
+	{"content-type", "text/html; charset=utf-8"},
+	{"content-type", "text/plain"},
+	{"content-type", "text/plain;charset=utf-8"},
+	{"range", "bytes=0-"},
+	{"strict-transport-security", "max-age=31536000"},
+	{"strict-transport-security", "max-age=31536000; includesubdomains"},
+	{"strict-transport-security", "max-age=31536000; includesubdomains; preload"},
+	{"vary", "accept-encoding"},
+	{"vary", "origin"},
+	{"x-content-type-options", "nosniff"},
+	{"x-xss-protection", "1; mode=block"},
+	{":status", "100"},
+	{":status", "204"},
+	{":status", "206"},
+	{":status", "302"},
+	{":status", "400"},
+	{":status", "403"},
+	{":status", "421"},
+	{":status", "425"},
+	{":status", "500"},
+	{"accept-language", ""},
+	{"access-control-allow-credentials", "FALSE"},
+	{"access-control-allow-credentials", "TRUE"},
+	{"access-control-allow-headers", "*"},
+	{"access-control-allow-methods", "get"},
+	{"access-control-allow-methods", "get, post, options"},
+	{"access-control-allow-methods", "options"},
+	{"access-control-expose-headers", "content-length"},
+	{"access-control-request-headers", "content-type"},
+	{"access-control-request-method", "get"},
+	{"access-control-request-method", "post"},
+	{"alt-svc", "clear"},
+	{"authorization", ""},
+	{"content-security-policy", "script-src 'none'; object-src 'none'; base-uri 'none'"},
+	{"early-data", "1"},
+	{"expect-ct", ""},
+	{"forwarded", ""},
+	{"if-range", ""},
+	{"origin", ""},
+	{"purpose", "prefetch"},
+	{"server", ""},
+	{"timing-allow-origin", "*"},
+	{"upgrade-insecure-requests", "1"},
+	{"user-agent", ""},
+	{"x-forwarded-for", ""},
+	{"x-frame-options", "deny"},
+	{"x-frame-options", "sameorigin"},
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+	"golang.org/x/net/quic"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// newHTTP3TestServer serves the requests of the probe with a server speaking
+// just enough HTTP/3 for them. handler returns the frames of the response.
+func newHTTP3TestServer(t *testing.T, cert tls.Certificate, handler func(fields map[string]string, body []byte) []byte) string {
+	t.Helper()
+	endpoint, err := quic.Listen("udp4", "127.0.0.1:0", &quic.Config{TLSConfig: &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h3"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		closeHTTP3Endpoint(endpoint)
+	})
+
+	serve := func(stream *quic.Stream) {
+		defer stream.Close()
+		fields := map[string]string{}
+		var body []byte
+		for {
+			frameType, length, err := readHTTP3FrameHeader(stream)
+			if err != nil {
+				break
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(stream, payload); err != nil {
+				return
+			}
+			switch frameType {
+			case http3FrameHeaders:
+				decodeQPACKFields(payload, func(name, value string) { fields[name] = value })
+			case http3FrameData:
+				body = append(body, payload...)
+			}
+		}
+		stream.Write(handler(fields, body))
+	}
+	go func() {
+		for {
+			conn, err := endpoint.Accept(ctx)
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					stream, err := conn.AcceptStream(ctx)
+					if err != nil {
+						return
+					}
+					// The control stream of the client is ignored.
+					if !stream.IsReadOnly() {
+						go serve(stream)
+					}
+				}
+			}()
+		}
+	}()
+	return "https://" + endpoint.LocalAddr().String()
+}
+
+func TestHTTPVersion3(t *testing.T) {
+	cert, _, key := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().Add(time.Hour), true))
+	target := newHTTP3TestServer(t, tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}, func(fields map[string]string, body []byte) []byte {
+		hints := appendQPACKField([]byte{0, 0}, ":status", "103")
+		headers := appendQPACKField([]byte{0, 0}, ":status", "200")
+		headers = appendQPACKField(headers, "content-type", "text/plain")
+		frames := appendHTTP3Frame(nil, http3FrameHeaders, hints)
+		frames = appendHTTP3Frame(frames, http3FrameHeaders, headers)
+		// The body is split across frames.
+		frames = appendHTTP3Frame(frames, http3FrameData, fmt.Appendf(nil, "%s %s ", fields[":method"], fields[":path"]))
+		return appendHTTP3Frame(frames, http3FrameData, fmt.Appendf(nil, "%s %s", body, fields["x-probe"]))
+	})
+	// Nothing speaks QUIC on the port of the server.
+	tcpOnly := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tcpOnly.Close()
+
+	for _, test := range []struct {
+		name    string
+		target  string
+		success bool
+		metrics map[string]float64
+	}{
+		{
+			name:    "HTTP/3",
+			target:  target + "/path",
+			success: true,
+			metrics: map[string]float64{
+				"probe_http_version":        3,
+				"probe_http_status_code":    200,
+				"probe_http_ssl":            1,
+				"probe_http_content_length": -1,
+			},
+		},
+		{
+			name:    "no QUIC",
+			target:  tcpOnly.URL,
+			success: false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			httpClientConfig := pconfig.DefaultHTTPClientConfig
+			httpClientConfig.TLSConfig.InsecureSkipVerify = true
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback:         true,
+				HTTPVersion:                "3",
+				HTTPClientConfig:           httpClientConfig,
+				Method:                     "POST",
+				Body:                       "ping",
+				Headers:                    map[string]string{"X-Probe": "pong"},
+				FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("^POST /path ping pong$")},
+			}}
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if result := ProbeHTTP(testCTX, test.target, module, registry, promslog.NewNopLogger()); result != test.success {
+				t.Fatalf("Expected success %t, got %t", test.success, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.metrics, mfs, t)
+		})
+	}
+}
+
+func TestDecodeQPACKFields(t *testing.T) {
+	// ":method: GET" from the static table, ":path: /index.html" with a name
+	// from the static table and "www.example.com" Huffman encoded.
+	fields := []byte{0x00, 0x00, 0xd1, 0x51, 0x0b}
+	fields = append(fields, "/index.html"...)
+	fields = append(fields, 0x50, 0x8c, 0xf1, 0xe3, 0xc2, 0xe5, 0xf2, 0x3a, 0x6b, 0xa0, 0xab, 0x90, 0xf4, 0xff)
+	fields = appendQPACKField(fields, "x-long", string(make([]byte, 300)))
+	var got [][2]string
+	if err := decodeQPACKFields(fields, func(name, value string) { got = append(got, [2]string{name, value}) }); err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{":method", "GET"}, {":path", "/index.html"}, {":authority", "www.example.com"}, {"x-long", string(make([]byte, 300))}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected fields %q, got %q", want, got)
+	}
+
+	// Fields referencing the dynamic table cannot be decoded.
+	if err := decodeQPACKFields([]byte{0x02, 0x00, 0x80}, func(string, string) {}); err != errQPACKDynamicTable {
+		t.Errorf("Expected %q for a dynamic table reference, got %v", errQPACKDynamicTable, err)
+	}
+}
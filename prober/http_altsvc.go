@@ -0,0 +1,202 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// altService is an alternative service advertised in an Alt-Svc header.
+type altService struct {
+	protocol string
+	// host is empty when the alternative is on the host of the origin.
+	host string
+	port string
+}
+
+func (a altService) authority() string {
+	return net.JoinHostPort(a.host, a.port)
+}
+
+// parseAltSvc parses the values of the Alt-Svc headers of a response, as
+// defined in RFC 7838. Invalid alternatives are skipped, and clear removes
+// the ones advertised before it.
+func parseAltSvc(values []string) []altService {
+	var alternatives []altService
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "clear" {
+				alternatives = nil
+				continue
+			}
+			// The parameters, such as ma and persist, are not used.
+			alternative, _, _ := strings.Cut(field, ";")
+			protocol, authority, ok := strings.Cut(strings.TrimSpace(alternative), "=")
+			if !ok {
+				continue
+			}
+			protocol, err := url.PathUnescape(protocol)
+			if err != nil || protocol == "" {
+				continue
+			}
+			host, port, err := net.SplitHostPort(strings.Trim(authority, `"`))
+			if err != nil || port == "" {
+				continue
+			}
+			alternatives = append(alternatives, altService{protocol: protocol, host: host, port: port})
+		}
+	}
+	return alternatives
+}
+
+// validateAltSvc exports the alternative services advertised by the response
+// and, with probe_alternatives, whether they answer the request like the
+// target did. The server name of httpClientConfig is the one of the origin of
+// the response, or empty to use its host.
+func validateAltSvc(ctx context.Context, resp *http.Response, httpClientConfig pconfig.HTTPClientConfig, v config.AltSvcValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	alternativesGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_alt_svc_alternatives",
+		Help: "Number of alternative services advertised in the Alt-Svc header",
+	})
+	alternativeSuccessGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_alt_svc_alternative_success",
+		Help: "Indicates if the request to the alternative service succeeded, for the protocols that can be probed",
+	}, []string{"protocol", "authority"})
+	registry.MustRegister(alternativesGauge)
+
+	alternatives := parseAltSvc(resp.Header.Values("Alt-Svc"))
+	alternativesGauge.Set(float64(len(alternatives)))
+	if len(alternatives) == 0 {
+		if v.FailIfMissing {
+			logger.Error("No alternative service advertised in the Alt-Svc header")
+			return false
+		}
+		return true
+	}
+	if !v.ProbeAlternatives || resp.Request == nil {
+		return true
+	}
+
+	registry.MustRegister(alternativeSuccessGaugeVec)
+	success := true
+	probed := map[altService]bool{}
+	for _, alternative := range alternatives {
+		if probed[alternative] {
+			continue
+		}
+		probed[alternative] = true
+		if alternative.protocol != "h3" && alternative.protocol != "h2" && alternative.protocol != "http/1.1" {
+			logger.Info("Not probing the alternative service, its protocol is not supported", "protocol", alternative.protocol, "authority", alternative.authority())
+			continue
+		}
+		gauge := alternativeSuccessGaugeVec.WithLabelValues(alternative.protocol, alternative.authority())
+		err := probeAltService(ctx, resp, alternative, httpClientConfig)
+		if err != nil {
+			logger.Error("Error probing the alternative service", "protocol", alternative.protocol, "authority", alternative.authority(), "err", err)
+			gauge.Set(0)
+			if v.FailIfBroken {
+				success = false
+			}
+			continue
+		}
+		logger.Info("Alternative service answered", "protocol", alternative.protocol, "authority", alternative.authority())
+		gauge.Set(1)
+	}
+	return success
+}
+
+// probeAltService sends the request of the response to the alternative
+// service. Alternatives serve the same origin, so the request is sent over
+// TLS with the Host and server name of the origin, and the alternative has
+// to answer it with the advertised protocol and the status code of the
+// response.
+func probeAltService(ctx context.Context, resp *http.Response, alternative altService, httpClientConfig pconfig.HTTPClientConfig) error {
+	// The URL of the first request has the resolved IP of the target as its
+	// host, its Host is the one of the origin.
+	origin := *resp.Request.URL
+	origin.Scheme = "https"
+	originHost := resp.Request.Host
+	if originHost == "" {
+		originHost = origin.Host
+	}
+	if httpClientConfig.TLSConfig.ServerName == "" {
+		httpClientConfig.TLSConfig.ServerName = originHost
+		if host, _, err := net.SplitHostPort(originHost); err == nil {
+			httpClientConfig.TLSConfig.ServerName = host
+		}
+	}
+	host := alternative.host
+	if host == "" {
+		host = origin.Hostname()
+	}
+	address := net.JoinHostPort(host, alternative.port)
+
+	keyLog := tlsKeyLogWriter(ctx)
+	if httpClientConfig.OAuth2 != nil {
+		keyLog = nil
+	}
+	var rt http.RoundTripper
+	var err error
+	if alternative.protocol == "h3" {
+		// The HTTP/3 transport connects to the host of the URL.
+		origin.Host = address
+		rt, err = newHTTP3RoundTripper(httpClientConfig, 0, keyLog)
+	} else {
+		httpClientConfig.EnableHTTP2 = alternative.protocol == "h2"
+		dialContext := func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		}
+		if keyLog != nil {
+			rt, err = newKeyLogRoundTripper(httpClientConfig, false, dialContext, keyLog)
+		} else {
+			rt, err = pconfig.NewRoundTripperFromConfig(httpClientConfig, "http_probe", pconfig.WithDialContextFunc(dialContext), pconfig.WithKeepAlivesDisabled())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	method := resp.Request.Method
+	if method != http.MethodHead {
+		method = http.MethodGet
+	}
+	request, err := http.NewRequestWithContext(ctx, method, origin.String(), nil)
+	if err != nil {
+		return err
+	}
+	request.Host = originHost
+	altResp, err := rt.RoundTrip(request)
+	if err != nil {
+		return err
+	}
+	altResp.Body.Close()
+	switch {
+	case alternative.protocol == "h2" && altResp.ProtoMajor != 2:
+		return fmt.Errorf("negotiated %s instead of HTTP/2", altResp.Proto)
+	case altResp.StatusCode != resp.StatusCode:
+		return fmt.Errorf("status code %d differs from the one of the target, %d", altResp.StatusCode, resp.StatusCode)
+	}
+	return nil
+}
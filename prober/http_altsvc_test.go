@@ -0,0 +1,213 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestParseAltSvc(t *testing.T) {
+	tests := map[string]struct {
+		values   []string
+		expected []altService
+	}{
+		"same host": {
+			values:   []string{`h3=":443"; ma=86400, h3-29=":443"; ma=86400`},
+			expected: []altService{{protocol: "h3", port: "443"}, {protocol: "h3-29", port: "443"}},
+		},
+		"other host": {
+			values:   []string{`h2="alt.example.com:8443"; persist=1`, `http%2F1.1="[2001:db8::1]:443"`},
+			expected: []altService{{protocol: "h2", host: "alt.example.com", port: "8443"}, {protocol: "http/1.1", host: "2001:db8::1", port: "443"}},
+		},
+		"clear": {
+			values: []string{`h2=":8443"`, "clear"},
+		},
+		"invalid": {
+			values:   []string{`h2, h3="example.com", h2=":8443"`},
+			expected: []altService{{protocol: "h2", port: "8443"}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := parseAltSvc(test.values); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateAltSvc(t *testing.T) {
+	h2 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+	defer h2.Close()
+	http1 := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer http1.Close()
+	cert, _, key := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().Add(time.Hour), true))
+	h3 := newHTTP3TestServer(t, tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}, func(map[string]string, []byte) []byte {
+		return appendHTTP3Frame(nil, http3FrameHeaders, appendQPACKField([]byte{0, 0}, ":status", "200"))
+	})
+	// Nothing listens on the port of a closed listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+
+	tests := map[string]struct {
+		altSvc         string
+		validator      config.AltSvcValidator
+		expectedResult bool
+		expected       map[string]float64
+	}{
+		"advertised": {
+			altSvc:         `h2="` + h2.Listener.Addr().String() + `"`,
+			validator:      config.AltSvcValidator{Enabled: true},
+			expectedResult: true,
+			expected:       map[string]float64{"probe_http_alt_svc_alternatives": 1},
+		},
+		"missing": {
+			validator: config.AltSvcValidator{Enabled: true, FailIfMissing: true},
+			expected:  map[string]float64{"probe_http_alt_svc_alternatives": 0},
+		},
+		"alternative answered": {
+			altSvc:         fmt.Sprintf(`h3="%s"; ma=86400, h2="%s"; ma=86400`, strings.TrimPrefix(h3, "https://"), h2.Listener.Addr()),
+			validator:      config.AltSvcValidator{Enabled: true, ProbeAlternatives: true, FailIfBroken: true},
+			expectedResult: true,
+			expected:       map[string]float64{"probe_http_alt_svc_alternatives": 2, "probe_http_alt_svc_alternative_success": 1},
+		},
+		"alternative without http3": {
+			altSvc:    `h3="` + http1.Listener.Addr().String() + `"`,
+			validator: config.AltSvcValidator{Enabled: true, ProbeAlternatives: true, FailIfBroken: true},
+			expected:  map[string]float64{"probe_http_alt_svc_alternative_success": 0},
+		},
+		"alternative without http2": {
+			altSvc:    `h2="` + http1.Listener.Addr().String() + `"`,
+			validator: config.AltSvcValidator{Enabled: true, ProbeAlternatives: true, FailIfBroken: true},
+			expected:  map[string]float64{"probe_http_alt_svc_alternative_success": 0},
+		},
+		"alternative down": {
+			altSvc:    `h2="` + closed + `"`,
+			validator: config.AltSvcValidator{Enabled: true, ProbeAlternatives: true, FailIfBroken: true},
+			expected:  map[string]float64{"probe_http_alt_svc_alternative_success": 0},
+		},
+		"alternative down without fail_if_broken": {
+			altSvc:         `h2="` + closed + `"`,
+			validator:      config.AltSvcValidator{Enabled: true, ProbeAlternatives: true},
+			expectedResult: true,
+			expected:       map[string]float64{"probe_http_alt_svc_alternative_success": 0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.altSvc != "" {
+					w.Header().Set("Alt-Svc", test.altSvc)
+				}
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			// The QUIC handshake with an alternative not speaking it only
+			// ends with the context.
+			testCTX, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				HTTPClientConfig:   pconfig.HTTPClientConfig{TLSConfig: pconfig.TLSConfig{InsecureSkipVerify: true}},
+				ValidateAltSvc:     test.validator,
+			}}
+			result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
+
+func TestValidateAltSvcOrigin(t *testing.T) {
+	// The certificate is only valid for localhost, not for the IP the target
+	// resolves to.
+	cert, pemCert, key := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().Add(time.Hour), false))
+	tlsCert := tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}
+
+	var (
+		mu    sync.Mutex
+		hosts []string
+	)
+	record := func(host, serverName string) {
+		mu.Lock()
+		defer mu.Unlock()
+		hosts = append(hosts, host+" "+serverName)
+	}
+	h2 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r.Host, r.TLS.ServerName)
+	}))
+	h2.EnableHTTP2 = true
+	h2.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	h2.StartTLS()
+	defer h2.Close()
+	h3 := newHTTP3TestServer(t, tlsCert, func(fields map[string]string, _ []byte) []byte {
+		record(fields[":authority"], "")
+		return appendHTTP3Frame(nil, http3FrameHeaders, appendQPACKField([]byte{0, 0}, ":status", "200"))
+	})
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", fmt.Sprintf(`h2="%s", h3="%s"`, h2.Listener.Addr(), strings.TrimPrefix(h3, "https://")))
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	ts.StartTLS()
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	origin := "localhost:" + port
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{Timeout: 5 * time.Second, HTTP: config.HTTPProbe{
+		IPProtocol:         "ip4",
+		IPProtocolFallback: true,
+		HTTPClientConfig:   pconfig.HTTPClientConfig{TLSConfig: pconfig.TLSConfig{CA: string(pemCert)}},
+		ValidateAltSvc:     config.AltSvcValidator{Enabled: true, ProbeAlternatives: true, FailIfBroken: true},
+	}}
+	if !ProbeHTTP(testCTX, "https://"+origin, module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected the alternatives to verify the certificate of the origin")
+	}
+	expected := []string{origin + " localhost", origin + " "}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Expected the alternatives to get the requests %q, got %q", expected, hosts)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// capturedHeaderMaxLength is the maximum length of the captured values,
+// which are truncated to keep the size of the series bounded.
+const capturedHeaderMaxLength = 128
+
+// captureHeaders exports the first value of the headers of the response, such
+// as request IDs, to correlate probes with the logs of the target.
+func captureHeaders(resp *http.Response, headers []string, registry *prometheus.Registry, logger *slog.Logger) {
+	headerInfoGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_header_info",
+		Help: "Contains the values of the captured response headers",
+	}, []string{"header", "value"})
+
+	registered := false
+	for _, header := range headers {
+		value := resp.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		value = truncateLabelValue(value, capturedHeaderMaxLength)
+		logger.Info("Captured response header", "header", header, "value", value)
+		if !registered {
+			registry.MustRegister(headerInfoGaugeVec)
+			registered = true
+		}
+		headerInfoGaugeVec.WithLabelValues(http.CanonicalHeaderKey(header), value).Set(1)
+	}
+}
+
+// truncateLabelValue truncates s to at most n bytes, without splitting a
+// UTF-8 character.
+func truncateLabelValue(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
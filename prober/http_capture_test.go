@@ -0,0 +1,77 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestCaptureHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "4bf92f3577b34da6")
+		w.Header().Set("X-Debug", strings.Repeat("é", capturedHeaderMaxLength))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+		IPProtocolFallback: true,
+		CaptureHeaders:     []string{"x-request-id", "X-Debug", "X-Missing"},
+	}}
+	// The headers are captured for failed probes.
+	if ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected the probe to fail")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_http_header_info" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var header, value string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "header":
+					header = l.GetValue()
+				case "value":
+					value = l.GetValue()
+				}
+			}
+			values[header] = value
+		}
+	}
+	if len(values) != 2 || values["X-Request-Id"] != "4bf92f3577b34da6" {
+		t.Fatalf("Unexpected captured headers %v", values)
+	}
+	if debug := values["X-Debug"]; debug != strings.Repeat("é", capturedHeaderMaxLength/2) {
+		t.Errorf("Expected X-Debug to be truncated to %d bytes, got %q", capturedHeaderMaxLength, debug)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+var bodyHashFuncs = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// bodyHashReader hashes the body of the response as it is read, so that
+// bodies that are not kept in memory can be hashed too.
+type bodyHashReader struct {
+	io.ReadCloser
+	hashes map[string]hash.Hash
+	eof    bool
+}
+
+func newBodyHashReader(body io.ReadCloser, httpConfig config.HTTPProbe) *bodyHashReader {
+	r := &bodyHashReader{ReadCloser: body, hashes: map[string]hash.Hash{}}
+	if httpConfig.BodyHash != "" {
+		r.hashes[httpConfig.BodyHash] = bodyHashFuncs[httpConfig.BodyHash]()
+	}
+	if httpConfig.FailIfBodySHA256NotEquals != "" {
+		r.hashes["sha256"] = sha256.New()
+	}
+	return r
+}
+
+func (r *bodyHashReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	for _, h := range r.hashes {
+		h.Write(p[:n])
+	}
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+func (r *bodyHashReader) sum(algorithm string) string {
+	return hex.EncodeToString(r.hashes[algorithm].Sum(nil))
+}
+
+// check exports the hash of the body and compares its SHA-256 digest with
+// the expected one. Bodies that were not read to the end, e.g. because the
+// connection broke, are not hashed.
+func (r *bodyHashReader) check(httpConfig config.HTTPProbe, registry *prometheus.Registry, logger *slog.Logger) bool {
+	if !r.eof {
+		logger.Error("Body was not read to the end, not hashing it")
+		return httpConfig.FailIfBodySHA256NotEquals == ""
+	}
+
+	if httpConfig.BodyHash != "" {
+		bodyHashGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_http_body_hash_info",
+			Help: "Contains the hash of the response body",
+		}, []string{"algorithm", "hash"})
+		registry.MustRegister(bodyHashGaugeVec)
+		bodyHashGaugeVec.WithLabelValues(httpConfig.BodyHash, r.sum(httpConfig.BodyHash)).Set(1)
+	}
+
+	if httpConfig.FailIfBodySHA256NotEquals != "" {
+		if sum := r.sum("sha256"); sum != httpConfig.FailIfBodySHA256NotEquals {
+			logger.Error("Body SHA-256 digest does not match", "sha256", sum, "expected", httpConfig.FailIfBodySHA256NotEquals)
+			return false
+		}
+	}
+	return true
+}
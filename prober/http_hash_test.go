@@ -0,0 +1,97 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestBodyHash(t *testing.T) {
+	const (
+		body = "release-1.2.3.tar.gz contents"
+		// printf 'release-1.2.3.tar.gz contents' | sha256sum
+		bodySHA256 = "b23467a1fd16b02ba38671df2c15e1d76542c5d6cad49d48b6b4807d3f73f568"
+	)
+
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		httpConfig     config.HTTPProbe
+		expectedResult bool
+		hash           map[string]string
+	}{
+		"md5": {
+			handler:        func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) },
+			httpConfig:     config.HTTPProbe{BodyHash: "md5"},
+			expectedResult: true,
+			// printf 'release-1.2.3.tar.gz contents' | md5sum
+			hash: map[string]string{"algorithm": "md5", "hash": "8b54e56a0b4863272f0d6271c942ff3b"},
+		},
+		"sha256 matches": {
+			handler:        func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) },
+			httpConfig:     config.HTTPProbe{BodyHash: "sha256", FailIfBodySHA256NotEquals: bodySHA256},
+			expectedResult: true,
+			hash:           map[string]string{"algorithm": "sha256", "hash": bodySHA256},
+		},
+		"tampered": {
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body + "!")) },
+			httpConfig: config.HTTPProbe{FailIfBodySHA256NotEquals: bodySHA256},
+		},
+		"truncated": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1000")
+				w.Write([]byte(body))
+			},
+			httpConfig: config.HTTPProbe{FailIfBodySHA256NotEquals: bodySHA256},
+		},
+		"empty body": {
+			handler:        func(w http.ResponseWriter, r *http.Request) {},
+			httpConfig:     config.HTTPProbe{BodyHash: "sha1"},
+			expectedResult: true,
+			hash:           map[string]string{"algorithm": "sha1", "hash": "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(test.handler)
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			test.httpConfig.IPProtocolFallback = true
+			module := config.Module{Timeout: time.Second, HTTP: test.httpConfig}
+			result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.hash != nil {
+				checkRegistryLabels(map[string]map[string]string{"probe_http_body_hash_info": test.hash}, mfs, t)
+			}
+		})
+	}
+}
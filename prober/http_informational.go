@@ -0,0 +1,55 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exportInformationalResponses exports the 1xx responses received before the
+// response of the round trip, such as the 103 Early Hints a CDN sends while
+// the origin prepares the response.
+func exportInformationalResponses(trace *roundTripTrace, registry *prometheus.Registry) {
+	earlyHintsGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_early_hints",
+		Help: "Number of 103 Early Hints responses received before the final response",
+	})
+	informationalGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_informational_responses",
+		Help: "Number of informational (1xx) responses received before the final response, by status code",
+	}, []string{"code"})
+	earlyHintDurationGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_early_hint_duration_seconds",
+		Help: "Time from the connection to the first 103 Early Hints response",
+	})
+	registry.MustRegister(earlyHintsGauge)
+
+	if len(trace.informational) == 0 {
+		return
+	}
+	registry.MustRegister(informationalGaugeVec)
+	for _, code := range trace.informational {
+		informationalGaugeVec.WithLabelValues(strconv.Itoa(code)).Inc()
+		if code == http.StatusEarlyHints {
+			earlyHintsGauge.Inc()
+		}
+	}
+	if !trace.firstEarlyHint.IsZero() && !trace.gotConn.IsZero() {
+		registry.MustRegister(earlyHintDurationGauge)
+		earlyHintDurationGauge.Set(trace.firstEarlyHint.Sub(trace.gotConn).Seconds())
+	}
+}
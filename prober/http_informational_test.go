@@ -0,0 +1,103 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestInformationalResponses(t *testing.T) {
+	tests := map[string]struct {
+		informational []int
+		expected      map[string]float64
+		unexpected    []string
+	}{
+		"none": {
+			expected:   map[string]float64{"probe_http_early_hints": 0},
+			unexpected: []string{"probe_http_informational_responses", "probe_http_early_hint_duration_seconds"},
+		},
+		"early hints": {
+			informational: []int{http.StatusEarlyHints, http.StatusEarlyHints},
+			expected:      map[string]float64{"probe_http_early_hints": 2, "probe_http_informational_responses": 2},
+		},
+		"processing": {
+			informational: []int{http.StatusProcessing},
+			expected:      map[string]float64{"probe_http_early_hints": 0, "probe_http_informational_responses": 1},
+			unexpected:    []string{"probe_http_early_hint_duration_seconds"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, code := range test.informational {
+					w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+					w.WriteHeader(code)
+				}
+				// The final response comes after the origin did some work.
+				time.Sleep(50 * time.Millisecond)
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("final response"))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback:         true,
+				FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("^final response$")},
+			}}
+			if !ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) {
+				t.Fatal("Expected the final response to be matched")
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+			metrics := map[string]*dto.MetricFamily{}
+			for _, mf := range mfs {
+				metrics[mf.GetName()] = mf
+			}
+			for _, name := range test.unexpected {
+				if _, ok := metrics[name]; ok {
+					t.Errorf("Unexpected metric %s", name)
+				}
+			}
+			// The processing phase lasts until the final response, not
+			// until the first informational one.
+			for _, m := range metrics["probe_http_duration_seconds"].GetMetric() {
+				if m.GetLabel()[0].GetValue() == "processing" && m.GetGauge().GetValue() < 0.05 {
+					t.Errorf("Expected the processing phase to include the wait for the final response, got %v", m.GetGauge().GetValue())
+				}
+			}
+			if test.informational != nil && test.informational[0] == http.StatusEarlyHints {
+				if d := metrics["probe_http_early_hint_duration_seconds"].GetMetric()[0].GetGauge().GetValue(); d <= 0 || d >= 0.05 {
+					t.Errorf("Expected the early hint before the final response, got %v", d)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// matchJSON evaluates the rules of fail_if_body_json_not_matches against the
+// body decoded as JSON. A body that is not JSON fails all of them.
+func matchJSON(body []byte, matches []config.JSONMatch, results *validatorResults, logger *slog.Logger) bool {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		logger.Error("Error decoding the body as JSON", "err", err)
+		for _, m := range matches {
+			results.record(m.Name, false)
+		}
+		return false
+	}
+
+	success := true
+	for _, m := range matches {
+		values := m.JSONPath.Select(document)
+		matched := jsonValuesMatch(m, values)
+		if !matched {
+			logger.Error("Body did not match JSON rule", "json_path", m.JSONPath.String(), "values", len(values))
+			success = false
+		}
+		results.record(m.Name, matched)
+	}
+	return success
+}
+
+// jsonValuesMatch reports whether one of the selected values satisfies the
+// rule. Strings are compared as is, other values in their JSON encoding.
+func jsonValuesMatch(m config.JSONMatch, values []interface{}) bool {
+	for _, value := range values {
+		var matched bool
+		switch {
+		case m.Regexp.Regexp != nil:
+			matched = m.Regexp.MatchString(formatJSONValue(value))
+		case m.Value != "":
+			matched = formatJSONValue(value) == m.Value
+		case m.MinLength > 0:
+			length, ok := jsonLength(value)
+			matched = ok && length >= m.MinLength
+		default:
+			matched = value != nil
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLength returns the number of elements of an array, members of an
+// object or characters of a string.
+func jsonLength(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	case string:
+		return utf8.RuneCountInString(v), true
+	}
+	return 0, false
+}
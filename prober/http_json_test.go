@@ -0,0 +1,122 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestFailIfBodyJSONNotMatches(t *testing.T) {
+	const body = `{"status": "ok", "healthy": true, "version": 3, "items": [{"name": "a"}, {"name": "b"}], "error": null}`
+
+	tests := map[string]struct {
+		body           string
+		matches        []config.JSONMatch
+		expectedResult bool
+	}{
+		"value": {
+			matches:        []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.status"), Value: "ok"}},
+			expectedResult: true,
+		},
+		"value differs": {
+			matches: []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.status"), Value: "degraded"}},
+		},
+		"non string values": {
+			matches: []config.JSONMatch{
+				{JSONPath: config.MustNewJSONPath("$.healthy"), Value: "true"},
+				{JSONPath: config.MustNewJSONPath("$.version"), Regexp: config.MustNewRegexp("^[3-9]$")},
+			},
+			expectedResult: true,
+		},
+		"any selected value": {
+			matches:        []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.items[*].name"), Value: "b"}},
+			expectedResult: true,
+		},
+		"min length": {
+			matches:        []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.items"), MinLength: 2}},
+			expectedResult: true,
+		},
+		"too short": {
+			matches: []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.items"), MinLength: 3}},
+		},
+		"present": {
+			matches:        []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.items[0]")}},
+			expectedResult: true,
+		},
+		"null": {
+			matches: []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.error")}},
+		},
+		"missing": {
+			matches: []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$.uptime")}},
+		},
+		"not json": {
+			body:    "<html>ok</html>",
+			matches: []config.JSONMatch{{JSONPath: config.MustNewJSONPath("$")}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.body != "" {
+					w.Write([]byte(test.body))
+					return
+				}
+				w.Write([]byte(body))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, FailIfBodyJSONNotMatches: test.matches}}
+			result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestFailIfBodyJSONNotMatchesNamed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "degraded"}`))
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, FailIfBodyJSONNotMatches: []config.JSONMatch{
+		{JSONPath: config.MustNewJSONPath("$.status"), Value: "ok", Name: "status_ok"},
+	}}}
+	if ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected the probe to fail")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{"probe_validator_success": 0}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{"probe_validator_success": {"name": "status_ok"}}, mfs, t)
+}
@@ -0,0 +1,353 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// legacyMaxHeaderLines bounds the header lines read by the lenient parser.
+const legacyMaxHeaderLines = 1000
+
+// legacyRoundTripper makes HTTP/1.x requests over a new connection each,
+// for the servers of appliances the transport of net/http cannot talk to. It
+// can send HTTP/1.0 requests and parse malformed responses leniently. If raw
+// is set, it is sent instead of the request.
+type legacyRoundTripper struct {
+	tlsConfig   *tls.Config
+	dialContext pconfig.DialContextFunc
+	auth        func(*http.Request) error
+	http10      bool
+	lenient     bool
+	raw         []byte
+}
+
+func newLegacyRoundTripper(httpClientConfig pconfig.HTTPClientConfig, httpConfig config.HTTPProbe, dialContext pconfig.DialContextFunc, keyLog io.Writer) (*legacyRoundTripper, error) {
+	tlsConfig, err := pconfig.NewTLSConfig(&httpClientConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.KeyLogWriter = keyLog
+	if dialContext == nil {
+		dialContext = (&net.Dialer{}).DialContext
+	}
+	var raw []byte
+	if httpConfig.RawRequest != "" {
+		raw = []byte(httpConfig.RawRequest)
+	} else if httpConfig.RawRequestFile != "" {
+		raw, err = os.ReadFile(httpConfig.RawRequestFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &legacyRoundTripper{
+		tlsConfig:   tlsConfig,
+		dialContext: dialContext,
+		auth:        legacyAuth(httpClientConfig),
+		http10:      httpConfig.ForceHTTP10,
+		lenient:     httpConfig.LenientParsing || raw != nil,
+		raw:         raw,
+	}, nil
+}
+
+// legacyAuth returns a function adding the basic_auth or authorization of
+// the configuration to requests, which the round tripper of the HTTP client
+// configuration would otherwise do.
+func legacyAuth(c pconfig.HTTPClientConfig) func(*http.Request) error {
+	readSecret := func(secret pconfig.Secret, file string) (string, error) {
+		if file == "" {
+			return string(secret), nil
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return func(req *http.Request) error {
+		switch {
+		case c.BasicAuth != nil:
+			password, err := readSecret(c.BasicAuth.Password, c.BasicAuth.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("unable to read basic auth password file %s: %w", c.BasicAuth.PasswordFile, err)
+			}
+			req.SetBasicAuth(c.BasicAuth.Username, password)
+		case c.Authorization != nil:
+			credentials, err := readSecret(c.Authorization.Credentials, c.Authorization.CredentialsFile)
+			if err != nil {
+				return fmt.Errorf("unable to read authorization credentials file %s: %w", c.Authorization.CredentialsFile, err)
+			}
+			req.Header.Set("Authorization", c.Authorization.Type+" "+credentials)
+		}
+		return nil
+	}
+}
+
+// writeLegacyRequest writes the request with a Content-Length rather than a
+// chunked body, which HTTP/1.0 servers do not support.
+func writeLegacyRequest(w io.Writer, req *http.Request, http10 bool) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	proto := "HTTP/1.1"
+	if http10 {
+		proto = "HTTP/1.0"
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s %s %s\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), proto, host)
+	header := req.Header.Clone()
+	header.Set("Connection", "close")
+	if len(body) > 0 || req.Method == http.MethodPost || req.Method == http.MethodPut {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	if err := header.Write(bw); err != nil {
+		return err
+	}
+	bw.WriteString("\r\n")
+	bw.Write(body)
+	return bw.Flush()
+}
+
+// parseLenientStatusLine parses status lines such as "HTTP/1.0 200",
+// "HTTP 200 OK" or "HTTP/1.1 200OK", which http.ReadResponse rejects.
+func parseLenientStatusLine(line string) (proto string, code int, status string, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.HasPrefix(strings.ToUpper(fields[0]), "HTTP") {
+		return "", 0, "", fmt.Errorf("malformed status line %q", line)
+	}
+	proto = strings.ToUpper(fields[0])
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		proto = "HTTP/1.0"
+	}
+	digits := fields[1]
+	if len(digits) > 3 {
+		digits = digits[:3]
+	}
+	code, err = strconv.Atoi(digits)
+	if err != nil || code < 100 || code > 999 {
+		return "", 0, "", fmt.Errorf("malformed status code in status line %q", line)
+	}
+	reason := strings.TrimSpace(strings.TrimPrefix(strings.Join(fields[1:], " "), digits))
+	if reason == "" {
+		reason = http.StatusText(code)
+	}
+	return proto, code, strconv.Itoa(code) + " " + reason, nil
+}
+
+// readLenientResponse reads a response, tolerating bare LF line endings,
+// malformed status lines and header lines, and bodies announced as chunked
+// that are not.
+func readLenientResponse(br *bufio.Reader, req *http.Request) (*http.Response, error) {
+	readLine := func() (string, error) {
+		line, err := br.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	line, err := readLine()
+	// Some servers send empty lines before the status line.
+	for err == nil && strings.TrimSpace(line) == "" {
+		line, err = readLine()
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := &http.Response{Request: req, Header: http.Header{}, ContentLength: -1}
+	resp.Proto, resp.StatusCode, resp.Status, err = parseLenientStatusLine(line)
+	if err != nil {
+		return nil, err
+	}
+	resp.ProtoMajor, resp.ProtoMinor, _ = http.ParseHTTPVersion(resp.Proto)
+
+	var last string
+	for i := 0; ; i++ {
+		if i == legacyMaxHeaderLines {
+			return nil, errors.New("too many header lines")
+		}
+		line, err := readLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		if (line[0] == ' ' || line[0] == '\t') && last != "" {
+			values := resp.Header[last]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			// Header lines without a name are ignored.
+			continue
+		}
+		last = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		resp.Header.Add(last, strings.TrimSpace(value))
+	}
+
+	var body io.Reader = br
+	if length, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil && length >= 0 {
+		resp.ContentLength = length
+		body = io.LimitReader(br, length)
+	}
+	if strings.EqualFold(resp.Header.Get("Transfer-Encoding"), "chunked") {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := io.ReadAll(httputil.NewChunkedReader(bytes.NewReader(raw)))
+		if err == nil {
+			raw = decoded
+		}
+		resp.Header.Del("Transfer-Encoding")
+		body = bytes.NewReader(raw)
+	}
+	resp.Body = io.NopCloser(body)
+	return resp, nil
+}
+
+// legacyBody closes the connection of the response with its body.
+type legacyBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+func (b *legacyBody) Close() error {
+	b.stop()
+	b.ReadCloser.Close()
+	return b.conn.Close()
+}
+
+// RoundTrip implements the http.RoundTripper interface. It reports the
+// progress of the request to the httptrace.ClientTrace of its context like
+// the transport of net/http.
+func (rt *legacyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = &httptrace.ClientTrace{}
+	}
+	req = req.Clone(ctx)
+	if err := rt.auth(req); err != nil {
+		return nil, err
+	}
+
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		port := "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(req.URL.Hostname(), port)
+	}
+	if trace.ConnectStart != nil {
+		trace.ConnectStart("tcp", addr)
+	}
+	conn, err := rt.dialContext(ctx, "tcp", addr)
+	if trace.ConnectDone != nil {
+		trace.ConnectDone("tcp", addr, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	var state *tls.ConnectionState
+	if req.URL.Scheme == "https" {
+		tlsConfig := rt.tlsConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = req.URL.Hostname()
+		}
+		if trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		err := tlsConn.HandshakeContext(ctx)
+		cs := tlsConn.ConnectionState()
+		if trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(cs, err)
+		}
+		if err != nil {
+			stop()
+			conn.Close()
+			return nil, err
+		}
+		conn, state = tlsConn, &cs
+	}
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+	}
+
+	if rt.raw != nil {
+		_, err = conn.Write(rt.raw)
+	} else {
+		err = writeLegacyRequest(conn, req, rt.http10)
+	}
+	if trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	if _, err := br.Peek(1); err == nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	var resp *http.Response
+	if rt.lenient {
+		resp, err = readLenientResponse(br, req)
+	} else {
+		resp, err = http.ReadResponse(br, req)
+	}
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, err
+	}
+	resp.TLS = state
+	resp.Body = &legacyBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+	return resp, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startRawHTTPServer answers every connection with response once it has read
+// the request headers, and sends the request line it got to lines.
+func startRawHTTPServer(t *testing.T, response string, lines chan<- string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			br := bufio.NewReader(conn)
+			for i := 0; ; i++ {
+				line, err := br.ReadString('\n')
+				if err != nil || line == "\r\n" {
+					break
+				}
+				if i == 0 {
+					lines <- line
+				}
+			}
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+	return "http://" + ln.Addr().String()
+}
+
+func TestHTTPLegacyServers(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		probe       config.HTTPProbe
+		success     bool
+		requestLine string
+		expected    map[string]float64
+	}{
+		{
+			name:        "status line without reason and bare LF",
+			response:    "HTTP/1.0 200\nServer: appliance\nnot a header\n\nstatus: ok",
+			probe:       config.HTTPProbe{ForceHTTP10: true, LenientParsing: true, FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("status: ok")}},
+			success:     true,
+			requestLine: "GET / HTTP/1.0\r\n",
+			expected:    map[string]float64{"probe_http_status_code": 200, "probe_http_version": 1.0},
+		},
+		{
+			name:        "strict parsing",
+			response:    "HTTP/1.0 200\nServer: appliance\nnot a header\n\nstatus: ok",
+			probe:       config.HTTPProbe{ForceHTTP10: true},
+			requestLine: "GET / HTTP/1.0\r\n",
+		},
+		{
+			name:        "status code without space",
+			response:    "HTTP 503Busy\r\n\r\n",
+			probe:       config.HTTPProbe{LenientParsing: true, ValidStatusCodes: []int{503}},
+			success:     true,
+			requestLine: "GET / HTTP/1.1\r\n",
+			expected:    map[string]float64{"probe_http_status_code": 503},
+		},
+		{
+			name:        "body announced as chunked",
+			response:    "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nstatus: ok",
+			probe:       config.HTTPProbe{LenientParsing: true, FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("^status: ok$")}},
+			success:     true,
+			requestLine: "GET / HTTP/1.1\r\n",
+		},
+		{
+			name:        "chunked body",
+			response:    "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\na\r\nstatus: ok\r\n0\r\n\r\n",
+			probe:       config.HTTPProbe{LenientParsing: true, FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("^status: ok$")}},
+			success:     true,
+			requestLine: "GET / HTTP/1.1\r\n",
+		},
+		{
+			name:     "raw request",
+			response: "HTTP/1.1 403 Forbidden\nX-Frontend: haproxy\n\nblocked",
+			probe: config.HTTPProbe{
+				RawRequest:                   "GET /admin HTTP/1.1\r\nHost: internal\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+				ValidStatusCodes:             []int{403},
+				FailIfHeaderNotMatchesRegexp: []config.HeaderMatch{{Header: "X-Frontend", Regexp: config.MustNewRegexp("haproxy")}},
+				FailIfBodyNotMatchesRegexp:   []config.Regexp{config.MustNewRegexp("^blocked$")},
+			},
+			success:     true,
+			requestLine: "GET /admin HTTP/1.1\r\n",
+			expected:    map[string]float64{"probe_http_status_code": 403},
+		},
+		{
+			name:        "raw request not following redirects",
+			response:    "HTTP/1.1 302 Found\r\nLocation: /elsewhere\r\nContent-Length: 0\r\n\r\n",
+			probe:       config.HTTPProbe{RawRequest: "GET / HTTP/1.0\r\n\r\n", ValidStatusCodes: []int{302}},
+			success:     true,
+			requestLine: "GET / HTTP/1.0\r\n",
+			expected:    map[string]float64{"probe_http_status_code": 302},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := make(chan string, 1)
+			target := startRawHTTPServer(t, test.response, lines)
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			test.probe.IPProtocol = "ip4"
+			test.probe.HTTPClientConfig.FollowRedirects = true
+			if success := ProbeHTTP(testCTX, target, config.Module{Timeout: time.Second, HTTP: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if line := <-lines; line != test.requestLine {
+				t.Errorf("Expected request line %q, got %q", test.requestLine, line)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
+
+func TestHTTPForceHTTP10(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Proto != "HTTP/1.0" || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	probe := config.HTTPProbe{IPProtocol: "ip4", ForceHTTP10: true}
+	probe.HTTPClientConfig.BasicAuth = &pconfig.BasicAuth{Username: "admin", Password: "secret"}
+	if !ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: probe}, prometheus.NewRegistry(), promslog.NewNopLogger()) {
+		t.Fatal("Expected HTTP/1.0 request with basic auth to succeed")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// roundTripLimits are the timeouts and limits of the round trips of a probe,
+// 0 meaning none. The HTTP client library does not allow to configure those
+// of its transport, so they are enforced by the transport of the probe.
+type roundTripLimits struct {
+	tlsHandshakeTimeout    time.Duration
+	responseHeaderTimeout  time.Duration
+	expectContinueTimeout  time.Duration
+	maxResponseHeaderBytes int64
+}
+
+func (l roundTripLimits) timeouts() bool {
+	return l.tlsHandshakeTimeout > 0 || l.responseHeaderTimeout > 0
+}
+
+// startTimer cancels the current round trip if the phase is not over within
+// the timeout. It must be called with t.mu held.
+func (t *transport) startTimer(timer **time.Timer, timeout time.Duration, phase string) {
+	trace := t.current
+	if timeout <= 0 || trace.cancel == nil {
+		return
+	}
+	*timer = time.AfterFunc(timeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if trace.limitErr != nil || trace.headersDone {
+			return
+		}
+		trace.limitErr = fmt.Errorf("%s timed out after %s", phase, timeout)
+		trace.cancel(trace.limitErr)
+	})
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// gotHeaders ends the phases of a round trip limited until its response
+// headers are received, and returns the limit it exceeded, if any.
+func (t *transport) gotHeaders(trace *roundTripTrace) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace.headersDone = true
+	trace.readingHeaders = false
+	// The first byte was the one of the first informational response, the
+	// response itself starts now.
+	if len(trace.informational) > 0 {
+		trace.responseStart = time.Now()
+	}
+	stopTimer(trace.tlsTimer)
+	stopTimer(trace.headerTimer)
+	if trace.continueGate != nil {
+		trace.continueGate.open(errNoContinue)
+	}
+	return trace.limitErr
+}
+
+// errNoContinue is returned by the body of a request whose server answered
+// with a final response instead of 100 Continue, so that it is not sent.
+var errNoContinue = errors.New("server answered without 100 Continue, not sending the request body")
+
+// expectContinue makes the body of a request with an "Expect: 100-continue"
+// header wait for the 100 Continue response at most the timeout of the
+// probe. The HTTP client library would wait its own, so the header is moved
+// to a key it does not look up, but still sends.
+func (t *transport) expectContinue(req *http.Request, trace *roundTripTrace) *http.Request {
+	if t.limits.expectContinueTimeout <= 0 || req.Body == nil || req.Body == http.NoBody ||
+		!strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.Header["expect"] = req.Header.Values("Expect")
+	req.Header.Del("Expect")
+	trace.continueGate = &continueGate{
+		ReadCloser: req.Body,
+		ctx:        req.Context(),
+		timeout:    t.limits.expectContinueTimeout,
+		ready:      make(chan struct{}),
+	}
+	req.Body = trace.continueGate
+	return req
+}
+
+// continueGate holds the body of a request back until it is opened by the
+// 100 Continue response, or its timeout elapses.
+type continueGate struct {
+	io.ReadCloser
+	ctx     context.Context
+	timeout time.Duration
+	waited  bool
+
+	once  sync.Once
+	ready chan struct{}
+	err   error
+}
+
+// open lets the body be read, or fail with err if not nil.
+func (g *continueGate) open(err error) {
+	g.once.Do(func() {
+		g.err = err
+		close(g.ready)
+	})
+}
+
+func (g *continueGate) Read(b []byte) (int, error) {
+	if !g.waited {
+		g.waited = true
+		timer := time.NewTimer(g.timeout)
+		defer timer.Stop()
+		select {
+		case <-g.ready:
+		case <-timer.C:
+			g.open(nil)
+		case <-g.ctx.Done():
+			g.open(context.Cause(g.ctx))
+		}
+		<-g.ready
+	}
+	if g.err != nil {
+		return 0, g.err
+	}
+	return g.ReadCloser.Read(b)
+}
+
+func (g *continueGate) Close() error {
+	g.open(net.ErrClosed)
+	return g.ReadCloser.Close()
+}
+
+// dialContext dials connections counting the bytes of the response headers.
+func (t *transport) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &headerLimitConn{Conn: conn, t: t}, nil
+}
+
+// countHeaderBytes counts bytes received while the current round trip waits
+// for its response headers. The count is approximate, as it includes the
+// overhead of TLS and the start of the body read along with the headers.
+func (t *transport) countHeaderBytes(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := t.current
+	if trace == nil || !trace.readingHeaders {
+		return nil
+	}
+	trace.headerBytes += int64(n)
+	if trace.headerBytes > t.limits.maxResponseHeaderBytes {
+		trace.limitErr = fmt.Errorf("response headers exceed %d bytes", t.limits.maxResponseHeaderBytes)
+		return trace.limitErr
+	}
+	return nil
+}
+
+// headerLimitConn fails reads once the response headers exceed their limit.
+type headerLimitConn struct {
+	net.Conn
+	t *transport
+}
+
+func (c *headerLimitConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if limitErr := c.t.countHeaderBytes(n); limitErr != nil {
+		return n, limitErr
+	}
+	return n, err
+}
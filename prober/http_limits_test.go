@@ -0,0 +1,215 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func probeHTTPWithLimits(t *testing.T, target string, httpConfig config.HTTPProbe) (bool, time.Duration) {
+	t.Helper()
+	testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	httpConfig.IPProtocolFallback = true
+	start := time.Now()
+	success := ProbeHTTP(testCTX, target, config.Module{Timeout: 5 * time.Second, HTTP: httpConfig}, prometheus.NewRegistry(), promslog.NewNopLogger())
+	return success, time.Since(start)
+}
+
+func TestHTTPTLSHandshakeTimeout(t *testing.T) {
+	// The listener accepts connections but never answers the handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	success, duration := probeHTTPWithLimits(t, "https://"+ln.Addr().String(), config.HTTPProbe{TLSHandshakeTimeout: 100 * time.Millisecond})
+	if success {
+		t.Fatal("Expected the probe to fail")
+	}
+	if duration > 2*time.Second {
+		t.Errorf("Expected the handshake to time out after 100ms, took %s", duration)
+	}
+}
+
+func TestHTTPResponseHeaderTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	if success, _ := probeHTTPWithLimits(t, ts.URL, config.HTTPProbe{ResponseHeaderTimeout: time.Second}); !success {
+		t.Error("Expected the probe to succeed within the response header timeout")
+	}
+	if success, _ := probeHTTPWithLimits(t, ts.URL, config.HTTPProbe{ResponseHeaderTimeout: 100 * time.Millisecond}); success {
+		t.Error("Expected the probe to fail after the response header timeout")
+	}
+}
+
+func TestHTTPMaxResponseHeaderBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Padding", strings.Repeat("x", 64<<10))
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	if success, _ := probeHTTPWithLimits(t, ts.URL, config.HTTPProbe{MaxResponseHeaderBytes: units.MiB}); !success {
+		t.Error("Expected the probe to succeed with headers below the limit")
+	}
+	if success, _ := probeHTTPWithLimits(t, ts.URL, config.HTTPProbe{MaxResponseHeaderBytes: 16 * units.KiB}); success {
+		t.Error("Expected the probe to fail with headers above the limit")
+	}
+}
+
+func TestHTTPExpectContinueTimeout(t *testing.T) {
+	// The server reads the request body sending a 100 Continue first, or
+	// not, and measures how long it waited for the body after the headers.
+	serve := func(t *testing.T, sendContinue bool, waited chan<- time.Duration) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			req, err := http.ReadRequest(bufio.NewReader(conn))
+			if err != nil {
+				return
+			}
+			start := time.Now()
+			if sendContinue && req.Header.Get("Expect") == "100-continue" {
+				conn.Write([]byte("HTTP/1.1 100 Continue\r\n\r\n"))
+			}
+			io.ReadAll(req.Body)
+			waited <- time.Since(start)
+			conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+		}()
+		return "http://" + ln.Addr().String()
+	}
+	httpConfig := config.HTTPProbe{
+		Method:                "POST",
+		Headers:               map[string]string{"Expect": "100-continue"},
+		Body:                  "hello",
+		ExpectContinueTimeout: 1500 * time.Millisecond,
+	}
+
+	// Without 100 Continue the body is sent once the timeout elapsed, later
+	// than the 1s of the HTTP client library.
+	waited := make(chan time.Duration, 1)
+	if success, _ := probeHTTPWithLimits(t, serve(t, false, waited), httpConfig); !success {
+		t.Fatal("Expected the probe to succeed")
+	}
+	if got := <-waited; got < 1400*time.Millisecond {
+		t.Errorf("Expected the body to be sent after the 1.5s timeout, got it after %s", got)
+	}
+
+	// With 100 Continue it is sent right away.
+	if success, _ := probeHTTPWithLimits(t, serve(t, true, waited), httpConfig); !success {
+		t.Fatal("Expected the probe to succeed")
+	}
+	if got := <-waited; got > time.Second {
+		t.Errorf("Expected the body to be sent on 100 Continue, got it after %s", got)
+	}
+}
+
+func TestHTTPEnableKeepAlives(t *testing.T) {
+	var connections atomic.Int64
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/done", http.StatusFound)
+		}
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	for _, test := range []struct {
+		enableKeepAlives bool
+		connections      int64
+	}{
+		{false, 2},
+		{true, 1},
+	} {
+		connections.Store(0)
+		if success, _ := probeHTTPWithLimits(t, ts.URL, config.HTTPProbe{EnableKeepAlives: test.enableKeepAlives, HTTPClientConfig: pconfig.DefaultHTTPClientConfig}); !success {
+			t.Fatal("Expected the probe to succeed")
+		}
+		if got := connections.Load(); got != test.connections {
+			t.Errorf("Expected %d connections with enable_keep_alives %v, got %d", test.connections, test.enableKeepAlives, got)
+		}
+	}
+}
+
+func TestHTTPWarmupRequests(t *testing.T) {
+	var connections, requests atomic.Int64
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "ping" {
+			t.Errorf("Expected every request to have the body of the module, got %q", body)
+		}
+		requests.Add(1)
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	httpConfig := config.HTTPProbe{Method: "POST", Body: "ping", WarmupRequests: 2, HTTPClientConfig: pconfig.DefaultHTTPClientConfig}
+	if success, _ := probeHTTPWithLimits(t, ts.URL, httpConfig); !success {
+		t.Fatal("Expected the probe to succeed")
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected 2 warm-up requests and the probe request, got %d requests", got)
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("Expected the probe request to reuse the connection of the warm-up requests, got %d connections", got)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// readLongPollBody reads the first bytes of the body of a long-poll response,
+// which the validations of the body see. The rest of the body is left unread,
+// as the server only sends it once it has something to tell.
+func readLongPollBody(body io.Reader, c config.LongPoll) ([]byte, error) {
+	if c.FirstBytes == 0 {
+		return nil, nil
+	}
+	return io.ReadAll(io.LimitReader(body, int64(c.FirstBytes)))
+}
+
+// exportLongPollTimes exports the time to the headers of the response of the
+// round trip apart from the time to the bytes of the body that were read.
+func exportLongPollTimes(trace *roundTripTrace, bodyBytes int64, registry *prometheus.Registry) {
+	headersGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_long_poll_headers_duration_seconds",
+		Help: "Time from the start of the request to the response headers",
+	})
+	bodyGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_long_poll_body_duration_seconds",
+		Help: "Time from the response headers to the end of the part of the body that was read",
+	})
+	bodyBytesGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_http_long_poll_body_bytes",
+		Help: "Number of bytes of the body read before the probe stopped",
+	})
+	if trace.responseStart.IsZero() {
+		return
+	}
+	registry.MustRegister(headersGauge, bodyGauge, bodyBytesGauge)
+	headersGauge.Set(trace.responseStart.Sub(trace.start).Seconds())
+	bodyGauge.Set(trace.end.Sub(trace.responseStart).Seconds())
+	bodyBytesGauge.Set(float64(bodyBytes))
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestLongPoll(t *testing.T) {
+	tests := map[string]struct {
+		longPoll config.LongPoll
+		regexps  []config.Regexp
+		success  bool
+		expected map[string]float64
+	}{
+		"disabled": {},
+		"headers": {
+			longPoll: config.LongPoll{Enabled: true},
+			success:  true,
+			expected: map[string]float64{"probe_http_long_poll_body_bytes": 0},
+		},
+		"first bytes": {
+			longPoll: config.LongPoll{Enabled: true, FirstBytes: 6},
+			regexps:  []config.Regexp{config.MustNewRegexp("^data: $")},
+			success:  true,
+			expected: map[string]float64{"probe_http_long_poll_body_bytes": 6},
+		},
+		"first bytes not sent": {
+			longPoll: config.LongPoll{Enabled: true, FirstBytes: 100},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("data: "))
+				w.(http.Flusher).Flush()
+				// The server holds the request until it has something to
+				// send, which it never has.
+				<-r.Context().Done()
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback:         true,
+				LongPoll:                   test.longPoll,
+				FailIfBodyNotMatchesRegexp: test.regexps,
+			}}
+			if ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) != test.success {
+				t.Fatalf("Expected success %t", test.success)
+			}
+			if !test.success {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+			for _, mf := range mfs {
+				if mf.GetName() == "probe_http_long_poll_headers_duration_seconds" && mf.GetMetric()[0].GetGauge().GetValue() <= 0 {
+					t.Errorf("Expected the time to the headers, got %v", mf.GetMetric()[0].GetGauge().GetValue())
+				}
+			}
+		})
+	}
+}
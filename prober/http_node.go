@@ -0,0 +1,74 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// identifyNode exports the identifier of the anycast or CDN node that served
+// the response, so that failures can be attributed to a node.
+func identifyNode(resp *http.Response, body []byte, c config.NodeIdentification, registry *prometheus.Registry, logger *slog.Logger) {
+	nodeInfoGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_node_info",
+		Help: "Contains the identifier of the node that served the response",
+	}, []string{"node"})
+
+	var node string
+	if c.Header != "" {
+		node = nodeFromHeader(resp.Header.Values(c.Header), c.HeaderRegexp)
+	} else if body != nil {
+		node = nodeFromMatch(c.BodyRegexp, string(body))
+	}
+	if node == "" {
+		logger.Info("Could not identify the node that served the response")
+		return
+	}
+	logger.Info("Response served by node", "node", node)
+	registry.MustRegister(nodeInfoGaugeVec)
+	nodeInfoGaugeVec.WithLabelValues(node).Set(1)
+}
+
+func nodeFromHeader(values []string, re config.Regexp) string {
+	if re.Regexp == nil {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	for _, value := range values {
+		if node := nodeFromMatch(re, value); node != "" {
+			return node
+		}
+	}
+	return ""
+}
+
+// nodeFromMatch returns the first group of the regexp if it has one, and the
+// whole match otherwise.
+func nodeFromMatch(re config.Regexp, s string) string {
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestIdentifyNode(t *testing.T) {
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		identification config.NodeIdentification
+		expectedResult bool
+		node           string
+	}{
+		"header": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Served-By", "pop-ams1")
+			},
+			identification: config.NodeIdentification{Header: "X-Served-By"},
+			expectedResult: true,
+			node:           "pop-ams1",
+		},
+		"header regexp": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Served-By", "shield-lhr")
+				w.Header().Add("X-Served-By", "cache-fra19146-FRA")
+			},
+			identification: config.NodeIdentification{Header: "X-Served-By", HeaderRegexp: config.MustNewRegexp("^cache-([a-z0-9]+)")},
+			expectedResult: true,
+			node:           "fra19146",
+		},
+		"failed probe": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte("<p>Served by edge-07</p>"))
+			},
+			identification: config.NodeIdentification{BodyRegexp: config.MustNewRegexp("edge-[0-9]+")},
+			node:           "edge-07",
+		},
+		"no node": {
+			handler:        func(w http.ResponseWriter, r *http.Request) {},
+			identification: config.NodeIdentification{Header: "X-Served-By"},
+			expectedResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(test.handler)
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, IdentifyNode: test.identification}}
+			result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.node == "" {
+				for _, mf := range mfs {
+					if mf.GetName() == "probe_http_node_info" {
+						t.Fatalf("Unexpected node %s", mf.GetMetric()[0].GetLabel()[0].GetValue())
+					}
+				}
+				return
+			}
+			checkRegistryLabels(map[string]map[string]string{"probe_http_node_info": {"node": test.node}}, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// probeHTTPSamples sends the requests of a module with samples set one after
+// the other, within the timeout of the probe. The first request exports the
+// usual metrics, and all of them the spread of their durations, so that
+// backends answering part of the requests slowly or with errors show within
+// a single probe. The probe succeeds if all requests do.
+func probeHTTPSamples(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		samplesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_samples",
+			Help: "The number of requests sent by the probe",
+		})
+		succeededGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_samples_succeeded",
+			Help: "The number of requests of the probe that succeeded",
+		})
+		stddevGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_sample_duration_stddev_seconds",
+			Help: "Standard deviation of the durations of the requests of the probe",
+		})
+		p95Gauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_sample_duration_p95_seconds",
+			Help: "Estimate of the 95th percentile of the durations of the requests of the probe",
+		})
+	)
+
+	samples := module.HTTP.Samples
+	module.HTTP.Samples = 0
+	durations := make([]float64, 0, samples)
+	succeeded := 0
+	for i := 0; i < samples && ctx.Err() == nil; i++ {
+		sampleRegistry := registry
+		if i > 0 {
+			sampleRegistry = prometheus.NewRegistry()
+		}
+		start := time.Now()
+		if ProbeHTTP(ctx, target, module, sampleRegistry, logger.With("sample", i)) {
+			succeeded++
+		}
+		durations = append(durations, time.Since(start).Seconds())
+	}
+	if len(durations) < samples {
+		logger.Error("Timeout reached before all samples were taken", "samples", len(durations), "expected_samples", samples)
+	}
+
+	registry.MustRegister(samplesGauge, succeededGauge, stddevGauge, p95Gauge)
+	samplesGauge.Set(float64(len(durations)))
+	succeededGauge.Set(float64(succeeded))
+	stddevGauge.Set(stddev(durations))
+	p95Gauge.Set(percentile(durations, 0.95))
+	return succeeded == samples
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestHTTPSamples(t *testing.T) {
+	tests := []struct {
+		name      string
+		failEvery int64
+		success   bool
+		expected  map[string]float64
+	}{
+		{
+			name:     "healthy",
+			success:  true,
+			expected: map[string]float64{"probe_http_samples": 4, "probe_http_samples_succeeded": 4, "probe_http_status_code": 200},
+		},
+		{
+			name:      "flapping",
+			failEvery: 2,
+			expected:  map[string]float64{"probe_http_samples": 4, "probe_http_samples_succeeded": 2, "probe_http_status_code": 200},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if n := requests.Add(1); test.failEvery > 0 && n%test.failEvery == 0 {
+					w.WriteHeader(http.StatusBadGateway)
+				}
+			}))
+			defer ts.Close()
+
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, Samples: 4}}
+			if success := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if requests.Load() != 4 {
+				t.Fatalf("Expected 4 requests, got %d", requests.Load())
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// probeSignature returns the value of the signature header of a request made
+// at now: "t=<unix timestamp>,kid=<key id>,sig=<hex HMAC-SHA256>". The
+// timestamp, method, host and request URI are signed, separated by newlines,
+// so that a signature cannot be replayed for another request or much later.
+func probeSignature(key []byte, keyID string, req *http.Request, now time.Time) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{timestamp, req.Method, host, req.URL.RequestURI()}, "\n")))
+	value := "t=" + timestamp
+	if keyID != "" {
+		value += ",kid=" + keyID
+	}
+	return value + ",sig=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// signingRoundTripper adds the probe signature to every request, including
+// the redirects followed, each signed for its own URL.
+type signingRoundTripper struct {
+	rt  http.RoundTripper
+	c   config.ProbeSignature
+	key []byte
+}
+
+func newSigningRoundTripper(rt http.RoundTripper, c config.ProbeSignature) (*signingRoundTripper, error) {
+	key := []byte(c.Key)
+	if c.KeyFile != "" {
+		b, err := os.ReadFile(c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read probe signature key file %s: %w", c.KeyFile, err)
+		}
+		key = []byte(strings.TrimSpace(string(b)))
+	}
+	return &signingRoundTripper{rt: rt, c: c, key: key}, nil
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (s *signingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(s.c.Header, probeSignature(s.key, s.c.KeyID, req, time.Now()))
+	return s.rt.RoundTrip(req)
+}
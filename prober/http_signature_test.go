@@ -0,0 +1,117 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProbeSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://192.0.2.1/health?full=1", nil)
+	req.Host = "www.example.com"
+	got := probeSignature([]byte("secret"), "2026-10", req, time.Unix(1790000000, 0))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1790000000\nGET\nwww.example.com\n/health?full=1"))
+	want := "t=1790000000,kid=2026-10,sig=" + hex.EncodeToString(mac.Sum(nil))
+	if got != want {
+		t.Errorf("Expected signature %q, got %q", want, got)
+	}
+}
+
+// verifyProbeSignature checks a signature header like a target would.
+func verifyProbeSignature(r *http.Request, keys map[string]string) bool {
+	fields := map[string]string{}
+	for _, field := range strings.Split(r.Header.Get("X-Blackbox-Signature"), ",") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	key, ok := keys[fields["kid"]]
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fields["t"] + "\n" + r.Method + "\n" + r.Host + "\n" + r.URL.RequestURI()))
+	sig, err := hex.DecodeString(fields["sig"])
+	return err == nil && hmac.Equal(sig, mac.Sum(nil))
+}
+
+func TestHTTPProbeSignature(t *testing.T) {
+	// The target accepts the old and the new key during the rotation.
+	keys := map[string]string{"old": "key1", "new": "key2"}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !verifyProbeSignature(r, keys) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/allowed", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("key2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		signature config.ProbeSignature
+		success   bool
+	}{
+		{
+			name:      "key",
+			signature: config.ProbeSignature{Header: "X-Blackbox-Signature", KeyID: "old", Key: "key1"},
+			success:   true,
+		},
+		{
+			name:      "key file",
+			signature: config.ProbeSignature{Header: "X-Blackbox-Signature", KeyID: "new", KeyFile: keyFile},
+			success:   true,
+		},
+		{
+			name:      "wrong key",
+			signature: config.ProbeSignature{Header: "X-Blackbox-Signature", KeyID: "new", Key: "key1"},
+		},
+		{
+			name: "unsigned",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			probe := config.HTTPProbe{IPProtocolFallback: true, HTTPClientConfig: pconfig.DefaultHTTPClientConfig, ProbeSignature: test.signature}
+			if success := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: probe}, prometheus.NewRegistry(), promslog.NewNopLogger()); success != test.success {
+				t.Errorf("Expected success %v, got %v", test.success, success)
+			}
+		})
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/andybalholm/brotli"
 	"github.com/prometheus/client_golang/prometheus"
 	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promslog"
 
 	"github.com/prometheus/blackbox_exporter/config"
@@ -688,6 +689,67 @@ func TestRedirectNotFollowed(t *testing.T) {
 
 // TestRedirectionLimit verifies that the probe stops following
 // redirects after some limit
+func TestRedirectHopsSSL(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result := ProbeHTTP(testCTX, ts.URL,
+		config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+			IPProtocolFallback: true,
+			HTTPClientConfig: pconfig.HTTPClientConfig{
+				FollowRedirects: true,
+				TLSConfig:       pconfig.TLSConfig{InsecureSkipVerify: true},
+			},
+		}}, registry, promslog.NewNopLogger())
+	if !result {
+		t.Fatalf("Redirect test failed unexpectedly")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(ts.URL, "https://")
+	checkRegistryResults(map[string]float64{
+		"probe_http_redirects":                         1,
+		"probe_http_redirect_ssl_earliest_cert_expiry": float64(ts.Certificate().NotAfter.Unix()),
+		"probe_http_redirect_ssl_chain_info":           1,
+	}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_http_redirect_ssl_earliest_cert_expiry": {"hop": "0", "host": host},
+		"probe_http_redirect_ssl_chain_info":           {"hop": "0", "host": host, "issuer": ts.Certificate().Issuer.String()},
+	}, mfs, t)
+}
+
+func TestRedirectHopsWithoutSSL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result := ProbeHTTP(testCTX, ts.URL,
+		config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, HTTPClientConfig: pconfig.DefaultHTTPClientConfig}}, registry, promslog.NewNopLogger())
+	if !result {
+		t.Fatalf("Redirect test failed unexpectedly")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAbsentMetrics([]string{"probe_http_redirect_ssl_earliest_cert_expiry", "probe_http_redirect_ssl_chain_info"}, mfs, t)
+}
+
 func TestRedirectionLimit(t *testing.T) {
 	const redirectLimit = 11
 
@@ -768,6 +830,25 @@ func TestPost(t *testing.T) {
 	}
 }
 
+func TestCustomMethods(t *testing.T) {
+	for _, method := range []string{"PATCH", "PROPFIND", "PURGE"} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}))
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		result := ProbeHTTP(testCTX, ts.URL,
+			config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, Method: method, AllowUnsafeMethod: true}}, registry, promslog.NewNopLogger())
+		cancel()
+		ts.Close()
+		if !result {
+			t.Fatalf("%s test failed unexpectedly", method)
+		}
+	}
+}
+
 func TestBasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	}))
@@ -1072,15 +1153,15 @@ func TestFailIfHeaderMatchesRegexp(t *testing.T) {
 		Values        []string
 		ShouldSucceed bool
 	}{
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false}, []string{"text/javascript"}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false}, []string{"application/octet-stream"}, true},
-		{config.HeaderMatch{"content-type", config.MustNewRegexp("text/javascript"), false}, []string{"application/octet-stream"}, true},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false}, []string{""}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false}, []string{}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true}, []string{""}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true}, []string{}, true},
-		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false}, []string{"gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, false},
-		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/", "gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"text/javascript"}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"application/octet-stream"}, true},
+		{config.HeaderMatch{"content-type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"application/octet-stream"}, true},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false, ""}, []string{""}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false, ""}, []string{}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true, ""}, []string{""}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true, ""}, []string{}, true},
+		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false, ""}, []string{"gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, false},
+		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false, ""}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/", "gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, false},
 	}
 
 	for i, test := range tests {
@@ -1121,14 +1202,14 @@ func TestFailIfHeaderNotMatchesRegexp(t *testing.T) {
 		Values        []string
 		ShouldSucceed bool
 	}{
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false}, []string{"text/javascript"}, true},
-		{config.HeaderMatch{"content-type", config.MustNewRegexp("text/javascript"), false}, []string{"text/javascript"}, true},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false}, []string{"application/octet-stream"}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false}, []string{""}, true},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false}, []string{}, false},
-		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true}, []string{}, true},
-		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/"}, false},
-		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/", "gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, true},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"text/javascript"}, true},
+		{config.HeaderMatch{"content-type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"text/javascript"}, true},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp("text/javascript"), false, ""}, []string{"application/octet-stream"}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false, ""}, []string{""}, true},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), false, ""}, []string{}, false},
+		{config.HeaderMatch{"Content-Type", config.MustNewRegexp(".*"), true, ""}, []string{}, true},
+		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false, ""}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/"}, false},
+		{config.HeaderMatch{"Set-Cookie", config.MustNewRegexp(".*Domain=\\.example\\.com.*"), false, ""}, []string{"zz=4; expires=Mon, 01-Jan-1990 00:00:00 GMT; Domain=www.example.com; Path=/", "gid=1; Expires=Tue, 19-Mar-2019 20:08:29 GMT; Domain=.example.com; Path=/"}, true},
 	}
 
 	for i, test := range tests {
@@ -1194,6 +1275,13 @@ func TestHTTPHeaders(t *testing.T) {
 	if !result {
 		t.Fatalf("Probe failed unexpectedly.")
 	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_http_user_agent_info": {"user_agent": "unsuspicious user"},
+	}, mfs, t)
 }
 
 func TestFailIfSelfSignedCA(t *testing.T) {
@@ -1554,3 +1642,190 @@ func TestBody(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateHSTS(t *testing.T) {
+	const year = model.Duration(365 * 24 * time.Hour)
+
+	testcases := map[string]struct {
+		header         string
+		validator      config.HSTSValidator
+		expectedResult bool
+		expectedMaxAge float64
+	}{
+		"no header, no validator": {
+			expectedResult: true,
+		},
+		"no header, validator": {
+			validator:      config.HSTSValidator{FailIfMaxAgeBelow: year},
+			expectedResult: false,
+		},
+		"max-age above minimum": {
+			header:         "max-age=63072000; includeSubDomains; preload",
+			validator:      config.HSTSValidator{FailIfMaxAgeBelow: year, FailIfNotIncludeSubdomains: true, FailIfNotPreload: true},
+			expectedResult: true,
+			expectedMaxAge: 63072000,
+		},
+		"max-age below minimum": {
+			header:         "max-age=300",
+			validator:      config.HSTSValidator{FailIfMaxAgeBelow: year},
+			expectedResult: false,
+			expectedMaxAge: 300,
+		},
+		"quoted max-age, case insensitive directives": {
+			header:         `MAX-AGE="31536000"; INCLUDESUBDOMAINS`,
+			validator:      config.HSTSValidator{FailIfMaxAgeBelow: year, FailIfNotIncludeSubdomains: true},
+			expectedResult: true,
+			expectedMaxAge: 31536000,
+		},
+		"missing includeSubDomains": {
+			header:         "max-age=31536000; preload",
+			validator:      config.HSTSValidator{FailIfNotIncludeSubdomains: true},
+			expectedResult: false,
+			expectedMaxAge: 31536000,
+		},
+		"missing preload": {
+			header:         "max-age=31536000; includeSubDomains",
+			validator:      config.HSTSValidator{FailIfNotPreload: true},
+			expectedResult: false,
+			expectedMaxAge: 31536000,
+		},
+		"invalid header": {
+			header:         "includeSubDomains",
+			validator:      config.HSTSValidator{FailIfNotIncludeSubdomains: true},
+			expectedResult: false,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.header != "" {
+					w.Header().Set("Strict-Transport-Security", tc.header)
+				}
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, ValidateHSTS: tc.validator}}, registry, promslog.NewNopLogger())
+			if result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedMaxAge == 0 {
+				checkAbsentMetrics([]string{"probe_http_hsts_max_age_seconds"}, mfs, t)
+				return
+			}
+			checkRegistryResults(map[string]float64{"probe_http_hsts_max_age_seconds": tc.expectedMaxAge}, mfs, t)
+		})
+	}
+}
+
+func TestServerClockSkew(t *testing.T) {
+	received := time.Date(2026, time.March, 1, 12, 0, 0, 200*int(time.Millisecond), time.UTC)
+	testcases := map[string]struct {
+		date, age string
+		expected  time.Duration
+		err       bool
+	}{
+		"in sync":       {date: "Sun, 01 Mar 2026 12:00:00 GMT", expected: 300 * time.Millisecond},
+		"server ahead":  {date: "Sun, 01 Mar 2026 12:05:00 GMT", expected: 5*time.Minute + 300*time.Millisecond},
+		"server behind": {date: "Sun, 01 Mar 2026 11:59:30 GMT", expected: -29*time.Second - 700*time.Millisecond},
+		"cached":        {date: "Sun, 01 Mar 2026 11:00:00 GMT", age: "3600", expected: 300 * time.Millisecond},
+		"beyond bound":  {date: "Sat, 01 Mar 2025 12:00:00 GMT", err: true},
+		"invalid":       {date: "yesterday", err: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			header := http.Header{"Date": {tc.date}}
+			if tc.age != "" {
+				header.Set("Age", tc.age)
+			}
+			skew, err := serverClockSkew(header, received)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected an error, got skew %s", skew)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skew != tc.expected {
+				t.Errorf("Expected skew %s, got %s", tc.expected, skew)
+			}
+		})
+	}
+}
+
+func TestHTTPServerClockSkew(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}}, registry, promslog.NewNopLogger()) {
+		t.Fatal("Probe failed")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_http_server_clock_skew_seconds" {
+			continue
+		}
+		if skew := mf.GetMetric()[0].GetGauge().GetValue(); skew < -3601 || skew > -3599 {
+			t.Errorf("Expected a skew of about -3600 seconds, got %g", skew)
+		}
+		return
+	}
+	t.Error("probe_http_server_clock_skew_seconds not found")
+}
+
+func TestHTTPVersion(t *testing.T) {
+	tests := []struct {
+		httpVersion string
+		serverHTTP2 bool
+		success     bool
+		version     float64
+	}{
+		{httpVersion: "2", serverHTTP2: true, success: true, version: 2},
+		{httpVersion: "1.1", serverHTTP2: true, success: true, version: 1.1},
+		// The server only speaks HTTP/1.1, the negotiation falls back.
+		{httpVersion: "2", serverHTTP2: false, success: false, version: 1.1},
+	}
+	for i, test := range tests {
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.EnableHTTP2 = test.serverHTTP2
+		ts.StartTLS()
+		defer ts.Close()
+
+		registry := prometheus.NewRegistry()
+		httpClientConfig := pconfig.DefaultHTTPClientConfig
+		httpClientConfig.TLSConfig.InsecureSkipVerify = true
+		module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+			IPProtocolFallback: true,
+			HTTPVersion:        test.httpVersion,
+			HTTPClientConfig:   httpClientConfig,
+		}}
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); result != test.success {
+			t.Fatalf("Test %d had unexpected result: %t", i, result)
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryResults(map[string]float64{"probe_http_version": test.version}, mfs, t)
+	}
+}
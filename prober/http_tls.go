@@ -0,0 +1,86 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// verifyPeerCertificates verifies the certificates sent by the server as the
+// TLS handshake would, against roots or, if nil, the system roots.
+func verifyPeerCertificates(state *tls.ConnectionState, roots *x509.CertPool, serverName string) ([][]*x509.Certificate, error) {
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("no certificates sent by the server")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	return state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+}
+
+// isSelfSigned returns whether the certificate is signed by its own key. It
+// does not require the certificate to be a CA, unlike CheckSignatureFrom.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// checkTLSState verifies the certificates of the final response if they
+// were not verified during the handshake, which fills the verified chains
+// of the state, and returns whether the TLS connection is acceptable.
+func checkTLSState(resp *http.Response, httpConfig config.HTTPProbe, roots *x509.CertPool, registry *prometheus.Registry, logger *slog.Logger) bool {
+	state := resp.TLS
+	if httpConfig.InsecureCapture {
+		verifiedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ssl_verified",
+			Help: "Indicates if the certificates of the server were verified",
+		})
+		registry.MustRegister(verifiedGauge)
+		// The server name is not sent for IP addresses.
+		serverName := state.ServerName
+		if serverName == "" {
+			serverName = resp.Request.URL.Hostname()
+		}
+		chains, err := verifyPeerCertificates(state, roots, serverName)
+		if err != nil {
+			logger.Warn("Certificates of the server could not be verified", "err", err)
+		} else {
+			state.VerifiedChains = chains
+			verifiedGauge.Set(1)
+		}
+	}
+
+	success := true
+	if httpConfig.FailIfTLSVersionBelow != 0 && state.Version < uint16(httpConfig.FailIfTLSVersionBelow) {
+		logger.Error("Final request used a TLS version below the minimum", "version", getTLSVersion(state), "min_version", getTLSVersion(&tls.ConnectionState{Version: uint16(httpConfig.FailIfTLSVersionBelow)}))
+		success = false
+	}
+	if httpConfig.FailIfSelfSigned && len(state.PeerCertificates) > 0 && isSelfSigned(state.PeerCertificates[0]) {
+		logger.Error("Certificate of the final request is self-signed", "subject", getSubject(state))
+		success = false
+	}
+	return success
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestHTTPTLSChecks(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	tests := []struct {
+		name     string
+		probe    config.HTTPProbe
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:    "untrusted",
+			probe:   config.HTTPProbe{},
+			success: false,
+		},
+		{
+			name:     "insecure capture",
+			probe:    config.HTTPProbe{InsecureCapture: true},
+			success:  true,
+			expected: map[string]float64{"probe_http_ssl": 1, "probe_ssl_verified": 0, "probe_ssl_earliest_cert_expiry": float64(ts.Certificate().NotAfter.Unix())},
+		},
+		{
+			name: "insecure capture with CA",
+			probe: config.HTTPProbe{
+				InsecureCapture:  true,
+				HTTPClientConfig: pconfig.HTTPClientConfig{TLSConfig: pconfig.TLSConfig{CA: string(ca)}},
+			},
+			success:  true,
+			expected: map[string]float64{"probe_ssl_verified": 1, "probe_ssl_last_chain_expiry_timestamp_seconds": float64(ts.Certificate().NotAfter.Unix())},
+		},
+		{
+			name:     "self-signed",
+			probe:    config.HTTPProbe{InsecureCapture: true, FailIfSelfSigned: true},
+			success:  false,
+			expected: map[string]float64{"probe_http_ssl": 1},
+		},
+		{
+			name:    "TLS version",
+			probe:   config.HTTPProbe{InsecureCapture: true, FailIfTLSVersionBelow: pconfig.TLSVersion(tls.VersionTLS13)},
+			success: false,
+		},
+		{
+			name:    "TLS version met",
+			probe:   config.HTTPProbe{InsecureCapture: true, FailIfTLSVersionBelow: pconfig.TLSVersion(tls.VersionTLS12)},
+			success: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			test.probe.IPProtocolFallback = true
+			if success := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
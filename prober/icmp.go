@@ -16,11 +16,13 @@ package prober
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"log/slog"
 	"math/rand"
 	"net"
 	"os"
 	"runtime"
+	"slices"
 	"sync"
 	"time"
 
@@ -32,6 +34,9 @@ import (
 	"github.com/prometheus/blackbox_exporter/config"
 )
 
+// icmpPayload is the start of the data of echo requests.
+const icmpPayload = "Prometheus Blackbox Exporter"
+
 var (
 	icmpID            int
 	icmpSequence      uint16
@@ -65,7 +70,6 @@ func getICMPSequence() uint16 {
 func ProbeICMP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) (success bool) {
 	var (
 		requestType     icmp.Type
-		replyType       icmp.Type
 		icmpConn        *icmp.PacketConn
 		v4RawConn       *ipv4.RawConn
 		hopLimitFlagSet bool = true
@@ -87,6 +91,21 @@ func ProbeICMP(ctx context.Context, target string, module config.Module, registr
 
 	registry.MustRegister(durationGaugeVec)
 
+	var noReply, unreachable bool
+	if module.ICMP.ExpectFailure {
+		defer func() {
+			switch {
+			case success:
+				logger.Error("Received reply from a target expected to be unreachable")
+				success = false
+			case len(module.ICMP.ExpectedUnreachableCodes) > 0:
+				success = unreachable
+			default:
+				success = noReply
+			}
+		}()
+	}
+
 	dstIPAddr, lookupTime, err := chooseProtocol(ctx, module.ICMP.IPProtocol, module.ICMP.IPProtocolFallback, target, registry, logger)
 
 	if err != nil {
@@ -108,12 +127,12 @@ func ProbeICMP(ctx context.Context, target string, module config.Module, registr
 	logger.Info("Creating socket")
 
 	privileged := true
-	// Unprivileged sockets are supported on Darwin and Linux only.
-	tryUnprivileged := runtime.GOOS == "darwin" || runtime.GOOS == "linux"
+	// Unprivileged sockets are supported on Darwin and Linux only. They do
+	// not receive ICMP error messages.
+	tryUnprivileged := (runtime.GOOS == "darwin" || runtime.GOOS == "linux") && len(module.ICMP.ExpectedUnreachableCodes) == 0
 
 	if dstIPAddr.IP.To4() == nil {
 		requestType = ipv6.ICMPTypeEchoRequest
-		replyType = ipv6.ICMPTypeEchoReply
 
 		if srcIP == nil {
 			srcIP = net.ParseIP("::")
@@ -144,7 +163,6 @@ func ProbeICMP(ctx context.Context, target string, module config.Module, registr
 		}
 	} else {
 		requestType = ipv4.ICMPTypeEcho
-		replyType = ipv4.ICMPTypeEchoReply
 
 		if srcIP == nil {
 			srcIP = net.ParseIP("0.0.0.0")
@@ -205,106 +223,135 @@ func ProbeICMP(ctx context.Context, target string, module config.Module, registr
 	var data []byte
 	if module.ICMP.PayloadSize != 0 {
 		data = make([]byte, module.ICMP.PayloadSize)
-		copy(data, "Prometheus Blackbox Exporter")
+		copy(data, icmpPayload)
 	} else {
-		data = []byte("Prometheus Blackbox Exporter")
+		data = []byte(icmpPayload)
 	}
 
-	body := &icmp.Echo{
-		ID:   icmpID,
-		Seq:  int(getICMPSequence()),
-		Data: data,
-	}
-	logger.Info("Creating ICMP packet", "seq", body.Seq, "id", body.ID)
-	wm := icmp.Message{
-		Type: requestType,
-		Code: 0,
-		Body: body,
+	packetCount := max(module.ICMP.PacketCount, 1)
+	packetInterval := module.ICMP.PacketInterval
+	if packetInterval <= 0 {
+		packetInterval = config.DefaultICMPPacketInterval
 	}
 
-	wb, err := wm.Marshal(nil)
-	if err != nil {
-		logger.Error("Error marshalling packet", "err", err)
-		return
+	if icmpConn != nil && module.ICMP.TTL > 0 {
+		ttl := module.ICMP.TTL
+		if c4 := icmpConn.IPv4PacketConn(); c4 != nil {
+			logger.Debug("Setting TTL (IPv4 unprivileged)", "ttl", ttl)
+			c4.SetTTL(ttl)
+		}
+		if c6 := icmpConn.IPv6PacketConn(); c6 != nil {
+			logger.Debug("Setting TTL (IPv6 unprivileged)", "ttl", ttl)
+			c6.SetHopLimit(ttl)
+		}
 	}
 
-	durationGaugeVec.WithLabelValues("setup").Add(time.Since(setupStart).Seconds())
-	logger.Info("Writing out packet")
-	rttStart := time.Now()
-
-	if icmpConn != nil {
-		ttl := module.ICMP.TTL
-		if ttl > 0 {
-			if c4 := icmpConn.IPv4PacketConn(); c4 != nil {
-				logger.Debug("Setting TTL (IPv4 unprivileged)", "ttl", ttl)
-				c4.SetTTL(ttl)
-			}
-			if c6 := icmpConn.IPv6PacketConn(); c6 != nil {
-				logger.Debug("Setting TTL (IPv6 unprivileged)", "ttl", ttl)
-				c6.SetHopLimit(ttl)
-			}
+	// outstanding are the times the echo requests waiting for a reply were
+	// sent, by sequence number.
+	var (
+		packetsSent int
+		outstanding = map[int]time.Time{}
+		rtts        []float64
+	)
+	send := func() bool {
+		body := &icmp.Echo{
+			ID:   icmpID,
+			Seq:  int(getICMPSequence()),
+			Data: data,
 		}
-		_, err = icmpConn.WriteTo(wb, dst)
-	} else {
-		ttl := config.DefaultICMPTTL
-		if module.ICMP.TTL > 0 {
-			logger.Debug("Overriding TTL (raw IPv4)", "ttl", ttl)
-			ttl = module.ICMP.TTL
+		logger.Info("Creating ICMP packet", "seq", body.Seq, "id", body.ID)
+		wm := icmp.Message{
+			Type: requestType,
+			Code: 0,
+			Body: body,
 		}
-		// Only for IPv4 raw. Needed for setting DontFragment flag.
-		header := &ipv4.Header{
-			Version:  ipv4.Version,
-			Len:      ipv4.HeaderLen,
-			Protocol: 1,
-			TotalLen: ipv4.HeaderLen + len(wb),
-			TTL:      ttl,
-			Dst:      dstIPAddr.IP,
-			Src:      srcIP,
+
+		wb, err := wm.Marshal(nil)
+		if err != nil {
+			logger.Error("Error marshalling packet", "err", err)
+			return false
 		}
 
-		header.Flags |= ipv4.DontFragment
+		logger.Info("Writing out packet")
+		packetsSent++
+		outstanding[body.Seq] = time.Now()
+		if icmpConn != nil {
+			_, err = icmpConn.WriteTo(wb, dst)
+		} else {
+			ttl := config.DefaultICMPTTL
+			if module.ICMP.TTL > 0 {
+				logger.Debug("Overriding TTL (raw IPv4)", "ttl", ttl)
+				ttl = module.ICMP.TTL
+			}
+			// Only for IPv4 raw. Needed for setting DontFragment flag.
+			header := &ipv4.Header{
+				Version:  ipv4.Version,
+				Len:      ipv4.HeaderLen,
+				Protocol: 1,
+				TotalLen: ipv4.HeaderLen + len(wb),
+				TTL:      ttl,
+				Dst:      dstIPAddr.IP,
+				Src:      srcIP,
+			}
+
+			header.Flags |= ipv4.DontFragment
 
-		err = v4RawConn.WriteTo(header, wb, nil)
-	}
-	if err != nil {
-		logger.Warn("Error writing to socket", "err", err)
-		return
+			err = v4RawConn.WriteTo(header, wb, nil)
+		}
+		if err != nil {
+			logger.Warn("Error writing to socket", "err", err)
+			return false
+		}
+		return true
 	}
 
-	// Reply should be the same except for the message type and ID if
-	// unprivileged sockets were used and the kernel used its own.
-	wm.Type = replyType
-	// Unprivileged cannot set IDs on Linux.
-	idUnknown := !privileged && runtime.GOOS == "linux"
-	if idUnknown {
-		body.ID = 0
+	durationGaugeVec.WithLabelValues("setup").Add(time.Since(setupStart).Seconds())
+	if packetCount > 1 {
+		// The probe succeeds if any echo request got a reply, the loss is
+		// left to the packet metrics.
+		defer func() {
+			exportICMPPackets(packetsSent, rtts, registry)
+			if len(rtts) > 0 {
+				// The rtt phase is the average round trip time.
+				durationGaugeVec.WithLabelValues("rtt").Set(icmpRTTStats(rtts).avg)
+				success = true
+			}
+		}()
 	}
-	wb, err = wm.Marshal(nil)
-	if err != nil {
-		logger.Error("Error marshalling packet", "err", err)
+	if !send() {
 		return
 	}
 
-	if idUnknown {
-		// If the ID is unknown (due to unprivileged sockets) we also cannot know
-		// the checksum in userspace.
-		wb[2] = 0
-		wb[3] = 0
-	}
+	// Unprivileged cannot set IDs on Linux, the kernel uses its own and keeps
+	// track of our packet for us.
+	idUnknown := !privileged && runtime.GOOS == "linux"
+	v6 := dstIPAddr.IP.To4() == nil
+	// Stacks may echo a truncated payload, so only its start is checked.
+	payloadPrefix := data[:min(len(data), len(icmpPayload))]
+	var redirectedBy net.Addr
+	hopLimitRegistered := false
 
 	rb := make([]byte, 65536)
 	deadline, _ := ctx.Deadline()
-	if icmpConn != nil {
-		err = icmpConn.SetReadDeadline(deadline)
-	} else {
-		err = v4RawConn.SetReadDeadline(deadline)
-	}
-	if err != nil {
-		logger.Error("Error setting socket deadline", "err", err)
-		return
-	}
+	nextSend := time.Now().Add(packetInterval)
 	logger.Info("Waiting for reply packets")
 	for {
+		// The read is interrupted when the next echo request is due.
+		sendDue := packetsSent < packetCount && (deadline.IsZero() || nextSend.Before(deadline))
+		readDeadline := deadline
+		if sendDue {
+			readDeadline = nextSend
+		}
+		if icmpConn != nil {
+			err = icmpConn.SetReadDeadline(readDeadline)
+		} else {
+			err = v4RawConn.SetReadDeadline(readDeadline)
+		}
+		if err != nil {
+			logger.Error("Error setting socket deadline", "err", err)
+			return
+		}
+
 		var n int
 		var peer net.Addr
 		var err error
@@ -342,34 +389,218 @@ func ProbeICMP(ctx context.Context, target string, module config.Module, registr
 		}
 		if err != nil {
 			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
-				logger.Warn("Timeout reading from socket", "err", err)
+				if sendDue {
+					if !send() {
+						return
+					}
+					nextSend = nextSend.Add(packetInterval)
+					continue
+				}
+				logger.Warn("Timeout reading from socket", "err", err, "outstanding_requests", len(outstanding))
+				if redirectedBy != nil {
+					logger.Error("No reply received after redirect", "from", redirectedBy)
+				}
+				noReply = true
 				return
 			}
 			logger.Error("Error reading from socket", "err", err)
 			continue
 		}
-		if peer.String() != dst.String() {
-			continue
+		// Error messages usually come from a router rather than from the target.
+		for seq := range outstanding {
+			typ, code, ok := icmpErrorReply(rb[:n], v6, dstIPAddr.IP, icmpID, seq, idUnknown)
+			if !ok {
+				continue
+			}
+			if typ == ipv4.ICMPTypeRedirect {
+				// The router still forwards the packet, a reply may follow.
+				logger.Info("Received redirect message", "from", peer, "code", code)
+				redirectedBy = peer
+				break
+			}
+			logger.Info("Received destination unreachable message", "from", peer, "code", code)
+			if packetCount > 1 {
+				// Only this echo request is lost.
+				delete(outstanding, seq)
+				break
+			}
+			unreachableCodeGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_icmp_unreachable_code",
+				Help: "Code of the ICMP destination unreachable message received",
+			})
+			registry.MustRegister(unreachableCodeGauge)
+			unreachableCodeGauge.Set(float64(code))
+			switch {
+			case slices.Contains(module.ICMP.ExpectedUnreachableCodes, code):
+				noReply, unreachable = true, true
+			case len(module.ICMP.ExpectedUnreachableCodes) > 0:
+				logger.Error("Destination unreachable code is not one of the expected codes", "code", code, "expected_codes", module.ICMP.ExpectedUnreachableCodes)
+			case module.ICMP.ExpectFailure:
+				noReply = true
+			default:
+				logger.Error("Destination unreachable", "from", peer, "code", code)
+			}
+			return false
 		}
-		if idUnknown {
-			// Clear the ID from the packet, as the kernel will have replaced it (and
-			// kept track of our packet for us, hence clearing is safe).
-			rb[4] = 0
-			rb[5] = 0
+		if peer.String() == dst.String() {
+			for seq, sentAt := range outstanding {
+				if !icmpEchoReplyMatches(rb[:n], v6, icmpID, seq, idUnknown, payloadPrefix) {
+					continue
+				}
+				rtt := time.Since(sentAt).Seconds()
+				logger.Info("Found matching reply packet", "seq", seq)
+				if hopLimit >= 0 {
+					hopLimitGauge.Set(hopLimit)
+					if !hopLimitRegistered {
+						registry.MustRegister(hopLimitGauge)
+						hopLimitRegistered = true
+					}
+				}
+				if packetCount == 1 {
+					durationGaugeVec.WithLabelValues("rtt").Add(rtt)
+					return true
+				}
+				rtts = append(rtts, rtt)
+				delete(outstanding, seq)
+				break
+			}
 		}
-		if idUnknown || replyType == ipv6.ICMPTypeEchoReply {
-			// Clear checksum to make comparison succeed.
-			rb[2] = 0
-			rb[3] = 0
+		if packetsSent == packetCount && len(outstanding) == 0 {
+			return
 		}
-		if bytes.Equal(rb[:n], wb) {
-			durationGaugeVec.WithLabelValues("rtt").Add(time.Since(rttStart).Seconds())
-			if hopLimit >= 0 {
-				hopLimitGauge.Set(hopLimit)
-				registry.MustRegister(hopLimitGauge)
-			}
-			logger.Info("Found matching reply packet")
-			return true
+	}
+}
+
+// icmpEchoReplyMatches reports whether msg is the reply to the echo request
+// with the given ID and sequence number, whose data starts with payload.
+// The checksum is not verified and extensions are ignored, as some stacks
+// rewrite or append them.
+func icmpEchoReplyMatches(msg []byte, v6 bool, id, seq int, idUnknown bool, payload []byte) bool {
+	proto, replyType := 1, icmp.Type(ipv4.ICMPTypeEchoReply)
+	if v6 {
+		proto, replyType = 58, ipv6.ICMPTypeEchoReply
+	}
+	m, err := icmp.ParseMessage(proto, msg)
+	if err != nil || m.Type != replyType {
+		return false
+	}
+	echo, ok := m.Body.(*icmp.Echo)
+	if !ok || echo.Seq != seq || (!idUnknown && echo.ID != id) {
+		return false
+	}
+	return bytes.HasPrefix(echo.Data, payload)
+}
+
+// icmpErrorReply returns the type and code of an ICMP destination
+// unreachable or, for IPv4, redirect message sent in response to the echo
+// request to dst with the given ID and sequence number. As for replies, the
+// ID is not checked if the kernel replaced it.
+func icmpErrorReply(msg []byte, v6 bool, dst net.IP, id, seq int, idUnknown bool) (icmp.Type, int, bool) {
+	proto, unreachableType := 1, icmp.Type(ipv4.ICMPTypeDestinationUnreachable)
+	if v6 {
+		proto, unreachableType = 58, ipv6.ICMPTypeDestinationUnreachable
+	}
+	m, err := icmp.ParseMessage(proto, msg)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	// The message quotes the IP header of the original packet, followed by
+	// the start of the echo request.
+	var data []byte
+	switch body := m.Body.(type) {
+	case *icmp.DstUnreach:
+		if m.Type != unreachableType {
+			return nil, 0, false
+		}
+		data = body.Data
+	case *icmp.RawBody:
+		// Redirects are preceded by the address of the gateway.
+		if v6 || m.Type != ipv4.ICMPTypeRedirect || len(body.Data) < 4 {
+			return nil, 0, false
 		}
+		data = body.Data[4:]
+	default:
+		return nil, 0, false
+	}
+	echoType := byte(ipv4.ICMPTypeEcho)
+	if v6 {
+		if len(data) < ipv6.HeaderLen || !net.IP(data[24:40]).Equal(dst) {
+			return nil, 0, false
+		}
+		data = data[ipv6.HeaderLen:]
+		echoType = byte(ipv6.ICMPTypeEchoRequest)
+	} else {
+		if len(data) < ipv4.HeaderLen || !net.IP(data[16:20]).Equal(dst) {
+			return nil, 0, false
+		}
+		headerLen := int(data[0]&0x0f) * 4
+		if len(data) < headerLen {
+			return nil, 0, false
+		}
+		data = data[headerLen:]
+	}
+	if len(data) < 8 || data[0] != echoType {
+		return nil, 0, false
 	}
+	if int(binary.BigEndian.Uint16(data[6:8])) != seq || (!idUnknown && int(binary.BigEndian.Uint16(data[4:6])) != id) {
+		return nil, 0, false
+	}
+	return m.Type, m.Code, true
+}
+
+// icmpRTT are the statistics of the round trip times of the echo requests of
+// a probe, in seconds.
+type icmpRTT struct {
+	min, avg, max, stddev float64
+}
+
+func icmpRTTStats(rtts []float64) icmpRTT {
+	stats := icmpRTT{min: rtts[0], max: rtts[0]}
+	var sum float64
+	for _, rtt := range rtts {
+		stats.min = min(stats.min, rtt)
+		stats.max = max(stats.max, rtt)
+		sum += rtt
+	}
+	stats.avg = sum / float64(len(rtts))
+	stats.stddev = stddev(rtts)
+	return stats
+}
+
+// exportICMPPackets exports the loss and the round trip times of the echo
+// requests of a probe sending more than one.
+func exportICMPPackets(sent int, rtts []float64, registry *prometheus.Registry) {
+	packetsSentGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_icmp_packets_sent",
+		Help: "Number of echo requests sent by the probe",
+	})
+	packetsReceivedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_icmp_packets_received",
+		Help: "Number of echo replies received by the probe",
+	})
+	packetLossGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_icmp_packet_loss_ratio",
+		Help: "Ratio of the echo requests of the probe that got no reply",
+	})
+	registry.MustRegister(packetsSentGauge, packetsReceivedGauge, packetLossGauge)
+	packetsSentGauge.Set(float64(sent))
+	packetsReceivedGauge.Set(float64(len(rtts)))
+	if sent > 0 {
+		packetLossGauge.Set(1 - float64(len(rtts))/float64(sent))
+	}
+	if len(rtts) == 0 {
+		return
+	}
+
+	rttGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_icmp_rtt_seconds",
+		Help: "Round trip time of the echo requests of the probe, by statistic",
+	}, []string{"stat"})
+	registry.MustRegister(rttGaugeVec)
+	stats := icmpRTTStats(rtts)
+	rttGaugeVec.WithLabelValues("min").Set(stats.min)
+	rttGaugeVec.WithLabelValues("avg").Set(stats.avg)
+	rttGaugeVec.WithLabelValues("max").Set(stats.max)
+	rttGaugeVec.WithLabelValues("stddev").Set(stats.stddev)
 }
@@ -0,0 +1,192 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestICMPErrorReply(t *testing.T) {
+	echo := func(typ icmp.Type, id, seq int) []byte {
+		b, err := (&icmp.Message{Type: typ, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("Prometheus Blackbox Exporter")}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	unreachable := func(typ icmp.Type, code int, quoted []byte) []byte {
+		b, err := (&icmp.Message{Type: typ, Code: code, Body: &icmp.DstUnreach{Data: quoted}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	v4Header := func(dst string) []byte {
+		b, err := (&ipv4.Header{Version: ipv4.Version, Len: ipv4.HeaderLen, TTL: 64, Protocol: 1, Dst: net.ParseIP(dst)}).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	v6Header := make([]byte, ipv6.HeaderLen)
+	copy(v6Header[24:], net.ParseIP("2001:db8::1"))
+	v4Quoted := append(v4Header("192.0.2.1"), echo(ipv4.ICMPTypeEcho, 1, 42)[:8]...)
+	v6Quoted := append(v6Header, echo(ipv6.ICMPTypeEchoRequest, 1, 42)[:8]...)
+	foreignID := append(v4Header("192.0.2.1"), echo(ipv4.ICMPTypeEcho, 7, 42)[:8]...)
+	dst := func(v6 bool) net.IP {
+		if v6 {
+			return net.ParseIP("2001:db8::1")
+		}
+		return net.ParseIP("192.0.2.1")
+	}
+	redirect, err := (&icmp.Message{Type: ipv4.ICMPTypeRedirect, Code: 1, Body: &icmp.RawBody{Data: append([]byte{192, 0, 2, 254}, v4Quoted...)}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		msg          []byte
+		v6           bool
+		idUnknown    bool
+		expectedType icmp.Type
+		expectedCode int
+		expectedOK   bool
+	}{
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, v4Quoted), expectedType: ipv4.ICMPTypeDestinationUnreachable, expectedCode: 13, expectedOK: true},
+		{msg: unreachable(ipv6.ICMPTypeDestinationUnreachable, 1, v6Quoted), v6: true, expectedType: ipv6.ICMPTypeDestinationUnreachable, expectedCode: 1, expectedOK: true},
+		{msg: redirect, expectedType: ipv4.ICMPTypeRedirect, expectedCode: 1, expectedOK: true},
+		// Response to another echo request.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, append(v4Header("192.0.2.1"), echo(ipv4.ICMPTypeEcho, 1, 43)[:8]...))},
+		// Response to the echo request of another process with the same
+		// sequence number.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, foreignID)},
+		// ID replaced by the kernel.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, foreignID), idUnknown: true, expectedType: ipv4.ICMPTypeDestinationUnreachable, expectedCode: 13, expectedOK: true},
+		// Response to an echo request to another destination.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, append(v4Header("192.0.2.2"), echo(ipv4.ICMPTypeEcho, 1, 42)[:8]...))},
+		{msg: unreachable(ipv6.ICMPTypeDestinationUnreachable, 1, append(make([]byte, ipv6.HeaderLen), echo(ipv6.ICMPTypeEchoRequest, 1, 42)[:8]...)), v6: true},
+		// Response to another kind of packet.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, append(v4Header("192.0.2.1"), echo(ipv4.ICMPTypeTimestamp, 1, 42)[:8]...))},
+		// Truncated quote.
+		{msg: unreachable(ipv4.ICMPTypeDestinationUnreachable, 13, v4Header("192.0.2.1")[:10])},
+		// Echo reply.
+		{msg: echo(ipv4.ICMPTypeEchoReply, 1, 42)},
+	}
+	for i, test := range tests {
+		typ, code, ok := icmpErrorReply(test.msg, test.v6, dst(test.v6), 1, 42, test.idUnknown)
+		if ok != test.expectedOK || typ != test.expectedType || code != test.expectedCode {
+			t.Fatalf("Test %d: expected (%v, %d, %t), got (%v, %d, %t)", i, test.expectedType, test.expectedCode, test.expectedOK, typ, code, ok)
+		}
+	}
+}
+
+func TestICMPEchoReplyMatches(t *testing.T) {
+	reply := func(typ icmp.Type, id, seq int, data string) []byte {
+		b, err := (&icmp.Message{Type: typ, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte(data)}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	payload := []byte(icmpPayload)
+
+	tests := []struct {
+		msg       []byte
+		v6        bool
+		idUnknown bool
+		expected  bool
+	}{
+		{msg: reply(ipv4.ICMPTypeEchoReply, 1, 42, icmpPayload), expected: true},
+		{msg: reply(ipv6.ICMPTypeEchoReply, 1, 42, icmpPayload), v6: true, expected: true},
+		// Trailing data added by the stack.
+		{msg: reply(ipv4.ICMPTypeEchoReply, 1, 42, icmpPayload+"\x00\x01"), expected: true},
+		// ID replaced by the kernel.
+		{msg: reply(ipv4.ICMPTypeEchoReply, 7, 42, icmpPayload), idUnknown: true, expected: true},
+		{msg: reply(ipv4.ICMPTypeEchoReply, 7, 42, icmpPayload)},
+		{msg: reply(ipv4.ICMPTypeEchoReply, 1, 43, icmpPayload)},
+		{msg: reply(ipv4.ICMPTypeEchoReply, 1, 42, "Another payload")},
+		{msg: reply(ipv4.ICMPTypeEcho, 1, 42, icmpPayload)},
+	}
+	for i, test := range tests {
+		if matches := icmpEchoReplyMatches(test.msg, test.v6, 1, 42, test.idUnknown, payload); matches != test.expected {
+			t.Fatalf("Test %d: expected %t, got %t", i, test.expected, matches)
+		}
+	}
+}
+
+func TestICMPRTTStats(t *testing.T) {
+	stats := icmpRTTStats([]float64{0.01, 0.03, 0.02, 0.02})
+	want := icmpRTT{min: 0.01, avg: 0.02, max: 0.03, stddev: math.Sqrt(0.0002 / 4)}
+	for name, values := range map[string][2]float64{
+		"min":    {stats.min, want.min},
+		"avg":    {stats.avg, want.avg},
+		"max":    {stats.max, want.max},
+		"stddev": {stats.stddev, want.stddev},
+	} {
+		if math.Abs(values[0]-values[1]) > 1e-9 {
+			t.Errorf("Expected %s %g, got %g", name, values[1], values[0])
+		}
+	}
+}
+
+func TestICMPPacketCount(t *testing.T) {
+	conn, err := icmp.ListenPacket("udp4", "127.0.0.1")
+	if err != nil {
+		conn, err = icmp.ListenPacket("ip4:icmp", "127.0.0.1")
+	}
+	if err != nil {
+		t.Skipf("Cannot send ICMP echo requests: %s", err)
+	}
+	conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	registry := prometheus.NewRegistry()
+	module := config.Module{ICMP: config.ICMPProbe{
+		IPProtocol:     "ip4",
+		PacketCount:    3,
+		PacketInterval: 10 * time.Millisecond,
+	}}
+	if !ProbeICMP(ctx, "127.0.0.1", module, registry, promslog.NewNopLogger()) {
+		t.Fatal("ICMP probe of the loopback address failed")
+	}
+	if ctx.Err() != nil {
+		t.Error("Expected the probe to end once all the replies were received")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{
+		"probe_icmp_packets_sent":      3,
+		"probe_icmp_packets_received":  3,
+		"probe_icmp_packet_loss_ratio": 0,
+	}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_icmp_rtt_seconds": {"stat": "stddev"},
+	}, mfs, t)
+}
@@ -0,0 +1,306 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// The encoding of IPP messages, see RFC 8010, and the printer attributes,
+// see RFC 8011.
+const (
+	ippDefaultPort          = "631"
+	ippDefaultPath          = "/ipp/print"
+	ippMaxResponseSize      = 1 << 20
+	ippGetPrinterAttributes = 0x000b
+
+	ippTagOperationAttributes = 0x01
+	ippTagEndOfAttributes     = 0x03
+	ippTagInteger             = 0x21
+	ippTagBoolean             = 0x22
+	ippTagEnum                = 0x23
+	ippTagKeyword             = 0x44
+	ippTagURI                 = 0x45
+	ippTagCharset             = 0x47
+	ippTagNaturalLanguage     = 0x48
+)
+
+// ippPrinterStates are the values of the printer-state attribute.
+var ippPrinterStates = map[int]string{
+	3: "idle",
+	4: "processing",
+	5: "stopped",
+}
+
+// ippRequestedAttributes are the printer attributes the prober requests.
+var ippRequestedAttributes = []string{
+	"printer-state",
+	"printer-state-reasons",
+	"printer-is-accepting-jobs",
+	"queued-job-count",
+}
+
+// ippAttribute is an attribute with its values, whose encoding depends on
+// the tag.
+type ippAttribute struct {
+	tag    byte
+	values [][]byte
+}
+
+func appendIPPAttribute(b []byte, tag byte, name string, values ...string) []byte {
+	for i, value := range values {
+		// Additional values have an empty name.
+		if i > 0 {
+			name = ""
+		}
+		b = append(b, tag)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(name)))
+		b = append(b, name...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(value)))
+		b = append(b, value...)
+	}
+	return b
+}
+
+// encodeIPPGetPrinterAttributes returns a Get-Printer-Attributes request.
+func encodeIPPGetPrinterAttributes(printerURI string, requestID uint32) []byte {
+	// IPP version 2.0.
+	b := []byte{2, 0}
+	b = binary.BigEndian.AppendUint16(b, ippGetPrinterAttributes)
+	b = binary.BigEndian.AppendUint32(b, requestID)
+	b = append(b, ippTagOperationAttributes)
+	b = appendIPPAttribute(b, ippTagCharset, "attributes-charset", "utf-8")
+	b = appendIPPAttribute(b, ippTagNaturalLanguage, "attributes-natural-language", "en")
+	b = appendIPPAttribute(b, ippTagURI, "printer-uri", printerURI)
+	b = appendIPPAttribute(b, ippTagKeyword, "requested-attributes", ippRequestedAttributes...)
+	return append(b, ippTagEndOfAttributes)
+}
+
+// decodeIPPResponse returns the status code of a response and its
+// attributes, regardless of their group.
+func decodeIPPResponse(b []byte, requestID uint32) (uint16, map[string]*ippAttribute, error) {
+	if len(b) < 9 {
+		return 0, nil, errors.New("IPP response too short")
+	}
+	if binary.BigEndian.Uint32(b[4:]) != requestID {
+		return 0, nil, errors.New("unexpected IPP request ID")
+	}
+	statusCode := binary.BigEndian.Uint16(b[2:])
+	attributes := map[string]*ippAttribute{}
+	var last *ippAttribute
+	for b = b[8:]; len(b) > 0; {
+		tag := b[0]
+		b = b[1:]
+		if tag == ippTagEndOfAttributes {
+			return statusCode, attributes, nil
+		}
+		// Delimiters start a group of attributes.
+		if tag < 0x10 {
+			continue
+		}
+		if len(b) < 2 || len(b) < 4+int(binary.BigEndian.Uint16(b)) {
+			return 0, nil, errors.New("invalid IPP attribute")
+		}
+		name := string(b[2 : 2+binary.BigEndian.Uint16(b)])
+		b = b[2+len(name):]
+		valueLength := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+valueLength {
+			return 0, nil, errors.New("invalid IPP attribute")
+		}
+		value := b[2 : 2+valueLength]
+		b = b[2+valueLength:]
+		if name != "" {
+			last = &ippAttribute{tag: tag}
+			attributes[name] = last
+		}
+		if last == nil {
+			return 0, nil, errors.New("IPP value without attribute name")
+		}
+		last.values = append(last.values, value)
+	}
+	return 0, nil, errors.New("IPP response has no end of attributes")
+}
+
+// intValue returns the first value of an integer or enum attribute.
+func (a *ippAttribute) intValue() (int, bool) {
+	if a == nil || (a.tag != ippTagInteger && a.tag != ippTagEnum) || len(a.values[0]) != 4 {
+		return 0, false
+	}
+	return int(int32(binary.BigEndian.Uint32(a.values[0]))), true
+}
+
+// ippURLs returns the HTTP URL of a printer and its printer-uri.
+func ippURLs(target string) (string, string, error) {
+	if !strings.Contains(target, "://") {
+		target = "ipp://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", "", err
+	}
+	switch u.Scheme {
+	case "ipp", "ipps":
+		if u.Path == "" {
+			u.Path = ippDefaultPath
+		}
+		printerURI := u.String()
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), ippDefaultPort)
+		}
+		u.Scheme = map[string]string{"ipp": "http", "ipps": "https"}[u.Scheme]
+		return u.String(), printerURI, nil
+	case "http", "https":
+		printerURI := *u
+		printerURI.Scheme = map[string]string{"http": "ipp", "https": "ipps"}[u.Scheme]
+		return u.String(), printerURI.String(), nil
+	}
+	return "", "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+}
+
+// ProbeIPP gets the state of a printer with a Get-Printer-Attributes
+// request, and fails if it is not in a valid state or reports a reason that
+// is configured to fail the probe.
+func ProbeIPP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		statusCodeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ipp_status_code",
+			Help: "Response IPP status code",
+		})
+		printerStateGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ipp_printer_state",
+			Help: "The state of the printer: 3 for idle, 4 for processing and 5 for stopped",
+		})
+		stateReasonGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_ipp_printer_state_reason",
+			Help: "Contains the reasons of the state of the printer",
+		}, []string{"reason"})
+		acceptingJobsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ipp_printer_accepting_jobs",
+			Help: "Indicates if the printer is accepting jobs",
+		})
+		queuedJobsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ipp_queued_jobs",
+			Help: "The number of jobs queued by the printer",
+		})
+	)
+
+	c := module.IPP
+	httpURL, printerURI, err := ippURLs(target)
+	if err != nil {
+		logger.Error("Could not parse target URL", "err", err)
+		return false
+	}
+	client, err := pconfig.NewClientFromConfig(c.HTTPClientConfig, "ipp_probe", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating HTTP client", "err", err)
+		return false
+	}
+
+	requestID := rand.Uint32()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, httpURL, bytes.NewReader(encodeIPPGetPrinterAttributes(printerURI, requestID)))
+	if err != nil {
+		logger.Error("Error creating request", "err", err)
+		return false
+	}
+	req.Header.Set("Content-Type", "application/ipp")
+	req.Header.Set("User-Agent", userAgentDefaultHeader)
+	logger.Info("Getting printer attributes", "url", httpURL, "printer_uri", printerURI)
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Error("Error sending IPP request", "err", err)
+		return false
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, ippMaxResponseSize))
+	if err != nil {
+		logger.Error("Error reading IPP response", "err", err)
+		return false
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Unexpected HTTP status code", "status_code", resp.StatusCode)
+		return false
+	}
+	statusCode, attributes, err := decodeIPPResponse(body, requestID)
+	if err != nil {
+		logger.Error("Error decoding IPP response", "err", err)
+		return false
+	}
+	registry.MustRegister(statusCodeGauge)
+	statusCodeGauge.Set(float64(statusCode))
+	// Status codes below 0x0100 are successful.
+	if statusCode >= 0x0100 {
+		logger.Error("Get-Printer-Attributes failed", "status_code", fmt.Sprintf("0x%04x", statusCode))
+		return false
+	}
+
+	success := true
+	if state, ok := attributes["printer-state"].intValue(); ok {
+		registry.MustRegister(printerStateGauge)
+		printerStateGauge.Set(float64(state))
+		name := ippPrinterStates[state]
+		logger.Info("Got printer state", "state", name)
+		if !slices.Contains(c.ValidPrinterStates, name) {
+			logger.Error("Printer state is not valid", "state", name)
+			success = false
+		}
+	} else {
+		logger.Error("Response has no printer-state")
+		success = false
+	}
+
+	if reasons := attributes["printer-state-reasons"]; reasons != nil {
+		registry.MustRegister(stateReasonGaugeVec)
+		for _, value := range reasons.values {
+			reason := string(value)
+			if reason == "none" {
+				continue
+			}
+			stateReasonGaugeVec.WithLabelValues(reason).Set(1)
+			for _, re := range c.FailIfStateReasonMatchesRegexp {
+				if re.MatchString(reason) {
+					logger.Error("Printer state reason matched regular expression", "reason", reason, "regexp", re)
+					success = false
+				}
+			}
+		}
+	}
+	if accepting := attributes["printer-is-accepting-jobs"]; accepting != nil && accepting.tag == ippTagBoolean && len(accepting.values[0]) == 1 {
+		registry.MustRegister(acceptingJobsGauge)
+		if accepting.values[0][0] != 0 {
+			acceptingJobsGauge.Set(1)
+		}
+	}
+	if queued, ok := attributes["queued-job-count"].intValue(); ok {
+		registry.MustRegister(queuedJobsGauge)
+		queuedJobsGauge.Set(float64(queued))
+	}
+	return success
+}
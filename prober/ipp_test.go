@@ -0,0 +1,163 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// ippTestPrinter answers Get-Printer-Attributes requests for the printer
+// ipp://<host>/ipp/print with the state and reasons.
+func ippTestPrinter(t *testing.T, state uint32, reasons ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/ipp" || len(body) < 8 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Requests are encoded like responses, with the operation in place
+		// of the status code.
+		requestID := binary.BigEndian.Uint32(body[4:])
+		operation, attributes, err := decodeIPPResponse(body, requestID)
+		if err != nil || operation != ippGetPrinterAttributes {
+			t.Errorf("Invalid request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := []byte{2, 0}
+		uri := attributes["printer-uri"]
+		if uri == nil || !strings.HasSuffix(string(uri.values[0]), "/ipp/print") {
+			// client-error-not-found.
+			resp = binary.BigEndian.AppendUint16(resp, 0x0406)
+		} else {
+			resp = binary.BigEndian.AppendUint16(resp, 0)
+		}
+		resp = binary.BigEndian.AppendUint32(resp, requestID)
+		resp = append(resp, ippTagOperationAttributes)
+		resp = appendIPPAttribute(resp, ippTagCharset, "attributes-charset", "utf-8")
+		resp = appendIPPAttribute(resp, ippTagNaturalLanguage, "attributes-natural-language", "en")
+		// Printer attributes.
+		resp = append(resp, 0x04)
+		resp = appendIPPAttribute(resp, ippTagEnum, "printer-state", string(binary.BigEndian.AppendUint32(nil, state)))
+		resp = appendIPPAttribute(resp, ippTagKeyword, "printer-state-reasons", reasons...)
+		resp = appendIPPAttribute(resp, ippTagBoolean, "printer-is-accepting-jobs", "\x01")
+		resp = appendIPPAttribute(resp, ippTagInteger, "queued-job-count", "\x00\x00\x00\x02")
+		resp = append(resp, ippTagEndOfAttributes)
+		w.Header().Set("Content-Type", "application/ipp")
+		w.Write(resp)
+	}))
+}
+
+func TestIPP(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    uint32
+		reasons  []string
+		path     string
+		probe    config.IPPProbe
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "idle",
+			state:    3,
+			reasons:  []string{"none"},
+			path:     "/ipp/print",
+			probe:    config.IPPProbe{ValidPrinterStates: []string{"idle", "processing"}},
+			success:  true,
+			expected: map[string]float64{"probe_ipp_status_code": 0, "probe_ipp_printer_state": 3, "probe_ipp_printer_accepting_jobs": 1, "probe_ipp_queued_jobs": 2},
+		},
+		{
+			name:     "stopped",
+			state:    5,
+			reasons:  []string{"media-empty-error"},
+			path:     "/ipp/print",
+			probe:    config.IPPProbe{ValidPrinterStates: []string{"idle", "processing"}},
+			expected: map[string]float64{"probe_ipp_printer_state": 5, "probe_ipp_printer_state_reason": 1},
+		},
+		{
+			name:    "warning",
+			state:   4,
+			reasons: []string{"toner-low-warning", "media-low-report"},
+			path:    "/ipp/print",
+			probe: config.IPPProbe{
+				ValidPrinterStates:             []string{"idle", "processing"},
+				FailIfStateReasonMatchesRegexp: []config.Regexp{config.MustNewRegexp(".*-(error|warning)")},
+			},
+			expected: map[string]float64{"probe_ipp_printer_state": 4},
+		},
+		{
+			name:     "unknown printer",
+			state:    3,
+			path:     "/ipp/fax",
+			probe:    config.IPPProbe{ValidPrinterStates: []string{"idle", "processing"}},
+			expected: map[string]float64{"probe_ipp_status_code": 0x0406},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := ippTestPrinter(t, test.state, test.reasons...)
+			defer ts.Close()
+
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			test.probe.HTTPClientConfig = pconfig.DefaultHTTPClientConfig
+			target := strings.Replace(ts.URL, "http://", "ipp://", 1) + test.path
+			if success := ProbeIPP(testCTX, target, config.Module{IPP: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+			if len(test.reasons) > 0 && test.reasons[0] != "none" {
+				checkRegistryLabels(map[string]map[string]string{"probe_ipp_printer_state_reason": {"reason": test.reasons[0]}}, mfs, t)
+			}
+		})
+	}
+}
+
+func TestIPPURLs(t *testing.T) {
+	for _, test := range []struct {
+		target, httpURL, printerURI string
+	}{
+		{"printer.example.com", "http://printer.example.com:631/ipp/print", "ipp://printer.example.com/ipp/print"},
+		{"ipps://printer.example.com/printers/office", "https://printer.example.com:631/printers/office", "ipps://printer.example.com/printers/office"},
+		{"http://printer.example.com:8631/ipp/print", "http://printer.example.com:8631/ipp/print", "ipp://printer.example.com:8631/ipp/print"},
+	} {
+		httpURL, printerURI, err := ippURLs(test.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if httpURL != test.httpURL || printerURI != test.printerURI {
+			t.Errorf("Expected %s and %s for %s, got %s and %s", test.httpURL, test.printerURI, test.target, httpURL, printerURI)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// jsonWebKey is a public JSON Web Key, see RFC 7517 and RFC 8037.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Elliptic curve and Ed25519 keys.
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+	// X5C is the certificate chain of the key, as base64 encoded DER.
+	X5C []string `json:"x5c"`
+}
+
+// jsonWebKeySet is a JSON Web Key Set.
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// publicKey returns the RSA, ECDSA or Ed25519 public key.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// certExpiry returns the earliest expiry of the certificates of the key, or
+// the zero time if it has none.
+func (k jsonWebKey) certExpiry() (time.Time, error) {
+	var earliest time.Time
+	for _, encoded := range k.X5C {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid certificate of key %q: %w", k.KeyID, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid certificate of key %q: %w", k.KeyID, err)
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	return earliest, nil
+}
+
+// jwtHashes are the hashes of the asymmetric JWS algorithms, see RFC 7518.
+var jwtHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verifyJWTSignature verifies the signature of the JWS signing input with
+// the public key, which must suit the algorithm.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signingInput, signature []byte) error {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, signingInput, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	hash, ok := jwtHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signingInput)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		// The signature is the concatenation of r and s.
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(alg, "ES") && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if !ecdsa.Verify(pub, digest, r, s) {
+				return errors.New("invalid signature")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("key does not suit algorithm %q", alg)
+}
+
+// jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jwtClaims are the registered claims of a JWT that are exported.
+type jwtClaims struct {
+	Issuer    string  `json:"iss"`
+	ExpiresAt float64 `json:"exp"`
+}
+
+// parseJWT decodes a JWT in the JWS compact serialization.
+func parseJWT(token string) (header jwtHeader, claims jwtClaims, signingInput, signature []byte, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, claims, nil, nil, errors.New("token is not a JWS in compact serialization")
+	}
+	for i, v := range []interface{}{&header, &claims} {
+		b, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return header, claims, nil, nil, fmt.Errorf("invalid token encoding: %w", err)
+		}
+		if err := json.Unmarshal(b, v); err != nil {
+			return header, claims, nil, nil, fmt.Errorf("invalid token: %w", err)
+		}
+	}
+	signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, claims, nil, nil, fmt.Errorf("invalid token encoding: %w", err)
+	}
+	return header, claims, []byte(parts[0] + "." + parts[1]), signature, nil
+}
+
+// responseJWT returns the JWT of the response, in a header, optionally
+// preceded by its authentication scheme, or in a field of a JSON body.
+func responseJWT(header http.Header, body []byte, v config.JWTValidator) (string, error) {
+	if v.Header != "" {
+		value := header.Get(v.Header)
+		if value == "" {
+			return "", fmt.Errorf("missing header %q", v.Header)
+		}
+		if i := strings.LastIndexByte(value, ' '); i >= 0 {
+			value = value[i+1:]
+		}
+		return value, nil
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return "", fmt.Errorf("error decoding JSON body: %w", err)
+	}
+	values := v.JSONPath.Select(document)
+	if len(values) == 0 {
+		return "", fmt.Errorf("no value at path %q", v.JSONPath.String())
+	}
+	token, ok := values[0].(string)
+	if !ok {
+		return "", fmt.Errorf("value at path %q is not a string", v.JSONPath.String())
+	}
+	return token, nil
+}
+
+// validateJWT verifies the JWT of the response with the keys of the JWKS
+// URL, and exports its expiry and issuer, and the expiry of the certificate
+// of the key that signed it.
+func validateJWT(ctx context.Context, httpClientConfig pconfig.HTTPClientConfig, header http.Header, body []byte, v config.JWTValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		signatureValidGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_jwt_signature_valid",
+			Help: "Indicates if the signature of the JWT of the response is valid",
+		})
+		expiryGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_jwt_expiry_timestamp_seconds",
+			Help: "Returns the expiry of the JWT of the response in unixtime",
+		})
+		infoGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_jwt_info",
+			Help: "Contains the issuer and key of the JWT of the response",
+		}, []string{"issuer", "alg", "kid"})
+		keyCertExpiryGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_jwt_signing_key_cert_expiry",
+			Help: "Returns earliest certificate expiry of the key that signed the JWT in unixtime",
+		})
+	)
+	registry.MustRegister(signatureValidGauge)
+
+	token, err := responseJWT(header, body, v)
+	if err != nil {
+		logger.Error("Error getting the JWT of the response", "err", err)
+		return false
+	}
+	jwtHeader, claims, signingInput, signature, err := parseJWT(token)
+	if err != nil {
+		logger.Error("Error parsing JWT", "err", err)
+		return false
+	}
+	registry.MustRegister(infoGaugeVec)
+	infoGaugeVec.WithLabelValues(claims.Issuer, jwtHeader.Algorithm, jwtHeader.KeyID).Set(1)
+	if claims.ExpiresAt > 0 {
+		registry.MustRegister(expiryGauge)
+		expiryGauge.Set(claims.ExpiresAt)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.JWKSURL, nil)
+	if err != nil {
+		logger.Error("Error creating JWKS request", "err", err)
+		return false
+	}
+	// The JWKS URL is usually on another host than the target, so only the
+	// TLS and proxy settings of the module apply to it, not its credentials.
+	client, err := pconfig.NewClientFromConfig(pconfig.HTTPClientConfig{
+		TLSConfig:       pconfig.TLSConfig{CAFile: httpClientConfig.TLSConfig.CAFile, CA: httpClientConfig.TLSConfig.CA, InsecureSkipVerify: httpClientConfig.TLSConfig.InsecureSkipVerify},
+		ProxyConfig:     httpClientConfig.ProxyConfig,
+		FollowRedirects: true,
+		EnableHTTP2:     true,
+	}, "jwks", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating JWKS HTTP client", "err", err)
+		return false
+	}
+	var jwks jsonWebKeySet
+	if err := getJSONDocument(client, req, &jwks); err != nil {
+		logger.Error("Error fetching JWKS", "url", v.JWKSURL, "err", err)
+		return false
+	}
+
+	// Without a key ID, any of the keys may have signed the token.
+	var signingKey *jsonWebKey
+	for i, k := range jwks.Keys {
+		if jwtHeader.KeyID != "" && k.KeyID != jwtHeader.KeyID {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			logger.Debug("Skipping key", "kid", k.KeyID, "err", err)
+			continue
+		}
+		if err := verifyJWTSignature(jwtHeader.Algorithm, pub, signingInput, signature); err == nil {
+			signingKey = &jwks.Keys[i]
+			break
+		} else {
+			logger.Debug("Signature not verified by key", "kid", k.KeyID, "err", err)
+		}
+	}
+	if signingKey == nil {
+		logger.Error("JWT signature could not be verified with the JWKS", "alg", jwtHeader.Algorithm, "kid", jwtHeader.KeyID)
+		return false
+	}
+	signatureValidGauge.Set(1)
+
+	success := true
+	if expiry, err := signingKey.certExpiry(); err != nil {
+		logger.Error("Error parsing the certificate of the signing key", "err", err)
+		success = false
+	} else if !expiry.IsZero() {
+		registry.MustRegister(keyCertExpiryGauge)
+		keyCertExpiryGauge.Set(float64(expiry.Unix()))
+	}
+	if claims.ExpiresAt > 0 && time.Now().After(time.Unix(int64(claims.ExpiresAt), 0)) {
+		logger.Error("JWT has expired", "exp", claims.ExpiresAt)
+		success = false
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		logger.Error("Unexpected JWT issuer", "issuer", claims.Issuer, "expected", v.Issuer)
+		success = false
+	}
+	return success
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func encodeJWKInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+// signTestJWT returns a JWT signed with the key.
+func signTestJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var (
+		signature []byte
+		err       error
+	)
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signingInput))
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certExpiry := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	cert, _ := generateCertificate(generateCertificateTemplate(certExpiry, false), nil, &rsaKey.PublicKey, rsaKey)
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]interface{}{
+			{"kty": "RSA", "kid": "rsa", "n": encodeJWKInt(rsaKey.N), "e": encodeJWKInt(big.NewInt(int64(rsaKey.E))),
+				"x5c": []string{base64.StdEncoding.EncodeToString(cert.Raw)}},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encodeJWKInt(ecKey.X), "y": encodeJWKInt(ecKey.Y)},
+			{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))},
+		}})
+	}))
+	defer jwks.Close()
+
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	claims := map[string]interface{}{"iss": "https://auth.example.com", "exp": exp}
+	expired := map[string]interface{}{"iss": "https://auth.example.com", "exp": float64(time.Now().Add(-time.Hour).Unix())}
+	header := config.JWTValidator{Header: "Authorization", JWKSURL: jwks.URL}
+	body := config.JWTValidator{JSONPath: config.MustNewJSONPath("$.access_token"), JWKSURL: jwks.URL}
+
+	tests := []struct {
+		name      string
+		token     string
+		validator config.JWTValidator
+		success   bool
+		valid     float64
+		expected  map[string]float64
+	}{
+		{
+			name:      "RS256 in header",
+			token:     signTestJWT(t, "RS256", "rsa", rsaKey, claims),
+			validator: header,
+			success:   true,
+			valid:     1,
+			expected:  map[string]float64{"probe_jwt_expiry_timestamp_seconds": exp, "probe_jwt_signing_key_cert_expiry": float64(certExpiry.Unix())},
+		},
+		{
+			name:      "ES256 in body",
+			token:     signTestJWT(t, "ES256", "ec", ecKey, claims),
+			validator: body,
+			success:   true,
+			valid:     1,
+		},
+		{
+			name:      "EdDSA without key ID",
+			token:     signTestJWT(t, "EdDSA", "", edKey, claims),
+			validator: body,
+			success:   true,
+			valid:     1,
+		},
+		{
+			name:      "unknown key",
+			token:     signTestJWT(t, "RS256", "rsa", otherKey, claims),
+			validator: header,
+		},
+		{
+			name:      "expired",
+			token:     signTestJWT(t, "RS256", "rsa", rsaKey, expired),
+			validator: header,
+			valid:     1,
+		},
+		{
+			name:      "unexpected issuer",
+			token:     signTestJWT(t, "RS256", "rsa", rsaKey, claims),
+			validator: config.JWTValidator{Header: "Authorization", JWKSURL: jwks.URL, Issuer: "https://login.example.com"},
+			valid:     1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Authorization", "Bearer "+test.token)
+				json.NewEncoder(w).Encode(map[string]string{"access_token": test.token})
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				ValidateJWT:        test.validator,
+			}}, registry, promslog.NewNopLogger())
+			if result != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]float64{"probe_jwt_signature_valid": test.valid}
+			for name, value := range test.expected {
+				expected[name] = value
+			}
+			checkRegistryResults(expected, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{"probe_jwt_info": {"issuer": "https://auth.example.com"}}, mfs, t)
+		})
+	}
+}
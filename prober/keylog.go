@@ -0,0 +1,138 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	pconfig "github.com/prometheus/common/config"
+)
+
+var (
+	tlsKeyLogEnabled bool
+	tlsKeyLogFile    string
+)
+
+// EnableTLSKeyLog allows debug probe requests to capture the TLS session
+// keys of the probe with tls_key_log=true, in the NSS key log format read by
+// Wireshark. The keys are written to the debug output, or appended to file
+// if it is not empty.
+func EnableTLSKeyLog(file string) {
+	tlsKeyLogEnabled = true
+	tlsKeyLogFile = file
+}
+
+type tlsKeyLogKey struct{}
+
+// withTLSKeyLog returns a context making the probers write the TLS session
+// keys of the probe to w.
+func withTLSKeyLog(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, tlsKeyLogKey{}, w)
+}
+
+// tlsKeyLogWriter returns the writer of the TLS session keys of the probe,
+// for tls.Config.KeyLogWriter. It is nil unless the keys are captured.
+func tlsKeyLogWriter(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(tlsKeyLogKey{}).(io.Writer)
+	return w
+}
+
+// newKeyLogRoundTripper returns the round tripper of an HTTP probe writing
+// its TLS session keys to w. It is built like the ones of
+// http_client_config, which have no way to set the key log, and so does not
+// support oauth2.
+func newKeyLogRoundTripper(httpClientConfig pconfig.HTTPClientConfig, keepAlives bool, dialContext pconfig.DialContextFunc, w io.Writer) (http.RoundTripper, error) {
+	tlsConfig, err := pconfig.NewTLSConfig(&httpClientConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.KeyLogWriter = w
+	var rt http.RoundTripper = &authRoundTripper{
+		rt:   newProbeTransport(httpClientConfig, tlsConfig, keepAlives, dialContext),
+		auth: legacyAuth(httpClientConfig),
+	}
+	if httpClientConfig.HTTPHeaders != nil {
+		rt = pconfig.NewHeadersRoundTripper(httpClientConfig.HTTPHeaders, rt)
+	}
+	return rt, nil
+}
+
+// authRoundTripper sets the basic auth or authorization header of
+// http_client_config on the requests.
+type authRoundTripper struct {
+	rt   http.RoundTripper
+	auth func(*http.Request) error
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := rt.auth(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return rt.rt.RoundTrip(req)
+}
+
+// tlsKeyLog receives the TLS session keys of a probe, which may come from
+// concurrent handshakes.
+type tlsKeyLog struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf *bytes.Buffer
+}
+
+// newTLSKeyLog returns a key log writing to the configured file, or to a
+// buffer for the debug output.
+func newTLSKeyLog() (*tlsKeyLog, error) {
+	if tlsKeyLogFile == "" {
+		buf := &bytes.Buffer{}
+		return &tlsKeyLog{w: buf, buf: buf}, nil
+	}
+	f, err := os.OpenFile(tlsKeyLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &tlsKeyLog{w: f}, nil
+}
+
+func (l *tlsKeyLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// Close closes the file the keys are appended to.
+func (l *tlsKeyLog) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// String returns the keys written to the debug output.
+func (l *tlsKeyLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buf == nil {
+		return ""
+	}
+	return l.buf.String()
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func tlsKeyLogProbe(t *testing.T, query string) (string, *ResultHistory) {
+	return tlsKeyLogProbeModule(t, "tls_connect", query)
+}
+
+func tlsKeyLogProbeModule(t *testing.T, module, query string) (string, *ResultHistory) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{
+		Modules: map[string]config.Module{
+			"tls_connect": {
+				Prober:  "tcp",
+				Timeout: 10 * time.Second,
+				TCP: config.TCPProbe{
+					IPProtocolFallback: true,
+					TLS:                true,
+					TLSConfig:          pconfig.TLSConfig{InsecureSkipVerify: true},
+				},
+			},
+			"https_2xx": {
+				Prober:  "http",
+				Timeout: 10 * time.Second,
+				HTTP: config.HTTPProbe{
+					IPProtocolFallback: true,
+					HTTPClientConfig: pconfig.HTTPClientConfig{
+						BasicAuth: &pconfig.BasicAuth{Username: "user", Password: "pass"},
+						TLSConfig: pconfig.TLSConfig{InsecureSkipVerify: true},
+					},
+				},
+			},
+		},
+	}
+	target := ts.Listener.Addr().String()
+	if module == "https_2xx" {
+		target = ts.URL
+	}
+	req, err := http.NewRequest("GET", "?module="+module+"&target="+target+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	rh := &ResultHistory{MaxResults: 1}
+	Handler(rr, req, c, promslog.NewNopLogger(), rh, 0.5, nil, nil, &promslog.AllowedLevel{})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("probe request handler returned wrong status code: %v, want %v", rr.Code, http.StatusOK)
+	}
+	return rr.Body.String(), rh
+}
+
+func TestTLSKeyLog(t *testing.T) {
+	defer func() { tlsKeyLogEnabled, tlsKeyLogFile = false, "" }()
+
+	body, _ := tlsKeyLogProbe(t, "&debug=true&tls_key_log=true")
+	if strings.Contains(body, "TLS key log") {
+		t.Fatalf("TLS keys captured without being enabled: %s", body)
+	}
+
+	EnableTLSKeyLog("")
+	body, rh := tlsKeyLogProbe(t, "&debug=true&tls_key_log=true")
+	if !strings.Contains(body, "probe_success 1") || !strings.Contains(body, "CLIENT_TRAFFIC_SECRET_0 ") {
+		t.Fatalf("TLS keys not found in the debug output: %s", body)
+	}
+	if strings.Contains(rh.List()[0].DebugOutput, "CLIENT_TRAFFIC_SECRET_0") {
+		t.Fatal("TLS keys kept in the history")
+	}
+
+	body, _ = tlsKeyLogProbe(t, "&tls_key_log=true")
+	if strings.Contains(body, "CLIENT_TRAFFIC_SECRET_0") {
+		t.Fatalf("TLS keys captured outside of a debug request: %s", body)
+	}
+}
+
+func TestTLSKeyLogFile(t *testing.T) {
+	defer func() { tlsKeyLogEnabled, tlsKeyLogFile = false, "" }()
+
+	file := filepath.Join(t.TempDir(), "keys.log")
+	EnableTLSKeyLog(file)
+	body, _ := tlsKeyLogProbe(t, "&debug=true&tls_key_log=true")
+	if strings.Contains(body, "CLIENT_TRAFFIC_SECRET_0") {
+		t.Fatalf("TLS keys written to the debug output: %s", body)
+	}
+	keys, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(keys), "CLIENT_TRAFFIC_SECRET_0 ") {
+		t.Fatalf("TLS keys not found in the key log file: %s", keys)
+	}
+}
+
+func TestTLSKeyLogHTTP(t *testing.T) {
+	defer func() { tlsKeyLogEnabled, tlsKeyLogFile = false, "" }()
+
+	EnableTLSKeyLog("")
+	body, _ := tlsKeyLogProbeModule(t, "https_2xx", "&debug=true&tls_key_log=true")
+	if !strings.Contains(body, "probe_success 1") || !strings.Contains(body, "CLIENT_TRAFFIC_SECRET_0 ") {
+		t.Fatalf("TLS keys not found in the debug output: %s", body)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// detectMaintenance exports whether the target announced that it is in
+// planned maintenance, so that alerts on the failed probe can be silenced.
+// Maintenance responses fail the probe like any other error.
+func detectMaintenance(resp *http.Response, body []byte, c config.MaintenanceSignal, registry *prometheus.Registry, logger *slog.Logger) bool {
+	maintenanceGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_maintenance",
+		Help: "Indicates if the target responded that it is in maintenance",
+	})
+	registry.MustRegister(maintenanceGauge)
+
+	if len(c.StatusCodes) > 0 && !slices.Contains(c.StatusCodes, resp.StatusCode) {
+		return true
+	}
+	if c.Header != "" && !maintenanceHeaderMatches(resp.Header.Values(c.Header), c.HeaderRegexp) {
+		return true
+	}
+	if !c.JSONPath.IsZero() && !maintenanceJSONMatches(body, c.JSONPath, c.JSONRegexp) {
+		return true
+	}
+	logger.Error("Target is in maintenance", "status_code", resp.StatusCode)
+	maintenanceGauge.Set(1)
+	return false
+}
+
+func maintenanceHeaderMatches(values []string, re config.Regexp) bool {
+	if re.Regexp == nil {
+		return len(values) > 0
+	}
+	for _, value := range values {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func maintenanceJSONMatches(body []byte, path config.JSONPath, re config.Regexp) bool {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return false
+	}
+	for _, value := range path.Select(document) {
+		if re.Regexp == nil {
+			if value == true {
+				return true
+			}
+		} else if re.MatchString(formatJSONValue(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestMaintenance(t *testing.T) {
+	headerSignal := config.MaintenanceSignal{StatusCodes: []int{http.StatusServiceUnavailable}, Header: "X-Maintenance", HeaderRegexp: config.MustNewRegexp("^true$")}
+	jsonSignal := config.MaintenanceSignal{JSONPath: config.MustNewJSONPath("$.status.maintenance")}
+
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		signal         config.MaintenanceSignal
+		expectedResult bool
+		maintenance    float64
+	}{
+		"up": {
+			handler:        func(w http.ResponseWriter, r *http.Request) {},
+			signal:         headerSignal,
+			expectedResult: true,
+		},
+		"maintenance header": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Maintenance", "true")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			signal:      headerSignal,
+			maintenance: 1,
+		},
+		"outage": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			signal: headerSignal,
+		},
+		"maintenance json": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"status": {"maintenance": true}}`))
+			},
+			signal:      jsonSignal,
+			maintenance: 1,
+		},
+		"json without maintenance": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status": {"maintenance": false}}`))
+			},
+			signal:         jsonSignal,
+			expectedResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(test.handler)
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, Maintenance: test.signal}}
+			result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_maintenance": test.maintenance}, mfs, t)
+		})
+	}
+}
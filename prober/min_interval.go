@@ -0,0 +1,58 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var probesRateLimitedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "blackbox_probes_rate_limited_total",
+	Help: "Count of probe requests rejected because the target was probed less than min_interval ago",
+}, []string{"module"})
+
+// probeIntervals enforces the min_interval of modules, so that targets we
+// may only probe at a limited rate are not probed more often by mistake,
+// e.g. by a scrape interval that is too short.
+type probeIntervals struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+var probeMinInterval = &probeIntervals{last: map[string]time.Time{}}
+
+// allow records a probe of key at now and returns true, unless the previous
+// probe was less than interval ago, in which case it returns how long to wait
+// until the next probe is allowed.
+func (p *probeIntervals) allow(key string, interval time.Duration, now time.Time) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if last, ok := p.last[key]; ok && now.Sub(last) < interval {
+		return interval - now.Sub(last), false
+	}
+	p.last[key] = now
+	// The entry is removed once it cannot reject probes anymore.
+	time.AfterFunc(interval, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.last[key].Equal(now) {
+			delete(p.last, key)
+		}
+	})
+	return 0, true
+}
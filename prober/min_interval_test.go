@@ -0,0 +1,94 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProbeIntervals(t *testing.T) {
+	p := &probeIntervals{last: map[string]time.Time{}}
+	now := time.Now()
+	if _, ok := p.allow("a", time.Minute, now); !ok {
+		t.Fatal("Expected the first probe to be allowed")
+	}
+	wait, ok := p.allow("a", time.Minute, now.Add(20*time.Second))
+	if ok || wait != 40*time.Second {
+		t.Fatalf("Expected the probe to be rejected for 40s, got %v, %v", wait, ok)
+	}
+	if _, ok := p.allow("b", time.Minute, now.Add(20*time.Second)); !ok {
+		t.Fatal("Expected the probe of another target to be allowed")
+	}
+	if _, ok := p.allow("a", time.Minute, now.Add(time.Minute)); !ok {
+		t.Fatal("Expected the probe after the interval to be allowed")
+	}
+}
+
+func TestHandlerMinInterval(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{
+		Modules: map[string]config.Module{
+			"http_polite": {
+				Prober:      "http",
+				Timeout:     5 * time.Second,
+				MinInterval: time.Hour,
+				HTTP:        config.HTTPProbe{IPProtocolFallback: true},
+			},
+		},
+	}
+	rateLimited := func() float64 {
+		var m dto.Metric
+		if err := probesRateLimitedCounter.WithLabelValues("http_polite").Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := rateLimited()
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req, err := http.NewRequest("GET", "?module=http_polite&target="+ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		if rr.Code != want {
+			t.Fatalf("Request %d: expected status %d, got %d: %s", i, want, rr.Code, rr.Body.String())
+		}
+		if want == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "3600" {
+			t.Errorf("Expected Retry-After 3600, got %q", rr.Header().Get("Retry-After"))
+		}
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("Expected target to be probed once, got %d requests", requests.Load())
+	}
+	if got := rateLimited() - before; got != 1 {
+		t.Fatalf("Expected 1 rate limited probe, got %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	moduleProbesDesc = prometheus.NewDesc(
+		"blackbox_exporter_module_probes_total",
+		"Count of probe requests of the module since the exporter started.",
+		[]string{"module"}, nil,
+	)
+	moduleLastUsedDesc = prometheus.NewDesc(
+		"blackbox_exporter_module_last_used_timestamp",
+		"Time of the last probe request of the module, kept across restarts by --state.file.",
+		[]string{"module"}, nil,
+	)
+)
+
+// moduleUsage counts the probe requests of each module, to find the modules
+// that are not used anymore.
+type moduleUsage struct {
+	mu       sync.Mutex
+	probes   map[string]float64
+	lastUsed map[string]time.Time
+}
+
+var modulesUsage = &moduleUsage{probes: map[string]float64{}, lastUsed: map[string]time.Time{}}
+
+func (u *moduleUsage) record(module string, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.probes[module]++
+	u.lastUsed[module] = now
+}
+
+type moduleUsageCollector struct {
+	modules func() []string
+}
+
+// NewModuleUsageCollector returns a collector of the probe requests of each
+// module returned by modules, the modules of the configuration. Modules that
+// were not used are exported with no probes and no last used time, so that
+// they can be found, and modules removed from the configuration are not
+// exported anymore.
+func NewModuleUsageCollector(modules func() []string) prometheus.Collector {
+	return &moduleUsageCollector{modules: modules}
+}
+
+func (c *moduleUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- moduleProbesDesc
+	ch <- moduleLastUsedDesc
+}
+
+func (c *moduleUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	modulesUsage.mu.Lock()
+	defer modulesUsage.mu.Unlock()
+	for _, module := range c.modules() {
+		ch <- prometheus.MustNewConstMetric(moduleProbesDesc, prometheus.CounterValue, modulesUsage.probes[module], module)
+		if last, ok := modulesUsage.lastUsed[module]; ok {
+			ch <- prometheus.MustNewConstMetric(moduleLastUsedDesc, prometheus.GaugeValue, float64(last.UnixNano())/1e9, module)
+		}
+	}
+}
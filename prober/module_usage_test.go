@@ -0,0 +1,53 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestModuleUsageCollector(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	modulesUsage.record("usage_used", now.Add(-time.Hour))
+	modulesUsage.record("usage_used", now)
+	modulesUsage.record("usage_removed", now)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewModuleUsageCollector(func() []string { return []string{"usage_used", "usage_unused"} }))
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]map[string]float64{}
+	for _, mf := range mfs {
+		got[mf.GetName()] = map[string]float64{}
+		for _, m := range mf.GetMetric() {
+			value := m.GetGauge().GetValue()
+			if m.GetCounter() != nil {
+				value = m.GetCounter().GetValue()
+			}
+			got[mf.GetName()][m.GetLabel()[0].GetValue()] = value
+		}
+	}
+	if probes := got["blackbox_exporter_module_probes_total"]; len(probes) != 2 || probes["usage_used"] != 2 || probes["usage_unused"] != 0 {
+		t.Errorf("Unexpected probes of the modules: %v", probes)
+	}
+	if lastUsed := got["blackbox_exporter_module_last_used_timestamp"]; len(lastUsed) != 1 || lastUsed["usage_used"] != 1700000000 {
+		t.Errorf("Unexpected last used time of the modules: %v", lastUsed)
+	}
+}
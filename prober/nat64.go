@@ -0,0 +1,57 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"fmt"
+	"net"
+)
+
+// nat64Prefix is the prefix IPv4 addresses are reached through, nil unless
+// the exporter runs on an IPv6-only host with NAT64.
+var nat64Prefix *net.IPNet
+
+// SetNAT64Prefix makes the probers reach the IPv4 addresses of targets through
+// NAT64, at the IPv6 addresses synthesized from prefix as described in RFC
+// 6052, e.g. 64:ff9b::/96. This lets an exporter on an IPv6-only host probe
+// IPv4-only targets, whether or not its resolver does DNS64.
+func SetNAT64Prefix(prefix string) error {
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return err
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits != 8*net.IPv6len || (ones != 32 && ones != 40 && ones != 48 && ones != 56 && ones != 64 && ones != 96) {
+		return fmt.Errorf("NAT64 prefix %s must be an IPv6 prefix of length 32, 40, 48, 56, 64 or 96", prefix)
+	}
+	nat64Prefix = ipNet
+	return nil
+}
+
+// synthesizeNAT64 returns the IPv6 address of the IPv4 address ip in prefix.
+// The address follows the prefix, skipping bits 64 to 71 which are reserved.
+func synthesizeNAT64(prefix *net.IPNet, ip net.IP) net.IP {
+	ones, _ := prefix.Mask.Size()
+	synthesized := make(net.IP, net.IPv6len)
+	copy(synthesized, prefix.IP.To16())
+	v4 := ip.To4()
+	for i, j := ones/8, 0; j < len(v4); i++ {
+		if i == 8 {
+			continue
+		}
+		synthesized[i] = v4[j]
+		j++
+	}
+	return synthesized
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+)
+
+func TestSynthesizeNAT64(t *testing.T) {
+	// The examples of RFC 6052, section 2.4.
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::c000:221"},
+	}
+	for _, test := range tests {
+		_, prefix, err := net.ParseCIDR(test.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := synthesizeNAT64(prefix, net.ParseIP("192.0.2.33")); !got.Equal(net.ParseIP(test.want)) {
+			t.Errorf("%s: expected %s, got %s", test.prefix, test.want, got)
+		}
+	}
+}
+
+func TestSetNAT64Prefix(t *testing.T) {
+	defer func() { nat64Prefix = nil }()
+	for _, prefix := range []string{"64:ff9b::", "64:ff9b::/80", "192.0.2.0/24"} {
+		if err := SetNAT64Prefix(prefix); err == nil {
+			t.Errorf("Expected an error for prefix %s", prefix)
+		}
+	}
+	if err := SetNAT64Prefix("64:ff9b::/96"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChooseProtocolNAT64(t *testing.T) {
+	if err := SetNAT64Prefix("64:ff9b::/96"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { nat64Prefix = nil }()
+
+	tests := []struct {
+		target   string
+		fallback bool
+		want     string
+		nat64    float64
+	}{
+		{"127.0.0.1", false, "64:ff9b::7f00:1", 1},
+		{"127.0.0.1", true, "64:ff9b::7f00:1", 1},
+		{"::1", true, "::1", 0},
+	}
+	for _, test := range tests {
+		registry := prometheus.NewRegistry()
+		ip, _, err := chooseProtocol(context.Background(), "ip6", test.fallback, test.target, registry, promslog.NewNopLogger())
+		if err != nil {
+			t.Fatalf("%s: %s", test.target, err)
+		}
+		if !ip.IP.Equal(net.ParseIP(test.want)) {
+			t.Errorf("%s: expected %s, got %s", test.target, test.want, ip)
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryResults(map[string]float64{"probe_ip_nat64": test.nat64, "probe_ip_protocol": 6}, mfs, t)
+	}
+}
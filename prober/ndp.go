@@ -0,0 +1,360 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// Options of Neighbor Discovery messages, see RFC 4861 section 4.6.
+const (
+	ndpOptionSourceLinkLayerAddress = 1
+	ndpOptionTargetLinkLayerAddress = 2
+	ndpOptionPrefixInformation      = 3
+)
+
+// ndpAllRouters is the address router solicitations are sent to.
+var ndpAllRouters = net.ParseIP("ff02::2")
+
+type ndpPrefix struct {
+	prefix        net.IPNet
+	validLifetime time.Duration
+}
+
+type routerAdvertisement struct {
+	managed        bool
+	otherConfig    bool
+	routerLifetime time.Duration
+	prefixes       []ndpPrefix
+}
+
+type neighborAdvertisement struct {
+	router           bool
+	solicited        bool
+	target           net.IP
+	linkLayerAddress net.HardwareAddr
+}
+
+// ndpOptions calls fn with the type and the data of each option of a Neighbor
+// Discovery message.
+func ndpOptions(b []byte, fn func(typ byte, data []byte) error) error {
+	for len(b) > 0 {
+		if len(b) < 2 || b[1] == 0 || int(b[1])*8 > len(b) {
+			return errors.New("malformed option")
+		}
+		l := int(b[1]) * 8
+		if err := fn(b[0], b[2:l]); err != nil {
+			return err
+		}
+		b = b[l:]
+	}
+	return nil
+}
+
+// parseRouterAdvertisement parses the body of a router advertisement, after
+// the type, code and checksum.
+func parseRouterAdvertisement(b []byte) (*routerAdvertisement, error) {
+	if len(b) < 12 {
+		return nil, errors.New("router advertisement too short")
+	}
+	ra := &routerAdvertisement{
+		managed:        b[1]&0x80 != 0,
+		otherConfig:    b[1]&0x40 != 0,
+		routerLifetime: time.Duration(binary.BigEndian.Uint16(b[2:4])) * time.Second,
+	}
+	err := ndpOptions(b[12:], func(typ byte, data []byte) error {
+		if typ != ndpOptionPrefixInformation {
+			return nil
+		}
+		if len(data) != 30 || data[0] > 128 {
+			return errors.New("malformed prefix information option")
+		}
+		mask := net.CIDRMask(int(data[0]), 128)
+		ra.prefixes = append(ra.prefixes, ndpPrefix{
+			prefix:        net.IPNet{IP: net.IP(slices.Clone(data[14:30])).Mask(mask), Mask: mask},
+			validLifetime: time.Duration(binary.BigEndian.Uint32(data[2:6])) * time.Second,
+		})
+		return nil
+	})
+	return ra, err
+}
+
+// parseNeighborAdvertisement parses the body of a neighbor advertisement,
+// after the type, code and checksum.
+func parseNeighborAdvertisement(b []byte) (*neighborAdvertisement, error) {
+	if len(b) < 20 {
+		return nil, errors.New("neighbor advertisement too short")
+	}
+	na := &neighborAdvertisement{
+		router:    b[0]&0x80 != 0,
+		solicited: b[0]&0x40 != 0,
+		target:    net.IP(slices.Clone(b[4:20])),
+	}
+	err := ndpOptions(b[20:], func(typ byte, data []byte) error {
+		if typ == ndpOptionTargetLinkLayerAddress {
+			na.linkLayerAddress = net.HardwareAddr(slices.Clone(data))
+		}
+		return nil
+	})
+	return na, err
+}
+
+// solicitedNodeAddress returns the multicast address neighbor solicitations
+// for ip are sent to, see RFC 4291 section 2.7.1.
+func solicitedNodeAddress(ip net.IP) net.IP {
+	addr := net.ParseIP("ff02::1:ff00:0")
+	copy(addr[13:], ip.To16()[13:])
+	return addr
+}
+
+// ndpSolicitation returns a router or neighbor solicitation for target, and
+// the address to send it to. The checksum is computed by the kernel.
+func ndpSolicitation(solicitation string, target net.IP, hwAddr net.HardwareAddr) ([]byte, net.IP, error) {
+	msg := icmp.Message{Type: ipv6.ICMPTypeRouterSolicitation}
+	body := make([]byte, 4)
+	dst := ndpAllRouters
+	if solicitation == "neighbor" {
+		msg.Type = ipv6.ICMPTypeNeighborSolicitation
+		body = append(body, target.To16()...)
+		dst = solicitedNodeAddress(target)
+	}
+	if len(hwAddr) > 0 {
+		option := make([]byte, (2+len(hwAddr)+7)/8*8)
+		option[0] = ndpOptionSourceLinkLayerAddress
+		option[1] = byte(len(option) / 8)
+		copy(option[2:], hwAddr)
+		body = append(body, option...)
+	}
+	msg.Body = &icmp.RawBody{Data: body}
+	b, err := msg.Marshal(nil)
+	return b, dst, err
+}
+
+// validRouterAdvertisement checks a router advertisement against the
+// expectations of the module.
+func validRouterAdvertisement(ra *routerAdvertisement, module config.NDPProbe, logger *slog.Logger) bool {
+	valid := true
+	if module.ExpectManaged != nil && ra.managed != *module.ExpectManaged {
+		logger.Error("Managed address configuration flag is not as expected", "managed", ra.managed)
+		valid = false
+	}
+	if module.ExpectOtherConfig != nil && ra.otherConfig != *module.ExpectOtherConfig {
+		logger.Error("Other configuration flag is not as expected", "other_config", ra.otherConfig)
+		valid = false
+	}
+	if module.MinRouterLifetime > 0 && ra.routerLifetime < module.MinRouterLifetime {
+		logger.Error("Router lifetime is below the minimum", "router_lifetime", ra.routerLifetime, "min_router_lifetime", module.MinRouterLifetime)
+		valid = false
+	}
+	for _, expected := range module.ExpectedPrefixes {
+		_, want, _ := net.ParseCIDR(expected)
+		if !slices.ContainsFunc(ra.prefixes, func(p ndpPrefix) bool { return p.prefix.String() == want.String() }) {
+			logger.Error("Expected prefix is not advertised", "prefix", want)
+			valid = false
+		}
+	}
+	return valid
+}
+
+// ProbeNDP sends a router or neighbor solicitation on a link, and validates
+// the advertisement answering it. The target is the address of the router or
+// neighbor expected to answer, or ff02::2 for router advertisements from any
+// router.
+func ProbeNDP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	probeNDPDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_ndp_duration_seconds",
+		Help: "Duration from the solicitation to the advertisement",
+	})
+	registry.MustRegister(probeNDPDuration)
+
+	addr, zone, _ := strings.Cut(target, "%")
+	ifName := module.NDP.Interface
+	if ifName == "" {
+		ifName = zone
+	}
+	if ifName == "" {
+		logger.Error("No interface to probe, set interface or add a zone to the target")
+		return false
+	}
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		logger.Error("Error looking up interface", "interface", ifName, "err", err)
+		return false
+	}
+	dst := net.ParseIP(addr)
+	if dst == nil || dst.To4() != nil {
+		logger.Error("Target is not an IPv6 address", "target", target)
+		return false
+	}
+	if module.NDP.Solicitation == "neighbor" && dst.IsMulticast() {
+		logger.Error("Neighbor solicitations need a unicast target", "target", target)
+		return false
+	}
+
+	request, group, err := ndpSolicitation(module.NDP.Solicitation, dst, ifi.HardwareAddr)
+	if err != nil {
+		logger.Error("Error marshalling solicitation", "err", err)
+		return false
+	}
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		logger.Error("Error listening to socket", "err", err)
+		return false
+	}
+	defer conn.Close()
+	pc := conn.IPv6PacketConn()
+	replyType := ipv6.ICMPTypeRouterAdvertisement
+	if module.NDP.Solicitation == "neighbor" {
+		replyType = ipv6.ICMPTypeNeighborAdvertisement
+	}
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(replyType)
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		logger.Debug("Failed to set ICMP filter", "err", err)
+	}
+	if err := pc.SetControlMessage(ipv6.FlagHopLimit|ipv6.FlagInterface, true); err != nil {
+		logger.Error("Error setting control message", "err", err)
+		return false
+	}
+	deadline, _ := ctx.Deadline()
+	if err := pc.SetReadDeadline(deadline); err != nil {
+		logger.Error("Error setting socket deadline", "err", err)
+		return false
+	}
+
+	logger.Info("Sending solicitation", "solicitation", module.NDP.Solicitation, "interface", ifi.Name, "dst", group)
+	start := time.Now()
+	// Neighbor Discovery messages are only valid with a hop limit of 255, which
+	// shows that they were not forwarded by a router.
+	if _, err := pc.WriteTo(request, &ipv6.ControlMessage{HopLimit: 255, IfIndex: ifi.Index}, &net.IPAddr{IP: group, Zone: ifi.Name}); err != nil {
+		logger.Error("Error sending solicitation", "err", err)
+		return false
+	}
+
+	probeNDPUnexpectedRouters := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_ndp_unexpected_router_advertisements",
+		Help: "Number of router advertisements received from other routers than the target",
+	})
+	if module.NDP.Solicitation == "router" {
+		registry.MustRegister(probeNDPUnexpectedRouters)
+	}
+	unexpectedRouters := 0
+
+	rb := make([]byte, 1500)
+	for {
+		n, cm, peer, err := pc.ReadFrom(rb)
+		if err != nil {
+			logger.Error("No advertisement received", "err", err)
+			return false
+		}
+		if cm != nil && (cm.IfIndex != ifi.Index || cm.HopLimit != 255) {
+			continue
+		}
+		msg, err := icmp.ParseMessage(58, rb[:n])
+		if err != nil || msg.Type != replyType {
+			continue
+		}
+		body, ok := msg.Body.(*icmp.RawBody)
+		if !ok {
+			continue
+		}
+		from := peer.(*net.IPAddr).IP
+
+		if module.NDP.Solicitation == "neighbor" {
+			na, err := parseNeighborAdvertisement(body.Data)
+			if err != nil {
+				logger.Debug("Ignoring malformed neighbor advertisement", "from", from, "err", err)
+				continue
+			}
+			if !na.target.Equal(dst) {
+				continue
+			}
+			probeNDPDuration.Set(time.Since(start).Seconds())
+			logger.Info("Received neighbor advertisement", "from", from, "router", na.router, "solicited", na.solicited, "link_layer_address", na.linkLayerAddress)
+			probeNDPNeighborInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "probe_ndp_neighbor_info",
+				Help: "Contains the link-layer address of the neighbor",
+			}, []string{"link_layer_address"})
+			probeNDPNeighborRouter := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_ndp_neighbor_router",
+				Help: "Indicates if the neighbor advertised itself as a router",
+			})
+			registry.MustRegister(probeNDPNeighborInfo, probeNDPNeighborRouter)
+			probeNDPNeighborInfo.WithLabelValues(na.linkLayerAddress.String()).Set(1)
+			if na.router {
+				probeNDPNeighborRouter.Set(1)
+			}
+			return true
+		}
+
+		ra, err := parseRouterAdvertisement(body.Data)
+		if err != nil {
+			logger.Debug("Ignoring malformed router advertisement", "from", from, "err", err)
+			continue
+		}
+		if !dst.IsMulticast() && !from.Equal(dst) {
+			logger.Warn("Router advertisement from an unexpected router", "from", from)
+			unexpectedRouters++
+			probeNDPUnexpectedRouters.Inc()
+			continue
+		}
+		probeNDPDuration.Set(time.Since(start).Seconds())
+		logger.Info("Received router advertisement", "from", from, "managed", ra.managed, "other_config", ra.otherConfig, "router_lifetime", ra.routerLifetime)
+		exportRouterAdvertisement(ra, registry)
+		return validRouterAdvertisement(ra, module.NDP, logger) && unexpectedRouters == 0
+	}
+}
+
+// exportRouterAdvertisement registers the metrics of a router advertisement.
+func exportRouterAdvertisement(ra *routerAdvertisement, registry *prometheus.Registry) {
+	probeNDPRouterLifetime := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_ndp_router_lifetime_seconds",
+		Help: "Returns the lifetime of the router as a default router, 0 if it is not one",
+	})
+	probeNDPFlags := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_ndp_router_flag",
+		Help: "Indicates if a flag of the router advertisement is set",
+	}, []string{"flag"})
+	probeNDPPrefixValidLifetime := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_ndp_prefix_valid_lifetime_seconds",
+		Help: "Returns the valid lifetime of the prefixes of the router advertisement",
+	}, []string{"prefix"})
+	registry.MustRegister(probeNDPRouterLifetime, probeNDPFlags, probeNDPPrefixValidLifetime)
+
+	probeNDPRouterLifetime.Set(ra.routerLifetime.Seconds())
+	for flag, set := range map[string]bool{"managed": ra.managed, "other_config": ra.otherConfig} {
+		if set {
+			probeNDPFlags.WithLabelValues(flag).Set(1)
+		} else {
+			probeNDPFlags.WithLabelValues(flag).Set(0)
+		}
+	}
+	for _, p := range ra.prefixes {
+		probeNDPPrefixValidLifetime.WithLabelValues(p.prefix.String()).Set(p.validLifetime.Seconds())
+	}
+}
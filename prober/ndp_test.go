@@ -0,0 +1,114 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	body := []byte{
+		64, 0x80, 0x07, 0x08, // Hop limit, M flag, router lifetime 1800s.
+		0, 0, 0, 0, 0, 0, 0, 0, // Reachable time and retransmission timer.
+		ndpOptionSourceLinkLayerAddress, 1, 0x02, 0, 0, 0, 0, 0x01,
+		ndpOptionPrefixInformation, 4, 64, 0xc0,
+		0, 0, 0x0e, 0x10, // Valid lifetime 3600s.
+		0, 0, 0x07, 0x08, // Preferred lifetime.
+		0, 0, 0, 0,
+	}
+	body = append(body, net.ParseIP("2001:db8:1::1")...)
+	ra, err := parseRouterAdvertisement(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ra.managed || ra.otherConfig || ra.routerLifetime != 30*time.Minute {
+		t.Errorf("Unexpected router advertisement: %+v", ra)
+	}
+	if len(ra.prefixes) != 1 || ra.prefixes[0].prefix.String() != "2001:db8:1::/64" || ra.prefixes[0].validLifetime != time.Hour {
+		t.Errorf("Unexpected prefixes: %+v", ra.prefixes)
+	}
+
+	logger := promslog.NewNopLogger()
+	managed, other := true, true
+	if !validRouterAdvertisement(ra, config.NDPProbe{ExpectedPrefixes: []string{"2001:db8:1::/64"}, ExpectManaged: &managed, MinRouterLifetime: 10 * time.Minute}, logger) {
+		t.Error("Expected the router advertisement to be valid")
+	}
+	for _, module := range []config.NDPProbe{
+		{ExpectedPrefixes: []string{"2001:db8:2::/64"}},
+		{ExpectOtherConfig: &other},
+		{MinRouterLifetime: time.Hour},
+	} {
+		if validRouterAdvertisement(ra, module, logger) {
+			t.Errorf("Expected the router advertisement to be invalid with %+v", module)
+		}
+	}
+
+	// Options have to fit in the message.
+	if _, err := parseRouterAdvertisement(body[:len(body)-8]); err == nil {
+		t.Error("Expected an error for a truncated option")
+	}
+}
+
+func TestParseNeighborAdvertisement(t *testing.T) {
+	body := append([]byte{0xc0, 0, 0, 0}, net.ParseIP("fe80::1")...)
+	body = append(body, ndpOptionTargetLinkLayerAddress, 1, 0x02, 0, 0, 0, 0, 0x01)
+	na, err := parseNeighborAdvertisement(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !na.router || !na.solicited || !na.target.Equal(net.ParseIP("fe80::1")) || na.linkLayerAddress.String() != "02:00:00:00:00:01" {
+		t.Errorf("Unexpected neighbor advertisement: %+v", na)
+	}
+}
+
+func TestNDPSolicitation(t *testing.T) {
+	hwAddr := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x02}
+	b, dst, err := ndpSolicitation("neighbor", net.ParseIP("2001:db8::12:3456"), hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Equal(net.ParseIP("ff02::1:ff12:3456")) {
+		t.Errorf("Expected the solicited-node address, got %s", dst)
+	}
+	// Type, code, checksum, reserved, target and the source link-layer option.
+	if len(b) != 8+16+8 || b[0] != 135 || !bytes.Equal(b[26:32], hwAddr) {
+		t.Errorf("Unexpected neighbor solicitation: %x", b)
+	}
+
+	b, dst, err = ndpSolicitation("router", net.ParseIP("fe80::1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Equal(ndpAllRouters) || len(b) != 8 || b[0] != 133 {
+		t.Errorf("Unexpected router solicitation to %s: %x", dst, b)
+	}
+}
+
+func TestNDPInvalidTarget(t *testing.T) {
+	module := config.Module{Timeout: time.Second, NDP: config.NDPProbe{Solicitation: "router"}}
+	for _, target := range []string{"fe80::1", "192.0.2.1%lo", "fe80::1%does-not-exist"} {
+		if ProbeNDP(context.Background(), target, module, prometheus.NewRegistry(), promslog.NewNopLogger()) {
+			t.Errorf("Expected the probe of %s to fail", target)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// ONC RPC over TCP, see RFC 5531, and the portmapper, see RFC 1833.
+const (
+	nfsDefaultPort        = "2049"
+	portmapperDefaultPort = "111"
+
+	nfsProgram        = 100003
+	portmapperProgram = 100000
+	portmapperVersion = 2
+	portmapperGetPort = 3
+	portmapperTCP     = 6
+
+	rpcVersion   = 2
+	rpcCall      = 0
+	rpcReply     = 1
+	rpcNull      = 0
+	rpcLastFrag  = 0x80000000
+	rpcMaxRecord = 1 << 16
+)
+
+// rpcAcceptErrors are the names of the errors of accepted RPC calls.
+var rpcAcceptErrors = map[uint32]string{
+	1: "program unavailable",
+	2: "program version mismatch",
+	3: "procedure unavailable",
+	4: "garbage arguments",
+	5: "system error",
+}
+
+// encodeRPCCall returns a call with null credentials as a single record.
+func encodeRPCCall(xid, program, version, procedure uint32, args []byte) []byte {
+	// The record mark is set once the length of the call is known.
+	b := make([]byte, 4, 44+len(args))
+	for _, v := range []uint32{xid, rpcCall, rpcVersion, program, version, procedure, 0, 0, 0, 0} {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	b = append(b, args...)
+	binary.BigEndian.PutUint32(b, rpcLastFrag|uint32(len(b)-4))
+	return b
+}
+
+// readRPCReply reads the reply to the call with the given transaction ID and
+// returns its results.
+func readRPCReply(r io.Reader, xid uint32) ([]byte, error) {
+	var record []byte
+	for {
+		var mark [4]byte
+		if _, err := io.ReadFull(r, mark[:]); err != nil {
+			return nil, err
+		}
+		size := binary.BigEndian.Uint32(mark[:]) &^ rpcLastFrag
+		if len(record)+int(size) > rpcMaxRecord {
+			return nil, fmt.Errorf("RPC reply larger than %d bytes", rpcMaxRecord)
+		}
+		fragment := make([]byte, size)
+		if _, err := io.ReadFull(r, fragment); err != nil {
+			return nil, err
+		}
+		record = append(record, fragment...)
+		if binary.BigEndian.Uint32(mark[:])&rpcLastFrag != 0 {
+			break
+		}
+	}
+
+	if len(record) < 16 {
+		return nil, errors.New("RPC reply too short")
+	}
+	if binary.BigEndian.Uint32(record) != xid || binary.BigEndian.Uint32(record[4:]) != rpcReply {
+		return nil, errors.New("unexpected RPC message")
+	}
+	if binary.BigEndian.Uint32(record[8:]) != 0 {
+		if binary.BigEndian.Uint32(record[12:]) == 0 {
+			return nil, errors.New("RPC call denied: RPC version mismatch")
+		}
+		return nil, errors.New("RPC call denied: authentication error")
+	}
+	// Skip the verifier, whose body is padded to a multiple of 4 bytes.
+	body := record[12:]
+	if len(body) < 8 {
+		return nil, errors.New("RPC reply too short")
+	}
+	verifierSize := (int(binary.BigEndian.Uint32(body[4:])) + 3) &^ 3
+	if len(body) < 12+verifierSize {
+		return nil, errors.New("RPC reply too short")
+	}
+	status, results := binary.BigEndian.Uint32(body[8+verifierSize:]), body[12+verifierSize:]
+	switch {
+	case status == 0:
+		return results, nil
+	case status == 2 && len(results) >= 8:
+		return nil, fmt.Errorf("program version mismatch, versions %d to %d are supported",
+			binary.BigEndian.Uint32(results), binary.BigEndian.Uint32(results[4:]))
+	}
+	name, ok := rpcAcceptErrors[status]
+	if !ok {
+		name = fmt.Sprintf("accept status %d", status)
+	}
+	return nil, fmt.Errorf("RPC call failed: %s", name)
+}
+
+// callRPC makes a call and returns its results.
+func callRPC(conn net.Conn, program, version, procedure uint32, args []byte) ([]byte, error) {
+	xid := rand.Uint32()
+	if _, err := conn.Write(encodeRPCCall(xid, program, version, procedure, args)); err != nil {
+		return nil, err
+	}
+	return readRPCReply(conn, xid)
+}
+
+// ProbeNFS calls the NULL procedure of the NFS service of the target, which
+// is only answered if the service is running, looking up its port with the
+// portmapper if so configured.
+func ProbeNFS(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_nfs_duration_seconds",
+			Help: "Duration of the NFS checks by phase",
+		}, []string{"phase"})
+		portGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_nfs_port",
+			Help: "The port of the NFS service registered with the portmapper",
+		})
+		versionGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_nfs_version",
+			Help: "The NFS version that answered the NULL call",
+		})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.NFS
+	defaultPort := nfsDefaultPort
+	if c.UsePortmapper {
+		defaultPort = portmapperDefaultPort
+	}
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, defaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+
+	if c.UsePortmapper {
+		start := time.Now()
+		conn, err := dialTCPIP(ctx, ip, port, c.SourceIPAddress, logger)
+		if err != nil {
+			logger.Error("Error dialing portmapper", "err", err)
+			return false
+		}
+		args := binary.BigEndian.AppendUint32(nil, nfsProgram)
+		args = binary.BigEndian.AppendUint32(args, c.Version)
+		args = binary.BigEndian.AppendUint32(args, portmapperTCP)
+		args = binary.BigEndian.AppendUint32(args, 0)
+		results, err := callRPC(conn, portmapperProgram, portmapperVersion, portmapperGetPort, args)
+		conn.Close()
+		durationGaugeVec.WithLabelValues("portmap").Set(time.Since(start).Seconds())
+		if err == nil && len(results) < 4 {
+			err = errors.New("RPC reply too short")
+		}
+		if err != nil {
+			logger.Error("Error looking up NFS port", "err", err)
+			return false
+		}
+		nfsPort := binary.BigEndian.Uint32(results)
+		if nfsPort == 0 || nfsPort > 65535 {
+			logger.Error("NFS version is not registered with the portmapper", "version", c.Version)
+			return false
+		}
+		registry.MustRegister(portGauge)
+		portGauge.Set(float64(nfsPort))
+		port = strconv.Itoa(int(nfsPort))
+		logger.Info("Looked up NFS port", "port", port)
+	}
+
+	start := time.Now()
+	conn, err := dialTCPIP(ctx, ip, port, c.SourceIPAddress, logger)
+	durationGaugeVec.WithLabelValues("connect").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error dialing NFS server", "err", err)
+		return false
+	}
+	defer conn.Close()
+
+	start = time.Now()
+	_, err = callRPC(conn, nfsProgram, c.Version, rpcNull, nil)
+	durationGaugeVec.WithLabelValues("null").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error calling NFS NULL procedure", "version", c.Version, "err", err)
+		return false
+	}
+	registry.MustRegister(versionGauge)
+	versionGauge.Set(float64(c.Version))
+	logger.Info("NFS server answered the NULL call", "version", c.Version)
+	return true
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startRPCTestServer answers the calls it receives with handle, which returns
+// the accept status and results of a call.
+func startRPCTestServer(t *testing.T, handle func(program, version, procedure uint32, args []byte) (uint32, []byte)) string {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(time.Second))
+				var mark [4]byte
+				if _, err := io.ReadFull(conn, mark[:]); err != nil {
+					return
+				}
+				call := make([]byte, binary.BigEndian.Uint32(mark[:])&^rpcLastFrag)
+				if _, err := io.ReadFull(conn, call); err != nil || len(call) < 40 {
+					return
+				}
+				status, results := handle(binary.BigEndian.Uint32(call[12:]), binary.BigEndian.Uint32(call[16:]), binary.BigEndian.Uint32(call[20:]), call[40:])
+				reply := make([]byte, 4)
+				// The transaction ID, an accepted reply and a null verifier.
+				reply = append(reply, call[:4]...)
+				for _, v := range []uint32{rpcReply, 0, 0, 0, status} {
+					reply = binary.BigEndian.AppendUint32(reply, v)
+				}
+				reply = append(reply, results...)
+				binary.BigEndian.PutUint32(reply, rpcLastFrag|uint32(len(reply)-4))
+				conn.Write(reply)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestNFS(t *testing.T) {
+	// An NFS server supporting versions 3 and 4.
+	nfs := startRPCTestServer(t, func(program, version, procedure uint32, _ []byte) (uint32, []byte) {
+		switch {
+		case program != nfsProgram:
+			return 1, nil
+		case version < 3 || version > 4:
+			return 2, []byte{0, 0, 0, 3, 0, 0, 0, 4}
+		case procedure != rpcNull:
+			return 3, nil
+		}
+		return 0, nil
+	})
+	_, nfsPort, _ := net.SplitHostPort(nfs)
+	port, _ := strconv.Atoi(nfsPort)
+	portmapper := startRPCTestServer(t, func(program, version, procedure uint32, args []byte) (uint32, []byte) {
+		if program != portmapperProgram || version != portmapperVersion || procedure != portmapperGetPort || len(args) != 16 {
+			return 4, nil
+		}
+		if binary.BigEndian.Uint32(args[4:]) != 3 {
+			return 0, []byte{0, 0, 0, 0}
+		}
+		return 0, binary.BigEndian.AppendUint32(nil, uint32(port))
+	})
+
+	tests := []struct {
+		name     string
+		target   string
+		probe    config.NFSProbe
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "null call",
+			target:   nfs,
+			probe:    config.NFSProbe{Version: 4},
+			success:  true,
+			expected: map[string]float64{"probe_nfs_version": 4},
+		},
+		{
+			name:   "unsupported version",
+			target: nfs,
+			probe:  config.NFSProbe{Version: 2},
+		},
+		{
+			name:     "portmapper",
+			target:   portmapper,
+			probe:    config.NFSProbe{Version: 3, UsePortmapper: true},
+			success:  true,
+			expected: map[string]float64{"probe_nfs_version": 3, "probe_nfs_port": float64(port)},
+		},
+		{
+			name:   "not registered",
+			target: portmapper,
+			probe:  config.NFSProbe{Version: 4, UsePortmapper: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.probe.IPProtocol = "ip4"
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if success := ProbeNFS(testCTX, test.target, config.Module{NFS: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
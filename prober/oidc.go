@@ -0,0 +1,236 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// maxJSONDocumentSize is the maximum size of the documents read by the oidc
+// prober and the JWT validator.
+const maxJSONDocumentSize = 1 << 20
+
+// oidcTokenResponse is the response of the token endpoint, see RFC 6749.
+type oidcTokenResponse struct {
+	AccessToken      string  `json:"access_token"`
+	ExpiresIn        float64 `json:"expires_in"`
+	Error            string  `json:"error"`
+	ErrorDescription string  `json:"error_description"`
+}
+
+// getJSONDocument decodes the JSON document returned by a request.
+func getJSONDocument(client *http.Client, req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgentDefaultHeader)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJSONDocumentSize))
+	if err != nil {
+		return err
+	}
+	// Token errors are returned with a 400 or 401 status code.
+	if err := json.Unmarshal(body, v); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// earliestJWKSCertExpiry returns the earliest expiry of the certificates of
+// the keys, or the zero time if they have none.
+func earliestJWKSCertExpiry(jwks jsonWebKeySet) (time.Time, error) {
+	var earliest time.Time
+	for _, key := range jwks.Keys {
+		expiry, err := key.certExpiry()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !expiry.IsZero() && (earliest.IsZero() || expiry.Before(earliest)) {
+			earliest = expiry
+		}
+	}
+	return earliest, nil
+}
+
+// ProbeOIDC fetches the discovery document of an OpenID Connect provider and
+// its keys, and requests a token with the client credentials grant if a
+// client is configured.
+func ProbeOIDC(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_oidc_duration_seconds",
+			Help: "Duration of the OpenID Connect checks by phase",
+		}, []string{"phase"})
+		stepSuccessGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_oidc_step_success",
+			Help: "Indicates if a step of the OpenID Connect checks succeeded",
+		}, []string{"step"})
+		keysGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_oidc_jwks_keys",
+			Help: "The number of keys of the JSON Web Key Set",
+		})
+		certExpiryGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_oidc_jwks_earliest_cert_expiry",
+			Help: "Returns earliest certificate expiry of the keys in unixtime",
+		})
+		tokenExpiresInGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_oidc_token_expires_in_seconds",
+			Help: "The lifetime of the access token returned by the token endpoint",
+		})
+	)
+	registry.MustRegister(durationGaugeVec, stepSuccessGaugeVec)
+
+	c := module.OIDC
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "https://" + target
+	}
+	issuer := strings.TrimSuffix(target, "/")
+
+	client, err := pconfig.NewClientFromConfig(c.HTTPClientConfig, "oidc_probe", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating HTTP client", "err", err)
+		return false
+	}
+
+	// step runs a step of the checks and records its outcome.
+	step := func(name string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		durationGaugeVec.WithLabelValues(name).Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("OpenID Connect check failed", "step", name, "err", err)
+			stepSuccessGaugeVec.WithLabelValues(name).Set(0)
+			return false
+		}
+		stepSuccessGaugeVec.WithLabelValues(name).Set(1)
+		return true
+	}
+
+	var discovery map[string]interface{}
+	if !step("discovery", func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+		if err != nil {
+			return err
+		}
+		if err := getJSONDocument(client, req, &discovery); err != nil {
+			return err
+		}
+		for _, field := range c.RequiredFields {
+			if v, ok := discovery[field]; !ok || v == nil || v == "" {
+				return fmt.Errorf("missing field %q", field)
+			}
+		}
+		// The issuer must be identical to the URL the document was
+		// retrieved from.
+		if v, ok := discovery["issuer"].(string); ok && strings.TrimSuffix(v, "/") != issuer {
+			return fmt.Errorf("issuer %q does not match the target", v)
+		}
+		return nil
+	}) {
+		return false
+	}
+
+	if !step("jwks", func() error {
+		jwksURI, _ := discovery["jwks_uri"].(string)
+		if jwksURI == "" {
+			return errors.New("missing field \"jwks_uri\"")
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+		if err != nil {
+			return err
+		}
+		var jwks jsonWebKeySet
+		if err := getJSONDocument(client, req, &jwks); err != nil {
+			return err
+		}
+		registry.MustRegister(keysGauge)
+		keysGauge.Set(float64(len(jwks.Keys)))
+		if len(jwks.Keys) == 0 {
+			return errors.New("no keys")
+		}
+		expiry, err := earliestJWKSCertExpiry(jwks)
+		if err != nil {
+			return err
+		}
+		if expiry.IsZero() {
+			return nil
+		}
+		registry.MustRegister(certExpiryGauge)
+		certExpiryGauge.Set(float64(expiry.Unix()))
+		if expiry.Before(time.Now()) {
+			return fmt.Errorf("certificate of a key expired at %s", expiry)
+		}
+		return nil
+	}) {
+		return false
+	}
+
+	if c.ClientID == "" {
+		return true
+	}
+	return step("token", func() error {
+		tokenEndpoint, _ := discovery["token_endpoint"].(string)
+		if tokenEndpoint == "" {
+			return errors.New("missing field \"token_endpoint\"")
+		}
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if len(c.Scopes) > 0 {
+			form.Set("scope", strings.Join(c.Scopes, " "))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(string(c.ClientSecret)))
+		var token oidcTokenResponse
+		err = getJSONDocument(client, req, &token)
+		if token.Error != "" {
+			return fmt.Errorf("token request rejected: %s %s", token.Error, token.ErrorDescription)
+		}
+		if err != nil {
+			return err
+		}
+		if token.AccessToken == "" {
+			return errors.New("no access token")
+		}
+		if token.ExpiresIn > 0 {
+			registry.MustRegister(tokenExpiresInGauge)
+			tokenExpiresInGauge.Set(token.ExpiresIn)
+		}
+		return nil
+	})
+}
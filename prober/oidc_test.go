@@ -0,0 +1,174 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// oidcTestProvider serves the discovery document, keys and token endpoint
+// of an OpenID Connect provider.
+type oidcTestProvider struct {
+	issuer     string
+	certExpiry time.Time
+	noKeys     bool
+}
+
+func (p *oidcTestProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.issuer,
+			"authorization_endpoint":                p.issuer + "/authorize",
+			"token_endpoint":                        p.issuer + "/token",
+			"jwks_uri":                              p.issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	case "/jwks":
+		keys := []map[string]interface{}{}
+		if !p.noKeys {
+			cert, _, _ := generateSelfSignedCertificate(generateCertificateTemplate(p.certExpiry, false))
+			keys = append(keys, map[string]interface{}{
+				"kty": "RSA",
+				"kid": "1",
+				"x5c": []string{base64.StdEncoding.EncodeToString(cert.Raw)},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	case "/token":
+		if id, secret, _ := r.BasicAuth(); id != "monitor" || secret != "secret" || r.PostFormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":300}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	expiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name     string
+		provider oidcTestProvider
+		probe    config.OIDCProbe
+		success  bool
+		steps    map[string]float64
+	}{
+		{
+			name:    "discovery and keys",
+			success: true,
+			steps:   map[string]float64{"discovery": 1, "jwks": 1},
+		},
+		{
+			name:    "token",
+			probe:   config.OIDCProbe{ClientID: "monitor", ClientSecret: "secret"},
+			success: true,
+			steps:   map[string]float64{"discovery": 1, "jwks": 1, "token": 1},
+		},
+		{
+			name:  "token rejected",
+			probe: config.OIDCProbe{ClientID: "monitor", ClientSecret: "wrong"},
+			steps: map[string]float64{"discovery": 1, "jwks": 1, "token": 0},
+		},
+		{
+			name:  "missing field",
+			probe: config.OIDCProbe{RequiredFields: []string{"issuer", "userinfo_endpoint"}},
+			steps: map[string]float64{"discovery": 0},
+		},
+		{
+			name:     "wrong issuer",
+			provider: oidcTestProvider{issuer: "https://accounts.example.com"},
+			steps:    map[string]float64{"discovery": 0},
+		},
+		{
+			name:     "no keys",
+			provider: oidcTestProvider{noKeys: true},
+			steps:    map[string]float64{"discovery": 1, "jwks": 0},
+		},
+		{
+			name:     "expired key",
+			provider: oidcTestProvider{certExpiry: time.Now().Add(-time.Hour)},
+			steps:    map[string]float64{"discovery": 1, "jwks": 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := test.provider
+			if provider.certExpiry.IsZero() {
+				provider.certExpiry = expiry
+			}
+			ts := httptest.NewServer(&provider)
+			defer ts.Close()
+			if provider.issuer == "" {
+				provider.issuer = ts.URL
+			}
+
+			c := test.probe
+			if c.RequiredFields == nil {
+				c.RequiredFields = config.DefaultOIDCProbe.RequiredFields
+			}
+			c.HTTPClientConfig = config.DefaultOIDCProbe.HTTPClientConfig
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if success := ProbeOIDC(testCTX, ts.URL, config.Module{OIDC: c}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mf := range mfs {
+				if mf.GetName() != "probe_oidc_step_success" {
+					continue
+				}
+				if len(mf.Metric) != len(test.steps) {
+					t.Errorf("Expected steps %v, got %v", test.steps, mf.Metric)
+				}
+				for _, m := range mf.Metric {
+					if v, ok := test.steps[m.Label[0].GetValue()]; !ok || v != m.GetGauge().GetValue() {
+						t.Errorf("Unexpected step %s: %v", m.Label[0].GetValue(), m.GetGauge().GetValue())
+					}
+				}
+			}
+			if !test.success {
+				return
+			}
+			expected := map[string]float64{
+				"probe_oidc_jwks_keys":                 1,
+				"probe_oidc_jwks_earliest_cert_expiry": float64(expiry.Unix()),
+			}
+			if test.probe.ClientID != "" {
+				expected["probe_oidc_token_expires_in_seconds"] = 300
+			}
+			checkRegistryResults(expected, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// headerOverridePrefix starts the parameters overriding HTTP headers, such as
+// header.X-Tenant=acme.
+const headerOverridePrefix = "header."
+
+// urlOverride returns the field of the module a URL parameter overrides, if
+// any.
+func urlOverride(param string) string {
+	if strings.HasPrefix(param, headerOverridePrefix) {
+		return "headers"
+	}
+	if _, ok := config.URLOverrideProbers[param]; ok && param != "headers" {
+		return param
+	}
+	return ""
+}
+
+// applyURLOverrides overrides the fields of the module listed in its
+// url_overrides with the parameters of the probe request. It returns the
+// parameters applied, which tell apart the probes of a target.
+func applyURLOverrides(module *config.Module, params url.Values) (string, error) {
+	applied := url.Values{}
+	for param, values := range params {
+		override := urlOverride(param)
+		if override == "" {
+			continue
+		}
+		if !slices.Contains(module.URLOverrides, override) {
+			return "", fmt.Errorf("the module does not allow overriding %s with URL parameters", override)
+		}
+		applied[param] = values
+	}
+	if len(applied) == 0 {
+		return "", nil
+	}
+
+	if v := applied.Get("timeout"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return "", fmt.Errorf("timeout %q is not a positive duration", v)
+		}
+		module.Timeout = timeout
+	}
+	if v := applied.Get("query_name"); v != "" {
+		module.DNS.QueryName = v
+	}
+	if v := applied.Get("query_response"); v != "" {
+		var steps []config.QueryResponse
+		if err := yaml.Unmarshal([]byte(v), &steps); err != nil {
+			return "", fmt.Errorf("error parsing query_response: %w", err)
+		}
+		module.TCP.QueryResponse = steps
+	}
+
+	// The headers of the configuration are copied, so that they are not
+	// changed for other probes.
+	headers := make(map[string]string, len(module.HTTP.Headers))
+	for name, value := range module.HTTP.Headers {
+		headers[name] = value
+	}
+	overridden := false
+	for param := range applied {
+		name, ok := strings.CutPrefix(param, headerOverridePrefix)
+		if !ok {
+			continue
+		}
+		value := applied.Get(param)
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return "", fmt.Errorf("header %q is not valid", name)
+		}
+		// Replace the header whatever the case of its name in the module.
+		for configured := range headers {
+			if http.CanonicalHeaderKey(configured) == http.CanonicalHeaderKey(name) {
+				delete(headers, configured)
+			}
+		}
+		headers[name] = value
+		overridden = true
+	}
+	if overridden {
+		module.HTTP.Headers = headers
+	}
+	return applied.Encode(), nil
+}
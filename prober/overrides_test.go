@@ -0,0 +1,120 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestApplyURLOverrides(t *testing.T) {
+	headers := map[string]string{"x-tenant": "default", "Accept": "text/html"}
+	module := config.Module{
+		Prober:       "http",
+		Timeout:      5 * time.Second,
+		URLOverrides: []string{"timeout", "headers"},
+		HTTP:         config.HTTPProbe{Headers: headers},
+	}
+	applied, err := applyURLOverrides(&module, url.Values{
+		"target":          {"https://example.com"},
+		"timeout":         {"2s"},
+		"header.X-Tenant": {"acme"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != "header.X-Tenant=acme&timeout=2s" {
+		t.Errorf("Unexpected applied overrides %q", applied)
+	}
+	if module.Timeout != 2*time.Second {
+		t.Errorf("Expected timeout 2s, got %s", module.Timeout)
+	}
+	if len(module.HTTP.Headers) != 2 || module.HTTP.Headers["X-Tenant"] != "acme" || module.HTTP.Headers["Accept"] != "text/html" {
+		t.Errorf("Unexpected headers %v", module.HTTP.Headers)
+	}
+	if headers["x-tenant"] != "default" {
+		t.Errorf("The headers of the configuration were changed: %v", headers)
+	}
+
+	tcpModule := config.Module{Prober: "tcp", URLOverrides: []string{"query_response"}}
+	if _, err := applyURLOverrides(&tcpModule, url.Values{"query_response": {`[{"send": "PING"}, {"expect": "^PONG"}]`}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tcpModule.TCP.QueryResponse) != 2 || tcpModule.TCP.QueryResponse[0].Send != "PING" || tcpModule.TCP.QueryResponse[1].Expect.String() != "^PONG" {
+		t.Errorf("Unexpected query_response %+v", tcpModule.TCP.QueryResponse)
+	}
+
+	for _, params := range []url.Values{
+		{"query_name": {"example.com"}},
+		{"timeout": {"-1s"}},
+		{"header.Bad Header": {"value"}},
+	} {
+		m := config.Module{Prober: "http", URLOverrides: []string{"timeout", "headers"}}
+		if _, err := applyURLOverrides(&m, params); err == nil {
+			t.Errorf("Expected an error overriding %v", params)
+		}
+	}
+}
+
+func TestURLOverridesParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{
+		Modules: map[string]config.Module{
+			"http_tenant": {
+				Prober:       "http",
+				Timeout:      10 * time.Second,
+				URLOverrides: []string{"headers"},
+				HTTP:         config.HTTPProbe{IPProtocolFallback: true},
+			},
+			"http_2xx": {
+				Prober:  "http",
+				Timeout: 10 * time.Second,
+				HTTP:    config.HTTPProbe{IPProtocolFallback: true},
+			},
+		},
+	}
+	for _, test := range []struct {
+		module string
+		status int
+		body   string
+	}{
+		{"http_tenant", http.StatusOK, "probe_success 1"},
+		{"http_2xx", http.StatusBadRequest, "does not allow overriding headers"},
+	} {
+		req, err := http.NewRequest("GET", fmt.Sprintf("?module=%s&target=%s&header.X-Tenant=acme", test.module, ts.URL), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, c, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		if rr.Code != test.status || !strings.Contains(rr.Body.String(), test.body) {
+			t.Errorf("%s: unexpected response %d: %s", test.module, rr.Code, rr.Body.String())
+		}
+	}
+}
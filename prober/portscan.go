@@ -0,0 +1,146 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// portScanResult is the outcome of the connection attempt to a port.
+type portScanResult struct {
+	scanned bool
+	open    bool
+}
+
+// ProbePortScan attempts TCP connections to the configured ports of the
+// target and reports the open ports. If allowed ports are configured, the
+// probe fails when any other port is open.
+func ProbePortScan(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		portOpenGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_portscan_port_open",
+			Help: "Indicates that the port accepted the connection",
+		}, []string{"port"})
+		portsScannedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_portscan_ports_scanned",
+			Help: "Number of ports a connection was attempted to",
+		})
+		portsOpenGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_portscan_ports_open",
+			Help: "Number of ports that accepted the connection",
+		})
+		unexpectedPortsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_portscan_unexpected_ports_open",
+			Help: "Number of open ports that are not allowed",
+		})
+	)
+	registry.MustRegister(portOpenGaugeVec, portsScannedGauge, portsOpenGauge)
+
+	ports := module.PortScan.Ports.List()
+	if len(ports) == 0 {
+		logger.Error("No ports to scan")
+		return false
+	}
+
+	ip, _, err := chooseProtocol(ctx, module.PortScan.IPProtocol, module.PortScan.IPProtocolFallback, target, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	dialProtocol := "tcp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "tcp6"
+	}
+
+	dialer := &net.Dialer{Timeout: module.PortScan.ConnectTimeout}
+	if len(module.PortScan.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(module.PortScan.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", module.PortScan.SourceIPAddress)
+			return false
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.TCPAddr{IP: srcIP}
+	}
+
+	logger.Info("Scanning ports", "ports", len(ports), "concurrency", module.PortScan.Concurrency)
+	results := make([]portScanResult, len(ports))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < module.PortScan.Concurrency && i < len(ports); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), strconv.Itoa(ports[i])))
+				if err == nil {
+					conn.Close()
+					results[i] = portScanResult{scanned: true, open: true}
+					continue
+				}
+				// Connection attempts interrupted by the end of the probe
+				// say nothing about the port.
+				results[i] = portScanResult{scanned: ctx.Err() == nil}
+			}
+		}()
+	}
+	for i := range ports {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var scanned, open, unexpected int
+	for i, result := range results {
+		if !result.scanned {
+			continue
+		}
+		scanned++
+		if !result.open {
+			continue
+		}
+		open++
+		portOpenGaugeVec.WithLabelValues(strconv.Itoa(ports[i])).Set(1)
+		if len(module.PortScan.AllowedPorts.List()) > 0 && !module.PortScan.AllowedPorts.Contains(ports[i]) {
+			logger.Error("Unexpected port is open", "port", ports[i])
+			unexpected++
+		}
+	}
+	portsScannedGauge.Set(float64(scanned))
+	portsOpenGauge.Set(float64(open))
+	logger.Info("Scanned ports", "scanned", scanned, "open", open)
+
+	success := true
+	if len(module.PortScan.AllowedPorts.List()) > 0 {
+		registry.MustRegister(unexpectedPortsGauge)
+		unexpectedPortsGauge.Set(float64(unexpected))
+		success = unexpected == 0
+	}
+	if scanned < len(ports) {
+		logger.Error("Probe timed out before all ports were scanned", "scanned", scanned, "ports", len(ports))
+		return false
+	}
+	return success
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProbePortScan(t *testing.T) {
+	var openPorts []int
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen("tcp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Error listening on socket: %s", err)
+		}
+		defer ln.Close()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+			}
+		}()
+		openPorts = append(openPorts, ln.Addr().(*net.TCPAddr).Port)
+	}
+	closed, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	ports := config.MustNewPorts(fmt.Sprintf("%d,%d,%d", openPorts[0], openPorts[1], closedPort))
+
+	tests := []struct {
+		allowedPorts       config.Ports
+		expectedResult     bool
+		expectedUnexpected float64
+	}{
+		{expectedResult: true},
+		{allowedPorts: config.MustNewPorts(fmt.Sprintf("%d,%d", openPorts[0], openPorts[1])), expectedResult: true},
+		{allowedPorts: config.MustNewPorts(strconv.Itoa(openPorts[0])), expectedResult: false, expectedUnexpected: 1},
+	}
+	for i, test := range tests {
+		module := config.Module{PortScan: config.PortScanProbe{
+			IPProtocol:         "ip4",
+			IPProtocolFallback: true,
+			Ports:              ports,
+			AllowedPorts:       test.allowedPorts,
+			ConnectTimeout:     time.Second,
+			Concurrency:        2,
+		}}
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if result := ProbePortScan(testCTX, "127.0.0.1", module, registry, promslog.NewNopLogger()); result != test.expectedResult {
+			t.Fatalf("Test %d: expected result %t, got %t", i, test.expectedResult, result)
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedResults := map[string]float64{
+			"probe_portscan_ports_scanned": 3,
+			"probe_portscan_ports_open":    2,
+		}
+		if len(test.allowedPorts.List()) > 0 {
+			expectedResults["probe_portscan_unexpected_ports_open"] = test.expectedUnexpected
+		} else {
+			checkAbsentMetrics([]string{"probe_portscan_unexpected_ports_open"}, mfs, t)
+		}
+		checkRegistryResults(expectedResults, mfs, t)
+
+		open := map[string]bool{}
+		for _, mf := range mfs {
+			if mf.GetName() != "probe_portscan_port_open" {
+				continue
+			}
+			for _, m := range mf.Metric {
+				open[m.Label[0].GetValue()] = true
+			}
+		}
+		if len(open) != 2 || !open[strconv.Itoa(openPorts[0])] || !open[strconv.Itoa(openPorts[1])] {
+			t.Fatalf("Test %d: unexpected open ports %v", i, open)
+		}
+	}
+}
+
+func TestProbePortScanTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	module := config.Module{PortScan: config.PortScanProbe{
+		IPProtocol:     "ip4",
+		Ports:          config.MustNewPorts(strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)),
+		ConnectTimeout: time.Second,
+		Concurrency:    1,
+	}}
+	// The probe ends before any port is scanned.
+	testCTX, cancel := context.WithCancel(context.Background())
+	cancel()
+	registry := prometheus.NewRegistry()
+	if ProbePortScan(testCTX, "127.0.0.1", module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Port scan succeeded, expected failure")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{"probe_portscan_ports_scanned": 0}, mfs, t)
+}
@@ -0,0 +1,284 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// proxyRegionLabel is the label added to the metrics relayed from the
+// exporter of a region.
+const proxyRegionLabel = "probe_region"
+
+// relayCollector exports the metric families scraped from another exporter,
+// with an additional constant label.
+type relayCollector struct {
+	mfs        map[string]*dto.MetricFamily
+	labelName  string
+	labelValue string
+}
+
+// Describe implements prometheus.Collector. The metrics are only known once
+// they have been scraped, so relayCollector is an unchecked collector.
+func (c *relayCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *relayCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, mf := range c.mfs {
+		for _, m := range mf.Metric {
+			var (
+				labelNames  []string
+				labelValues []string
+				hasLabel    bool
+			)
+			for _, lp := range m.Label {
+				labelNames = append(labelNames, lp.GetName())
+				labelValues = append(labelValues, lp.GetValue())
+				hasLabel = hasLabel || lp.GetName() == c.labelName
+			}
+			// Metrics relayed through several exporters keep the label
+			// of the first one.
+			if !hasLabel {
+				labelNames = append(labelNames, c.labelName)
+				labelValues = append(labelValues, c.labelValue)
+			}
+			desc := prometheus.NewDesc(name, mf.GetHelp(), labelNames, nil)
+			metric, err := relayMetric(desc, mf.GetType(), m, labelValues)
+			if err != nil {
+				metric = prometheus.NewInvalidMetric(desc, err)
+			}
+			ch <- metric
+		}
+	}
+}
+
+func relayMetric(desc *prometheus.Desc, metricType dto.MetricType, m *dto.Metric, labelValues []string) (prometheus.Metric, error) {
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+	case dto.MetricType_GAUGE:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+	case dto.MetricType_UNTYPED:
+		return prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := map[float64]uint64{}
+		for _, b := range m.GetHistogram().GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		return prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(), buckets, labelValues...)
+	case dto.MetricType_SUMMARY:
+		quantiles := map[float64]float64{}
+		for _, q := range m.GetSummary().GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		return prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(), quantiles, labelValues...)
+	default:
+		return nil, fmt.Errorf("unsupported metric type %s", metricType)
+	}
+}
+
+// scrapeRegion runs the probe on the exporter of a region and returns the
+// metric families it exported.
+func scrapeRegion(ctx context.Context, client *http.Client, exporterURL, target string, module config.Module, logger *slog.Logger) (map[string]*dto.MetricFamily, error) {
+	probeURL, err := url.JoinPath(exporterURL, "probe")
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probeURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("target", target)
+	q.Set("module", module.Proxy.Module)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	req.Header.Set("User-Agent", userAgentDefaultHeader)
+	// Let the remote exporter finish its probe before this one times out.
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(time.Until(deadline).Seconds(), 'f', 3, 64))
+	}
+
+	logger.Info("Forwarding probe", "url", u.String())
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+// proxyRegions returns the regions to probe. The region may be a
+// comma-separated list of regions, or "all". Regions listed several times
+// are probed once, their metrics would collide.
+func proxyRegions(proxy config.ProxyProbe) []string {
+	if proxy.Region == "all" {
+		regions := make([]string, 0, len(proxy.Regions))
+		for region := range proxy.Regions {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+		return regions
+	}
+	var regions []string
+	for _, region := range strings.Split(proxy.Region, ",") {
+		if region = strings.TrimSpace(region); region != "" && !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// regionResult is the outcome of the probe of a region.
+type regionResult struct {
+	region   string
+	mfs      map[string]*dto.MetricFamily
+	success  bool
+	duration float64
+}
+
+// probeRegion forwards the probe to the exporter of a region.
+func probeRegion(ctx context.Context, client *http.Client, region, target string, module config.Module, logger *slog.Logger) regionResult {
+	result := regionResult{region: region}
+	start := time.Now()
+	mfs, err := scrapeRegion(ctx, client, module.Proxy.Regions[region], target, module, logger)
+	result.duration = time.Since(start).Seconds()
+	if err != nil {
+		logger.Error("Error forwarding probe", "region", region, "err", err)
+		return result
+	}
+
+	if mf, ok := mfs["probe_success"]; ok && len(mf.Metric) == 1 {
+		result.success = mf.Metric[0].GetGauge().GetValue() == 1
+	}
+	if mf, ok := mfs["probe_duration_seconds"]; ok && len(mf.Metric) == 1 {
+		result.duration = mf.Metric[0].GetGauge().GetValue()
+	}
+	// The outcome of the probe is reported by the metrics of this exporter.
+	delete(mfs, "probe_success")
+	delete(mfs, "probe_duration_seconds")
+	result.mfs = mfs
+	logger.Info("Relayed probe result", "region", region, "success", result.success)
+	return result
+}
+
+// ProbeProxy forwards the probe to the blackbox exporters of one or more
+// regions and relays the metrics they return. When several regions are
+// probed, the probe succeeds if enough of them succeeded.
+func ProbeProxy(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	regions := proxyRegions(module.Proxy)
+	if len(regions) == 0 {
+		logger.Error("No region to probe")
+		return false
+	}
+	for _, region := range regions {
+		if _, ok := module.Proxy.Regions[region]; !ok {
+			logger.Error("Unknown region", "region", region)
+			return false
+		}
+	}
+
+	client, err := pconfig.NewClientFromConfig(module.Proxy.HTTPClientConfig, "proxy_probe", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating HTTP client", "err", err)
+		return false
+	}
+
+	if len(regions) == 1 {
+		result := probeRegion(ctx, client, regions[0], target, module, logger)
+		if result.mfs != nil {
+			registry.MustRegister(&relayCollector{mfs: result.mfs, labelName: proxyRegionLabel, labelValue: result.region})
+		}
+		return result.success
+	}
+
+	var (
+		regionSuccessGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_region_success",
+			Help: "Displays whether or not the probe from the region was a success",
+		}, []string{proxyRegionLabel})
+		regionDurationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_region_duration_seconds",
+			Help: "Returns how long the probe from the region took to complete in seconds",
+		}, []string{proxyRegionLabel})
+		successRatioGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_success_ratio_across_regions",
+			Help: "Ratio of the probed regions from which the probe was a success",
+		})
+	)
+	registry.MustRegister(regionSuccessGaugeVec, regionDurationGaugeVec, successRatioGauge)
+
+	results := make([]regionResult, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			results[i] = probeRegion(ctx, client, region, target, module, logger)
+		}(i, region)
+	}
+	wg.Wait()
+
+	successful := 0
+	for _, result := range results {
+		if result.mfs != nil {
+			registry.MustRegister(&relayCollector{mfs: result.mfs, labelName: proxyRegionLabel, labelValue: result.region})
+		}
+		regionDurationGaugeVec.WithLabelValues(result.region).Set(result.duration)
+		if result.success {
+			successful++
+			regionSuccessGaugeVec.WithLabelValues(result.region).Set(1)
+		} else {
+			regionSuccessGaugeVec.WithLabelValues(result.region).Set(0)
+		}
+	}
+	successRatioGauge.Set(float64(successful) / float64(len(regions)))
+
+	required := module.Proxy.MinSuccessfulRegions
+	if required == 0 || required > len(regions) {
+		required = len(regions)
+	}
+	if successful < required {
+		logger.Error("Probe did not succeed from enough regions", "successful_regions", successful, "min_successful_regions", required)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// proxyChainHeaders are the headers in which proxies and CDN layers report
+// themselves.
+var proxyChainHeaders = []string{"Via", "Server", "X-Served-By"}
+
+// proxyHop is a proxy, CDN layer or server reported in a header.
+type proxyHop struct {
+	header string
+	value  string
+}
+
+// splitProxyHops splits a list of hops on the commas that are not inside the
+// comments of Via entries, e.g. "1.1 varnish (Varnish/7.1), 1.1 cloudfront".
+func splitProxyHops(value string) []string {
+	var (
+		hops  []string
+		depth int
+		start int
+	)
+	for i, c := range value {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				hops = append(hops, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	hops = append(hops, value[start:])
+
+	var trimmed []string
+	for _, hop := range hops {
+		if hop = strings.TrimSpace(hop); hop != "" {
+			trimmed = append(trimmed, hop)
+		}
+	}
+	return trimmed
+}
+
+// proxyChain returns the hops reported in the response headers. The Server
+// header names a single product, the others are lists of hops.
+func proxyChain(header http.Header) []proxyHop {
+	var chain []proxyHop
+	for _, name := range proxyChainHeaders {
+		for _, value := range header.Values(name) {
+			if name == "Server" {
+				if value = strings.TrimSpace(value); value != "" {
+					chain = append(chain, proxyHop{header: name, value: value})
+				}
+				continue
+			}
+			for _, hop := range splitProxyHops(value) {
+				chain = append(chain, proxyHop{header: name, value: hop})
+			}
+		}
+	}
+	return chain
+}
+
+func proxyHopMatches(chain []proxyHop, m config.ProxyHopMatch) bool {
+	header := textproto.CanonicalMIMEHeaderKey(m.Header)
+	for _, hop := range chain {
+		if (m.Header == "" || hop.header == header) && m.Regexp.MatchString(hop.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateProxyChain exports the proxies and CDN layers the request
+// traversed, and returns false if the chain misses an expected hop or
+// contains an unexpected one, e.g. when traffic bypasses the WAF.
+func validateProxyChain(header http.Header, v config.ProxyChainValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	if !v.Enabled {
+		return true
+	}
+
+	chainInfoGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_proxy_chain_info",
+		Help: "Contains the proxies and CDN layers reported in the response headers, by position in the header",
+	}, []string{"header", "hop", "value"})
+	registry.MustRegister(chainInfoGaugeVec)
+
+	chain := proxyChain(header)
+	hops := map[string]int{}
+	for _, hop := range chain {
+		chainInfoGaugeVec.WithLabelValues(hop.header, strconv.Itoa(hops[hop.header]), hop.value).Set(1)
+		hops[hop.header]++
+	}
+
+	success := true
+	for _, m := range v.MustTraverse {
+		if !proxyHopMatches(chain, m) {
+			logger.Error("Request did not traverse an expected proxy", "header", m.Header, "regexp", m.Regexp.String())
+			success = false
+		}
+	}
+	for _, m := range v.MustNotTraverse {
+		if proxyHopMatches(chain, m) {
+			logger.Error("Request traversed an unexpected proxy", "header", m.Header, "regexp", m.Regexp.String())
+			success = false
+		}
+	}
+	return success
+}
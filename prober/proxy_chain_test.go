@@ -0,0 +1,111 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestProxyChain(t *testing.T) {
+	waf := config.ProxyHopMatch{Header: "via", Regexp: config.MustNewRegexp("waf")}
+	origin := config.ProxyHopMatch{Regexp: config.MustNewRegexp("^origin-")}
+
+	testcases := map[string]struct {
+		headers        http.Header
+		validator      config.ProxyChainValidator
+		expectedResult bool
+		expectedHops   int
+	}{
+		"traversed": {
+			headers: http.Header{
+				"Via":         {"1.1 waf.example.com (Envoy, 1.30), 1.1 varnish"},
+				"X-Served-By": {"cache-iad-1, cache-lhr-2"},
+				"Server":      {"nginx"},
+			},
+			validator:      config.ProxyChainValidator{Enabled: true, MustTraverse: []config.ProxyHopMatch{waf}},
+			expectedResult: true,
+			expectedHops:   5,
+		},
+		"bypassed": {
+			headers:      http.Header{"Via": {"1.1 varnish"}},
+			validator:    config.ProxyChainValidator{Enabled: true, MustTraverse: []config.ProxyHopMatch{waf}},
+			expectedHops: 1,
+		},
+		"matched in other header": {
+			headers:      http.Header{"Server": {"waf"}},
+			validator:    config.ProxyChainValidator{Enabled: true, MustTraverse: []config.ProxyHopMatch{waf}},
+			expectedHops: 1,
+		},
+		"unexpected hop": {
+			headers:      http.Header{"Via": {"1.1 waf"}, "X-Served-By": {"origin-2"}},
+			validator:    config.ProxyChainValidator{Enabled: true, MustNotTraverse: []config.ProxyHopMatch{origin}},
+			expectedHops: 2,
+		},
+		"info only": {
+			headers:        http.Header{"Via": {"1.1 varnish"}},
+			validator:      config.ProxyChainValidator{Enabled: true},
+			expectedResult: true,
+			expectedHops:   1,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, values := range tc.headers {
+					w.Header()[name] = values
+				}
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				ValidateProxyChain: tc.validator,
+			}}, registry, promslog.NewNopLogger())
+			if result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mf := range mfs {
+				if mf.GetName() == "probe_http_proxy_chain_info" && len(mf.Metric) != tc.expectedHops {
+					t.Errorf("Expected %d hops, got %d", tc.expectedHops, len(mf.Metric))
+				}
+			}
+		})
+	}
+}
+
+func TestSplitProxyHops(t *testing.T) {
+	hops := splitProxyHops("1.1 waf (Envoy, 1.30) ,HTTP/2.0 cdn,, 1.0 fred")
+	expected := []string{"1.1 waf (Envoy, 1.30)", "HTTP/2.0 cdn", "1.0 fred"}
+	if !slices.Equal(hops, expected) {
+		t.Fatalf("Expected hops %q, got %q", expected, hops)
+	}
+}
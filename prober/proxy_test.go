@@ -0,0 +1,207 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// newRegionExporter starts a blackbox exporter serving /probe with the given
+// modules.
+func newRegionExporter(modules map[string]config.Module) *httptest.Server {
+	conf := &config.Config{Modules: modules}
+	// The level is shared by the concurrent probes of the regions, so it is
+	// set before, not by the first of them.
+	logLevel := &promslog.AllowedLevel{}
+	_ = logLevel.Set("info")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/probe" {
+			http.NotFound(w, r)
+			return
+		}
+		Handler(w, r, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.1, nil, nil, logLevel)
+	}))
+}
+
+func TestProbeProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	exporter := newRegionExporter(map[string]config.Module{
+		"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+	})
+	defer exporter.Close()
+
+	proxyModule := func(region string) config.Module {
+		return config.Module{Prober: "proxy", Timeout: 5 * time.Second, Proxy: config.ProxyProbe{
+			Regions: map[string]string{
+				"eu":   exporter.URL,
+				"us":   exporter.URL + "/prefix",
+				"down": "http://127.0.0.1:0",
+			},
+			Region:           region,
+			Module:           "http_2xx",
+			HTTPClientConfig: pconfig.DefaultHTTPClientConfig,
+		}}
+	}
+
+	testcases := map[string]struct {
+		region         string
+		target         string
+		expectedResult bool
+	}{
+		"success":           {region: "eu", target: target.URL, expectedResult: true},
+		"remote failure":    {region: "eu", target: target.URL + "/missing", expectedResult: false},
+		"unknown region":    {region: "ap", target: target.URL, expectedResult: false},
+		"wrong path":        {region: "us", target: target.URL, expectedResult: false},
+		"unreachable proxy": {region: "down", target: target.URL, expectedResult: false},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result := ProbeProxy(testCTX, tc.target, proxyModule(tc.region), registry, promslog.NewNopLogger())
+			if result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.region != "eu" {
+				if len(mfs) != 0 {
+					t.Fatalf("Expected no relayed metrics, got %v", mfs)
+				}
+				return
+			}
+			checkAbsentMetrics([]string{"probe_success", "probe_duration_seconds"}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{
+				"probe_http_status_code": {"probe_region": "eu"},
+			}, mfs, t)
+		})
+	}
+}
+
+func TestProbeProxyRegionParam(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	exporter := newRegionExporter(map[string]config.Module{
+		"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+	})
+	defer exporter.Close()
+
+	conf := &config.Config{Modules: map[string]config.Module{
+		"proxy": {Prober: "proxy", Timeout: 5 * time.Second, Proxy: config.ProxyProbe{
+			Regions:          map[string]string{"eu": exporter.URL},
+			Module:           "http_2xx",
+			HTTPClientConfig: pconfig.DefaultHTTPClientConfig,
+		}},
+	}}
+
+	for region, expected := range map[string]string{"eu": "probe_success 1", "eu,eu": "probe_success 1", "": "probe_success 0"} {
+		req, err := http.NewRequest("GET", "?module=proxy&target="+target.URL+"&region="+region, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, conf, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		if !strings.Contains(rr.Body.String(), expected) {
+			t.Fatalf("Expected %q for region %q, got: %s", expected, region, rr.Body.String())
+		}
+		if region != "" && !strings.Contains(rr.Body.String(), `probe_http_status_code{probe_region="eu"} 200`) {
+			t.Fatalf("Expected relayed metrics, got: %s", rr.Body.String())
+		}
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for region %q, got %d", region, rr.Code)
+		}
+	}
+}
+
+func TestProbeProxyAggregation(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	up := newRegionExporter(map[string]config.Module{
+		"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+	})
+	defer up.Close()
+	// This region cannot reach the target.
+	down := newRegionExporter(map[string]config.Module{
+		"http_2xx": {Prober: "http", Timeout: 5 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true, ValidStatusCodes: []int{418}}},
+	})
+	defer down.Close()
+
+	testcases := map[string]struct {
+		region         string
+		minSuccessful  int
+		expectedResult bool
+		expectedRatio  float64
+	}{
+		"all regions required":   {region: "all", expectedResult: false, expectedRatio: 2.0 / 3.0},
+		"two regions required":   {region: "all", minSuccessful: 2, expectedResult: true, expectedRatio: 2.0 / 3.0},
+		"selected regions":       {region: "eu,us", expectedResult: true, expectedRatio: 1},
+		"duplicated regions":     {region: "eu,eu,us", expectedResult: true, expectedRatio: 1},
+		"selected failed region": {region: "eu,ap", minSuccessful: 2, expectedResult: false, expectedRatio: 0.5},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			module := config.Module{Prober: "proxy", Timeout: 5 * time.Second, Proxy: config.ProxyProbe{
+				Regions: map[string]string{
+					"eu": up.URL,
+					"us": up.URL,
+					"ap": down.URL,
+				},
+				Region:               tc.region,
+				MinSuccessfulRegions: tc.minSuccessful,
+				Module:               "http_2xx",
+				HTTPClientConfig:     pconfig.DefaultHTTPClientConfig,
+			}}
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if result := ProbeProxy(testCTX, target.URL, module, registry, promslog.NewNopLogger()); result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_success_ratio_across_regions": tc.expectedRatio}, mfs, t)
+			for _, mf := range mfs {
+				switch mf.GetName() {
+				case "probe_region_success", "probe_region_duration_seconds", "probe_http_status_code":
+					if len(mf.Metric) != len(proxyRegions(module.Proxy)) {
+						t.Fatalf("Expected one %s metric per region, got %v", mf.GetName(), mf.Metric)
+					}
+				}
+			}
+		})
+	}
+}
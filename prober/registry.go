@@ -0,0 +1,96 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	probersMtx sync.RWMutex
+	probers    = map[string]ProbeFn{}
+)
+
+func init() {
+	Register("http", ProbeHTTP)
+	Register("tcp", ProbeTCP)
+	Register("icmp", ProbeICMP)
+	Register("dns", ProbeDNS)
+	Register("grpc", ProbeGRPC)
+	Register("proxy", ProbeProxy)
+	Register("portscan", ProbePortScan)
+	Register("roughtime", ProbeRoughtime)
+	Register("snmp", ProbeSNMP)
+	Register("bgp", ProbeBGP)
+	Register("oidc", ProbeOIDC)
+	Register("nfs", ProbeNFS)
+	Register("smb", ProbeSMB)
+	Register("etcd", ProbeEtcd)
+	Register("zookeeper", ProbeZooKeeper)
+	Register("consul", ProbeConsul)
+	Register("gameserver", ProbeGameServer)
+	Register("ipp", ProbeIPP)
+	Register("smtp", ProbeSMTP)
+	Register("ndp", ProbeNDP)
+	Register("sse", ProbeSSE)
+	Register("traceroute", ProbeTraceroute)
+}
+
+// Register makes a prober available to modules under the given name.
+// Distributions of the exporter can register their own probers at start-up.
+// It panics if a prober is already registered under the name.
+func Register(name string, fn ProbeFn) {
+	probersMtx.Lock()
+	defer probersMtx.Unlock()
+	if _, ok := probers[name]; ok {
+		panic(fmt.Sprintf("prober %q is already registered", name))
+	}
+	probers[name] = fn
+}
+
+// Unregister removes the prober registered under the given name, so that
+// modules using it are rejected when the configuration is loaded.
+func Unregister(name string) {
+	probersMtx.Lock()
+	defer probersMtx.Unlock()
+	delete(probers, name)
+}
+
+// Lookup returns the prober registered under the given name.
+func Lookup(name string) (ProbeFn, bool) {
+	probersMtx.RLock()
+	defer probersMtx.RUnlock()
+	fn, ok := probers[name]
+	return fn, ok
+}
+
+// IsRegistered returns whether a prober is registered under the given name.
+func IsRegistered(name string) bool {
+	_, ok := Lookup(name)
+	return ok
+}
+
+// Names returns the sorted names of the registered probers.
+func Names() []string {
+	probersMtx.RLock()
+	defer probersMtx.RUnlock()
+	names := make([]string, 0, len(probers))
+	for name := range probers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuiltinProbers(t *testing.T) {
+	expected := []string{"bgp", "consul", "dns", "etcd", "gameserver", "grpc", "http", "icmp", "ipp", "ndp", "nfs", "oidc", "portscan", "proxy", "roughtime", "smb", "smtp", "snmp", "sse", "tcp", "traceroute", "zookeeper"}
+	if names := Names(); !slices.Equal(names, expected) {
+		t.Fatalf("Expected probers %v, got %v", expected, names)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("fake", fakeProbe)
+	if !IsRegistered("fake") {
+		t.Fatal("Expected the fake prober to be registered")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected registering a prober twice to panic")
+			}
+		}()
+		Register("fake", fakeProbe)
+	}()
+
+	Unregister("fake")
+	if _, ok := Lookup("fake"); ok {
+		t.Fatal("Expected the fake prober to be unregistered")
+	}
+}
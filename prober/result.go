@@ -0,0 +1,302 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// ObservationType is the type of the metric an observation comes from.
+type ObservationType string
+
+const (
+	ObservationGauge     ObservationType = "gauge"
+	ObservationCounter   ObservationType = "counter"
+	ObservationUntyped   ObservationType = "untyped"
+	ObservationHistogram ObservationType = "histogram"
+	ObservationSummary   ObservationType = "summary"
+)
+
+// Observation is a value recorded by a prober.
+type Observation struct {
+	Name   string
+	Help   string
+	Type   ObservationType
+	Labels map[string]string
+	// Value is the value of gauges, counters and untyped observations.
+	Value float64
+	// Histogram is the value of histogram observations.
+	Histogram *HistogramObservation
+	// Summary is the value of summary observations.
+	Summary *SummaryObservation
+}
+
+// HistogramObservation is the value of a histogram.
+type HistogramObservation struct {
+	Count uint64
+	Sum   float64
+	// Buckets are the cumulative counts by upper bound.
+	Buckets map[float64]uint64
+}
+
+// SummaryObservation is the value of a summary, such as those the proxy
+// prober relays from the exporters of its regions.
+type SummaryObservation struct {
+	Count uint64
+	Sum   float64
+	// Quantiles are the values by quantile.
+	Quantiles map[float64]float64
+}
+
+// ProbePhase is the duration of a phase of a probe, e.g. the DNS resolution
+// or the TLS handshake.
+type ProbePhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ProbeResult is the outcome of a probe, independent of the format it is
+// exported in. The Prometheus metrics served by the exporter are generated
+// from it, as can be any other representation of the probe. Probers do not
+// fill it in themselves, RunProbe builds it from what they record.
+type ProbeResult struct {
+	Prober   string
+	Target   string
+	Success  bool
+	Duration time.Duration
+	// Phases are taken from the duration observations with a phase label,
+	// which remain in Observations.
+	Phases []ProbePhase
+	// Errors are the errors logged by the prober.
+	Errors       []string
+	Observations []Observation
+}
+
+// RunProbe runs a probe and collects what the prober recorded into its
+// result, adapting the probers rather than changing each of them: they
+// record observations in the registry they are given, and report errors
+// through the logger.
+func RunProbe(ctx context.Context, prober ProbeFn, target string, module config.Module, logger *slog.Logger) *ProbeResult {
+	start := time.Now()
+	registry := prometheus.NewRegistry()
+	recorder := &errorRecorder{next: logger.Handler(), errors: &probeErrors{}}
+	success := prober(ctx, target, module, registry, slog.New(recorder))
+
+	result := &ProbeResult{
+		Prober:   module.Prober,
+		Target:   target,
+		Success:  success,
+		Duration: time.Since(start),
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		logger.Error("Error gathering the metrics of the probe", "err", err)
+	}
+	result.Observations = observationsFromMetricFamilies(mfs)
+	result.Phases = phasesFromObservations(result.Observations)
+	result.Errors = recorder.errors.list()
+	return result
+}
+
+func observationsFromMetricFamilies(mfs []*dto.MetricFamily) []Observation {
+	var observations []Observation
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			o := Observation{
+				Name:   mf.GetName(),
+				Help:   mf.GetHelp(),
+				Labels: make(map[string]string, len(m.Label)),
+			}
+			for _, l := range m.Label {
+				o.Labels[l.GetName()] = l.GetValue()
+			}
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				o.Type, o.Value = ObservationGauge, m.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				o.Type, o.Value = ObservationCounter, m.GetCounter().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				o.Type = ObservationHistogram
+				o.Histogram = &HistogramObservation{
+					Count:   h.GetSampleCount(),
+					Sum:     h.GetSampleSum(),
+					Buckets: make(map[float64]uint64, len(h.Bucket)),
+				}
+				for _, b := range h.Bucket {
+					o.Histogram.Buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+				}
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				o.Type = ObservationSummary
+				o.Summary = &SummaryObservation{
+					Count:     summary.GetSampleCount(),
+					Sum:       summary.GetSampleSum(),
+					Quantiles: make(map[float64]float64, len(summary.Quantile)),
+				}
+				for _, q := range summary.Quantile {
+					o.Summary.Quantiles[q.GetQuantile()] = q.GetValue()
+				}
+			default:
+				o.Type, o.Value = ObservationUntyped, m.GetUntyped().GetValue()
+			}
+			observations = append(observations, o)
+		}
+	}
+	return observations
+}
+
+func phasesFromObservations(observations []Observation) []ProbePhase {
+	var phases []ProbePhase
+	for _, o := range observations {
+		phase, ok := o.Labels["phase"]
+		if !ok || o.Type != ObservationGauge || !strings.HasSuffix(o.Name, "_duration_seconds") {
+			continue
+		}
+		phases = append(phases, ProbePhase{
+			Name:     phase,
+			Duration: time.Duration(o.Value * float64(time.Second)),
+		})
+	}
+	return phases
+}
+
+// probeErrors are the errors logged during a probe, shared by the loggers
+// derived from the one given to the prober.
+type probeErrors struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (e *probeErrors) add(message string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messages = append(e.messages, message)
+}
+
+func (e *probeErrors) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.messages...)
+}
+
+// errorRecorder records the messages of the errors logged by a prober,
+// along with their "err" attribute, before passing them on. It implements
+// slog.Handler.
+type errorRecorder struct {
+	next   slog.Handler
+	errors *probeErrors
+}
+
+func (h *errorRecorder) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+func (h *errorRecorder) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		message := r.Message
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "err" {
+				message = fmt.Sprintf("%s: %s", message, a.Value)
+				return false
+			}
+			return true
+		})
+		h.errors.add(message)
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *errorRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &errorRecorder{next: h.next.WithAttrs(attrs), errors: h.errors}
+}
+
+func (h *errorRecorder) WithGroup(name string) slog.Handler {
+	return &errorRecorder{next: h.next.WithGroup(name), errors: h.errors}
+}
+
+// resultCollector exports a probe result as Prometheus metrics.
+type resultCollector struct {
+	result *ProbeResult
+}
+
+func newResultCollector(result *ProbeResult) prometheus.Collector {
+	return &resultCollector{result: result}
+}
+
+// Describe implements prometheus.Collector. The metrics of a result are not
+// known in advance, so the collector is unchecked.
+func (c *resultCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *resultCollector) Collect(ch chan<- prometheus.Metric) {
+	success := 0.0
+	if c.result.Success {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		prometheus.NewDesc("probe_success", "Displays whether or not the probe was a success", nil, nil),
+		prometheus.GaugeValue, success)
+	ch <- prometheus.MustNewConstMetric(
+		prometheus.NewDesc("probe_duration_seconds", "Returns how long the probe took to complete in seconds", nil, nil),
+		prometheus.GaugeValue, c.result.Duration.Seconds())
+
+	for _, o := range c.result.Observations {
+		labelNames := make([]string, 0, len(o.Labels))
+		for name := range o.Labels {
+			labelNames = append(labelNames, name)
+		}
+		sort.Strings(labelNames)
+		labelValues := make([]string, len(labelNames))
+		for i, name := range labelNames {
+			labelValues[i] = o.Labels[name]
+		}
+		desc := prometheus.NewDesc(o.Name, o.Help, labelNames, nil)
+
+		var (
+			m   prometheus.Metric
+			err error
+		)
+		switch o.Type {
+		case ObservationGauge:
+			m, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, o.Value, labelValues...)
+		case ObservationCounter:
+			m, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, o.Value, labelValues...)
+		case ObservationHistogram:
+			m, err = prometheus.NewConstHistogram(desc, o.Histogram.Count, o.Histogram.Sum, o.Histogram.Buckets, labelValues...)
+		case ObservationSummary:
+			m, err = prometheus.NewConstSummary(desc, o.Summary.Count, o.Summary.Sum, o.Summary.Quantiles, labelValues...)
+		default:
+			m, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, o.Value, labelValues...)
+		}
+		if err != nil {
+			m = prometheus.NewInvalidMetric(desc, err)
+		}
+		ch <- m
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// resultDocument is the JSON form of a probe result, served by /probe with
+// format=json for consumers that do not read Prometheus metrics.
+type resultDocument struct {
+	Module          string      `json:"module"`
+	Prober          string      `json:"prober"`
+	Target          string      `json:"target"`
+	Success         bool        `json:"success"`
+	DurationSeconds jsonFloat   `json:"duration_seconds"`
+	FailureReason   string      `json:"failure_reason,omitempty"`
+	Errors          []string    `json:"errors"`
+	Phases          []jsonPhase `json:"phases"`
+	// Validators are the outcomes of the named validators of the module.
+	Validators map[string]bool `json:"validators,omitempty"`
+	Metrics    []jsonMetric    `json:"metrics"`
+}
+
+type jsonPhase struct {
+	Name            string    `json:"name"`
+	DurationSeconds jsonFloat `json:"duration_seconds"`
+}
+
+type jsonMetric struct {
+	Name      string            `json:"name"`
+	Type      ObservationType   `json:"type"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     *jsonFloat        `json:"value,omitempty"`
+	Histogram *jsonHistogram    `json:"histogram,omitempty"`
+	Summary   *jsonSummary      `json:"summary,omitempty"`
+}
+
+type jsonHistogram struct {
+	Count   uint64       `json:"count"`
+	Sum     jsonFloat    `json:"sum"`
+	Buckets []jsonBucket `json:"buckets"`
+}
+
+type jsonBucket struct {
+	UpperBound jsonFloat `json:"le"`
+	Count      uint64    `json:"count"`
+}
+
+type jsonSummary struct {
+	Count     uint64         `json:"count"`
+	Sum       jsonFloat      `json:"sum"`
+	Quantiles []jsonQuantile `json:"quantiles"`
+}
+
+type jsonQuantile struct {
+	Quantile jsonFloat `json:"quantile"`
+	Value    jsonFloat `json:"value"`
+}
+
+// jsonFloat is a float64 whose special values, which JSON numbers cannot
+// represent, are encoded as the strings Prometheus uses.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return json.Marshal(v)
+}
+
+// newResultDocument returns the JSON form of the result of a probe of the
+// module. The failure reason is the first error logged by the prober, which
+// is usually the cause of the ones that follow.
+func newResultDocument(module string, result *ProbeResult) *resultDocument {
+	doc := &resultDocument{
+		Module:          module,
+		Prober:          result.Prober,
+		Target:          result.Target,
+		Success:         result.Success,
+		DurationSeconds: jsonFloat(result.Duration.Seconds()),
+		Errors:          append([]string{}, result.Errors...),
+		Phases:          []jsonPhase{},
+		Metrics:         []jsonMetric{},
+	}
+	if !result.Success && len(result.Errors) > 0 {
+		doc.FailureReason = result.Errors[0]
+	}
+	for _, p := range result.Phases {
+		doc.Phases = append(doc.Phases, jsonPhase{Name: p.Name, DurationSeconds: jsonFloat(p.Duration.Seconds())})
+	}
+	for _, o := range result.Observations {
+		if o.Name == "probe_validator_success" {
+			if doc.Validators == nil {
+				doc.Validators = map[string]bool{}
+			}
+			doc.Validators[o.Labels["name"]] = o.Value == 1
+		}
+		m := jsonMetric{Name: o.Name, Type: o.Type, Labels: o.Labels}
+		if o.Histogram != nil {
+			m.Histogram = &jsonHistogram{Count: o.Histogram.Count, Sum: jsonFloat(o.Histogram.Sum), Buckets: []jsonBucket{}}
+			for upperBound, count := range o.Histogram.Buckets {
+				m.Histogram.Buckets = append(m.Histogram.Buckets, jsonBucket{UpperBound: jsonFloat(upperBound), Count: count})
+			}
+			sort.Slice(m.Histogram.Buckets, func(i, j int) bool {
+				return m.Histogram.Buckets[i].UpperBound < m.Histogram.Buckets[j].UpperBound
+			})
+		} else if o.Summary != nil {
+			m.Summary = &jsonSummary{Count: o.Summary.Count, Sum: jsonFloat(o.Summary.Sum), Quantiles: []jsonQuantile{}}
+			for quantile, value := range o.Summary.Quantiles {
+				m.Summary.Quantiles = append(m.Summary.Quantiles, jsonQuantile{Quantile: jsonFloat(quantile), Value: jsonFloat(value)})
+			}
+			sort.Slice(m.Summary.Quantiles, func(i, j int) bool {
+				return m.Summary.Quantiles[i].Quantile < m.Summary.Quantiles[j].Quantile
+			})
+		} else {
+			value := jsonFloat(o.Value)
+			m.Value = &value
+		}
+		doc.Metrics = append(doc.Metrics, m)
+	}
+	return doc
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestResultDocument(t *testing.T) {
+	result := RunProbe(context.Background(), fakeProbe, "example.com", config.Module{Prober: "fake"}, promslog.NewNopLogger())
+	result.Observations = append(result.Observations,
+		Observation{Name: "probe_validator_success", Type: ObservationGauge, Labels: map[string]string{"name": "has_title"}, Value: 1},
+		Observation{Name: "probe_fake_ratio", Type: ObservationGauge, Value: math.NaN()},
+	)
+
+	b, err := json.Marshal(newResultDocument("fake_module", result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Module        string `json:"module"`
+		Success       bool   `json:"success"`
+		FailureReason string `json:"failure_reason"`
+		Phases        []struct {
+			Name            string  `json:"name"`
+			DurationSeconds float64 `json:"duration_seconds"`
+		} `json:"phases"`
+		Validators map[string]bool `json:"validators"`
+		Metrics    []struct {
+			Name      string      `json:"name"`
+			Value     interface{} `json:"value"`
+			Histogram *struct {
+				Buckets []struct {
+					UpperBound interface{} `json:"le"`
+					Count      uint64      `json:"count"`
+				} `json:"buckets"`
+			} `json:"histogram"`
+			Summary *struct {
+				Count     uint64 `json:"count"`
+				Quantiles []struct {
+					Quantile float64 `json:"quantile"`
+					Value    float64 `json:"value"`
+				} `json:"quantiles"`
+			} `json:"summary"`
+		} `json:"metrics"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Module != "fake_module" || doc.Success || doc.FailureReason != "Error connecting to target: connection refused" {
+		t.Errorf("Unexpected result %s", b)
+	}
+	if len(doc.Phases) != 1 || doc.Phases[0].Name != "connect" || doc.Phases[0].DurationSeconds != 0.25 {
+		t.Errorf("Unexpected phases %+v", doc.Phases)
+	}
+	if !doc.Validators["has_title"] {
+		t.Errorf("Expected validator has_title to succeed, got %v", doc.Validators)
+	}
+	values := map[string]interface{}{}
+	for _, m := range doc.Metrics {
+		values[m.Name] = m.Value
+		if m.Name == "probe_fake_latency_seconds" {
+			buckets := m.Histogram.Buckets
+			if len(buckets) != 2 || buckets[0].UpperBound != 0.1 || buckets[1].UpperBound != 1.0 || buckets[1].Count != 2 {
+				t.Errorf("Unexpected buckets %+v", buckets)
+			}
+		}
+		if m.Name == "probe_fake_relayed_seconds" {
+			if m.Summary == nil || m.Summary.Count != 1 || len(m.Summary.Quantiles) != 1 || m.Summary.Quantiles[0].Quantile != 0.5 || m.Summary.Quantiles[0].Value != 2 {
+				t.Errorf("Unexpected summary %+v", m.Summary)
+			}
+		}
+	}
+	if values["probe_fake_status_code"] != 503.0 || values["probe_fake_ratio"] != "NaN" {
+		t.Errorf("Unexpected metrics %s", b)
+	}
+}
+
+func TestJSONFormatParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c := &config.Config{
+		Modules: map[string]config.Module{
+			"http_2xx": {Prober: "http", Timeout: 10 * time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}},
+		},
+	}
+	req, err := http.NewRequest("GET", "?module=http_2xx&format=json&target="+ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Handler(rr, req, c, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected JSON, got %s: %s", ct, rr.Body.String())
+	}
+	var doc resultDocument
+	if err := json.NewDecoder(strings.NewReader(rr.Body.String())).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Success || doc.Module != "http_2xx" || doc.Prober != "http" || len(doc.Phases) == 0 {
+		t.Errorf("Unexpected result %s", rr.Body.String())
+	}
+}
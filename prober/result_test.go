@@ -0,0 +1,146 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func fakeProbe(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	durationGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_fake_duration_seconds",
+		Help: "Duration of the phases of the fake probe",
+	}, []string{"phase"})
+	statusCodeGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_fake_status_code",
+		Help: "Status code of the fake probe",
+	})
+	retriesCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "probe_fake_retries_total",
+		Help: "Retries of the fake probe",
+	})
+	latencyHistogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "probe_fake_latency_seconds",
+		Help:    "Latency of the requests of the fake probe",
+		Buckets: []float64{0.1, 1},
+	})
+	relayedSummary := prometheus.NewSummary(prometheus.SummaryOpts{
+		Name:       "probe_fake_relayed_seconds",
+		Help:       "Summary relayed by the fake probe",
+		Objectives: map[float64]float64{0.5: 0.05},
+	})
+	registry.MustRegister(durationGaugeVec, statusCodeGauge, retriesCounter, latencyHistogram, relayedSummary)
+
+	durationGaugeVec.WithLabelValues("connect").Set(0.25)
+	statusCodeGauge.Set(503)
+	retriesCounter.Add(2)
+	latencyHistogram.Observe(0.25)
+	latencyHistogram.Observe(0.5)
+	relayedSummary.Observe(2)
+	logger.With("attempt", 2).Error("Error connecting to target", "err", errors.New("connection refused"))
+	logger.Info("Not an error")
+	return false
+}
+
+func TestRunProbe(t *testing.T) {
+	result := RunProbe(context.Background(), fakeProbe, "example.com", config.Module{Prober: "fake"}, promslog.NewNopLogger())
+
+	if result.Success || result.Prober != "fake" || result.Target != "example.com" {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	if expected := []string{"Error connecting to target: connection refused"}; !slices.Equal(result.Errors, expected) {
+		t.Errorf("Expected errors %q, got %q", expected, result.Errors)
+	}
+	if expected := []ProbePhase{{Name: "connect", Duration: 250 * time.Millisecond}}; !slices.Equal(result.Phases, expected) {
+		t.Errorf("Expected phases %v, got %v", expected, result.Phases)
+	}
+
+	types := map[string]ObservationType{}
+	for _, o := range result.Observations {
+		types[o.Name] = o.Type
+	}
+	expectedTypes := map[string]ObservationType{
+		"probe_fake_duration_seconds": ObservationGauge,
+		"probe_fake_status_code":      ObservationGauge,
+		"probe_fake_retries_total":    ObservationCounter,
+		"probe_fake_latency_seconds":  ObservationHistogram,
+		"probe_fake_relayed_seconds":  ObservationSummary,
+	}
+	for name, typ := range expectedTypes {
+		if types[name] != typ {
+			t.Errorf("Expected observation %s of type %q, got %q", name, typ, types[name])
+		}
+	}
+}
+
+func TestResultCollector(t *testing.T) {
+	result := RunProbe(context.Background(), fakeProbe, "example.com", config.Module{Prober: "fake"}, promslog.NewNopLogger())
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newResultCollector(result))
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{
+		"probe_success":               0,
+		"probe_fake_duration_seconds": 0.25,
+		"probe_fake_status_code":      503,
+	}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_fake_duration_seconds": {"phase": "connect"},
+	}, mfs, t)
+
+	for _, mf := range mfs {
+		if mf.GetName() == "probe_fake_retries_total" && mf.Metric[0].GetCounter().GetValue() != 2 {
+			t.Errorf("Expected probe_fake_retries_total to be 2, got %v", mf.Metric[0].GetCounter().GetValue())
+		}
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_fake_relayed_seconds" {
+			continue
+		}
+		s := mf.Metric[0].GetSummary()
+		if mf.GetType() != dto.MetricType_SUMMARY || s.GetSampleCount() != 1 || s.GetSampleSum() != 2 || len(s.Quantile) != 1 || s.Quantile[0].GetValue() != 2 {
+			t.Errorf("Unexpected summary %v of type %v", s, mf.GetType())
+		}
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_fake_latency_seconds" {
+			continue
+		}
+		h := mf.Metric[0].GetHistogram()
+		if h.GetSampleCount() != 2 || h.GetSampleSum() != 0.75 {
+			t.Errorf("Unexpected histogram count %d and sum %v", h.GetSampleCount(), h.GetSampleSum())
+		}
+		for _, b := range h.Bucket {
+			if b.GetUpperBound() == 1 && b.GetCumulativeCount() != 2 {
+				t.Errorf("Expected 2 observations up to 1, got %d", b.GetCumulativeCount())
+			}
+		}
+		return
+	}
+	t.Fatal("Histogram probe_fake_latency_seconds not found")
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// runProbeWithRetries runs the probe and, while it fails, runs it again up
+// to the retries of the module, as long as there is time left for another
+// attempt. The result is the one of the last attempt, with the errors of all
+// of them, and probe_attempts_total tells how many were made.
+func runProbeWithRetries(ctx context.Context, prober ProbeFn, target string, module config.Module, logger *slog.Logger) *ProbeResult {
+	if module.Retries == 0 {
+		return runProbeWithFallback(ctx, prober, target, module, logger)
+	}
+
+	start := time.Now()
+	var previousErrors []string
+	result := runProbeWithFallback(ctx, prober, target, module, logger)
+	attempts := 1
+	for !result.Success && attempts <= module.Retries && canRetry(ctx, module.RetryInterval) {
+		logger.Warn("Probe failed, retrying", "attempt", attempts, "retry_interval", module.RetryInterval)
+		if !sleepContext(ctx, module.RetryInterval) {
+			break
+		}
+		previousErrors = append(previousErrors, result.Errors...)
+		result = runProbeWithFallback(ctx, prober, target, module, logger.With("attempt", attempts+1))
+		attempts++
+	}
+	result.Errors = append(previousErrors, result.Errors...)
+	result.Duration = time.Since(start)
+	result.Observations = append(result.Observations, Observation{
+		Name:  "probe_attempts_total",
+		Help:  "Number of times the target was probed, including retries",
+		Type:  ObservationCounter,
+		Value: float64(attempts),
+	})
+	return result
+}
+
+// canRetry tells if the context leaves time to wait for the interval before
+// another attempt.
+func canRetry(ctx context.Context, interval time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > interval
+}
+
+// sleepContext waits for d, and reports false if the context ended first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestRunProbeWithRetries(t *testing.T) {
+	// The probe fails until it was run failures times.
+	var attempts, failures int
+	prober := func(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+		attempts++
+		if attempts <= failures {
+			logger.Error("Error probing target", "err", "connection reset by peer")
+			return false
+		}
+		return true
+	}
+
+	tests := []struct {
+		name          string
+		retries       int
+		retryInterval time.Duration
+		failures      int
+		success       bool
+		attempts      float64
+	}{
+		{name: "no retries", failures: 1, attempts: -1},
+		{name: "first attempt answered", retries: 2, success: true, attempts: 1},
+		{name: "retry answered", retries: 2, retryInterval: 10 * time.Millisecond, failures: 2, success: true, attempts: 3},
+		{name: "all attempts failed", retries: 2, failures: 5, attempts: 3},
+		// The second retry would end after the timeout.
+		{name: "no time left", retries: 2, retryInterval: 150 * time.Millisecond, failures: 5, attempts: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts, failures = 0, test.failures
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			module := config.Module{Retries: test.retries, RetryInterval: test.retryInterval}
+			result := runProbeWithRetries(ctx, prober, "target", module, promslog.NewNopLogger())
+			if result.Success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, result.Success)
+			}
+			if want := min(test.failures, attempts); len(result.Errors) != want {
+				t.Errorf("Expected %d errors, got %v", want, result.Errors)
+			}
+			got := -1.0
+			for _, o := range result.Observations {
+				if o.Name == "probe_attempts_total" {
+					got = o.Value
+				}
+			}
+			if got != test.attempts {
+				t.Errorf("Expected probe_attempts_total %v, got %v", test.attempts, got)
+			}
+		})
+	}
+}
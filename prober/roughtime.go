@@ -0,0 +1,355 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// The Roughtime protocol, as implemented by the Google servers. Messages
+// map tags to values, times are in microseconds since the Unix epoch.
+var (
+	roughtimeTagSIG  = roughtimeTag("SIG\x00")
+	roughtimeTagNONC = roughtimeTag("NONC")
+	roughtimeTagDELE = roughtimeTag("DELE")
+	roughtimeTagPATH = roughtimeTag("PATH")
+	roughtimeTagRADI = roughtimeTag("RADI")
+	roughtimeTagPUBK = roughtimeTag("PUBK")
+	roughtimeTagMIDP = roughtimeTag("MIDP")
+	roughtimeTagSREP = roughtimeTag("SREP")
+	roughtimeTagMINT = roughtimeTag("MINT")
+	roughtimeTagROOT = roughtimeTag("ROOT")
+	roughtimeTagCERT = roughtimeTag("CERT")
+	roughtimeTagMAXT = roughtimeTag("MAXT")
+	roughtimeTagINDX = roughtimeTag("INDX")
+	roughtimeTagPAD  = roughtimeTag("PAD\xff")
+)
+
+const (
+	roughtimeDefaultPort    = "2002"
+	roughtimeNonceSize      = 64
+	roughtimeRequestSize    = 1024
+	roughtimeDelegationCtx  = "RoughTime v1 delegation signature--\x00"
+	roughtimeResponseCtx    = "RoughTime v1 response signature\x00"
+	roughtimeMaxMessageSize = 65536
+)
+
+func roughtimeTag(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+// encodeRoughtimeMessage encodes a message, whose values must be multiples
+// of 4 bytes long.
+func encodeRoughtimeMessage(msg map[uint32][]byte) []byte {
+	tags := make([]uint32, 0, len(msg))
+	for tag := range msg {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(tags)))
+	offset := 0
+	for _, tag := range tags[:len(tags)-1] {
+		offset += len(msg[tag])
+		b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+	}
+	for _, tag := range tags {
+		b = binary.LittleEndian.AppendUint32(b, tag)
+	}
+	for _, tag := range tags {
+		b = append(b, msg[tag]...)
+	}
+	return b
+}
+
+// parseRoughtimeMessage decodes a message. The values refer to b.
+func parseRoughtimeMessage(b []byte) (map[uint32][]byte, error) {
+	if len(b) < 4 {
+		return nil, errors.New("message too short")
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n == 0 {
+		return map[uint32][]byte{}, nil
+	}
+	if n > len(b)/8 {
+		return nil, errors.New("message header too short")
+	}
+	header := b[4 : 8*n]
+	values := b[8*n:]
+	msg := make(map[uint32][]byte, n)
+	start := 0
+	var previous uint32
+	for i := 0; i < n; i++ {
+		end := len(values)
+		if i < n-1 {
+			end = int(binary.LittleEndian.Uint32(header[4*i:]))
+		}
+		if end%4 != 0 || end < start || end > len(values) {
+			return nil, errors.New("invalid offset in message")
+		}
+		tag := binary.LittleEndian.Uint32(header[4*(n-1)+4*i:])
+		if i > 0 && tag <= previous {
+			return nil, errors.New("tags of message not in ascending order")
+		}
+		msg[tag] = values[start:end]
+		previous, start = tag, end
+	}
+	return msg, nil
+}
+
+// roughtimeField is a tag expected in a message, with the length of its
+// value if it is fixed.
+type roughtimeField struct {
+	tag    uint32
+	length int
+}
+
+// checkRoughtimeFields checks that a message has the expected tags.
+func checkRoughtimeFields(msg map[uint32][]byte, fields ...roughtimeField) error {
+	for _, f := range fields {
+		v, ok := msg[f.tag]
+		if !ok {
+			return fmt.Errorf("tag %q missing", binary.LittleEndian.AppendUint32(nil, f.tag))
+		}
+		if f.length > 0 && len(v) != f.length {
+			return fmt.Errorf("tag %q has length %d, expected %d", binary.LittleEndian.AppendUint32(nil, f.tag), len(v), f.length)
+		}
+	}
+	return nil
+}
+
+// roughtimeResponse is a verified response of a Roughtime server.
+type roughtimeResponse struct {
+	midpoint  time.Time
+	radius    time.Duration
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+func roughtimeTime(b []byte) time.Time {
+	return time.UnixMicro(int64(binary.LittleEndian.Uint64(b)))
+}
+
+// verifyRoughtimeResponse checks that the response was signed by a key
+// delegated by the long-term key of the server, and covers the nonce.
+func verifyRoughtimeResponse(b []byte, publicKey ed25519.PublicKey, nonce []byte) (*roughtimeResponse, error) {
+	msg, err := parseRoughtimeMessage(b)
+	if err != nil {
+		return nil, err
+	}
+	err = checkRoughtimeFields(msg,
+		roughtimeField{roughtimeTagSIG, ed25519.SignatureSize},
+		roughtimeField{roughtimeTagSREP, 0},
+		roughtimeField{roughtimeTagCERT, 0},
+		roughtimeField{roughtimeTagINDX, 4},
+		roughtimeField{roughtimeTagPATH, 0},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseRoughtimeMessage(msg[roughtimeTagCERT])
+	if err != nil {
+		return nil, fmt.Errorf("certificate: %w", err)
+	}
+	if err := checkRoughtimeFields(cert, roughtimeField{roughtimeTagSIG, ed25519.SignatureSize}, roughtimeField{roughtimeTagDELE, 0}); err != nil {
+		return nil, fmt.Errorf("certificate: %w", err)
+	}
+	if !ed25519.Verify(publicKey, append([]byte(roughtimeDelegationCtx), cert[roughtimeTagDELE]...), cert[roughtimeTagSIG]) {
+		return nil, errors.New("delegation not signed by the public key of the server")
+	}
+	dele, err := parseRoughtimeMessage(cert[roughtimeTagDELE])
+	if err != nil {
+		return nil, fmt.Errorf("delegation: %w", err)
+	}
+	if err := checkRoughtimeFields(dele, roughtimeField{roughtimeTagPUBK, ed25519.PublicKeySize}, roughtimeField{roughtimeTagMINT, 8}, roughtimeField{roughtimeTagMAXT, 8}); err != nil {
+		return nil, fmt.Errorf("delegation: %w", err)
+	}
+	if !ed25519.Verify(dele[roughtimeTagPUBK], append([]byte(roughtimeResponseCtx), msg[roughtimeTagSREP]...), msg[roughtimeTagSIG]) {
+		return nil, errors.New("response not signed by the delegated key")
+	}
+
+	srep, err := parseRoughtimeMessage(msg[roughtimeTagSREP])
+	if err != nil {
+		return nil, fmt.Errorf("signed response: %w", err)
+	}
+	if err := checkRoughtimeFields(srep, roughtimeField{roughtimeTagROOT, sha512.Size}, roughtimeField{roughtimeTagMIDP, 8}, roughtimeField{roughtimeTagRADI, 4}); err != nil {
+		return nil, fmt.Errorf("signed response: %w", err)
+	}
+
+	// The server signs the root of a Merkle tree of the nonces of a batch of
+	// requests.
+	path := msg[roughtimeTagPATH]
+	if len(path)%sha512.Size != 0 {
+		return nil, errors.New("invalid Merkle tree path")
+	}
+	index := binary.LittleEndian.Uint32(msg[roughtimeTagINDX])
+	hash := sha512.Sum512(append([]byte{0}, nonce...))
+	for ; len(path) > 0; path = path[sha512.Size:] {
+		node := path[:sha512.Size]
+		if index&1 == 0 {
+			hash = sha512.Sum512(append(append([]byte{1}, hash[:]...), node...))
+		} else {
+			hash = sha512.Sum512(append(append([]byte{1}, node...), hash[:]...))
+		}
+		index >>= 1
+	}
+	if !bytes.Equal(hash[:], srep[roughtimeTagROOT]) {
+		return nil, errors.New("nonce not covered by the signed response")
+	}
+
+	resp := &roughtimeResponse{
+		midpoint:  roughtimeTime(srep[roughtimeTagMIDP]),
+		radius:    time.Duration(binary.LittleEndian.Uint32(srep[roughtimeTagRADI])) * time.Microsecond,
+		notBefore: roughtimeTime(dele[roughtimeTagMINT]),
+		notAfter:  roughtimeTime(dele[roughtimeTagMAXT]),
+	}
+	if resp.midpoint.Before(resp.notBefore) || resp.midpoint.After(resp.notAfter) {
+		return nil, errors.New("time outside of the validity of the delegated key")
+	}
+	return resp, nil
+}
+
+// ProbeRoughtime queries the time of a Roughtime server. The response is
+// authenticated with the public key of the server, so that unlike with SNTP
+// the offset of the local clock can be trusted.
+func ProbeRoughtime(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		validGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_roughtime_signature_valid",
+			Help: "Indicates if the response was authenticated with the public key of the server",
+		})
+		offsetGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_roughtime_offset_seconds",
+			Help: "Difference between the time of the server and the local clock",
+		})
+		radiusGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_roughtime_radius_seconds",
+			Help: "Uncertainty of the time of the server",
+		})
+		delegationExpiryGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_roughtime_delegation_expiry_timestamp_seconds",
+			Help: "Returns the end of the validity of the key signing the responses in unixtime",
+		})
+	)
+
+	publicKey, err := base64.StdEncoding.DecodeString(module.Roughtime.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		logger.Error("Invalid public key of the server", "err", err)
+		return false
+	}
+
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, roughtimeDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, module.Roughtime.IPProtocol, module.Roughtime.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	dialProtocol := "udp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "udp6"
+	}
+	dialer := &net.Dialer{}
+	if len(module.Roughtime.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(module.Roughtime.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", module.Roughtime.SourceIPAddress)
+			return false
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.UDPAddr{IP: srcIP}
+	}
+	conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), port))
+	if err != nil {
+		logger.Error("Error dialing UDP", "err", err)
+		return false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			logger.Error("Error setting deadline", "err", err)
+			return false
+		}
+	}
+
+	nonce := make([]byte, roughtimeNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		logger.Error("Error generating nonce", "err", err)
+		return false
+	}
+	// Requests are padded so that responses are not larger than requests.
+	padding := roughtimeRequestSize - 16 - roughtimeNonceSize
+	request := encodeRoughtimeMessage(map[uint32][]byte{
+		roughtimeTagNONC: nonce,
+		roughtimeTagPAD:  make([]byte, padding),
+	})
+
+	logger.Info("Sending Roughtime request")
+	sent := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		logger.Error("Error sending request", "err", err)
+		return false
+	}
+	buf := make([]byte, roughtimeMaxMessageSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		logger.Error("Error reading response", "err", err)
+		return false
+	}
+	received := time.Now()
+
+	registry.MustRegister(validGauge)
+	resp, err := verifyRoughtimeResponse(buf[:n], publicKey, nonce)
+	if err != nil {
+		logger.Error("Invalid response", "err", err)
+		return false
+	}
+	validGauge.Set(1)
+
+	// The server time is compared to the local time in the middle of the
+	// round trip.
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := resp.midpoint.Sub(local)
+	registry.MustRegister(offsetGauge, radiusGauge, delegationExpiryGauge)
+	offsetGauge.Set(offset.Seconds())
+	radiusGauge.Set(resp.radius.Seconds())
+	delegationExpiryGauge.Set(float64(resp.notAfter.Unix()))
+	logger.Info("Received Roughtime response", "offset", offset, "radius", resp.radius)
+
+	if module.Roughtime.MaxOffset > 0 && math.Abs(offset.Seconds())-resp.radius.Seconds() > module.Roughtime.MaxOffset.Seconds() {
+		logger.Error("Clock offset exceeds max_offset", "offset", offset, "max_offset", module.Roughtime.MaxOffset)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startRoughtimeServer answers Roughtime requests with the time shifted by
+// offset, as the second request of a batch of two.
+func startRoughtimeServer(t *testing.T, rootKey ed25519.PrivateKey, offset time.Duration) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	deleKey, deleKeyPriv, _ := ed25519.GenerateKey(nil)
+	now := time.Now()
+	dele := encodeRoughtimeMessage(map[uint32][]byte{
+		roughtimeTagPUBK: deleKey,
+		roughtimeTagMINT: binary.LittleEndian.AppendUint64(nil, uint64(now.Add(-time.Hour).UnixMicro())),
+		roughtimeTagMAXT: binary.LittleEndian.AppendUint64(nil, uint64(now.Add(48*time.Hour).UnixMicro())),
+	})
+	cert := encodeRoughtimeMessage(map[uint32][]byte{
+		roughtimeTagSIG:  ed25519.Sign(rootKey, append([]byte(roughtimeDelegationCtx), dele...)),
+		roughtimeTagDELE: dele,
+	})
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			request, err := parseRoughtimeMessage(buf[:n])
+			if err != nil {
+				continue
+			}
+			other := sha512.Sum512(append([]byte{0}, make([]byte, roughtimeNonceSize)...))
+			leaf := sha512.Sum512(append([]byte{0}, request[roughtimeTagNONC]...))
+			root := sha512.Sum512(append(append([]byte{1}, other[:]...), leaf[:]...))
+			srep := encodeRoughtimeMessage(map[uint32][]byte{
+				roughtimeTagROOT: root[:],
+				roughtimeTagMIDP: binary.LittleEndian.AppendUint64(nil, uint64(time.Now().Add(offset).UnixMicro())),
+				roughtimeTagRADI: binary.LittleEndian.AppendUint32(nil, 1000000),
+			})
+			resp := encodeRoughtimeMessage(map[uint32][]byte{
+				roughtimeTagSIG:  ed25519.Sign(deleKeyPriv, append([]byte(roughtimeResponseCtx), srep...)),
+				roughtimeTagPATH: other[:],
+				roughtimeTagSREP: srep,
+				roughtimeTagCERT: cert,
+				roughtimeTagINDX: binary.LittleEndian.AppendUint32(nil, 1),
+			})
+			pc.WriteTo(resp, addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestRoughtime(t *testing.T) {
+	publicKey, rootKey, _ := ed25519.GenerateKey(nil)
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+
+	tests := []struct {
+		name      string
+		publicKey ed25519.PublicKey
+		offset    time.Duration
+		maxOffset time.Duration
+		success   bool
+		valid     float64
+	}{
+		{name: "valid", publicKey: publicKey, success: true, valid: 1},
+		{name: "other key", publicKey: otherKey, success: false, valid: 0},
+		{name: "within max offset", publicKey: publicKey, offset: 5 * time.Second, maxOffset: 10 * time.Second, success: true, valid: 1},
+		{name: "beyond max offset", publicKey: publicKey, offset: time.Hour, maxOffset: 10 * time.Second, success: false, valid: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startRoughtimeServer(t, rootKey, test.offset)
+			module := config.Module{
+				Timeout: time.Second,
+				Roughtime: config.RoughtimeProbe{
+					IPProtocolFallback: true,
+					PublicKey:          base64.StdEncoding.EncodeToString(test.publicKey),
+					MaxOffset:          test.maxOffset,
+				},
+			}
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if success := ProbeRoughtime(testCTX, addr, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_roughtime_signature_valid": test.valid}, mfs, t)
+			if test.valid == 0 {
+				checkAbsentMetrics([]string{"probe_roughtime_offset_seconds"}, mfs, t)
+				return
+			}
+			checkRegistryResults(map[string]float64{"probe_roughtime_radius_seconds": 1}, mfs, t)
+			for _, mf := range mfs {
+				if mf.GetName() == "probe_roughtime_offset_seconds" {
+					if offset := mf.Metric[0].GetGauge().GetValue(); offset < test.offset.Seconds()-0.5 || offset > test.offset.Seconds()+0.5 {
+						t.Errorf("Expected an offset of about %v, got %vs", test.offset, offset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseRoughtimeMessage(t *testing.T) {
+	msg := map[uint32][]byte{
+		roughtimeTagNONC: make([]byte, 8),
+		roughtimeTagPAD:  make([]byte, 4),
+		roughtimeTagSIG:  {1, 2, 3, 4},
+	}
+	parsed, err := parseRoughtimeMessage(encodeRoughtimeMessage(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 3 || len(parsed[roughtimeTagNONC]) != 8 || string(parsed[roughtimeTagSIG]) != "\x01\x02\x03\x04" {
+		t.Fatalf("Unexpected message %v", parsed)
+	}
+
+	for _, b := range [][]byte{
+		{1},
+		{2, 0, 0, 0, 8, 0, 0, 0},
+		// The offset is beyond the values.
+		{2, 0, 0, 0, 8, 0, 0, 0, 'S', 'I', 'G', 0, 'N', 'O', 'N', 'C'},
+		// The tags are not in ascending order.
+		{2, 0, 0, 0, 0, 0, 0, 0, 'N', 'O', 'N', 'C', 'S', 'I', 'G', 0},
+	} {
+		if _, err := parseRoughtimeMessage(b); err == nil {
+			t.Errorf("Expected an error parsing %v", b)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// RunOptions are the options of Run, which correspond to the parameters of
+// /probe requests.
+type RunOptions struct {
+	// ModuleName is the name of the module, which selects its failpoints and
+	// the state kept for its targets, such as their previous certificate.
+	ModuleName string
+	// Hostname is the hostname of the target, as the hostname parameter.
+	Hostname string
+	// Logger receives the logs of the probe, which are discarded if nil.
+	Logger *slog.Logger
+}
+
+// Run probes the target with the module, as /probe does, for programs that
+// embed the probers. The timeout of the module applies in addition to the
+// deadline of ctx. It returns an error if the probe could not run, a probe
+// that failed is a result that is not successful.
+func Run(ctx context.Context, module config.Module, target string, opts RunOptions) (*ProbeResult, error) {
+	prober, err := prepareProbe(&module, opts.ModuleName, opts.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = promslog.NewNopLogger()
+	}
+	if module.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, module.Timeout)
+		defer cancel()
+	}
+	ctx = withModuleName(ctx, opts.ModuleName)
+	return runProbeInActiveHours(ctx, prober, target, module, logger, time.Now()), nil
+}
+
+// prepareProbe returns the prober of the module, and sets the hostname of the
+// target in the module.
+func prepareProbe(module *config.Module, moduleName, hostname string) (ProbeFn, error) {
+	prober, ok := Lookup(module.Prober)
+	if !ok {
+		return nil, fmt.Errorf("unknown prober %q", module.Prober)
+	}
+
+	if module.Prober == "http" && hostname != "" {
+		if err := setHTTPHost(hostname, module); err != nil {
+			return nil, err
+		}
+	}
+
+	if module.Prober == "tcp" && hostname != "" {
+		if module.TCP.TLSConfig.ServerName == "" {
+			module.TCP.TLSConfig.ServerName = hostname
+		}
+	}
+	return withFailpoint(prober, moduleName), nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "example.com" {
+			w.WriteHeader(http.StatusMisdirectedRequest)
+		}
+	}))
+	defer ts.Close()
+
+	module := config.Module{Prober: "http", Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}}
+	result, err := Run(context.Background(), module, ts.URL, RunOptions{Hostname: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Prober != "http" || result.Target != ts.URL || len(result.Phases) == 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	result, err = Run(context.Background(), module, ts.URL, RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Errorf("Expected the probe without hostname to fail, got %+v", result)
+	}
+
+	if _, err := Run(context.Background(), config.Module{Prober: "ldap"}, ts.URL, RunOptions{}); err == nil {
+		t.Error("Expected an error for an unknown prober")
+	}
+}
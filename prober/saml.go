@@ -0,0 +1,172 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// The elements of SAML 2.0 metadata the validator reads. Their namespaces
+// are not checked.
+type samlKeyDescriptor struct {
+	// Use is "signing" or "encryption", keys without it are used for both.
+	Use          string   `xml:"use,attr"`
+	Certificates []string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+type samlEntityDescriptor struct {
+	EntityID   string `xml:"entityID,attr"`
+	ValidUntil string `xml:"validUntil,attr"`
+	IDPSSO     []struct {
+		KeyDescriptors []samlKeyDescriptor `xml:"KeyDescriptor"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+type samlEntitiesDescriptor struct {
+	ValidUntil string                 `xml:"validUntil,attr"`
+	Entities   []samlEntityDescriptor `xml:"EntityDescriptor"`
+}
+
+// parseSAMLMetadata returns the entities of the metadata, whose root is an
+// EntityDescriptor or an EntitiesDescriptor, and when it is valid until.
+func parseSAMLMetadata(body []byte) ([]samlEntityDescriptor, time.Time, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var (
+		entities   []samlEntityDescriptor
+		validUntil string
+	)
+	switch root.XMLName.Local {
+	case "EntityDescriptor":
+		var entity samlEntityDescriptor
+		if err := xml.Unmarshal(body, &entity); err != nil {
+			return nil, time.Time{}, err
+		}
+		entities, validUntil = []samlEntityDescriptor{entity}, entity.ValidUntil
+	case "EntitiesDescriptor":
+		var descriptor samlEntitiesDescriptor
+		if err := xml.Unmarshal(body, &descriptor); err != nil {
+			return nil, time.Time{}, err
+		}
+		entities, validUntil = descriptor.Entities, descriptor.ValidUntil
+	default:
+		return nil, time.Time{}, fmt.Errorf("unexpected root element %q", root.XMLName.Local)
+	}
+
+	if validUntil == "" {
+		return entities, time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, validUntil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid validUntil: %w", err)
+	}
+	return entities, t, nil
+}
+
+// parseSAMLCertificate decodes a certificate of a KeyInfo element, whose
+// base64 encoding is usually wrapped.
+func parseSAMLCertificate(encoded string) (*x509.Certificate, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// validateSAMLMetadata exports the expiry of the signing certificates of the
+// identity providers of SAML metadata. The probe fails if the metadata has no
+// identity provider with a signing certificate, or if one of the
+// certificates is not valid now, or soon if so configured.
+func validateSAMLMetadata(body []byte, v config.SAMLMetadataValidator, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		certExpiryGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_saml_cert_expiry",
+			Help: "Returns the expiry of the signing certificates of the SAML identity providers in unixtime",
+		}, []string{"entity_id", "subject", "serial_number"})
+		validUntilGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_saml_metadata_valid_until",
+			Help: "Returns the end of the validity of the SAML metadata in unixtime",
+		})
+	)
+
+	entities, validUntil, err := parseSAMLMetadata(body)
+	if err != nil {
+		logger.Error("Error parsing SAML metadata", "err", err)
+		return false
+	}
+	registry.MustRegister(certExpiryGaugeVec)
+
+	now := time.Now()
+	success := true
+	if !validUntil.IsZero() {
+		registry.MustRegister(validUntilGauge)
+		validUntilGauge.Set(float64(validUntil.Unix()))
+		if now.After(validUntil) {
+			logger.Error("SAML metadata is no longer valid", "valid_until", validUntil)
+			success = false
+		}
+	}
+
+	certificates := 0
+	for _, entity := range entities {
+		for _, idp := range entity.IDPSSO {
+			for _, key := range idp.KeyDescriptors {
+				if key.Use != "" && key.Use != "signing" {
+					continue
+				}
+				for _, encoded := range key.Certificates {
+					cert, err := parseSAMLCertificate(encoded)
+					if err != nil {
+						logger.Error("Error parsing SAML signing certificate", "entity_id", entity.EntityID, "err", err)
+						success = false
+						continue
+					}
+					certificates++
+					certExpiryGaugeVec.WithLabelValues(entity.EntityID, cert.Subject.String(), fmt.Sprintf("%x", cert.SerialNumber.Bytes())).Set(float64(cert.NotAfter.Unix()))
+					switch {
+					case now.Before(cert.NotBefore):
+						logger.Error("SAML signing certificate is not valid yet", "entity_id", entity.EntityID, "not_before", cert.NotBefore)
+						success = false
+					case now.After(cert.NotAfter):
+						logger.Error("SAML signing certificate has expired", "entity_id", entity.EntityID, "not_after", cert.NotAfter)
+						success = false
+					case now.Add(time.Duration(v.FailIfCertExpiresWithin)).After(cert.NotAfter):
+						logger.Error("SAML signing certificate expires soon", "entity_id", entity.EntityID, "not_after", cert.NotAfter)
+						success = false
+					}
+				}
+			}
+		}
+	}
+	if certificates == 0 {
+		logger.Error("SAML metadata has no identity provider signing certificate")
+		return false
+	}
+	return success
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// samlTestMetadata returns the metadata of an identity provider with a key
+// for the given use whose certificate expires at the given time, wrapped as
+// in the metadata of common identity providers.
+func samlTestMetadata(expiry time.Time, use string) string {
+	cert, _, _ := generateSelfSignedCertificate(generateCertificateTemplate(expiry, false))
+	encoded := base64.StdEncoding.EncodeToString(cert.Raw)
+	var wrapped string
+	for len(encoded) > 64 {
+		wrapped += encoded[:64] + "\n          "
+		encoded = encoded[64:]
+	}
+	wrapped += encoded
+	return fmt.Sprintf(`<?xml version="1.0"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" entityID="https://idp.example.com/saml">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="%s">
+      <ds:KeyInfo>
+        <ds:X509Data>
+          <ds:X509Certificate>
+          %s
+          </ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, use, wrapped)
+}
+
+func TestSAMLMetadata(t *testing.T) {
+	expiry := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name      string
+		metadata  string
+		validator config.SAMLMetadataValidator
+		success   bool
+		expiry    float64
+	}{
+		{
+			name:      "valid",
+			metadata:  samlTestMetadata(expiry, "signing"),
+			validator: config.SAMLMetadataValidator{Enabled: true},
+			success:   true,
+			expiry:    float64(expiry.Unix()),
+		},
+		{
+			name:      "expires soon",
+			metadata:  samlTestMetadata(expiry, "signing"),
+			validator: config.SAMLMetadataValidator{Enabled: true, FailIfCertExpiresWithin: model.Duration(30 * 24 * time.Hour)},
+			expiry:    float64(expiry.Unix()),
+		},
+		{
+			name:      "expired",
+			metadata:  samlTestMetadata(time.Now().Add(-time.Hour).Truncate(time.Second), "signing"),
+			validator: config.SAMLMetadataValidator{Enabled: true},
+		},
+		{
+			name:      "encryption only",
+			metadata:  samlTestMetadata(expiry, "encryption"),
+			validator: config.SAMLMetadataValidator{Enabled: true},
+		},
+		{
+			name:      "not metadata",
+			metadata:  "<html><body>Login</body></html>",
+			validator: config.SAMLMetadataValidator{Enabled: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/samlmetadata+xml")
+				w.Write([]byte(test.metadata))
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback:   true,
+				ValidateSAMLMetadata: test.validator,
+			}}, registry, promslog.NewNopLogger())
+			if result != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, result)
+			}
+			if test.expiry == 0 {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_saml_cert_expiry": test.expiry}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{"probe_saml_cert_expiry": {"entity_id": "https://idp.example.com/saml"}}, mfs, t)
+		})
+	}
+}
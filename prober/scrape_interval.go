@@ -0,0 +1,80 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeIntervalExpiry is how long the time of a probe is remembered. Targets
+// that are scraped less often than this never report an interval.
+const scrapeIntervalExpiry = time.Hour
+
+type scrapeIntervalKey struct {
+	module string
+	target string
+}
+
+// scrapeIntervalTracker remembers when each target and module combination
+// was last probed.
+type scrapeIntervalTracker struct {
+	mu        sync.Mutex
+	last      map[scrapeIntervalKey]time.Time
+	lastPrune time.Time
+}
+
+var scrapeIntervals = &scrapeIntervalTracker{last: map[scrapeIntervalKey]time.Time{}}
+
+// observe records a probe started at now and returns the time elapsed since
+// the previous probe of the same module and target, if there was one.
+func (t *scrapeIntervalTracker) observe(module, target string, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastPrune) > scrapeIntervalExpiry {
+		for k, last := range t.last {
+			if now.Sub(last) > scrapeIntervalExpiry {
+				delete(t.last, k)
+			}
+		}
+		t.lastPrune = now
+	}
+
+	key := scrapeIntervalKey{module: module, target: target}
+	last, ok := t.last[key]
+	t.last[key] = now
+	if !ok || now.Sub(last) > scrapeIntervalExpiry {
+		return 0, false
+	}
+	return now.Sub(last), true
+}
+
+// measureScrapeInterval exports the time since the previous probe of the same
+// module and target, which shows gaps in the scrape schedule as well as
+// duplicate scrapes, e.g. from a pair of HA Prometheus servers.
+func measureScrapeInterval(module, target string, start time.Time, registry *prometheus.Registry) {
+	interval, ok := scrapeIntervals.observe(module, target, start)
+	if !ok {
+		return
+	}
+	scrapeIntervalGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_scrape_interval_seconds",
+		Help: "Time since the previous probe of the same target and module started",
+	})
+	registry.MustRegister(scrapeIntervalGauge)
+	scrapeIntervalGauge.Set(interval.Seconds())
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+)
+
+func TestScrapeIntervalTracker(t *testing.T) {
+	tracker := &scrapeIntervalTracker{last: map[scrapeIntervalKey]time.Time{}}
+	now := time.Now()
+
+	if _, ok := tracker.observe("http_2xx", "a", now); ok {
+		t.Fatal("Expected no interval for the first probe")
+	}
+	if interval, ok := tracker.observe("http_2xx", "a", now.Add(15*time.Second)); !ok || interval != 15*time.Second {
+		t.Fatalf("Expected interval of 15s, got %v (%t)", interval, ok)
+	}
+	if _, ok := tracker.observe("tcp_connect", "a", now.Add(15*time.Second)); ok {
+		t.Fatal("Expected no interval for a different module")
+	}
+	if _, ok := tracker.observe("http_2xx", "a", now.Add(15*time.Second+2*scrapeIntervalExpiry)); ok {
+		t.Fatal("Expected no interval after expiry")
+	}
+	if len(tracker.last) != 1 {
+		t.Fatalf("Expected expired entries to be pruned, got %v", tracker.last)
+	}
+}
+
+func TestMeasureScrapeIntervalParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	probe := func(query string) string {
+		req, err := http.NewRequest("GET", "?target="+ts.URL+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Handler(rr, req, c, promslog.NewNopLogger(), &ResultHistory{}, 0.5, nil, nil, &promslog.AllowedLevel{})
+		return rr.Body.String()
+	}
+
+	if body := probe("&measure_scrape_interval=true"); strings.Contains(body, "probe_scrape_interval_seconds") {
+		t.Fatalf("Unexpected scrape interval for first probe: %s", body)
+	}
+	if body := probe(""); strings.Contains(body, "probe_scrape_interval_seconds") {
+		t.Fatalf("Unexpected scrape interval without parameter: %s", body)
+	}
+	if body := probe("&measure_scrape_interval=true"); !strings.Contains(body, "probe_scrape_interval_seconds") {
+		t.Fatalf("Expected scrape interval for second probe: %s", body)
+	}
+}
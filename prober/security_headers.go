@@ -0,0 +1,150 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// headerDirectives returns the lower-cased names of the directives of a
+// header using the Content-Security-Policy syntax, e.g.
+// "default-src 'self'; frame-ancestors 'none'".
+func headerDirectives(values []string) map[string]struct{} {
+	directives := map[string]struct{}{}
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ";") {
+			fields := strings.Fields(directive)
+			if len(fields) == 0 {
+				continue
+			}
+			directives[strings.ToLower(fields[0])] = struct{}{}
+		}
+	}
+	return directives
+}
+
+// checkSecurityHeaderRule returns true if the header values satisfy the rule.
+func checkSecurityHeaderRule(values []string, rule config.SecurityHeaderRule, logger *slog.Logger) bool {
+	if len(values) == 0 {
+		logger.Info("Security header is missing", "header", rule.Header)
+		return false
+	}
+
+	if len(rule.AllowedValues) > 0 {
+		for _, value := range values {
+			allowed := false
+			for _, allowedValue := range rule.AllowedValues {
+				if strings.EqualFold(strings.TrimSpace(value), allowedValue) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				logger.Info("Security header has a value that is not allowed", "header", rule.Header, "value", value)
+				return false
+			}
+		}
+	}
+
+	if len(rule.RequiredDirectives) > 0 {
+		directives := headerDirectives(values)
+		for _, required := range rule.RequiredDirectives {
+			if _, ok := directives[strings.ToLower(required)]; !ok {
+				logger.Info("Security header is missing a required directive", "header", rule.Header, "directive", required)
+				return false
+			}
+		}
+	}
+
+	if rule.Regexp.Regexp != nil {
+		matched := false
+		for _, value := range values {
+			if rule.Regexp.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			logger.Info("Security header did not match regular expression", "header", rule.Header, "regexp", rule.Regexp)
+			return false
+		}
+	}
+
+	return true
+}
+
+// scoreSecurityHeaders evaluates the response headers against the configured
+// policy. It exports the weighted score of the passing rules as well as the
+// result of every single rule, and returns false if the score is below the
+// configured threshold.
+func scoreSecurityHeaders(header http.Header, policy config.SecurityHeadersPolicy, registry *prometheus.Registry, logger *slog.Logger) bool {
+	if len(policy.Rules) == 0 {
+		return true
+	}
+
+	var (
+		scoreGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_security_headers_score",
+			Help: "Weighted ratio of security header rules the response passed, between 0 and 1",
+		})
+		headerPassGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_http_security_header_passed",
+			Help: "Indicates if the response passed the security header rule for a header",
+		}, []string{"header"})
+	)
+	registry.MustRegister(scoreGauge, headerPassGaugeVec)
+
+	var passed, total float64
+	// A header passes only if all the rules configured for it pass.
+	headerPassed := map[string]bool{}
+	for _, rule := range policy.Rules {
+		name := textproto.CanonicalMIMEHeaderKey(rule.Header)
+		total += rule.Weight
+		ok := checkSecurityHeaderRule(header[name], rule, logger)
+		if ok {
+			passed += rule.Weight
+		}
+		if prev, seen := headerPassed[name]; seen {
+			ok = ok && prev
+		}
+		headerPassed[name] = ok
+	}
+	for name, ok := range headerPassed {
+		if ok {
+			headerPassGaugeVec.WithLabelValues(name).Set(1)
+		} else {
+			headerPassGaugeVec.WithLabelValues(name).Set(0)
+		}
+	}
+
+	score := 1.0
+	if total > 0 {
+		score = passed / total
+	}
+	scoreGauge.Set(score)
+	logger.Info("Evaluated security headers", "score", score)
+
+	if score < policy.FailIfScoreBelow {
+		logger.Error("Security headers score is below the required minimum", "score", score, "min_score", policy.FailIfScoreBelow)
+		return false
+	}
+	return true
+}
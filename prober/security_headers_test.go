@@ -0,0 +1,141 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestSecurityHeadersScore(t *testing.T) {
+	rules := []config.SecurityHeaderRule{
+		{Header: "Content-Security-Policy", RequiredDirectives: []string{"default-src", "frame-ancestors"}, Weight: 2},
+		{Header: "X-Frame-Options", AllowedValues: []string{"DENY", "SAMEORIGIN"}, Weight: 1},
+		{Header: "Referrer-Policy", Regexp: config.MustNewRegexp("^(no-referrer|strict-origin)"), Weight: 1},
+	}
+
+	testcases := map[string]struct {
+		headers          map[string]string
+		failIfScoreBelow float64
+		expectedResult   bool
+		expectedScore    float64
+		expectedPassed   map[string]float64
+	}{
+		"all headers pass": {
+			headers: map[string]string{
+				"Content-Security-Policy": "default-src 'self'; Frame-Ancestors 'none'",
+				"X-Frame-Options":         "deny",
+				"Referrer-Policy":         "no-referrer",
+			},
+			failIfScoreBelow: 1,
+			expectedResult:   true,
+			expectedScore:    1,
+			expectedPassed:   map[string]float64{"Content-Security-Policy": 1, "X-Frame-Options": 1, "Referrer-Policy": 1},
+		},
+		"missing CSP directive": {
+			headers: map[string]string{
+				"Content-Security-Policy": "default-src 'self'",
+				"X-Frame-Options":         "SAMEORIGIN",
+				"Referrer-Policy":         "strict-origin-when-cross-origin",
+			},
+			failIfScoreBelow: 0.5,
+			expectedResult:   true,
+			expectedScore:    0.5,
+			expectedPassed:   map[string]float64{"Content-Security-Policy": 0, "X-Frame-Options": 1, "Referrer-Policy": 1},
+		},
+		"below threshold": {
+			headers: map[string]string{
+				"X-Frame-Options": "ALLOW-FROM https://example.com",
+				"Referrer-Policy": "unsafe-url",
+			},
+			failIfScoreBelow: 0.5,
+			expectedResult:   false,
+			expectedScore:    0,
+			expectedPassed:   map[string]float64{"Content-Security-Policy": 0, "X-Frame-Options": 0, "Referrer-Policy": 0},
+		},
+		"no threshold": {
+			headers:        map[string]string{"X-Frame-Options": "DENY"},
+			expectedResult: true,
+			expectedScore:  0.25,
+			expectedPassed: map[string]float64{"Content-Security-Policy": 0, "X-Frame-Options": 1, "Referrer-Policy": 0},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.headers {
+					w.Header().Set(k, v)
+				}
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				SecurityHeaders:    config.SecurityHeadersPolicy{Rules: rules, FailIfScoreBelow: tc.failIfScoreBelow},
+			}}
+			if result := ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()); result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_http_security_headers_score": tc.expectedScore}, mfs, t)
+			for _, mf := range mfs {
+				if mf.GetName() != "probe_http_security_header_passed" {
+					continue
+				}
+				if len(mf.Metric) != len(tc.expectedPassed) {
+					t.Fatalf("Expected %d header results, got %d", len(tc.expectedPassed), len(mf.Metric))
+				}
+				for _, m := range mf.Metric {
+					header := m.GetLabel()[0].GetValue()
+					if m.GetGauge().GetValue() != tc.expectedPassed[header] {
+						t.Fatalf("Expected %s to be %v, got %v", header, tc.expectedPassed[header], m.GetGauge().GetValue())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeHTTP(testCTX, ts.URL, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{IPProtocolFallback: true}}, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected probe to succeed")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAbsentMetrics([]string{"probe_http_security_headers_score", "probe_http_security_header_passed"}, mfs, t)
+}
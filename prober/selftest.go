@@ -0,0 +1,121 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// selfTestTimeout is the timeout of the self-test of modules without one.
+const selfTestTimeout = 10 * time.Second
+
+// selfTestTarget returns the target the self-test probes the module with, its
+// self_test_target or the loopback server listening on port. Modules whose
+// probes cannot succeed against the loopback server are not tested.
+func selfTestTarget(module config.Module, port int) (string, bool) {
+	if module.SelfTestTarget != "" {
+		return module.SelfTestTarget, true
+	}
+	addr := net.JoinHostPort("localhost", strconv.Itoa(port))
+	switch module.Prober {
+	case "http":
+		return "http://" + addr + "/", true
+	case "tcp":
+		if module.TCP.TLS || len(module.TCP.QueryResponse) > 0 || module.TCP.ExpectFailure {
+			return "", false
+		}
+		return addr, true
+	case "icmp":
+		return "localhost", true
+	default:
+		return "", false
+	}
+}
+
+// SelfTest probes each module once, to check that it works on this host, e.g.
+// that the exporter has the privileges of the icmp prober and that the IP
+// protocol of the module is available. Modules are probed against their
+// self_test_target, or a loopback HTTP server answering 200 to any request
+// for the http, tcp and icmp probers. It returns the success of the probe of
+// each module that was tested.
+func SelfTest(ctx context.Context, modules map[string]config.Module, logger *slog.Logger) map[string]bool {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		logger.Error("Error starting the loopback server of the self-test", "err", err)
+		return nil
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	listeners := []net.Listener{ln}
+	// The server listens on the same port over IPv6, so that localhost
+	// targets can be reached with either protocol.
+	if ln6, err := net.Listen("tcp", net.JoinHostPort("::1", strconv.Itoa(port))); err == nil {
+		listeners = append(listeners, ln6)
+	} else {
+		logger.Debug("Loopback server of the self-test not available over IPv6", "err", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	for _, l := range listeners {
+		go server.Serve(l)
+	}
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = map[string]bool{}
+	)
+	for name, module := range modules {
+		target, ok := selfTestTarget(module, port)
+		if !ok {
+			logger.Info("Module not self-tested, set self_test_target to test it", "module", name)
+			continue
+		}
+		// The probe runs whatever the time, and a failure must not be hidden
+		// by the fallback target.
+		module.ActiveHours = nil
+		module.FallbackTarget = ""
+		if module.Timeout <= 0 {
+			module.Timeout = selfTestTimeout
+		}
+		wg.Add(1)
+		go func(name string, module config.Module) {
+			defer wg.Done()
+			result, err := Run(ctx, module, target, RunOptions{ModuleName: name})
+			success := err == nil && result.Success
+			switch {
+			case success:
+				logger.Info("Module self-test succeeded", "module", name, "target", target)
+			case err != nil:
+				logger.Warn("Module self-test failed", "module", name, "target", target, "err", err)
+			case len(result.Errors) > 0:
+				logger.Warn("Module self-test failed", "module", name, "target", target, "err", result.Errors[0])
+			default:
+				logger.Warn("Module self-test failed", "module", name, "target", target)
+			}
+			mu.Lock()
+			results[name] = success
+			mu.Unlock()
+		}(name, module)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestSelfTest(t *testing.T) {
+	// A port nothing listens on, for the module expected to fail.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	httpProbe := config.DefaultHTTPProbe
+	httpProbe.IPProtocol = "ip4"
+	tcpProbe := config.DefaultTCPProbe
+	tcpProbe.IPProtocol = "ip4"
+	tlsProbe := tcpProbe
+	tlsProbe.TLS = true
+	modules := map[string]config.Module{
+		"http_2xx":    {Prober: "http", Timeout: time.Second, HTTP: httpProbe},
+		"tcp_connect": {Prober: "tcp", Timeout: time.Second, TCP: tcpProbe},
+		"tcp_tls":     {Prober: "tcp", Timeout: time.Second, TCP: tlsProbe},
+		"tcp_closed":  {Prober: "tcp", Timeout: time.Second, TCP: tcpProbe, SelfTestTarget: closedAddr},
+		"dns_udp":     {Prober: "dns", Timeout: time.Second, DNS: config.DefaultDNSProbe},
+	}
+	results := SelfTest(context.Background(), modules, promslog.NewNopLogger())
+
+	want := map[string]bool{"http_2xx": true, "tcp_connect": true, "tcp_closed": false}
+	if len(results) != len(want) {
+		t.Fatalf("Expected the self-test of %v, got %v", want, results)
+	}
+	for name, success := range want {
+		if got, ok := results[name]; !ok || got != success {
+			t.Errorf("Expected the self-test of module %s to return %t, got %v", name, success, results)
+		}
+	}
+}
+
+func TestSelfTestTarget(t *testing.T) {
+	tests := []struct {
+		module config.Module
+		target string
+		ok     bool
+	}{
+		{config.Module{Prober: "http"}, "http://localhost:9000/", true},
+		{config.Module{Prober: "tcp"}, "localhost:9000", true},
+		{config.Module{Prober: "tcp", TCP: config.TCPProbe{ExpectFailure: true}}, "", false},
+		{config.Module{Prober: "icmp"}, "localhost", true},
+		{config.Module{Prober: "dns"}, "", false},
+		{config.Module{Prober: "dns", SelfTestTarget: "127.0.0.1:53"}, "127.0.0.1:53", true},
+	}
+	for i, test := range tests {
+		target, ok := selfTestTarget(test.module, 9000)
+		if target != test.target || ok != test.ok {
+			t.Errorf("Test %d: expected %q, %t, got %q, %t", i, test.target, test.ok, target, ok)
+		}
+	}
+}
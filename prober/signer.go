@@ -0,0 +1,135 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// commandSigner signs with the key of the client certificate by running an
+// external command, e.g. a wrapper of pkcs11-tool, for keys that cannot be
+// read by the exporter. The hash function and, for RSA keys, the padding are
+// passed in the SIGNER_HASH and SIGNER_PADDING environment variables.
+type commandSigner struct {
+	ctx     context.Context
+	command []string
+	public  crypto.PublicKey
+}
+
+func (s *commandSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *commandSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash := "none"
+	if opts.HashFunc() != 0 {
+		hash = opts.HashFunc().String()
+	}
+	padding := "pkcs1v15"
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		padding = "pss"
+	}
+	cmd := exec.CommandContext(s.ctx, s.command[0], s.command[1:]...)
+	cmd.Env = append(os.Environ(), "SIGNER_HASH="+hash, "SIGNER_PADDING="+padding)
+	cmd.Stdin = bytes.NewReader(digest)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	signature, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running signer: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(signature) == 0 {
+		return nil, errors.New("signer returned an empty signature")
+	}
+	return signature, nil
+}
+
+// withExternalSigner makes the client certificate of tlsConfig sign the
+// handshakes with the command of the signer.
+func withExternalSigner(tlsConfig *tls.Config, c config.ExternalSigner) error {
+	pemBytes, err := os.ReadFile(c.CertificateFile)
+	if err != nil {
+		return err
+	}
+	var chain [][]byte
+	for block, rest := pem.Decode(pemBytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("no certificate found in %s", c.CertificateFile)
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &tls.Certificate{
+			Certificate: chain,
+			PrivateKey:  &commandSigner{ctx: cri.Context(), command: c.Command, public: leaf.PublicKey},
+			Leaf:        leaf,
+		}, nil
+	}
+	return nil
+}
+
+// newSignerRoundTripper returns the round tripper of an HTTP probe whose
+// client certificate is signed by a command. It is built like the ones of
+// http_client_config, which have no way to set the signer.
+func newSignerRoundTripper(httpClientConfig pconfig.HTTPClientConfig, c config.ExternalSigner, keepAlives bool, dialContext pconfig.DialContextFunc, keyLog io.Writer) (http.RoundTripper, error) {
+	tlsConfig, err := pconfig.NewTLSConfig(&httpClientConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := withExternalSigner(tlsConfig, c); err != nil {
+		return nil, err
+	}
+	tlsConfig.KeyLogWriter = keyLog
+	return newProbeTransport(httpClientConfig, tlsConfig, keepAlives, dialContext), nil
+}
+
+// newProbeTransport returns a transport like the ones of http_client_config,
+// with the given TLS configuration.
+func newProbeTransport(httpClientConfig pconfig.HTTPClientConfig, tlsConfig *tls.Config, keepAlives bool, dialContext pconfig.DialContextFunc) *http.Transport {
+	return &http.Transport{
+		Proxy:                 httpClientConfig.ProxyConfig.Proxy(),
+		ProxyConnectHeader:    httpClientConfig.ProxyConfig.GetProxyConnectHeader(),
+		DisableKeepAlives:     !keepAlives,
+		TLSClientConfig:       tlsConfig,
+		DisableCompression:    true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     httpClientConfig.EnableHTTP2,
+	}
+}
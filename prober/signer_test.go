@@ -0,0 +1,182 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// TestSignerHelperProcess is the signer command of the tests, signing with
+// the key in SIGNER_TEST_KEY.
+func TestSignerHelperProcess(t *testing.T) {
+	block, _ := pem.Decode([]byte(os.Getenv("SIGNER_TEST_KEY")))
+	if block == nil {
+		return
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]crypto.Hash{"SHA-256": crypto.SHA256, "SHA-384": crypto.SHA384, "SHA-512": crypto.SHA512}
+	hash := hashes[os.Getenv("SIGNER_HASH")]
+	digest, _ := io.ReadAll(os.Stdin)
+	var signature []byte
+	if os.Getenv("SIGNER_PADDING") == "pss" {
+		signature, err = rsa.SignPSS(rand.Reader, key, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	} else {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout.Write(signature)
+	os.Exit(0)
+}
+
+// testExternalSigner returns a signer running the test binary, and the TLS
+// configuration of servers requiring its client certificate.
+func testExternalSigner(t *testing.T) (config.ExternalSigner, *tls.Config) {
+	cert, certPEM, key := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().Add(time.Hour), true))
+	certFile := filepath.Join(t.TempDir(), "client.crt")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SIGNER_TEST_KEY", string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	_, serverCertPEM, serverKey := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().Add(time.Hour), true))
+	serverKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serverKey)})
+	keyPair, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := config.ExternalSigner{
+		Command:         []string{os.Args[0], "-test.run=^TestSignerHelperProcess$"},
+		CertificateFile: certFile,
+	}
+	return signer, &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+}
+
+func TestTCPExternalSigner(t *testing.T) {
+	signer, serverTLSConfig := testExternalSigner(t)
+	for _, test := range []struct {
+		name    string
+		command []string
+		success bool
+	}{
+		{name: "signed", command: signer.Command, success: true},
+		{name: "failing signer", command: []string{"false"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ln, err := tls.Listen("tcp", "127.0.0.1:0", serverTLSConfig)
+			if err != nil {
+				t.Fatalf("Error listening on socket: %s", err)
+			}
+			defer ln.Close()
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() == nil {
+					conn.Write([]byte("pong\n"))
+				}
+			}()
+
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{TCP: config.TCPProbe{
+				IPProtocolFallback: true,
+				TLS:                true,
+				TLSConfig:          pconfig.TLSConfig{InsecureSkipVerify: true},
+				TLSSigner:          config.ExternalSigner{Command: test.command, CertificateFile: signer.CertificateFile},
+			}}
+			// The handshake of the client completes before the server
+			// verifies the certificate with TLS 1.3, so the server answers
+			// once it did.
+			module.TCP.QueryResponse = []config.QueryResponse{{Expect: config.MustNewRegexp("^pong$")}}
+			if success := ProbeTCP(testCTX, ln.Addr().String(), module, prometheus.NewRegistry(), promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+		})
+	}
+}
+
+func TestHTTPExternalSigner(t *testing.T) {
+	signer, serverTLSConfig := testExternalSigner(t)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = serverTLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	for _, test := range []struct {
+		name    string
+		signer  config.ExternalSigner
+		success bool
+	}{
+		{name: "signed", signer: signer, success: true},
+		{name: "without certificate"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				HTTPClientConfig:   pconfig.HTTPClientConfig{TLSConfig: pconfig.TLSConfig{InsecureSkipVerify: true}},
+				TLSSigner:          test.signer,
+			}}
+			if success := ProbeHTTP(testCTX, ts.URL, module, prometheus.NewRegistry(), promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+		})
+	}
+}
+
+func TestExternalSignerCertificate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "empty.crt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"missing.crt", "empty.crt"} {
+		err := withExternalSigner(&tls.Config{}, config.ExternalSigner{Command: []string{"true"}, CertificateFile: filepath.Join(dir, file)})
+		if err == nil {
+			t.Errorf("Expected error for %s", file)
+		}
+	}
+}
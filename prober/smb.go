@@ -0,0 +1,236 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// SMB 2 negotiation over direct TCP, see [MS-SMB2].
+const (
+	smbDefaultPort    = "445"
+	smb2HeaderSize    = 64
+	smbMaxMessageSize = 1 << 16
+
+	smb2Negotiate       = 0
+	smb2SigningEnabled  = 0x01
+	smb2SigningRequired = 0x02
+
+	smb2PreauthIntegrityCapabilities = 1
+	smb2SHA512                       = 1
+	smb2Dialect311                   = 0x0311
+)
+
+var (
+	smb2ProtocolID = []byte{0xfe, 'S', 'M', 'B'}
+	smb1ProtocolID = []byte{0xff, 'S', 'M', 'B'}
+)
+
+// smbDialectRevisions are the revision numbers of the dialects.
+var smbDialectRevisions = map[string]uint16{
+	"2.0.2": 0x0202,
+	"2.1":   0x0210,
+	"3.0":   0x0300,
+	"3.0.2": 0x0302,
+	"3.1.1": smb2Dialect311,
+}
+
+func smbDialectName(revision uint16) string {
+	for name, r := range smbDialectRevisions {
+		if r == revision {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", revision)
+}
+
+// encodeSMBNegotiate returns a NEGOTIATE request offering the dialects, with
+// the transport header. Offering SMB 3.1.1 requires a preauthentication
+// integrity context.
+func encodeSMBNegotiate(dialects []uint16, clientGUID, salt []byte) []byte {
+	b := make([]byte, smb2HeaderSize, 256)
+	copy(b, smb2ProtocolID)
+	binary.LittleEndian.PutUint16(b[4:], smb2HeaderSize)
+	binary.LittleEndian.PutUint16(b[12:], smb2Negotiate)
+	// Credits requested.
+	binary.LittleEndian.PutUint16(b[14:], 1)
+
+	b = binary.LittleEndian.AppendUint16(b, 36)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(dialects)))
+	b = binary.LittleEndian.AppendUint16(b, smb2SigningEnabled)
+	b = append(b, make([]byte, 6)...)
+	b = append(b, clientGUID...)
+	// The negotiate context offset and count, or the unused client start time.
+	contexts := len(b)
+	b = append(b, make([]byte, 8)...)
+	offers311 := false
+	for _, dialect := range dialects {
+		b = binary.LittleEndian.AppendUint16(b, dialect)
+		offers311 = offers311 || dialect == smb2Dialect311
+	}
+	if offers311 {
+		for len(b)%8 != 0 {
+			b = append(b, 0)
+		}
+		binary.LittleEndian.PutUint32(b[contexts:], uint32(len(b)))
+		binary.LittleEndian.PutUint16(b[contexts+4:], 1)
+		b = binary.LittleEndian.AppendUint16(b, smb2PreauthIntegrityCapabilities)
+		b = binary.LittleEndian.AppendUint16(b, uint16(6+len(salt)))
+		b = append(b, make([]byte, 4)...)
+		b = binary.LittleEndian.AppendUint16(b, 1)
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(salt)))
+		b = binary.LittleEndian.AppendUint16(b, smb2SHA512)
+		b = append(b, salt...)
+	}
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(b))), b...)
+}
+
+// readSMBMessage reads a message without its transport header.
+func readSMBMessage(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length > smbMaxMessageSize {
+		return nil, fmt.Errorf("invalid SMB message length %d", length)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// smbNegotiateResponse is the content of a NEGOTIATE response.
+type smbNegotiateResponse struct {
+	securityMode uint16
+	dialect      uint16
+}
+
+func decodeSMBNegotiateResponse(msg []byte) (*smbNegotiateResponse, error) {
+	if len(msg) >= 4 && string(msg[:4]) == string(smb1ProtocolID) {
+		return nil, errors.New("server only supports SMB 1")
+	}
+	if len(msg) < smb2HeaderSize+64 || string(msg[:4]) != string(smb2ProtocolID) {
+		return nil, errors.New("invalid SMB 2 NEGOTIATE response")
+	}
+	if command := binary.LittleEndian.Uint16(msg[12:]); command != smb2Negotiate {
+		return nil, fmt.Errorf("unexpected SMB 2 command %d", command)
+	}
+	if status := binary.LittleEndian.Uint32(msg[8:]); status != 0 {
+		return nil, fmt.Errorf("negotiation failed with status 0x%08x", status)
+	}
+	body := msg[smb2HeaderSize:]
+	return &smbNegotiateResponse{
+		securityMode: binary.LittleEndian.Uint16(body[2:]),
+		dialect:      binary.LittleEndian.Uint16(body[4:]),
+	}, nil
+}
+
+// ProbeSMB negotiates an SMB dialect with the target, which a file server
+// only does if its SMB service is running.
+func ProbeSMB(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_smb_duration_seconds",
+			Help: "Duration of the SMB negotiation by phase",
+		}, []string{"phase"})
+		dialectGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_smb_dialect_info",
+			Help: "Contains the SMB dialect selected by the server",
+		}, []string{"dialect"})
+		signingRequiredGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_smb_signing_required",
+			Help: "Indicates if the server requires messages to be signed",
+		})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.SMB
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, smbDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+
+	start := time.Now()
+	conn, err := dialTCPIP(ctx, ip, port, c.SourceIPAddress, logger)
+	durationGaugeVec.WithLabelValues("connect").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error dialing SMB server", "err", err)
+		return false
+	}
+	defer conn.Close()
+
+	dialects := make([]uint16, 0, len(c.Dialects))
+	for _, name := range c.Dialects {
+		dialects = append(dialects, smbDialectRevisions[name])
+	}
+	random := make([]byte, 48)
+	if _, err := rand.Read(random); err != nil {
+		logger.Error("Error generating client GUID", "err", err)
+		return false
+	}
+
+	logger.Info("Sending NEGOTIATE", "dialects", c.Dialects)
+	start = time.Now()
+	if _, err := conn.Write(encodeSMBNegotiate(dialects, random[:16], random[16:])); err != nil {
+		logger.Error("Error sending NEGOTIATE", "err", err)
+		return false
+	}
+	msg, err := readSMBMessage(conn)
+	durationGaugeVec.WithLabelValues("negotiate").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error reading NEGOTIATE response", "err", err)
+		return false
+	}
+	resp, err := decodeSMBNegotiateResponse(msg)
+	if err != nil {
+		logger.Error("Error negotiating SMB dialect", "err", err)
+		return false
+	}
+
+	dialect := smbDialectName(resp.dialect)
+	logger.Info("Negotiated SMB dialect", "dialect", dialect, "security_mode", resp.securityMode)
+	registry.MustRegister(dialectGaugeVec, signingRequiredGauge)
+	dialectGaugeVec.WithLabelValues(dialect).Set(1)
+	if resp.securityMode&smb2SigningRequired != 0 {
+		signingRequiredGauge.Set(1)
+	}
+
+	for _, offered := range dialects {
+		if offered == resp.dialect {
+			return true
+		}
+	}
+	logger.Error("Server selected a dialect that was not offered", "dialect", dialect)
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startSMBTestServer selects the highest offered dialect up to maxDialect,
+// and requires signing. It rejects SMB 3.1.1 offers without a
+// preauthentication integrity context, as Windows does.
+func startSMBTestServer(t *testing.T, maxDialect uint16) string {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(time.Second))
+		req, err := readSMBMessage(conn)
+		if err != nil || len(req) < smb2HeaderSize+36 {
+			return
+		}
+		body := req[smb2HeaderSize:]
+		count := int(binary.LittleEndian.Uint16(body[2:]))
+		var selected uint16
+		for i := 0; i < count; i++ {
+			dialect := binary.LittleEndian.Uint16(body[36+2*i:])
+			if dialect <= maxDialect && dialect > selected {
+				selected = dialect
+			}
+		}
+		// STATUS_NOT_SUPPORTED.
+		var status uint32 = 0xc00000bb
+		if selected == smb2Dialect311 {
+			offset := binary.LittleEndian.Uint32(body[28:])
+			if binary.LittleEndian.Uint16(body[32:]) == 0 || int(offset)+2 > len(req) || binary.LittleEndian.Uint16(req[offset:]) != smb2PreauthIntegrityCapabilities {
+				selected = 0
+			}
+		}
+		if selected != 0 {
+			status = 0
+		}
+
+		resp := make([]byte, smb2HeaderSize+64)
+		copy(resp, smb2ProtocolID)
+		binary.LittleEndian.PutUint16(resp[4:], smb2HeaderSize)
+		binary.LittleEndian.PutUint32(resp[8:], status)
+		binary.LittleEndian.PutUint16(resp[smb2HeaderSize:], 65)
+		binary.LittleEndian.PutUint16(resp[smb2HeaderSize+2:], smb2SigningEnabled|smb2SigningRequired)
+		binary.LittleEndian.PutUint16(resp[smb2HeaderSize+4:], selected)
+		conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(resp))), resp...))
+	}()
+	return ln.Addr().String()
+}
+
+func TestSMB(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxDialect uint16
+		dialects   []string
+		success    bool
+		dialect    string
+	}{
+		{name: "SMB 3.1.1", maxDialect: smb2Dialect311, dialects: config.DefaultSMBProbe.Dialects, success: true, dialect: "3.1.1"},
+		{name: "SMB 2.1", maxDialect: 0x0210, dialects: config.DefaultSMBProbe.Dialects, success: true, dialect: "2.1"},
+		{name: "no common dialect", maxDialect: 0x0210, dialects: []string{"3.0", "3.0.2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startSMBTestServer(t, test.maxDialect)
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			module := config.Module{SMB: config.SMBProbe{IPProtocol: "ip4", Dialects: test.dialects}}
+			if success := ProbeSMB(testCTX, addr, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if !test.success {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_smb_signing_required": 1}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{"probe_smb_dialect_info": {"dialect": test.dialect}}, mfs, t)
+		})
+	}
+}
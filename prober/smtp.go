@@ -0,0 +1,201 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const smtpDefaultPort = "25"
+
+// smtpCommand sends a command and returns the code and text of the reply,
+// whose lines are joined by newlines.
+func smtpCommand(tp *textproto.Conn, command string) (int, string, error) {
+	id, err := tp.Cmd("%s", command)
+	if err != nil {
+		return 0, "", err
+	}
+	tp.StartResponse(id)
+	defer tp.EndResponse(id)
+	return tp.ReadResponse(0)
+}
+
+// smtpExtensions returns the keywords of the extensions in the reply to
+// EHLO, whose first line is the greeting of the server.
+func smtpExtensions(reply string) map[string]bool {
+	extensions := map[string]bool{}
+	lines := strings.Split(reply, "\n")
+	for _, line := range lines[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			extensions[strings.ToUpper(fields[0])] = true
+		}
+	}
+	return extensions
+}
+
+// ProbeSMTP checks that a mail server greets with a 220 banner, and
+// optionally that it answers EHLO and upgrades the connection with STARTTLS.
+func ProbeSMTP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_smtp_duration_seconds",
+			Help: "Duration of the SMTP session by phase",
+		}, []string{"phase"})
+		statusCodeGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_smtp_status_code",
+			Help: "Reply code of the server to the greeting and the commands",
+		}, []string{"command"})
+		bannerValidGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_smtp_banner_valid",
+			Help: "Indicates if the banner has the 220 code and matches banner_regexp",
+		})
+		startTLSGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_smtp_starttls_advertised",
+			Help: "Indicates if the server advertises STARTTLS in its reply to EHLO",
+		})
+	)
+	registry.MustRegister(durationGaugeVec, statusCodeGaugeVec)
+
+	c := module.SMTP
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, smtpDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+
+	start := time.Now()
+	conn, err := dialTCPIP(ctx, ip, port, c.SourceIPAddress, logger)
+	durationGaugeVec.WithLabelValues("connect").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error dialing SMTP server", "err", err)
+		return false
+	}
+	defer func() { conn.Close() }()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		logger.Error("Error setting deadline", "err", err)
+		return false
+	}
+	tp := textproto.NewConn(conn)
+
+	start = time.Now()
+	code, banner, err := tp.ReadResponse(0)
+	durationGaugeVec.WithLabelValues("banner").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error reading banner", "err", err)
+		return false
+	}
+	registry.MustRegister(bannerValidGauge)
+	statusCodeGaugeVec.WithLabelValues("banner").Set(float64(code))
+	logger.Info("Got banner", "code", code, "banner", banner)
+	if code != 220 {
+		logger.Error("Server is not ready", "code", code)
+		return false
+	}
+	if c.BannerRegexp.Regexp != nil && !c.BannerRegexp.MatchString(banner) {
+		logger.Error("Banner did not match regexp", "regexp", c.BannerRegexp)
+		return false
+	}
+	bannerValidGauge.Set(1)
+
+	if c.EHLO || c.StartTLS {
+		start = time.Now()
+		code, reply, err := smtpCommand(tp, "EHLO "+c.EHLODomain)
+		durationGaugeVec.WithLabelValues("ehlo").Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("Error sending EHLO", "err", err)
+			return false
+		}
+		statusCodeGaugeVec.WithLabelValues("ehlo").Set(float64(code))
+		if code != 250 {
+			logger.Error("EHLO was rejected", "code", code, "reply", reply)
+			return false
+		}
+		registry.MustRegister(startTLSGauge)
+		if !smtpExtensions(reply)["STARTTLS"] {
+			if c.StartTLS {
+				logger.Error("Server does not advertise STARTTLS")
+				return false
+			}
+		} else {
+			startTLSGauge.Set(1)
+		}
+	}
+
+	if c.StartTLS {
+		start = time.Now()
+		code, reply, err := smtpCommand(tp, "STARTTLS")
+		if err != nil {
+			logger.Error("Error sending STARTTLS", "err", err)
+			return false
+		}
+		statusCodeGaugeVec.WithLabelValues("starttls").Set(float64(code))
+		if code != 220 {
+			logger.Error("STARTTLS was rejected", "code", code, "reply", reply)
+			return false
+		}
+		tlsConfig, err := pconfig.NewTLSConfig(&c.TLSConfig)
+		if err != nil {
+			logger.Error("Failed to create TLS configuration", "err", err)
+			return false
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = targetAddress
+		}
+		tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
+		tlsConn := tls.Client(conn, tlsConfig)
+		err = tlsConn.HandshakeContext(ctx)
+		durationGaugeVec.WithLabelValues("starttls").Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("TLS handshake failed", "err", err)
+			return false
+		}
+		state := tlsConn.ConnectionState()
+		exportTLSState(ctx, target, &state, registry)
+		conn = tlsConn
+		tp = textproto.NewConn(conn)
+
+		// The state of the session is discarded by STARTTLS, the server has
+		// to be greeted again.
+		code, reply, err = smtpCommand(tp, "EHLO "+c.EHLODomain)
+		if err != nil {
+			logger.Error("Error sending EHLO after STARTTLS", "err", err)
+			return false
+		}
+		if code != 250 {
+			logger.Error("EHLO after STARTTLS was rejected", "code", code, "reply", reply)
+			return false
+		}
+	}
+
+	if code, reply, err := smtpCommand(tp, "QUIT"); err != nil || code != 221 {
+		logger.Debug("Server did not acknowledge QUIT", "code", code, "reply", reply, "err", err)
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// startSMTPServer answers the probes with banner, and advertises STARTTLS
+// if tlsConfig is set.
+func startSMTPServer(t *testing.T, banner string, tlsConfig *tls.Config) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { conn.Close() }()
+				conn.Write([]byte(banner))
+				tp := textproto.NewConn(conn)
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					switch command, _, _ := strings.Cut(line, " "); command {
+					case "EHLO":
+						reply := "250-mail.example.com\r\n250-SIZE 10240000\r\n"
+						if tlsConfig != nil {
+							if _, ok := conn.(*tls.Conn); !ok {
+								reply += "250-STARTTLS\r\n"
+							}
+						}
+						conn.Write([]byte(reply + "250 8BITMIME\r\n"))
+					case "STARTTLS":
+						conn.Write([]byte("220 2.0.0 Ready to start TLS\r\n"))
+						conn = tls.Server(conn, tlsConfig)
+						tp = textproto.NewConn(conn)
+					case "QUIT":
+						conn.Write([]byte("221 2.0.0 Bye\r\n"))
+						return
+					default:
+						conn.Write([]byte("500 5.5.1 Unknown command\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestSMTPExtensions(t *testing.T) {
+	extensions := smtpExtensions("mail.example.com greets localhost\nSIZE 10240000\nstarttls\nAUTH PLAIN LOGIN")
+	for _, extension := range []string{"SIZE", "STARTTLS", "AUTH"} {
+		if !extensions[extension] {
+			t.Errorf("Expected extension %s in %v", extension, extensions)
+		}
+	}
+	if extensions["MAIL.EXAMPLE.COM"] {
+		t.Errorf("Expected the greeting not to be an extension, got %v", extensions)
+	}
+}
+
+func TestProbeSMTP(t *testing.T) {
+	certExpiry := time.Now().AddDate(0, 0, 1)
+	_, certPEM, key := generateSelfSignedCertificate(generateCertificateTemplate(certExpiry, true))
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLSConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	tests := []struct {
+		name     string
+		banner   string
+		tls      bool
+		probe    config.SMTPProbe
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:     "banner",
+			banner:   "220 mail.example.com ESMTP Postfix\r\n",
+			probe:    config.SMTPProbe{BannerRegexp: config.MustNewRegexp("ESMTP")},
+			success:  true,
+			expected: map[string]float64{"probe_smtp_banner_valid": 1, "probe_smtp_status_code": 220},
+		},
+		{
+			name:     "banner not matching",
+			banner:   "220 mail.example.com ESMTP Exim\r\n",
+			probe:    config.SMTPProbe{BannerRegexp: config.MustNewRegexp("Postfix")},
+			expected: map[string]float64{"probe_smtp_banner_valid": 0},
+		},
+		{
+			name:     "service not available",
+			banner:   "554-mail.example.com\r\n554 No SMTP service here\r\n",
+			expected: map[string]float64{"probe_smtp_banner_valid": 0, "probe_smtp_status_code": 554},
+		},
+		{
+			name:     "ehlo",
+			banner:   "220 mail.example.com ESMTP\r\n",
+			probe:    config.SMTPProbe{EHLO: true, EHLODomain: "localhost"},
+			success:  true,
+			expected: map[string]float64{"probe_smtp_starttls_advertised": 0},
+		},
+		{
+			name:     "starttls not advertised",
+			banner:   "220 mail.example.com ESMTP\r\n",
+			probe:    config.SMTPProbe{StartTLS: true, EHLODomain: "localhost"},
+			expected: map[string]float64{"probe_smtp_starttls_advertised": 0},
+		},
+		{
+			name:     "starttls",
+			banner:   "220 mail.example.com ESMTP\r\n",
+			tls:      true,
+			probe:    config.SMTPProbe{StartTLS: true, EHLODomain: "localhost", TLSConfig: pconfig.TLSConfig{InsecureSkipVerify: true}},
+			success:  true,
+			expected: map[string]float64{"probe_smtp_starttls_advertised": 1, "probe_ssl_earliest_cert_expiry": float64(certExpiry.Unix())},
+		},
+		{
+			name:   "starttls with an untrusted certificate",
+			banner: "220 mail.example.com ESMTP\r\n",
+			tls:    true,
+			probe:  config.SMTPProbe{StartTLS: true, EHLODomain: "localhost"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tlsConfig *tls.Config
+			if test.tls {
+				tlsConfig = serverTLSConfig
+			}
+			target := startSMTPServer(t, test.banner, tlsConfig)
+			testCTX, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			test.probe.IPProtocol = "ip4"
+			if success := ProbeSMTP(testCTX, target, config.Module{Timeout: time.Second, SMTP: test.probe}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+		})
+	}
+}
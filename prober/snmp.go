@@ -0,0 +1,622 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const (
+	snmpDefaultPort    = "161"
+	snmpMaxMessageSize = 65507
+
+	snmpFlagAuth       = 0x01
+	snmpFlagPriv       = 0x02
+	snmpFlagReportable = 0x04
+)
+
+// snmpUSMReports are the errors reported by the User-based Security Model,
+// see RFC 3414.
+var snmpUSMReports = map[string]string{
+	"1.3.6.1.6.3.15.1.1.1.0": "unsupported security level",
+	"1.3.6.1.6.3.15.1.1.2.0": "not in time window",
+	"1.3.6.1.6.3.15.1.1.3.0": "unknown user name",
+	"1.3.6.1.6.3.15.1.1.4.0": "unknown engine ID",
+	"1.3.6.1.6.3.15.1.1.5.0": "wrong digest",
+	"1.3.6.1.6.3.15.1.1.6.0": "decryption error",
+}
+
+const snmpReportNotInTimeWindow = "1.3.6.1.6.3.15.1.1.2.0"
+
+// snmpPDU is a PDU with at most one variable binding, as sent by the probe.
+type snmpPDU struct {
+	tag         byte
+	requestID   int64
+	errorStatus int64
+	oid         string
+	value       berTLV
+}
+
+func encodeSNMPGetRequest(requestID int64, oid []byte) []byte {
+	var varbinds []byte
+	if oid != nil {
+		varbinds = berEncodeSequence(berSequence, oid, berEncode(berNull, nil))
+	}
+	return berEncodeSequence(snmpGetRequest,
+		berEncodeInteger(requestID), berEncodeInteger(0), berEncodeInteger(0),
+		berEncode(berSequence, varbinds))
+}
+
+func decodeSNMPPDU(v berTLV) (*snmpPDU, error) {
+	if v.tag&0xe0 != 0xa0 {
+		return nil, fmt.Errorf("unexpected PDU type 0x%02x", v.tag)
+	}
+	fields, err := berDecodeSequence(v, v.tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 4 {
+		return nil, errors.New("invalid PDU")
+	}
+	pdu := &snmpPDU{tag: v.tag}
+	if pdu.requestID, err = berDecodeInteger(fields[0]); err != nil {
+		return nil, err
+	}
+	if pdu.errorStatus, err = berDecodeInteger(fields[1]); err != nil {
+		return nil, err
+	}
+	varbinds, err := berDecodeSequence(fields[3], berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(varbinds) == 0 {
+		return pdu, nil
+	}
+	varbind, err := berDecodeSequence(varbinds[0], berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(varbind) != 2 || varbind[0].tag != berOID {
+		return nil, errors.New("invalid variable binding")
+	}
+	if pdu.oid, err = berDecodeOID(varbind[0].content); err != nil {
+		return nil, err
+	}
+	pdu.value = varbind[1]
+	return pdu, nil
+}
+
+// encodeSNMPv2cMessage wraps a PDU in an SNMP v2c message.
+func encodeSNMPv2cMessage(community string, pdu []byte) []byte {
+	return berEncodeSequence(berSequence, berEncodeInteger(1), berEncode(berOctetString, []byte(community)), pdu)
+}
+
+func decodeSNMPv2cMessage(b []byte) (*snmpPDU, error) {
+	msg, _, err := berDecode(b)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := berDecodeSequence(msg, berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 3 {
+		return nil, errors.New("invalid SNMP message")
+	}
+	if version, err := berDecodeInteger(fields[0]); err != nil || version != 1 {
+		return nil, errors.New("not an SNMP v2c message")
+	}
+	return decodeSNMPPDU(fields[2])
+}
+
+// usmEngine is the SNMP engine of the agent, whose ID and clock are
+// discovered before the first authenticated request.
+type usmEngine struct {
+	id    []byte
+	boots int64
+	time  int64
+}
+
+// usmUser holds the keys of a user of the User-based Security Model,
+// localized to the engine of the agent.
+type usmUser struct {
+	name     string
+	flags    byte
+	authHash func() hash.Hash
+	authLen  int
+	authKey  []byte
+	privKey  []byte
+}
+
+func newUSMUser(c config.SNMPProbe, engineID []byte) *usmUser {
+	u := &usmUser{name: c.Username, flags: snmpFlagReportable}
+	if c.SecurityLevel == "noAuthNoPriv" {
+		return u
+	}
+	u.flags |= snmpFlagAuth
+	switch c.AuthProtocol {
+	case "MD5":
+		u.authHash, u.authLen = md5.New, 12
+	case "SHA":
+		u.authHash, u.authLen = sha1.New, 12
+	case "SHA256":
+		u.authHash, u.authLen = sha256.New, 24
+	}
+	u.authKey = usmLocalizedKey(u.authHash, string(c.AuthPassword), engineID)
+	if c.SecurityLevel == "authPriv" {
+		u.flags |= snmpFlagPriv
+		// AES-128 uses the start of the key localized with the hash of the
+		// authentication protocol, see RFC 3826.
+		u.privKey = usmLocalizedKey(u.authHash, string(c.PrivPassword), engineID)[:16]
+	}
+	return u
+}
+
+// usmLocalizedKey derives the key of a password for an engine, see RFC 3414
+// section A.2.
+func usmLocalizedKey(newHash func() hash.Hash, password string, engineID []byte) []byte {
+	h := newHash()
+	buf := make([]byte, 64)
+	for i := 0; i < 1048576; i += len(buf) {
+		for j := range buf {
+			buf[j] = password[(i+j)%len(password)]
+		}
+		h.Write(buf)
+	}
+	key := h.Sum(nil)
+	h.Reset()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// snmpV3Message is a decoded SNMP v3 message.
+type snmpV3Message struct {
+	msgID  int64
+	flags  byte
+	engine usmEngine
+	user   string
+	// authParams refers to the bytes of the message, for the verification
+	// of the digest.
+	authParams []byte
+	privParams []byte
+	data       berTLV
+}
+
+func encodeSNMPv3Message(msgID int64, flags byte, engine usmEngine, user string, authParams, privParams, data []byte) []byte {
+	secParams := berEncodeSequence(berSequence,
+		berEncode(berOctetString, engine.id),
+		berEncodeInteger(engine.boots),
+		berEncodeInteger(engine.time),
+		berEncode(berOctetString, []byte(user)),
+		berEncode(berOctetString, authParams),
+		berEncode(berOctetString, privParams))
+	return berEncodeSequence(berSequence,
+		berEncodeInteger(3),
+		berEncodeSequence(berSequence,
+			berEncodeInteger(msgID),
+			berEncodeInteger(snmpMaxMessageSize),
+			berEncode(berOctetString, []byte{flags}),
+			berEncodeInteger(3)),
+		berEncode(berOctetString, secParams),
+		data)
+}
+
+func decodeSNMPv3Message(b []byte) (*snmpV3Message, error) {
+	v, _, err := berDecode(b)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := berDecodeSequence(v, berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 4 {
+		return nil, errors.New("invalid SNMP message")
+	}
+	if version, err := berDecodeInteger(fields[0]); err != nil || version != 3 {
+		return nil, errors.New("not an SNMP v3 message")
+	}
+	global, err := berDecodeSequence(fields[1], berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(global) != 4 || global[2].tag != berOctetString || len(global[2].content) != 1 {
+		return nil, errors.New("invalid SNMP v3 header")
+	}
+	msg := &snmpV3Message{flags: global[2].content[0], data: fields[3]}
+	if msg.msgID, err = berDecodeInteger(global[0]); err != nil {
+		return nil, err
+	}
+	if fields[2].tag != berOctetString {
+		return nil, errors.New("invalid security parameters")
+	}
+	secParams, _, err := berDecode(fields[2].content)
+	if err != nil {
+		return nil, err
+	}
+	sec, err := berDecodeSequence(secParams, berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(sec) != 6 {
+		return nil, errors.New("invalid security parameters")
+	}
+	msg.engine.id = sec[0].content
+	if msg.engine.boots, err = berDecodeInteger(sec[1]); err != nil {
+		return nil, err
+	}
+	if msg.engine.time, err = berDecodeInteger(sec[2]); err != nil {
+		return nil, err
+	}
+	msg.user = string(sec[3].content)
+	msg.authParams = sec[4].content
+	msg.privParams = sec[5].content
+	return msg, nil
+}
+
+// digest computes the digest of a message, whose authentication parameters
+// are replaced by zeros.
+func (u *usmUser) digest(b []byte, authParams []byte) []byte {
+	// authParams is a slice of b: the difference of their capacities is
+	// its offset.
+	offset := cap(b) - cap(authParams)
+	zeroed := append([]byte(nil), b...)
+	clear(zeroed[offset : offset+len(authParams)])
+	mac := hmac.New(u.authHash, u.authKey)
+	mac.Write(zeroed)
+	return mac.Sum(nil)[:u.authLen]
+}
+
+func (u *usmUser) aesIV(engine usmEngine, salt []byte) []byte {
+	iv := binary.BigEndian.AppendUint32(nil, uint32(engine.boots))
+	iv = binary.BigEndian.AppendUint32(iv, uint32(engine.time))
+	return append(iv, salt...)
+}
+
+// encodeMessage encodes a PDU as sent by the user.
+func (u *usmUser) encodeMessage(msgID int64, engine usmEngine, contextName string, pdu []byte) ([]byte, error) {
+	data := berEncodeSequence(berSequence,
+		berEncode(berOctetString, engine.id),
+		berEncode(berOctetString, []byte(contextName)),
+		pdu)
+	var authParams, privParams []byte
+	if u.flags&snmpFlagPriv != 0 {
+		privParams = make([]byte, 8)
+		if _, err := rand.Read(privParams); err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(u.privKey)
+		if err != nil {
+			return nil, err
+		}
+		encrypted := make([]byte, len(data))
+		cipher.NewCFBEncrypter(block, u.aesIV(engine, privParams)).XORKeyStream(encrypted, data)
+		data = berEncode(berOctetString, encrypted)
+	}
+	if u.flags&snmpFlagAuth != 0 {
+		authParams = make([]byte, u.authLen)
+	}
+	b := encodeSNMPv3Message(msgID, u.flags, engine, u.name, authParams, privParams, data)
+	if u.flags&snmpFlagAuth != 0 {
+		msg, err := decodeSNMPv3Message(b)
+		if err != nil {
+			return nil, err
+		}
+		copy(msg.authParams, u.digest(b, msg.authParams))
+	}
+	return b, nil
+}
+
+// decodeMessage verifies and decrypts a message to the user.
+func (u *usmUser) decodeMessage(b []byte, msg *snmpV3Message) (*snmpPDU, error) {
+	data := msg.data
+	// Reports of errors before the user was authenticated are not
+	// authenticated themselves.
+	if msg.flags&snmpFlagAuth != 0 {
+		if u.flags&snmpFlagAuth == 0 {
+			return nil, errors.New("unexpected authenticated message")
+		}
+		if !hmac.Equal(msg.authParams, u.digest(b, msg.authParams)) {
+			return nil, errors.New("message digest does not match")
+		}
+	} else if u.flags&snmpFlagAuth != 0 && data.tag == berOctetString {
+		return nil, errors.New("unauthenticated encrypted message")
+	}
+	if msg.flags&snmpFlagPriv != 0 {
+		if u.flags&snmpFlagPriv == 0 || data.tag != berOctetString || len(msg.privParams) != 8 {
+			return nil, errors.New("unexpected encrypted message")
+		}
+		block, err := aes.NewCipher(u.privKey)
+		if err != nil {
+			return nil, err
+		}
+		decrypted := make([]byte, len(data.content))
+		cipher.NewCFBDecrypter(block, u.aesIV(msg.engine, msg.privParams)).XORKeyStream(decrypted, data.content)
+		if data, _, err = berDecode(decrypted); err != nil {
+			return nil, fmt.Errorf("decrypting message: %w", err)
+		}
+	}
+	scoped, err := berDecodeSequence(data, berSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(scoped) != 3 {
+		return nil, errors.New("invalid scoped PDU")
+	}
+	pdu, err := decodeSNMPPDU(scoped[2])
+	if err != nil {
+		return nil, err
+	}
+	if u.flags&snmpFlagAuth != 0 && msg.flags&snmpFlagAuth == 0 && pdu.tag != snmpReport {
+		return nil, errors.New("unauthenticated message")
+	}
+	return pdu, nil
+}
+
+// snmpExchange sends a request and returns the first response accepted by
+// decode, skipping the responses to previous requests.
+func snmpExchange(conn net.Conn, request []byte, decode func([]byte) (*snmpPDU, bool, error)) (*snmpPDU, error) {
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, snmpMaxMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		pdu, ok, err := decode(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return pdu, nil
+		}
+	}
+}
+
+func randomSNMPID() int64 {
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<31-1))
+	if err != nil {
+		return time.Now().UnixNano() & (1<<31 - 1)
+	}
+	return n.Int64()
+}
+
+func snmpGetV2c(conn net.Conn, community string, oid []byte) (*snmpPDU, error) {
+	requestID := randomSNMPID()
+	request := encodeSNMPv2cMessage(community, encodeSNMPGetRequest(requestID, oid))
+	return snmpExchange(conn, request, func(b []byte) (*snmpPDU, bool, error) {
+		pdu, err := decodeSNMPv2cMessage(b)
+		if err != nil {
+			return nil, false, err
+		}
+		return pdu, pdu.requestID == requestID, nil
+	})
+}
+
+// discoverSNMPEngine asks the agent for the ID and clock of its engine, see
+// RFC 3414 section 4.
+func discoverSNMPEngine(conn net.Conn) (usmEngine, error) {
+	msgID := randomSNMPID()
+	request := encodeSNMPv3Message(msgID, snmpFlagReportable, usmEngine{}, "", nil, nil,
+		berEncodeSequence(berSequence, berEncode(berOctetString, nil), berEncode(berOctetString, nil), encodeSNMPGetRequest(randomSNMPID(), nil)))
+	var engine usmEngine
+	_, err := snmpExchange(conn, request, func(b []byte) (*snmpPDU, bool, error) {
+		msg, err := decodeSNMPv3Message(b)
+		if err != nil {
+			return nil, false, err
+		}
+		engine = msg.engine
+		return nil, msg.msgID == msgID, nil
+	})
+	if err == nil && len(engine.id) == 0 {
+		err = errors.New("agent did not report its engine ID")
+	}
+	return engine, err
+}
+
+func snmpGetV3(conn net.Conn, user *usmUser, engine usmEngine, contextName string, oid []byte) (*snmpPDU, error) {
+	// A request out of the time window of the agent is retried once with
+	// the clock it reports.
+	for attempt := 0; ; attempt++ {
+		msgID := randomSNMPID()
+		request, err := user.encodeMessage(msgID, engine, contextName, encodeSNMPGetRequest(randomSNMPID(), oid))
+		if err != nil {
+			return nil, err
+		}
+		pdu, err := snmpExchange(conn, request, func(b []byte) (*snmpPDU, bool, error) {
+			msg, err := decodeSNMPv3Message(b)
+			if err != nil {
+				return nil, false, err
+			}
+			if msg.msgID != msgID {
+				return nil, false, nil
+			}
+			pdu, err := user.decodeMessage(b, msg)
+			if err == nil && pdu.oid == snmpReportNotInTimeWindow {
+				engine.boots, engine.time = msg.engine.boots, msg.engine.time
+			}
+			return pdu, true, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if pdu.tag != snmpReport {
+			return pdu, nil
+		}
+		if pdu.oid != snmpReportNotInTimeWindow || attempt > 0 {
+			if report, ok := snmpUSMReports[pdu.oid]; ok {
+				return nil, fmt.Errorf("agent reported %s", report)
+			}
+			return nil, fmt.Errorf("agent reported %s", pdu.oid)
+		}
+	}
+}
+
+// ProbeSNMP gets the value of an OID from an SNMP agent, to check that the
+// agent is reachable and accepts the credentials of the module.
+func ProbeSNMP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_snmp_duration_seconds",
+			Help: "Duration of the SNMP requests by phase",
+		}, []string{"phase"})
+		valueGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_snmp_value",
+			Help: "The value of the OID, if it is numeric",
+		})
+		probeFailedDueToRegex = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_failed_due_to_regex",
+			Help: "Indicates if probe failed due to regex",
+		})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.SNMP
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, snmpDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	dialProtocol := "udp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "udp6"
+	}
+	dialer := &net.Dialer{}
+	if len(c.SourceIPAddress) > 0 {
+		srcIP := net.ParseIP(c.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", c.SourceIPAddress)
+			return false
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.UDPAddr{IP: srcIP}
+	}
+	conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), port))
+	if err != nil {
+		logger.Error("Error dialing UDP", "err", err)
+		return false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			logger.Error("Error setting deadline", "err", err)
+			return false
+		}
+	}
+
+	oid, err := berEncodeOID(c.OID)
+	if err != nil {
+		logger.Error("Error encoding OID", "err", err)
+		return false
+	}
+
+	var pdu *snmpPDU
+	if c.Version == 3 {
+		logger.Info("Discovering the SNMP engine of the agent")
+		start := time.Now()
+		engine, err := discoverSNMPEngine(conn)
+		durationGaugeVec.WithLabelValues("discovery").Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("Error discovering the SNMP engine", "err", err)
+			return false
+		}
+		logger.Info("Getting OID", "oid", c.OID, "version", c.Version, "security_level", c.SecurityLevel)
+		start = time.Now()
+		pdu, err = snmpGetV3(conn, newUSMUser(c, engine.id), engine, c.ContextName, oid)
+		durationGaugeVec.WithLabelValues("request").Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("Error getting OID", "err", err)
+			return false
+		}
+	} else {
+		logger.Info("Getting OID", "oid", c.OID, "version", c.Version)
+		start := time.Now()
+		pdu, err = snmpGetV2c(conn, string(c.Community), oid)
+		durationGaugeVec.WithLabelValues("request").Set(time.Since(start).Seconds())
+		if err != nil {
+			logger.Error("Error getting OID", "err", err)
+			return false
+		}
+	}
+
+	if pdu.tag != snmpResponse {
+		logger.Error("Agent sent an unexpected PDU", "type", pdu.tag)
+		return false
+	}
+	if pdu.errorStatus != 0 {
+		logger.Error("Agent returned an error", "error_status", pdu.errorStatus)
+		return false
+	}
+	if pdu.oid != c.OID {
+		logger.Error("Agent returned another OID", "oid", pdu.oid)
+		return false
+	}
+	value, err := decodeSNMPValue(pdu.value)
+	if err != nil {
+		logger.Error("Error getting the value of the OID", "err", err)
+		return false
+	}
+	logger.Info("Received value", "value", value.text)
+
+	if value.numeric {
+		registry.MustRegister(valueGauge)
+		valueGauge.Set(value.number)
+	}
+	if c.ValueRegexp.Regexp != nil {
+		registry.MustRegister(probeFailedDueToRegex)
+		if !c.ValueRegexp.MatchString(value.text) {
+			logger.Error("Value did not match regular expression", "regexp", c.ValueRegexp.String())
+			probeFailedDueToRegex.Set(1)
+			return false
+		}
+	}
+	if c.ValueMin != nil || c.ValueMax != nil {
+		if !value.numeric {
+			logger.Error("Value is not numeric, cannot check its range")
+			return false
+		}
+		if (c.ValueMin != nil && value.number < *c.ValueMin) || (c.ValueMax != nil && value.number > *c.ValueMax) {
+			logger.Error("Value is out of range", "value", value.number)
+			return false
+		}
+	}
+	return true
+}
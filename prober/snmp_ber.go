@@ -0,0 +1,254 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// The subset of BER used by SNMP messages.
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+
+	snmpIPAddress      = 0x40
+	snmpCounter32      = 0x41
+	snmpGauge32        = 0x42
+	snmpTimeTicks      = 0x43
+	snmpOpaque         = 0x44
+	snmpCounter64      = 0x46
+	snmpNoSuchObject   = 0x80
+	snmpNoSuchInstance = 0x81
+	snmpEndOfMibView   = 0x82
+
+	snmpGetRequest = 0xa0
+	snmpResponse   = 0xa2
+	snmpReport     = 0xa8
+)
+
+// berTLV is a decoded BER element.
+type berTLV struct {
+	tag     byte
+	content []byte
+}
+
+func berEncode(tag byte, content []byte) []byte {
+	b := []byte{tag}
+	switch n := len(content); {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n <= 0xff:
+		b = append(b, 0x81, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, content...)
+}
+
+func berEncodeSequence(tag byte, elements ...[]byte) []byte {
+	var content []byte
+	for _, e := range elements {
+		content = append(content, e...)
+	}
+	return berEncode(tag, content)
+}
+
+func berEncodeInteger(v int64) []byte {
+	var content []byte
+	for {
+		content = append([]byte{byte(v)}, content...)
+		v >>= 8
+		if (v == 0 && content[0]&0x80 == 0) || (v == -1 && content[0]&0x80 != 0) {
+			break
+		}
+	}
+	return berEncode(berInteger, content)
+}
+
+func berEncodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(oid, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	arcs := make([]uint64, len(parts))
+	for i, p := range parts {
+		arc, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %q", oid)
+		}
+		arcs[i] = arc
+	}
+	content := berEncodeBase128(nil, arcs[0]*40+arcs[1])
+	for _, arc := range arcs[2:] {
+		content = berEncodeBase128(content, arc)
+	}
+	return berEncode(berOID, content), nil
+}
+
+func berEncodeBase128(b []byte, v uint64) []byte {
+	var groups []byte
+	for {
+		groups = append([]byte{byte(v & 0x7f)}, groups...)
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := range groups[:len(groups)-1] {
+		groups[i] |= 0x80
+	}
+	return append(b, groups...)
+}
+
+// berDecode decodes the element at the start of b, and returns the rest.
+func berDecode(b []byte) (berTLV, []byte, error) {
+	if len(b) < 2 {
+		return berTLV{}, nil, errors.New("truncated BER element")
+	}
+	tag, length, b := b[0], int(b[1]), b[2:]
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 3 || len(b) < n {
+			return berTLV{}, nil, errors.New("invalid BER length")
+		}
+		length = 0
+		for _, c := range b[:n] {
+			length = length<<8 | int(c)
+		}
+		b = b[n:]
+	}
+	if len(b) < length {
+		return berTLV{}, nil, errors.New("truncated BER element")
+	}
+	return berTLV{tag: tag, content: b[:length]}, b[length:], nil
+}
+
+// berDecodeSequence decodes the elements of a constructed element, which
+// must have the given tag.
+func berDecodeSequence(v berTLV, tag byte) ([]berTLV, error) {
+	if v.tag != tag {
+		return nil, fmt.Errorf("unexpected BER tag 0x%02x, expected 0x%02x", v.tag, tag)
+	}
+	var elements []berTLV
+	for b := v.content; len(b) > 0; {
+		var (
+			e   berTLV
+			err error
+		)
+		e, b, err = berDecode(b)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+	}
+	return elements, nil
+}
+
+func berDecodeInteger(v berTLV) (int64, error) {
+	if v.tag != berInteger || len(v.content) == 0 || len(v.content) > 8 {
+		return 0, errors.New("invalid BER integer")
+	}
+	n := int64(int8(v.content[0]))
+	for _, c := range v.content[1:] {
+		n = n<<8 | int64(c)
+	}
+	return n, nil
+}
+
+// berDecodeUnsigned decodes the unsigned integers of SNMP, such as counters.
+func berDecodeUnsigned(content []byte) (uint64, error) {
+	if len(content) == 0 || len(content) > 9 || (len(content) == 9 && content[0] != 0) {
+		return 0, errors.New("invalid BER unsigned integer")
+	}
+	var n uint64
+	for _, c := range content {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func berDecodeOID(content []byte) (string, error) {
+	var (
+		arcs []string
+		arc  uint64
+	)
+	for i, c := range content {
+		arc = arc<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(content)-1 {
+				return "", errors.New("truncated BER OID")
+			}
+			continue
+		}
+		if len(arcs) == 0 {
+			first := min(arc/40, 2)
+			arcs = append(arcs, strconv.FormatUint(first, 10), strconv.FormatUint(arc-40*first, 10))
+		} else {
+			arcs = append(arcs, strconv.FormatUint(arc, 10))
+		}
+		arc = 0
+	}
+	if len(arcs) == 0 {
+		return "", errors.New("empty BER OID")
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+// snmpValue is the value of a variable binding.
+type snmpValue struct {
+	// text is the value as displayed and matched against regexps.
+	text string
+	// number is the value of numeric types.
+	number  float64
+	numeric bool
+}
+
+func decodeSNMPValue(v berTLV) (snmpValue, error) {
+	switch v.tag {
+	case berInteger:
+		n, err := berDecodeInteger(v)
+		return snmpValue{text: strconv.FormatInt(n, 10), number: float64(n), numeric: true}, err
+	case snmpCounter32, snmpGauge32, snmpTimeTicks, snmpCounter64:
+		n, err := berDecodeUnsigned(v.content)
+		return snmpValue{text: strconv.FormatUint(n, 10), number: float64(n), numeric: true}, err
+	case berOctetString, snmpOpaque:
+		return snmpValue{text: string(v.content)}, nil
+	case berOID:
+		oid, err := berDecodeOID(v.content)
+		return snmpValue{text: oid}, err
+	case snmpIPAddress:
+		if len(v.content) != 4 {
+			return snmpValue{}, errors.New("invalid IP address")
+		}
+		return snmpValue{text: net.IP(v.content).String()}, nil
+	case berNull:
+		return snmpValue{}, errors.New("no value")
+	case snmpNoSuchObject:
+		return snmpValue{}, errors.New("no such object")
+	case snmpNoSuchInstance:
+		return snmpValue{}, errors.New("no such instance")
+	case snmpEndOfMibView:
+		return snmpValue{}, errors.New("end of MIB view")
+	}
+	return snmpValue{}, fmt.Errorf("unsupported value type 0x%02x", v.tag)
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+var snmpTestEngine = usmEngine{id: []byte{0x80, 0x00, 0x1f, 0x88, 0x04, 't', 'e', 's', 't'}, boots: 3, time: 1000}
+
+// snmpTestAgent answers GetRequests for sysUpTime.0 and sysDescr.0, with
+// SNMP v2c for the community "public" and SNMP v3 for the configured user.
+type snmpTestAgent struct {
+	user *usmUser
+}
+
+func (a *snmpTestAgent) values(oid string) []byte {
+	switch oid {
+	case "1.3.6.1.2.1.1.3.0":
+		return berEncode(snmpTimeTicks, []byte{0x01, 0x00, 0x00})
+	case "1.3.6.1.2.1.1.1.0":
+		return berEncode(berOctetString, []byte("Linux router 6.1"))
+	}
+	return berEncode(snmpNoSuchObject, nil)
+}
+
+func (a *snmpTestAgent) response(pdu *snmpPDU) []byte {
+	oid, _ := berEncodeOID(pdu.oid)
+	return berEncodeSequence(snmpResponse,
+		berEncodeInteger(pdu.requestID), berEncodeInteger(0), berEncodeInteger(0),
+		berEncodeSequence(berSequence, berEncodeSequence(berSequence, oid, a.values(pdu.oid))))
+}
+
+func (a *snmpTestAgent) report(msgID int64, oid string) []byte {
+	encodedOID, _ := berEncodeOID(oid)
+	pdu := berEncodeSequence(snmpReport,
+		berEncodeInteger(0), berEncodeInteger(0), berEncodeInteger(0),
+		berEncodeSequence(berSequence, berEncodeSequence(berSequence, encodedOID, berEncode(snmpCounter32, []byte{1}))))
+	return encodeSNMPv3Message(msgID, 0, snmpTestEngine, "", nil, nil,
+		berEncodeSequence(berSequence, berEncode(berOctetString, snmpTestEngine.id), berEncode(berOctetString, nil), pdu))
+}
+
+func (a *snmpTestAgent) handle(b []byte) []byte {
+	if pdu, err := decodeSNMPv2cMessage(b); err == nil {
+		msg, _, _ := berDecode(b)
+		fields, _ := berDecodeSequence(msg, berSequence)
+		if string(fields[1].content) != "public" {
+			return nil
+		}
+		return encodeSNMPv2cMessage("public", a.response(pdu))
+	}
+	msg, err := decodeSNMPv3Message(b)
+	if err != nil {
+		return nil
+	}
+	if len(msg.engine.id) == 0 {
+		return a.report(msg.msgID, "1.3.6.1.6.3.15.1.1.4.0")
+	}
+	if msg.user != a.user.name {
+		return a.report(msg.msgID, "1.3.6.1.6.3.15.1.1.3.0")
+	}
+	pdu, err := a.user.decodeMessage(b, msg)
+	if err != nil {
+		return a.report(msg.msgID, "1.3.6.1.6.3.15.1.1.5.0")
+	}
+	resp, _ := a.user.encodeMessage(msg.msgID, snmpTestEngine, "", a.response(pdu))
+	return resp
+}
+
+func startSNMPTestAgent(t *testing.T, user config.SNMPProbe) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	agent := &snmpTestAgent{user: newUSMUser(user, snmpTestEngine.id)}
+	go func() {
+		buf := make([]byte, snmpMaxMessageSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := agent.handle(buf[:n]); resp != nil {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestSNMP(t *testing.T) {
+	v3User := config.DefaultSNMPProbe
+	v3User.Version = 3
+	v3User.Username = "monitor"
+	v3User.SecurityLevel = "authPriv"
+	v3User.AuthPassword = "authpassword"
+	v3User.PrivPassword = "privpassword"
+
+	minUptime, maxUptime := 1000.0, 10000.0
+	tests := []struct {
+		name    string
+		setup   func(*config.SNMPProbe)
+		success bool
+		value   float64
+		// agentLevel is the security level of the user of the agent.
+		agentLevel string
+	}{
+		{name: "v2c", success: true, value: 65536},
+		{name: "v2c wrong community", setup: func(c *config.SNMPProbe) { c.Community = "private" }},
+		{name: "v2c no such object", setup: func(c *config.SNMPProbe) { c.OID = "1.3.6.1.2.1.1.99.0" }},
+		{name: "v2c value regexp", success: true, setup: func(c *config.SNMPProbe) {
+			c.OID = "1.3.6.1.2.1.1.1.0"
+			c.ValueRegexp = config.MustNewRegexp("^Linux")
+		}},
+		{name: "v2c value regexp mismatch", setup: func(c *config.SNMPProbe) {
+			c.OID = "1.3.6.1.2.1.1.1.0"
+			c.ValueRegexp = config.MustNewRegexp("^Cisco")
+		}},
+		{name: "v2c value in range", success: true, value: 65536, setup: func(c *config.SNMPProbe) { c.ValueMin = &minUptime }},
+		{name: "v2c value out of range", setup: func(c *config.SNMPProbe) { c.ValueMax = &maxUptime }},
+		{name: "v3 authPriv", success: true, value: 65536, setup: func(c *config.SNMPProbe) { *c = v3User }},
+		{name: "v3 authNoPriv", success: true, value: 65536, agentLevel: "authNoPriv", setup: func(c *config.SNMPProbe) {
+			*c = v3User
+			c.SecurityLevel = "authNoPriv"
+		}},
+		{name: "v3 wrong password", setup: func(c *config.SNMPProbe) {
+			*c = v3User
+			c.AuthPassword = "wrongpassword"
+		}},
+		{name: "v3 unknown user", setup: func(c *config.SNMPProbe) {
+			*c = v3User
+			c.Username = "admin"
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agentUser := v3User
+			if test.agentLevel != "" {
+				agentUser.SecurityLevel = test.agentLevel
+			}
+			addr := startSNMPTestAgent(t, agentUser)
+
+			c := config.DefaultSNMPProbe
+			if test.setup != nil {
+				test.setup(&c)
+			}
+			testCTX, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if success := ProbeSNMP(testCTX, addr, config.Module{SNMP: c}, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if test.value == 0 {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_snmp_value": test.value}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{"probe_snmp_duration_seconds": {"phase": "request"}}, mfs, t)
+		})
+	}
+}
+
+func TestUSMLocalizedKey(t *testing.T) {
+	// The test vectors of RFC 3414 section A.3.
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+	if key := hex.EncodeToString(usmLocalizedKey(md5.New, "maplesyrup", engineID)); key != "526f5eed9fcce26f8964c2930787d82b" {
+		t.Errorf("Unexpected MD5 key %s", key)
+	}
+	if key := hex.EncodeToString(usmLocalizedKey(sha1.New, "maplesyrup", engineID)); key != "6695febc9288e36282235fc7151f128497b38f3f" {
+		t.Errorf("Unexpected SHA key %s", key)
+	}
+}
+
+func TestBEROID(t *testing.T) {
+	for _, oid := range []string{"1.3.6.1.2.1.1.3.0", "1.3.6.1.4.1.2636.3.1.13.1.8.9.1.0.0", "2.999.3"} {
+		encoded, err := berEncodeOID(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _, err := berDecode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded, err := berDecodeOID(v.content); err != nil || decoded != oid {
+			t.Errorf("Expected OID %s, got %s (%v)", oid, decoded, err)
+		}
+	}
+}
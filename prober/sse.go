@@ -0,0 +1,180 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// sseEvent is an event of a Server-Sent Events stream.
+type sseEvent struct {
+	eventType string
+	data      string
+}
+
+// readSSEEvent reads the next event of a Server-Sent Events stream, as
+// defined by the HTML standard. Comments and blocks without data, such as
+// the keep-alives of servers, are skipped.
+func readSSEEvent(r *bufio.Reader) (sseEvent, error) {
+	var (
+		eventType string
+		data      []string
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// An event that is not terminated by a blank line is
+			// discarded.
+			return sseEvent{}, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			if data != nil {
+				if eventType == "" {
+					eventType = "message"
+				}
+				return sseEvent{eventType: eventType, data: strings.Join(data, "\n")}, nil
+			}
+			eventType = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
+
+// ProbeSSE connects to the Server-Sent Events stream of the target and waits
+// for its first events, whose data is validated with regexps.
+func ProbeSSE(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		statusCodeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_sse_status_code",
+			Help: "Response HTTP status code of the event stream",
+		})
+		eventsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_sse_events_received",
+			Help: "Number of events received, of the event type of the module if it has one",
+		})
+		firstEventGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_sse_time_to_first_event_seconds",
+			Help: "Time from the request to the first event",
+		})
+	)
+	registry.MustRegister(statusCodeGauge, eventsGauge)
+
+	c := module.SSE
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "http://" + target
+	}
+	client, err := pconfig.NewClientFromConfig(c.HTTPClientConfig, "sse_probe", pconfig.WithKeepAlivesDisabled())
+	if err != nil {
+		logger.Error("Error generating HTTP client", "err", err)
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		logger.Error("Error creating request", "err", err)
+		return false
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Error("Error connecting to the event stream", "err", err)
+		return false
+	}
+	defer resp.Body.Close()
+	statusCodeGauge.Set(float64(resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Invalid HTTP response status code, wanted 200", "status_code", resp.StatusCode)
+		return false
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "text/event-stream" {
+		logger.Error("Response is not an event stream", "content_type", resp.Header.Get("Content-Type"))
+		return false
+	}
+
+	// Modules without sse settings wait for one event.
+	want := max(c.Events, 1)
+	r := bufio.NewReader(resp.Body)
+	for received := 0; received < want; {
+		event, err := readSSEEvent(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				logger.Error("Timed out waiting for events", "received", received, "events", want)
+			} else {
+				logger.Error("Error reading the event stream", "received", received, "events", want, "err", err)
+			}
+			return false
+		}
+		if c.EventType != "" && event.eventType != c.EventType {
+			continue
+		}
+		received++
+		eventsGauge.Set(float64(received))
+		if received == 1 {
+			registry.MustRegister(firstEventGauge)
+			firstEventGauge.Set(time.Since(start).Seconds())
+		}
+		logger.Info("Received event", "event", event.eventType, "data_length", len(event.data))
+		if !matchSSEData(event.data, c, logger) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSSEData checks the data of an event against the regexps of the
+// module.
+func matchSSEData(data string, c config.SSEProbe, logger *slog.Logger) bool {
+	for _, re := range c.FailIfDataMatchesRegexp {
+		if re.MatchString(data) {
+			logger.Error("Event data matched regular expression", "regexp", re)
+			return false
+		}
+	}
+	for _, re := range c.FailIfDataNotMatchesRegexp {
+		if !re.MatchString(data) {
+			logger.Error("Event data did not match regular expression", "regexp", re)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestReadSSEEvent(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"data: first\n\n" +
+		"event: update\r\ndata: {\"line\": 1}\r\ndata:{\"line\": 2}\r\nid: 7\r\n\r\n" +
+		"event: ignored\n\n" +
+		"data\n\n" +
+		"data: unterminated\n"
+	r := bufio.NewReader(strings.NewReader(stream))
+
+	var events []sseEvent
+	for {
+		event, err := readSSEEvent(r)
+		if err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+		events = append(events, event)
+	}
+	expected := []sseEvent{
+		{eventType: "message", data: "first"},
+		{eventType: "update", data: "{\"line\": 1}\n{\"line\": 2}"},
+		{eventType: "message", data: ""},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expected %q, got %q", expected, events)
+	}
+}
+
+func TestProbeSSE(t *testing.T) {
+	tests := map[string]struct {
+		contentType    string
+		events         []string
+		sse            config.SSEProbe
+		expectedResult bool
+		received       float64
+	}{
+		"first event": {
+			events:         []string{"data: hello\n\n"},
+			expectedResult: true,
+			received:       1,
+		},
+		"event type": {
+			events:         []string{": ping\n\n", "event: heartbeat\ndata: {}\n\n", "event: price\ndata: {\"price\": 10}\n\n", "event: price\ndata: {\"price\": 11}\n\n"},
+			sse:            config.SSEProbe{Events: 2, EventType: "price", FailIfDataNotMatchesRegexp: []config.Regexp{config.MustNewRegexp(`"price": \d+`)}},
+			expectedResult: true,
+			received:       2,
+		},
+		"data matches": {
+			events:   []string{"data: {\"status\": \"degraded\"}\n\n"},
+			sse:      config.SSEProbe{Events: 1, FailIfDataMatchesRegexp: []config.Regexp{config.MustNewRegexp("degraded")}},
+			received: 1,
+		},
+		"too few events": {
+			events:   []string{"data: hello\n\n"},
+			sse:      config.SSEProbe{Events: 2},
+			received: 1,
+		},
+		"not an event stream": {
+			contentType: "text/html",
+			events:      []string{"data: hello\n\n"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept") != "text/event-stream" {
+					w.WriteHeader(http.StatusNotAcceptable)
+					return
+				}
+				contentType := test.contentType
+				if contentType == "" {
+					contentType = "text/event-stream; charset=utf-8"
+				}
+				w.Header().Set("Content-Type", contentType)
+				for _, event := range test.events {
+					w.Write([]byte(event))
+					w.(http.Flusher).Flush()
+				}
+				// Keep the stream open like an SSE server, until the
+				// probe gives up.
+				<-r.Context().Done()
+			}))
+			defer ts.Close()
+
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			module := config.Module{Timeout: time.Second, SSE: test.sse}
+			result := ProbeSSE(testCTX, ts.URL, module, registry, promslog.NewNopLogger())
+			if result != test.expectedResult {
+				t.Fatalf("Expected result %t, got %t", test.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{"probe_sse_status_code": 200, "probe_sse_events_received": test.received}, mfs, t)
+			var timed bool
+			for _, mf := range mfs {
+				if mf.GetName() == "probe_sse_time_to_first_event_seconds" {
+					timed = mf.GetMetric()[0].GetGauge().GetValue() > 0
+				}
+			}
+			if timed != (test.received > 0) {
+				t.Errorf("Unexpected time to first event for %v events received", test.received)
+			}
+		})
+	}
+}
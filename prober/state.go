@@ -0,0 +1,157 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateVersion is the version of the format of the state file.
+const stateVersion = 1
+
+// probeState is the state of the probers that is kept across restarts.
+type probeState struct {
+	// ICMPSequence continues the sequence of the ICMP echo requests, so that
+	// replies to requests sent before the restart are not mistaken for new
+	// ones.
+	ICMPSequence uint16 `json:"icmp_sequence"`
+	// Bodies are the previous bodies the http prober compares responses to.
+	Bodies map[string][]byte `json:"bodies,omitempty"`
+	// ModulesLastUsed are the times of the last probe request of each module,
+	// so that modules used rarely are not mistaken for unused ones.
+	ModulesLastUsed map[string]time.Time `json:"modules_last_used,omitempty"`
+}
+
+// stateFile is the content of the state file. The checksum of the state
+// detects files that were truncated or corrupted.
+type stateFile struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum"`
+	State    json.RawMessage `json:"state"`
+}
+
+func stateChecksum(state []byte) string {
+	sum := sha256.Sum256(state)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadState restores the state of the probers saved in file. A missing file
+// is not an error. The state is only restored if the whole file is valid, so
+// that the probers start with a fresh state otherwise.
+func LoadState(file string) error {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f stateFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("state file is corrupted: %w", err)
+	}
+	if f.Version != stateVersion {
+		return fmt.Errorf("state file version %d is not supported", f.Version)
+	}
+	if f.Checksum != stateChecksum(f.State) {
+		return errors.New("state file is corrupted: checksum mismatch")
+	}
+	var state probeState
+	if err := json.Unmarshal(f.State, &state); err != nil {
+		return fmt.Errorf("state file is corrupted: %w", err)
+	}
+
+	icmpSequenceMutex.Lock()
+	icmpSequence = state.ICMPSequence
+	icmpSequenceMutex.Unlock()
+
+	previousBodies.mu.Lock()
+	for target, body := range state.Bodies {
+		if len(previousBodies.bodies) >= maxPreviousBodies {
+			break
+		}
+		previousBodies.bodies[target] = body
+	}
+	previousBodies.mu.Unlock()
+
+	modulesUsage.mu.Lock()
+	for module, last := range state.ModulesLastUsed {
+		if last.After(modulesUsage.lastUsed[module]) {
+			modulesUsage.lastUsed[module] = last
+		}
+	}
+	modulesUsage.mu.Unlock()
+	return nil
+}
+
+// SaveState saves the state of the probers in file. The state is written to
+// a temporary file that replaces file, so that a crash while saving leaves
+// the previous state intact.
+func SaveState(file string) error {
+	var state probeState
+	icmpSequenceMutex.Lock()
+	state.ICMPSequence = icmpSequence
+	icmpSequenceMutex.Unlock()
+	previousBodies.mu.Lock()
+	if len(previousBodies.bodies) > 0 {
+		state.Bodies = make(map[string][]byte, len(previousBodies.bodies))
+		for target, body := range previousBodies.bodies {
+			state.Bodies[target] = body
+		}
+	}
+	previousBodies.mu.Unlock()
+	modulesUsage.mu.Lock()
+	if len(modulesUsage.lastUsed) > 0 {
+		state.ModulesLastUsed = make(map[string]time.Time, len(modulesUsage.lastUsed))
+		for module, last := range modulesUsage.lastUsed {
+			state.ModulesLastUsed[module] = last
+		}
+	}
+	modulesUsage.mu.Unlock()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	b, err = json.Marshal(stateFile{Version: stateVersion, Checksum: stateChecksum(b), State: b})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
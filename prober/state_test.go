@@ -0,0 +1,76 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state.json")
+	if err := LoadState(file); err != nil {
+		t.Fatalf("Expected a missing state file to be ignored, got %s", err)
+	}
+
+	sequence := getICMPSequence()
+	previousBodies.swap("http://state.example.com", []byte("saved body"))
+	lastUsed := time.Unix(1700000000, 0).UTC()
+	modulesUsage.record("state_module", lastUsed)
+	if err := SaveState(file); err != nil {
+		t.Fatal(err)
+	}
+
+	getICMPSequence()
+	previousBodies.swap("http://state.example.com", []byte("new body"))
+	modulesUsage.mu.Lock()
+	delete(modulesUsage.lastUsed, "state_module")
+	modulesUsage.mu.Unlock()
+	if err := LoadState(file); err != nil {
+		t.Fatal(err)
+	}
+	if icmpSequence != sequence {
+		t.Errorf("Expected ICMP sequence %d, got %d", sequence, icmpSequence)
+	}
+	if body := previousBodies.bodies["http://state.example.com"]; !bytes.Equal(body, []byte("saved body")) {
+		t.Errorf("Expected the saved body, got %q", body)
+	}
+	if last := modulesUsage.lastUsed["state_module"]; !last.Equal(lastUsed) {
+		t.Errorf("Expected the module to be last used at %s, got %s", lastUsed, last)
+	}
+
+	// A state file modified or truncated is not restored.
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, corrupted := range map[string][]byte{
+		"modified":  bytes.Replace(b, []byte(`"icmp_sequence":`), []byte(`"icmp_sequence":1`), 1),
+		"truncated": b[:len(b)/2],
+	} {
+		if err := os.WriteFile(file, corrupted, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		getICMPSequence()
+		if err := LoadState(file); err == nil {
+			t.Errorf("Expected an error loading the %s state file", name)
+		}
+		if icmpSequence == sequence {
+			t.Errorf("Expected the ICMP sequence not to be restored from the %s state file", name)
+		}
+	}
+}
@@ -15,11 +15,21 @@ package prober
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	pconfig "github.com/prometheus/common/config"
@@ -27,6 +37,23 @@ import (
 	"github.com/prometheus/blackbox_exporter/config"
 )
 
+// newTCPTLSConfig returns the TLS configuration of the connections of a TCP
+// probe, also used by STARTTLS.
+func newTCPTLSConfig(ctx context.Context, c config.TCPProbe) (*tls.Config, error) {
+	tlsConfig, err := pconfig.NewTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	if c.TLSSigner.Enabled() {
+		if err := withExternalSigner(tlsConfig, c.TLSSigner); err != nil {
+			return nil, err
+		}
+	}
+	tlsConfig.KeyLogWriter = tlsKeyLogWriter(ctx)
+	applyTLSExpectations(tlsConfig, c.ValidateTLS)
+	return tlsConfig, nil
+}
+
 func dialTCP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) (net.Conn, error) {
 	var dialProtocol, dialTarget string
 	dialer := &net.Dialer{}
@@ -64,7 +91,7 @@ func dialTCP(ctx context.Context, target string, module config.Module, registry
 		logger.Info("Dialing TCP without TLS")
 		return dialer.DialContext(ctx, dialProtocol, dialTarget)
 	}
-	tlsConfig, err := pconfig.NewTLSConfig(&module.TCP.TLSConfig)
+	tlsConfig, err := newTCPTLSConfig(ctx, module.TCP)
 	if err != nil {
 		logger.Error("Error creating TLS configuration", "err", err)
 		return nil, err
@@ -105,28 +132,279 @@ func probeExpectInfo(registry *prometheus.Registry, qr *config.QueryResponse, by
 	metric.WithLabelValues(values...).Set(1)
 }
 
+// tcpDialFailureReason classifies the error of a connection attempt. It
+// returns an empty string if the error is not caused by the network, e.g. a
+// resolution or configuration error.
+func tcpDialFailureReason(err error) string {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "timeout"
+		}
+		return ""
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case opErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "unreachable"
+	default:
+		return "other"
+	}
+}
+
+// expectTCPFailure returns true if the connection attempt failed as expected.
+func expectTCPFailure(conn net.Conn, err error, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	if err == nil {
+		conn.Close()
+		logger.Error("Connection succeeded to a target expected to be unreachable")
+		return false
+	}
+
+	reason := tcpDialFailureReason(err)
+	if reason == "" {
+		logger.Error("Error dialing TCP", "err", err)
+		return false
+	}
+	failureGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_tcp_connection_failure",
+		Help: "Indicates why the connection failed, when the probe expects it to fail",
+	}, []string{"reason"})
+	registry.MustRegister(failureGaugeVec)
+	failureGaugeVec.WithLabelValues(reason).Set(1)
+	logger.Info("Connection failed as expected", "reason", reason, "err", err)
+
+	if module.TCP.ExpectRefused && reason != "refused" {
+		logger.Error("Connection was not refused by the target", "reason", reason)
+		return false
+	}
+	return true
+}
+
+// tcpMaxReceivedBytes bounds the lines kept for the success criteria and the
+// send templates, the oldest lines are dropped beyond it.
+const tcpMaxReceivedBytes = 1 << 20
+
+var errReadLimit = errors.New("read limit of the step exceeded")
+
+// tcpReaderBuffers are the buffers of the readers, which are large enough for
+// the longest line and reused across probes.
+var tcpReaderBuffers = sync.Pool{
+	New: func() any {
+		return bufio.NewReaderSize(nil, bufio.MaxScanTokenSize)
+	},
+}
+
+// tcpReader reads what the target sends, either line by line or up to an
+// expected sequence of bytes.
+type tcpReader struct {
+	r *bufio.Reader
+	// read is the number of bytes read, and stepRead the number read in the
+	// current step, which fails beyond its limit unless it is 0.
+	read      int64
+	stepRead  int64
+	stepLimit int64
+}
+
+func newTCPReader(conn net.Conn) *tcpReader {
+	r := tcpReaderBuffers.Get().(*bufio.Reader)
+	r.Reset(conn)
+	return &tcpReader{r: r}
+}
+
+// reset reads from another connection, such as the one upgraded to TLS. The
+// data buffered from the previous connection is discarded.
+func (r *tcpReader) reset(conn net.Conn) {
+	r.r.Reset(conn)
+}
+
+// release returns the buffer of the reader to the pool, it cannot be used
+// afterwards.
+func (r *tcpReader) release() {
+	r.r.Reset(nil)
+	tcpReaderBuffers.Put(r.r)
+	r.r = nil
+}
+
+// startStep starts counting the bytes read by a step, which fails once it has
+// read more than limit bytes, unless it is 0.
+func (r *tcpReader) startStep(limit int64) {
+	r.stepRead = 0
+	r.stepLimit = limit
+}
+
+func (r *tcpReader) count(n int) error {
+	r.read += int64(n)
+	r.stepRead += int64(n)
+	if r.stepLimit > 0 && r.stepRead > r.stepLimit {
+		return errReadLimit
+	}
+	return nil
+}
+
+// readLine returns the next line without its line ending. The last line is
+// returned even if the connection is closed before its line ending.
+func (r *tcpReader) readLine() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err := r.count(len(line)); err != nil {
+		return nil, err
+	}
+	switch {
+	case err == bufio.ErrBufferFull:
+		return nil, errors.New("line too long")
+	case err == io.EOF && len(line) > 0:
+		err = nil
+	case err != nil:
+		return nil, err
+	}
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	// The buffer of ReadSlice is overwritten by the next read.
+	return append([]byte(nil), line...), err
+}
+
+// readLineOrPrompt returns the next line like readLine, unless the data
+// received since the last line ending matches the prompt first, in which
+// case it is returned with isPrompt set.
+func (r *tcpReader) readLineOrPrompt(prompt *regexp.Regexp) (line []byte, isPrompt bool, err error) {
+	for {
+		b, err := r.r.ReadByte()
+		if err == io.EOF && len(line) > 0 {
+			return bytes.TrimSuffix(line, []byte("\r")), false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if err := r.count(1); err != nil {
+			return nil, false, err
+		}
+		if b == '\n' {
+			return bytes.TrimSuffix(line, []byte("\r")), false, nil
+		}
+		line = append(line, b)
+		if prompt.Match(line) {
+			return line, true, nil
+		}
+		if len(line) >= bufio.MaxScanTokenSize {
+			return nil, false, errors.New("line too long")
+		}
+	}
+}
+
+// lineEnding returns the line ending appended to the lines sent.
+func lineEnding(setting string) string {
+	switch setting {
+	case "crlf":
+		return "\r\n"
+	case "none":
+		return ""
+	default:
+		return "\n"
+	}
+}
+
+// readUntil reads until the expected bytes have been received, whatever
+// comes before them.
+func (r *tcpReader) readUntil(expected []byte) error {
+	var window []byte
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if err := r.count(1); err != nil {
+			return err
+		}
+		window = append(window, b)
+		if bytes.HasSuffix(window, expected) {
+			return nil
+		}
+		// Only the end of the data received can be part of a match.
+		if len(window) >= bufio.MaxScanTokenSize+len(expected) {
+			window = append(window[:0], window[len(window)-len(expected)+1:]...)
+		}
+	}
+}
+
+// decodeBinaryPayload decodes the bytes of a step given in hex, ignoring
+// whitespace, or in base64.
+func decodeBinaryPayload(hexPayload, base64Payload string) ([]byte, error) {
+	if hexPayload != "" {
+		return hex.DecodeString(strings.Join(strings.Fields(hexPayload), ""))
+	}
+	return base64.StdEncoding.DecodeString(base64Payload)
+}
+
+// sendTemplateData is the data available to the send template of a step.
+type sendTemplateData struct {
+	// Groups are the capture groups of the expect regexp of the step, the
+	// first one being the whole match.
+	Groups []string
+	// Named are the named capture groups of the expect regexp.
+	Named map[string]string
+	// Lines are the lines received so far.
+	Lines []string
+}
+
+func newSendTemplateData(re config.Regexp, line string, match []int) sendTemplateData {
+	data := sendTemplateData{Named: map[string]string{}}
+	names := re.SubexpNames()
+	for i := 0; 2*i+1 < len(match); i++ {
+		var group string
+		if match[2*i] >= 0 {
+			group = line[match[2*i]:match[2*i+1]]
+		}
+		data.Groups = append(data.Groups, group)
+		if i < len(names) && names[i] != "" {
+			data.Named[names[i]] = group
+		}
+	}
+	return data
+}
+
+// appendReceived appends a line to the lines received, dropping the oldest
+// ones if they exceed tcpMaxReceivedBytes.
+func appendReceived(received []string, size *int, line string) []string {
+	received = append(received, line)
+	*size += len(line)
+	drop := 0
+	for *size > tcpMaxReceivedBytes && drop < len(received)-1 {
+		*size -= len(received[drop])
+		drop++
+	}
+	if drop > 0 {
+		received = append(received[:0], received[drop:]...)
+	}
+	return received
+}
+
+func usesSendTemplates(steps []config.QueryResponse) bool {
+	for _, qr := range steps {
+		if !qr.SendTemplate.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
 func ProbeTCP(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
-	probeSSLEarliestCertExpiry := prometheus.NewGauge(sslEarliestCertExpiryGaugeOpts)
-	probeSSLLastChainExpiryTimestampSeconds := prometheus.NewGauge(sslChainExpiryInTimeStampGaugeOpts)
-	probeSSLLastInformation := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "probe_ssl_last_chain_info",
-			Help: "Contains SSL leaf certificate information",
-		},
-		[]string{"fingerprint_sha256", "subject", "issuer", "subjectalternative", "serialnumber"},
-	)
-	probeTLSVersion := prometheus.NewGaugeVec(
-		probeTLSInfoGaugeOpts,
-		[]string{"version"},
-	)
 	probeFailedDueToRegex := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "probe_failed_due_to_regex",
 		Help: "Indicates if probe failed due to regex",
 	})
 	registry.MustRegister(probeFailedDueToRegex)
+	probeBytesRead := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_tcp_bytes_read",
+		Help: "The number of bytes read from the target",
+	})
 	deadline, _ := ctx.Deadline()
+	exportClientCertExpiry(module.TCP.TLSConfig, module.TCP.TLSSigner, registry, logger)
 
 	conn, err := dialTCP(ctx, target, module, registry, logger)
+	if module.TCP.ExpectFailure {
+		return expectTCPFailure(conn, err, module, registry, logger)
+	}
 	if err != nil {
 		logger.Error("Error dialing TCP", "err", err)
 		return false
@@ -143,52 +421,139 @@ func ProbeTCP(ctx context.Context, target string, module config.Module, registry
 	}
 	if module.TCP.TLS {
 		state := conn.(*tls.Conn).ConnectionState()
-		registry.MustRegister(probeSSLEarliestCertExpiry, probeTLSVersion, probeSSLLastChainExpiryTimestampSeconds, probeSSLLastInformation)
-		probeSSLEarliestCertExpiry.Set(float64(getEarliestCertExpiry(&state).Unix()))
-		probeTLSVersion.WithLabelValues(getTLSVersion(&state)).Set(1)
-		probeSSLLastChainExpiryTimestampSeconds.Set(float64(getLastChainExpiry(&state).Unix()))
-		probeSSLLastInformation.WithLabelValues(getFingerprint(&state), getSubject(&state), getIssuer(&state), getDNSNames(&state), getSerialNumber(&state)).Set(1)
+		exportTLSState(ctx, target, &state, registry)
+		if !validTLSExpectations(&state, module.TCP.ValidateTLS, registry, logger) {
+			return false
+		}
+	}
+	if module.TCP.Telnet {
+		conn = newTelnetConn(conn, logger)
 	}
-	scanner := bufio.NewScanner(conn)
+	reader := newTCPReader(conn)
+	defer reader.release()
+	registry.MustRegister(probeBytesRead)
+	defer func() {
+		probeBytesRead.Set(float64(reader.read))
+	}()
+	// The lines received are kept to evaluate the success criteria and the
+	// send templates.
+	var (
+		received     []string
+		receivedSize int
+	)
+	keepReceived := module.TCP.SuccessCriteria != nil || usesSendTemplates(module.TCP.QueryResponse)
 	for i, qr := range module.TCP.QueryResponse {
 		logger.Info("Processing query response entry", "entry_number", i)
+		if qr.Delay > 0 {
+			logger.Debug("Waiting before the step", "delay", qr.Delay)
+			select {
+			case <-time.After(qr.Delay):
+			case <-ctx.Done():
+				logger.Error("Probe timed out during the delay of the step")
+				return false
+			}
+		}
+		reader.startStep(int64(qr.ReadLimit))
 		send := qr.Send
+		data := sendTemplateData{Named: map[string]string{}}
+		// A prompt is expected like a line, except that it is matched before
+		// its line ending, if any, is received.
+		prompt := qr.ExpectPrompt.Regexp != nil
+		if prompt {
+			qr.Expect = qr.ExpectPrompt
+		}
 		if qr.Expect.Regexp != nil {
-			var match []int
+			var (
+				line     []byte
+				isPrompt bool
+				match    []int
+				err      error
+			)
 			// Read lines until one of them matches the configured regexp.
-			for scanner.Scan() {
-				logger.Debug("Read line", "line", scanner.Text())
-				match = qr.Expect.Regexp.FindSubmatchIndex(scanner.Bytes())
+			for {
+				if prompt {
+					line, isPrompt, err = reader.readLineOrPrompt(qr.Expect.Regexp)
+				} else {
+					line, err = reader.readLine()
+				}
+				if err != nil {
+					break
+				}
+				logger.Debug("Read line", "line", string(line))
+				if keepReceived {
+					received = appendReceived(received, &receivedSize, string(line))
+				}
+				if prompt && !isPrompt {
+					continue
+				}
+				match = qr.Expect.Regexp.FindSubmatchIndex(line)
 				if match != nil {
-					logger.Info("Regexp matched", "regexp", qr.Expect.Regexp, "line", scanner.Text())
+					logger.Info("Regexp matched", "regexp", qr.Expect.Regexp, "line", string(line))
 					break
 				}
 			}
-			if scanner.Err() != nil {
-				logger.Error("Error reading from connection", "err", scanner.Err().Error())
+			if err != nil && err != io.EOF {
+				logger.Error("Error reading from connection", "err", err.Error())
 				return false
 			}
 			if match == nil {
 				probeFailedDueToRegex.Set(1)
-				logger.Error("Regexp did not match", "regexp", qr.Expect.Regexp, "line", scanner.Text())
+				logger.Error("Regexp did not match", "regexp", qr.Expect.Regexp)
 				return false
 			}
 			probeFailedDueToRegex.Set(0)
-			send = string(qr.Expect.Regexp.Expand(nil, []byte(send), scanner.Bytes(), match))
+			send = string(qr.Expect.Regexp.Expand(nil, []byte(send), line, match))
 			if qr.Labels != nil {
-				probeExpectInfo(registry, &qr, scanner.Bytes(), match)
+				probeExpectInfo(registry, &qr, line, match)
+			}
+			data = newSendTemplateData(qr.Expect, string(line), match)
+		}
+		if qr.ExpectHex != "" || qr.ExpectBase64 != "" {
+			expected, err := decodeBinaryPayload(qr.ExpectHex, qr.ExpectBase64)
+			if err != nil {
+				logger.Error("Error decoding expected bytes", "err", err)
+				return false
+			}
+			if err := reader.readUntil(expected); err != nil {
+				probeFailedDueToRegex.Set(1)
+				logger.Error("Expected bytes were not received", "expected", hex.EncodeToString(expected), "err", err)
+				return false
+			}
+			probeFailedDueToRegex.Set(0)
+			logger.Info("Expected bytes received", "expected", hex.EncodeToString(expected))
+		}
+		if qr.SendHex != "" || qr.SendBase64 != "" {
+			payload, err := decodeBinaryPayload(qr.SendHex, qr.SendBase64)
+			if err != nil {
+				logger.Error("Error decoding bytes to send", "err", err)
+				return false
+			}
+			// Binary payloads are sent as is, without a line ending.
+			logger.Debug("Sending bytes", "bytes", hex.EncodeToString(payload))
+			if _, err := conn.Write(payload); err != nil {
+				logger.Error("Failed to send", "err", err)
+				return false
+			}
+		}
+		if !qr.SendTemplate.IsZero() {
+			data.Lines = received
+			var err error
+			send, err = qr.SendTemplate.ExecuteString(data)
+			if err != nil {
+				logger.Error("Error executing send template", "err", err)
+				return false
 			}
 		}
 		if send != "" {
 			logger.Debug("Sending line", "line", send)
-			if _, err := fmt.Fprintf(conn, "%s\n", send); err != nil {
+			if _, err := fmt.Fprintf(conn, "%s%s", send, lineEnding(module.TCP.LineEnding)); err != nil {
 				logger.Error("Failed to send", "err", err)
 				return false
 			}
 		}
 		if qr.StartTLS {
 			// Upgrade TCP connection to TLS.
-			tlsConfig, err := pconfig.NewTLSConfig(&module.TCP.TLSConfig)
+			tlsConfig, err := newTCPTLSConfig(ctx, module.TCP)
 			if err != nil {
 				logger.Error("Failed to create TLS configuration", "err", err)
 				return false
@@ -208,15 +573,29 @@ func ProbeTCP(ctx context.Context, target string, module config.Module, registry
 			}
 			logger.Info("TLS Handshake (client) succeeded.")
 			conn = net.Conn(tlsConn)
-			scanner = bufio.NewScanner(conn)
+			reader.reset(conn)
 
 			// Get certificate expiry.
 			state := tlsConn.ConnectionState()
-			registry.MustRegister(probeSSLEarliestCertExpiry, probeTLSVersion, probeSSLLastChainExpiryTimestampSeconds, probeSSLLastInformation)
-			probeSSLEarliestCertExpiry.Set(float64(getEarliestCertExpiry(&state).Unix()))
-			probeTLSVersion.WithLabelValues(getTLSVersion(&state)).Set(1)
-			probeSSLLastChainExpiryTimestampSeconds.Set(float64(getLastChainExpiry(&state).Unix()))
-			probeSSLLastInformation.WithLabelValues(getFingerprint(&state), getSubject(&state), getIssuer(&state), getDNSNames(&state), getSerialNumber(&state)).Set(1)
+			exportTLSState(ctx, target, &state, registry)
+			if !validTLSExpectations(&state, module.TCP.ValidateTLS, registry, logger) {
+				return false
+			}
+		}
+	}
+	if module.TCP.SuccessCriteria != nil {
+		// Without a dialog, the criteria are evaluated on the banner.
+		if len(module.TCP.QueryResponse) == 0 {
+			if line, err := reader.readLine(); err == nil {
+				logger.Debug("Read line", "line", string(line))
+				received = append(received, string(line))
+			}
+		}
+		if !evaluateCriterion(module.TCP.SuccessCriteria, func(c *config.SuccessCriterion) bool {
+			return tcpConditionsHold(c, received)
+		}) {
+			logger.Error("Success criteria did not hold", "lines", len(received))
+			return false
 		}
 	}
 	return true
@@ -14,6 +14,7 @@
 package prober
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
@@ -22,12 +23,16 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/prometheus/client_golang/prometheus"
 	pconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/promslog"
@@ -70,6 +75,65 @@ func TestTCPConnectionFails(t *testing.T) {
 	}
 }
 
+func TestTCPConnectionExpectFailure(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// Reserve a port and close it again to get a port that refuses connections.
+	closed, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		target         string
+		probe          config.TCPProbe
+		expectedResult bool
+		expectedReason string
+	}{
+		{target: ln.Addr().String(), probe: config.TCPProbe{ExpectFailure: true}, expectedResult: false},
+		{target: closedAddr, probe: config.TCPProbe{ExpectFailure: true}, expectedResult: true, expectedReason: "refused"},
+		{target: closedAddr, probe: config.TCPProbe{ExpectFailure: true, ExpectRefused: true}, expectedResult: true, expectedReason: "refused"},
+		// Resolution errors do not prove that the target is unreachable.
+		{target: "nonexistent.invalid:80", probe: config.TCPProbe{ExpectFailure: true}, expectedResult: false},
+	}
+	for i, test := range tests {
+		test.probe.IPProtocol = "ip4"
+		test.probe.IPProtocolFallback = true
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if result := ProbeTCP(testCTX, test.target, config.Module{TCP: test.probe}, registry, promslog.NewNopLogger()); result != test.expectedResult {
+			t.Fatalf("Test %d: expected result %t, got %t", i, test.expectedResult, result)
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.expectedReason == "" {
+			checkAbsentMetrics([]string{"probe_tcp_connection_failure"}, mfs, t)
+			continue
+		}
+		checkRegistryLabels(map[string]map[string]string{
+			"probe_tcp_connection_failure": {"reason": test.expectedReason},
+		}, mfs, t)
+	}
+}
+
 func TestTCPConnectionWithTLS(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip("skipping; CI is failing on ipv6 dns requests")
@@ -200,6 +264,71 @@ func TestTCPConnectionWithTLS(t *testing.T) {
 	checkRegistryResults(expectedResults, mfs, t)
 }
 
+func TestTCPConnectionValidateTLS(t *testing.T) {
+	_, certPEM, key := generateSelfSignedCertificate(generateCertificateTemplate(time.Now().AddDate(0, 0, 1), true))
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates:     []tls.Certificate{cert},
+		NextProtos:       []string{"h2"},
+		CurvePreferences: []tls.CurveID{tls.X25519},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		expectations config.TLSExpectations
+		success      bool
+	}{
+		{config.TLSExpectations{ALPN: []string{"h2"}, Curves: []string{"X25519"}, KeyTypes: []string{"RSA"}, MinRSAKeyBits: 2048}, true},
+		{config.TLSExpectations{ALPN: []string{"imap"}}, false},
+		{config.TLSExpectations{Curves: []string{"P-384"}}, false},
+		{config.TLSExpectations{KeyTypes: []string{"ECDSA", "Ed25519"}}, false},
+		{config.TLSExpectations{MinRSAKeyBits: 3072}, false},
+	}
+	for i, test := range tests {
+		module := config.Module{
+			TCP: config.TCPProbe{
+				IPProtocol:  "ip4",
+				TLS:         true,
+				TLSConfig:   pconfig.TLSConfig{InsecureSkipVerify: true},
+				ValidateTLS: test.expectations,
+			},
+		}
+		registry := prometheus.NewRegistry()
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if result := ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()); result != test.success {
+			t.Fatalf("Test %d had unexpected result: %t", i, result)
+		}
+		if !test.success {
+			continue
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryLabels(map[string]map[string]string{
+			"probe_tls_alpn_info":            {"protocol": "h2"},
+			"probe_ssl_certificate_key_info": {"type": "RSA", "bits": "2048"},
+		}, mfs, t)
+	}
+}
+
 func TestTCPConnectionWithTLSAndVerifiedCertificateChain(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip("skipping; CI is failing on ipv6 dns requests")
@@ -580,6 +709,272 @@ func TestTCPConnectionQueryResponseMatching(t *testing.T) {
 
 }
 
+func TestTCPConnectionQueryResponseSendTemplate(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{
+		TCP: config.TCPProbe{
+			IPProtocolFallback: true,
+			QueryResponse: []config.QueryResponse{
+				{Expect: config.MustNewRegexp("^HELLO$")},
+				{
+					// Answer the challenge with its length, checksum and
+					// base64 encoding.
+					Expect:       config.MustNewRegexp("^CHALLENGE (?P<nonce>[a-z]+)$"),
+					SendTemplate: config.MustNewTemplate(`{{ $n := .Named.nonce }}AUTH {{ len $n }} {{ sum8 $n }} {{ base64 $n }} {{ len .Lines }}`),
+				},
+				{Expect: config.MustNewRegexp("^OK$")},
+			},
+		},
+	}
+
+	ch := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			panic(fmt.Sprintf("Error accepting on socket: %s", err))
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(1 * time.Second))
+		fmt.Fprintf(conn, "HELLO\nCHALLENGE abc\n")
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprintf(conn, "OK\n")
+		ch <- line
+	}()
+	registry := prometheus.NewRegistry()
+	if !ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("TCP module failed, expected success.")
+	}
+	// "a" + "b" + "c" is 294, 38 modulo 256.
+	if got, want := <-ch, "AUTH 3 38 YWJj 2\n"; got != want {
+		t.Fatalf("Read unexpected response: got %q, want %q", got, want)
+	}
+}
+
+func TestTCPConnectionQueryResponseBinary(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{
+		TCP: config.TCPProbe{
+			IPProtocolFallback: true,
+			QueryResponse: []config.QueryResponse{
+				{ExpectHex: "ca fe ff 00", SendHex: "de ad be ef 0a 0d"},
+				// "\x00OK\xfe" in base64.
+				{ExpectBase64: "AE9L/g==", SendBase64: "gA=="},
+			},
+		},
+	}
+
+	ch := make(chan []byte)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			panic(fmt.Sprintf("Error accepting on socket: %s", err))
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(1 * time.Second))
+		// The expected bytes are preceded by non UTF-8 data.
+		conn.Write([]byte("\xff\xfe\ncafe\xca\xfe\xff\x00"))
+		received := make([]byte, 6)
+		if _, err := io.ReadFull(conn, received); err != nil {
+			ch <- nil
+			return
+		}
+		conn.Write([]byte("\x00OK\xfe"))
+		last := make([]byte, 1)
+		if _, err := io.ReadFull(conn, last); err != nil {
+			ch <- nil
+			return
+		}
+		ch <- append(received, last...)
+	}()
+	registry := prometheus.NewRegistry()
+	if !ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("TCP module failed, expected success.")
+	}
+	if got, want := <-ch, []byte("\xde\xad\xbe\xef\n\r\x80"); !bytes.Equal(got, want) {
+		t.Fatalf("Read unexpected bytes: got %q, want %q", got, want)
+	}
+}
+
+func TestTCPConnectionQueryResponseBinaryMismatch(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{
+		TCP: config.TCPProbe{
+			IPProtocolFallback: true,
+			QueryResponse:      []config.QueryResponse{{ExpectHex: "cafe"}},
+		},
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			panic(fmt.Sprintf("Error accepting on socket: %s", err))
+		}
+		conn.Write([]byte("\xca\xfa"))
+		conn.Close()
+	}()
+	registry := prometheus.NewRegistry()
+	if ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("TCP module succeeded, expected failure.")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{"probe_failed_due_to_regex": 1}, mfs, t)
+}
+
+func TestTCPConnectionQueryResponsePrompt(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{
+		TCP: config.TCPProbe{
+			IPProtocolFallback: true,
+			LineEnding:         "crlf",
+			QueryResponse: []config.QueryResponse{
+				{ExpectPrompt: config.MustNewRegexp("^Username: $"), Send: "admin"},
+				{ExpectPrompt: config.MustNewRegexp("^Password: $"), Send: "secret", Delay: 10 * time.Millisecond},
+				{ExpectPrompt: config.MustNewRegexp(`^(\w+)>$`), Send: "exit ${1}"},
+			},
+		},
+	}
+
+	ch := make(chan []string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			panic(fmt.Sprintf("Error accepting on socket: %s", err))
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(1 * time.Second))
+		r := bufio.NewReader(conn)
+		var lines []string
+		// Prompts are not followed by a line ending.
+		for _, prompt := range []string{"Welcome\r\nUsername: ", "Password: ", "router>"} {
+			fmt.Fprint(conn, prompt)
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+		}
+		ch <- lines
+	}()
+	registry := prometheus.NewRegistry()
+	if !ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("TCP module failed, expected success.")
+	}
+	if got, want := <-ch, []string{"admin\r\n", "secret\r\n", "exit router\r\n"}; !slices.Equal(got, want) {
+		t.Fatalf("Read unexpected lines: got %q, want %q", got, want)
+	}
+}
+
+func TestTCPConnectionQueryResponseReadLimit(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	// The target sends 10 lines of 10 bytes before the one expected.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			for i := 0; i < 10; i++ {
+				fmt.Fprintf(conn, "log %05d\n", i)
+			}
+			fmt.Fprintf(conn, "READY\n")
+			conn.Close()
+		}
+	}()
+
+	for _, test := range []struct {
+		readLimit units.Base2Bytes
+		success   bool
+		bytesRead float64
+	}{
+		{readLimit: 0, success: true, bytesRead: 106},
+		{readLimit: 200, success: true, bytesRead: 106},
+		{readLimit: 50, success: false, bytesRead: 60},
+	} {
+		testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		module := config.Module{
+			TCP: config.TCPProbe{
+				IPProtocolFallback: true,
+				QueryResponse: []config.QueryResponse{
+					{Expect: config.MustNewRegexp("^READY$"), ReadLimit: test.readLimit},
+				},
+			},
+		}
+		registry := prometheus.NewRegistry()
+		if success := ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()); success != test.success {
+			t.Fatalf("Expected success %v with a read limit of %d, got %v", test.success, test.readLimit, success)
+		}
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRegistryResults(map[string]float64{"probe_tcp_bytes_read": test.bytesRead}, mfs, t)
+	}
+}
+
+func TestAppendReceived(t *testing.T) {
+	var (
+		received []string
+		size     int
+	)
+	line := strings.Repeat("x", tcpMaxReceivedBytes/4)
+	for i := 0; i < 6; i++ {
+		received = appendReceived(received, &size, line)
+	}
+	if len(received) != 4 || size != tcpMaxReceivedBytes {
+		t.Errorf("Expected the last 4 lines of %d bytes to be kept, got %d lines of %d bytes", len(line), len(received), size)
+	}
+	// The last line is kept even if it is too long.
+	received = appendReceived(received, &size, line+line+line+line+"x")
+	if len(received) != 1 {
+		t.Errorf("Expected only the last line to be kept, got %d lines", len(received))
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	for setting, want := range map[string]string{"": "\n", "lf": "\n", "crlf": "\r\n", "none": ""} {
+		if got := lineEnding(setting); got != want {
+			t.Errorf("Unexpected line ending for %q: got %q, want %q", setting, got, want)
+		}
+	}
+}
+
 func TestTCPConnectionProtocol(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip("skipping; CI is failing on ipv6 dns requests")
@@ -0,0 +1,147 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+)
+
+// Telnet commands and options, see RFC 854 and RFC 855.
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptionEcho            = 1
+	telnetOptionSuppressGoAhead = 3
+)
+
+// States of the parser of the data received.
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSubnegotiation
+	telnetStateSubnegotiationIAC
+)
+
+// telnetConn handles the option negotiation of a Telnet server, so that the
+// steps of the probe only see the data. The server may echo and suppress
+// go-ahead, all other options are refused.
+type telnetConn struct {
+	net.Conn
+	logger  *slog.Logger
+	state   int
+	command byte
+	buf     []byte
+}
+
+func newTelnetConn(conn net.Conn, logger *slog.Logger) *telnetConn {
+	return &telnetConn{Conn: conn, logger: logger}
+}
+
+// Read returns the data received, without the Telnet commands, and answers
+// the option negotiation.
+func (c *telnetConn) Read(p []byte) (int, error) {
+	if len(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	for {
+		n, err := c.Conn.Read(c.buf[:len(p)])
+		out := 0
+		var replies []byte
+		for _, b := range c.buf[:n] {
+			switch c.state {
+			case telnetStateData:
+				if b == telnetIAC {
+					c.state = telnetStateIAC
+					continue
+				}
+				p[out] = b
+				out++
+			case telnetStateIAC:
+				switch b {
+				case telnetIAC:
+					// An escaped 255 data byte.
+					p[out] = b
+					out++
+					c.state = telnetStateData
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					c.command = b
+					c.state = telnetStateOption
+				case telnetSB:
+					c.state = telnetStateSubnegotiation
+				default:
+					// Commands without option, e.g. go-ahead.
+					c.state = telnetStateData
+				}
+			case telnetStateOption:
+				replies = append(replies, c.reply(c.command, b)...)
+				c.state = telnetStateData
+			case telnetStateSubnegotiation:
+				if b == telnetIAC {
+					c.state = telnetStateSubnegotiationIAC
+				}
+			case telnetStateSubnegotiationIAC:
+				if b == telnetSE {
+					c.state = telnetStateData
+				} else {
+					c.state = telnetStateSubnegotiation
+				}
+			}
+		}
+		if len(replies) > 0 {
+			if _, werr := c.Conn.Write(replies); werr != nil {
+				return out, werr
+			}
+		}
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+// reply returns the answer to an option negotiation command.
+func (c *telnetConn) reply(command, option byte) []byte {
+	c.logger.Debug("Received Telnet option negotiation", "command", command, "option", option)
+	switch command {
+	case telnetWILL:
+		if option == telnetOptionEcho || option == telnetOptionSuppressGoAhead {
+			return []byte{telnetIAC, telnetDO, option}
+		}
+		return []byte{telnetIAC, telnetDONT, option}
+	case telnetDO:
+		return []byte{telnetIAC, telnetWONT, option}
+	}
+	// WONT and DONT are acknowledgements, or refusals that need no answer.
+	return nil
+}
+
+// Write escapes the 255 data bytes, which would otherwise start a command.
+func (c *telnetConn) Write(p []byte) (int, error) {
+	if bytes.IndexByte(p, telnetIAC) == -1 {
+		return c.Conn.Write(p)
+	}
+	escaped := bytes.ReplaceAll(p, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})
+	if _, err := c.Conn.Write(escaped); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestTCPConnectionTelnet(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	defer ln.Close()
+
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	module := config.Module{
+		TCP: config.TCPProbe{
+			IPProtocolFallback: true,
+			Telnet:             true,
+			QueryResponse: []config.QueryResponse{
+				{ExpectPrompt: config.MustNewRegexp("^Login: $"), Send: "admin"},
+				{Expect: config.MustNewRegexp("^Welcome admin$")},
+			},
+		},
+	}
+
+	negotiation := []byte{
+		telnetIAC, telnetDO, 24, // Terminal type.
+		telnetIAC, telnetWILL, telnetOptionEcho,
+		telnetIAC, telnetWILL, 5, // Status.
+		telnetIAC, telnetSB, 24, 1, telnetIAC, telnetSE,
+	}
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		conn, err := ln.Accept()
+		if err != nil {
+			panic(fmt.Sprintf("Error accepting on socket: %s", err))
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(1 * time.Second))
+		conn.Write(append(negotiation, "Login: "...))
+		replies := make([]byte, 9)
+		if _, err := io.ReadFull(conn, replies); err != nil {
+			return
+		}
+		want := []byte{
+			telnetIAC, telnetWONT, 24,
+			telnetIAC, telnetDO, telnetOptionEcho,
+			telnetIAC, telnetDONT, 5,
+		}
+		if !bytes.Equal(replies, want) {
+			ch <- fmt.Sprintf("unexpected replies %v", replies)
+			return
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		conn.Write([]byte{telnetIAC, telnetWILL, telnetOptionSuppressGoAhead})
+		fmt.Fprintf(conn, "Welcome %s", line)
+		ch <- line
+	}()
+	registry := prometheus.NewRegistry()
+	if !ProbeTCP(testCTX, ln.Addr().String(), module, registry, promslog.NewNopLogger()) {
+		t.Fatalf("TCP module failed, expected success.")
+	}
+	if got := <-ch; got != "admin\n" {
+		t.Fatalf("Read unexpected line: %q", got)
+	}
+}
+
+func TestTelnetWriteEscapesIAC(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	conn := newTelnetConn(client, promslog.NewNopLogger())
+	go func() {
+		if n, err := conn.Write([]byte{1, telnetIAC, 2}); err != nil || n != 3 {
+			t.Errorf("Unexpected write result: %d, %v", n, err)
+		}
+	}()
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(server, got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, telnetIAC, telnetIAC, 2}; !bytes.Equal(got, want) {
+		t.Fatalf("Unexpected data written: got %v, want %v", got, want)
+	}
+}
@@ -14,14 +14,100 @@
 package prober
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"strings"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/blackbox_exporter/config"
 )
 
+// exportTLSState exports the metrics of the TLS connection of a probe, the
+// same for all the probers connecting over TLS: the expiry of the
+// certificates, the leaf certificate, whether it changed since the previous
+// probe of target, and the TLS version.
+func exportTLSState(ctx context.Context, target string, state *tls.ConnectionState, registry *prometheus.Registry) {
+	probeTLSVersion := prometheus.NewGaugeVec(probeTLSInfoGaugeOpts, []string{"version"})
+	registry.MustRegister(probeTLSVersion)
+	probeTLSVersion.WithLabelValues(getTLSVersion(state)).Set(1)
+	if len(state.PeerCertificates) == 0 {
+		return
+	}
+
+	probeSSLEarliestCertExpiry := prometheus.NewGauge(sslEarliestCertExpiryGaugeOpts)
+	probeSSLLastChainExpiryTimestampSeconds := prometheus.NewGauge(sslChainExpiryInTimeStampGaugeOpts)
+	probeSSLLastInformation := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "probe_ssl_last_chain_info",
+			Help: "Contains SSL leaf certificate information",
+		},
+		[]string{"fingerprint_sha256", "subject", "issuer", "subjectalternative", "serialnumber"},
+	)
+	registry.MustRegister(probeSSLEarliestCertExpiry, probeSSLLastChainExpiryTimestampSeconds, probeSSLLastInformation)
+	probeSSLEarliestCertExpiry.Set(float64(getEarliestCertExpiry(state).Unix()))
+	probeSSLLastChainExpiryTimestampSeconds.Set(float64(getLastChainExpiry(state).Unix()))
+	probeSSLLastInformation.WithLabelValues(getFingerprint(state), getSubject(state), getIssuer(state), getDNSNames(state), getSerialNumber(state)).Set(1)
+	exportCertChanged(ctx, target, getSerialNumber(state), registry)
+}
+
+// exportClientCertExpiry exports the expiry of the client certificate the
+// probe authenticates with, from tls_config or tls_signer, so that the
+// credentials of the probe itself can be alerted on before they expire.
+func exportClientCertExpiry(tlsConfig pconfig.TLSConfig, signer config.ExternalSigner, registry *prometheus.Registry, logger *slog.Logger) {
+	var (
+		pemBytes []byte
+		err      error
+	)
+	switch {
+	case tlsConfig.Cert != "":
+		pemBytes = []byte(tlsConfig.Cert)
+	case tlsConfig.CertFile != "":
+		pemBytes, err = os.ReadFile(tlsConfig.CertFile)
+	case signer.Enabled():
+		pemBytes, err = os.ReadFile(signer.CertificateFile)
+	default:
+		return
+	}
+	if err == nil {
+		var cert *x509.Certificate
+		if cert, err = parseClientCert(pemBytes); err == nil {
+			probeTLSClientCertExpiry := prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "probe_tls_client_cert_expiry_seconds",
+				Help: "Returns the expiry of the client certificate of the probe in unixtime",
+			})
+			registry.MustRegister(probeTLSClientCertExpiry)
+			probeTLSClientCertExpiry.Set(float64(cert.NotAfter.Unix()))
+			return
+		}
+	}
+	logger.Info("Error reading client certificate", "err", err)
+}
+
+// parseClientCert returns the first certificate of a PEM encoded chain, the
+// one of the client.
+func parseClientCert(pemBytes []byte) (*x509.Certificate, error) {
+	for block, rest := pem.Decode(pemBytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+	return nil, errors.New("no certificate found")
+}
+
 func getEarliestCertExpiry(state *tls.ConnectionState) time.Time {
 	earliest := time.Time{}
 	for _, cert := range state.PeerCertificates {
@@ -96,3 +182,18 @@ func getTLSVersion(state *tls.ConnectionState) string {
 func getTLSCipher(state *tls.ConnectionState) string {
 	return tls.CipherSuiteName(state.CipherSuite)
 }
+
+// getCertificateKey returns the type and the size in bits of the key of the
+// leaf certificate.
+func getCertificateKey(state *tls.ConnectionState) (string, int) {
+	switch key := state.PeerCertificates[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return "unknown", 0
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// applyTLSExpectations restricts the protocols and curves offered in the
+// handshake to the expected ones.
+func applyTLSExpectations(tlsConfig *tls.Config, e config.TLSExpectations) {
+	if len(e.ALPN) > 0 {
+		tlsConfig.NextProtos = e.ALPN
+	}
+	for _, curve := range e.Curves {
+		tlsConfig.CurvePreferences = append(tlsConfig.CurvePreferences, config.TLSCurves[curve])
+	}
+}
+
+// validTLSExpectations exports the protocol negotiated with ALPN and the key
+// of the certificate, and checks them against the expectations.
+func validTLSExpectations(state *tls.ConnectionState, e config.TLSExpectations, registry *prometheus.Registry, logger *slog.Logger) bool {
+	probeTLSALPNInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_tls_alpn_info",
+		Help: "Contains the protocol negotiated with ALPN",
+	}, []string{"protocol"})
+	probeSSLCertificateKeyInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_ssl_certificate_key_info",
+		Help: "Contains the type and the size of the key of the leaf certificate",
+	}, []string{"type", "bits"})
+	registry.MustRegister(probeTLSALPNInfo, probeSSLCertificateKeyInfo)
+
+	valid := true
+	if state.NegotiatedProtocol != "" {
+		probeTLSALPNInfo.WithLabelValues(state.NegotiatedProtocol).Set(1)
+	}
+	if len(e.ALPN) > 0 && !slices.Contains(e.ALPN, state.NegotiatedProtocol) {
+		logger.Error("Server did not select an expected protocol with ALPN", "protocol", state.NegotiatedProtocol, "expected", e.ALPN)
+		valid = false
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		return valid
+	}
+	keyType, bits := getCertificateKey(state)
+	probeSSLCertificateKeyInfo.WithLabelValues(keyType, strconv.Itoa(bits)).Set(1)
+	if len(e.KeyTypes) > 0 && !slices.Contains(e.KeyTypes, keyType) {
+		logger.Error("Key type of the certificate is not accepted", "type", keyType, "accepted", e.KeyTypes)
+		valid = false
+	}
+	if (keyType == "RSA" && bits < e.MinRSAKeyBits) || (keyType == "ECDSA" && bits < e.MinECDSAKeyBits) {
+		logger.Error("Key of the certificate is too small", "type", keyType, "bits", bits)
+		valid = false
+	}
+	return valid
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestExportTLSState(t *testing.T) {
+	rootExpiry := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+	leafExpiry := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	rootTemplate := generateCertificateTemplate(rootExpiry, false)
+	rootTemplate.IsCA = true
+	rootTemplate.KeyUsage |= x509.KeyUsageCertSign
+	rootCert, _, rootKey := generateSelfSignedCertificate(rootTemplate)
+	leafCert, _, _ := generateSignedCertificate(generateCertificateTemplate(leafExpiry, false), rootCert, rootKey)
+
+	state := &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		PeerCertificates: []*x509.Certificate{leafCert, rootCert},
+		VerifiedChains:   [][]*x509.Certificate{{leafCert, rootCert}},
+	}
+	registry := prometheus.NewRegistry()
+	exportTLSState(context.Background(), "tls.example.com:443", state, registry)
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRegistryResults(map[string]float64{
+		"probe_ssl_earliest_cert_expiry":                float64(leafExpiry.Unix()),
+		"probe_ssl_last_chain_expiry_timestamp_seconds": float64(leafExpiry.Unix()),
+		"probe_ssl_last_chain_info":                     1,
+		"probe_tls_version_info":                        1,
+	}, mfs, t)
+	checkRegistryLabels(map[string]map[string]string{
+		"probe_ssl_last_chain_info": {
+			"subject":            leafCert.Subject.String(),
+			"issuer":             leafCert.Issuer.String(),
+			"serialnumber":       getSerialNumber(state),
+			"fingerprint_sha256": getFingerprint(state),
+		},
+		"probe_tls_version_info": {"version": "TLS 1.3"},
+	}, mfs, t)
+
+	// Without peer certificates, only the version is exported.
+	registry = prometheus.NewRegistry()
+	exportTLSState(context.Background(), "tls.example.com:443", &tls.ConnectionState{Version: tls.VersionTLS12}, registry)
+	mfs, err = registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mfs) != 1 || mfs[0].GetName() != "probe_tls_version_info" {
+		t.Errorf("Expected only probe_tls_version_info without certificates, got %v", mfs)
+	}
+}
+
+func TestExportClientCertExpiry(t *testing.T) {
+	expiry := time.Now().Add(14 * 24 * time.Hour).Truncate(time.Second)
+	_, pemCert, _ := generateSelfSignedCertificate(generateCertificateTemplate(expiry, false))
+	certFile := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(certFile, pemCert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		tlsConfig pconfig.TLSConfig
+		signer    config.ExternalSigner
+		exported  bool
+	}{
+		"none":      {},
+		"inline":    {tlsConfig: pconfig.TLSConfig{Cert: string(pemCert)}, exported: true},
+		"file":      {tlsConfig: pconfig.TLSConfig{CertFile: certFile}, exported: true},
+		"signer":    {signer: config.ExternalSigner{Command: []string{"sign"}, CertificateFile: certFile}, exported: true},
+		"not a pem": {tlsConfig: pconfig.TLSConfig{Cert: "not a certificate"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			exportClientCertExpiry(test.tlsConfig, test.signer, registry, promslog.NewNopLogger())
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.exported {
+				checkRegistryResults(map[string]float64{"probe_tls_client_cert_expiry_seconds": float64(expiry.Unix())}, mfs, t)
+			} else {
+				checkAbsentMetrics([]string{"probe_tls_client_cert_expiry_seconds"}, mfs, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// tracerouteData is the payload of the probes, so that they can be told
+// apart in captures.
+var tracerouteData = []byte("blackbox_exporter traceroute")
+
+// tracerouteHop is the answer of a hop of the path to the target.
+type tracerouteHop struct {
+	address net.Addr
+	rtt     time.Duration
+}
+
+// parseTracerouteReply returns the key of the probe an ICMP message answers,
+// the sequence number of an echo request or the destination port of a UDP
+// datagram, and whether the message is an echo reply or a destination
+// unreachable one rather than a time exceeded one.
+func parseTracerouteReply(msg []byte, v6, udp bool, dst net.IP) (key int, final, ok bool) {
+	proto, replyType, requestType := 1, icmp.Type(ipv4.ICMPTypeEchoReply), icmp.Type(ipv4.ICMPTypeEcho)
+	if v6 {
+		proto, replyType, requestType = 58, ipv6.ICMPTypeEchoReply, ipv6.ICMPTypeEchoRequest
+	}
+	m, err := icmp.ParseMessage(proto, msg)
+	if err != nil {
+		return 0, false, false
+	}
+	var data []byte
+	switch body := m.Body.(type) {
+	case *icmp.Echo:
+		if udp || m.Type != replyType || body.ID != icmpID {
+			return 0, false, false
+		}
+		return body.Seq, true, true
+	case *icmp.TimeExceeded:
+		data = body.Data
+	case *icmp.DstUnreach:
+		data, final = body.Data, true
+	default:
+		return 0, false, false
+	}
+
+	// The message quotes the IP header of the probe, followed by the start
+	// of its payload.
+	var quotedProto int
+	var quotedDst net.IP
+	if v6 {
+		if len(data) < ipv6.HeaderLen {
+			return 0, false, false
+		}
+		quotedProto, quotedDst, data = int(data[6]), net.IP(data[24:40]), data[ipv6.HeaderLen:]
+	} else {
+		if len(data) < ipv4.HeaderLen {
+			return 0, false, false
+		}
+		headerLen := int(data[0]&0x0f) * 4
+		if headerLen < ipv4.HeaderLen || len(data) < headerLen {
+			return 0, false, false
+		}
+		quotedProto, quotedDst, data = int(data[9]), net.IP(data[16:20]), data[headerLen:]
+	}
+	if !quotedDst.Equal(dst) || len(data) < 8 {
+		return 0, false, false
+	}
+	if udp {
+		if quotedProto != 17 {
+			return 0, false, false
+		}
+		return int(binary.BigEndian.Uint16(data[2:4])), final, true
+	}
+	if quotedProto != proto || icmp.Type(ipv4.ICMPType(data[0])) != requestType && icmp.Type(ipv6.ICMPType(data[0])) != requestType {
+		return 0, false, false
+	}
+	if int(binary.BigEndian.Uint16(data[4:6])) != icmpID {
+		return 0, false, false
+	}
+	return int(binary.BigEndian.Uint16(data[6:8])), final, true
+}
+
+// ProbeTraceroute sends a probe for each TTL up to the maximum number of
+// hops at once, and collects the ICMP messages the hops send back until the
+// target and all the hops before it answered, or until the timeout.
+func ProbeTraceroute(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		hopsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_traceroute_hops",
+			Help: "Number of hops to the target, or to the last hop that answered if the target was not reached",
+		})
+		reachedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_traceroute_reached",
+			Help: "Indicates if the target answered",
+		})
+		hopRTTGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_traceroute_hop_rtt_seconds",
+			Help: "Round trip time of the probe of each hop that answered",
+		}, []string{"hop", "address"})
+	)
+	registry.MustRegister(hopsGauge, reachedGauge)
+
+	c := module.Traceroute
+	// Modules without traceroute settings use the defaults.
+	maxHops, port := c.MaxHops, c.Port
+	if maxHops == 0 {
+		maxHops = config.DefaultTracerouteProbe.MaxHops
+	}
+	if port == 0 {
+		port = config.DefaultTracerouteProbe.Port
+	}
+	udp := c.Protocol == "udp"
+
+	dstIPAddr, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, target, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+	v6 := dstIPAddr.IP.To4() == nil
+
+	listenAddr := "0.0.0.0"
+	if v6 {
+		listenAddr = "::"
+	}
+	if c.SourceIPAddress != "" {
+		srcIP := net.ParseIP(c.SourceIPAddress)
+		if srcIP == nil {
+			logger.Error("Error parsing source ip address", "srcIP", c.SourceIPAddress)
+			return false
+		}
+		listenAddr = srcIP.String()
+	}
+
+	// The ICMP messages of the hops are only received on raw sockets.
+	network := "ip4:icmp"
+	if v6 {
+		network = "ip6:ipv6-icmp"
+	}
+	conn, err := icmp.ListenPacket(network, listenAddr)
+	if err != nil {
+		logger.Error("Error listening to socket, traceroutes need the privileges to open raw sockets", "err", err)
+		return false
+	}
+	defer conn.Close()
+
+	var (
+		udpConn net.PacketConn
+		setTTL  func(int) error
+	)
+	if udp {
+		udpNetwork := "udp4"
+		if v6 {
+			udpNetwork = "udp6"
+		}
+		udpConn, err = net.ListenPacket(udpNetwork, net.JoinHostPort(listenAddr, "0"))
+		if err != nil {
+			logger.Error("Error listening to UDP socket", "err", err)
+			return false
+		}
+		defer udpConn.Close()
+		if v6 {
+			setTTL = ipv6.NewPacketConn(udpConn).SetHopLimit
+		} else {
+			setTTL = ipv4.NewPacketConn(udpConn).SetTTL
+		}
+	} else if v6 {
+		setTTL = conn.IPv6PacketConn().SetHopLimit
+	} else {
+		setTTL = conn.IPv4PacketConn().SetTTL
+	}
+
+	echoType := icmp.Type(ipv4.ICMPTypeEcho)
+	if v6 {
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+	// The probes are told apart by their key, the TTL is not quoted by the
+	// time exceeded messages.
+	ttls := map[int]int{}
+	sent := make([]time.Time, maxHops+1)
+	logger.Info("Sending probes", "protocol", c.Protocol, "max_hops", maxHops)
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		if err := setTTL(ttl); err != nil {
+			logger.Error("Error setting TTL", "ttl", ttl, "err", err)
+			return false
+		}
+		var key int
+		if udp {
+			key = port + ttl - 1
+			_, err = udpConn.WriteTo(tracerouteData, &net.UDPAddr{IP: dstIPAddr.IP, Port: key, Zone: dstIPAddr.Zone})
+		} else {
+			key = int(getICMPSequence())
+			var b []byte
+			b, err = (&icmp.Message{
+				Type: echoType,
+				Body: &icmp.Echo{ID: icmpID, Seq: key, Data: tracerouteData},
+			}).Marshal(nil)
+			if err == nil {
+				_, err = conn.WriteTo(b, dstIPAddr)
+			}
+		}
+		if err != nil {
+			logger.Error("Error sending probe", "ttl", ttl, "err", err)
+			return false
+		}
+		ttls[key] = ttl
+		sent[ttl] = time.Now()
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		logger.Error("Error setting socket deadline", "err", err)
+		return false
+	}
+	hops := map[int]tracerouteHop{}
+	reachedAt := 0
+	complete := func() bool {
+		if reachedAt == 0 {
+			return false
+		}
+		for ttl := 1; ttl < reachedAt; ttl++ {
+			if _, ok := hops[ttl]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	rb := make([]byte, 1500)
+	for !complete() {
+		n, peer, err := conn.ReadFrom(rb)
+		if err != nil {
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() || errors.Is(err, os.ErrDeadlineExceeded) {
+				logger.Info("Timeout waiting for the replies of the hops", "replies", len(hops))
+				break
+			}
+			logger.Error("Error reading from socket", "err", err)
+			return false
+		}
+		key, final, ok := parseTracerouteReply(rb[:n], v6, udp, dstIPAddr.IP)
+		if !ok {
+			continue
+		}
+		ttl, ok := ttls[key]
+		if !ok {
+			continue
+		}
+		if _, ok := hops[ttl]; ok {
+			continue
+		}
+		hops[ttl] = tracerouteHop{address: peer, rtt: time.Since(sent[ttl])}
+		// Routers may also report the target unreachable.
+		if ip, ok := peer.(*net.IPAddr); ok && final && ip.IP.Equal(dstIPAddr.IP) && (reachedAt == 0 || ttl < reachedAt) {
+			reachedAt = ttl
+		}
+	}
+
+	last := 0
+	for ttl := 1; ttl <= maxHops && (reachedAt == 0 || ttl <= reachedAt); ttl++ {
+		hop, ok := hops[ttl]
+		if !ok {
+			logger.Info("No reply from hop", "hop", ttl)
+			continue
+		}
+		logger.Info("Reply from hop", "hop", ttl, "address", hop.address, "rtt", hop.rtt)
+		hopRTTGaugeVec.WithLabelValues(strconv.Itoa(ttl), hop.address.String()).Set(hop.rtt.Seconds())
+		last = ttl
+	}
+	if last > 0 {
+		registry.MustRegister(hopRTTGaugeVec)
+	}
+	if reachedAt == 0 {
+		hopsGauge.Set(float64(last))
+		logger.Error("Target was not reached", "max_hops", maxHops)
+		return false
+	}
+	hopsGauge.Set(float64(reachedAt))
+	reachedGauge.Set(1)
+	return true
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// quotedIPv4Probe returns the start of a probe to dst, as quoted by ICMP
+// error messages.
+func quotedIPv4Probe(dst net.IP, proto int, payload []byte) []byte {
+	header := make([]byte, ipv4.HeaderLen)
+	header[0] = 0x45
+	header[9] = byte(proto)
+	copy(header[12:16], net.ParseIP("192.0.2.1").To4())
+	copy(header[16:20], dst.To4())
+	return append(header, payload...)
+}
+
+func TestParseTracerouteReply(t *testing.T) {
+	dst := net.ParseIP("198.51.100.7")
+	echo := make([]byte, 8)
+	echo[0] = byte(ipv4.ICMPTypeEcho)
+	binary.BigEndian.PutUint16(echo[4:6], uint16(icmpID))
+	binary.BigEndian.PutUint16(echo[6:8], 42)
+	datagram := make([]byte, 8)
+	binary.BigEndian.PutUint16(datagram[0:2], 50000)
+	binary.BigEndian.PutUint16(datagram[2:4], 33436)
+
+	marshal := func(m icmp.Message) []byte {
+		b, err := m.Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := map[string]struct {
+		msg   []byte
+		udp   bool
+		key   int
+		final bool
+		ok    bool
+	}{
+		"echo reply": {
+			msg:   marshal(icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: icmpID, Seq: 42}}),
+			key:   42,
+			final: true,
+			ok:    true,
+		},
+		"echo reply of another process": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: icmpID + 1, Seq: 42}}),
+		},
+		"time exceeded for echo request": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedIPv4Probe(dst, 1, echo)}}),
+			key: 42,
+			ok:  true,
+		},
+		"time exceeded for another target": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedIPv4Probe(net.ParseIP("198.51.100.8"), 1, echo)}}),
+		},
+		"time exceeded for datagram": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedIPv4Probe(dst, 17, datagram)}}),
+			udp: true,
+			key: 33436,
+			ok:  true,
+		},
+		"port unreachable for datagram": {
+			msg:   marshal(icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 3, Body: &icmp.DstUnreach{Data: quotedIPv4Probe(dst, 17, datagram)}}),
+			udp:   true,
+			key:   33436,
+			final: true,
+			ok:    true,
+		},
+		"time exceeded for echo request in udp mode": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedIPv4Probe(dst, 1, echo)}}),
+			udp: true,
+		},
+		"truncated quote": {
+			msg: marshal(icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedIPv4Probe(dst, 1, nil)}}),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, final, ok := parseTracerouteReply(test.msg, false, test.udp, dst)
+			if ok != test.ok || ok && (key != test.key || final != test.final) {
+				t.Errorf("Expected key %d, final %t and ok %t, got %d, %t and %t", test.key, test.final, test.ok, key, final, ok)
+			}
+		})
+	}
+}
+
+func TestTracerouteLoopback(t *testing.T) {
+	if conn, err := icmp.ListenPacket("ip4:icmp", "127.0.0.1"); err != nil {
+		t.Skipf("Raw sockets are not available: %s", err)
+	} else {
+		conn.Close()
+	}
+
+	for _, protocol := range []string{"icmp", "udp"} {
+		t.Run(protocol, func(t *testing.T) {
+			module := config.Module{Traceroute: config.TracerouteProbe{
+				IPProtocol: "ip4",
+				Protocol:   protocol,
+				MaxHops:    3,
+				Port:       config.DefaultTracerouteProbe.Port,
+			}}
+			testCTX, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			if !ProbeTraceroute(testCTX, "127.0.0.1", module, registry, promslog.NewNopLogger()) {
+				t.Fatal("Traceroute to the loopback address failed")
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(map[string]float64{
+				"probe_traceroute_hops":    1,
+				"probe_traceroute_reached": 1,
+			}, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{
+				"probe_traceroute_hop_rtt_seconds": {"hop": "1", "address": "127.0.0.1"},
+			}, mfs, t)
+		})
+	}
+}
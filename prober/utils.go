@@ -18,7 +18,9 @@ import (
 	"fmt"
 	"hash/fnv"
 	"log/slog"
+	"math"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,6 +52,23 @@ func chooseProtocol(ctx context.Context, IPProtocol string, fallbackIPProtocol b
 	registry.MustRegister(probeDNSLookupTimeSeconds)
 	registry.MustRegister(probeIPAddrHash)
 
+	if nat64Prefix != nil {
+		probeIPNAT64Gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ip_nat64",
+			Help: "Indicates if the IPv4 address of the target is reached through NAT64",
+		})
+		registry.MustRegister(probeIPNAT64Gauge)
+		defer func() {
+			if ip == nil || ip.IP.To4() == nil {
+				return
+			}
+			ip = &net.IPAddr{IP: synthesizeNAT64(nat64Prefix, ip.IP)}
+			logger.Info("Reaching the IPv4 address of the target through NAT64", "target", target, "ip", ip.String())
+			probeIPNAT64Gauge.Set(1)
+			probeIPProtocolGauge.Set(6)
+		}()
+	}
+
 	if IPProtocol == "ip6" || IPProtocol == "" {
 		IPProtocol = "ip6"
 		fallbackProtocol = "ip4"
@@ -69,6 +88,10 @@ func chooseProtocol(ctx context.Context, IPProtocol string, fallbackIPProtocol b
 	resolver := &net.Resolver{}
 	if !fallbackIPProtocol {
 		ips, err := resolver.LookupIP(ctx, IPProtocol, target)
+		if err != nil && IPProtocol == "ip6" && nat64Prefix != nil {
+			// Targets without IPv6 addresses are reached through NAT64.
+			ips, err = resolver.LookupIP(ctx, "ip4", target)
+		}
 		if err == nil {
 			for _, ip := range ips {
 				logger.Info("Resolved target address", "target", target, "ip", ip.String())
@@ -140,3 +163,69 @@ func ipHash(ip net.IP) float64 {
 	}
 	return float64(h.Sum32())
 }
+
+// stringHash hashes a string the way ipHash hashes addresses, so that changes
+// of values such as names can be detected without a series per value.
+func stringHash(s string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return float64(h.Sum32())
+}
+
+// dialTCPIP connects to a port of an IP address, from the source address if
+// set, with the deadline of the context.
+func dialTCPIP(ctx context.Context, ip *net.IPAddr, port, sourceIPAddress string, logger *slog.Logger) (net.Conn, error) {
+	dialProtocol := "tcp4"
+	if ip.IP.To4() == nil {
+		dialProtocol = "tcp6"
+	}
+	dialer := &net.Dialer{}
+	if len(sourceIPAddress) > 0 {
+		srcIP := net.ParseIP(sourceIPAddress)
+		if srcIP == nil {
+			return nil, fmt.Errorf("error parsing source ip address %q", sourceIPAddress)
+		}
+		logger.Info("Using local address", "srcIP", srcIP)
+		dialer.LocalAddr = &net.TCPAddr{IP: srcIP}
+	}
+	conn, err := dialer.DialContext(ctx, dialProtocol, net.JoinHostPort(ip.String(), port))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// stddev returns the population standard deviation of values.
+func stddev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares / float64(len(values)))
+}
+
+// percentile returns the q-th percentile of values with the nearest-rank
+// method, which with few values is an estimate erring on the high side.
+func percentile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(q * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -300,3 +300,19 @@ func checkAbsentMetrics(absent []string, mfs []*dto.MetricFamily, t *testing.T)
 		}
 	}
 }
+
+func TestStatistics(t *testing.T) {
+	values := []float64{0.3, 0.1, 0.2, 0.4}
+	if got := stddev(values); got < 0.1118 || got > 0.1119 {
+		t.Errorf("Expected a standard deviation of 0.1118, got %g", got)
+	}
+	if got := percentile(values, 0.95); got != 0.4 {
+		t.Errorf("Expected 0.4 as 95th percentile, got %g", got)
+	}
+	if got := percentile(values, 0.5); got != 0.2 {
+		t.Errorf("Expected 0.2 as median, got %g", got)
+	}
+	if values[0] != 0.3 {
+		t.Error("Expected the values to be left unsorted")
+	}
+}
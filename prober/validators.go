@@ -0,0 +1,49 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// validatorResults exports the results of the named validators of a probe.
+// The metric is only registered once a named validator has been evaluated.
+type validatorResults struct {
+	registry *prometheus.Registry
+	gaugeVec *prometheus.GaugeVec
+}
+
+func newValidatorResults(registry *prometheus.Registry) *validatorResults {
+	return &validatorResults{registry: registry}
+}
+
+// record sets the result of the validator with the given name. Unnamed
+// validators are not exported.
+func (v *validatorResults) record(name string, success bool) {
+	if v == nil || name == "" {
+		return
+	}
+	if v.gaugeVec == nil {
+		v.gaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_validator_success",
+			Help: "Displays whether or not the named validator succeeded",
+		}, []string{"name"})
+		v.registry.MustRegister(v.gaugeVec)
+	}
+	if success {
+		v.gaugeVec.WithLabelValues(name).Set(1)
+	} else {
+		v.gaugeVec.WithLabelValues(name).Set(0)
+	}
+}
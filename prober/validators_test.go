@@ -0,0 +1,153 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// validatorSuccess returns the values of probe_validator_success by name.
+func validatorSuccess(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]float64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "probe_validator_success" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" {
+					results[l.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return results
+}
+
+func TestHTTPNamedValidators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html>Down for maintenance</html>")
+	}))
+	defer ts.Close()
+
+	module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+		IPProtocolFallback: true,
+		FailIfBodyMatchesRegexp: []config.Regexp{
+			config.MustNewRegexp("maintenance").WithName("not_in_maintenance"),
+			config.MustNewRegexp("Internal Server Error"),
+		},
+		FailIfBodyNotMatchesRegexp: []config.Regexp{
+			config.MustNewRegexp("<html>").WithName("is_html"),
+		},
+		FailIfHeaderNotMatchesRegexp: []config.HeaderMatch{
+			{Header: "Content-Type", Regexp: config.MustNewRegexp("text/html"), Name: "html_content_type"},
+		},
+	}}
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected the probe to fail on the body in maintenance")
+	}
+
+	expected := map[string]float64{
+		"not_in_maintenance": 0,
+		"is_html":            1,
+		"html_content_type":  1,
+	}
+	results := validatorSuccess(t, registry)
+	if len(results) != len(expected) {
+		t.Fatalf("Expected results for %d validators, got %v", len(expected), results)
+	}
+	for name, value := range expected {
+		if v, ok := results[name]; !ok || v != value {
+			t.Errorf("Expected probe_validator_success{name=%q} to be %v, got %v", name, value, results[name])
+		}
+	}
+}
+
+func TestHTTPWithoutNamedValidators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	module := config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+		IPProtocolFallback:         true,
+		FailIfBodyNotMatchesRegexp: []config.Regexp{config.MustNewRegexp("ok")},
+	}}
+
+	registry := prometheus.NewRegistry()
+	testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !ProbeHTTP(testCTX, ts.URL, module, registry, promslog.NewNopLogger()) {
+		t.Fatal("Expected the probe to succeed")
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAbsentMetrics([]string{"probe_validator_success"}, mfs, t)
+}
+
+func TestDNSNamedValidators(t *testing.T) {
+	a, err := dns.NewRR("example.com. 300 IN A 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := &dns.Msg{Answer: []dns.RR{a}}
+	module := config.Module{DNS: config.DNSProbe{
+		ValidateAnswer: config.DNSRRValidator{
+			Name:                   "answer_is_documentation_ip",
+			FailIfNotMatchesRegexp: []string{`IN\s+A\s+192\.0\.2\.`},
+		},
+		ValidateAuthority: config.DNSRRValidator{
+			Name:                    "has_authority",
+			FailIfNoneMatchesRegexp: []string{"SOA"},
+		},
+	}}
+
+	registry := prometheus.NewRegistry()
+	if validDNSResponse(response, module, newValidatorResults(registry), promslog.NewNopLogger()) {
+		t.Fatal("Expected the response to be invalid without authority RRs")
+	}
+
+	results := validatorSuccess(t, registry)
+	if v, ok := results["answer_is_documentation_ip"]; !ok || v != 1 {
+		t.Errorf("Expected the answer validator to succeed, got %v", results)
+	}
+	if v, ok := results["has_authority"]; !ok || v != 0 {
+		t.Errorf("Expected the authority validator to fail, got %v", results)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected results for 2 validators, got %v", results)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+// checkWAFCanary returns true if the request designed to be blocked was,
+// with one of the expected status codes and, if layers are configured, by one
+// of them. A canary that reaches the origin means the protection is down.
+func checkWAFCanary(resp *http.Response, c config.WAFCanary, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		blockedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_waf_canary_blocked",
+			Help: "Indicates if the canary request was blocked with an expected status code",
+		})
+		layerInfoGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_waf_canary_layer_info",
+			Help: "Contains the protection layer that blocked the canary request",
+		}, []string{"layer"})
+	)
+	registry.MustRegister(blockedGauge)
+
+	if !slices.Contains(c.ExpectedStatusCodes, resp.StatusCode) {
+		logger.Error("Canary request was not blocked", "status_code", resp.StatusCode,
+			"expected_status_codes", fmt.Sprintf("%v", c.ExpectedStatusCodes))
+		return false
+	}
+	blockedGauge.Set(1)
+
+	if len(c.Layers) == 0 {
+		return true
+	}
+	registry.MustRegister(layerInfoGaugeVec)
+	for _, layer := range c.Layers {
+		for _, value := range resp.Header.Values(layer.Header) {
+			if layer.Regexp.MatchString(value) {
+				logger.Info("Canary request was blocked", "status_code", resp.StatusCode, "layer", layer.Name)
+				layerInfoGaugeVec.WithLabelValues(layer.Name).Set(1)
+				return true
+			}
+		}
+	}
+	// The origin itself may answer with the same status codes.
+	logger.Error("Canary request was blocked by an unknown layer", "status_code", resp.StatusCode)
+	layerInfoGaugeVec.WithLabelValues("unknown").Set(1)
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+func TestWAFCanary(t *testing.T) {
+	// The WAF blocks requests whose query contains a script tag, the origin
+	// rejects requests without a session with 403 as well.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("q"), "<script>") {
+			w.Header().Set("Server", "waf")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/private" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	payload := map[string]string{"q": "<script>alert(1)</script>"}
+	layers := []config.WAFLayer{{Name: "waf", Header: "Server", Regexp: config.MustNewRegexp("^waf$")}}
+	expected := []int{http.StatusForbidden, http.StatusTooManyRequests}
+
+	testcases := map[string]struct {
+		path           string
+		canary         config.WAFCanary
+		expectedResult bool
+		expectedLayer  string
+	}{
+		"blocked": {
+			canary:         config.WAFCanary{Enabled: true, QueryParams: payload, ExpectedStatusCodes: expected},
+			expectedResult: true,
+		},
+		"blocked by layer": {
+			canary:         config.WAFCanary{Enabled: true, QueryParams: payload, ExpectedStatusCodes: expected, Layers: layers},
+			expectedResult: true,
+			expectedLayer:  "waf",
+		},
+		"not blocked": {
+			canary: config.WAFCanary{Enabled: true, QueryParams: map[string]string{"q": "hello"}, ExpectedStatusCodes: expected},
+		},
+		"blocked by origin": {
+			path:          "/private",
+			canary:        config.WAFCanary{Enabled: true, QueryParams: map[string]string{"q": "hello"}, ExpectedStatusCodes: expected, Layers: layers},
+			expectedLayer: "unknown",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			testCTX, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			result := ProbeHTTP(testCTX, ts.URL+tc.path, config.Module{Timeout: time.Second, HTTP: config.HTTPProbe{
+				IPProtocolFallback: true,
+				WAFCanary:          tc.canary,
+			}}, registry, promslog.NewNopLogger())
+			if result != tc.expectedResult {
+				t.Fatalf("Expected result %t, got %t", tc.expectedResult, result)
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocked := 0.0
+			if tc.expectedResult || tc.expectedLayer != "" {
+				blocked = 1
+			}
+			checkRegistryResults(map[string]float64{"probe_waf_canary_blocked": blocked}, mfs, t)
+			if tc.expectedLayer == "" {
+				checkAbsentMetrics([]string{"probe_waf_canary_layer_info"}, mfs, t)
+				return
+			}
+			checkRegistryLabels(map[string]map[string]string{"probe_waf_canary_layer_info": {"layer": tc.expectedLayer}}, mfs, t)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const (
+	zookeeperDefaultPort = "2181"
+	// zookeeperMaxResponseSize bounds the answers to four letter words.
+	zookeeperMaxResponseSize = 1 << 16
+)
+
+// errZooKeeperNotServing is the answer of servers that are not part of a
+// quorum.
+var errZooKeeperNotServing = errors.New("server is not currently serving requests")
+
+// zookeeperCommand sends a four letter word on a new connection and returns
+// the answer, which ends when the server closes the connection.
+func zookeeperCommand(ctx context.Context, ip *net.IPAddr, port, sourceIPAddress, command string, logger *slog.Logger) (string, error) {
+	conn, err := dialTCPIP(ctx, ip, port, sourceIPAddress, logger)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return "", err
+	}
+	answer, err := io.ReadAll(io.LimitReader(conn, zookeeperMaxResponseSize))
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(string(answer), "not in the whitelist") {
+		return "", fmt.Errorf("%s is not in the 4lw.commands.whitelist of the server", command)
+	}
+	if strings.HasPrefix(string(answer), "This ZooKeeper instance is not currently serving requests") {
+		return "", errZooKeeperNotServing
+	}
+	return string(answer), nil
+}
+
+// parseZooKeeperStats returns the "Name: value" lines of the answer to srvr.
+func parseZooKeeperStats(answer string) map[string]string {
+	stats := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(answer))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			stats[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return stats
+}
+
+// ProbeZooKeeper checks that a ZooKeeper server answers ruok and is serving
+// requests, which it only does as a member of a quorum, and exports its mode
+// and the request latency it reports.
+func ProbeZooKeeper(ctx context.Context, target string, module config.Module, registry *prometheus.Registry, logger *slog.Logger) bool {
+	var (
+		durationGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_duration_seconds",
+			Help: "Duration of the ZooKeeper commands",
+		}, []string{"phase"})
+		modeGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_mode_info",
+			Help: "Contains the mode of the server: leader, follower, observer or standalone",
+		}, []string{"mode"})
+		isLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_is_leader",
+			Help: "Indicates if the server is the leader of the ensemble",
+		})
+		latencyGaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_server_latency_seconds",
+			Help: "The minimum, average and maximum request latency reported by the server",
+		}, []string{"stat"})
+		outstandingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_outstanding_requests",
+			Help: "The number of requests queued by the server",
+		})
+		znodesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_zookeeper_znodes",
+			Help: "The number of znodes of the data tree",
+		})
+	)
+	registry.MustRegister(durationGaugeVec)
+
+	c := module.ZooKeeper
+	targetAddress, port, err := net.SplitHostPort(target)
+	if err != nil {
+		targetAddress, port = target, zookeeperDefaultPort
+	}
+	ip, _, err := chooseProtocol(ctx, c.IPProtocol, c.IPProtocolFallback, targetAddress, registry, logger)
+	if err != nil {
+		logger.Error("Error resolving address", "err", err)
+		return false
+	}
+
+	start := time.Now()
+	answer, err := zookeeperCommand(ctx, ip, port, c.SourceIPAddress, "ruok", logger)
+	durationGaugeVec.WithLabelValues("ruok").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error sending ruok", "err", err)
+		return false
+	}
+	if answer != "imok" {
+		logger.Error("Unexpected answer to ruok", "answer", answer)
+		return false
+	}
+
+	start = time.Now()
+	answer, err = zookeeperCommand(ctx, ip, port, c.SourceIPAddress, "srvr", logger)
+	durationGaugeVec.WithLabelValues("srvr").Set(time.Since(start).Seconds())
+	if err != nil {
+		logger.Error("Error sending srvr", "err", err)
+		return false
+	}
+	stats := parseZooKeeperStats(answer)
+	mode := stats["Mode"]
+	if mode == "" {
+		logger.Error("Unexpected answer to srvr", "answer", answer)
+		return false
+	}
+	logger.Info("Got server stats", "mode", mode, "zxid", stats["Zxid"])
+	registry.MustRegister(modeGaugeVec, isLeaderGauge)
+	modeGaugeVec.WithLabelValues(mode).Set(1)
+	if mode == "leader" {
+		isLeaderGauge.Set(1)
+	}
+
+	// The latency is in milliseconds, with decimals since ZooKeeper 3.5.
+	if latency := strings.Split(stats["Latency min/avg/max"], "/"); len(latency) == 3 {
+		registry.MustRegister(latencyGaugeVec)
+		for i, stat := range []string{"min", "avg", "max"} {
+			if ms, err := strconv.ParseFloat(latency[i], 64); err == nil {
+				latencyGaugeVec.WithLabelValues(stat).Set(ms / 1000)
+			}
+		}
+	}
+	if outstanding, err := strconv.Atoi(stats["Outstanding"]); err == nil {
+		registry.MustRegister(outstandingGauge)
+		outstandingGauge.Set(float64(outstanding))
+	}
+	if znodes, err := strconv.Atoi(stats["Node count"]); err == nil {
+		registry.MustRegister(znodesGauge)
+		znodesGauge.Set(float64(znodes))
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prober
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/blackbox_exporter/config"
+)
+
+const zookeeperTestStats = `Zookeeper version: 3.8.4-9316c2a7a97e1666d8f4593f34dd6fc36ecc436c, built on 2024-02-12 22:16 UTC
+Latency min/avg/max: 0/1.25/40
+Received: 1042
+Sent: 1041
+Connections: 3
+Outstanding: 2
+Zxid: 0x500000012
+Mode: leader
+Node count: 152
+Proposal sizes last/min/max: 48/32/612
+`
+
+// startZooKeeperTestServer answers four letter words with the answers.
+func startZooKeeperTestServer(t *testing.T, answers map[string]string) string {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening on socket: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.SetDeadline(time.Now().Add(time.Second))
+			command := make([]byte, 4)
+			if _, err := conn.Read(command); err == nil {
+				conn.Write([]byte(answers[string(command)]))
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestZooKeeper(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  map[string]string
+		success  bool
+		expected map[string]float64
+	}{
+		{
+			name:    "leader",
+			answers: map[string]string{"ruok": "imok", "srvr": zookeeperTestStats},
+			success: true,
+			expected: map[string]float64{
+				"probe_zookeeper_is_leader":            1,
+				"probe_zookeeper_outstanding_requests": 2,
+				"probe_zookeeper_znodes":               152,
+			},
+		},
+		{
+			name:    "no quorum",
+			answers: map[string]string{"ruok": "imok", "srvr": "This ZooKeeper instance is not currently serving requests\n"},
+		},
+		{
+			name:    "not whitelisted",
+			answers: map[string]string{"ruok": "ruok is not executed because it is not in the whitelist.\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startZooKeeperTestServer(t, test.answers)
+			testCTX, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			registry := prometheus.NewRegistry()
+			module := config.Module{ZooKeeper: config.ZooKeeperProbe{IPProtocol: "ip4"}}
+			if success := ProbeZooKeeper(testCTX, addr, module, registry, promslog.NewNopLogger()); success != test.success {
+				t.Fatalf("Expected success %v, got %v", test.success, success)
+			}
+			if !test.success {
+				return
+			}
+			mfs, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRegistryResults(test.expected, mfs, t)
+			checkRegistryLabels(map[string]map[string]string{"probe_zookeeper_mode_info": {"mode": "leader"}}, mfs, t)
+			for _, mf := range mfs {
+				if mf.GetName() != "probe_zookeeper_server_latency_seconds" {
+					continue
+				}
+				for _, m := range mf.GetMetric() {
+					if m.GetLabel()[0].GetValue() == "max" && m.GetGauge().GetValue() != 0.04 {
+						t.Errorf("Expected a maximum latency of 0.04s, got %v", m.GetGauge().GetValue())
+					}
+				}
+			}
+		})
+	}
+}